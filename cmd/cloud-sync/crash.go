@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/crash"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// crashExitCode is the exit code of a crash, the same as an unrecovered
+// panic's
+const crashExitCode = 2
+
+// configDir returns the directory of config.json, or the temporary
+// directory when the home directory is unknown
+func configDir() string {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "cloud-sync")
+	}
+	return filepath.Dir(configManager.GetConfigPath())
+}
+
+// newCrashReporter creates the reporter of this run. Reports go to the
+// crash directory next to config.json.
+func newCrashReporter() *crash.Reporter {
+	return crash.NewReporter(filepath.Join(configDir(), "crash"), Version, configSnapshot)
+}
+
+// configSnapshot returns the configuration and sync pairs for a crash
+// report, without the remotes' keys
+func configSnapshot() (any, error) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	snapshot := map[string]any{"config": appConfig.WithoutCredentials()}
+	if pairs := syncconfig.NewManager(configManager.SyncConfigPath()); pairs.ConfigExists() {
+		if syncConfig, err := pairs.Load(); err == nil {
+			snapshot["sync_config"] = syncConfig
+		} else {
+			snapshot["sync_config"] = err.Error()
+		}
+	}
+	return snapshot, nil
+}
+
+// reportPanic, deferred by main, writes a crash report for a panic outside
+// the interface, e.g. in a command, tells where it is and exits
+func reportPanic(reporter *crash.Reporter) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	reporter.Report(r, debug.Stack())
+	shutdown()
+	fmt.Fprintln(os.Stderr, reporter.Message())
+	os.Exit(crashExitCode)
+}
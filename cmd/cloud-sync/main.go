@@ -3,8 +3,12 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/debuglog"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
 	"github.com/andreisuslov/cloud-sync/internal/ui"
 )
 
@@ -14,13 +18,94 @@ var (
 )
 
 func main() {
+	// A panic writes a crash report instead of only a stack trace
+	reporter := newCrashReporter()
+	defer reportPanic(reporter)
+
+	// --simulate records brew, rclone and launchctl commands instead of
+	// running them, wherever it appears
+	args, simulating := simulate.TakeFlag(os.Args[1:])
+	if simulating {
+		simulate.Enable()
+	}
+
+	// --read-only disables actions in the interactive interface, so it is
+	// refused where it would not stop anything from running
+	args, readOnly := ui.TakeReadOnlyFlag(args)
+	if readOnly && (cli.IsCommand(args) || cli.WantsPlain(args)) {
+		fmt.Fprintf(os.Stderr, "Error: %s only applies to the interactive interface\n", ui.ReadOnlyFlag)
+		os.Exit(cli.ExitConfig)
+	}
+
+	// --quiet leaves a command only its exit code, for scripts; Run takes
+	// it out of args
+	_, quiet := cli.TakeQuietFlag(args)
+	if quiet && !cli.IsCommand(args) {
+		fmt.Fprintf(os.Stderr, "Error: %s only applies to commands\n", cli.QuietFlag)
+		os.Exit(cli.ExitConfig)
+	}
+
+	// Subcommands run without the interactive interface
+	if cli.IsCommand(args) {
+		handleSignals(exitOnSignal)
+		code := cli.Run(args, os.Stdout, os.Stderr)
+		if simulating && !quiet {
+			reportSimulation(os.Stdout)
+		}
+		os.Exit(code)
+	}
+
+	// Screen readers and dumb terminals get a line-oriented menu
+	if cli.WantsPlain(args) {
+		handleSignals(exitOnSignal)
+		code := cli.RunPlain(os.Stdin, os.Stdout, os.Stderr)
+		if simulating {
+			reportSimulation(os.Stdout)
+		}
+		os.Exit(code)
+	}
+
+	// --debug and -v turn on the debug log, which ctrl+g also toggles
+	_, debugLevel := ui.TakeDebugFlag(args)
+	debugLog := debuglog.New(filepath.Join(configDir(), debuglog.FileName), debugLevel)
+	defer debugLog.Close()
+
+	// Read-only mode also covers restoring a backup of the config file
+	readOnly = ui.ApplyReadOnly(readOnly)
+
+	// A config file broken by a crash or hand edit can be put back first
+	if !readOnly {
+		offerRestore()
+	}
+
+	if err := ui.ApplyTheme(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: using the default theme: %v\n", err)
+	}
+
 	// Initialize the Bubbletea program
-	// Note: Not using tea.WithAltScreen() to allow text selection/copying from terminal
-	// Not using tea.WithMouseCellMotion() to allow normal terminal mouse behavior
-	p := tea.NewProgram(ui.NewModel())
+	// Note: The alt screen and mouse are only enabled when "ui.mouse" is set,
+	// so text can be selected and copied from the terminal by default
+	p := tea.NewProgram(ui.Guard(ui.NewDebugModel(ui.NewModel(), debugLog), reporter), append(ui.ProgramOptions(), tea.WithoutSignalHandler())...)
+
+	// Quitting restores the terminal, including the alt screen
+	handleSignals(func(os.Signal, int) { p.Quit() })
 
 	// Run the program
-	if _, err := p.Run(); err != nil {
+	_, err := p.Run()
+
+	// Syncs started from the interface must not outlive it
+	shutdown()
+
+	if simulating {
+		reportSimulation(os.Stdout)
+	}
+
+	if reporter.Crashed() {
+		fmt.Fprintln(os.Stderr, reporter.Message())
+		os.Exit(crashExitCode)
+	}
+
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running application: %v\n", err)
 		os.Exit(1)
 	}
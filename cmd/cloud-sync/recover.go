@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+)
+
+// offerRestore asks, before the interface starts, whether to restore the
+// backup of a configuration file that cannot be read. Files without a
+// backup are left for the interface to report.
+func offerRestore() {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return
+	}
+	configManager, err := config.NewManager()
+	if err != nil {
+		return
+	}
+
+	input := bufio.NewReader(os.Stdin)
+	for _, corrupt := range configManager.CorruptFiles() {
+		if !corrupt.HasBackup() {
+			continue
+		}
+
+		name := filepath.Base(corrupt.Path)
+		fmt.Printf("%s cannot be read: %v\n", name, corrupt.Err)
+		fmt.Printf("Restore the backup from %s? [Y/n] ", corrupt.Backup.Format("2006-01-02 15:04"))
+		answer, _ := input.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+		default:
+			continue
+		}
+
+		if err := jsonfile.Restore(corrupt.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+		fmt.Printf("Restored %s; the damaged file was kept as %s\n", name, filepath.Base(jsonfile.CorruptPath(corrupt.Path)))
+	}
+}
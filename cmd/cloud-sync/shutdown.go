@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// shutdownTimeout is how long a command whose transfer was cancelled gets to
+// log it and return before the process exits anyway
+const shutdownTimeout = 2 * time.Second
+
+// handleSignals runs onSignal after shutting down when the process is
+// interrupted, killed or loses its terminal. stopped is how many rclone
+// processes were cancelled.
+func handleSignals(onSignal func(sig os.Signal, stopped int)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		sig := <-signals
+		onSignal(sig, shutdown())
+	}()
+}
+
+// exitOnSignal is the signal handler for commands: it exits once a cancelled
+// sync has had time to record the cancellation
+func exitOnSignal(sig os.Signal, stopped int) {
+	if stopped > 0 {
+		time.Sleep(shutdownTimeout)
+	}
+	os.Exit(signalExitCode(sig))
+}
+
+// shutdown stops the rclone processes cloud-sync started and removes the
+// lockfile they leave behind. It returns how many processes were stopped.
+func shutdown() int {
+	stopped := rclone.DefaultRuns.Shutdown(rclone.CancelGrace)
+	if stopped == 0 {
+		return 0
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return stopped
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return stopped
+	}
+	backup.CleanupLockfile(appConfig.LogDir, appConfig.LaunchAgent.RCAddress())
+
+	return stopped
+}
+
+// signalExitCode is the shell convention for a process ended by sig
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+)
+
+// reportSimulation lists the commands a --simulate run skipped
+func reportSimulation(w io.Writer) {
+	actions := simulate.Actions()
+	if len(actions) == 0 {
+		fmt.Fprintln(w, "Simulation: no brew, rclone or launchctl commands would have run.")
+		return
+	}
+
+	fmt.Fprintln(w, "Simulation: these commands would have run:")
+	for _, action := range actions {
+		fmt.Fprintf(w, "  %s\n", action)
+	}
+}
@@ -96,7 +96,10 @@ func main() {
 	// Uncomment to actually sync all folders
 	/*
 	fmt.Println("\n=== Syncing All Enabled Folders ===")
-	err = manager.SyncAllEnabled(true, false) // progress=true, dryRun=false
+	results, err := manager.SyncAllEnabled(true, false) // progress=true, dryRun=false
+	for _, result := range results {
+		fmt.Printf("%s: %v in %s\n", result.Pair, result.Err, result.Duration)
+	}
 	if err != nil {
 		log.Fatalf("Sync failed: %v", err)
 	}
@@ -0,0 +1,242 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+)
+
+// ProgressFunc receives how many bytes of the folder's files have been
+// archived so far, out of total
+type ProgressFunc func(done, total int64)
+
+// Result describes a finished archive
+type Result struct {
+	Files int
+	Bytes int64 // Size of the archived files
+	Size  int64 // Size of the compressed archive
+}
+
+// homebrewBins are where Homebrew installs zstd, which is not in the PATH
+// of scheduled runs
+var homebrewBins = []string{"/opt/homebrew/bin", "/usr/local/bin"}
+
+// FindZstd returns the zstd binary in PATH or Homebrew's bin folder
+func FindZstd() (string, error) {
+	if path, err := exec.LookPath("zstd"); err == nil {
+		return path, nil
+	}
+	for _, dir := range homebrewBins {
+		path := filepath.Join(dir, "zstd")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("zstd not found in PATH")
+}
+
+// Compressor writes folders to zstd-compressed tarballs
+type Compressor struct {
+	zstdPath string
+}
+
+// NewCompressor creates a compressor running the zstd binary at zstdPath,
+// or the one found via PATH if it is empty
+func NewCompressor(zstdPath string) *Compressor {
+	if zstdPath == "" {
+		zstdPath = "zstd"
+	}
+	return &Compressor{zstdPath: zstdPath}
+}
+
+// entry is a file or folder to archive
+type entry struct {
+	path string
+	name string // Slash-separated path inside the archive
+	info fs.FileInfo
+}
+
+// Create archives source into dest. The tarball is written here and piped
+// through zstd, so onProgress hears about every file as it is read. The
+// archive is built next to dest and only moved there once complete.
+func (c *Compressor) Create(source, dest string, onProgress ProgressFunc) (*Result, error) {
+	entries, total, err := scan(source, filepath.Dir(dest))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	partial := dest + ".partial"
+	out, err := os.Create(partial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(partial)
+	defer out.Close()
+
+	cmd := simulate.Command(c.zstdPath, "-q", "-T0", "-c")
+	var stderr bytes.Buffer
+	cmd.Stdout = out
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start zstd: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start zstd: %w", err)
+	}
+
+	result := &Result{}
+	files, writeErr := writeTar(stdin, entries, func(n int64) {
+		result.Bytes += n
+		if onProgress != nil {
+			onProgress(result.Bytes, total)
+		}
+	})
+	result.Files = files
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("zstd failed: %w (output: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat archive: %w", err)
+	}
+	result.Size = info.Size()
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := os.Rename(partial, dest); err != nil {
+		return nil, fmt.Errorf("failed to move archive into place: %w", err)
+	}
+	return result, nil
+}
+
+// scan lists the folders, files and symlinks under source in walk order,
+// and adds up the size of the files. The staging folder is left out in case
+// it is inside source, e.g. when a pair archives the home folder.
+func scan(source, staging string) ([]entry, int64, error) {
+	var entries []entry
+	var total int64
+	err := filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		if p == source {
+			return nil
+		}
+		if d.IsDir() && p == staging {
+			return filepath.SkipDir
+		}
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		// Sockets, pipes and devices have no content worth keeping
+		if !info.Mode().IsRegular() && !info.IsDir() && info.Mode()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		rel, _ := filepath.Rel(source, p)
+		entries = append(entries, entry{path: p, name: filepath.ToSlash(rel), info: info})
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// writeTar writes entries as a tar stream to w, calling onRead with the
+// number of bytes of each chunk of file content it copies. It returns how
+// many files it archived.
+func writeTar(w io.Writer, entries []entry, onRead func(n int64)) (int, error) {
+	files := 0
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		link := ""
+		if e.info.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(e.path)
+			if err != nil {
+				return files, fmt.Errorf("failed to read link %s: %w", e.path, err)
+			}
+			link = target
+		}
+		header, err := tar.FileInfoHeader(e.info, link)
+		if err != nil {
+			return files, fmt.Errorf("failed to archive %s: %w", e.path, err)
+		}
+		header.Name = e.name
+		if e.info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return files, fmt.Errorf("failed to write archive: %w", err)
+		}
+		if !e.info.Mode().IsRegular() {
+			continue
+		}
+		if err := copyFile(tw, e, onRead); err != nil {
+			return files, err
+		}
+		files++
+	}
+	if err := tw.Close(); err != nil {
+		return files, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return files, nil
+}
+
+// copyFile copies a file's content into the tar stream. The header holds
+// the size seen by scan, so a file that changed size since is an error
+// rather than an entry cut or padded to it.
+func copyFile(tw *tar.Writer, e entry, onRead func(n int64)) error {
+	f, err := os.Open(e.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", e.path, err)
+	}
+	defer f.Close()
+
+	src := io.LimitReader(f, e.info.Size())
+	buf := make([]byte, 1<<20)
+	var copied int64
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := tw.Write(buf[:n]); werr != nil {
+				return fmt.Errorf("failed to write archive: %w", werr)
+			}
+			copied += int64(n)
+			onRead(int64(n))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", e.path, err)
+		}
+	}
+	if copied < e.info.Size() {
+		return fmt.Errorf("%s shrank from %d to %d bytes while it was archived", e.path, e.info.Size(), copied)
+	}
+	if n, _ := f.Read(buf[:1]); n > 0 {
+		return fmt.Errorf("%s grew past %d bytes while it was archived", e.path, e.info.Size())
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Package b2 asks Backblaze B2 what an application key is allowed to do, so
+// a restricted key is caught when it is entered instead of when a sync
+// fails.
+package b2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+)
+
+// AuthorizeURL is B2's b2_authorize_account call
+var AuthorizeURL = "https://api.backblazeb2.com/b2api/v3/b2_authorize_account"
+
+// timeout bounds the authorize call
+const timeout = 10 * time.Second
+
+// capabilityEffects are the capabilities syncs need, with what fails
+// without each
+var capabilityEffects = map[string]string{
+	"listBuckets": "the bucket cannot be looked up by name, so every sync fails; pick a bucket the key is restricted to",
+	"listFiles":   "the bucket's files cannot be listed, so syncs cannot compare it with the source and fail",
+	"readFiles":   "files cannot be downloaded, so restores, checks and downloads fail",
+	"writeFiles":  "files cannot be uploaded, so nothing is backed up",
+	"deleteFiles": "files deleted at the source stay in the bucket, and unfinished large uploads cannot be cleaned up",
+}
+
+// Key describes what an application key may do
+type Key struct {
+	Capabilities []string
+	Buckets      []string // Buckets the key is restricted to, empty for all
+	NamePrefix   string   // File name prefix the key is restricted to, "" for all
+}
+
+// authorizeResponse is the part of b2_authorize_account's response that
+// describes the key
+type authorizeResponse struct {
+	APIInfo struct {
+		StorageAPI struct {
+			Allowed struct {
+				Capabilities []string `json:"capabilities"`
+				Buckets      []struct {
+					Name *string `json:"name"`
+				} `json:"buckets"`
+				NamePrefix *string `json:"namePrefix"`
+			} `json:"allowed"`
+		} `json:"storageApi"`
+	} `json:"apiInfo"`
+}
+
+// Authorize logs in with an application key and returns what it may do.
+// Rejected keys are errors of kind errkind.ErrRemoteAuth, and unreachable
+// servers of kind errkind.ErrNetwork.
+func Authorize(keyID, applicationKey string) (*Key, error) {
+	req, err := http.NewRequest(http.MethodGet, AuthorizeURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the B2 authorize request: %w", err)
+	}
+	req.SetBasicAuth(keyID, applicationKey)
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return nil, errkind.Wrap(errkind.ErrNetwork, fmt.Errorf("failed to reach B2: %w", err))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, errkind.Wrap(errkind.ErrRemoteAuth, fmt.Errorf("B2 rejected the key ID or application key"))
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("B2 authorize returned %s", resp.Status)
+	}
+
+	var body authorizeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode the B2 authorize response: %w", err)
+	}
+	allowed := body.APIInfo.StorageAPI.Allowed
+	key := &Key{Capabilities: allowed.Capabilities}
+	for _, bucket := range allowed.Buckets {
+		// Buckets deleted since the key was made have no name
+		if bucket.Name != nil {
+			key.Buckets = append(key.Buckets, *bucket.Name)
+		}
+	}
+	if allowed.NamePrefix != nil {
+		key.NamePrefix = *allowed.NamePrefix
+	}
+	return key, nil
+}
+
+// Missing returns the capabilities syncs need that the key lacks, sorted.
+// A key restricted to buckets does not need listBuckets, as rclone is told
+// its bucket when it logs in.
+func (k Key) Missing() []string {
+	has := map[string]bool{}
+	for _, capability := range k.Capabilities {
+		has[capability] = true
+	}
+	var missing []string
+	for capability := range capabilityEffects {
+		if has[capability] || (capability == "listBuckets" && len(k.Buckets) > 0) {
+			continue
+		}
+		missing = append(missing, capability)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Warnings say what will fail with the key, one line per missing
+// capability and restriction
+func (k Key) Warnings() []string {
+	var warnings []string
+	for _, capability := range k.Missing() {
+		warnings = append(warnings, fmt.Sprintf("No %s: %s.", capability, capabilityEffects[capability]))
+	}
+	if len(k.Buckets) > 0 {
+		warnings = append(warnings, fmt.Sprintf("The key only opens bucket %s; syncs to other buckets fail.", strings.Join(k.Buckets, ", ")))
+	}
+	if k.NamePrefix != "" {
+		warnings = append(warnings, fmt.Sprintf("The key only opens files under '%s'; syncs must stay inside it.", k.NamePrefix))
+	}
+	return warnings
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+)
+
+// command is a non-interactive subcommand
+type command struct {
+	name        string
+	summary     string
+	run         func(args []string, stdout, stderr io.Writer) int
+	subcommands []string // Subcommands with flags of their own; "" is the command itself
+}
+
+// commands lists the subcommands in the order shown by usage
+var commands = []command{
+	{name: "status", summary: "Show pairs, the last and next run, and warnings", run: runStatus},
+	{name: "pairs", summary: "List the configured sync pairs, or export and import them as YAML", run: runPairs,
+		subcommands: []string{"", "export", "import"}},
+	{name: "sync", summary: "Sync one or more pairs, all enabled pairs with --all, or a tag with --tag", run: runSync},
+	{name: "config", summary: "Export, import or restore the configuration, or set the color theme, mouse support and read-only mode", run: runConfig,
+		subcommands: []string{"export", "import", "format", "theme", "mouse", "read-only", "tuning", "reports", "templates", "restore", "env"}},
+	{name: "watch-drives", summary: "Sync pairs set to sync on mount when their drive is connected", run: runWatchDrives},
+	{name: "watch-power", summary: "Run scheduled syncs deferred on battery once power is connected", run: runWatchPower},
+	{name: "scrub", summary: "Check pairs against their destinations by hash, monthly with --agent", run: runScrub},
+	{name: "monitor", summary: "Check monitor pairs for drift without transferring, daily with --agent", run: runMonitor},
+	{name: "dedup", summary: "Report files backed up more than once across pairs", run: runDedup},
+	{name: "orphans", summary: "Find data in the pairs' buckets that no pair syncs to, and delete it with --clean", run: runOrphans},
+	{name: "doctor", summary: "Check the installation and configuration and suggest fixes", run: runDoctor},
+	{name: "lint", summary: "Cross-check pairs, remotes, scripts and agents; repair what needs no decision with --fix", run: runLint},
+	{name: "daemon", summary: "Run scheduled backups from a system-wide LaunchDaemon, or remove it with --off", run: runDaemon},
+	{name: "uninstall", summary: "Remove the LaunchAgent, scripts and optionally logs and config", run: runUninstall},
+}
+
+// IsCommand reports whether args start with a known subcommand, in which
+// case Run should be used instead of starting the TUI. Commands print plain
+// text already, so --plain is accepted and ignored wherever it appears.
+func IsCommand(args []string) bool {
+	args, _ = TakeQuietFlag(args)
+	args, plain := TakePlainFlag(args)
+	if plain && len(args) > 0 {
+		return true
+	}
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "help", "-h", "--help":
+		return true
+	}
+	return lookup(args[0]) != nil
+}
+
+// Run executes a subcommand and returns the process exit code. With
+// --quiet nothing is printed and questions are answered no.
+func Run(args []string, stdout, stderr io.Writer) int {
+	args, quiet := TakeQuietFlag(args)
+	if quiet {
+		stdout, stderr = io.Discard, io.Discard
+	}
+	args, _ = TakePlainFlag(args)
+	if len(args) == 0 {
+		usage(stderr)
+		return ExitConfig
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		usage(stdout)
+		return ExitOK
+	}
+
+	cmd := lookup(args[0])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "unknown command '%s'\n\n", args[0])
+		usage(stderr)
+		return ExitConfig
+	}
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+// lookup finds a subcommand by name
+func lookup(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// overview says what runs without a command
+const overview = `Without a command the interactive interface starts. With --plain, or
+when TERM=dumb, a line-oriented menu is used instead. With --simulate,
+brew, rclone and launchctl commands are listed on exit instead of run.`
+
+// usage prints the list of subcommands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: cloud-sync [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, overview)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'cloud-sync <command> -h' for command flags. With --quiet, commands")
+	fmt.Fprintln(w, "print nothing and only set the exit code:")
+	fmt.Fprintln(w)
+	for _, exit := range exitCodes {
+		fmt.Fprintf(w, "  %d  %s\n", exit.code, exit.meaning)
+	}
+}
+
+// flagSets, while the reference is generated, collects the flag sets the
+// commands create
+var flagSets *[]*flag.FlagSet
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("cloud-sync "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if flagSets != nil {
+		*flagSets = append(*flagSets, fs)
+	}
+	return fs
+}
+
+// printError reports err on stderr, followed by what to do about it when it
+// is of a kind the user can fix
+func printError(stderr io.Writer, err error) {
+	fmt.Fprintf(stderr, "Error: %v\n", err)
+	if hint := errkind.Hint(err); hint != "" {
+		fmt.Fprintf(stderr, "Hint: %s\n", hint)
+	}
+}
@@ -0,0 +1,578 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/fileformat"
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/scripts"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// passphraseEnv is read when no passphrase file is given
+const passphraseEnv = "CLOUD_SYNC_PASSPHRASE"
+
+// runConfig implements `cloud-sync config <export|import|format|theme|mouse|read-only|tuning|reports|templates|restore|env>`
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "Usage: cloud-sync config <export|import|format|theme|mouse|read-only|tuning|reports|templates|restore|env> [flags]")
+		return ExitConfig
+	}
+
+	switch args[0] {
+	case "export":
+		return runConfigExport(args[1:], stdout, stderr)
+	case "import":
+		return runConfigImport(args[1:], stdout, stderr)
+	case "format":
+		return runConfigFormat(args[1:], stdout, stderr)
+	case "theme":
+		return runConfigTheme(args[1:], stdout, stderr)
+	case "mouse":
+		return runConfigMouse(args[1:], stdout, stderr)
+	case "read-only":
+		return runConfigReadOnly(args[1:], stdout, stderr)
+	case "tuning":
+		return runConfigTuning(args[1:], stdout, stderr)
+	case "reports":
+		return runConfigReports(args[1:], stdout, stderr)
+	case "templates":
+		return runConfigTemplates(args[1:], stdout, stderr)
+	case "restore":
+		return runConfigRestore(args[1:], stdout, stderr)
+	case "env":
+		return runConfigEnv(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown config command '%s'\n", args[0])
+		return ExitConfig
+	}
+}
+
+// runConfigExport implements `cloud-sync config export`
+func runConfigExport(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("config export", stderr)
+	output := fs.String("o", "", "Bundle file to write (default cloud-sync-config-YYYY-MM-DD.tar.gz)")
+	credentials := fs.Bool("credentials", false, "Include rclone.conf and remote keys, encrypted with a passphrase")
+	passphraseFile := fs.String("passphrase-file", "", "Read the passphrase from a file instead of $"+passphraseEnv)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("cloud-sync-config-%s.tar.gz", time.Now().Format("2006-01-02"))
+		if passphrase != "" {
+			path += ".enc"
+		}
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: failed to create bundle: %v\n", err)
+		return ExitFailure
+	}
+	defer file.Close()
+
+	manifest, err := configManager.Export(file, config.ExportOptions{
+		IncludeCredentials: *credentials,
+		Passphrase:         passphrase,
+	})
+	if err != nil {
+		os.Remove(path)
+		return fail(stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Exported %s to %s\n", strings.Join(manifest.Files, ", "), path)
+	if !manifest.Credentials {
+		fmt.Fprintln(stdout, "Credentials were not included; use --credentials to add them.")
+	}
+	return ExitOK
+}
+
+// runConfigImport implements `cloud-sync config import`
+func runConfigImport(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("config import", stderr)
+	force := fs.Bool("force", false, "Replace an existing configuration")
+	passphraseFile := fs.String("passphrase-file", "", "Read the passphrase from a file instead of $"+passphraseEnv)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "Usage: cloud-sync config import [flags] <bundle>")
+		return ExitConfig
+	}
+
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: failed to open bundle: %v\n", err)
+		return ExitFailure
+	}
+	defer file.Close()
+
+	manifest, err := configManager.Import(file, config.ImportOptions{
+		Passphrase: passphrase,
+		Overwrite:  *force,
+	})
+	if err != nil {
+		printError(stderr, err)
+		if !*force && configManager.ConfigExists() {
+			fmt.Fprintln(stderr, "Use --force to replace it.")
+		}
+		return ExitFailure
+	}
+
+	fmt.Fprintf(stdout, "Imported %s (exported %s)\n", strings.Join(manifest.Files, ", "),
+		manifest.Created.Format("2006-01-02 15:04"))
+	fmt.Fprintln(stdout, "Run cloud-sync and open Scheduling & Maintenance to reinstall the LaunchAgent.")
+	return ExitOK
+}
+
+// runConfigFormat implements `cloud-sync config format`, which shows the
+// format the configuration files are kept in, or converts them to another
+func runConfigFormat(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 1 {
+		fmt.Fprintf(stderr, "Usage: cloud-sync config format [%s]\n", strings.Join(fileformat.Formats, "|"))
+		return ExitConfig
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if len(args) == 1 {
+		if _, err := fileformat.Parse(args[0]); err != nil {
+			fmt.Fprintf(stderr, "Usage: cloud-sync config format [%s]\n", strings.Join(fileformat.Formats, "|"))
+			return ExitConfig
+		}
+		written, err := configManager.ConvertFormat(args[0])
+		for _, path := range written {
+			fmt.Fprintf(stdout, "Wrote %s\n", path)
+		}
+		if err != nil {
+			return fail(stderr, err)
+		}
+	}
+
+	fmt.Fprintf(stdout, "Format: %s (%s, %s)\n", configManager.Format(),
+		filepath.Base(configManager.GetConfigPath()), filepath.Base(configManager.SyncConfigPath()))
+	return ExitOK
+}
+
+// runConfigTheme implements `cloud-sync config theme`, which shows or sets
+// the TUI color theme
+func runConfigTheme(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("config theme", stderr)
+	palette := fs.String("palette", "", "Hex color overrides, e.g. primary=#005F87,error=#D70000")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(stderr, "Usage: cloud-sync config theme [--palette name=#hex,...] [theme]")
+		return ExitConfig
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return fail(stderr, configError(err))
+	}
+
+	if fs.NArg() == 0 && *palette == "" {
+		theme := appConfig.UI.Theme
+		if theme == "" {
+			theme = styles.ThemeAuto
+		}
+		fmt.Fprintf(stdout, "Theme: %s\n", theme)
+		fmt.Fprintf(stdout, "Available: %s\n", strings.Join(styles.ThemeNames(), ", "))
+		return ExitOK
+	}
+
+	uiConfig := appConfig.UI
+	if fs.NArg() == 1 {
+		uiConfig.Theme = fs.Arg(0)
+	}
+	if *palette != "" {
+		colors, err := parsePalette(*palette)
+		if err != nil {
+			printError(stderr, err)
+			return ExitConfig
+		}
+		uiConfig.Palette = colors
+		if uiConfig.Theme == "" {
+			uiConfig.Theme = styles.ThemeCustom
+		}
+	}
+
+	if _, err := styles.ResolveTheme(uiConfig.Theme, uiConfig.Palette); err != nil {
+		printError(stderr, err)
+		return ExitConfig
+	}
+	if err := configManager.UpdateUIConfig(uiConfig); err != nil {
+		return fail(stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Theme set to %s\n", uiConfig.Theme)
+	return ExitOK
+}
+
+// runConfigMouse implements `cloud-sync config mouse`
+func runConfigMouse(args []string, stdout, stderr io.Writer) int {
+	return runConfigSwitch("mouse", "Mouse", args, stdout, stderr, func(ui *config.UIConfig) *bool {
+		return &ui.Mouse
+	})
+}
+
+// runConfigReadOnly implements `cloud-sync config read-only`
+func runConfigReadOnly(args []string, stdout, stderr io.Writer) int {
+	return runConfigSwitch("read-only", "Read-only", args, stdout, stderr, func(ui *config.UIConfig) *bool {
+		return &ui.ReadOnly
+	})
+}
+
+// runConfigSwitch shows an on/off TUI preference, or sets it when given
+// "on" or "off". field returns the preference within the UI settings.
+func runConfigSwitch(name, label string, args []string, stdout, stderr io.Writer, field func(*config.UIConfig) *bool) int {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "on" && args[0] != "off") {
+		fmt.Fprintf(stderr, "Usage: cloud-sync config %s [on|off]\n", name)
+		return ExitConfig
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return fail(stderr, configError(err))
+	}
+
+	uiConfig := appConfig.UI
+	if len(args) == 1 {
+		*field(&uiConfig) = args[0] == "on"
+		if err := configManager.UpdateUIConfig(uiConfig); err != nil {
+			return fail(stderr, err)
+		}
+	}
+
+	state := "off"
+	if *field(&uiConfig) {
+		state = "on"
+	}
+	fmt.Fprintf(stdout, "%s: %s\n", label, state)
+	return ExitOK
+}
+
+// runConfigTuning implements `cloud-sync config tuning`, which shows or
+// changes how a remote uploads large files and writes rclone.conf again
+func runConfigTuning(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("config tuning", stderr)
+	chunkSize := fs.String("chunk-size", "", "Size of each part of a multipart upload, e.g. 96M (empty for rclone's default)")
+	uploadCutoff := fs.String("upload-cutoff", "", "Upload files larger than this in parts, e.g. 200M (empty for rclone's default)")
+	concurrency := fs.Int("concurrency", 0, "Parts of one file to upload at the same time (0 for rclone's default)")
+	reset := fs.Bool("reset", false, "Go back to rclone's defaults")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "Usage: cloud-sync config tuning [--chunk-size SIZE] [--upload-cutoff SIZE] [--concurrency N] [--reset] <remote>")
+		return ExitConfig
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	remote, err := configManager.GetRemote(fs.Arg(0))
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	tuning := config.UploadTuning{}
+	if remote.Tuning != nil {
+		tuning = *remote.Tuning
+	}
+	changed := *reset
+	if *reset {
+		tuning = config.UploadTuning{}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "chunk-size":
+			tuning.ChunkSize = strings.TrimSpace(*chunkSize)
+		case "upload-cutoff":
+			tuning.UploadCutoff = strings.TrimSpace(*uploadCutoff)
+		case "concurrency":
+			tuning.UploadConcurrency = *concurrency
+		default:
+			return
+		}
+		changed = true
+	})
+
+	if changed {
+		remote.Tuning = &tuning
+		if tuning.IsZero() {
+			remote.Tuning = nil
+		}
+		if err := configManager.UpdateRemote(remote.Name, *remote); err != nil {
+			return fail(stderr, err)
+		}
+		if err := configManager.GenerateRcloneConfig(); err != nil {
+			return fail(stderr, err)
+		}
+	}
+
+	fmt.Fprintf(stdout, "%s: %s\n", remote.Name, tuning)
+	return ExitOK
+}
+
+// runConfigReports implements `cloud-sync config reports`, which shows or
+// changes the report written into the log directory after each sync run
+func runConfigReports(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("config reports", stderr)
+	format := fs.String("format", "", "Report format: markdown or html")
+	keep := fs.Int("keep", 0, "Reports to keep per pair, the oldest are removed")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(stderr, "Usage: cloud-sync config reports [--format markdown|html] [--keep N]")
+		return ExitConfig
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return fail(stderr, configError(err))
+	}
+
+	reports := appConfig.Reports
+	changed := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "format":
+			reports.Format = strings.TrimSpace(*format)
+		case "keep":
+			reports.Keep = *keep
+			if *keep < 1 {
+				reports.Keep = -1 // Rejected, rather than taken for the default
+			}
+		}
+		changed = true
+	})
+	if changed {
+		if err := configManager.UpdateReportsConfig(reports); err != nil {
+			return fail(stderr, err)
+		}
+	}
+
+	if reports.Format == "" {
+		reports.Format = logs.ReportMarkdown
+	}
+	if reports.Keep == 0 {
+		reports.Keep = logs.DefaultReportsKept
+	}
+	fmt.Fprintf(stdout, "Reports: %s, the last %d per pair, in %s\n", reports.Format, reports.Keep, filepath.Join(appConfig.LogDir, "reports"))
+	return ExitOK
+}
+
+// runConfigTemplates implements `cloud-sync config templates`, which lists
+// the script templates and whether a custom one replaces the built-in one
+func runConfigTemplates(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("config templates", stderr)
+	export := fs.Bool("export", false, "Copy the built-in templates into the template directory to customize them")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	dir, err := scripts.DefaultTemplateDir()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	generator := scripts.NewGeneratorWithOverrides(dir)
+
+	if *export {
+		written, err := generator.ExportTemplates(dir)
+		for _, path := range written {
+			fmt.Fprintf(stdout, "Wrote %s\n", path)
+		}
+		if err != nil {
+			return fail(stderr, err)
+		}
+	}
+
+	fmt.Fprintf(stdout, "Template directory: %s\n\n", dir)
+	for _, t := range scripts.Templates {
+		source := "built-in"
+		if path, ok := generator.OverridePath(t.Name); ok {
+			source = path
+		}
+		fmt.Fprintf(stdout, "%-20s %-58s %s\n", t.Name, t.Description, source)
+	}
+	return ExitOK
+}
+
+// runConfigEnv implements `cloud-sync config env`, which prints the
+// credential references of the remotes, resolved, as shell exports of the
+// variables rclone reads them from. The generated scripts evaluate it
+// before running rclone, so the secrets never land on disk. References that
+// resolve are printed even when others fail.
+func runConfigEnv(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("config env", stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	env, err := config.RcloneEnv()
+	for _, variable := range env {
+		name, value, _ := strings.Cut(variable, "=")
+		fmt.Fprintf(stdout, "export %s=%s\n", name, shellQuote(value))
+	}
+	if err != nil {
+		return fail(stderr, err)
+	}
+	return ExitOK
+}
+
+// shellQuote quotes value as a single word for sh and zsh
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// runConfigRestore implements `cloud-sync config restore`, which puts back
+// the backup of each configuration file that cannot be read, or of the
+// files named on the command line
+func runConfigRestore(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("config restore", stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	files := map[string]string{
+		filepath.Base(configManager.GetConfigPath()):  configManager.GetConfigPath(),
+		filepath.Base(configManager.SyncConfigPath()): configManager.SyncConfigPath(),
+	}
+
+	var paths []string
+	for _, name := range fs.Args() {
+		path, ok := files[name]
+		if !ok {
+			fmt.Fprintln(stderr, "Usage: cloud-sync config restore [config.json] [sync-config.json]")
+			return ExitConfig
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		for _, corrupt := range configManager.CorruptFiles() {
+			paths = append(paths, corrupt.Path)
+		}
+		if len(paths) == 0 {
+			fmt.Fprintln(stdout, "The configuration files are readable; nothing to restore.")
+			fmt.Fprintln(stdout, "Name a file to restore its backup anyway, e.g. 'cloud-sync config restore config.json'.")
+			return ExitOK
+		}
+	}
+
+	failed := false
+	for _, path := range paths {
+		if err := jsonfile.Restore(path); err != nil {
+			fmt.Fprintf(stderr, "Error: %s: %v\n", filepath.Base(path), err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(stdout, "Restored %s from its backup; the replaced file was kept as %s\n",
+			filepath.Base(path), filepath.Base(jsonfile.CorruptPath(path)))
+	}
+	if failed {
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// parsePalette parses "name=#hex,name=#hex" palette overrides
+func parsePalette(input string) (map[string]string, error) {
+	palette := make(map[string]string)
+	for _, entry := range strings.Split(input, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid palette entry '%s', expected name=#hex", entry)
+		}
+		palette[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return palette, nil
+}
+
+// readPassphrase reads the passphrase from a file, or the environment
+func readPassphrase(path string) (string, error) {
+	if path == "" {
+		return os.Getenv(passphraseEnv), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
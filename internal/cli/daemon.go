@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+)
+
+// runDaemon implements `cloud-sync daemon`, which moves the scheduled
+// backup from the user's LaunchAgent to a system-wide LaunchDaemon running
+// as that user, so backups run whether or not anyone is logged in
+func runDaemon(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("daemon", stderr)
+	off := fs.Bool("off", false, "Remove the LaunchDaemon again")
+	username := fs.String("user", "", "User whose backups the daemon runs (default the current user)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	// Under sudo the current user is root, whose home has no configuration
+	if *username == "" {
+		if os.Geteuid() == 0 {
+			fmt.Fprintln(stderr, "Error: run 'cloud-sync daemon' without sudo, or name the user with --user; it asks for the password when needed")
+			return ExitConfig
+		}
+		*username = launchd.CurrentUsername()
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return fail(stderr, configError(err))
+	}
+
+	agent := launchd.NewManager(*username)
+	daemon := agent.Daemon()
+	if os.Geteuid() != 0 {
+		fmt.Fprintf(stdout, "Writing %s needs administrator privileges; sudo may ask for your password.\n", launchd.DaemonDir)
+	}
+
+	launchConfig := appConfig.LaunchAgent
+	if *off {
+		if err := daemon.Remove(); err != nil {
+			return fail(stderr, err)
+		}
+		launchConfig.System = false
+		if err := configManager.UpdateLaunchAgentConfig(launchConfig); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintf(stdout, "Removed %s.\n", daemon.GetPlistPath())
+		fmt.Fprintln(stdout, "Save the schedule again in Scheduling & Maintenance to run backups from a LaunchAgent.")
+		return ExitOK
+	}
+
+	scriptPath := launchConfig.ScriptPath
+	if scriptPath == "" {
+		scriptPath = filepath.Join(appConfig.BinDir, "monthly_backup.sh")
+	}
+	if _, err := os.Stat(scriptPath); err != nil {
+		fmt.Fprintf(stderr, "Error: the backup script %s is missing; finish Installation & Setup first\n", scriptPath)
+		return ExitFailure
+	}
+
+	schedule := launchConfig.EffectiveSchedule()
+	stdoutPath, stderrPath := launchConfig.OutputPaths(appConfig.LogDir)
+	if err := daemon.InstallDaemon(scriptPath, schedule, stdoutPath, stderrPath); err != nil {
+		return fail(stderr, err)
+	}
+	// Backups would run twice if the LaunchAgent stayed loaded
+	if err := agent.Remove(); err != nil {
+		fmt.Fprintf(stderr, "Warning: failed to remove the LaunchAgent: %v\n", err)
+	}
+
+	launchConfig.Enabled = true
+	launchConfig.System = true
+	if err := configManager.UpdateLaunchAgentConfig(launchConfig); err != nil {
+		return fail(stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Installed %s.\n", daemon.GetPlistPath())
+	fmt.Fprintf(stdout, "Backups run as %s (%s), whether or not anyone is logged in.\n", *username, schedule)
+	return ExitOK
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/dedup"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// runDedup implements `cloud-sync dedup`, which reports files that are
+// backed up more than once across the configured pairs
+func runDedup(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("dedup", stderr)
+	remote := fs.Bool("remote", false, "Compare the hashes rclone lists for the pairs' remotes instead of hashing local files")
+	limit := fs.Int("limit", 20, "Show at most this many duplicated files, most savings first")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	all, err := syncConfigMgr.ListSyncPairs()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	// Monitor pairs back nothing up. Remote paths are compared as this Mac
+	// syncs them today.
+	vars := syncconfig.CurrentPathVars()
+	var pairs []syncconfig.SyncPair
+	for _, pair := range all {
+		if !pair.IsMonitor() {
+			pairs = append(pairs, pair.Expand(vars))
+		}
+	}
+	if len(pairs) == 0 {
+		fmt.Fprintln(stdout, "No sync pairs configured.")
+		return ExitOK
+	}
+
+	var report *dedup.Report
+	if *remote {
+		report, err = scanRemotes(pairs)
+	} else {
+		report, err = dedup.ScanLocal(pairs)
+	}
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	writeDedupReport(stdout, report, *limit)
+	return ExitOK
+}
+
+// scanRemotes lists the pairs' remotes with rclone to compare their hashes
+func scanRemotes(pairs []syncconfig.SyncPair) (*dedup.Report, error) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return nil, configError(fmt.Errorf("failed to load config: %w", err))
+	}
+	env, err := config.RcloneEnv()
+	if err != nil {
+		return nil, err
+	}
+	indexDir, err := rclone.DefaultIndexDir()
+	if err != nil {
+		return nil, err
+	}
+
+	mgr := rclone.NewManagerWithConfig(rclonePath(appConfig.RclonePath), appConfig.RcloneConfig)
+	mgr.SetEnv(env)
+	return dedup.ScanRemote(mgr, rclone.NewIndexCache(indexDir), pairs)
+}
+
+// writeDedupReport prints the overlapping pairs and up to limit duplicated
+// files
+func writeDedupReport(w io.Writer, report *dedup.Report, limit int) {
+	fmt.Fprintf(w, "Scanned %d files in %d pairs.\n", report.Files, report.Pairs)
+
+	if len(report.Overlaps) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Overlapping pairs:")
+		for _, overlap := range report.Overlaps {
+			fmt.Fprintf(w, "  %s is inside %s (%s)\n", overlap.Inner, overlap.Outer, overlap.Path)
+		}
+	}
+
+	fmt.Fprintln(w)
+	if len(report.Groups) == 0 {
+		fmt.Fprintln(w, "No file is backed up more than once.")
+		return
+	}
+	fmt.Fprintf(w, "Backed up more than once: %d files, %d extra copies, %s could be saved.\n",
+		len(report.Groups), report.ExtraCopies(), formatSize(report.Savings()))
+	for i, group := range report.Groups {
+		if i == limit {
+			fmt.Fprintf(w, "  ...and %d more\n", len(report.Groups)-limit)
+			break
+		}
+		copies := make([]string, len(group.Copies))
+		for j, c := range group.Copies {
+			copies[j] = c.String()
+		}
+		fmt.Fprintf(w, "  %s x%d: %s\n", formatSize(group.Size), len(group.Copies), strings.Join(copies, ", "))
+	}
+}
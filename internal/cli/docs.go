@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// The reference lists every command, itself included, so it joins the
+// list once the others are defined
+func init() {
+	commands = append(commands, command{name: "docs", summary: "Print the reference of the commands and keys, as text or a man page", run: runDocs})
+}
+
+// defaultPager pages the reference on a terminal when PAGER is not set
+const defaultPager = "less"
+
+// runDocs implements `cloud-sync docs`, which prints the reference of the
+// commands and keys of the interface. Both are generated from their
+// definitions, so the reference cannot drift from what the binary does.
+func runDocs(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("docs", stderr)
+	format := fs.String("format", "text", "Output format: text or man")
+	output := fs.String("o", "", "File to write (default standard output, paged on a terminal)")
+	noPager := fs.Bool("no-pager", false, "Print to the terminal without a pager")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+	if (*format != "text" && *format != "man") || fs.NArg() > 0 {
+		fmt.Fprintln(stderr, "Usage: cloud-sync docs [--format text|man] [-o FILE] [--no-pager]")
+		return ExitConfig
+	}
+
+	var doc bytes.Buffer
+	if *format == "man" {
+		writeManPage(&doc, commandDocs(), ui.KeyReference())
+	} else {
+		writeTextReference(&doc, commandDocs(), ui.KeyReference())
+	}
+
+	if *output != "" {
+		if err := os.WriteFile(*output, doc.Bytes(), 0644); err != nil {
+			return fail(stderr, fmt.Errorf("failed to write %s: %w", *output, err))
+		}
+		fmt.Fprintf(stdout, "Wrote %s\n", *output)
+		return ExitOK
+	}
+	if !*noPager && stdout == os.Stdout && term.IsTerminal(int(os.Stdout.Fd())) && page(doc.Bytes()) == nil {
+		return ExitOK
+	}
+	stdout.Write(doc.Bytes())
+	return ExitOK
+}
+
+// page shows text in PAGER, or less
+func page(text []byte) error {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = defaultPager
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = bytes.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// commandDoc is the reference of a command or subcommand
+type commandDoc struct {
+	name    string // e.g. "pairs export"
+	summary string // Only for commands
+	usage   string // The usage line of commands without flags, if any
+	flags   []*flag.Flag
+}
+
+// commandDocs returns the reference of the commands and their subcommands
+// with the flags they define. Each is run with -h to find them, which
+// returns before anything is done.
+func commandDocs() []commandDoc {
+	var docs []commandDoc
+	for _, cmd := range commands {
+		doc := commandDoc{name: cmd.name, summary: cmd.summary}
+		// A command whose subcommands are all it does has no flags itself
+		if len(cmd.subcommands) == 0 || slices.Contains(cmd.subcommands, "") {
+			doc.usage, doc.flags = commandHelp(cmd, "")
+		}
+		docs = append(docs, doc)
+
+		for _, sub := range cmd.subcommands {
+			if sub == "" {
+				continue
+			}
+			doc := commandDoc{name: cmd.name + " " + sub}
+			doc.usage, doc.flags = commandHelp(cmd, sub)
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// commandHelp runs a command, or its subcommand sub, with -h and returns
+// the flags it defines, or the usage line it prints when it has none
+func commandHelp(cmd command, sub string) (string, []*flag.Flag) {
+	args := []string{"-h"}
+	if sub != "" {
+		args = []string{sub, "-h"}
+	}
+	var sets []*flag.FlagSet
+	var stderr bytes.Buffer
+	flagSets = &sets
+	cmd.run(args, io.Discard, &stderr)
+	flagSets = nil
+
+	var flags []*flag.Flag
+	for _, fs := range sets {
+		fs.VisitAll(func(f *flag.Flag) {
+			flags = append(flags, f)
+		})
+	}
+	if len(sets) > 0 {
+		return "", flags
+	}
+	line, ok := strings.CutPrefix(stderr.String(), "Usage: ")
+	if !ok {
+		return "", nil
+	}
+	usage, _, _ := strings.Cut(line, "\n")
+	return usage, nil
+}
+
+// flagName returns how a flag is written, -o or --format, with the name of
+// its value
+func flagName(f *flag.Flag) string {
+	name := "--" + f.Name
+	if len(f.Name) == 1 {
+		name = "-" + f.Name
+	}
+	if value, _ := flag.UnquoteUsage(f); value != "" {
+		name += " " + value
+	}
+	return name
+}
+
+// flagUsage returns what a flag does, with its default when it has one
+func flagUsage(f *flag.Flag) string {
+	_, usage := flag.UnquoteUsage(f)
+	switch f.DefValue {
+	case "", "false", "0", "0s":
+		return usage
+	}
+	return fmt.Sprintf("%s (default %s)", usage, f.DefValue)
+}
+
+// writeTextReference writes the reference for reading in a terminal
+func writeTextReference(w io.Writer, docs []commandDoc, keys []views.KeySection) {
+	fmt.Fprintln(w, "Usage: cloud-sync [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, overview)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "COMMANDS")
+	for _, doc := range docs {
+		fmt.Fprintf(w, "\n  cloud-sync %s\n", doc.name)
+		if doc.summary != "" {
+			fmt.Fprintf(w, "    %s\n", doc.summary)
+		}
+		if doc.usage != "" {
+			fmt.Fprintf(w, "    Usage: %s\n", doc.usage)
+		}
+		for _, f := range doc.flags {
+			fmt.Fprintf(w, "    %-26s %s\n", flagName(f), flagUsage(f))
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "KEYS")
+	for _, section := range keys {
+		fmt.Fprintf(w, "\n  %s\n", section.Title)
+		for _, k := range section.Keys {
+			fmt.Fprintf(w, "    %-20s %s\n", k.Key, k.Help)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "EXIT CODES")
+	fmt.Fprintln(w)
+	for _, exit := range exitCodes {
+		fmt.Fprintf(w, "    %d  %s\n", exit.code, exit.meaning)
+	}
+}
+
+// manEscape escapes text for a man page: backslashes, hyphens, which would
+// otherwise be typeset as dashes, and control characters at line starts
+func manEscape(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+// writeManPage writes the reference as a man page in section 1
+func writeManPage(w io.Writer, docs []commandDoc, keys []views.KeySection) {
+	fmt.Fprintln(w, `.TH CLOUD\-SYNC 1 "" "cloud-sync" "User Commands"`)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintln(w, `cloud\-sync \- back up folders to cloud storage with rclone`)
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintln(w, `.B cloud\-sync`)
+	fmt.Fprintln(w, `[\fB\-\-plain\fR] [\fB\-\-read\-only\fR] [\fB\-\-simulate\fR]`)
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, `.B cloud\-sync`)
+	fmt.Fprintln(w, `\fIcommand\fR [\fB\-\-quiet\fR] [\fIflags\fR]`)
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, manEscape(strings.ReplaceAll(overview, "\n", " ")))
+
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, doc := range docs {
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintf(w, ".B %s\n", manEscape(doc.name))
+		if doc.summary != "" {
+			fmt.Fprintln(w, manEscape(doc.summary))
+		}
+		if doc.usage != "" {
+			fmt.Fprintf(w, "Usage: %s\n", manEscape(doc.usage))
+		}
+		if len(doc.flags) == 0 {
+			continue
+		}
+		fmt.Fprintln(w, ".RS")
+		for _, f := range doc.flags {
+			name, value, _ := strings.Cut(flagName(f), " ")
+			fmt.Fprintln(w, ".TP")
+			if value != "" {
+				fmt.Fprintf(w, `\fB%s\fR \fI%s\fR`+"\n", manEscape(name), manEscape(value))
+			} else {
+				fmt.Fprintf(w, `\fB%s\fR`+"\n", manEscape(name))
+			}
+			fmt.Fprintln(w, manEscape(flagUsage(f)))
+		}
+		fmt.Fprintln(w, ".RE")
+	}
+
+	fmt.Fprintln(w, ".SH KEYS")
+	for _, section := range keys {
+		fmt.Fprintf(w, ".SS %s\n", manEscape(section.Title))
+		for _, k := range section.Keys {
+			fmt.Fprintln(w, ".TP")
+			fmt.Fprintf(w, ".B %s\n", manEscape(k.Key))
+			fmt.Fprintln(w, manEscape(k.Help))
+		}
+	}
+
+	fmt.Fprintln(w, ".SH EXIT STATUS")
+	for _, exit := range exitCodes {
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintf(w, ".B %d\n", exit.code)
+		fmt.Fprintln(w, manEscape(exit.meaning))
+	}
+	fmt.Fprintln(w, ".SH SEE ALSO")
+	fmt.Fprintln(w, ".BR rclone (1)")
+}
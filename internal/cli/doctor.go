@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/doctor"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// runDoctor implements `cloud-sync doctor`
+func runDoctor(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("doctor", stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	// rclonePath falls back to a bare "rclone" when it finds none
+	path := rclonePath(configuredRclone(configManager))
+	if path == "rclone" {
+		path = ""
+	}
+
+	checks := doctor.Run(doctor.Options{
+		Config:     configManager,
+		Pairs:      syncconfig.NewManager(configManager.SyncConfigPath()),
+		Launchd:    config.BackupScheduler(launchd.CurrentUsername()),
+		RclonePath: path,
+	})
+	return writeChecks(stdout, checks)
+}
+
+// configuredRclone returns the rclone binary config.json names, if it can
+// be read
+func configuredRclone(configManager *config.Manager) string {
+	if appConfig, err := configManager.Load(); err == nil {
+		return appConfig.RclonePath
+	}
+	return ""
+}
+
+// writeChecks prints one line per check with a fix under each problem, then
+// a tally. It returns ExitFailure if any check failed.
+func writeChecks(w io.Writer, checks []doctor.Check) int {
+	counts := make(map[doctor.Level]int)
+	for _, check := range checks {
+		counts[check.Level]++
+		fmt.Fprintf(w, "%s  %s: %s\n", check.Level, check.Name, check.Detail)
+		if check.Fix != "" {
+			fmt.Fprintf(w, "      Fix: %s\n", check.Fix)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d passed, %d warning(s), %d failed\n", counts[doctor.Pass], counts[doctor.Warn], counts[doctor.Fail])
+	if counts[doctor.Fail] > 0 {
+		return ExitFailure
+	}
+	return ExitOK
+}
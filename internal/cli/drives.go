@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/volume"
+)
+
+// runWatchDrives implements `cloud-sync watch-drives`, which installs the
+// agent running `cloud-sync sync --mounted` when a drive is connected
+func runWatchDrives(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("watch-drives", stderr)
+	off := fs.Bool("off", false, "Remove the agent again")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	agent := launchd.NewManager(launchd.CurrentUsername()).Mount()
+	if *off {
+		if err := agent.Remove(); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintln(stdout, "Pairs no longer sync when a drive is connected.")
+		return ExitOK
+	}
+
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	pairs, err := syncConfigMgr.ListEnabledSyncPairs()
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	var watched []string
+	for _, pair := range pairs {
+		if pair.SyncOnMount {
+			watched = append(watched, fmt.Sprintf("%s (%s)", pair.Name, strings.Join(pair.Volumes(), ", ")))
+		}
+	}
+	if len(watched) == 0 {
+		fmt.Fprintln(stderr, "No enabled pair is set to sync on mount; set sync_on_mount in sync-config.json first.")
+		return ExitFailure
+	}
+
+	program, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: failed to find the cloud-sync binary: %v\n", err)
+		return ExitFailure
+	}
+	if err := agent.InstallMountAgent(program, volume.Root); err != nil {
+		return fail(stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Installed %s.\n", agent.GetPlistPath())
+	fmt.Fprintln(stdout, "These pairs now sync when their drive is connected:")
+	for _, pair := range watched {
+		fmt.Fprintf(stdout, "  %s\n", pair)
+	}
+	return ExitOK
+}
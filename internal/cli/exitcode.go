@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"errors"
+	"io"
+
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// Exit codes of the commands. They are documented, so scripts can tell
+// failures apart without reading the output.
+const (
+	ExitOK      = 0 // Everything succeeded
+	ExitFailure = 1 // Something failed, e.g. some of the pairs of a sync
+	ExitConfig  = 2 // Bad flags or arguments, an unknown pair or an unreadable config file
+	ExitLocked  = 3 // Another backup holds the lockfile
+	ExitNetwork = 4 // The remote could not be reached
+)
+
+// exitCodes describes each exit code, for usage and the reference
+var exitCodes = []struct {
+	code    int
+	meaning string
+}{
+	{ExitOK, "success"},
+	{ExitFailure, "failure, or some of the pairs failed"},
+	{ExitConfig, "bad flags, an unknown pair or an unreadable config file"},
+	{ExitLocked, "another backup is running"},
+	{ExitNetwork, "the remote could not be reached"},
+}
+
+// QuietFlag suppresses all output of a command, leaving only its exit code
+const QuietFlag = "--quiet"
+
+// TakeQuietFlag removes QuietFlag from args, wherever it appears, and
+// reports whether it was present
+func TakeQuietFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == QuietFlag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// exitCode returns the exit code of a command that failed with err
+func exitCode(err error) int {
+	var corrupt *jsonfile.CorruptError
+	var notFound *syncconfig.PairNotFoundError
+	var loadErr *configLoadError
+	switch {
+	case errors.Is(err, errkind.ErrLocked):
+		return ExitLocked
+	case errors.Is(err, errkind.ErrNetwork):
+		return ExitNetwork
+	case errors.As(err, &corrupt), errors.As(err, &notFound), errors.As(err, &loadErr):
+		return ExitConfig
+	}
+	return ExitFailure
+}
+
+// configLoadError is a failure to load the configuration. Its message is
+// the error's own.
+type configLoadError struct {
+	err error
+}
+
+func (e *configLoadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *configLoadError) Unwrap() error {
+	return e.err
+}
+
+// configError marks err as a failure to load the configuration
+func configError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &configLoadError{err: err}
+}
+
+// fail reports err on stderr and returns the exit code for it
+func fail(stderr io.Writer, err error) int {
+	printError(stderr, err)
+	return exitCode(err)
+}
+
+// combinedExitCode returns the exit code of a command that ran several
+// jobs, given the codes of the ones that failed: ExitOK when none failed,
+// their code when all of them failed the same way and none succeeded, and
+// ExitFailure otherwise
+func combinedExitCode(failures []int, succeeded int) int {
+	if len(failures) == 0 {
+		return ExitOK
+	}
+	if succeeded > 0 {
+		return ExitFailure
+	}
+	for _, code := range failures[1:] {
+		if code != failures[0] {
+			return ExitFailure
+		}
+	}
+	return failures[0]
+}
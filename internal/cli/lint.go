@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/doctor"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// runLint implements `cloud-sync lint`, which cross-checks the pairs,
+// remotes, scripts and agents, and with --fix repairs the issues that need
+// no decision
+func runLint(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("lint", stderr)
+	fix := fs.Bool("fix", false, "Repair the issues that have one repair, e.g. a script running an rclone that moved")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	path := rclonePath(configuredRclone(configManager))
+	if path == "rclone" {
+		path = ""
+	}
+
+	issues, err := doctor.Lint(doctor.Options{
+		Config:     configManager,
+		Pairs:      syncconfig.NewManager(configManager.SyncConfigPath()),
+		Launchd:    config.BackupScheduler(launchd.CurrentUsername()),
+		RclonePath: path,
+	})
+	if err != nil {
+		return fail(stderr, configError(err))
+	}
+	if len(issues) == 0 {
+		fmt.Fprintln(stdout, "No issues found.")
+		return ExitOK
+	}
+
+	remaining, repairable := 0, 0
+	for _, issue := range issues {
+		fmt.Fprintf(stdout, "%s: %s\n", issue.Rule, issue.Detail)
+		switch {
+		case *fix && issue.Repairable():
+			if err := issue.Apply(); err != nil {
+				remaining++
+				fmt.Fprintf(stdout, "      Repair failed: %v\n", err)
+				fmt.Fprintf(stdout, "      Fix: %s\n", issue.Fix)
+				continue
+			}
+			fmt.Fprintf(stdout, "      Repaired: %s\n", issue.Repair)
+		default:
+			remaining++
+			fmt.Fprintf(stdout, "      Fix: %s\n", issue.Fix)
+			if issue.Repairable() {
+				repairable++
+				fmt.Fprintf(stdout, "      Or run 'cloud-sync lint --fix' to %s\n", issue.Repair)
+			}
+		}
+	}
+
+	fmt.Fprintf(stdout, "\n%d issue(s)", len(issues))
+	if *fix {
+		fmt.Fprintf(stdout, ", %d repaired", len(issues)-remaining)
+	} else if repairable > 0 {
+		fmt.Fprintf(stdout, ", %d can be repaired with --fix", repairable)
+	}
+	fmt.Fprintln(stdout)
+	if remaining > 0 {
+		return ExitFailure
+	}
+	return ExitOK
+}
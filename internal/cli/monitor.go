@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+)
+
+// runMonitor implements `cloud-sync monitor`, which checks monitor pairs
+// for drift between their two locations, and installs the agent running
+// it daily
+func runMonitor(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("monitor", stderr)
+	install := fs.Bool("agent", false, "Install the agent checking the monitor pairs at 5:00 every day")
+	off := fs.Bool("off", false, "Remove the daily agent again")
+	scheduled := fs.Bool("scheduled", false, "Run as the daily check, skipping pairs whose drive is not connected")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	agent := launchd.NewManager(launchd.CurrentUsername()).Monitor()
+	switch {
+	case *off:
+		if err := agent.Remove(); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintln(stdout, "The monitor pairs are no longer checked daily.")
+		return ExitOK
+	case *install:
+		program, err := os.Executable()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: failed to find the cloud-sync binary: %v\n", err)
+			return ExitFailure
+		}
+		if err := agent.InstallMonitorAgent(program); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintf(stdout, "Installed %s.\n", agent.GetPlistPath())
+		fmt.Fprintln(stdout, "Enabled monitor pairs are checked at 5:00 every day.")
+		return ExitOK
+	}
+
+	backupMgr, err := newBackupManager(stdout, stderr)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		if names, err = backupMgr.MonitorPairs(); err != nil {
+			return fail(stderr, err)
+		}
+		if len(names) == 0 {
+			fmt.Fprintln(stderr, "No enabled monitor pairs; add a pair with \"type\": \"monitor\" to sync-config.json.")
+			return ExitFailure
+		}
+	}
+	return scrubPairs(backupMgr, names, "Checking", *scheduled, stdout, stderr)
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/orphans"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// runOrphans implements `cloud-sync orphans`, which reports data in the
+// pairs' buckets that no pair syncs to anymore
+func runOrphans(args []string, stdout, stderr io.Writer) int {
+	return findOrphans(args, stdout, stderr, terminalConfirm(stdout))
+}
+
+// findOrphans lists the orphans and with --clean asks before deleting each
+func findOrphans(args []string, stdout, stderr io.Writer, confirm confirmFunc) int {
+	fs := newFlagSet("orphans", stderr)
+	clean := fs.Bool("clean", false, "Ask before deleting each orphan, largest first")
+	yes := fs.Bool("yes", false, "With --clean, delete every orphan without asking")
+	refresh := fs.Bool("refresh", false, "List the buckets again instead of using listings from the last 10 minutes")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return fail(stderr, configError(fmt.Errorf("failed to load config: %w", err)))
+	}
+	pairs, err := syncconfig.NewManager(configManager.SyncConfigPath()).ListSyncPairs()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	env, err := config.RcloneEnv()
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	mgr := rclone.NewManagerWithConfig(rclonePath(appConfig.RclonePath), appConfig.RcloneConfig)
+	mgr.SetEnv(env)
+	listingDir, err := rclone.DefaultListingDir()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	cache := rclone.NewListingCache(listingDir, rclone.DefaultListingTTL)
+	// Nothing is deleted on the strength of a cached listing
+	if *refresh || *clean {
+		cache = cache.Refreshed()
+	}
+	report, err := orphans.Scan(mgr, cache, pairs)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	for _, skipped := range report.Skipped {
+		fmt.Fprintf(stdout, "Skipped %s: folders on this Mac are not scanned.\n", skipped)
+	}
+	if len(report.Buckets) == 0 && len(report.Skipped) == 0 {
+		fmt.Fprintln(stdout, "No sync pairs with a remote configured.")
+		return ExitOK
+	}
+	if len(report.Buckets) == 0 {
+		fmt.Fprintln(stdout, "No buckets to scan.")
+		return ExitOK
+	}
+
+	fmt.Fprintf(stdout, "Scanned %d bucket(s).\n", len(report.Buckets))
+	if len(report.Orphans) == 0 {
+		fmt.Fprintln(stdout, "No orphaned data found.")
+		return ExitOK
+	}
+	fmt.Fprintf(stdout, "No pair syncs to %d path(s), %s in total:\n", len(report.Orphans), formatSize(report.Size()))
+	for _, orphan := range report.Orphans {
+		fmt.Fprintf(stdout, "  %s  %s\n", orphan, formatSize(orphan.Size))
+	}
+	if !*clean {
+		fmt.Fprintln(stdout)
+		fmt.Fprintln(stdout, "Run 'cloud-sync orphans --clean' to delete them one by one.")
+		return ExitOK
+	}
+
+	fmt.Fprintln(stdout)
+	var freed int64
+	removed, failed := 0, 0
+	for _, orphan := range report.Orphans {
+		if !*yes && !confirm(fmt.Sprintf("Delete %s (%s) permanently? [y/N] ", orphan, formatSize(orphan.Size))) {
+			fmt.Fprintf(stdout, "Kept %s\n", orphan)
+			continue
+		}
+		if err := orphans.Remove(mgr, cache, orphan); err != nil {
+			failed++
+			printError(stderr, err)
+			continue
+		}
+		removed++
+		freed += orphan.Size
+		fmt.Fprintf(stdout, "Deleted %s\n", orphan)
+	}
+	fmt.Fprintf(stdout, "\nDeleted %d of %d, %s freed.\n", removed, len(report.Orphans), formatSize(freed))
+	if failed > 0 {
+		return ExitFailure
+	}
+	return ExitOK
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// runPairsExport implements `cloud-sync pairs export`, which writes sync
+// pairs to a file other machines can import
+func runPairsExport(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("pairs export", stderr)
+	format := fs.String("format", "yaml", "File format: yaml or json")
+	tag := fs.String("tag", "", "Only export the pairs with this tag or group")
+	output := fs.String("o", "", "File to write (default standard output)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+	if *format != "yaml" && *format != "json" {
+		fmt.Fprintln(stderr, "Usage: cloud-sync pairs export [--format yaml|json] [--tag TAG] [-o FILE] [pair...]")
+		return ExitConfig
+	}
+
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	pairs, err := syncConfigMgr.ListSyncPairs()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if *tag != "" {
+		pairs = taggedPairs(pairs, *tag)
+	}
+	if fs.NArg() > 0 {
+		pairs, err = namedPairs(pairs, fs.Args())
+		if err != nil {
+			return fail(stderr, err)
+		}
+	}
+	if len(pairs) == 0 {
+		fmt.Fprintln(stderr, "Error: no sync pairs to export")
+		return ExitFailure
+	}
+
+	home, _ := os.UserHomeDir()
+	data, err := syncconfig.Export(pairs, home, *format)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if *output == "" {
+		stdout.Write(data)
+		return ExitOK
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprintf(stderr, "Error: failed to write %s: %v\n", *output, err)
+		return ExitFailure
+	}
+	fmt.Fprintf(stdout, "Exported %d sync pair(s) to %s\n", len(pairs), *output)
+	return ExitOK
+}
+
+// namedPairs returns the pairs with the given names, in that order
+func namedPairs(pairs []syncconfig.SyncPair, names []string) ([]syncconfig.SyncPair, error) {
+	named := make([]syncconfig.SyncPair, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, pair := range pairs {
+			if pair.Name == name {
+				named = append(named, pair)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, &syncconfig.PairNotFoundError{Name: name}
+		}
+	}
+	return named, nil
+}
+
+// runPairsImport implements `cloud-sync pairs import`, which adds the sync
+// pairs of a file written by `pairs export` or by hand
+func runPairsImport(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("pairs import", stderr)
+	onConflict := fs.String("on-conflict", "fail", "When a pair's name or folder is taken: fail, skip, replace or rename")
+	dryRun := fs.Bool("dry-run", false, "Show what would be imported without saving it")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+	policy, err := syncconfig.ParseConflictPolicy(*onConflict)
+	if fs.NArg() != 1 || err != nil {
+		fmt.Fprintln(stderr, "Usage: cloud-sync pairs import [--on-conflict fail|skip|replace|rename] [--dry-run] <file|->")
+		return ExitConfig
+	}
+
+	var data []byte
+	if fs.Arg(0) == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: failed to read %s: %v\n", fs.Arg(0), err)
+		return ExitFailure
+	}
+	file, err := syncconfig.ParsePairFile(data)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	results, err := syncConfigMgr.Import(file.Pairs, policy, *dryRun)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: nothing was imported:\n%v\n", err)
+		if policy == syncconfig.ConflictFail {
+			fmt.Fprintln(stderr, "Use --on-conflict skip, replace or rename to import the other pairs.")
+		}
+		return ExitFailure
+	}
+
+	for _, result := range results {
+		switch result.Action {
+		case syncconfig.ImportRenamed:
+			fmt.Fprintf(stdout, "%s: renamed to %s\n", result.Name, result.Detail)
+		case syncconfig.ImportSkipped:
+			fmt.Fprintf(stdout, "%s: skipped, %s\n", result.Name, result.Detail)
+		default:
+			if result.Detail != "" {
+				fmt.Fprintf(stdout, "%s: %s, %s\n", result.Name, result.Action, result.Detail)
+			} else {
+				fmt.Fprintf(stdout, "%s: %s\n", result.Name, result.Action)
+			}
+		}
+	}
+	if *dryRun {
+		fmt.Fprintln(stdout, "Dry run: nothing was saved.")
+	}
+	return ExitOK
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// plainFlag selects the line-oriented interface instead of the TUI
+const plainFlag = "--plain"
+
+// WantsPlain reports whether the line-oriented menu should be used instead
+// of the TUI: when --plain is given or the terminal cannot move the cursor
+func WantsPlain(args []string) bool {
+	if _, plain := TakePlainFlag(args); plain {
+		return true
+	}
+	return os.Getenv("TERM") == "dumb"
+}
+
+// TakePlainFlag removes --plain from args, wherever it appears, and
+// reports whether it was present
+func TakePlainFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == plainFlag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// RunPlain runs a numbered menu that reads one answer per line and prints
+// plain text, for screen readers, CI and terminals without cursor control
+func RunPlain(stdin io.Reader, stdout, stderr io.Writer) int {
+	input := bufio.NewScanner(stdin)
+
+	for {
+		fmt.Fprintln(stdout, "Cloud Sync")
+		fmt.Fprintln(stdout, "  1) List sync pairs")
+		fmt.Fprintln(stdout, "  2) Run sync")
+		fmt.Fprintln(stdout, "  3) Show status")
+		fmt.Fprintln(stdout, "  q) Quit")
+
+		choice, ok := prompt(input, stdout, "Choose an option: ")
+		if !ok {
+			return ExitOK
+		}
+
+		switch strings.ToLower(choice) {
+		case "1":
+			runPairs(nil, stdout, stderr)
+		case "2":
+			name, ok := prompt(input, stdout, "Pair to sync (blank for all enabled pairs): ")
+			if !ok {
+				return ExitOK
+			}
+			confirm := func(question string) bool {
+				answer, ok := prompt(input, stdout, question)
+				return ok && isYes(answer)
+			}
+			if name == "" {
+				syncPairs([]string{"--all"}, stdout, stderr, confirm)
+			} else {
+				syncPairs([]string{name}, stdout, stderr, confirm)
+			}
+		case "3":
+			runStatus(nil, stdout, stderr)
+		case "q", "quit", "exit":
+			return ExitOK
+		default:
+			fmt.Fprintf(stdout, "Unknown option '%s'.\n", choice)
+		}
+		fmt.Fprintln(stdout)
+	}
+}
+
+// prompt prints a question and reads one trimmed line. It returns false at
+// the end of input.
+func prompt(input *bufio.Scanner, stdout io.Writer, question string) (string, bool) {
+	fmt.Fprint(stdout, question)
+	if !input.Scan() {
+		fmt.Fprintln(stdout)
+		return "", false
+	}
+	return strings.TrimSpace(input.Text()), true
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// runWatchPower implements `cloud-sync watch-power`, which installs the
+// agent running `cloud-sync sync --scheduled --deferred` when the power
+// source changes
+func runWatchPower(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("watch-power", stderr)
+	off := fs.Bool("off", false, "Remove the agent again")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	agent := launchd.NewManager(launchd.CurrentUsername()).Power()
+	if *off {
+		if err := agent.Remove(); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintln(stdout, "Deferred syncs no longer run when power is connected.")
+		return ExitOK
+	}
+
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	pairs, err := syncConfigMgr.ListEnabledSyncPairs()
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	var watched []string
+	for _, pair := range pairs {
+		if pair.MinBatteryPercent > 0 {
+			watched = append(watched, fmt.Sprintf("%s (below %d%%)", pair.Name, pair.MinBatteryPercent))
+		}
+	}
+	if len(watched) == 0 {
+		fmt.Fprintln(stderr, "No enabled pair has a battery threshold; set min_battery_percent in sync-config.json first.")
+		return ExitFailure
+	}
+
+	program, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: failed to find the cloud-sync binary: %v\n", err)
+		return ExitFailure
+	}
+	if err := agent.InstallPowerAgent(program); err != nil {
+		return fail(stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Installed %s.\n", agent.GetPlistPath())
+	fmt.Fprintln(stdout, "Scheduled syncs of these pairs deferred on battery run once power is connected:")
+	for _, pair := range watched {
+		fmt.Fprintf(stdout, "  %s\n", pair)
+	}
+	return ExitOK
+}
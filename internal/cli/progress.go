@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+	"golang.org/x/term"
+)
+
+// progressBarWidth is the width of the pairs bar `cloud-sync sync` draws
+const progressBarWidth = 20
+
+// progressReportInterval is how often `cloud-sync sync --progress` prints
+// a progress line when it cannot redraw one in place
+const progressReportInterval = 2 * time.Second
+
+// syncProgress shows how a sync of several pairs is getting on: a bar of
+// the pairs synced so far and the files and bytes transferred for the
+// active pair. On a terminal it redraws one status line below the output;
+// otherwise, with --progress, it prints a progress line every
+// progressReportInterval, which the TUI follows.
+type syncProgress struct {
+	mu       sync.Mutex
+	out      io.Writer
+	live     bool // Redraw the status line in place
+	lines    bool // Print progress lines
+	drawn    bool // The status line is on screen
+	reported time.Time
+
+	pair     string
+	done     int
+	total    int
+	transfer backup.TransferProgress
+}
+
+// newSyncProgress returns the progress display for out. It draws nothing
+// unless out is a terminal or lines is set.
+func newSyncProgress(out io.Writer, lines bool) *syncProgress {
+	return &syncProgress{out: out, live: isTerminal(out), lines: lines}
+}
+
+// Shown reports whether the display shows anything, and so needs the
+// transfer progress of each pair
+func (p *syncProgress) Shown() bool {
+	return p.live || p.lines
+}
+
+// isTerminal reports whether w is a terminal that can redraw a line
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd())) && os.Getenv("TERM") != "dumb"
+}
+
+// Write implements io.Writer, keeping the status line below what is
+// written. A line still being written, e.g. a question, is left alone.
+func (p *syncProgress) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	n, err := p.out.Write(data)
+	if strings.HasSuffix(string(data), "\n") {
+		p.draw()
+	}
+	return n, err
+}
+
+// Start shows pair as the active pair, with done of total pairs synced
+func (p *syncProgress) Start(pair string, done, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pair, p.done, p.total = pair, done, total
+	p.transfer = backup.TransferProgress{}
+	p.report(true)
+}
+
+// Transfer updates the active pair's transfer progress
+func (p *syncProgress) Transfer(pair string, progress backup.TransferProgress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pair != p.pair {
+		return
+	}
+	p.transfer = progress
+	p.report(false)
+}
+
+// Finish removes the status line
+func (p *syncProgress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	p.total = 0
+}
+
+// report redraws the status line, or prints a progress line if one is due
+func (p *syncProgress) report(force bool) {
+	switch {
+	case p.live:
+		p.clear()
+		p.draw()
+	case p.lines && (force || time.Since(p.reported) >= progressReportInterval):
+		p.reported = time.Now()
+		fmt.Fprintf(p.out, "Progress: %d of %d pairs done, %s: %d of %d files, %d of %d bytes\n",
+			p.done, p.total, p.pair, p.transfer.Files, p.transfer.TotalFiles, p.transfer.Bytes, p.transfer.TotalBytes)
+	}
+}
+
+// draw writes the status line on a terminal
+func (p *syncProgress) draw() {
+	if !p.live || p.total == 0 {
+		return
+	}
+	fmt.Fprint(p.out, p.status())
+	p.drawn = true
+}
+
+// clear erases the status line drawn last
+func (p *syncProgress) clear() {
+	if p.drawn {
+		fmt.Fprint(p.out, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// status returns the status line, e.g.
+// "[██████░░░░] 2/5 pairs • Photos: 12/40 files, 1.2 MB/5.0 MB"
+func (p *syncProgress) status() string {
+	filled := p.done * progressBarWidth / p.total
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	line := fmt.Sprintf("[%s] %d/%d pairs • %s", bar, p.done, p.total, p.pair)
+	if p.transfer.TotalFiles > 0 || p.transfer.TotalBytes > 0 {
+		line += fmt.Sprintf(": %d/%d files, %s/%s", p.transfer.Files, p.transfer.TotalFiles,
+			formatSize(p.transfer.Bytes), formatSize(p.transfer.TotalBytes))
+	}
+	return line
+}
+
+// syncOutcome is how the sync of one pair ended, for the summary table
+type syncOutcome struct {
+	target   string
+	result   string // e.g. "done", "failed", "skipped"
+	duration time.Duration
+}
+
+// printOutcomes prints a table of how each pair's sync ended
+func printOutcomes(stdout io.Writer, outcomes []syncOutcome) {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PAIR\tRESULT\tTIME")
+	for _, outcome := range outcomes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", outcome.target, outcome.result, outcome.duration.Round(time.Second))
+	}
+	w.Flush()
+}
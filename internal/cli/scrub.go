@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// runScrub implements `cloud-sync scrub`, which compares pairs with their
+// destinations by hash, and installs the agent running it monthly
+func runScrub(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("scrub", stderr)
+	install := fs.Bool("agent", false, "Install the agent running the scrub on the first of every month")
+	off := fs.Bool("off", false, "Remove the monthly agent again")
+	history := fs.Bool("history", false, "List the recorded scrub runs")
+	ack := fs.Bool("ack", false, "Acknowledge the problems found, clearing the dashboard's warning")
+	scheduled := fs.Bool("scheduled", false, "Run as the monthly scrub, skipping pairs whose drive is not connected")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	agent := launchd.NewManager(launchd.CurrentUsername()).Scrub()
+	switch {
+	case *off:
+		if err := agent.Remove(); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintln(stdout, "The monthly integrity scrub no longer runs.")
+		return ExitOK
+	case *install:
+		program, err := os.Executable()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: failed to find the cloud-sync binary: %v\n", err)
+			return ExitFailure
+		}
+		if err := agent.InstallScrubAgent(program); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintf(stdout, "Installed %s.\n", agent.GetPlistPath())
+		fmt.Fprintln(stdout, "Pairs with \"scrub\": true are checked at 4:00 on the first of every month.")
+		return ExitOK
+	}
+
+	backupMgr, err := newBackupManager(stdout, stderr)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	switch {
+	case *history:
+		return printScrubHistory(backupMgr, stdout, stderr)
+	case *ack:
+		count, err := backupMgr.AcknowledgeScrubs()
+		if err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintf(stdout, "Acknowledged %d scrub run(s) with problems.\n", count)
+		return ExitOK
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		if names, err = backupMgr.ScrubPairs(); err != nil {
+			return fail(stderr, err)
+		}
+		if len(names) == 0 {
+			fmt.Fprintln(stderr, "No enabled pair has scrubbing on; name pairs to check, or set \"scrub\": true in sync-config.json.")
+			return ExitFailure
+		}
+	}
+
+	return scrubPairs(backupMgr, names, "Scrubbing", *scheduled, stdout, stderr)
+}
+
+// scrubPairs checks the named pairs, saying what it is doing with verb,
+// and lists what each check found. A scheduled run skips pairs whose drive
+// is not connected.
+func scrubPairs(backupMgr *backup.Manager, names []string, verb string, scheduled bool, stdout, stderr io.Writer) int {
+	var failures []int // Exit codes of the pairs that failed or had problems
+	passed := 0
+	for _, name := range names {
+		fmt.Fprintf(stdout, "%s '%s'...\n", verb, name)
+		runs, err := backupMgr.Scrub(name)
+		var volumeErr *backup.VolumeError
+		if scheduled && errors.As(err, &volumeErr) {
+			fmt.Fprintf(stdout, "  Skipped: %v\n", err)
+			continue
+		}
+		if err != nil {
+			printError(stderr, err)
+			failures = append(failures, exitCode(err))
+			continue
+		}
+		problem := false
+		for _, run := range runs {
+			fmt.Fprintf(stdout, "  %s: %s\n", run.Destination, run.Summary())
+			for _, file := range run.Files {
+				fmt.Fprintf(stdout, "    %s\n", file)
+			}
+			problem = problem || run.Problem()
+		}
+		if problem {
+			failures = append(failures, ExitFailure)
+		} else {
+			passed++
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Fprintln(stdout, "Problems stay flagged on the dashboard until acknowledged with 'cloud-sync scrub --ack'.")
+	}
+	return combinedExitCode(failures, passed)
+}
+
+// printScrubHistory lists the recorded scrub runs, newest first
+func printScrubHistory(backupMgr *backup.Manager, stdout, stderr io.Writer) int {
+	runs, err := backupMgr.ScrubHistory()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if len(runs) == 0 {
+		fmt.Fprintln(stdout, "No scrub runs recorded.")
+		return ExitOK
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tPAIR\tDESTINATION\tRESULT")
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		result := run.Summary()
+		if run.Problem() && !run.Acknowledged {
+			result += " (not acknowledged)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", run.Time.Format("2006-01-02 15:04"), run.Pair, run.Destination, result)
+	}
+	w.Flush()
+	return ExitOK
+}
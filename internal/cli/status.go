@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/cost"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/status"
+)
+
+// runStatus implements `cloud-sync status`
+func runStatus(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("status", stderr)
+	size := fs.Bool("size", false, "Also add up the size of the enabled pairs' folders (can be slow)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	summary := status.Load(config.BackupScheduler(launchd.CurrentUsername()), time.Now())
+	writeStatus(stdout, summary)
+
+	if *size {
+		sizes, err := status.PairSizes()
+		if err != nil {
+			return fail(stderr, err)
+		}
+		var bytes int64
+		for _, size := range sizes {
+			bytes += size
+		}
+		fmt.Fprintf(stdout, "Protected size: %s\n", formatSize(bytes))
+
+		// The estimate only adds to the size, so it cannot fail the command
+		if estimate, err := status.EstimateCost(sizes, time.Now()); err == nil {
+			writeCost(stdout, estimate)
+		}
+	}
+	return ExitOK
+}
+
+// writeCost prints the estimated monthly bill of each remote and in total
+func writeCost(w io.Writer, estimate *cost.Estimate) {
+	for _, line := range estimate.Lines {
+		fmt.Fprintf(w, "Estimated cost of %s (%s): %s%.2f/month for %s stored, %s downloaded\n",
+			line.Remote, line.Plan.Name, line.Plan.Currency, line.Cost,
+			formatSize(line.Usage.StoredBytes), formatSize(line.Usage.EgressBytes))
+	}
+	if total := estimate.Total(); total != "" {
+		fmt.Fprintf(w, "Estimated monthly cost: %s\n", total)
+	}
+	if len(estimate.Unpriced) > 0 {
+		fmt.Fprintf(w, "No pricing for: %s\n", strings.Join(estimate.Unpriced, ", "))
+	}
+}
+
+// writeStatus prints a status summary one fact per line, without colors or
+// symbols, so it reads well in logs and screen readers
+func writeStatus(w io.Writer, summary status.Summary) {
+	fmt.Fprintf(w, "Sync pairs: %d (%d enabled)\n", summary.Pairs, summary.EnabledPairs)
+
+	if summary.LastRun != nil {
+		result := "succeeded"
+		if !summary.LastRun.Success {
+			result = "failed"
+		}
+		fmt.Fprintf(w, "Last run: %s, %s\n", summary.LastRun.EndTime.Format("Mon Jan 2 15:04"), result)
+	} else {
+		fmt.Fprintln(w, "Last run: never")
+	}
+
+	if summary.Schedule != "" {
+		fmt.Fprintf(w, "Schedule: %s\n", summary.Schedule)
+	}
+	if !summary.NextRun.IsZero() {
+		fmt.Fprintf(w, "Next run: %s\n", summary.NextRun.Format("Mon Jan 2 15:04"))
+	} else {
+		fmt.Fprintln(w, "Next run: not scheduled")
+	}
+
+	for _, warning := range summary.Warnings {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+	for _, issue := range summary.ScrubIssues {
+		fmt.Fprintf(w, "Integrity: '%s' at %s: %s (acknowledge with 'cloud-sync scrub --ack')\n", issue.Pair, issue.Destination, issue.Summary())
+	}
+}
+
+// formatSize formats a byte count with a binary unit
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,577 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/archive"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/installer"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/network"
+	"github.com/andreisuslov/cloud-sync/internal/power"
+	"github.com/andreisuslov/cloud-sync/internal/queue"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/volume"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+	"golang.org/x/term"
+)
+
+// runPairs implements `cloud-sync pairs`, and `pairs export` and `pairs
+// import`
+func runPairs(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "export":
+			return runPairsExport(args[1:], stdout, stderr)
+		case "import":
+			return runPairsImport(args[1:], stdout, stderr)
+		}
+	}
+
+	fs := newFlagSet("pairs", stderr)
+	tag := fs.String("tag", "", "Only list the pairs with this tag or group")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	pairs, err := syncConfigMgr.ListSyncPairs()
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	if len(pairs) == 0 {
+		fmt.Fprintln(stdout, "No sync pairs configured.")
+		return ExitOK
+	}
+	if *tag != "" {
+		if pairs = taggedPairs(pairs, *tag); len(pairs) == 0 {
+			fmt.Fprintf(stdout, "No sync pairs are tagged '%s'.\n", *tag)
+			return ExitOK
+		}
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDIRECTION\tMODE\tENABLED\tLOCAL\tDESTINATION\tTAGS")
+	for _, pair := range pairs {
+		enabled := "no"
+		if pair.Enabled {
+			enabled = "yes"
+		}
+		direction, mode := pair.Direction, pair.TransferMode()
+		if pair.IsMonitor() {
+			direction, mode = "monitor", "check"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			pair.Name, direction, mode, enabled, pair.LocalPath, pair.Target(), strings.Join(pair.Tags, ","))
+	}
+	w.Flush()
+	return ExitOK
+}
+
+// confirmFunc asks a yes or no question and reports whether the answer was yes
+type confirmFunc func(question string) bool
+
+// runSync implements `cloud-sync sync`
+func runSync(args []string, stdout, stderr io.Writer) int {
+	return syncPairs(args, stdout, stderr, terminalConfirm(stdout))
+}
+
+// terminalConfirm asks on the terminal. Without one, e.g. when run by a
+// scheduler, or with --quiet, every answer is no.
+func terminalConfirm(stdout io.Writer) confirmFunc {
+	return func(question string) bool {
+		if stdout == io.Discard || !term.IsTerminal(int(os.Stdin.Fd())) {
+			return false
+		}
+		answer, ok := prompt(bufio.NewScanner(os.Stdin), stdout, question)
+		return ok && isYes(answer)
+	}
+}
+
+// isYes reports whether an answer to a confirmation means yes
+func isYes(answer string) bool {
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// archiveReportPercent is how much of a pair's folder is compressed
+// between the lines `cloud-sync sync` prints about it
+const archiveReportPercent = 10
+
+// indexReportEvery is how many files a pair's index pass lists between the
+// lines `cloud-sync sync` prints about it
+const indexReportEvery = 100000
+
+// syncPairs runs `cloud-sync sync`, asking confirm before a sync that would
+// delete more files than its pair's limit allows
+func syncPairs(args []string, stdout, stderr io.Writer, confirm confirmFunc) int {
+	fs := newFlagSet("sync", stderr)
+	all := fs.Bool("all", false, "Sync every enabled pair")
+	tag := fs.String("tag", "", "Sync the enabled pairs with this tag or group")
+	mounted := fs.Bool("mounted", false, "Sync the enabled pairs set to sync on mount whose drives are connected")
+	deferred := fs.Bool("deferred", false, "Sync the enabled pairs whose scheduled run waits for power")
+	scheduled := fs.Bool("scheduled", false, "Run as a scheduled sync, deferring pairs while the battery is low")
+	dryRun := fs.Bool("dry-run", false, "Show what would be transferred without changing anything")
+	allowDeletes := fs.Bool("allow-deletes", false, "Sync even when more files would be deleted than a pair's limit allows")
+	subpath := fs.String("path", "", "Only sync this folder of the pair, relative to its local folder")
+	showProgress := fs.Bool("progress", false, "Print progress lines when not on a terminal, where a status line is redrawn instead")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+	selectors := 0
+	for _, set := range []bool{*all, *tag != "", *mounted, *deferred, fs.NArg() > 0} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 || *subpath != "" && fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "Usage: cloud-sync sync [--dry-run] [--allow-deletes] [--scheduled] (--all | --tag TAG | --mounted | --deferred | <pair>... | --path FOLDER <pair>)")
+		return ExitConfig
+	}
+
+	manager, err := newBackupManager(stdout, stderr)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	// Output goes through the progress display, which keeps its status
+	// line below it
+	progress := newSyncProgress(stdout, *showProgress)
+	stdout = progress
+	setUp := func(manager *backup.Manager) {
+		if progress.Shown() {
+			manager.SetTransferProgress(progress.Transfer)
+		}
+		manager.SetAnomalyWarning(func(pair, warning string) {
+			fmt.Fprintf(stdout, "%s: warning: unusual run, %s\n", pair, warning)
+		})
+		manager.SetBudgetWarning(func(pair, warning string) {
+			fmt.Fprintf(stdout, "%s: warning: %s\n", pair, warning)
+		})
+		manager.SetIndexProgress(func(pair string, files int, bytes int64) {
+			if files%indexReportEvery == 0 {
+				fmt.Fprintf(stdout, "%s: indexed %d files so far\n", pair, files)
+			}
+		})
+		reported := make(map[string]int64)
+		manager.SetArchiveProgress(func(pair string, done, total int64) {
+			if total == 0 {
+				return
+			}
+			if percent := done * 100 / total / archiveReportPercent * archiveReportPercent; percent > reported[pair] {
+				reported[pair] = percent
+				fmt.Fprintf(stdout, "%s: compressed %d%% (%s of %s)\n", pair, percent, formatSize(done), formatSize(total))
+			}
+		})
+	}
+	setUp(manager)
+
+	names := fs.Args()
+	if *deferred {
+		if names, err = deferredPairs(manager); err != nil {
+			return fail(stderr, err)
+		}
+		if len(names) == 0 {
+			fmt.Fprintln(stdout, "No pairs are waiting for power.")
+			return ExitOK
+		}
+	}
+	if *all || *mounted || *tag != "" {
+		pairs, err := manager.ListSyncPairs()
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if *tag != "" {
+			if pairs = taggedPairs(pairs, *tag); len(pairs) == 0 {
+				fmt.Fprintf(stderr, "Error: no sync pairs are tagged '%s'\n", *tag)
+				return ExitFailure
+			}
+		}
+		for _, pair := range pairs {
+			// Monitor pairs are only checked, with 'cloud-sync monitor'
+			if pair.Enabled && !pair.IsMonitor() && (!*mounted || pair.SyncOnMount && drivesConnected(pair)) {
+				names = append(names, pair.Name)
+			}
+		}
+		if len(names) == 0 && *mounted {
+			fmt.Fprintln(stdout, "No pairs to sync on mount have their drive connected.")
+			return ExitOK
+		}
+		if len(names) == 0 && *tag != "" {
+			fmt.Fprintf(stdout, "No enabled sync pairs are tagged '%s'.\n", *tag)
+			return ExitOK
+		}
+		if len(names) == 0 {
+			fmt.Fprintln(stdout, "No enabled sync pairs.")
+			return ExitOK
+		}
+	}
+
+	trigger, priority := queue.TriggerManual, queue.High
+	switch {
+	case *mounted:
+		trigger, priority = queue.TriggerMount, queue.Normal
+	case *deferred:
+		trigger, priority = queue.TriggerPower, queue.Normal
+	case *scheduled:
+		trigger, priority = queue.TriggerScheduled, queue.Low
+	}
+	jobs := make([]queue.Job, 0, len(names))
+	for _, name := range names {
+		jobs = append(jobs, queue.Job{
+			Pair:         name,
+			Subpath:      *subpath,
+			Priority:     priority,
+			Trigger:      trigger,
+			AllowDeletes: *allowDeletes,
+			Scheduled:    *scheduled,
+		})
+	}
+
+	skipped, ran := 0, 0
+	var failures []int // Exit codes of the failed jobs
+	var outcomes []syncOutcome
+	upgradeDeclined := false
+	// syncJob runs one job, with left more waiting after it; own is false
+	// for the jobs other triggers queued, which are not asked about on
+	// this terminal
+	syncJob := func(job queue.Job, own bool, left int) {
+		ran++
+		progress.Start(job.Target(), ran-1, ran+left)
+		name := job.Pair
+		switch {
+		case job.Subpath != "":
+			fmt.Fprintf(stdout, "Syncing %s of %s...\n", job.Subpath, name)
+		case !own:
+			fmt.Fprintf(stdout, "Syncing %s (queued by %s)...\n", name, job.Trigger)
+		default:
+			fmt.Fprintf(stdout, "Syncing %s...\n", name)
+		}
+		ask := func(question string) bool {
+			return own && confirm(question)
+		}
+		start := time.Now()
+		result := "failed"
+		defer func() {
+			outcomes = append(outcomes, syncOutcome{target: job.Target(), result: result, duration: time.Since(start)})
+		}()
+		manager.SetScheduled(job.Scheduled)
+		manager.AllowDeletes(job.AllowDeletes)
+		manager.AllowOverBudget(false)
+		err := manager.SyncSubpath(name, job.Subpath, false, *dryRun)
+
+		var limitErr *backup.DeleteLimitError
+		if errors.As(err, &limitErr) {
+			fmt.Fprintf(stdout, "%s: %v\n", name, limitErr)
+			if ask("Delete them anyway? [y/N] ") {
+				manager.AllowDeletes(true)
+				err = manager.SyncSubpath(name, job.Subpath, false, *dryRun)
+			} else {
+				err = fmt.Errorf("stopped to protect %s; run with --allow-deletes to sync anyway", limitErr.Destination)
+			}
+		}
+		var budgetErr *backup.BudgetError
+		if errors.As(err, &budgetErr) {
+			fmt.Fprintf(stdout, "%s: %v\n", name, budgetErr)
+			if ask("Sync anyway? [y/N] ") {
+				manager.AllowOverBudget(true)
+				err = manager.SyncSubpath(name, job.Subpath, false, *dryRun)
+			} else {
+				err = fmt.Errorf("stopped at the size budget of %s; raise max_remote_gb or set budget_warn_only to sync anyway", budgetErr.Destination)
+			}
+		}
+
+		var volumeErr *backup.VolumeError
+		if errors.As(err, &volumeErr) {
+			fmt.Fprintf(stdout, "%s: skipped: %s is not connected\n", name, volumeErr.Volume)
+			result = "skipped"
+			skipped++
+			return
+		}
+		var networkErr *backup.NetworkError
+		if errors.As(err, &networkErr) {
+			fmt.Fprintf(stdout, "%s: skipped: %v\n", name, networkErr.Reason)
+			result = "skipped"
+			skipped++
+			return
+		}
+		var powerErr *backup.PowerError
+		if errors.As(err, &powerErr) {
+			fmt.Fprintf(stdout, "%s: deferred: on battery at %d%%, waiting for power\n", name, powerErr.Percent)
+			result = "deferred"
+			skipped++
+			return
+		}
+		if errors.Is(err, rclone.ErrCancelled) {
+			fmt.Fprintf(stdout, "%s: cancelled\n", name)
+			result = "cancelled"
+			skipped++
+			return
+		}
+
+		var versionErr *rclone.VersionError
+		if errors.As(err, &versionErr) && !upgradeDeclined {
+			fmt.Fprintf(stdout, "%s: %v\n", name, versionErr)
+			if ask("Upgrade rclone with 'brew upgrade rclone' now? [y/N] ") {
+				output, upgradeErr := installer.NewInstaller().UpdateRcloneWithOutput()
+				fmt.Fprint(stdout, output)
+				if upgradeErr != nil {
+					err = fmt.Errorf("failed to upgrade rclone: %w", upgradeErr)
+				} else if upgraded, newErr := newBackupManager(stdout, stderr); newErr != nil {
+					err = newErr
+				} else {
+					// A new manager reads the upgraded version again
+					manager = upgraded
+					setUp(manager)
+					manager.SetScheduled(job.Scheduled)
+					manager.AllowDeletes(job.AllowDeletes)
+					err = manager.SyncSubpath(name, job.Subpath, false, *dryRun)
+				}
+			} else {
+				upgradeDeclined = true
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(stdout, "%s: failed: %v\n", name, err)
+			if hint := errkind.Hint(err); hint != "" {
+				fmt.Fprintf(stdout, "  Hint: %s\n", hint)
+			}
+			failures = append(failures, exitCode(err))
+			return
+		}
+		fmt.Fprintf(stdout, "%s: done in %s\n", name, time.Since(start).Round(time.Second))
+		result = "done"
+	}
+
+	if *dryRun {
+		// Dry runs change nothing, so they run at once instead of queueing
+		for i, job := range jobs {
+			syncJob(job, true, len(jobs)-i-1)
+		}
+	} else if code := runQueue(manager.Queue(), jobs, syncJob, stdout, stderr); code >= 0 {
+		progress.Finish()
+		return code
+	}
+	progress.Finish()
+
+	if len(outcomes) > 1 {
+		printOutcomes(stdout, outcomes)
+	}
+
+	synced := ran - len(failures) - skipped
+	if skipped > 0 {
+		fmt.Fprintf(stdout, "Synced %d of %d pair(s), %d skipped.\n", synced, ran, skipped)
+	} else {
+		fmt.Fprintf(stdout, "Synced %d of %d pair(s).\n", synced, ran)
+	}
+	return combinedExitCode(failures, synced)
+}
+
+// cancelPollInterval is how often a running job checks whether it was
+// cancelled from the queue view
+const cancelPollInterval = time.Second
+
+// runQueue adds jobs to the sync queue and runs the queue until it is
+// empty, including the jobs other triggers queued meanwhile. When another
+// process is already running the queue, it leaves the jobs to it and
+// returns ExitOK; otherwise it returns -1 once the queue is empty, or the
+// exit code of the error if the queue cannot be read.
+func runQueue(syncQueue *queue.Queue, jobs []queue.Job, syncJob func(job queue.Job, own bool, left int), stdout, stderr io.Writer) int {
+	own := make(map[int]bool, len(jobs))
+	for _, job := range jobs {
+		queued, added, err := syncQueue.Add(job)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if !added {
+			fmt.Fprintf(stdout, "%s: already queued\n", queued.Target())
+		}
+		own[queued.ID] = true
+	}
+
+	for ran := 0; ; ran++ {
+		job, ok, err := syncQueue.Claim(os.Getpid())
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if !ok {
+			if ran > 0 {
+				return -1
+			}
+			return reportQueued(syncQueue, own, stdout, stderr)
+		}
+
+		// The claimed job is the only running one
+		queued, err := syncQueue.Jobs()
+		if err != nil {
+			return fail(stderr, err)
+		}
+
+		done := make(chan struct{})
+		go watchCancel(syncQueue, job.ID, done)
+		syncJob(job, own[job.ID], len(queued)-1)
+		close(done)
+		if err := syncQueue.Finish(job.ID); err != nil {
+			return fail(stderr, err)
+		}
+	}
+}
+
+// reportQueued says where this command's jobs wait while another process
+// runs the queue
+func reportQueued(syncQueue *queue.Queue, own map[int]bool, stdout, stderr io.Writer) int {
+	jobs, err := syncQueue.Jobs()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	running := ""
+	for i, job := range jobs {
+		if job.Running() {
+			running = job.Target()
+			continue
+		}
+		if own[job.ID] {
+			fmt.Fprintf(stdout, "%s: queued (position %d)\n", job.Target(), i)
+		}
+	}
+	if running == "" {
+		fmt.Fprintln(stdout, "Nothing left to sync.")
+		return ExitOK
+	}
+	fmt.Fprintf(stdout, "Queued behind the running sync of %s, which runs them next.\n", running)
+	return ExitOK
+}
+
+// watchCancel stops the rclone processes of a running job once it is
+// cancelled from the queue view, until done is closed
+func watchCancel(syncQueue *queue.Queue, id int, done <-chan struct{}) {
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if cancelling, _ := syncQueue.Cancelling(id); cancelling {
+				rclone.DefaultRuns.CancelAll(rclone.CancelGrace)
+			}
+		}
+	}
+}
+
+// taggedPairs returns the pairs with tag as a tag or as their group
+func taggedPairs(pairs []syncconfig.SyncPair, tag string) []syncconfig.SyncPair {
+	var tagged []syncconfig.SyncPair
+	for _, pair := range pairs {
+		if pair.HasTag(tag) {
+			tagged = append(tagged, pair)
+		}
+	}
+	return tagged
+}
+
+// deferredPairs returns the enabled pairs waiting for power
+func deferredPairs(manager *backup.Manager) ([]string, error) {
+	deferred, err := manager.DeferredPairs()
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := manager.ListSyncPairs()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pair := range pairs {
+		if pair.Enabled && slices.Contains(deferred, pair.Name) {
+			names = append(names, pair.Name)
+		}
+	}
+	return names, nil
+}
+
+// drivesConnected reports whether every drive a pair uses is mounted
+func drivesConnected(pair syncconfig.SyncPair) bool {
+	for _, v := range pair.Volumes() {
+		if !volume.Mounted(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// newBackupManager creates a backup manager from the saved configuration
+// whose transfers print to stdout and stderr
+func newBackupManager(stdout, stderr io.Writer) (*backup.Manager, error) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return nil, configError(fmt.Errorf("failed to load config: %w", err))
+	}
+
+	return backup.NewManager(&backup.Config{
+		Username:     launchd.CurrentUsername(),
+		HomeDir:      appConfig.HomeDir,
+		SourceRemote: appConfig.SyncConfig.SourceRemote,
+		SourceBucket: appConfig.SyncConfig.SourceBucket,
+		DestRemote:   appConfig.SyncConfig.DestRemote,
+		DestBucket:   appConfig.SyncConfig.DestBucket,
+		RclonePath:   rclonePath(appConfig.RclonePath),
+		ZstdPath:     zstdPath(),
+		Network:      network.NewChecker(),
+		Power:        power.NewChecker(),
+		LogDir:       appConfig.LogDir,
+		BinDir:       appConfig.BinDir,
+		RCAddr:       appConfig.LaunchAgent.RCAddress(),
+		ReportFormat: appConfig.Reports.Format,
+		ReportsKept:  appConfig.Reports.Keep,
+		Stdout:       stdout,
+		Stderr:       stderr,
+	})
+}
+
+// zstdPath returns the zstd binary archive-mode pairs compress with, or ""
+// to look for it in PATH when the sync runs and report it missing then
+func zstdPath() string {
+	path, _ := archive.FindZstd()
+	return path
+}
+
+// rclonePath returns the configured rclone binary if it exists, and
+// otherwise the one found in PATH
+func rclonePath(configured string) string {
+	if configured != "" {
+		if _, err := os.Stat(configured); err == nil {
+			return configured
+		}
+	}
+	if path, err := installer.NewInstaller().GetRclonePath(); err == nil {
+		return path
+	}
+	return "rclone"
+}
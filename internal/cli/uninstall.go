@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/uninstall"
+)
+
+// runUninstall implements `cloud-sync uninstall`
+func runUninstall(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("uninstall", stderr)
+	dryRun := fs.Bool("dry-run", false, "Show what would be removed without removing it")
+	removeLogs := fs.Bool("logs", false, "Also remove the backup log")
+	removeConfig := fs.Bool("config", false, "Also remove the cloud-sync configuration")
+	all := fs.Bool("all", false, "Remove logs and configuration too")
+	yes := fs.Bool("yes", false, "Remove the items; without it only a preview is shown")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitConfig
+	}
+
+	opts, err := uninstall.DefaultOptions(config.BackupScheduler(launchd.CurrentUsername()))
+	if err != nil {
+		return fail(stderr, err)
+	}
+	opts.RemoveLogs = *removeLogs || *all
+	opts.RemoveConfig = *removeConfig || *all
+
+	// Without --yes only preview, so nothing is removed by accident
+	opts.DryRun = *dryRun || !*yes
+
+	result, err := uninstall.Run(opts)
+	fmt.Fprintln(stdout, result.Summary())
+
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if !*dryRun && !*yes && len(result.Items) > 0 {
+		fmt.Fprintln(stdout)
+		fmt.Fprintln(stdout, "Nothing was removed. Re-run with --yes to uninstall.")
+	}
+	return ExitOK
+}
@@ -0,0 +1,400 @@
+package config
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/fileformat"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// Names of the files inside a config bundle
+const (
+	BundleConfigFile     = "config.json"
+	BundleSyncConfigFile = "sync-config.json"
+	BundleRcloneFile     = "rclone.conf"
+	bundleManifestFile   = "manifest.json"
+)
+
+// bundleMagic prefixes encrypted bundles so import can tell them apart
+const bundleMagic = "CLOUDSYNC-ENC1\n"
+
+const (
+	bundleSaltSize     = 16
+	bundleKeyRounds    = 600000
+	bundleMaxEntrySize = 16 << 20
+)
+
+// ExportOptions controls what a config bundle contains
+type ExportOptions struct {
+	// IncludeCredentials adds rclone.conf and keeps remote keys in
+	// config.json. The bundle is then encrypted with Passphrase.
+	IncludeCredentials bool
+	Passphrase         string
+}
+
+// BundleManifest describes a config bundle
+type BundleManifest struct {
+	Version     string    `json:"version"`
+	Created     time.Time `json:"created"`
+	HomeDir     string    `json:"home_dir"` // Home directory the bundle was exported from
+	Files       []string  `json:"files"`
+	Credentials bool      `json:"credentials"`
+}
+
+// ImportOptions controls how a config bundle is restored
+type ImportOptions struct {
+	Passphrase string
+	Overwrite  bool // Replace an existing configuration
+}
+
+// SyncConfigPath returns the path of sync-config.json, which lives next to
+// config.json. It is in whichever format exists, and one that does not
+// exist yet is created in the format of config.json.
+func (m *Manager) SyncConfigPath() string {
+	return fileformat.Find(filepath.Dir(m.configPath), "sync-config", fileformat.Of(m.configPath))
+}
+
+// Export writes the configuration as a gzipped tar bundle. Credentials are
+// only included when requested, and then the whole bundle is encrypted.
+func (m *Manager) Export(w io.Writer, opts ExportOptions) (*BundleManifest, error) {
+	if opts.IncludeCredentials && opts.Passphrase == "" {
+		return nil, fmt.Errorf("a passphrase is required to export credentials")
+	}
+
+	appConfig, err := m.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.IncludeCredentials {
+		appConfig = appConfig.WithoutCredentials()
+	}
+
+	files := make(map[string][]byte)
+	if files[BundleConfigFile], err = json.MarshalIndent(appConfig, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// Bundles hold JSON whichever format the files are kept in
+	if pairs := syncconfig.NewManager(m.SyncConfigPath()); pairs.ConfigExists() {
+		syncConfig, err := pairs.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sync config: %w", err)
+		}
+		if files[BundleSyncConfigFile], err = json.MarshalIndent(syncConfig, "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to marshal sync config: %w", err)
+		}
+	}
+
+	if opts.IncludeCredentials {
+		if data, err := os.ReadFile(appConfig.RcloneConfig); err == nil {
+			files[BundleRcloneFile] = data
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read rclone config: %w", err)
+		}
+	}
+
+	manifest := &BundleManifest{
+		Version:     "1",
+		Created:     time.Now(),
+		HomeDir:     appConfig.HomeDir,
+		Credentials: opts.IncludeCredentials,
+	}
+	for _, name := range []string{BundleConfigFile, BundleSyncConfigFile, BundleRcloneFile} {
+		if _, ok := files[name]; ok {
+			manifest.Files = append(manifest.Files, name)
+		}
+	}
+	if files[bundleManifestFile], err = json.MarshalIndent(manifest, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	archive, err := writeBundle(files, append([]string{bundleManifestFile}, manifest.Files...))
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Passphrase != "" {
+		if archive, err = encryptBundle(archive, opts.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := w.Write(archive); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return manifest, nil
+}
+
+// Import restores a bundle written by Export. Paths under the exporting
+// user's home directory are moved to this user's home, so a bundle can be
+// taken to a new Mac.
+func (m *Manager) Import(r io.Reader, opts ImportOptions) (*BundleManifest, error) {
+	if m.ConfigExists() && !opts.Overwrite {
+		return nil, fmt.Errorf("configuration already exists at %s", m.configPath)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	if bytes.HasPrefix(data, []byte(bundleMagic)) {
+		if opts.Passphrase == "" {
+			return nil, fmt.Errorf("bundle is encrypted, a passphrase is required")
+		}
+		if data, err = decryptBundle(data, opts.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := readBundle(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(files[bundleManifestFile], &manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+
+	var appConfig AppConfig
+	if err := json.Unmarshal(files[BundleConfigFile], &appConfig); err != nil {
+		return nil, fmt.Errorf("invalid config in bundle: %w", err)
+	}
+	var syncConfig *syncconfig.Config
+	if syncData, ok := files[BundleSyncConfigFile]; ok {
+		syncConfig = &syncconfig.Config{}
+		if err := json.Unmarshal(syncData, syncConfig); err != nil {
+			return nil, fmt.Errorf("invalid sync config in bundle: %w", err)
+		}
+	}
+
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		rebaseHome(&appConfig, syncConfig, manifest.HomeDir, homeDir)
+	}
+
+	// Wait for changes in progress elsewhere rather than racing them
+	unlock, err := m.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := m.Save(&appConfig); err != nil {
+		return nil, err
+	}
+
+	if syncConfig != nil {
+		pairs := syncconfig.NewManager(m.SyncConfigPath())
+		unlockPairs, err := pairs.Lock()
+		if err != nil {
+			return nil, err
+		}
+		defer unlockPairs()
+		if err := pairs.Save(syncConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if rcloneData, ok := files[BundleRcloneFile]; ok {
+		if err := os.MkdirAll(filepath.Dir(appConfig.RcloneConfig), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create rclone config directory: %w", err)
+		}
+		if err := os.WriteFile(appConfig.RcloneConfig, rcloneData, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write rclone config: %w", err)
+		}
+	}
+
+	return &manifest, nil
+}
+
+// rebaseHome moves paths under oldHome to newHome
+func rebaseHome(c *AppConfig, syncConfig *syncconfig.Config, oldHome, newHome string) {
+	if oldHome == "" || oldHome == newHome {
+		return
+	}
+	rebase := func(path string) string {
+		if path == oldHome || strings.HasPrefix(path, oldHome+string(filepath.Separator)) {
+			return newHome + path[len(oldHome):]
+		}
+		return path
+	}
+	c.HomeDir = rebase(c.HomeDir)
+	c.BinDir = rebase(c.BinDir)
+	c.LogDir = rebase(c.LogDir)
+	c.RcloneConfig = rebase(c.RcloneConfig)
+	c.LaunchAgent.ScriptPath = rebase(c.LaunchAgent.ScriptPath)
+
+	if syncConfig != nil {
+		for i := range syncConfig.SyncPairs {
+			syncConfig.SyncPairs[i].LocalPath = rebase(syncConfig.SyncPairs[i].LocalPath)
+			syncConfig.SyncPairs[i].TargetPath = rebase(syncConfig.SyncPairs[i].TargetPath)
+		}
+	}
+}
+
+// writeBundle writes the named files, in order, to a gzipped tar
+func writeBundle(files map[string][]byte, order []string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range order {
+		data := files[name]
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write bundle entry %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to write bundle entry %s: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// readBundle reads the known files from a gzipped tar, ignoring anything else
+func readBundle(data []byte) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("not a cloud-sync config bundle: %w", err)
+	}
+	defer gz.Close()
+
+	known := map[string]bool{
+		bundleManifestFile:   true,
+		BundleConfigFile:     true,
+		BundleSyncConfigFile: true,
+		BundleRcloneFile:     true,
+	}
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if !known[header.Name] || header.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Read one byte past the limit to tell a file of exactly the
+		// limit from a larger one
+		content, err := io.ReadAll(io.LimitReader(tr, bundleMaxEntrySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle entry %s: %w", header.Name, err)
+		}
+		if len(content) > bundleMaxEntrySize {
+			return nil, fmt.Errorf("bundle entry %s is too large: over %d MiB", header.Name, bundleMaxEntrySize>>20)
+		}
+		files[header.Name] = content
+	}
+
+	if _, ok := files[bundleManifestFile]; !ok {
+		return nil, fmt.Errorf("not a cloud-sync config bundle: missing %s", bundleManifestFile)
+	}
+	if _, ok := files[BundleConfigFile]; !ok {
+		return nil, fmt.Errorf("bundle does not contain %s", BundleConfigFile)
+	}
+	return files, nil
+}
+
+// bundleKey derives the AES-256 key for a passphrase and salt
+func bundleKey(passphrase string, salt []byte) ([]byte, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, bundleKeyRounds, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+// encryptBundle seals data with AES-GCM under a passphrase-derived key
+func encryptBundle(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, bundleSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := []byte(bundleMagic)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, []byte(bundleMagic)), nil
+}
+
+// decryptBundle opens data sealed by encryptBundle
+func decryptBundle(data []byte, passphrase string) ([]byte, error) {
+	data = data[len(bundleMagic):]
+	if len(data) < bundleSaltSize {
+		return nil, fmt.Errorf("encrypted bundle is truncated")
+	}
+	salt, data := data[:bundleSaltSize], data[bundleSaltSize:]
+
+	gcm, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted bundle is truncated")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plain, err := gcm.Open(nil, nonce, sealed, []byte(bundleMagic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt bundle: wrong passphrase or corrupted file")
+	}
+	return plain, nil
+}
+
+// bundleCipher returns the AES-GCM cipher for a passphrase and salt
+func bundleCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := bundleKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
@@ -2,21 +2,396 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/fileformat"
+	"github.com/andreisuslov/cloud-sync/internal/filewatch"
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
 )
 
 // RemoteConfig represents rclone remote configuration
 type RemoteConfig struct {
 	Name             string `json:"name"`
-	Type             string `json:"type"`              // "b2" or "s3"
+	Type             string `json:"type"`              // "b2", "s3", "sftp", "webdav", "azureblob", TypeGCS, TypeLocal, TypeAlias or TypeUnion
 	Provider         string `json:"provider"`          // "Backblaze" or "Scaleway"
 	AccountID        string `json:"account_id"`        // B2 account ID or S3 access key
 	ApplicationKey   string `json:"application_key"`   // B2 app key or S3 secret key
 	Region           string `json:"region,omitempty"`  // For S3
 	Endpoint         string `json:"endpoint,omitempty"` // For S3
 	Bucket           string `json:"bucket"`            // Default bucket for this remote
+
+	// Self-hosted S3 servers such as MinIO (provider "Minio"). Buckets are
+	// addressed by path unless VirtualHostStyle is set, and SkipTLSVerify
+	// accepts self-signed certificates.
+	VirtualHostStyle bool `json:"virtual_host_style,omitempty"`
+	SkipTLSVerify    bool `json:"skip_tls_verify,omitempty"`
+
+	// SFTP servers (type "sftp"). The server is logged into with Password
+	// or the private key at KeyFile; with KnownHostsFile set, its host key
+	// must be listed there. A leading ~ in the files is the home directory.
+	Host           string `json:"host,omitempty"`
+	Port           int    `json:"port,omitempty"` // 22 when unset
+	User           string `json:"user,omitempty"`
+	Password       string `json:"password,omitempty"`
+	KeyFile        string `json:"key_file,omitempty"`
+	KnownHostsFile string `json:"known_hosts_file,omitempty"`
+
+	// WebDAV servers (type "webdav"), such as Nextcloud and ownCloud. User
+	// and Password log in; Vendor is one of WebDAVVendors and turns on
+	// rclone's support for the server's extensions, e.g. checksums.
+	URL    string `json:"url,omitempty"`
+	Vendor string `json:"vendor,omitempty"`
+
+	// Google Cloud Storage (type TypeGCS). ServiceAccountFile is the JSON
+	// key of a service account; ProjectNumber is only needed to list and
+	// create buckets. BucketPolicyOnly is for buckets with uniform
+	// bucket-level access, which reject per-object ACLs.
+	ServiceAccountFile string `json:"service_account_file,omitempty"`
+	ProjectNumber      string `json:"project_number,omitempty"`
+	BucketPolicyOnly   bool   `json:"bucket_policy_only,omitempty"`
+
+	// Azure Blob Storage (type "azureblob") logs in with the storage
+	// account's name and key in AccountID and ApplicationKey, or with a SAS
+	// URL for the account or one container. EndpointSuffix selects a
+	// sovereign cloud, e.g. core.chinacloudapi.cn.
+	SASURL         string `json:"sas_url,omitempty"`
+	EndpointSuffix string `json:"endpoint_suffix,omitempty"`
+
+	// Aliases (type TypeAlias) name a folder, such as a NAS share mounted
+	// under /Volumes, or a path on another remote ("remote:path"). Pairs on
+	// the alias name paths below Target.
+	Target string `json:"target,omitempty"`
+
+	// Unions (type TypeUnion) use several remotes as one, e.g. the free
+	// tiers of two providers. Upstreams are "remote:path" or folders, each
+	// with an optional :ro, :nc or :writeback suffix; Policy is the
+	// create_policy that picks the upstream new files go to.
+	Upstreams []string `json:"upstreams,omitempty"`
+	Policy    string   `json:"policy,omitempty"`
+
+	// Label, Icon and Color show the remote in the TUI under a friendly
+	// name, e.g. a green "📷 Photos" for a remote named b2, without
+	// renaming its section in rclone.conf. See label.go.
+	Label string `json:"label,omitempty"`
+	Icon  string `json:"icon,omitempty"`
+	Color string `json:"color,omitempty"`
+
+	// Tuning changes how large files are uploaded, e.g. bigger chunks
+	// for multi-GB videos (b2 and s3 only)
+	Tuning *UploadTuning `json:"tuning,omitempty"`
+
+	// Restore is how restores from the remote download, as last chosen
+	// in the restore flow
+	Restore *rclone.RestoreTuning `json:"restore,omitempty"`
+}
+
+// ProviderMinio is the rclone provider of MinIO and other self-hosted S3
+// servers
+const ProviderMinio = "Minio"
+
+// IsSelfHosted reports whether the remote is a self-hosted S3 server
+func (r RemoteConfig) IsSelfHosted() bool {
+	return r.Type == "s3" && r.Provider == ProviderMinio
+}
+
+// TypeGCS is the rclone type of Google Cloud Storage remotes
+const TypeGCS = "google cloud storage"
+
+// IsGCS reports whether the remote is on Google Cloud Storage
+func (r RemoteConfig) IsGCS() bool {
+	return r.Type == TypeGCS
+}
+
+// TypeLocal is the rclone type of remotes that are a folder on this Mac,
+// such as the one the guided tour syncs to. They need no options.
+const TypeLocal = "local"
+
+// IsLocal reports whether the remote is this Mac's disks. Pairs on a
+// local remote name full paths, e.g. /Volumes/NAS/Backups.
+func (r RemoteConfig) IsLocal() bool {
+	return r.Type == TypeLocal
+}
+
+// TypeAlias is the rclone type of remotes that stand for a folder or a
+// path on another remote
+const TypeAlias = "alias"
+
+// IsAlias reports whether the remote is an alias of Target
+func (r RemoteConfig) IsAlias() bool {
+	return r.Type == TypeAlias
+}
+
+// TypeUnion is the rclone type of remotes that combine other remotes
+const TypeUnion = "union"
+
+// IsUnion reports whether the remote combines Upstreams
+func (r RemoteConfig) IsUnion() bool {
+	return r.Type == TypeUnion
+}
+
+// UnionPolicies are the policies rclone's union can pick the upstream of a
+// new file with, e.g. ff for the first upstream listed or mfs for the one
+// with the most free space. Those starting with "ep" only pick upstreams
+// where the file's folder already exists.
+var UnionPolicies = []string{"epmfs", "epff", "eplfs", "eplus", "eplno", "eprand", "epall", "ff", "mfs", "lfs", "lus", "lno", "rand", "all", "newest"}
+
+// unionModes are the suffixes an upstream can end in: read-only, no
+// create, and a cache that is written back to the other upstreams
+var unionModes = []string{":ro", ":nc", ":writeback"}
+
+// ValidateUpstream checks an upstream of a union: a folder or a path on a
+// remote, with an optional mode suffix
+func ValidateUpstream(upstream string) error {
+	for _, mode := range unionModes {
+		if trimmed, ok := strings.CutSuffix(upstream, mode); ok {
+			upstream = trimmed
+			break
+		}
+	}
+	if err := ValidateAliasTarget(upstream); err != nil {
+		return fmt.Errorf("upstream %w", err)
+	}
+	return nil
+}
+
+// upstreamRemote returns the remote an upstream is on, or "" for a folder
+func upstreamRemote(upstream string) string {
+	if filepath.IsAbs(upstream) {
+		return ""
+	}
+	name, _, _ := strings.Cut(upstream, ":")
+	return name
+}
+
+// joinUpstreams writes upstreams as rclone's upstreams option lists them:
+// separated by spaces, with those containing a space in double quotes
+func joinUpstreams(upstreams []string) string {
+	quoted := make([]string, len(upstreams))
+	for i, upstream := range upstreams {
+		quoted[i] = upstream
+		if strings.Contains(upstream, " ") {
+			quoted[i] = `"` + upstream + `"`
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// splitUpstreams reads the upstreams option written by joinUpstreams
+func splitUpstreams(value string) []string {
+	var upstreams []string
+	for value = strings.TrimSpace(value); value != ""; value = strings.TrimSpace(value) {
+		if rest, ok := strings.CutPrefix(value, `"`); ok {
+			upstream, after, _ := strings.Cut(rest, `"`)
+			upstreams = append(upstreams, upstream)
+			value = after
+			continue
+		}
+		upstream, after, _ := strings.Cut(value, " ")
+		upstreams = append(upstreams, upstream)
+		value = after
+	}
+	return upstreams
+}
+
+// ValidateAliasTarget checks what an alias stands for: a full path to a
+// folder, or a path on another remote such as nas:Backups
+func ValidateAliasTarget(target string) error {
+	if filepath.IsAbs(target) {
+		return nil
+	}
+	name, _, found := strings.Cut(target, ":")
+	if !found || name == "" || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("'%s' is not a full path like /Volumes/NAS/Backups or a remote path like nas:Backups", target)
+	}
+	return nil
+}
+
+// ServiceAccount holds the fields of a Google service account key that
+// identify it
+type ServiceAccount struct {
+	Type        string `json:"type"`
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+}
+
+// ReadServiceAccount reads a service account key file, as downloaded from
+// the Google Cloud console under IAM & Admin → Service Accounts → Keys
+func ReadServiceAccount(path string) (*ServiceAccount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account key: %w", err)
+	}
+	var account ServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil || account.Type != "service_account" || account.ClientEmail == "" {
+		return nil, fmt.Errorf("%s is not a service account key file", filepath.Base(path))
+	}
+	return &account, nil
+}
+
+// AzurePublicSuffix is the endpoint suffix of Azure's public cloud
+const AzurePublicSuffix = "core.windows.net"
+
+// IsAzureBlob reports whether the remote is on Azure Blob Storage
+func (r RemoteConfig) IsAzureBlob() bool {
+	return r.Type == "azureblob"
+}
+
+// SASContainer returns the container a SAS URL is limited to, or "" for a
+// SAS URL of the whole account
+func (r RemoteConfig) SASContainer() string {
+	u, err := url.Parse(r.SASURL)
+	if err != nil {
+		return ""
+	}
+	container, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	return container
+}
+
+// azureEndpoint returns the blob endpoint of an account in a sovereign
+// cloud, or "" in the public cloud, where rclone finds it itself
+func (r RemoteConfig) azureEndpoint() string {
+	if r.EndpointSuffix == "" || r.EndpointSuffix == AzurePublicSuffix {
+		return ""
+	}
+	return fmt.Sprintf("https://%s.blob.%s", r.AccountID, r.EndpointSuffix)
+}
+
+// validateAzure checks that an Azure remote uses one way to log in
+func (r RemoteConfig) validateAzure() error {
+	switch {
+	case r.SASURL != "" && r.ApplicationKey != "":
+		return fmt.Errorf("remote '%s': use an account key or a SAS URL, not both", r.Name)
+	case r.SASURL != "":
+		if IsSecretRef(r.SASURL) {
+			return nil
+		}
+		u, err := url.Parse(r.SASURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" || !u.Query().Has("sig") {
+			return fmt.Errorf("remote '%s': the SAS URL must be an https:// address with a signature (sig=...)", r.Name)
+		}
+	case r.AccountID == "" || r.ApplicationKey == "":
+		return fmt.Errorf("remote '%s': Azure Blob Storage needs an account name and key, or a SAS URL", r.Name)
+	}
+	return nil
+}
+
+// WebDAVVendors are the WebDAV servers rclone knows the extensions of
+var WebDAVVendors = []string{"nextcloud", "owncloud", "sharepoint", "sharepoint-ntlm", "rclone", "fastmail", "other"}
+
+// IsWebDAV reports whether the remote is a WebDAV server. Like SFTP
+// remotes, WebDAV remotes have no buckets.
+func (r RemoteConfig) IsWebDAV() bool {
+	return r.Type == "webdav"
+}
+
+// ValidateWebDAVURL checks the address of a WebDAV server. Nextcloud and
+// ownCloud only serve WebDAV under /remote.php/, which is easy to leave out
+// when pasting the address of the web interface.
+func ValidateWebDAVURL(vendor, address string) error {
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("'%s' is not an http:// or https:// address", address)
+	}
+	switch vendor {
+	case "nextcloud", "owncloud":
+		if !strings.Contains(u.Path, "/remote.php/") {
+			return fmt.Errorf("%s serves WebDAV under /remote.php/, e.g. %s://%s/remote.php/dav/files/USERNAME/", vendor, u.Scheme, u.Host)
+		}
+	}
+	return nil
+}
+
+// IsSFTP reports whether the remote is an SFTP server. SFTP remotes have
+// no buckets; sync pairs name a folder on the server instead.
+func (r RemoteConfig) IsSFTP() bool {
+	return r.Type == "sftp"
+}
+
+// validate checks the settings that depend on the remote's backend
+func (r RemoteConfig) validate() error {
+	if r.IsSelfHosted() && r.Endpoint == "" {
+		return fmt.Errorf("remote '%s': a self-hosted S3 server needs an endpoint", r.Name)
+	}
+	if r.IsSFTP() {
+		if r.Host == "" || r.User == "" {
+			return fmt.Errorf("remote '%s': an SFTP server needs a host and a user", r.Name)
+		}
+		if r.Password == "" && r.KeyFile == "" {
+			return fmt.Errorf("remote '%s': an SFTP server needs a password or a private key file", r.Name)
+		}
+		if r.Port < 0 || r.Port > 65535 {
+			return fmt.Errorf("remote '%s': port %d is not between 1 and 65535", r.Name, r.Port)
+		}
+	}
+	if r.IsAzureBlob() {
+		if err := r.validateAzure(); err != nil {
+			return err
+		}
+	}
+	if r.IsAlias() {
+		if r.Target == "" {
+			return fmt.Errorf("remote '%s': an alias needs a folder or remote path to stand for", r.Name)
+		}
+		if err := ValidateAliasTarget(r.Target); err != nil {
+			return fmt.Errorf("remote '%s': %w", r.Name, err)
+		}
+		if name, _, _ := strings.Cut(r.Target, ":"); name == r.Name {
+			return fmt.Errorf("remote '%s': an alias cannot stand for itself", r.Name)
+		}
+	}
+	if r.IsUnion() {
+		if len(r.Upstreams) < 2 {
+			return fmt.Errorf("remote '%s': a union needs at least two upstreams", r.Name)
+		}
+		for _, upstream := range r.Upstreams {
+			if err := ValidateUpstream(upstream); err != nil {
+				return fmt.Errorf("remote '%s': %w", r.Name, err)
+			}
+			if upstreamRemote(upstream) == r.Name {
+				return fmt.Errorf("remote '%s': a union cannot contain itself", r.Name)
+			}
+		}
+		if r.Policy != "" && !slices.Contains(UnionPolicies, r.Policy) {
+			return fmt.Errorf("remote '%s': unknown union policy '%s', expected one of %s", r.Name, r.Policy, strings.Join(UnionPolicies, ", "))
+		}
+	}
+	if r.IsGCS() && r.ServiceAccountFile == "" {
+		return fmt.Errorf("remote '%s': a Google Cloud Storage remote needs a service account key file", r.Name)
+	}
+	if r.IsWebDAV() {
+		if err := ValidateWebDAVURL(r.Vendor, r.URL); err != nil {
+			return fmt.Errorf("remote '%s': %w", r.Name, err)
+		}
+		if r.Vendor != "" && !slices.Contains(WebDAVVendors, r.Vendor) {
+			return fmt.Errorf("remote '%s': unknown WebDAV vendor '%s', expected one of %s", r.Name, r.Vendor, strings.Join(WebDAVVendors, ", "))
+		}
+	}
+	if err := r.validateLabel(); err != nil {
+		return fmt.Errorf("remote '%s': %w", r.Name, err)
+	}
+	if r.Restore != nil {
+		if err := r.Restore.Validate(); err != nil {
+			return fmt.Errorf("remote '%s': %w", r.Name, err)
+		}
+	}
+	if r.Tuning == nil {
+		return nil
+	}
+	if err := r.Tuning.Validate(r.Type); err != nil {
+		return fmt.Errorf("remote '%s': %w", r.Name, err)
+	}
+	return nil
 }
 
 // SyncConfig represents sync operation configuration
@@ -35,6 +410,143 @@ type LaunchAgentConfig struct {
 	Minute        int    `json:"minute"`         // Minute to run (0-59)
 	RunAtLoad     bool   `json:"run_at_load"`    // Run when loaded
 	ScriptPath    string `json:"script_path"`    // Path to script to run
+
+	// Schedule, when set, replaces Hour/Minute with multiple run times
+	Schedule *launchd.Schedule `json:"schedule,omitempty"`
+
+	// System runs the backup from a LaunchDaemon in /Library/LaunchDaemons,
+	// installed with 'cloud-sync daemon', instead of the user's LaunchAgent
+	System bool `json:"system,omitempty"`
+
+	// StdoutPath and StderrPath receive the backup script's output when it
+	// runs on schedule; empty means the defaults in the log directory
+	StdoutPath string `json:"stdout_path,omitempty"`
+	StderrPath string `json:"stderr_path,omitempty"`
+
+	// RCAddr is where the scheduled backup serves rclone's remote control
+	// API for the TUI to follow; empty means rclone.DefaultRCAddr. Change it
+	// when another program already listens there.
+	RCAddr string `json:"rc_addr,omitempty"`
+}
+
+// RCAddress returns where the scheduled backup serves rclone's remote
+// control API
+func (c LaunchAgentConfig) RCAddress() string {
+	if c.RCAddr == "" {
+		return rclone.DefaultRCAddr
+	}
+	return c.RCAddr
+}
+
+// Default files the scheduled backup's output is written to, in the log
+// directory
+const (
+	AgentStdoutName = "agent_stdout.log"
+	AgentStderrName = "agent_stderr.log"
+)
+
+// OutputPaths returns the files launchd writes the scheduled backup's
+// standard output and standard error to
+func (c LaunchAgentConfig) OutputPaths(logDir string) (stdout, stderr string) {
+	stdout, stderr = c.StdoutPath, c.StderrPath
+	if stdout == "" {
+		stdout = filepath.Join(logDir, AgentStdoutName)
+	}
+	if stderr == "" {
+		stderr = filepath.Join(logDir, AgentStderrName)
+	}
+	return stdout, stderr
+}
+
+// Scheduler returns the launchd manager of the scheduled backup for a user:
+// the LaunchDaemon when System is set, otherwise the LaunchAgent
+func (c LaunchAgentConfig) Scheduler(username string) *launchd.Manager {
+	agent := launchd.NewManager(username)
+	if c.System {
+		return agent.Daemon()
+	}
+	return agent
+}
+
+// BackupScheduler loads the default configuration and returns the launchd
+// manager of the scheduled backup, see LaunchAgentConfig.Scheduler. A
+// configuration that cannot be read counts as using the LaunchAgent.
+func BackupScheduler(username string) *launchd.Manager {
+	if m, err := NewManager(); err == nil {
+		if config, err := m.Load(); err == nil {
+			return config.LaunchAgent.Scheduler(username)
+		}
+	}
+	return launchd.NewManager(username)
+}
+
+// EffectiveSchedule returns the configured schedule, falling back to the
+// single daily Hour/Minute
+func (c LaunchAgentConfig) EffectiveSchedule() launchd.Schedule {
+	if c.Schedule != nil {
+		return *c.Schedule
+	}
+	return launchd.Schedule{Times: []launchd.TimeOfDay{{Hour: c.Hour, Minute: c.Minute}}}
+}
+
+// SetupProgress records how far the installation wizard got, so quitting
+// part way through resumes at the right step on the next launch
+type SetupProgress struct {
+	ToolsInstalled    bool  `json:"tools_installed"`
+	RemotesConfigured bool  `json:"remotes_configured"`
+	ScriptsGenerated  bool  `json:"scripts_generated"`
+	CompletedSteps    []int `json:"completed_steps,omitempty"` // Wizard step numbers, starting at 1
+}
+
+// StepDone reports whether the given wizard step has completed
+func (p SetupProgress) StepDone(step int) bool {
+	for _, s := range p.CompletedSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStep marks a wizard step as completed or not
+func (p *SetupProgress) SetStep(step int, done bool) {
+	steps := make([]int, 0, len(p.CompletedSteps)+1)
+	for _, s := range p.CompletedSteps {
+		if s != step {
+			steps = append(steps, s)
+		}
+	}
+	if done {
+		steps = append(steps, step)
+		sort.Ints(steps)
+	}
+	p.CompletedSteps = steps
+}
+
+// UIConfig holds TUI preferences
+type UIConfig struct {
+	Theme    string            `json:"theme,omitempty"`     // auto, dark, light, high-contrast or custom
+	Palette  map[string]string `json:"palette,omitempty"`   // Hex color overrides, e.g. "primary": "#00ADD8"
+	Mouse    bool              `json:"mouse,omitempty"`     // Click to select; uses the alternate screen
+	ReadOnly bool              `json:"read_only,omitempty"` // Disable installs, config edits and syncs in the TUI
+}
+
+// ReportsConfig controls the report written into the log directory after
+// each sync run
+type ReportsConfig struct {
+	Format string `json:"format,omitempty"` // "markdown" or "html"; Markdown if empty
+	Keep   int    `json:"keep,omitempty"`   // Reports kept per pair; logs.DefaultReportsKept if 0
+}
+
+// Validate checks the report settings
+func (r ReportsConfig) Validate() error {
+	if err := logs.ValidateReportFormat(r.Format); err != nil {
+		return err
+	}
+	if r.Keep < 0 {
+		return fmt.Errorf("reports to keep must be at least 1")
+	}
+	return nil
 }
 
 // AppConfig represents the complete application configuration
@@ -48,6 +560,25 @@ type AppConfig struct {
 	LogDir        string              `json:"log_dir"`
 	RclonePath    string              `json:"rclone_path"`
 	RcloneConfig  string              `json:"rclone_config"`
+	Setup         SetupProgress       `json:"setup"`
+	UI            UIConfig            `json:"ui"`
+	Reports       ReportsConfig       `json:"reports,omitempty"`
+}
+
+// WithoutCredentials returns a copy of the config with the remotes' keys
+// removed. The config itself is not modified.
+func (c *AppConfig) WithoutCredentials() *AppConfig {
+	remotes := make([]RemoteConfig, len(c.Remotes))
+	copy(remotes, c.Remotes)
+	for i := range remotes {
+		remotes[i].AccountID = ""
+		remotes[i].ApplicationKey = ""
+		remotes[i].Password = ""
+		remotes[i].SASURL = ""
+	}
+	stripped := *c
+	stripped.Remotes = remotes
+	return &stripped
 }
 
 // Manager handles application configuration
@@ -63,8 +594,9 @@ func NewManager() (*Manager, error) {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
+	// config.json may be written as config.yaml or config.toml instead
 	configDir := filepath.Join(homeDir, ".config", "cloud-sync")
-	configPath := filepath.Join(configDir, "config.json")
+	configPath := fileformat.Find(configDir, "config", fileformat.JSON)
 
 	return &Manager{
 		configPath: configPath,
@@ -91,33 +623,45 @@ func (m *Manager) Load() (*AppConfig, error) {
 	}
 
 	var config AppConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := jsonfile.Unmarshal(m.configPath, data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", jsonfile.NewCorruptError(m.configPath, err))
 	}
 
 	m.config = &config
 	return &config, nil
 }
 
-// Save saves the configuration to file
+// Save saves the configuration to file. The file is replaced in one step,
+// so a crash cannot leave it half written, and the previous version is kept
+// as config.json.bak.
 func (m *Manager) Save(config *AppConfig) error {
-	// Ensure directory exists
-	configDir := filepath.Dir(m.configPath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	if err := jsonfile.Write(m.configPath, config, 0600); err != nil {
+		return err
 	}
 
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
-	}
+	m.config = config
+	return nil
+}
 
-	if err := os.WriteFile(m.configPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+// CorruptFiles returns the parse errors of config.json and
+// sync-config.json, for the files that exist but cannot be read
+func (m *Manager) CorruptFiles() []*jsonfile.CorruptError {
+	var corrupt []*jsonfile.CorruptError
+
+	var configErr, pairsErr *jsonfile.CorruptError
+	if _, err := m.Load(); errors.As(err, &configErr) {
+		corrupt = append(corrupt, configErr)
+	}
+	if _, err := syncconfig.NewManager(m.SyncConfigPath()).Load(); errors.As(err, &pairsErr) {
+		corrupt = append(corrupt, pairsErr)
 	}
+	return corrupt
+}
 
-	m.config = config
-	return nil
+// RestoreBackup replaces config.json with the version saved before the last
+// change, e.g. after a crash or a bad hand edit
+func (m *Manager) RestoreBackup() error {
+	return jsonfile.Restore(m.configPath)
 }
 
 // ConfigExists checks if the config file exists
@@ -131,6 +675,12 @@ func (m *Manager) GetConfigPath() string {
 	return m.configPath
 }
 
+// Watch returns a watcher that reports changes to config.json and
+// sync-config.json, including edits made by other programs or instances
+func (m *Manager) Watch() *filewatch.Watcher {
+	return filewatch.NewWatcher(m.configPath, m.SyncConfigPath())
+}
+
 // getDefaultConfig returns a default configuration
 func (m *Manager) getDefaultConfig() *AppConfig {
 	homeDir, _ := os.UserHomeDir()
@@ -154,70 +704,88 @@ func (m *Manager) getDefaultConfig() *AppConfig {
 	}
 }
 
-// AddRemote adds a new remote configuration
-func (m *Manager) AddRemote(remote RemoteConfig) error {
+// Lock takes the lock on config.json that update holds, for changes that
+// span several files. It returns the function releasing it.
+func (m *Manager) Lock() (func(), error) {
+	return jsonfile.Lock(m.configPath)
+}
+
+// update loads the configuration, applies change and saves the result while
+// holding the config lock, so concurrent updates from another process are
+// applied one after the other instead of overwriting each other
+func (m *Manager) update(change func(*AppConfig) error) error {
+	unlock, err := m.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	config, err := m.Load()
 	if err != nil {
 		return err
 	}
+	if err := change(config); err != nil {
+		return err
+	}
+	return m.Save(config)
+}
 
-	// Check for duplicate names
-	for _, r := range config.Remotes {
-		if r.Name == remote.Name {
-			return fmt.Errorf("remote with name '%s' already exists", remote.Name)
-		}
+// AddRemote adds a new remote configuration
+func (m *Manager) AddRemote(remote RemoteConfig) error {
+	if err := remote.validate(); err != nil {
+		return err
 	}
 
-	config.Remotes = append(config.Remotes, remote)
-	return m.Save(config)
+	return m.update(func(config *AppConfig) error {
+		// Check for duplicate names
+		for _, r := range config.Remotes {
+			if r.Name == remote.Name {
+				return fmt.Errorf("remote with name '%s' already exists", remote.Name)
+			}
+		}
+
+		config.Remotes = append(config.Remotes, remote)
+		return nil
+	})
 }
 
 // UpdateRemote updates an existing remote configuration
 func (m *Manager) UpdateRemote(name string, remote RemoteConfig) error {
-	config, err := m.Load()
-	if err != nil {
+	if err := remote.validate(); err != nil {
 		return err
 	}
 
-	found := false
-	for i, r := range config.Remotes {
-		if r.Name == name {
-			config.Remotes[i] = remote
-			found = true
-			break
+	return m.update(func(config *AppConfig) error {
+		for i, r := range config.Remotes {
+			if r.Name == name {
+				config.Remotes[i] = remote
+				return nil
+			}
 		}
-	}
-
-	if !found {
 		return fmt.Errorf("remote '%s' not found", name)
-	}
-
-	return m.Save(config)
+	})
 }
 
 // RemoveRemote removes a remote configuration
 func (m *Manager) RemoveRemote(name string) error {
-	config, err := m.Load()
-	if err != nil {
-		return err
-	}
-
-	newRemotes := make([]RemoteConfig, 0)
-	found := false
-	for _, r := range config.Remotes {
-		if r.Name != name {
-			newRemotes = append(newRemotes, r)
-		} else {
-			found = true
+	return m.update(func(config *AppConfig) error {
+		newRemotes := make([]RemoteConfig, 0)
+		found := false
+		for _, r := range config.Remotes {
+			if r.Name != name {
+				newRemotes = append(newRemotes, r)
+			} else {
+				found = true
+			}
 		}
-	}
 
-	if !found {
-		return fmt.Errorf("remote '%s' not found", name)
-	}
+		if !found {
+			return fmt.Errorf("remote '%s' not found", name)
+		}
 
-	config.Remotes = newRemotes
-	return m.Save(config)
+		config.Remotes = newRemotes
+		return nil
+	})
 }
 
 // GetRemote retrieves a remote by name
@@ -236,26 +804,108 @@ func (m *Manager) GetRemote(name string) (*RemoteConfig, error) {
 	return nil, fmt.Errorf("remote '%s' not found", name)
 }
 
-// UpdateSyncConfig updates the sync configuration
-func (m *Manager) UpdateSyncConfig(syncConfig SyncConfig) error {
-	config, err := m.Load()
-	if err != nil {
-		return err
+// SetDefaultBucket sets the bucket sync pairs and scripts on a remote start
+// from; an empty bucket clears it
+func (m *Manager) SetDefaultBucket(name, bucket string) error {
+	return m.update(func(config *AppConfig) error {
+		for i, r := range config.Remotes {
+			if r.Name == name {
+				config.Remotes[i].Bucket = bucket
+				return nil
+			}
+		}
+		return fmt.Errorf("remote '%s' not found", name)
+	})
+}
+
+// SetRestoreTuning stores how restores from a remote download
+func (m *Manager) SetRestoreTuning(name string, tuning rclone.RestoreTuning) error {
+	if err := tuning.Validate(); err != nil {
+		return fmt.Errorf("remote '%s': %w", name, err)
 	}
+	return m.update(func(config *AppConfig) error {
+		for i, r := range config.Remotes {
+			if r.Name == name {
+				config.Remotes[i].Restore = &tuning
+				return nil
+			}
+		}
+		return fmt.Errorf("remote '%s' not found", name)
+	})
+}
 
-	config.SyncConfig = syncConfig
-	return m.Save(config)
+// RestoreTuning returns the restore settings of a stored remote, or nil
+// when the remote is unknown or has none
+func (c *AppConfig) RestoreTuning(remote string) *rclone.RestoreTuning {
+	for _, r := range c.Remotes {
+		if r.Name == remote {
+			return r.Restore
+		}
+	}
+	return nil
+}
+
+// DefaultBucket returns the default bucket of a stored remote, or "" when
+// the remote is unknown or has none
+func (c *AppConfig) DefaultBucket(remote string) string {
+	for _, r := range c.Remotes {
+		if r.Name == remote {
+			return r.Bucket
+		}
+	}
+	return ""
+}
+
+// UpdateSyncConfig updates the sync configuration
+func (m *Manager) UpdateSyncConfig(syncConfig SyncConfig) error {
+	return m.update(func(config *AppConfig) error {
+		config.SyncConfig = syncConfig
+		return nil
+	})
 }
 
 // UpdateLaunchAgentConfig updates the LaunchAgent configuration
 func (m *Manager) UpdateLaunchAgentConfig(launchConfig LaunchAgentConfig) error {
-	config, err := m.Load()
-	if err != nil {
+	return m.update(func(config *AppConfig) error {
+		config.LaunchAgent = launchConfig
+		return nil
+	})
+}
+
+// UpdateSetupProgress updates the installation wizard progress
+func (m *Manager) UpdateSetupProgress(progress SetupProgress) error {
+	return m.update(func(config *AppConfig) error {
+		config.Setup = progress
+		return nil
+	})
+}
+
+// UpdateUIConfig updates the TUI preferences
+func (m *Manager) UpdateUIConfig(uiConfig UIConfig) error {
+	return m.update(func(config *AppConfig) error {
+		config.UI = uiConfig
+		return nil
+	})
+}
+
+// UpdateRclonePath sets the rclone binary cloud-sync runs
+func (m *Manager) UpdateRclonePath(path string) error {
+	return m.update(func(config *AppConfig) error {
+		config.RclonePath = path
+		return nil
+	})
+}
+
+// UpdateReportsConfig updates the settings of the reports written after
+// each sync run
+func (m *Manager) UpdateReportsConfig(reports ReportsConfig) error {
+	if err := reports.Validate(); err != nil {
 		return err
 	}
-
-	config.LaunchAgent = launchConfig
-	return m.Save(config)
+	return m.update(func(config *AppConfig) error {
+		config.Reports = reports
+		return nil
+	})
 }
 
 // GenerateRcloneConfig generates rclone.conf from stored remotes
@@ -276,20 +926,19 @@ func (m *Manager) GenerateRcloneConfig() error {
 	for _, remote := range config.Remotes {
 		content += fmt.Sprintf("[%s]\n", remote.Name)
 		content += fmt.Sprintf("type = %s\n", remote.Type)
-		
-		if remote.Type == "b2" {
-			content += fmt.Sprintf("account = %s\n", remote.AccountID)
-			content += fmt.Sprintf("key = %s\n", remote.ApplicationKey)
-		} else if remote.Type == "s3" {
-			content += fmt.Sprintf("provider = %s\n", remote.Provider)
-			content += fmt.Sprintf("access_key_id = %s\n", remote.AccountID)
-			content += fmt.Sprintf("secret_access_key = %s\n", remote.ApplicationKey)
-			if remote.Region != "" {
-				content += fmt.Sprintf("region = %s\n", remote.Region)
+
+		for _, option := range remote.rcloneOptions() {
+			// Referenced secrets stay out of the file; rclone reads them
+			// from the environment at sync time (see SecretEnv)
+			if IsSecretRef(option[1]) {
+				content += fmt.Sprintf("# %s is read from $%s\n", option[0], RcloneEnvName(remote.Name, option[0]))
+				continue
 			}
-			if remote.Endpoint != "" {
-				content += fmt.Sprintf("endpoint = %s\n", remote.Endpoint)
+			value, err := rcloneValue(option[0], option[1])
+			if err != nil {
+				return fmt.Errorf("failed to write %s of remote '%s': %w", option[0], remote.Name, err)
 			}
+			content += fmt.Sprintf("%s = %s\n", option[0], value)
 		}
 		content += "\n"
 	}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andreisuslov/cloud-sync/internal/fileformat"
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// Format returns the format config.json is kept in: json, yaml or toml
+func (m *Manager) Format() string {
+	return fileformat.Of(m.configPath)
+}
+
+// ConvertFormat rewrites config.json and sync-config.json in format, e.g.
+// as config.yaml and sync-config.yaml, and removes the old files. Their
+// backups are left under the old names. config.json is written with the
+// defaults if it does not exist yet. It returns the files written.
+func (m *Manager) ConvertFormat(format string) ([]string, error) {
+	format, err := fileformat.Parse(format)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hold both locks like a rename does, config.json first
+	unlock, err := m.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	pairs := syncconfig.NewManager(m.SyncConfigPath())
+	unlockPairs, err := pairs.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlockPairs()
+
+	files := []struct {
+		path string
+		perm os.FileMode
+	}{
+		{pairs.GetConfigPath(), 0644},
+		{m.configPath, 0600},
+	}
+	var written, replaced []string
+	for _, file := range files {
+		target := filepath.Join(filepath.Dir(file.path), trimExt(file.path)+fileformat.Ext(format))
+		if target == file.path {
+			continue
+		}
+		data, err := os.ReadFile(file.path)
+		existed := err == nil
+		if os.IsNotExist(err) {
+			if file.path != m.configPath {
+				continue
+			}
+			// The defaults are written, so the next run finds the format
+			data, err = json.Marshal(m.getDefaultConfig())
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to read %s: %w", filepath.Base(file.path), err)
+		}
+		data, err = fileformat.ToJSON(fileformat.Of(file.path), data)
+		if err == nil && !json.Valid(data) {
+			err = fmt.Errorf("invalid JSON")
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to convert %s: %w", filepath.Base(file.path), jsonfile.NewCorruptError(file.path, err))
+		}
+		if err := jsonfile.Write(target, json.RawMessage(data), file.perm); err != nil {
+			return written, err
+		}
+		written = append(written, target)
+		if existed {
+			replaced = append(replaced, file.path)
+		}
+	}
+
+	// The old files go last, so a failure above leaves them in use
+	for _, path := range replaced {
+		if err := os.Remove(path); err != nil {
+			return written, fmt.Errorf("failed to remove %s: %w", filepath.Base(path), err)
+		}
+	}
+	m.configPath = filepath.Join(filepath.Dir(m.configPath), trimExt(m.configPath)+fileformat.Ext(format))
+	return written, nil
+}
+
+// trimExt returns the base name of path without its extension
+func trimExt(path string) string {
+	base := filepath.Base(path)
+	return base[:len(base)-len(filepath.Ext(base))]
+}
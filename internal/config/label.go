@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LabelColors are the named colors a remote's label can be shown in. A hex
+// value like #00ADD8 is accepted as well.
+var LabelColors = []string{"red", "orange", "yellow", "green", "cyan", "blue", "purple", "pink", "gray"}
+
+// Longest label and icon accepted. An icon is one emoji, which can take a
+// few code points, e.g. a flag or a skin tone.
+const (
+	maxLabelLength = 40
+	maxIconLength  = 8
+)
+
+var labelHexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// HasLabel reports whether the remote has a label, icon or color to be
+// shown with rather than its plain name
+func (r RemoteConfig) HasLabel() bool {
+	return r.Label != "" || r.Icon != "" || r.Color != ""
+}
+
+// DisplayName returns the name the TUI shows the remote under: its label
+// after its icon, e.g. "📷 Photos", or its name when it has no label
+func (r RemoteConfig) DisplayName() string {
+	name := r.Label
+	if name == "" {
+		name = r.Name
+	}
+	if r.Icon == "" {
+		return name
+	}
+	return r.Icon + " " + name
+}
+
+// validateLabel checks the label, icon and color
+func (r RemoteConfig) validateLabel() error {
+	if utf8.RuneCountInString(r.Label) > maxLabelLength {
+		return fmt.Errorf("the label is longer than %d characters", maxLabelLength)
+	}
+	if strings.TrimSpace(r.Label) != r.Label {
+		return fmt.Errorf("the label cannot start or end with spaces")
+	}
+	if utf8.RuneCountInString(r.Icon) > maxIconLength || strings.ContainsFunc(r.Icon, unicode.IsSpace) {
+		return fmt.Errorf("the icon '%s' is not a single emoji", r.Icon)
+	}
+	return ValidateLabelColor(r.Color)
+}
+
+// ValidateLabelColor checks a label color: empty, one of LabelColors or a
+// hex value
+func ValidateLabelColor(color string) error {
+	if color == "" || slices.Contains(LabelColors, color) || labelHexColor.MatchString(color) {
+		return nil
+	}
+	return fmt.Errorf("unknown color '%s' (expected one of %s, or a hex value like #00ADD8)", color, strings.Join(LabelColors, ", "))
+}
+
+// SetRemoteLabel sets the label, icon and color a remote is shown under;
+// empty values clear them
+func (m *Manager) SetRemoteLabel(name, label, icon, color string) error {
+	return m.update(func(config *AppConfig) error {
+		for i, r := range config.Remotes {
+			if r.Name == name {
+				r.Label, r.Icon, r.Color = label, icon, color
+				if err := r.validateLabel(); err != nil {
+					return fmt.Errorf("remote '%s': %w", name, err)
+				}
+				config.Remotes[i] = r
+				return nil
+			}
+		}
+		return fmt.Errorf("remote '%s' not found", name)
+	})
+}
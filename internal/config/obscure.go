@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// obscureKey is the fixed key rclone obscures passwords with. Obscuring only
+// keeps a password from being read at a glance; anyone with the file can
+// reveal it.
+var obscureKey = []byte{
+	0x9c, 0x93, 0x5b, 0x48, 0x73, 0x0a, 0x55, 0x4d,
+	0x6b, 0xfd, 0x7c, 0x63, 0xc8, 0x86, 0xa9, 0x2b,
+	0xd3, 0x90, 0x19, 0x8e, 0xb8, 0x12, 0x8a, 0xfb,
+	0xf4, 0xde, 0x16, 0x2b, 0x8b, 0x95, 0xf6, 0x38,
+}
+
+// rclonePasswordOptions are the rclone.conf options rclone expects to be
+// obscured, such as a crypt remote's password. Other credentials, including
+// the B2 key and S3 secret key, are stored as they are.
+var rclonePasswordOptions = map[string]bool{
+	"pass":      true,
+	"password":  true,
+	"password2": true,
+}
+
+// IsRclonePasswordOption reports whether rclone reads option obscured
+func IsRclonePasswordOption(option string) bool {
+	return rclonePasswordOptions[option]
+}
+
+// Obscure encodes a password the way 'rclone obscure' does
+func Obscure(plaintext string) (string, error) {
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to read random IV: %w", err)
+	}
+	return obscureWithIV(plaintext, iv)
+}
+
+// obscureWithIV encrypts plaintext with AES-CTR under the rclone key and
+// returns the IV and ciphertext as unpadded URL-safe base64
+func obscureWithIV(plaintext string, iv []byte) (string, error) {
+	block, err := aes.NewCipher(obscureKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	out := make([]byte, len(iv)+len(plaintext))
+	copy(out, iv)
+	cipher.NewCTR(block, iv).XORKeyStream(out[len(iv):], []byte(plaintext))
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// Reveal decodes a password encoded by Obscure or 'rclone obscure'
+func Reveal(obscured string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(obscured)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode obscured password: %w", err)
+	}
+	if len(data) < aes.BlockSize {
+		return "", fmt.Errorf("obscured password is too short")
+	}
+	block, err := aes.NewCipher(obscureKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	iv, ciphertext := data[:aes.BlockSize], data[aes.BlockSize:]
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCTR(block, iv).XORKeyStream(plaintext, ciphertext)
+	return string(plaintext), nil
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// RenameRemote renames a stored remote along with everything that refers to
+// it: the backup source and destination, the sync pairs in sync-config.json
+// (their extra destinations included), the aliases and unions of the remote
+// and the remote's section in rclone.conf. If a later write fails, the files already written are
+// restored, so the names never disagree.
+func (m *Manager) RenameRemote(oldName, newName string) error {
+	if oldName == newName {
+		return nil
+	}
+	if err := rclone.ValidateRemoteName(newName); err != nil {
+		return err
+	}
+
+	// Hold both files' locks for the whole rename, config.json first like
+	// every other change spanning the two
+	unlock, err := m.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	pairs := syncconfig.NewManager(m.SyncConfigPath())
+	unlockPairs, err := pairs.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlockPairs()
+
+	appConfig, err := m.Load()
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	for i, r := range appConfig.Remotes {
+		switch r.Name {
+		case oldName:
+			index = i
+		case newName:
+			return fmt.Errorf("remote with name '%s' already exists", newName)
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("remote '%s' not found", oldName)
+	}
+
+	appConfig.Remotes[index].Name = newName
+	if appConfig.SyncConfig.SourceRemote == oldName {
+		appConfig.SyncConfig.SourceRemote = newName
+	}
+	if appConfig.SyncConfig.DestRemote == oldName {
+		appConfig.SyncConfig.DestRemote = newName
+	}
+	for i, r := range appConfig.Remotes {
+		if target, ok := renameTarget(r.Target, oldName, newName); ok && r.IsAlias() {
+			appConfig.Remotes[i].Target = target
+		}
+		if r.IsUnion() {
+			appConfig.Remotes[i].Upstreams = renameUpstreams(r.Upstreams, oldName, newName)
+		}
+	}
+
+	// Write rclone.conf, then the sync pairs, then config.json, undoing the
+	// earlier writes if a later one fails
+	var undo []func()
+	rollback := func(err error) error {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		return err
+	}
+
+	restoreRclone, err := renameRcloneSection(appConfig.RcloneConfig, oldName, newName)
+	if err != nil {
+		return err
+	}
+	undo = append(undo, restoreRclone)
+
+	if pairs.ConfigExists() {
+		syncConfig, err := pairs.Load()
+		if err != nil {
+			return rollback(err)
+		}
+		original, err := pairs.Load()
+		if err != nil {
+			return rollback(err)
+		}
+		if renamePairRemotes(syncConfig.SyncPairs, oldName, newName) {
+			if err := pairs.Save(syncConfig); err != nil {
+				return rollback(fmt.Errorf("failed to update sync pairs: %w", err))
+			}
+			undo = append(undo, func() { pairs.Save(original) })
+		}
+	}
+
+	if err := m.Save(appConfig); err != nil {
+		return rollback(err)
+	}
+	return nil
+}
+
+// renamePairRemotes points the sync pairs using oldName at newName and
+// reports whether any changed
+func renamePairRemotes(pairs []syncconfig.SyncPair, oldName, newName string) bool {
+	changed := false
+	for i := range pairs {
+		if pairs[i].RemoteName == oldName {
+			pairs[i].RemoteName = newName
+			changed = true
+		}
+		for j := range pairs[i].Destinations {
+			if pairs[i].Destinations[j].RemoteName == oldName {
+				pairs[i].Destinations[j].RemoteName = newName
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// renameTarget returns the "remote:path" an alias stands for with the
+// remote oldName renamed to newName, and false if it is on another remote
+func renameTarget(target, oldName, newName string) (string, bool) {
+	path, ok := strings.CutPrefix(target, oldName+":")
+	if !ok {
+		return target, false
+	}
+	return newName + ":" + path, true
+}
+
+// renameUpstreams returns the upstreams of a union with those on the remote
+// oldName moved to newName
+func renameUpstreams(upstreams []string, oldName, newName string) []string {
+	renamed := make([]string, len(upstreams))
+	for i, upstream := range upstreams {
+		renamed[i], _ = renameTarget(upstream, oldName, newName)
+	}
+	return renamed
+}
+
+// renameRcloneSection renames a remote's section in rclone.conf in place,
+// keeping the other remotes, points the aliases, unions and other remotes
+// wrapping it at the new name, and updates the environment variable names in the
+// comments left for secret references. It returns a function that
+// restores the previous file.
+func renameRcloneSection(path, oldName, newName string) (func(), error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return func() {}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rclone config: %w", err)
+	}
+
+	// Comments read "# key is read from $RCLONE_CONFIG_<NAME>_KEY"
+	oldEnv, newEnv := "$"+RcloneEnvName(oldName, ""), "$"+RcloneEnvName(newName, "")
+
+	lines := strings.Split(string(data), "\n")
+	inSection := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			inSection = strings.Trim(trimmed, "[]") == oldName
+			if inSection {
+				lines[i] = "[" + newName + "]"
+			}
+			continue
+		}
+		if inSection && strings.HasPrefix(trimmed, "#") {
+			lines[i] = strings.Replace(line, oldEnv, newEnv, 1)
+		}
+		// Aliases, and backends such as crypt, name the remote they wrap
+		// in their "remote" option; unions list theirs in "upstreams"
+		key, value, found := strings.Cut(trimmed, "=")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "remote":
+			if target, ok := renameTarget(strings.TrimSpace(value), oldName, newName); ok {
+				lines[i] = "remote = " + target
+			}
+		case "upstreams":
+			upstreams := splitUpstreams(value)
+			if renamed := renameUpstreams(upstreams, oldName, newName); !slices.Equal(renamed, upstreams) {
+				lines[i] = "upstreams = " + joinUpstreams(renamed)
+			}
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write rclone config: %w", err)
+	}
+	return func() { os.WriteFile(path, data, 0600) }, nil
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Prefixes for credential fields that are looked up instead of stored.
+// "env:B2_KEY" reads an environment variable and "cmd:op read op://..."
+// runs a command, such as the 1Password CLI, and uses its output.
+const (
+	SecretEnvPrefix = "env:"
+	SecretCmdPrefix = "cmd:"
+)
+
+// IsSecretRef reports whether a field value refers to a secret instead of
+// holding it
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, SecretEnvPrefix) || strings.HasPrefix(value, SecretCmdPrefix)
+}
+
+// ResolveSecret returns the value a field refers to. Plain values are
+// returned unchanged.
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, SecretEnvPrefix):
+		name := strings.TrimSpace(strings.TrimPrefix(value, SecretEnvPrefix))
+		secret, ok := os.LookupEnv(name)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(value, SecretCmdPrefix):
+		command := strings.TrimSpace(strings.TrimPrefix(value, SecretCmdPrefix))
+		cmd := exec.Command("sh", "-c", command)
+		output, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("secret command '%s' failed: %w", command, err)
+		}
+		secret := strings.TrimRight(string(output), "\r\n")
+		if secret == "" {
+			return "", fmt.Errorf("secret command '%s' returned nothing", command)
+		}
+		return secret, nil
+
+	default:
+		return value, nil
+	}
+}
+
+// rcloneOptions returns the rclone.conf options for a remote, in file order
+func (r RemoteConfig) rcloneOptions() [][2]string {
+	options := r.credentialOptions()
+	if r.Tuning != nil {
+		options = append(options, r.Tuning.rcloneOptions()...)
+	}
+	return options
+}
+
+// credentialOptions returns the options that connect to the remote's backend
+func (r RemoteConfig) credentialOptions() [][2]string {
+	switch r.Type {
+	case "b2":
+		return [][2]string{
+			{"account", r.AccountID},
+			{"key", r.ApplicationKey},
+		}
+	case "s3":
+		options := [][2]string{
+			{"provider", r.Provider},
+			{"access_key_id", r.AccountID},
+			{"secret_access_key", r.ApplicationKey},
+		}
+		if r.Region != "" {
+			options = append(options, [2]string{"region", r.Region})
+		}
+		if r.Endpoint != "" {
+			options = append(options, [2]string{"endpoint", r.Endpoint})
+		}
+		if r.IsSelfHosted() {
+			options = append(options, [2]string{"force_path_style", strconv.FormatBool(!r.VirtualHostStyle)})
+			if r.SkipTLSVerify {
+				// Sets rclone's --no-check-certificate for this remote only
+				options = append(options, [2]string{"override.no_check_certificate", "true"})
+			}
+		}
+		return options
+	case "sftp":
+		options := [][2]string{
+			{"host", r.Host},
+			{"user", r.User},
+		}
+		if r.Port != 0 {
+			options = append(options, [2]string{"port", strconv.Itoa(r.Port)})
+		}
+		if r.Password != "" {
+			options = append(options, [2]string{"pass", r.Password})
+		}
+		if r.KeyFile != "" {
+			options = append(options, [2]string{"key_file", r.KeyFile})
+		}
+		if r.KnownHostsFile != "" {
+			options = append(options, [2]string{"known_hosts_file", r.KnownHostsFile})
+		}
+		return options
+	case "azureblob":
+		if r.SASURL != "" {
+			return [][2]string{{"sas_url", r.SASURL}}
+		}
+		options := [][2]string{
+			{"account", r.AccountID},
+			{"key", r.ApplicationKey},
+		}
+		if endpoint := r.azureEndpoint(); endpoint != "" {
+			options = append(options, [2]string{"endpoint", endpoint})
+		}
+		return options
+	case TypeGCS:
+		options := [][2]string{{"service_account_file", r.ServiceAccountFile}}
+		if r.ProjectNumber != "" {
+			options = append(options, [2]string{"project_number", r.ProjectNumber})
+		}
+		if r.BucketPolicyOnly {
+			options = append(options, [2]string{"bucket_policy_only", "true"})
+		}
+		return options
+	case "webdav":
+		options := [][2]string{{"url", r.URL}}
+		if r.Vendor != "" {
+			options = append(options, [2]string{"vendor", r.Vendor})
+		}
+		if r.User != "" {
+			options = append(options, [2]string{"user", r.User})
+		}
+		if r.Password != "" {
+			options = append(options, [2]string{"pass", r.Password})
+		}
+		return options
+	case TypeAlias:
+		return [][2]string{{"remote", r.Target}}
+	case TypeUnion:
+		options := [][2]string{{"upstreams", joinUpstreams(r.Upstreams)}}
+		if r.Policy != "" {
+			options = append(options, [2]string{"create_policy", r.Policy})
+		}
+		return options
+	default:
+		return nil
+	}
+}
+
+// rcloneValue returns value as rclone reads option, obscuring passwords
+func rcloneValue(option, value string) (string, error) {
+	if !IsRclonePasswordOption(option) {
+		return value, nil
+	}
+	return Obscure(value)
+}
+
+// RcloneEnvName returns the environment variable rclone reads a remote
+// option from, e.g. RCLONE_CONFIG_B2_KEY
+func RcloneEnvName(remote, option string) string {
+	name := strings.ToUpper(remote + "_" + option)
+	return "RCLONE_CONFIG_" + strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name)
+}
+
+// SecretFailure is a secret reference of a remote that did not resolve
+type SecretFailure struct {
+	Remote string
+	Option string
+	Err    error
+}
+
+// SecretError reports the secret references that did not resolve
+type SecretError struct {
+	Failures []SecretFailure
+}
+
+// Error implements the error interface
+func (e *SecretError) Error() string {
+	failed := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		failed = append(failed, fmt.Sprintf("%s %s: %v", f.Remote, f.Option, f.Err))
+	}
+	return "failed to resolve credentials: " + strings.Join(failed, "; ")
+}
+
+// Remote returns the failures of one remote as a *SecretError, or nil if
+// all of its references resolved
+func (e *SecretError) Remote(name string) error {
+	var failures []SecretFailure
+	for _, f := range e.Failures {
+		if f.Remote == name {
+			failures = append(failures, f)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &SecretError{Failures: failures}
+}
+
+// SecretEnv resolves every secret reference in the stored remotes into the
+// RCLONE_CONFIG_* variables rclone reads at run time, so resolved secrets
+// are passed to rclone without being written to rclone.conf. Remotes that
+// resolve are returned even when others fail; the error is then a
+// *SecretError naming the failed ones.
+func (m *Manager) SecretEnv() ([]string, error) {
+	config, err := m.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	env := make([]string, 0)
+	var failed []SecretFailure
+	for _, remote := range config.Remotes {
+		for _, option := range remote.rcloneOptions() {
+			if !IsSecretRef(option[1]) {
+				continue
+			}
+			secret, err := ResolveSecret(option[1])
+			if err == nil {
+				secret, err = rcloneValue(option[0], secret)
+			}
+			if err != nil {
+				failed = append(failed, SecretFailure{Remote: remote.Name, Option: option[0], Err: err})
+				continue
+			}
+			env = append(env, RcloneEnvName(remote.Name, option[0])+"="+secret)
+		}
+	}
+
+	if len(failed) > 0 {
+		return env, &SecretError{Failures: failed}
+	}
+	return env, nil
+}
+
+// Env returns the RCLONE_CONFIG_* variables that define the remote under
+// name without rclone.conf, with its secret references resolved, so its
+// settings can be tried before they are saved
+func (r RemoteConfig) Env(name string) ([]string, error) {
+	env := []string{RcloneEnvName(name, "type") + "=" + r.Type}
+	for _, option := range r.rcloneOptions() {
+		value, err := ResolveSecret(option[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", option[0], err)
+		}
+		value, err = rcloneValue(option[0], value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", option[0], err)
+		}
+		env = append(env, RcloneEnvName(name, option[0])+"="+value)
+	}
+	return env, nil
+}
+
+// RcloneEnv loads the default configuration and resolves its secret
+// references, see Manager.SecretEnv
+func RcloneEnv() ([]string, error) {
+	m, err := NewManager()
+	if err != nil {
+		return nil, err
+	}
+	return m.SecretEnv()
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// UploadTuning holds advanced settings for uploading large files to a
+// remote, such as multi-GB videos. They are written to rclone.conf as
+// options of the remote's backend, so each remote keeps its own. Empty
+// values keep rclone's defaults.
+type UploadTuning struct {
+	ChunkSize         string `json:"chunk_size,omitempty"`         // Size of each part of a multipart upload, e.g. "96M"
+	UploadCutoff      string `json:"upload_cutoff,omitempty"`      // Files larger than this are uploaded in parts
+	UploadConcurrency int    `json:"upload_concurrency,omitempty"` // Parts of one file uploaded at the same time
+}
+
+const (
+	mebibyte = 1 << 20
+	gibibyte = 1 << 30
+)
+
+// tuningLimits are the part sizes each backend accepts
+var tuningLimits = map[string]struct {
+	minChunk  int64
+	maxCutoff int64
+}{
+	"b2": {minChunk: 5 * mebibyte, maxCutoff: 4657 * mebibyte},
+	"s3": {minChunk: 5 * mebibyte, maxCutoff: 5 * gibibyte},
+}
+
+// IsZero reports whether no setting is changed from rclone's defaults
+func (t UploadTuning) IsZero() bool {
+	return t == UploadTuning{}
+}
+
+// Validate checks the settings against the limits of a backend type
+func (t UploadTuning) Validate(remoteType string) error {
+	if t.IsZero() {
+		return nil
+	}
+	limits, ok := tuningLimits[remoteType]
+	if !ok {
+		return fmt.Errorf("upload tuning is only supported for b2 and s3 remotes")
+	}
+
+	if t.ChunkSize != "" {
+		size, err := ParseSize(t.ChunkSize)
+		if err != nil {
+			return fmt.Errorf("invalid chunk size: %w", err)
+		}
+		if size < limits.minChunk {
+			return fmt.Errorf("chunk size must be at least %s for %s remotes", FormatSize(limits.minChunk), remoteType)
+		}
+	}
+	if t.UploadCutoff != "" {
+		size, err := ParseSize(t.UploadCutoff)
+		if err != nil {
+			return fmt.Errorf("invalid upload cutoff: %w", err)
+		}
+		if size > limits.maxCutoff {
+			return fmt.Errorf("upload cutoff cannot be more than %s for %s remotes", FormatSize(limits.maxCutoff), remoteType)
+		}
+	}
+	if t.UploadConcurrency < 0 {
+		return fmt.Errorf("upload concurrency cannot be negative")
+	}
+	return nil
+}
+
+// String describes the settings, e.g. "chunk size 96M, 8 parts at once"
+func (t UploadTuning) String() string {
+	var parts []string
+	if t.ChunkSize != "" {
+		parts = append(parts, "chunk size "+t.ChunkSize)
+	}
+	if t.UploadCutoff != "" {
+		parts = append(parts, "upload cutoff "+t.UploadCutoff)
+	}
+	if t.UploadConcurrency > 0 {
+		parts = append(parts, fmt.Sprintf("%d parts at once", t.UploadConcurrency))
+	}
+	if len(parts) == 0 {
+		return "rclone defaults"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// rcloneOptions returns the settings as rclone.conf options
+func (t UploadTuning) rcloneOptions() [][2]string {
+	var options [][2]string
+	if t.ChunkSize != "" {
+		options = append(options, [2]string{"chunk_size", rcloneSize(t.ChunkSize)})
+	}
+	if t.UploadCutoff != "" {
+		options = append(options, [2]string{"upload_cutoff", rcloneSize(t.UploadCutoff)})
+	}
+	if t.UploadConcurrency > 0 {
+		options = append(options, [2]string{"upload_concurrency", strconv.Itoa(t.UploadConcurrency)})
+	}
+	return options
+}
+
+// sizePattern matches sizes like "96M", "1.5G" or "200MiB"
+var sizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([KMGT])(?:I?B)?$`)
+
+// ParseSize parses a size with a binary unit, as rclone reads them, and
+// returns it in bytes. A unit is required, since rclone reads a bare
+// number as KiB.
+func ParseSize(s string) (int64, error) {
+	match := sizePattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if match == nil {
+		return 0, fmt.Errorf("'%s' is not a size like 96M or 1G", s)
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a size like 96M or 1G", s)
+	}
+	shift := 10 * (strings.Index("KMGT", match[2]) + 1)
+	return int64(value * float64(int64(1)<<shift)), nil
+}
+
+// FormatSize formats bytes in the units ParseSize reads, e.g. "5M"
+func FormatSize(bytes int64) string {
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"T", 1 << 40}, {"G", gibibyte}, {"M", mebibyte}, {"K", 1 << 10}} {
+		if bytes >= unit.size && bytes%unit.size == 0 {
+			return fmt.Sprintf("%d%s", bytes/unit.size, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dK", bytes>>10)
+}
+
+// rcloneSize returns a size parsed by ParseSize in the form rclone reads
+// in every version, e.g. "200MiB" as "200M"
+func rcloneSize(s string) string {
+	match := sizePattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if match == nil {
+		return s
+	}
+	return match[1] + match[2]
+}
@@ -0,0 +1,147 @@
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// gb is the gigabyte providers bill by
+const gb = 1e9
+
+// Pricing is a storage plan's list price. Prices are per GB and month, in
+// the provider's currency.
+type Pricing struct {
+	Name          string
+	Currency      string // Symbol, e.g. "$"
+	StoragePerGB  float64
+	EgressPerGB   float64
+	FreeStorageGB float64 // Stored for free each month
+	MinStorageGB  float64 // Billed at least this much storage
+	FreeEgressGB  float64 // Downloaded for free each month
+	// FreeEgressRatio allows downloading this many times the stored amount
+	// for free, e.g. 3 on B2
+	FreeEgressRatio float64
+}
+
+// Plans are the list prices of the plans cloud-sync can estimate, by key.
+// They are a snapshot and only meant for estimates; taxes, request fees and
+// minimum storage durations are left out.
+var Plans = map[string]Pricing{
+	"b2":                {Name: "Backblaze B2", Currency: "$", StoragePerGB: 0.006, EgressPerGB: 0.01, FreeStorageGB: 10, FreeEgressRatio: 3},
+	"s3-standard":       {Name: "Amazon S3 Standard", Currency: "$", StoragePerGB: 0.023, EgressPerGB: 0.09, FreeEgressGB: 100},
+	"s3-standard-ia":    {Name: "Amazon S3 Standard-IA", Currency: "$", StoragePerGB: 0.0125, EgressPerGB: 0.09, FreeEgressGB: 100},
+	"s3-onezone-ia":     {Name: "Amazon S3 One Zone-IA", Currency: "$", StoragePerGB: 0.01, EgressPerGB: 0.09, FreeEgressGB: 100},
+	"s3-glacier-ir":     {Name: "Amazon S3 Glacier Instant Retrieval", Currency: "$", StoragePerGB: 0.004, EgressPerGB: 0.09, FreeEgressGB: 100},
+	"s3-glacier":        {Name: "Amazon S3 Glacier Flexible Retrieval", Currency: "$", StoragePerGB: 0.0036, EgressPerGB: 0.09, FreeEgressGB: 100},
+	"s3-deep-archive":   {Name: "Amazon S3 Glacier Deep Archive", Currency: "$", StoragePerGB: 0.00099, EgressPerGB: 0.09, FreeEgressGB: 100},
+	"wasabi":            {Name: "Wasabi", Currency: "$", StoragePerGB: 0.00699, MinStorageGB: 1000},
+	"scaleway-standard": {Name: "Scaleway Standard", Currency: "€", StoragePerGB: 0.0146, EgressPerGB: 0.01, FreeEgressGB: 75},
+	"scaleway-onezone":  {Name: "Scaleway One Zone-IA", Currency: "€", StoragePerGB: 0.0075, EgressPerGB: 0.01, FreeEgressGB: 75},
+	"scaleway-glacier":  {Name: "Scaleway Glacier", Currency: "€", StoragePerGB: 0.00254, EgressPerGB: 0.01, FreeEgressGB: 75},
+}
+
+// awsStorageClasses maps S3 storage classes to plans
+var awsStorageClasses = map[string]string{
+	"":                    "s3-standard",
+	"STANDARD":            "s3-standard",
+	"INTELLIGENT_TIERING": "s3-standard",
+	"STANDARD_IA":         "s3-standard-ia",
+	"ONEZONE_IA":          "s3-onezone-ia",
+	"GLACIER_IR":          "s3-glacier-ir",
+	"GLACIER":             "s3-glacier",
+	"DEEP_ARCHIVE":        "s3-deep-archive",
+}
+
+// PlanFor returns the plan of a remote from its rclone.conf section, and
+// false for providers without a pricing table
+func PlanFor(remote map[string]string) (Pricing, bool) {
+	key := ""
+	class := strings.ToUpper(remote["storage_class"])
+	switch remote["type"] {
+	case "b2":
+		key = "b2"
+	case "s3":
+		switch strings.ToLower(remote["provider"]) {
+		case "aws":
+			key = awsStorageClasses[class]
+		case "wasabi":
+			key = "wasabi"
+		case "scaleway":
+			switch class {
+			case "GLACIER":
+				key = "scaleway-glacier"
+			case "ONEZONE_IA":
+				key = "scaleway-onezone"
+			default:
+				key = "scaleway-standard"
+			}
+		}
+	}
+	plan, ok := Plans[key]
+	return plan, ok
+}
+
+// Usage is what a remote stores and serves in a month
+type Usage struct {
+	StoredBytes int64
+	EgressBytes int64 // Downloaded from the remote
+}
+
+// Monthly returns the estimated monthly bill for usage
+func (p Pricing) Monthly(usage Usage) float64 {
+	stored := float64(usage.StoredBytes) / gb
+	billedStorage := max(stored, p.MinStorageGB) - p.FreeStorageGB
+	freeEgress := p.FreeEgressGB + p.FreeEgressRatio*stored
+	billedEgress := float64(usage.EgressBytes)/gb - freeEgress
+	return max(billedStorage, 0)*p.StoragePerGB + max(billedEgress, 0)*p.EgressPerGB
+}
+
+// Line is the estimate for one remote
+type Line struct {
+	Remote string
+	Plan   Pricing
+	Usage  Usage
+	Cost   float64
+}
+
+// Estimate is the estimated monthly bill of a set of remotes
+type Estimate struct {
+	Lines    []Line
+	Unpriced []string // Remotes of providers without a pricing table
+}
+
+// Add estimates a remote's bill and adds it to the estimate
+func (e *Estimate) Add(remote string, plan Pricing, usage Usage) {
+	e.Lines = append(e.Lines, Line{Remote: remote, Plan: plan, Usage: usage, Cost: plan.Monthly(usage)})
+}
+
+// Totals returns the estimated bill by currency
+func (e *Estimate) Totals() map[string]float64 {
+	totals := map[string]float64{}
+	for _, line := range e.Lines {
+		totals[line.Plan.Currency] += line.Cost
+	}
+	return totals
+}
+
+// Total formats the estimated bill, e.g. "$1.23/month" or "$1.23 + €0.40/month"
+// for remotes billed in different currencies. It is empty without any priced
+// remote.
+func (e *Estimate) Total() string {
+	totals := e.Totals()
+	if len(totals) == 0 {
+		return ""
+	}
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	parts := make([]string, len(currencies))
+	for i, currency := range currencies {
+		parts[i] = fmt.Sprintf("%s%.2f", currency, totals[currency])
+	}
+	return strings.Join(parts, " + ") + "/month"
+}
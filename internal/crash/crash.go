@@ -0,0 +1,160 @@
+// Package crash writes a report when cloud-sync panics: the stack, the
+// events that led up to it and the configuration without its keys. Reports
+// stay on the machine, in a directory the user can attach to a bug report.
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxEvents is how many recent events a report lists
+const MaxEvents = 50
+
+// Reporter keeps the recent events of a program and writes a report the
+// first time it panics
+type Reporter struct {
+	dir      string
+	version  string
+	snapshot func() (any, error) // Configuration to include, without secrets
+
+	mu      sync.Mutex
+	events  []event
+	crashed bool
+	path    string // Report written, if any
+	err     error  // Why it could not be written
+}
+
+// event is a recorded event, with how many times in a row it happened
+type event struct {
+	text  string
+	count int
+}
+
+// NewReporter creates a reporter that writes its reports to dir. snapshot
+// returns the configuration to include and must leave out credentials; it
+// is only called on a crash.
+func NewReporter(dir, version string, snapshot func() (any, error)) *Reporter {
+	return &Reporter{dir: dir, version: version, snapshot: snapshot}
+}
+
+// Record adds an event to the recent ones. Repeats of the last event, such
+// as spinner ticks, are counted instead of pushing older events out.
+func (r *Reporter) Record(text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := len(r.events); n > 0 && r.events[n-1].text == text {
+		r.events[n-1].count++
+		return
+	}
+	if len(r.events) == MaxEvents {
+		r.events = append(r.events[:0], r.events[1:]...)
+	}
+	r.events = append(r.events, event{text: text, count: 1})
+}
+
+// Recent returns the recorded events, oldest first
+func (r *Reporter) Recent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recent := make([]string, 0, len(r.events))
+	for _, e := range r.events {
+		if e.count > 1 {
+			recent = append(recent, fmt.Sprintf("%s (x%d)", e.text, e.count))
+		} else {
+			recent = append(recent, e.text)
+		}
+	}
+	return recent
+}
+
+// Report writes a report of the panic value and its stack and returns its
+// path. Only the first panic is reported; later ones return that report.
+func (r *Reporter) Report(value any, stack []byte) (string, error) {
+	recent := r.Recent()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.crashed {
+		return r.path, r.err
+	}
+	r.crashed = true
+	r.path, r.err = r.write(value, stack, recent)
+	return r.path, r.err
+}
+
+// write writes a report into the crash directory
+func (r *Reporter) write(value any, stack []byte, recent []string) (string, error) {
+	if err := os.MkdirAll(r.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+	now := time.Now()
+	path := filepath.Join(r.dir, "crash-"+now.Format("20060102-150405")+".txt")
+	if err := os.WriteFile(path, []byte(r.format(now, value, stack, recent)), 0600); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	return path, nil
+}
+
+// Crashed reports whether a panic was reported
+func (r *Reporter) Crashed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.crashed
+}
+
+// Message is what to tell the user after a crash: where the report is, or
+// why it could not be written
+func (r *Reporter) Message() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return fmt.Sprintf("cloud-sync crashed, and the crash report could not be saved: %v", r.err)
+	}
+	return fmt.Sprintf("cloud-sync crashed. A report was saved to %s; please attach it to a bug report.", r.path)
+}
+
+// format renders a report
+func (r *Reporter) format(now time.Time, value any, stack []byte, recent []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cloud-sync crash report\n")
+	fmt.Fprintf(&b, "Time: %s\n", now.Format("2006-01-02 15:04:05 -0700"))
+	fmt.Fprintf(&b, "Version: %s\n", r.version)
+	fmt.Fprintf(&b, "Go: %s %s/%s\n\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	fmt.Fprintf(&b, "Panic: %v\n\n", value)
+	fmt.Fprintf(&b, "Stack:\n%s\n", strings.TrimRight(string(stack), "\n"))
+
+	fmt.Fprintf(&b, "\nRecent events (oldest first):\n")
+	if len(recent) == 0 {
+		b.WriteString("  none\n")
+	}
+	for _, e := range recent {
+		fmt.Fprintf(&b, "  %s\n", e)
+	}
+
+	b.WriteString("\nConfiguration (credentials removed):\n")
+	if r.snapshot == nil {
+		b.WriteString("  not available\n")
+		return b.String()
+	}
+	snapshot, err := r.snapshot()
+	if err == nil {
+		var data []byte
+		if data, err = json.MarshalIndent(snapshot, "", "  "); err == nil {
+			b.Write(data)
+			b.WriteString("\n")
+			return b.String()
+		}
+	}
+	fmt.Fprintf(&b, "  not available: %v\n", err)
+	return b.String()
+}
@@ -0,0 +1,162 @@
+// Package debuglog writes the interface's debug log: what messages it
+// received and what they did, for tracking down rendering and navigation
+// bugs. The log is rotated so it cannot grow without bound.
+package debuglog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is how much is logged
+type Level int
+
+const (
+	// Off logs nothing
+	Off Level = iota
+	// Info logs keys, mouse clicks, resizes and how they move the menu
+	Info
+	// Debug also logs every other message, such as ticks and command
+	// results, and the size of rendered views
+	Debug
+)
+
+// String implements fmt.Stringer
+func (l Level) String() string {
+	switch l {
+	case Info:
+		return "info"
+	case Debug:
+		return "debug"
+	default:
+		return "off"
+	}
+}
+
+const (
+	// MaxSize is the size past which the log is rotated
+	MaxSize = 1 << 20
+	// Keep is how many rotated logs are kept, as debug.log.1 (newest) to
+	// debug.log.3
+	Keep = 3
+)
+
+// FileName is the name of the log in the config directory
+const FileName = "debug.log"
+
+// Logger appends timestamped lines to a log file at a level that can be
+// changed while running. The file is opened on the first line logged.
+type Logger struct {
+	path string
+
+	mu    sync.Mutex
+	level Level
+	file  *os.File
+	size  int64
+}
+
+// New creates a logger writing to path at level
+func New(path string, level Level) *Logger {
+	return &Logger{path: path, level: level}
+}
+
+// Path returns the log file's path
+func (l *Logger) Path() string {
+	return l.path
+}
+
+// Level returns the current level
+func (l *Logger) Level() Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.level
+}
+
+// SetLevel changes the level. Turning the log off closes the file.
+func (l *Logger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+	if level == Off {
+		l.close()
+	}
+}
+
+// Enabled reports whether lines at level are logged
+func (l *Logger) Enabled(level Level) bool {
+	return level != Off && l.Level() >= level
+}
+
+// Printf logs a line at level. Errors writing the log are ignored, since
+// the log must never get in the way of the interface.
+func (l *Logger) Printf(level Level, format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if level == Off || l.level < level {
+		return
+	}
+
+	line := fmt.Sprintf("[%s] %s\n", time.Now().Format("2006/01/02 15:04:05.000"), strings.TrimRight(fmt.Sprintf(format, args...), "\n"))
+	if l.file != nil && l.size+int64(len(line)) > MaxSize {
+		l.close()
+		l.rotate()
+	}
+	if l.file == nil && !l.open() {
+		return
+	}
+	n, _ := l.file.WriteString(line)
+	l.size += int64(n)
+}
+
+// Close closes the log file
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.close()
+}
+
+// open opens the log for appending and reports whether it could. A log
+// already past MaxSize is rotated first.
+func (l *Logger) open() bool {
+	if info, err := os.Stat(l.path); err == nil && info.Size() >= MaxSize {
+		l.rotate()
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return false
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return false
+	}
+	l.file, l.size = f, info.Size()
+	return true
+}
+
+// close closes the file if it is open
+func (l *Logger) close() error {
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file, l.size = nil, 0
+	return err
+}
+
+// rotate moves debug.log to debug.log.1, shifting the older logs up and
+// dropping the oldest
+func (l *Logger) rotate() {
+	os.Remove(fmt.Sprintf("%s.%d", l.path, Keep))
+	for i := Keep - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+	}
+	os.Rename(l.path, l.path+".1")
+}
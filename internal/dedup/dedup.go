@@ -0,0 +1,285 @@
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// hashPreference orders the hashes rclone lists, so files on remotes that
+// keep several are grouped by the same one
+var hashPreference = []string{"sha1", "md5", "sha256"}
+
+// Copy is one place a file is backed up from
+type Copy struct {
+	Pair string
+	Path string // Relative to the pair's folder or remote path
+}
+
+// String formats the copy as pair:path
+func (c Copy) String() string {
+	return c.Pair + ":" + c.Path
+}
+
+// Group is a file with the same content in several places
+type Group struct {
+	Size   int64
+	Hash   string
+	Copies []Copy
+}
+
+// Savings returns the bytes kept by backing up only one of the copies
+func (g Group) Savings() int64 {
+	return g.Size * int64(len(g.Copies)-1)
+}
+
+// Overlap is a pair whose folder lies inside another pair's, so every file
+// of the inner pair is backed up twice
+type Overlap struct {
+	Outer string
+	Inner string
+	Path  string // Folder or remote path of the inner pair
+}
+
+// Report lists the files backed up more than once
+type Report struct {
+	Pairs    int
+	Files    int
+	Groups   []Group // Most savings first
+	Overlaps []Overlap
+}
+
+// Savings returns the bytes all groups could save
+func (r *Report) Savings() int64 {
+	var total int64
+	for _, g := range r.Groups {
+		total += g.Savings()
+	}
+	return total
+}
+
+// ExtraCopies returns how many copies could be left out
+func (r *Report) ExtraCopies() int {
+	extra := 0
+	for _, g := range r.Groups {
+		extra += len(g.Copies) - 1
+	}
+	return extra
+}
+
+// file is a file found while scanning
+type file struct {
+	copy Copy
+	size int64
+	abs  string // Absolute local path, empty for remote files
+	hash string
+}
+
+// ScanLocal hashes the files in every pair's local folder and groups those
+// with the same content. Only files sharing their size with another are
+// read. Files that cannot be read are skipped.
+func ScanLocal(pairs []syncconfig.SyncPair) (*Report, error) {
+	var files []file
+	for _, pair := range pairs {
+		root := pair.LocalPath
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, file{copy: Copy{Pair: pair.Name, Path: rel}, size: info.Size(), abs: path})
+			return nil
+		})
+	}
+
+	// A file reached through overlapping pairs is only read once
+	hashes := map[string]string{}
+	candidates := sameSize(files)
+	for i := range candidates {
+		f := &candidates[i]
+		hash, ok := hashes[f.abs]
+		if !ok {
+			hash, _ = hashFile(f.abs)
+			hashes[f.abs] = hash
+		}
+		f.hash = hash
+	}
+
+	report := newReport(pairs, files, candidates)
+	report.Overlaps = localOverlaps(pairs)
+	return report, nil
+}
+
+// ScanRemote lists each remote pair's destination with its hashes and groups
+// the files with the same content. Listings are kept in cache like the
+// index of a sync. Remotes without hashes, and different remotes keeping
+// different hashes, cannot be compared.
+func ScanRemote(mgr *rclone.Manager, cache *rclone.IndexCache, pairs []syncconfig.SyncPair) (*Report, error) {
+	var remotePairs []syncconfig.SyncPair
+	var files []file
+	for _, pair := range pairs {
+		if pair.IsLocal() {
+			continue
+		}
+		remotePairs = append(remotePairs, pair)
+		source := pair.Target()
+		if _, err := mgr.BuildIndex(source, cache, nil); err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", source, err)
+		}
+		err := cache.ReadListing(source, func(entry rclone.IndexEntry) error {
+			files = append(files, file{
+				copy: Copy{Pair: pair.Name, Path: entry.Path},
+				size: entry.Size,
+				hash: preferredHash(entry.Hashes),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := newReport(remotePairs, files, sameSize(files))
+	report.Overlaps = remoteOverlaps(remotePairs)
+	return report, nil
+}
+
+// sameSize returns the non-empty files that share their size with another,
+// the only ones that can be duplicates
+func sameSize(files []file) []file {
+	counts := map[int64]int{}
+	for _, f := range files {
+		counts[f.size]++
+	}
+	var candidates []file
+	for _, f := range files {
+		if f.size > 0 && counts[f.size] > 1 {
+			candidates = append(candidates, f)
+		}
+	}
+	return candidates
+}
+
+// newReport groups the hashed candidates by size and hash
+func newReport(pairs []syncconfig.SyncPair, files, candidates []file) *Report {
+	type key struct {
+		size int64
+		hash string
+	}
+	var order []key
+	groups := map[key]*Group{}
+	for _, f := range candidates {
+		if f.hash == "" {
+			continue
+		}
+		k := key{f.size, f.hash}
+		g, ok := groups[k]
+		if !ok {
+			g = &Group{Size: f.size, Hash: f.hash}
+			groups[k] = g
+			order = append(order, k)
+		}
+		g.Copies = append(g.Copies, f.copy)
+	}
+
+	report := &Report{Pairs: len(pairs), Files: len(files)}
+	for _, k := range order {
+		if g := groups[k]; len(g.Copies) > 1 {
+			report.Groups = append(report.Groups, *g)
+		}
+	}
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		return report.Groups[i].Savings() > report.Groups[j].Savings()
+	})
+	return report
+}
+
+// localOverlaps finds pairs whose folder is inside another pair's folder
+func localOverlaps(pairs []syncconfig.SyncPair) []Overlap {
+	return overlaps(pairs, func(p syncconfig.SyncPair) (string, string) {
+		return filepath.Clean(p.LocalPath), string(filepath.Separator)
+	}, func(p syncconfig.SyncPair) string { return p.LocalPath })
+}
+
+// remoteOverlaps finds pairs whose remote path is inside another pair's on
+// the same remote
+func remoteOverlaps(pairs []syncconfig.SyncPair) []Overlap {
+	return overlaps(pairs, func(p syncconfig.SyncPair) (string, string) {
+		return p.RemoteName + ":" + strings.Trim(p.RemotePath, "/"), "/"
+	}, syncconfig.SyncPair.Target)
+}
+
+// overlaps compares the locations of every two pairs. Two pairs with the
+// same location are reported once.
+func overlaps(pairs []syncconfig.SyncPair, location func(syncconfig.SyncPair) (string, string), display func(syncconfig.SyncPair) string) []Overlap {
+	var found []Overlap
+	for i, outer := range pairs {
+		outerPath, sep := location(outer)
+		for j, inner := range pairs {
+			innerPath, _ := location(inner)
+			if i == j || (innerPath == outerPath && j < i) {
+				continue
+			}
+			if within(innerPath, outerPath, sep) {
+				found = append(found, Overlap{Outer: outer.Name, Inner: inner.Name, Path: display(inner)})
+			}
+		}
+	}
+	return found
+}
+
+// within reports whether path is dir or lies inside it. A remote's root,
+// such as "b2:", contains everything on the remote.
+func within(path, dir, sep string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, sep)+sep) ||
+		(strings.HasSuffix(dir, ":") && strings.HasPrefix(path, dir))
+}
+
+// preferredHash returns the first hash of hashPreference the entry has, or
+// else any, as type:value
+func preferredHash(hashes map[string]string) string {
+	for _, name := range hashPreference {
+		if value := hashes[name]; value != "" {
+			return name + ":" + value
+		}
+	}
+	names := make([]string, 0, len(hashes))
+	for name, value := range hashes {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0] + ":" + hashes[names[0]]
+}
+
+// hashFile returns the SHA-256 of a local file
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,429 @@
+package doctor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/archive"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/scripts"
+	"github.com/andreisuslov/cloud-sync/internal/status"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// Level is the outcome of a check
+type Level int
+
+const (
+	Pass Level = iota
+	Warn
+	Fail
+)
+
+// String returns the label printed before a check
+func (l Level) String() string {
+	switch l {
+	case Warn:
+		return "WARN"
+	case Fail:
+		return "FAIL"
+	default:
+		return "PASS"
+	}
+}
+
+// Check is the result of one diagnostic
+type Check struct {
+	Name   string // What was checked, e.g. "rclone"
+	Level  Level
+	Detail string // What was found
+	Fix    string // How to resolve a warning or failure
+}
+
+// Options locate what the checks inspect
+type Options struct {
+	Config     *config.Manager
+	Pairs      *syncconfig.Manager
+	Launchd    *launchd.Manager
+	RclonePath string // rclone binary; empty when it was not found
+}
+
+// Run performs every check in order. Checks that depend on a file that
+// cannot be read are reported as skipped warnings.
+func Run(opts Options) []Check {
+	var checks []Check
+	appConfig, check := checkConfig(opts.Config)
+	checks = append(checks, check)
+	pairs, check := checkPairs(opts.Pairs)
+	checks = append(checks, check)
+
+	checks = append(checks, checkRclone(opts.RclonePath))
+	if check, ok := checkZstd(pairs); ok {
+		checks = append(checks, check)
+	}
+	if appConfig == nil {
+		return append(checks, Check{
+			Name:   "setup",
+			Level:  Warn,
+			Detail: "rclone.conf, LaunchAgent, scripts, lockfile and logs were not checked",
+			Fix:    "Fix config.json and run 'cloud-sync doctor' again",
+		})
+	}
+
+	checks = append(checks, checkRcloneConfig(appConfig, pairs))
+	checks = append(checks, checkLaunchAgent(appConfig, opts.Launchd))
+	checks = append(checks, checkScripts(appConfig))
+	checks = append(checks, checkLockfile(appConfig.LogDir))
+	checks = append(checks, checkLogDir(appConfig.LogDir))
+	return checks
+}
+
+// checkConfig loads config.json
+func checkConfig(manager *config.Manager) (*config.AppConfig, Check) {
+	check := Check{Name: "config.json"}
+	if !manager.ConfigExists() {
+		check.Detail = "not created yet; defaults are used"
+		appConfig, _ := manager.Load()
+		return appConfig, check
+	}
+
+	appConfig, err := manager.Load()
+	if err != nil {
+		check.Level = Fail
+		check.Detail = err.Error()
+		check.Fix = corruptFix(err, manager.GetConfigPath())
+		return nil, check
+	}
+	check.Detail = fmt.Sprintf("valid, %d remote(s)", len(appConfig.Remotes))
+	return appConfig, check
+}
+
+// checkPairs loads sync-config.json and validates each pair
+func checkPairs(manager *syncconfig.Manager) ([]syncconfig.SyncPair, Check) {
+	check := Check{Name: "sync-config.json"}
+	if !manager.ConfigExists() {
+		check.Detail = "not created yet; no sync pairs"
+		return nil, check
+	}
+
+	syncConfig, err := manager.Load()
+	if err != nil {
+		check.Level = Fail
+		check.Detail = err.Error()
+		check.Fix = corruptFix(err, manager.GetConfigPath())
+		return nil, check
+	}
+
+	var problems []string
+	for _, pair := range syncConfig.SyncPairs {
+		if err := syncconfig.ValidateSyncPair(&pair); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", pair.Name, err))
+			continue
+		}
+		if pair.Enabled {
+			if _, err := os.Stat(pair.LocalPath); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: local folder %s is missing", pair.Name, pair.LocalPath))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		check.Level = Fail
+		check.Detail = strings.Join(problems, "; ")
+		check.Fix = "Edit the pairs in Sync Pairs, or disable those whose folder is gone"
+		return syncConfig.SyncPairs, check
+	}
+
+	check.Detail = fmt.Sprintf("valid, %d sync pair(s)", len(syncConfig.SyncPairs))
+	return syncConfig.SyncPairs, check
+}
+
+// corruptFix suggests how to recover a file that could not be loaded
+func corruptFix(err error, path string) string {
+	var corrupt *jsonfile.CorruptError
+	if errors.As(err, &corrupt) && corrupt.HasBackup() {
+		return fmt.Sprintf("Restore the backup with 'cloud-sync config restore %s'", filepath.Base(path))
+	}
+	return fmt.Sprintf("Correct or remove %s", path)
+}
+
+// checkRclone finds rclone and checks its version
+func checkRclone(rclonePath string) Check {
+	check := Check{Name: "rclone"}
+	if rclonePath == "" {
+		check.Level = Fail
+		check.Detail = "not found in PATH"
+		check.Fix = "Install it with 'brew install rclone'"
+		return check
+	}
+
+	version, err := rclone.NewManager(rclonePath).Version()
+	if err != nil {
+		check.Level = Fail
+		check.Detail = fmt.Sprintf("%s does not run: %v", rclonePath, err)
+		check.Fix = "Reinstall it with 'brew reinstall rclone'"
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("%s (%s)", version, rclonePath)
+	if parsed, err := rclone.ParseVersion(version); err != nil {
+		check.Level = Warn
+		check.Detail = fmt.Sprintf("unrecognised version '%s' (%s)", version, rclonePath)
+	} else if parsed.Less(rclone.MinVersion) {
+		check.Level = Fail
+		check.Detail = fmt.Sprintf("%s is older than %s (%s)", version, rclone.MinVersion, rclonePath)
+		check.Fix = "Update it with 'brew upgrade rclone'"
+	}
+	return check
+}
+
+// checkZstd finds zstd when an enabled pair uploads archives, which are
+// compressed with it. ok is false when no pair needs it.
+func checkZstd(pairs []syncconfig.SyncPair) (Check, bool) {
+	var names []string
+	for _, pair := range pairs {
+		if pair.Enabled && pair.Archive {
+			names = append(names, pair.Name)
+		}
+	}
+	if len(names) == 0 {
+		return Check{}, false
+	}
+
+	check := Check{Name: "zstd"}
+	path, err := archive.FindZstd()
+	if err != nil {
+		check.Level = Fail
+		check.Detail = fmt.Sprintf("not found, but %s upload archives", strings.Join(names, ", "))
+		check.Fix = "Install it with 'brew install zstd'"
+		return check, true
+	}
+	check.Detail = path
+	return check, true
+}
+
+// checkRcloneConfig compares rclone.conf with the remotes cloud-sync manages
+// and the remotes the sync pairs use
+func checkRcloneConfig(appConfig *config.AppConfig, pairs []syncconfig.SyncPair) Check {
+	check := Check{Name: "rclone.conf"}
+
+	sections, err := readRcloneSections(appConfig.RcloneConfig)
+	if err != nil && !os.IsNotExist(err) {
+		check.Level = Fail
+		check.Detail = err.Error()
+		check.Fix = fmt.Sprintf("Make %s readable", appConfig.RcloneConfig)
+		return check
+	}
+
+	var problems []string
+	for _, remote := range appConfig.Remotes {
+		sectionType, ok := sections[remote.Name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("remote '%s' is missing", remote.Name))
+		case sectionType != remote.Type:
+			problems = append(problems, fmt.Sprintf("remote '%s' is type %s, not %s", remote.Name, sectionType, remote.Type))
+		}
+	}
+	if len(problems) > 0 {
+		check.Level = Fail
+		check.Detail = strings.Join(problems, "; ")
+		check.Fix = "Save the remote again in Installation & Setup (e: edit remotes) to rewrite rclone.conf"
+		return check
+	}
+
+	known := make(map[string]bool, len(sections))
+	for name := range sections {
+		known[name] = true
+	}
+	for _, pair := range pairs {
+		for _, dest := range pair.AllDestinations() {
+			if !known[dest.RemoteName] {
+				problems = append(problems, fmt.Sprintf("pair '%s' uses unknown remote '%s'", pair.Name, dest.RemoteName))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		check.Level = Fail
+		check.Detail = strings.Join(problems, "; ")
+		check.Fix = "Add the remote in Installation & Setup, or edit the pair to use another one"
+		return check
+	}
+
+	if len(sections) == 0 {
+		check.Detail = "no remotes configured yet"
+		return check
+	}
+	check.Detail = fmt.Sprintf("%d remote(s), consistent with config.json", len(sections))
+	return check
+}
+
+// readRcloneSections returns the type of each remote in an rclone.conf
+func readRcloneSections(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sections := make(map[string]string)
+	current := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			sections[current] = ""
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && current != "" && strings.TrimSpace(key) == "type" {
+			sections[current] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return sections, nil
+}
+
+// checkLaunchAgent checks that an enabled schedule has a loaded agent
+func checkLaunchAgent(appConfig *config.AppConfig, manager *launchd.Manager) Check {
+	check := Check{Name: "LaunchAgent"}
+	if !appConfig.LaunchAgent.Enabled {
+		check.Detail = "scheduling is off"
+		return check
+	}
+
+	plistPath := manager.GetPlistPath()
+	if _, err := os.Stat(plistPath); err != nil {
+		check.Level = Fail
+		check.Detail = fmt.Sprintf("scheduling is on but %s is missing", plistPath)
+		check.Fix = "Save the schedule again in Scheduling & Maintenance (c: Edit schedule)"
+		if manager.IsDaemon() {
+			check.Fix = "Run 'cloud-sync daemon' again"
+		}
+		return check
+	}
+
+	agentStatus, err := manager.GetStatus()
+	if err != nil {
+		check.Level = Warn
+		check.Detail = fmt.Sprintf("could not read its state: %v", err)
+		return check
+	}
+	if !agentStatus.Loaded {
+		check.Level = Fail
+		check.Detail = "installed but not loaded; scheduled backups will not run"
+		check.Fix = fmt.Sprintf("Load it in Scheduling & Maintenance, or run 'launchctl bootstrap gui/%d %s'", os.Getuid(), plistPath)
+		if manager.IsDaemon() {
+			check.Fix = fmt.Sprintf("Run 'sudo launchctl bootstrap system %s'", plistPath)
+		}
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("loaded (%s)", appConfig.LaunchAgent.EffectiveSchedule())
+	if agentStatus.LastExit != 0 {
+		check.Level = Warn
+		check.Detail += fmt.Sprintf(", last run exited with %d", agentStatus.LastExit)
+		check.Fix = "See the failed session in the Log Viewer"
+	}
+	return check
+}
+
+// checkScripts checks that the generated scripts exist and are executable
+func checkScripts(appConfig *config.AppConfig) Check {
+	check := Check{Name: "scripts"}
+
+	var missing, notExecutable []string
+	for _, name := range scripts.ScriptNames {
+		info, err := os.Stat(filepath.Join(appConfig.BinDir, name))
+		if err != nil {
+			missing = append(missing, name)
+		} else if info.Mode().Perm()&0111 == 0 {
+			notExecutable = append(notExecutable, name)
+		}
+	}
+
+	if len(notExecutable) > 0 {
+		check.Level = Fail
+		check.Detail = fmt.Sprintf("not executable: %s", strings.Join(notExecutable, ", "))
+		check.Fix = fmt.Sprintf("Run 'chmod +x' on them in %s", appConfig.BinDir)
+		return check
+	}
+	if len(missing) == len(scripts.ScriptNames) && !appConfig.LaunchAgent.Enabled {
+		check.Detail = "not generated; only scheduled backups need them"
+		return check
+	}
+	if len(missing) > 0 {
+		check.Level = Warn
+		if appConfig.LaunchAgent.Enabled {
+			check.Level = Fail
+		}
+		check.Detail = fmt.Sprintf("missing from %s: %s", appConfig.BinDir, strings.Join(missing, ", "))
+		check.Fix = "Run step 7, Generate Backup Scripts, in Installation & Setup"
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("%d scripts in %s", len(scripts.ScriptNames), appConfig.BinDir)
+	return check
+}
+
+// checkLockfile reports a lockfile left behind by a backup that died
+func checkLockfile(logDir string) Check {
+	check := Check{Name: "lockfile"}
+	lock := lockfile.NewManager(logDir)
+	if !lock.Exists() {
+		check.Detail = "no backup is running"
+		return check
+	}
+
+	age, _ := lock.GetAge()
+	if lock.IsStale(status.StaleLockAge) {
+		check.Level = Warn
+		check.Detail = fmt.Sprintf("%s is %s old and blocks backups", lock.GetPath(), age.Round(time.Minute))
+		check.Fix = fmt.Sprintf("If no backup is running, remove it with 'rm %s'", lock.GetPath())
+		return check
+	}
+	check.Detail = fmt.Sprintf("a backup is running (started %s ago)", age.Round(time.Second))
+	return check
+}
+
+// checkLogDir checks that logs can be written
+func checkLogDir(logDir string) Check {
+	check := Check{Name: "logs"}
+
+	info, err := os.Stat(logDir)
+	if os.IsNotExist(err) {
+		check.Level = Warn
+		check.Detail = fmt.Sprintf("%s does not exist yet", logDir)
+		check.Fix = fmt.Sprintf("It is created by the first backup, or run 'mkdir -p %s'", logDir)
+		return check
+	}
+	if err != nil || !info.IsDir() {
+		check.Level = Fail
+		check.Detail = fmt.Sprintf("%s is not a directory", logDir)
+		check.Fix = fmt.Sprintf("Move %s aside so the log directory can be created", logDir)
+		return check
+	}
+
+	probe, err := os.CreateTemp(logDir, ".doctor-*")
+	if err != nil {
+		check.Level = Fail
+		check.Detail = fmt.Sprintf("%s is not writable: %v", logDir, err)
+		check.Fix = fmt.Sprintf("Run 'chmod u+w %s'", logDir)
+		return check
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	check.Detail = fmt.Sprintf("%s is writable", logDir)
+	return check
+}
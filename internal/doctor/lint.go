@@ -0,0 +1,313 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/scripts"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/volume"
+)
+
+// Rules of the issues Lint reports
+const (
+	RuleUnknownRemote  = "unknown-remote"       // A pair syncs to a remote that is not configured
+	RuleMissingFolder  = "missing-local-path"   // A pair's local folder is gone
+	RuleDuplicateLocal = "duplicate-local-path" // Several pairs sync the same folder
+	RuleStaleRclone    = "stale-rclone-path"    // config.json or a script runs an rclone that is gone
+	RuleMovedProgram   = "moved-agent-program"  // An agent runs a script or binary that moved
+)
+
+// engineScript is the generated script that runs rclone, from the path on
+// its RCLONE_PATH line
+const engineScript = "run_rclone_sync.sh"
+
+// Issue is an inconsistency between the sync pairs, the remotes, the
+// scripts and the LaunchAgents
+type Issue struct {
+	Rule   string // One of the Rule constants
+	Detail string // What was found
+	Fix    string // Command or step that resolves it
+	Repair string // What 'cloud-sync lint --fix' does about it; empty when it needs a decision
+
+	repair func() error
+}
+
+// Repairable reports whether the issue can be repaired without asking
+func (i Issue) Repairable() bool {
+	return i.repair != nil
+}
+
+// Apply repairs the issue
+func (i Issue) Apply() error {
+	if i.repair == nil {
+		return fmt.Errorf("%s cannot be repaired automatically: %s", i.Rule, i.Fix)
+	}
+	return i.repair()
+}
+
+// Lint cross-checks the configuration: pairs on remotes that do not exist,
+// local folders that are gone or synced by several pairs, and scripts and
+// agents running an rclone, script or binary that moved. Unlike Run it
+// needs config.json and sync-config.json, and fails when they cannot be
+// read.
+func Lint(opts Options) ([]Issue, error) {
+	appConfig, err := opts.Config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	pairs, err := opts.Pairs.ListSyncPairs()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	issues = append(issues, lintRemotes(appConfig, pairs)...)
+	issues = append(issues, lintLocalPaths(pairs)...)
+	issues = append(issues, lintRclonePaths(opts, appConfig)...)
+	if opts.Launchd != nil {
+		issues = append(issues, lintAgents(opts.Launchd, appConfig)...)
+	}
+	return issues, nil
+}
+
+// lintRemotes finds destinations on remotes that neither config.json nor
+// rclone.conf has
+func lintRemotes(appConfig *config.AppConfig, pairs []syncconfig.SyncPair) []Issue {
+	known := make(map[string]bool)
+	sections, _ := readRcloneSections(appConfig.RcloneConfig)
+	for name := range sections {
+		known[name] = true
+	}
+	for _, remote := range appConfig.Remotes {
+		known[remote.Name] = true
+	}
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []Issue
+	for _, pair := range pairs {
+		for _, dest := range pair.AllDestinations() {
+			if known[dest.RemoteName] {
+				continue
+			}
+			fix := fmt.Sprintf("Add remote '%s' under Remotes", dest.RemoteName)
+			if len(names) > 0 {
+				fix += fmt.Sprintf(", or point '%s' at one of %s in Sync Pairs", pair.Name, strings.Join(names, ", "))
+			}
+			issues = append(issues, Issue{
+				Rule:   RuleUnknownRemote,
+				Detail: fmt.Sprintf("pair '%s' syncs to %s, but remote '%s' is not configured", pair.Name, dest, dest.RemoteName),
+				Fix:    fix,
+			})
+		}
+	}
+	return issues
+}
+
+// lintLocalPaths finds local folders that are gone, and folders several
+// pairs sync. Folders on a drive that is not connected are not gone.
+func lintLocalPaths(pairs []syncconfig.SyncPair) []Issue {
+	var issues []Issue
+	var folders []string
+	byFolder := make(map[string][]string)
+	for _, pair := range pairs {
+		if pair.LocalPath == "" {
+			continue
+		}
+		folder := filepath.Clean(pair.LocalPath)
+		if _, err := os.Stat(folder); os.IsNotExist(err) {
+			if v, ok := volume.Of(folder); !ok || volume.Mounted(v) {
+				issues = append(issues, Issue{
+					Rule:   RuleMissingFolder,
+					Detail: fmt.Sprintf("pair '%s' syncs %s, which no longer exists", pair.Name, folder),
+					Fix:    fmt.Sprintf("Recreate it with 'mkdir -p \"%s\"', or point '%s' at the folder's new place in Sync Pairs", folder, pair.Name),
+				})
+			}
+		}
+
+		// Monitor pairs only compare the folder with its destination
+		if pair.IsMonitor() {
+			continue
+		}
+		if _, ok := byFolder[folder]; !ok {
+			folders = append(folders, folder)
+		}
+		byFolder[folder] = append(byFolder[folder], "'"+pair.Name+"'")
+	}
+
+	for _, folder := range folders {
+		names := byFolder[folder]
+		if len(names) < 2 {
+			continue
+		}
+		issues = append(issues, Issue{
+			Rule:   RuleDuplicateLocal,
+			Detail: fmt.Sprintf("pairs %s all sync %s", strings.Join(names, ", "), folder),
+			Fix:    fmt.Sprintf("Keep %s, add the others' remotes to it as extra destinations in Sync Pairs, and delete the others", names[0]),
+		})
+	}
+	return issues
+}
+
+// lintRclonePaths finds an rclone in config.json or the engine script that
+// is gone, e.g. after rclone moved from /usr/local to /opt/homebrew. They
+// are repaired with the rclone found now, if any.
+func lintRclonePaths(opts Options, appConfig *config.AppConfig) []Issue {
+	var issues []Issue
+	current := opts.RclonePath
+	install := "Install rclone with 'brew install rclone'"
+
+	if configured := appConfig.RclonePath; opts.Config.ConfigExists() && configured != "" && !exists(configured) {
+		issue := Issue{
+			Rule:   RuleStaleRclone,
+			Detail: fmt.Sprintf("config.json runs rclone from %s, which no longer exists", configured),
+			Fix:    install,
+		}
+		if current != "" {
+			issue.Fix = fmt.Sprintf("Set rclone_path to %s in %s", current, opts.Config.GetConfigPath())
+			issue.Repair = fmt.Sprintf("set rclone_path to %s", current)
+			issue.repair = func() error {
+				return opts.Config.UpdateRclonePath(current)
+			}
+		}
+		issues = append(issues, issue)
+	}
+
+	script := filepath.Join(appConfig.BinDir, engineScript)
+	if scripted, ok := scriptRclonePath(script); ok && !exists(scripted) {
+		issue := Issue{
+			Rule:   RuleStaleRclone,
+			Detail: fmt.Sprintf("%s runs rclone from %s, which no longer exists", engineScript, scripted),
+			Fix:    install,
+		}
+		if current != "" {
+			issue.Fix = "Run step 7, Generate Backup Scripts, in Installation & Setup"
+			issue.Repair = fmt.Sprintf("point %s at %s", engineScript, current)
+			issue.repair = func() error {
+				return setScriptRclonePath(script, current)
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// scriptRclonePath returns the rclone a generated script runs, from its
+// RCLONE_PATH line
+func scriptRclonePath(script string) (string, bool) {
+	data, err := os.ReadFile(script)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "RCLONE_PATH="); ok {
+			return strings.Trim(value, `"`), true
+		}
+	}
+	return "", false
+}
+
+// setScriptRclonePath rewrites the RCLONE_PATH line of a generated script
+func setScriptRclonePath(script, rclonePath string) error {
+	info, err := os.Stat(script)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", script, err)
+	}
+	data, err := os.ReadFile(script)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", script, err)
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "RCLONE_PATH=") {
+			lines[i] = `RCLONE_PATH="` + rclonePath + `"`
+		}
+	}
+	if err := os.WriteFile(script, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", script, err)
+	}
+	return nil
+}
+
+// reinstallCommands reinstall each agent cloud-sync installs, by the last
+// part of its label
+var reinstallCommands = map[string]string{
+	"rclonebackup":      "Save the schedule again in Scheduling & Maintenance (c: Edit schedule)",
+	"cloudsync-mount":   "Run 'cloud-sync watch-drives' again",
+	"cloudsync-power":   "Run 'cloud-sync watch-power' again",
+	"cloudsync-scrub":   "Run 'cloud-sync scrub --agent' again",
+	"cloudsync-monitor": "Run 'cloud-sync monitor --agent' again",
+	"cloudsync-daemon":  "Run 'cloud-sync daemon' again",
+}
+
+// lintAgents finds agents running a script or binary that is gone. A
+// script found in the bin directory now, or the running cloud-sync binary,
+// takes its place on repair.
+func lintAgents(manager *launchd.Manager, appConfig *config.AppConfig) []Issue {
+	jobs, err := manager.Jobs()
+	if err != nil {
+		return nil
+	}
+	program, _ := os.Executable()
+
+	var issues []Issue
+	for _, job := range jobs {
+		args, err := job.ProgramArguments()
+		if err != nil {
+			continue
+		}
+		label := job.GetLabel()
+		for _, arg := range args {
+			if !filepath.IsAbs(arg) || isSystemProgram(arg) || exists(arg) {
+				continue
+			}
+			issue := Issue{
+				Rule:   RuleMovedProgram,
+				Detail: fmt.Sprintf("%s %s runs %s, which no longer exists", job.Kind(), label, arg),
+				Fix:    reinstallCommands[label[strings.LastIndex(label, ".")+1:]],
+			}
+
+			moved := ""
+			switch base := filepath.Base(arg); {
+			case slices.Contains(scripts.ScriptNames, base):
+				moved = filepath.Join(appConfig.BinDir, base)
+			case program != "" && base == filepath.Base(program):
+				moved = program
+			}
+			if moved != "" && exists(moved) && !job.IsDaemon() {
+				issue.Repair = fmt.Sprintf("run %s instead", moved)
+				issue.repair = func() error {
+					return job.ReplaceProgramArgument(arg, moved)
+				}
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// isSystemProgram reports whether a program is one of macOS, such as
+// /bin/zsh, which stays in place
+func isSystemProgram(path string) bool {
+	switch filepath.Dir(path) {
+	case "/bin", "/sbin", "/usr/bin", "/usr/sbin":
+		return true
+	}
+	return false
+}
+
+// exists reports whether a file or folder exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,70 @@
+// Package errkind names the failures a user can do something about, so
+// views and commands can say what to do instead of only showing the wrapped
+// error. Packages mark their errors with Wrap; callers test them with
+// errors.Is and explain them with Hint.
+package errkind
+
+import "errors"
+
+// Kinds of failure
+var (
+	ErrRemoteAuth    = errors.New("the remote rejected the credentials")
+	ErrNetwork       = errors.New("the remote could not be reached")
+	ErrQuotaExceeded = errors.New("the remote is out of space")
+	ErrLocked        = errors.New("another backup is running")
+	ErrRcloneMissing = errors.New("rclone is not installed")
+	ErrHostKey       = errors.New("the server's host key is not trusted")
+)
+
+// hints says what to do about each kind
+var hints = map[error]string{
+	ErrRemoteAuth:    "Check the remote's keys under Installation & Setup, or run 'rclone config reconnect <remote>:' for a remote that signs in through the browser.",
+	ErrNetwork:       "Check the internet connection and try again; scheduled backups retry on their next run.",
+	ErrQuotaExceeded: "Free up space on the remote, e.g. by purging old snapshots or trash, or raise its storage limit.",
+	ErrLocked:        "Wait for the running backup to finish. If none is running, the lockfile was left behind and can be deleted.",
+	ErrRcloneMissing: "Install rclone from Installation & Setup, or with 'brew install rclone'.",
+	ErrHostKey:       "Check the server's key fingerprint, then add it to the remote's known hosts file, e.g. with 'ssh-keyscan <host> >> ~/.ssh/known_hosts'.",
+}
+
+// kinds lists the kinds in the order Of tries them
+var kinds = []error{ErrRcloneMissing, ErrLocked, ErrHostKey, ErrRemoteAuth, ErrQuotaExceeded, ErrNetwork}
+
+// kindError is an error of a known kind. Its message is the error's own.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap lets errors.Is and errors.As see both the kind and the error
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Wrap marks err as a failure of kind, keeping its message. A nil err stays
+// nil, and an err of a known kind already is returned as it is.
+func Wrap(kind, err error) error {
+	if err == nil || Of(err) != nil {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// Of returns the kind of err, or nil if it has none
+func Of(err error) error {
+	for _, kind := range kinds {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
+
+// Hint returns what the user can do about err, or "" for an error of no
+// known kind
+func Hint(err error) string {
+	return hints[Of(err)]
+}
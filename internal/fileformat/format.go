@@ -0,0 +1,100 @@
+package fileformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Supported formats
+const (
+	JSON = "json"
+	YAML = "yaml"
+	TOML = "toml"
+)
+
+// Formats lists the supported formats, JSON first
+var Formats = []string{JSON, YAML, TOML}
+
+// extensions maps the file extensions Find looks for to their format, in
+// the order it prefers them
+var extensions = []struct{ ext, format string }{
+	{".json", JSON},
+	{".yaml", YAML},
+	{".yml", YAML},
+	{".toml", TOML},
+}
+
+// Of returns the format of a file from its extension; anything unknown is
+// JSON
+func Of(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range extensions {
+		if e.ext == ext {
+			return e.format
+		}
+	}
+	return JSON
+}
+
+// Ext returns the extension files in format are written with
+func Ext(format string) string {
+	return "." + format
+}
+
+// Parse checks a format name
+func Parse(format string) (string, error) {
+	for _, f := range Formats {
+		if f == format {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid format '%s', must be %s", format, strings.Join(Formats, ", "))
+}
+
+// Find returns the path of the file called name in dir, with the extension
+// of whichever supported format exists. If none does, the file is to be
+// created in format.
+func Find(dir, name, format string) string {
+	for _, e := range extensions {
+		path := filepath.Join(dir, name+e.ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, name+Ext(format))
+}
+
+// ToJSON converts a document in format to JSON
+func ToJSON(format string, data []byte) ([]byte, error) {
+	switch format {
+	case YAML:
+		return JSONFromYAML(data)
+	case TOML:
+		return JSONFromTOML(data)
+	default:
+		return data, nil
+	}
+}
+
+// FromJSON converts a JSON document to format. previous is the file being
+// replaced, if any: the comments of a YAML file are carried over to the
+// keys that are still there.
+func FromJSON(format string, data, previous []byte) ([]byte, error) {
+	switch format {
+	case YAML:
+		return yamlFromJSON(data, previous)
+	case TOML:
+		return TOMLFromJSON(data)
+	default:
+		return data, nil
+	}
+}
+
+// Valid reports whether data is a valid document in format
+func Valid(format string, data []byte) bool {
+	converted, err := ToJSON(format, data)
+	return err == nil && json.Valid(converted)
+}
@@ -0,0 +1,135 @@
+package fileformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// TOMLFromJSON converts a JSON object to TOML. Objects become tables and
+// lists of objects arrays of tables, with the keys of each sorted. TOML has
+// no null, so keys set to null are left out, which decodes to the same zero
+// value.
+func TOMLFromJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return nil, fmt.Errorf("failed to encode TOML: the document must be an object")
+	}
+	value, err := tomlValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode TOML: %w", err)
+	}
+
+	var b bytes.Buffer
+	encoder := toml.NewEncoder(&b)
+	encoder.SetIndentTables(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode TOML: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// tomlValue prepares a decoded JSON value for the encoder: numbers become
+// integers where they are whole, so they do not come back as floats, and
+// keys set to null are dropped
+func tomlValue(value any) (any, error) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if item == nil {
+				delete(value, key)
+				continue
+			}
+			converted, err := tomlValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			value[key] = converted
+		}
+		return value, nil
+	case []any:
+		for i, item := range value {
+			if item == nil {
+				return nil, fmt.Errorf("TOML cannot hold null in a list")
+			}
+			converted, err := tomlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = converted
+		}
+		return value, nil
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n, nil
+		}
+		return value.Float64()
+	default:
+		return value, nil
+	}
+}
+
+// JSONFromTOML converts a TOML document to JSON. Dates and times are kept
+// as the strings they are written as.
+func JSONFromTOML(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, _ := decodeErr.Position()
+			return nil, fmt.Errorf("failed to parse TOML: line %d: %w", row, err)
+		}
+		return nil, fmt.Errorf("failed to parse TOML: %w", err)
+	}
+	value, err := fromTOML(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TOML: %w", err)
+	}
+	return json.Marshal(value)
+}
+
+// fromTOML converts a decoded TOML value to one JSON can hold
+func fromTOML(value any) (any, error) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			converted, err := fromTOML(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			value[key] = converted
+		}
+		return value, nil
+	case []any:
+		for i, item := range value {
+			converted, err := fromTOML(item)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = converted
+		}
+		return value, nil
+	case float64:
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return nil, fmt.Errorf("%v cannot be stored", value)
+		}
+		return value, nil
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		// Local dates and times
+		return value.String(), nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,167 @@
+// Package fileformat converts cloud-sync's JSON documents to and from the
+// other formats they can be written in. The json tags of the Go types stay
+// the only description of the fields: a document is converted to JSON
+// before it is decoded, and encoded as JSON before it is converted.
+package fileformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLFromJSON converts a JSON document to block-style YAML, keeping the
+// order of its keys
+func YAMLFromJSON(data []byte) ([]byte, error) {
+	return yamlFromJSON(data, nil)
+}
+
+// yamlFromJSON converts a JSON document to YAML with the comments of the
+// previous YAML document, if it can be parsed
+func yamlFromJSON(data, previous []byte) ([]byte, error) {
+	// JSON is a subset of YAML, so the document parses as is
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	blockStyle(&doc)
+
+	var old yaml.Node
+	if len(previous) > 0 && yaml.Unmarshal(previous, &old) == nil {
+		copyComments(&old, &doc)
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// blockStyle drops the flow style and quotes JSON brings, so the encoder
+// only quotes strings that would otherwise read as another type
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// copyComments gives the nodes of doc the comments of the matching nodes
+// of old. Mapping values match by key and list items by their "name" key,
+// or by position when they have none, so a comment stays with its setting
+// or sync pair when others are added or removed.
+func copyComments(old, doc *yaml.Node) {
+	doc.HeadComment = old.HeadComment
+	doc.LineComment = old.LineComment
+	doc.FootComment = old.FootComment
+	if old.Kind != doc.Kind {
+		return
+	}
+
+	switch doc.Kind {
+	case yaml.DocumentNode:
+		if len(old.Content) > 0 && len(doc.Content) > 0 {
+			copyComments(old.Content[0], doc.Content[0])
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(doc.Content); i += 2 {
+			if j := mappingKey(old, doc.Content[i].Value); j >= 0 {
+				copyComments(old.Content[j], doc.Content[i])
+				copyComments(old.Content[j+1], doc.Content[i+1])
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range doc.Content {
+			if match := sequenceItem(old, item, i); match != nil {
+				copyComments(match, item)
+			}
+		}
+	}
+}
+
+// mappingKey returns the index of key's node in a mapping, or -1
+func mappingKey(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// sequenceItem returns the item of old that item at index i replaces
+func sequenceItem(old, item *yaml.Node, i int) *yaml.Node {
+	if name := itemName(item); name != "" {
+		for _, candidate := range old.Content {
+			if itemName(candidate) == name {
+				return candidate
+			}
+		}
+		return nil
+	}
+	if i < len(old.Content) {
+		return old.Content[i]
+	}
+	return nil
+}
+
+// itemName returns the "name" of a mapping, or ""
+func itemName(node *yaml.Node) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	if i := mappingKey(node, "name"); i >= 0 {
+		return node.Content[i+1].Value
+	}
+	return ""
+}
+
+// JSONFromYAML converts a YAML document to JSON. Mappings must have string
+// keys, as JSON objects do.
+func JSONFromYAML(data []byte) ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	value, err := jsonValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return json.Marshal(value)
+}
+
+// jsonValue checks that a decoded YAML value can be written as JSON
+func jsonValue(value any) (any, error) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			value[key] = converted
+		}
+		return value, nil
+	case map[any]any:
+		return nil, fmt.Errorf("mapping keys must be strings")
+	case []any:
+		for i, item := range value {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = converted
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,61 @@
+package filewatch
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Interval is how often the TUI checks watched files
+const Interval = 2 * time.Second
+
+// Watcher notices when files are written, created or removed, including by
+// other programs, by comparing their modification time and size between
+// checks
+type Watcher struct {
+	mu     sync.Mutex
+	paths  []string
+	stamps map[string]stamp
+}
+
+// stamp is what a check remembers about a file
+type stamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher watches paths, taking their current state as unchanged
+func NewWatcher(paths ...string) *Watcher {
+	w := &Watcher{paths: paths, stamps: make(map[string]stamp)}
+	for _, path := range paths {
+		w.stamps[path] = stampOf(path)
+	}
+	return w
+}
+
+// Changed returns the paths that changed since the previous check, in the
+// order they were given
+func (w *Watcher) Changed() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var changed []string
+	for _, path := range w.paths {
+		current := stampOf(path)
+		if current != w.stamps[path] {
+			w.stamps[path] = current
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// stampOf reads the state of a file; a missing file has the zero stamp
+func stampOf(path string) stamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{exists: true, modTime: info.ModTime(), size: info.Size()}
+}
@@ -0,0 +1,196 @@
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// FileName is the ignore file honored in synced folders
+const FileName = ".cloudsyncignore"
+
+// Rule is a filter rule in the syntax rclone's --filter-from and rsync's
+// merge files share: the first rule matching a path decides
+type Rule struct {
+	Include bool
+	Pattern string // Relative to the synced folder; a leading / anchors it there
+}
+
+// String formats the rule as a line of a filter file
+func (r Rule) String() string {
+	if r.Include {
+		return "+ " + r.Pattern
+	}
+	return "- " + r.Pattern
+}
+
+// Load reads every .cloudsyncignore under root and returns their rules in
+// filter order. In gitignore's terms the last matching pattern wins and a
+// file deeper in the tree overrides its parents, so the rules are returned
+// reversed: deepest file first, last line first.
+func Load(root string) ([]Rule, error) {
+	type ignoreFile struct {
+		depth int
+		rules []Rule
+	}
+	var files []ignoreFile
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable folders are left to the sync to report
+			return nil
+		}
+		if d.IsDir() || d.Name() != FileName {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		dir, _ := filepath.Rel(root, filepath.Dir(p))
+		dir = filepath.ToSlash(dir)
+		files = append(files, ignoreFile{depth: depth(dir), rules: Parse(dir, string(data))})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Parents before children; the walk visits a folder's entries in name
+	// order, so a subfolder can come before the folder's own ignore file
+	sort.SliceStable(files, func(i, j int) bool { return files[i].depth < files[j].depth })
+	var rules []Rule
+	for _, f := range files {
+		rules = append(rules, f.rules...)
+	}
+	slices.Reverse(rules)
+	return rules, nil
+}
+
+// depth returns how many folders deep dir is below the synced folder
+func depth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// Parse translates the gitignore-style lines of an ignore file in dir, a
+// slash-separated path relative to the synced folder ("." for the folder
+// itself), into rules in the file's own order
+func Parse(dir, content string) []Rule {
+	if dir == "." {
+		dir = ""
+	}
+
+	var rules []Rule
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		include := false
+		if strings.HasPrefix(line, "!") {
+			include = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+
+		// A trailing slash matches directories only, which for files means
+		// everything inside them
+		dirOnly := strings.HasSuffix(line, "/")
+		line = strings.TrimSuffix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		// A slash at the start or in the middle makes the pattern relative
+		// to dir, except for a leading **/, which matches in any folder
+		anchored := strings.Contains(line, "/")
+		if rest, ok := strings.CutPrefix(line, "**/"); ok {
+			line, anchored = rest, false
+		}
+		line = strings.TrimPrefix(line, "/")
+
+		// A filter pattern only matches files, while gitignore's also
+		// matches folders and so everything inside them
+		lines := []string{line, line + "/**"}
+		switch {
+		case dirOnly:
+			lines = []string{line + "/**"}
+		case strings.HasSuffix(line, "**"):
+			lines = []string{line}
+		}
+
+		for _, line := range lines {
+			for _, pattern := range patterns(dir, line, anchored) {
+				rules = append(rules, Rule{Include: include, Pattern: pattern})
+			}
+		}
+	}
+	return rules
+}
+
+// patterns places a pattern of an ignore file in dir. An anchored pattern
+// is relative to dir; any other matches at every depth below it.
+func patterns(dir, pattern string, anchored bool) []string {
+	if anchored {
+		return []string{"/" + path.Join(dir, pattern)}
+	}
+	if dir == "" {
+		return []string{pattern}
+	}
+	// ** needs at least one folder in between, so the entries of dir itself
+	// get a rule of their own
+	return []string{"/" + path.Join(dir, pattern), "/" + dir + "/**/" + pattern}
+}
+
+// Rebase turns rules for the synced folder into rules for its subfolder
+// dir, a slash-separated relative path, for a sync of only that subfolder.
+// Patterns anchored inside dir lose the prefix, those that match at any
+// depth below dir or one of its parents match at any depth in it, and those
+// anchored elsewhere are dropped.
+func Rebase(rules []Rule, dir string) []Rule {
+	prefix := "/" + strings.Trim(dir, "/") + "/"
+	var rebased []Rule
+	for _, rule := range rules {
+		if !strings.HasPrefix(rule.Pattern, "/") {
+			rebased = append(rebased, rule)
+			continue
+		}
+		if parent, rest, ok := strings.Cut(rule.Pattern, "/**/"); ok && strings.HasPrefix(prefix, parent+"/") {
+			for _, pattern := range patterns("", rest, false) {
+				rebased = append(rebased, Rule{Include: rule.Include, Pattern: pattern})
+			}
+			continue
+		}
+		if rest, ok := strings.CutPrefix(rule.Pattern, prefix); ok {
+			rebased = append(rebased, Rule{Include: rule.Include, Pattern: "/" + rest})
+		}
+	}
+	return rebased
+}
+
+// WriteFilter writes rules to a filter file at path
+func WriteFilter(rules []Rule, path string) error {
+	var b strings.Builder
+	for _, rule := range rules {
+		b.WriteString(rule.String())
+		b.WriteString("\n")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create filter directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write filter file: %w", err)
+	}
+	return nil
+}
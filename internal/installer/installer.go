@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
 )
 
 // Installer handles installation of required tools
@@ -31,7 +34,7 @@ func (e *DefaultExecutor) LookPath(file string) (string, error) {
 }
 
 func (e *DefaultExecutor) Command(name string, arg ...string) *exec.Cmd {
-	return exec.Command(name, arg...)
+	return simulate.Command(name, arg...)
 }
 
 func (e *DefaultExecutor) RunCommand(cmd *exec.Cmd) error {
@@ -114,7 +117,7 @@ func (i *Installer) InstallRclone() error {
 func (i *Installer) GetRclonePath() (string, error) {
 	path, err := i.executor.LookPath("rclone")
 	if err != nil {
-		return "", fmt.Errorf("rclone not found in PATH: %w", err)
+		return "", fmt.Errorf("rclone not found in PATH: %w", errkind.Wrap(errkind.ErrRcloneMissing, err))
 	}
 
 	// Resolve any symlinks
@@ -338,7 +341,7 @@ func (i *Installer) UpdateRsyncWithOutput() (string, error) {
 // GetRcloneVersion returns the rclone version string
 func (i *Installer) GetRcloneVersion() (string, error) {
 	if !i.CheckRcloneInstalled() {
-		return "", fmt.Errorf("rclone is not installed")
+		return "", errkind.ErrRcloneMissing
 	}
 
 	cmd := i.executor.Command("rclone", "version")
@@ -359,7 +362,7 @@ func (i *Installer) GetRcloneVersion() (string, error) {
 // GetRcloneVersionWithOutput returns the rclone version and full output
 func (i *Installer) GetRcloneVersionWithOutput() (string, string, error) {
 	if !i.CheckRcloneInstalled() {
-		return "", "", fmt.Errorf("rclone is not installed")
+		return "", "", errkind.ErrRcloneMissing
 	}
 
 	cmd := i.executor.Command("rclone", "version")
@@ -406,7 +409,7 @@ func (i *Installer) UpdateRcloneWithOutput() (string, error) {
 	}
 
 	if !i.CheckRcloneInstalled() {
-		return "", fmt.Errorf("rclone is not installed, use InstallRclone instead")
+		return "", fmt.Errorf("%w, use InstallRclone instead", errkind.ErrRcloneMissing)
 	}
 
 	cmd := i.executor.Command("brew", "upgrade", "rclone")
@@ -423,7 +426,7 @@ func (i *Installer) UpdateRcloneWithOutput() (string, error) {
 func (i *Installer) GetRcloneConfigCmd() func() error {
 	return func() error {
 		if !i.CheckRcloneInstalled() {
-			return fmt.Errorf("rclone is not installed")
+			return errkind.ErrRcloneMissing
 		}
 
 		cmd := i.executor.Command("rclone", "config")
@@ -443,7 +446,7 @@ func (i *Installer) GetRcloneConfigCmd() func() error {
 // Deprecated: Use GetRcloneConfigCmd with tea.Exec instead for better terminal handling
 func (i *Installer) RunRcloneConfig() (string, error) {
 	if !i.CheckRcloneInstalled() {
-		return "", fmt.Errorf("rclone is not installed")
+		return "", errkind.ErrRcloneMissing
 	}
 
 	cmd := i.executor.Command("rclone", "config")
@@ -461,7 +464,7 @@ func (i *Installer) RunRcloneConfig() (string, error) {
 // ListRcloneRemotes lists all configured rclone remotes
 func (i *Installer) ListRcloneRemotes() ([]string, error) {
 	if !i.CheckRcloneInstalled() {
-		return nil, fmt.Errorf("rclone is not installed")
+		return nil, errkind.ErrRcloneMissing
 	}
 
 	cmd := i.executor.Command("rclone", "listremotes")
@@ -484,7 +487,7 @@ func (i *Installer) ListRcloneRemotes() ([]string, error) {
 // TestRcloneRemote tests connectivity to a specific rclone remote
 func (i *Installer) TestRcloneRemote(remoteName string) (string, error) {
 	if !i.CheckRcloneInstalled() {
-		return "", fmt.Errorf("rclone is not installed")
+		return "", errkind.ErrRcloneMissing
 	}
 
 	// Use lsd with max-depth 1 to test connectivity
@@ -0,0 +1,128 @@
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// LineFunc receives command output one line at a time
+type LineFunc func(line string)
+
+// lineWriter splits written output into lines and hands each to a LineFunc.
+// Carriage returns count as line breaks so progress bars from curl and brew
+// show up as separate updates rather than one ever-growing line.
+type lineWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	onLine LineFunc
+	lines  int
+}
+
+// Write implements io.Writer
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	for {
+		data := w.buf.Bytes()
+		i := bytes.IndexAny(data, "\r\n")
+		if i < 0 {
+			break
+		}
+		w.emit(string(data[:i]))
+		w.buf.Next(i + 1)
+	}
+	return len(p), nil
+}
+
+// Flush emits any trailing output that did not end with a newline
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf.Len() > 0 {
+		w.emit(w.buf.String())
+		w.buf.Reset()
+	}
+}
+
+// emit forwards a non-blank line
+func (w *lineWriter) emit(line string) {
+	line = strings.TrimRight(line, " \t")
+	if line == "" {
+		return
+	}
+	w.lines++
+	if w.onLine != nil {
+		w.onLine(line)
+	}
+}
+
+// runStreaming runs cmd with stdout and stderr merged and streamed to onLine.
+// It returns the number of lines emitted.
+func (i *Installer) runStreaming(cmd *exec.Cmd, onLine LineFunc) (int, error) {
+	w := &lineWriter{onLine: onLine}
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	err := i.executor.RunCommand(cmd)
+	w.Flush()
+	return w.lines, err
+}
+
+// InstallHomebrewStreaming installs Homebrew non-interactively, streaming
+// the installer output to onLine instead of the terminal
+func (i *Installer) InstallHomebrewStreaming(onLine LineFunc) error {
+	if i.CheckHomebrewInstalled() {
+		return fmt.Errorf("homebrew is already installed")
+	}
+
+	installScript := `/bin/bash -c "$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)"`
+
+	// Without a terminal the script cannot prompt, so tell it not to
+	cmd := i.executor.Command("bash", "-c", installScript)
+	cmd.Env = append(os.Environ(), "NONINTERACTIVE=1")
+
+	if _, err := i.runStreaming(cmd, onLine); err != nil {
+		return fmt.Errorf("failed to install homebrew: %w", err)
+	}
+
+	return nil
+}
+
+// InstallRcloneStreaming installs rclone via Homebrew, streaming the output to onLine
+func (i *Installer) InstallRcloneStreaming(onLine LineFunc) error {
+	if !i.CheckHomebrewInstalled() {
+		return fmt.Errorf("homebrew must be installed first")
+	}
+
+	cmd := i.executor.Command("brew", "install", "rclone")
+	if _, err := i.runStreaming(cmd, onLine); err != nil {
+		return fmt.Errorf("failed to install rclone: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRcloneStreaming upgrades rclone via Homebrew, streaming the output to onLine
+func (i *Installer) UpdateRcloneStreaming(onLine LineFunc) error {
+	if !i.CheckHomebrewInstalled() {
+		return fmt.Errorf("homebrew must be installed first")
+	}
+
+	if !i.CheckRcloneInstalled() {
+		return fmt.Errorf("rclone is not installed, use InstallRclone instead")
+	}
+
+	cmd := i.executor.Command("brew", "upgrade", "rclone")
+	if _, err := i.runStreaming(cmd, onLine); err != nil {
+		return fmt.Errorf("failed to update rclone: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package jsonfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/fileformat"
+)
+
+// BackupPath returns where the previous version of path is kept
+func BackupPath(path string) string {
+	return path + ".bak"
+}
+
+// CorruptPath returns where Restore moves a file it replaces
+func CorruptPath(path string) string {
+	return path + ".corrupt"
+}
+
+// CorruptError reports a file that cannot be parsed, e.g. one cut short by
+// a crash or broken by a hand edit
+type CorruptError struct {
+	Path   string
+	Err    error
+	Backup time.Time // When the backup was written; zero if there is none
+}
+
+// NewCorruptError describes the parse error err of path, noting its backup
+func NewCorruptError(path string, err error) *CorruptError {
+	e := &CorruptError{Path: path, Err: err}
+	if info, statErr := os.Stat(BackupPath(path)); statErr == nil {
+		e.Backup = info.ModTime()
+	}
+	return e
+}
+
+// HasBackup reports whether a backup of the file can be restored
+func (e *CorruptError) HasBackup() bool {
+	return !e.Backup.IsZero()
+}
+
+// Error implements the error interface
+func (e *CorruptError) Error() string {
+	msg := fmt.Sprintf("%s is corrupt: %v", filepath.Base(e.Path), e.Err)
+	if e.HasBackup() {
+		msg += fmt.Sprintf(" (a backup from %s can be restored with 'cloud-sync config restore')",
+			e.Backup.Format("2006-01-02 15:04"))
+	}
+	return msg
+}
+
+// Unwrap returns the parse error
+func (e *CorruptError) Unwrap() error {
+	return e.Err
+}
+
+// Write saves v as indented JSON without ever leaving a half-written file:
+// the data goes to a temporary file in the same directory, which is flushed
+// to disk and renamed over path. The previous version is kept as a backup
+// if it is valid, so a broken file never replaces a good backup. A path
+// ending in .yaml, .yml or .toml is written in that format instead, and a
+// YAML file keeps the comments of its previous version.
+func Write(path string, v any, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	format := fileformat.Of(path)
+	previous, err := os.ReadFile(path)
+	if err == nil && fileformat.Valid(format, previous) {
+		if err := writeAtomic(BackupPath(path), previous, perm); err != nil {
+			return fmt.Errorf("failed to back up config file: %w", err)
+		}
+	} else {
+		previous = nil
+	}
+
+	if data, err = fileformat.FromJSON(format, data, previous); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := writeAtomic(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Unmarshal decodes data read from path into v, converting it to JSON
+// first if the path's extension names another format
+func Unmarshal(path string, data []byte, v any) error {
+	data, err := fileformat.ToJSON(fileformat.Of(path), data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Restore replaces path with its backup. The file it replaces is kept at
+// CorruptPath for inspection.
+func Restore(path string) error {
+	data, err := os.ReadFile(BackupPath(path))
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	if !fileformat.Valid(fileformat.Of(path), data) {
+		return fmt.Errorf("backup %s is not valid", BackupPath(path))
+	}
+
+	info, err := os.Stat(path)
+	perm := os.FileMode(0600)
+	if err == nil {
+		perm = info.Mode().Perm()
+		if err := os.Rename(path, CorruptPath(path)); err != nil {
+			return fmt.Errorf("failed to move aside %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	if err := writeAtomic(path, data, perm); err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	return nil
+}
+
+// writeAtomic writes data to a temporary file next to path, syncs it and
+// renames it over path
+func writeAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Removing fails harmlessly once the file has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
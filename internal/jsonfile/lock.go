@@ -0,0 +1,39 @@
+package jsonfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// LockPath returns the lock file guarding path
+func LockPath(path string) string {
+	return path + ".lock"
+}
+
+// Lock takes an exclusive lock on path that every cloud-sync process
+// honours, so a read-modify-write of the file is never interleaved with
+// another one, whether from a scheduled run, the TUI or a second instance.
+// It waits until the lock is free and returns the function releasing it.
+// The lock is an flock on a separate file, which the kernel releases if the
+// process dies.
+func Lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	f, err := os.OpenFile(LockPath(path), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", filepath.Base(path), err)
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,144 @@
+package launchd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+)
+
+// DaemonDir holds system-wide LaunchDaemons, which run whether or not
+// anyone is logged in
+const DaemonDir = "/Library/LaunchDaemons"
+
+// sudoPrompt is shown by sudo when it asks for the password; %u is the
+// user running it
+const sudoPrompt = "Password for %u to manage the cloud-sync LaunchDaemon: "
+
+// Daemon returns the manager of the system-wide LaunchDaemon that runs the
+// user's backups as that user, for machines where they must run regardless
+// of who is logged in. Its plist must belong to root, so writing, loading
+// and removing it go through sudo unless the process is root already.
+func (m *Manager) Daemon() *Manager {
+	return &Manager{username: m.username, agentPath: DaemonDir, name: "cloudsync-daemon", daemon: true}
+}
+
+// NonInteractive returns a copy of the manager whose sudo commands fail
+// instead of asking for a password, for callers that own the terminal
+func (m *Manager) NonInteractive() *Manager {
+	c := *m
+	c.noPrompt = true
+	return &c
+}
+
+// IsDaemon reports whether the manager handles a LaunchDaemon
+func (m *Manager) IsDaemon() bool {
+	return m.daemon
+}
+
+// Kind returns "LaunchDaemon" or "LaunchAgent", for messages
+func (m *Manager) Kind() string {
+	if m.daemon {
+		return "LaunchDaemon"
+	}
+	return "LaunchAgent"
+}
+
+// DaemonConfig returns the configuration of the daemon that runs
+// scriptPath as username on the schedule
+func DaemonConfig(label, username, scriptPath string, schedule Schedule) *Config {
+	config := &Config{
+		Label:         label,
+		ScriptPath:    scriptPath,
+		UserName:      username,
+		Intervals:     schedule.Intervals(),
+		StartInterval: schedule.StartInterval(),
+	}
+	if len(schedule.Times) > 0 {
+		config.Hour, config.Minute = schedule.Times[0].Hour, schedule.Times[0].Minute
+	}
+	return config
+}
+
+// InstallDaemon writes and loads the daemon, replacing a loaded one. The
+// script's output goes to stdoutPath and stderrPath when they are set.
+func (m *Manager) InstallDaemon(scriptPath string, schedule Schedule, stdoutPath, stderrPath string) error {
+	config := DaemonConfig(m.GetLabel(), m.username, scriptPath, schedule)
+	config.StdoutPath, config.StderrPath = stdoutPath, stderrPath
+	if err := ValidateConfig(config); err != nil {
+		return fmt.Errorf("invalid LaunchDaemon configuration: %w", err)
+	}
+	return m.install(config)
+}
+
+// privileged returns a command that runs as root: directly when the
+// process is root, otherwise through sudo, which asks for the password on
+// the terminal
+func (m *Manager) privileged(name string, arg ...string) *exec.Cmd {
+	if os.Geteuid() == 0 {
+		return simulate.Command(name, arg...)
+	}
+	sudo := []string{"-p", sudoPrompt}
+	if m.noPrompt {
+		sudo = []string{"-n"}
+	}
+	return simulate.Command("sudo", append(append(sudo, name), arg...)...)
+}
+
+// runPrivileged runs a command as root and returns its output. A sudo that
+// needed a password it was not allowed to ask for is reported as such.
+func (m *Manager) runPrivileged(name string, arg ...string) ([]byte, error) {
+	output, err := m.privileged(name, arg...).CombinedOutput()
+	if err != nil && strings.Contains(string(output), "a password is required") {
+		return output, fmt.Errorf("administrator privileges are required; run 'sudo -v' in a terminal first")
+	}
+	return output, err
+}
+
+// generateDaemonPlist renders the plist to a temporary file and installs it
+// into DaemonDir owned by root, which launchd requires of daemons. A
+// configuration without a user runs as the manager's user, never as root.
+func (m *Manager) generateDaemonPlist(config *Config) error {
+	if config.UserName == "" {
+		withUser := *config
+		withUser.UserName = m.username
+		config = &withUser
+	}
+	data, err := RenderPlist(config)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "cloud-sync-*.plist")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary plist: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary plist: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary plist: %w", err)
+	}
+
+	output, err := m.runPrivileged("install", "-m", "0644", "-o", "root", "-g", "wheel", tmp.Name(), m.GetPlistPath())
+	if err != nil {
+		return fmt.Errorf("failed to install plist file: %w (output: %s)", err, string(output))
+	}
+	return nil
+}
+
+// removeDaemonPlist deletes the daemon's plist as root
+func (m *Manager) removeDaemonPlist() error {
+	if _, err := os.Stat(m.GetPlistPath()); os.IsNotExist(err) {
+		return nil
+	}
+	output, err := m.runPrivileged("rm", "-f", m.GetPlistPath())
+	if err != nil {
+		return fmt.Errorf("failed to remove plist file: %w (output: %s)", err, string(output))
+	}
+	return nil
+}
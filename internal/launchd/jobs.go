@@ -0,0 +1,70 @@
+package launchd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// jobNames describes the jobs cloud-sync installs, by the last part of
+// their label
+var jobNames = map[string]string{
+	"rclonebackup":      "Scheduled backup",
+	"cloudsync-mount":   "Sync on drive mount",
+	"cloudsync-power":   "Retry on power change",
+	"cloudsync-scrub":   "Monthly integrity scrub",
+	"cloudsync-monitor": "Daily check of monitor pairs",
+	"cloudsync-daemon":  "Scheduled backup (runs without a login)",
+}
+
+// Jobs returns the managers of the cloud-sync jobs installed for the user:
+// the scheduled backup's agent or daemon, and every agent whose label is
+// com.<user>.cloudsync-*, such as the drive and power agents. The backup
+// agent comes first, the others by label, and all of them ask for a sudo
+// password only if m does.
+func (m *Manager) Jobs() ([]*Manager, error) {
+	entries, err := os.ReadDir(userAgentDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list LaunchAgents: %w", err)
+	}
+
+	prefix := fmt.Sprintf("com.%s.", m.username)
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".plist")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		name = strings.TrimPrefix(name, prefix)
+		if name == "rclonebackup" || strings.HasPrefix(name, "cloudsync-") {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "rclonebackup") != (names[j] == "rclonebackup") {
+			return names[i] == "rclonebackup"
+		}
+		return names[i] < names[j]
+	})
+
+	jobs := make([]*Manager, 0, len(names)+1)
+	for _, name := range names {
+		jobs = append(jobs, &Manager{username: m.username, agentPath: userAgentDir(), name: name, noPrompt: m.noPrompt})
+	}
+	daemon := m.Daemon()
+	daemon.noPrompt = m.noPrompt
+	if _, err := os.Stat(daemon.GetPlistPath()); err == nil {
+		jobs = append(jobs, daemon)
+	}
+	return jobs, nil
+}
+
+// Description says what the job does, or returns its label for a job this
+// version does not know
+func (m *Manager) Description() string {
+	if description, ok := jobNames[m.name]; ok {
+		return description
+	}
+	return m.GetLabel()
+}
@@ -0,0 +1,107 @@
+package launchd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+)
+
+// Jobs are managed with the bootstrap, bootout, kickstart and print
+// subcommands launchctl has had since OS X 10.10, which address a job by
+// its domain: gui/<uid> for the user's agents and system for daemons. The
+// load, unload, start, stop and list subcommands they replace are
+// deprecated and behave differently on newer macOS, so they are only used
+// when launchctl does not recognize the new ones.
+
+// domain returns the launchd domain the job is loaded into
+func (m *Manager) domain() string {
+	if m.daemon {
+		return "system"
+	}
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+// serviceTarget returns the job's address for bootout, kickstart, kill and
+// print, e.g. gui/501/com.alice.rclonebackup
+func (m *Manager) serviceTarget() string {
+	return m.domain() + "/" + m.GetLabel()
+}
+
+// launchctl returns a launchctl command, run as root for a daemon
+func (m *Manager) launchctl(arg ...string) *exec.Cmd {
+	if m.daemon {
+		return m.privileged("launchctl", arg...)
+	}
+	return simulate.Command("launchctl", arg...)
+}
+
+// run runs a launchctl subcommand, or its legacy equivalent when this macOS
+// does not have it, and returns the output
+func (m *Manager) run(modern, legacy []string) ([]byte, error) {
+	runArgs := func(args []string) ([]byte, error) {
+		if m.daemon {
+			return m.runPrivileged("launchctl", args...)
+		}
+		return m.launchctl(args...).CombinedOutput()
+	}
+
+	output, err := runArgs(modern)
+	if err != nil && unsupported(output) {
+		return runArgs(legacy)
+	}
+	return output, err
+}
+
+// unsupported reports whether launchctl rejected a subcommand it does not
+// know, as versions before OS X 10.10 do for bootstrap and friends
+func unsupported(output []byte) bool {
+	text := strings.ToLower(string(output))
+	return strings.Contains(text, "unrecognized subcommand") || strings.Contains(text, "unknown subcommand")
+}
+
+// notLoaded reports whether launchctl failed because the job is not loaded
+func notLoaded(output []byte) bool {
+	text := string(output)
+	return strings.Contains(text, "Could not find specified service") ||
+		strings.Contains(text, "Could not find service") ||
+		strings.Contains(text, "No such process")
+}
+
+// parsePrint reads the status from the output of 'launchctl print'
+func parsePrint(output []byte, err error) (*Status, error) {
+	status := &Status{PID: -1}
+	if err != nil {
+		if notLoaded(output) {
+			return status, nil
+		}
+		return nil, fmt.Errorf("failed to get status: %w (output: %s)", err, string(output))
+	}
+
+	status.Loaded = true
+	// Lines look like "pid = 123" and "last exit code = 0"; nested
+	// dictionaries repeat some keys, so only the first of each counts
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " = ")
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		switch key {
+		case "pid":
+			if pid, err := strconv.Atoi(value); err == nil && pid > 0 {
+				status.PID = pid
+				status.Running = true
+			}
+		case "last exit code":
+			if code, err := strconv.Atoi(value); err == nil {
+				status.LastExit = code
+			}
+		}
+	}
+	return status, nil
+}
@@ -4,17 +4,24 @@ import (
 	"bytes"
 	"fmt"
 	"os"
-	"os/exec"
+	"os/user"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
 )
 
 // Manager handles LaunchAgent operations
 type Manager struct {
 	username string
 	agentPath string
+	name string // Last part of the label, e.g. rclonebackup
+
+	daemon   bool // A system-wide LaunchDaemon in DaemonDir (see daemon.go)
+	noPrompt bool // sudo fails instead of asking for a password
 }
 
 // Config holds LaunchAgent configuration
@@ -24,6 +31,32 @@ type Config struct {
 	Hour       int    // Hour to run (0-23)
 	Minute     int    // Minute to run (0-59)
 	RunAtLoad  bool   // Run immediately when loaded
+
+	// Intervals replaces Hour/Minute with several calendar entries
+	Intervals []CalendarInterval
+
+	// StartInterval, in seconds, replaces the calendar entirely when set
+	StartInterval int
+
+	// WatchPaths starts the job whenever one of the paths changes, instead
+	// of on a schedule
+	WatchPaths []string
+
+	// NotifyEvents starts the job whenever one of these Darwin
+	// notifications is posted, e.g. PowerSourceNotification, instead of on
+	// a schedule
+	NotifyEvents []string
+
+	// Arguments replaces running ScriptPath with zsh when set
+	Arguments []string
+
+	// UserName runs a LaunchDaemon as this user instead of root
+	UserName string
+
+	// StdoutPath and StderrPath are the files launchd appends the job's
+	// output to; without them it is discarded
+	StdoutPath string
+	StderrPath string
 }
 
 // Status represents the status of a LaunchAgent
@@ -40,20 +73,85 @@ const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 <dict>
 	<key>Label</key>
 	<string>{{.Label}}</string>
-
+{{if .UserName}}
+	<key>UserName</key>
+	<string>{{.UserName}}</string>
+	<key>InitGroups</key>
+	<true/>
+{{end}}
 	<key>ProgramArguments</key>
 	<array>
+{{- if .Arguments}}
+{{- range .Arguments}}
+		<string>{{.}}</string>
+{{- end}}
+{{- else}}
 		<string>/bin/zsh</string>
 		<string>{{.ScriptPath}}</string>
+{{- end}}
 	</array>
-
+{{if .WatchPaths}}
+	<key>WatchPaths</key>
+	<array>
+{{- range .WatchPaths}}
+		<string>{{.}}</string>
+{{- end}}
+	</array>
+{{- else if .NotifyEvents}}
+	<key>LaunchEvents</key>
+	<dict>
+		<key>com.apple.notifyd.matching</key>
+		<dict>
+{{- range .NotifyEvents}}
+			<key>{{.}}</key>
+			<dict>
+				<key>Notification</key>
+				<string>{{.}}</string>
+			</dict>
+{{- end}}
+		</dict>
+	</dict>
+{{- else if .StartInterval}}
+	<key>StartInterval</key>
+	<integer>{{.StartInterval}}</integer>
+{{- else}}
 	<key>StartCalendarInterval</key>
+{{- if .Intervals}}
+	<array>
+{{- range .Intervals}}
+		<dict>
+{{- if .Weekday}}
+			<key>Weekday</key>
+			<integer>{{.Weekday}}</integer>
+{{- end}}
+{{- if .Day}}
+			<key>Day</key>
+			<integer>{{.Day}}</integer>
+{{- end}}
+			<key>Hour</key>
+			<integer>{{.Hour}}</integer>
+			<key>Minute</key>
+			<integer>{{.Minute}}</integer>
+		</dict>
+{{- end}}
+	</array>
+{{- else}}
 	<dict>
 		<key>Hour</key>
 		<integer>{{.Hour}}</integer>
 		<key>Minute</key>
 		<integer>{{.Minute}}</integer>
 	</dict>
+{{- end}}
+{{- end}}
+{{- if .StdoutPath}}
+	<key>StandardOutPath</key>
+	<string>{{.StdoutPath}}</string>
+{{- end}}
+{{- if .StderrPath}}
+	<key>StandardErrorPath</key>
+	<string>{{.StderrPath}}</string>
+{{- end}}
 {{if .RunAtLoad}}
 	<key>RunAtLoad</key>
 	<true/>
@@ -64,18 +162,31 @@ const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 
 // NewManager creates a new LaunchAgent manager
 func NewManager(username string) *Manager {
-	homeDir, _ := os.UserHomeDir()
-	agentPath := filepath.Join(homeDir, "Library", "LaunchAgents")
-
 	return &Manager{
 		username: username,
-		agentPath: agentPath,
+		agentPath: userAgentDir(),
+		name: "rclonebackup",
+	}
+}
+
+// CurrentUsername returns the login name of the current user, whose
+// agents NewManager manages, falling back to $USER
+func CurrentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
 	}
+	return os.Getenv("USER")
+}
+
+// userAgentDir returns the current user's LaunchAgents directory
+func userAgentDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "Library", "LaunchAgents")
 }
 
 // GetLabel returns the LaunchAgent label for the user
 func (m *Manager) GetLabel() string {
-	return fmt.Sprintf("com.%s.rclonebackup", m.username)
+	return fmt.Sprintf("com.%s.%s", m.username, m.name)
 }
 
 // GetPlistPath returns the full path to the plist file
@@ -85,41 +196,66 @@ func (m *Manager) GetPlistPath() string {
 
 // GeneratePlist generates a LaunchAgent plist file
 func (m *Manager) GeneratePlist(config *Config) error {
+	if m.daemon {
+		return m.generateDaemonPlist(config)
+	}
+
 	// Ensure LaunchAgents directory exists
 	if err := os.MkdirAll(m.agentPath, 0755); err != nil {
 		return fmt.Errorf("failed to create LaunchAgents directory: %w", err)
 	}
 
+	data, err := RenderPlist(config)
+	if err != nil {
+		return err
+	}
+
+	// Write plist file
+	plistPath := m.GetPlistPath()
+	if err := os.WriteFile(plistPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write plist file: %w", err)
+	}
+
+	return nil
+}
+
+// RenderPlist renders the plist for a LaunchAgent configuration
+func RenderPlist(config *Config) ([]byte, error) {
 	// Parse template
 	tmpl, err := template.New("plist").Parse(plistTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to parse plist template: %w", err)
+		return nil, fmt.Errorf("failed to parse plist template: %w", err)
 	}
 
 	// Execute template
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, config); err != nil {
-		return fmt.Errorf("failed to execute plist template: %w", err)
+		return nil, fmt.Errorf("failed to execute plist template: %w", err)
 	}
 
-	// Write plist file
-	plistPath := m.GetPlistPath()
-	if err := os.WriteFile(plistPath, buf.Bytes(), 0644); err != nil {
-		return fmt.Errorf("failed to write plist file: %w", err)
-	}
+	return buf.Bytes(), nil
+}
 
-	return nil
+// InstalledAt returns when the plist was last written, or the zero time if
+// it does not exist
+func (m *Manager) InstalledAt() time.Time {
+	info, err := os.Stat(m.GetPlistPath())
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
 }
 
 // Load loads the LaunchAgent
 func (m *Manager) Load() error {
 	plistPath := m.GetPlistPath()
-	if _, err := os.Stat(plistPath); os.IsNotExist(err) {
+	// A simulated install of a daemon writes nothing, since that needs root
+	simulated := m.daemon && simulate.Enabled()
+	if _, err := os.Stat(plistPath); os.IsNotExist(err) && !simulated {
 		return fmt.Errorf("plist file does not exist: %s", plistPath)
 	}
 
-	cmd := exec.Command("launchctl", "load", plistPath)
-	output, err := cmd.CombinedOutput()
+	output, err := m.run([]string{"bootstrap", m.domain(), plistPath}, []string{"load", plistPath})
 	if err != nil {
 		return fmt.Errorf("failed to load agent: %w (output: %s)", err, string(output))
 	}
@@ -130,11 +266,10 @@ func (m *Manager) Load() error {
 // Unload unloads the LaunchAgent
 func (m *Manager) Unload() error {
 	plistPath := m.GetPlistPath()
-	cmd := exec.Command("launchctl", "unload", plistPath)
-	output, err := cmd.CombinedOutput()
+	output, err := m.run([]string{"bootout", m.serviceTarget()}, []string{"unload", plistPath})
 	if err != nil {
 		// Unload might fail if not loaded, which is okay
-		if !strings.Contains(string(output), "Could not find specified service") {
+		if !notLoaded(output) {
 			return fmt.Errorf("failed to unload agent: %w (output: %s)", err, string(output))
 		}
 	}
@@ -144,9 +279,7 @@ func (m *Manager) Unload() error {
 
 // Start starts the LaunchAgent manually
 func (m *Manager) Start() error {
-	label := m.GetLabel()
-	cmd := exec.Command("launchctl", "start", label)
-	output, err := cmd.CombinedOutput()
+	output, err := m.run([]string{"kickstart", m.serviceTarget()}, []string{"start", m.GetLabel()})
 	if err != nil {
 		return fmt.Errorf("failed to start agent: %w (output: %s)", err, string(output))
 	}
@@ -156,9 +289,7 @@ func (m *Manager) Start() error {
 
 // Stop stops the LaunchAgent
 func (m *Manager) Stop() error {
-	label := m.GetLabel()
-	cmd := exec.Command("launchctl", "stop", label)
-	output, err := cmd.CombinedOutput()
+	output, err := m.run([]string{"kill", "SIGTERM", m.serviceTarget()}, []string{"stop", m.GetLabel()})
 	if err != nil {
 		return fmt.Errorf("failed to stop agent: %w (output: %s)", err, string(output))
 	}
@@ -168,8 +299,19 @@ func (m *Manager) Stop() error {
 
 // GetStatus gets the status of the LaunchAgent
 func (m *Manager) GetStatus() (*Status, error) {
+	// Unlike 'launchctl list', print sees the system domain without root
+	output, err := simulate.Command("launchctl", "print", m.serviceTarget()).CombinedOutput()
+	if err != nil && unsupported(output) {
+		return m.legacyStatus()
+	}
+	return parsePrint(output, err)
+}
+
+// legacyStatus reads the status with 'launchctl list', for macOS versions
+// without 'launchctl print'
+func (m *Manager) legacyStatus() (*Status, error) {
 	label := m.GetLabel()
-	cmd := exec.Command("launchctl", "list", label)
+	cmd := m.launchctl("list", label)
 	output, err := cmd.CombinedOutput()
 
 	status := &Status{
@@ -238,6 +380,9 @@ func (m *Manager) Remove() error {
 
 	// Remove the plist file
 	plistPath := m.GetPlistPath()
+	if m.daemon {
+		return m.removeDaemonPlist()
+	}
 	if err := os.Remove(plistPath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove plist file: %w", err)
 	}
@@ -250,7 +395,7 @@ func ValidateConfig(config *Config) error {
 	if config.Label == "" {
 		return fmt.Errorf("Label is required")
 	}
-	if config.ScriptPath == "" {
+	if config.ScriptPath == "" && len(config.Arguments) == 0 {
 		return fmt.Errorf("ScriptPath is required")
 	}
 	if config.Hour < 0 || config.Hour > 23 {
@@ -259,5 +404,22 @@ func ValidateConfig(config *Config) error {
 	if config.Minute < 0 || config.Minute > 59 {
 		return fmt.Errorf("Minute must be between 0 and 59")
 	}
+	if config.StartInterval < 0 {
+		return fmt.Errorf("StartInterval cannot be negative")
+	}
+	for _, interval := range config.Intervals {
+		if interval.Hour < 0 || interval.Hour > 23 {
+			return fmt.Errorf("Hour must be between 0 and 23")
+		}
+		if interval.Minute < 0 || interval.Minute > 59 {
+			return fmt.Errorf("Minute must be between 0 and 59")
+		}
+		if interval.Weekday < 0 || interval.Weekday > 7 {
+			return fmt.Errorf("Weekday must be between 1 and 7")
+		}
+		if interval.Day < 0 || interval.Day > 31 {
+			return fmt.Errorf("Day must be between 1 and 31")
+		}
+	}
 	return nil
 }
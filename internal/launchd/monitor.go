@@ -0,0 +1,27 @@
+package launchd
+
+// MonitorInterval is when the monitor agent runs: at 5:00 every day, after
+// the other tools' nightly backups have usually finished
+var MonitorInterval = CalendarInterval{Hour: 5}
+
+// Monitor returns the manager of the user's agent that checks the monitor
+// pairs for drift every day
+func (m *Manager) Monitor() *Manager {
+	return &Manager{username: m.username, agentPath: userAgentDir(), name: "cloudsync-monitor"}
+}
+
+// MonitorConfig returns the configuration of the agent that runs
+// 'program monitor --scheduled' at MonitorInterval
+func MonitorConfig(label, program string) *Config {
+	return &Config{
+		Label:     label,
+		Arguments: []string{program, "monitor", "--scheduled"},
+		Intervals: []CalendarInterval{MonitorInterval},
+	}
+}
+
+// InstallMonitorAgent writes and loads the monitor agent, replacing a
+// loaded one
+func (m *Manager) InstallMonitorAgent(program string) error {
+	return m.install(MonitorConfig(m.GetLabel(), program))
+}
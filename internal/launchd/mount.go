@@ -0,0 +1,38 @@
+package launchd
+
+import "os"
+
+// Mount returns the manager of the user's other agent, which syncs pairs
+// when a drive is connected
+func (m *Manager) Mount() *Manager {
+	return &Manager{username: m.username, agentPath: userAgentDir(), name: "cloudsync-mount"}
+}
+
+// MountConfig returns the configuration of the agent that runs
+// 'program sync --scheduled --mounted' whenever a drive appears in or
+// leaves volumesDir
+func MountConfig(label, program, volumesDir string) *Config {
+	return &Config{
+		Label:      label,
+		Arguments:  []string{program, "sync", "--scheduled", "--mounted"},
+		WatchPaths: []string{volumesDir},
+	}
+}
+
+// InstallMountAgent writes and loads the mount agent, replacing a loaded one
+func (m *Manager) InstallMountAgent(program, volumesDir string) error {
+	return m.install(MountConfig(m.GetLabel(), program, volumesDir))
+}
+
+// install writes and loads an agent, unloading the one it replaces
+func (m *Manager) install(config *Config) error {
+	if _, err := os.Stat(m.GetPlistPath()); err == nil {
+		if err := m.Unload(); err != nil {
+			return err
+		}
+	}
+	if err := m.GeneratePlist(config); err != nil {
+		return err
+	}
+	return m.Load()
+}
@@ -0,0 +1,213 @@
+package launchd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NextRun returns when launchd next starts the job, computed from the
+// StartCalendarInterval entries of the installed plist so that hand edits
+// count. The zero time means the plist is not installed or the job is not
+// started by the calendar, e.g. it runs every N hours.
+func (m *Manager) NextRun(now time.Time) (time.Time, error) {
+	data, err := os.ReadFile(m.GetPlistPath())
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read plist file: %w", err)
+	}
+
+	plist, err := readPlist(data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var entries []calendarEntry
+	switch value := plist["StartCalendarInterval"].(type) {
+	case map[string]any:
+		entries = append(entries, entryFromPlist(value))
+	case []any:
+		for _, item := range value {
+			if dict, ok := item.(map[string]any); ok {
+				entries = append(entries, entryFromPlist(dict))
+			}
+		}
+	}
+	return nextFire(entries, now), nil
+}
+
+// NextCalendarRun returns the first time at or after now at which any of
+// the intervals fires, or the zero time if none ever does
+func NextCalendarRun(intervals []CalendarInterval, now time.Time) time.Time {
+	entries := make([]calendarEntry, 0, len(intervals))
+	for _, interval := range intervals {
+		entry := calendarEntry{minute: interval.Minute, hour: interval.Hour, day: -1, weekday: -1, month: -1}
+		if interval.Day != 0 {
+			entry.day = interval.Day
+		}
+		if interval.Weekday != 0 {
+			entry.weekday = interval.Weekday % 7
+		}
+		entries = append(entries, entry)
+	}
+	return nextFire(entries, now)
+}
+
+// calendarEntry is a StartCalendarInterval entry as launchd reads it. -1
+// stands for an omitted key, which matches every value; weekday counts
+// from Sunday = 0 like time.Weekday.
+type calendarEntry struct {
+	minute, hour, day, weekday, month int
+}
+
+// entryFromPlist reads an entry from its plist dictionary. launchd takes
+// both 0 and 7 for Sunday.
+func entryFromPlist(dict map[string]any) calendarEntry {
+	get := func(key string) int {
+		if n, ok := dict[key].(int); ok {
+			return n
+		}
+		return -1
+	}
+	entry := calendarEntry{minute: get("Minute"), hour: get("Hour"), day: get("Day"), weekday: get("Weekday"), month: get("Month")}
+	if entry.weekday >= 0 {
+		entry.weekday %= 7
+	}
+	return entry
+}
+
+// matchesDay reports whether the entry fires on the day. As in crontab(5),
+// an entry with both a day and a weekday fires on either.
+func (e calendarEntry) matchesDay(day time.Time) bool {
+	if e.month >= 0 && int(day.Month()) != e.month {
+		return false
+	}
+	dayMatches := e.day < 0 || day.Day() == e.day
+	weekdayMatches := e.weekday < 0 || int(day.Weekday()) == e.weekday
+	if e.day >= 0 && e.weekday >= 0 {
+		return dayMatches || weekdayMatches
+	}
+	return dayMatches && weekdayMatches
+}
+
+// firstOn returns the entry's first run on the day at or after from
+func (e calendarEntry) firstOn(day, from time.Time) (time.Time, bool) {
+	for hour := 0; hour < 24; hour++ {
+		if e.hour >= 0 && hour != e.hour {
+			continue
+		}
+		for minute := 0; minute < 60; minute++ {
+			if e.minute >= 0 && minute != e.minute {
+				continue
+			}
+			run := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+			if !run.Before(from) {
+				return run, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextFire returns the earliest run of the entries at or after now. Four
+// years cover every entry that can fire at all, such as February 29th.
+func nextFire(entries []calendarEntry, now time.Time) time.Time {
+	if len(entries) == 0 {
+		return time.Time{}
+	}
+	from := now.Truncate(time.Minute)
+	if from.Before(now) {
+		from = from.Add(time.Minute)
+	}
+
+	for i := 0; i <= 4*366; i++ {
+		day := time.Date(from.Year(), from.Month(), from.Day()+i, 0, 0, 0, 0, from.Location())
+		var next time.Time
+		for _, entry := range entries {
+			if !entry.matchesDay(day) {
+				continue
+			}
+			if run, ok := entry.firstOn(day, from); ok && (next.IsZero() || run.Before(next)) {
+				next = run
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// readPlist decodes the top-level dictionary of an XML property list into
+// maps, slices, strings, ints and bools
+func readPlist(data []byte) (map[string]any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plist: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "dict" {
+			value, err := plistValue(decoder, start)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse plist: %w", err)
+			}
+			return value.(map[string]any), nil
+		}
+	}
+}
+
+// plistValue decodes the element that start opens
+func plistValue(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict", "array":
+		dict := make(map[string]any)
+		array := make([]any, 0)
+		var key string
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch token := token.(type) {
+			case xml.EndElement:
+				if start.Name.Local == "dict" {
+					return dict, nil
+				}
+				return array, nil
+			case xml.StartElement:
+				if token.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &token); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := plistValue(decoder, token)
+				if err != nil {
+					return nil, err
+				}
+				if start.Name.Local == "dict" {
+					dict[key] = value
+				} else {
+					array = append(array, value)
+				}
+			}
+		}
+	case "true", "false":
+		return start.Name.Local == "true", decoder.Skip()
+	default:
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		if start.Name.Local == "integer" {
+			return strconv.Atoi(strings.TrimSpace(text))
+		}
+		return text, nil
+	}
+}
@@ -0,0 +1,26 @@
+package launchd
+
+// PowerSourceNotification is posted when the Mac switches between battery
+// and power adapter
+const PowerSourceNotification = "com.apple.system.powersources.source"
+
+// Power returns the manager of the user's agent that retries deferred
+// syncs when the power source changes
+func (m *Manager) Power() *Manager {
+	return &Manager{username: m.username, agentPath: userAgentDir(), name: "cloudsync-power"}
+}
+
+// PowerConfig returns the configuration of the agent that runs
+// 'program sync --scheduled --deferred' whenever the power source changes
+func PowerConfig(label, program string) *Config {
+	return &Config{
+		Label:        label,
+		Arguments:    []string{program, "sync", "--scheduled", "--deferred"},
+		NotifyEvents: []string{PowerSourceNotification},
+	}
+}
+
+// InstallPowerAgent writes and loads the power agent, replacing a loaded one
+func (m *Manager) InstallPowerAgent(program string) error {
+	return m.install(PowerConfig(m.GetLabel(), program))
+}
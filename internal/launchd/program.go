@@ -0,0 +1,92 @@
+package launchd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ProgramArguments returns the program the plist runs followed by its
+// arguments, e.g. /bin/zsh and the backup script
+func (m *Manager) ProgramArguments() ([]string, error) {
+	data, err := os.ReadFile(m.GetPlistPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plist file: %w", err)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var key string
+	var args []string
+	inArgs := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return args, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", m.GetPlistPath(), err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "key":
+				if err := decoder.DecodeElement(&key, &token); err != nil {
+					return nil, fmt.Errorf("failed to parse %s: %w", m.GetPlistPath(), err)
+				}
+			case "array":
+				inArgs = key == "ProgramArguments"
+			case "string":
+				var value string
+				if err := decoder.DecodeElement(&value, &token); err != nil {
+					return nil, fmt.Errorf("failed to parse %s: %w", m.GetPlistPath(), err)
+				}
+				if inArgs {
+					args = append(args, value)
+				}
+			}
+		case xml.EndElement:
+			if token.Name.Local == "array" && inArgs {
+				return args, nil
+			}
+		}
+	}
+}
+
+// ReplaceProgramArgument rewrites one argument of the program the plist
+// runs, e.g. a script that moved, and reloads a loaded agent so launchd
+// runs the new one. The LaunchDaemon's plist belongs to root and is
+// rewritten with 'cloud-sync daemon' instead.
+func (m *Manager) ReplaceProgramArgument(old, new string) error {
+	if m.daemon {
+		return fmt.Errorf("the LaunchDaemon is owned by root; run 'cloud-sync daemon' again to rewrite it")
+	}
+
+	plistPath := m.GetPlistPath()
+	data, err := os.ReadFile(plistPath)
+	if err != nil {
+		return fmt.Errorf("failed to read plist file: %w", err)
+	}
+	// The plist template writes arguments as they are
+	oldElement, newElement := "<string>"+old+"</string>", "<string>"+new+"</string>"
+	if !bytes.Contains(data, []byte(oldElement)) {
+		return fmt.Errorf("%s does not run %s", plistPath, old)
+	}
+
+	loaded, _ := m.IsLoaded()
+	if loaded {
+		if err := m.Unload(); err != nil {
+			return err
+		}
+	}
+	data = bytes.Replace(data, []byte(oldElement), []byte(newElement), 1)
+	if err := os.WriteFile(plistPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write plist file: %w", err)
+	}
+	if loaded {
+		return m.Load()
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package launchd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeOfDay is a wall-clock time at which the agent runs
+type TimeOfDay struct {
+	Hour   int `json:"hour"`   // 0-23
+	Minute int `json:"minute"` // 0-59
+}
+
+// String returns the time as HH:MM
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// CalendarInterval is one StartCalendarInterval entry. Zero Weekday or Day
+// means the key is omitted, which launchd treats as "every".
+type CalendarInterval struct {
+	Hour    int
+	Minute  int
+	Weekday int // 1 = Monday ... 7 = Sunday, 0 = every day
+	Day     int // Day of month 1-31, 0 = every day
+}
+
+// Schedule describes when the agent runs: at every listed time, on the
+// listed weekdays or days of the month. With neither set it runs daily.
+//
+// IntervalHours switches to launchd's StartInterval, running every N hours
+// instead. Calendar jobs are skipped while the Mac is off, whereas interval
+// jobs simply run on the next wake, so this is the wake-safe choice.
+type Schedule struct {
+	Times         []TimeOfDay `json:"times,omitempty"`
+	Weekdays      []int       `json:"weekdays,omitempty"`       // 1 = Monday ... 7 = Sunday
+	DaysOfMonth   []int       `json:"days_of_month,omitempty"`  // 1-31
+	IntervalHours int         `json:"interval_hours,omitempty"` // Run every N hours instead of at set times
+}
+
+// UsesInterval reports whether the schedule runs every N hours
+func (s Schedule) UsesInterval() bool {
+	return s.IntervalHours > 0
+}
+
+// StartInterval returns the StartInterval in seconds, or 0 for calendar schedules
+func (s Schedule) StartInterval() int {
+	if !s.UsesInterval() {
+		return 0
+	}
+	return s.IntervalHours * 3600
+}
+
+var weekdayNames = []string{"", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// Intervals expands the schedule into StartCalendarInterval entries
+func (s Schedule) Intervals() []CalendarInterval {
+	intervals := make([]CalendarInterval, 0)
+	if s.UsesInterval() {
+		return intervals
+	}
+	for _, t := range s.Times {
+		switch {
+		case len(s.Weekdays) > 0:
+			for _, wd := range s.Weekdays {
+				intervals = append(intervals, CalendarInterval{Hour: t.Hour, Minute: t.Minute, Weekday: wd})
+			}
+		case len(s.DaysOfMonth) > 0:
+			for _, day := range s.DaysOfMonth {
+				intervals = append(intervals, CalendarInterval{Hour: t.Hour, Minute: t.Minute, Day: day})
+			}
+		default:
+			intervals = append(intervals, CalendarInterval{Hour: t.Hour, Minute: t.Minute})
+		}
+	}
+	return intervals
+}
+
+// Validate checks that the schedule can be expressed as calendar intervals
+func (s Schedule) Validate() error {
+	if s.IntervalHours < 0 {
+		return fmt.Errorf("interval hours cannot be negative")
+	}
+	if s.UsesInterval() {
+		if len(s.Weekdays) > 0 || len(s.DaysOfMonth) > 0 {
+			return fmt.Errorf("an hourly interval cannot be limited to weekdays or days of month")
+		}
+		return nil
+	}
+	if len(s.Times) == 0 {
+		return fmt.Errorf("at least one time is required")
+	}
+	for _, t := range s.Times {
+		if t.Hour < 0 || t.Hour > 23 {
+			return fmt.Errorf("hour must be between 0 and 23")
+		}
+		if t.Minute < 0 || t.Minute > 59 {
+			return fmt.Errorf("minute must be between 0 and 59")
+		}
+	}
+	// Mixing weekdays and days of month is ambiguous, so only one is allowed
+	if len(s.Weekdays) > 0 && len(s.DaysOfMonth) > 0 {
+		return fmt.Errorf("weekdays and days of month cannot be combined")
+	}
+	for _, wd := range s.Weekdays {
+		if wd < 1 || wd > 7 {
+			return fmt.Errorf("weekday must be between 1 (Monday) and 7 (Sunday)")
+		}
+	}
+	for _, day := range s.DaysOfMonth {
+		if day < 1 || day > 31 {
+			return fmt.Errorf("day of month must be between 1 and 31")
+		}
+	}
+	return nil
+}
+
+// String describes the schedule, e.g. "Mon, Wed, Fri at 09:00, 18:30"
+func (s Schedule) String() string {
+	if s.UsesInterval() {
+		if s.IntervalHours == 1 {
+			return "Every hour"
+		}
+		return fmt.Sprintf("Every %d hours", s.IntervalHours)
+	}
+
+	times := make([]string, 0, len(s.Times))
+	for _, t := range s.Times {
+		times = append(times, t.String())
+	}
+
+	var days string
+	switch {
+	case len(s.Weekdays) > 0:
+		names := make([]string, 0, len(s.Weekdays))
+		for _, wd := range s.Weekdays {
+			if wd >= 1 && wd <= 7 {
+				names = append(names, weekdayNames[wd])
+			}
+		}
+		days = strings.Join(names, ", ")
+	case len(s.DaysOfMonth) > 0:
+		nums := make([]string, 0, len(s.DaysOfMonth))
+		for _, day := range s.DaysOfMonth {
+			nums = append(nums, strconv.Itoa(day))
+		}
+		days = "Day " + strings.Join(nums, ", ") + " of each month"
+	default:
+		days = "Daily"
+	}
+
+	return fmt.Sprintf("%s at %s", days, strings.Join(times, ", "))
+}
+
+// ParseTimes parses a comma-separated list of HH:MM times
+func ParseTimes(input string) ([]TimeOfDay, error) {
+	times := make([]TimeOfDay, 0)
+	for _, field := range splitList(input) {
+		parts := strings.Split(field, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid time '%s', expected HH:MM", field)
+		}
+		hour, err := strconv.Atoi(parts[0])
+		if err != nil || hour < 0 || hour > 23 {
+			return nil, fmt.Errorf("invalid hour in '%s'", field)
+		}
+		minute, err := strconv.Atoi(parts[1])
+		if err != nil || minute < 0 || minute > 59 {
+			return nil, fmt.Errorf("invalid minute in '%s'", field)
+		}
+		times = append(times, TimeOfDay{Hour: hour, Minute: minute})
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Hour*60+times[i].Minute < times[j].Hour*60+times[j].Minute
+	})
+	return times, nil
+}
+
+// ParseWeekdays parses weekday names, numbers (1 = Monday) and ranges such
+// as "mon-fri" or "1,3,5"
+func ParseWeekdays(input string) ([]int, error) {
+	return parseNumberList(input, 1, 7, func(s string) (int, error) {
+		for i, name := range weekdayNames[1:] {
+			if len(s) >= 3 && strings.EqualFold(s[:3], name) {
+				return i + 1, nil
+			}
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid weekday '%s'", s)
+		}
+		return n, nil
+	})
+}
+
+// ParseDaysOfMonth parses day numbers and ranges such as "1,15" or "1-7"
+func ParseDaysOfMonth(input string) ([]int, error) {
+	return parseNumberList(input, 1, 31, func(s string) (int, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid day of month '%s'", s)
+		}
+		return n, nil
+	})
+}
+
+// parseNumberList parses a comma-separated list of values and ranges into
+// sorted, de-duplicated numbers within [min, max]
+func parseNumberList(input string, min, max int, parse func(string) (int, error)) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, field := range splitList(input) {
+		from, to := field, field
+		if i := strings.Index(field, "-"); i > 0 {
+			from, to = strings.TrimSpace(field[:i]), strings.TrimSpace(field[i+1:])
+		}
+		start, err := parse(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parse(to)
+		if err != nil {
+			return nil, err
+		}
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("'%s' is out of range %d-%d", field, min, max)
+		}
+		for n := start; n <= end; n++ {
+			seen[n] = true
+		}
+	}
+
+	numbers := make([]int, 0, len(seen))
+	for n := range seen {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(input string) []string {
+	fields := make([]string, 0)
+	for _, field := range strings.Split(input, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ExpectedRuns returns the times in [from, to) at which the schedule fires,
+// oldest first. Interval schedules are counted from from.
+func (s Schedule) ExpectedRuns(from, to time.Time) []time.Time {
+	runs := make([]time.Time, 0)
+	if s.UsesInterval() {
+		step := time.Duration(s.IntervalHours) * time.Hour
+		for run := from; run.Before(to); run = run.Add(step) {
+			runs = append(runs, run)
+		}
+		return runs
+	}
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !s.runsOn(day) {
+			continue
+		}
+		for _, t := range s.Times {
+			run := time.Date(day.Year(), day.Month(), day.Day(), t.Hour, t.Minute, 0, 0, day.Location())
+			if !run.Before(from) && run.Before(to) {
+				runs = append(runs, run)
+			}
+		}
+	}
+
+	return runs
+}
+
+// runsOn reports whether the schedule fires on the given day
+func (s Schedule) runsOn(day time.Time) bool {
+	switch {
+	case len(s.Weekdays) > 0:
+		weekday := int(day.Weekday())
+		if weekday == 0 {
+			weekday = 7 // Sunday
+		}
+		for _, wd := range s.Weekdays {
+			if wd == weekday {
+				return true
+			}
+		}
+		return false
+	case len(s.DaysOfMonth) > 0:
+		for _, d := range s.DaysOfMonth {
+			if d == day.Day() {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
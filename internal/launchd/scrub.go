@@ -0,0 +1,26 @@
+package launchd
+
+// ScrubInterval is when the scrub agent runs: at 4:00 on the first of
+// every month, when the Mac is likely idle
+var ScrubInterval = CalendarInterval{Day: 1, Hour: 4}
+
+// Scrub returns the manager of the user's agent that runs the monthly
+// integrity scrub
+func (m *Manager) Scrub() *Manager {
+	return &Manager{username: m.username, agentPath: userAgentDir(), name: "cloudsync-scrub"}
+}
+
+// ScrubConfig returns the configuration of the agent that runs
+// 'program scrub --scheduled' at ScrubInterval
+func ScrubConfig(label, program string) *Config {
+	return &Config{
+		Label:     label,
+		Arguments: []string{program, "scrub", "--scheduled"},
+		Intervals: []CalendarInterval{ScrubInterval},
+	}
+}
+
+// InstallScrubAgent writes and loads the scrub agent, replacing a loaded one
+func (m *Manager) InstallScrubAgent(program string) error {
+	return m.install(ScrubConfig(m.GetLabel(), program))
+}
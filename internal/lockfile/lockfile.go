@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
 )
 
 // Manager handles lockfile operations
@@ -32,10 +34,10 @@ func (m *Manager) Exists() bool {
 	return err == nil
 }
 
-// Create creates a lockfile
+// Create creates a lockfile. An existing one is an errkind.ErrLocked error.
 func (m *Manager) Create() error {
 	if m.Exists() {
-		return fmt.Errorf("lockfile already exists at %s", m.lockfilePath)
+		return errkind.Wrap(errkind.ErrLocked, fmt.Errorf("lockfile already exists at %s", m.lockfilePath))
 	}
 
 	// Create the lockfile with current timestamp
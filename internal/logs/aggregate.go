@@ -0,0 +1,73 @@
+package logs
+
+import (
+	"sort"
+	"time"
+)
+
+// AggregateStats sums up the sessions of one day or one sync pair
+type AggregateStats struct {
+	Key         string // Day as 2006-01-02, or the pair name (empty for the main log)
+	Sessions    int
+	Successful  int
+	Files       int
+	Bytes       int64
+	SuccessRate float64
+}
+
+// add counts a session
+func (a *AggregateStats) add(session SyncSession) {
+	a.Sessions++
+	if session.Success {
+		a.Successful++
+	}
+	a.Files += session.Transfers
+	a.Bytes += session.Bytes
+	a.SuccessRate = float64(a.Successful) / float64(a.Sessions) * 100
+}
+
+// GetStatsByDay aggregates the sessions started at or after since per day,
+// oldest day first. Days without sessions are left out.
+func (m *Manager) GetStatsByDay(since time.Time) ([]AggregateStats, error) {
+	return m.aggregate(since, func(s SyncSession) string {
+		return s.StartTime.Format("2006-01-02")
+	})
+}
+
+// GetStatsByPair aggregates the sessions started at or after since per sync
+// pair, sorted by name with the main log first. A zero since includes every
+// session.
+func (m *Manager) GetStatsByPair(since time.Time) ([]AggregateStats, error) {
+	return m.aggregate(since, func(s SyncSession) string {
+		return s.Pair
+	})
+}
+
+// aggregate groups the sessions started at or after since by key, sorted by key
+func (m *Manager) aggregate(since time.Time, key func(SyncSession) string) ([]AggregateStats, error) {
+	sessions, err := m.GetSyncSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*AggregateStats)
+	for _, session := range sessions {
+		if session.StartTime.Before(since) {
+			continue
+		}
+		k := key(session)
+		if byKey[k] == nil {
+			byKey[k] = &AggregateStats{Key: k}
+		}
+		byKey[k].add(session)
+	}
+
+	result := make([]AggregateStats, 0, len(byKey))
+	for _, stats := range byKey {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
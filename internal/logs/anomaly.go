@@ -0,0 +1,142 @@
+package logs
+
+import (
+	"fmt"
+	"math"
+)
+
+// Anomaly checks compare a run with the mean of a pair's earlier successful
+// runs. A run is only flagged once the pair has MinHistoryRuns of them, and
+// when it exceeds AnomalyFactor times the mean, or the floor for small means,
+// so a pair that usually deletes nothing is not flagged for deleting one file.
+const (
+	AnomalyFactor  = 10
+	MinHistoryRuns = 5
+	historyRuns    = 30 // Most recent runs the mean is taken over
+
+	deletesFloor = 10
+	filesFloor   = 100
+	bytesFloor   = 100 << 20
+)
+
+// Measures compared by the anomaly checks
+const (
+	MeasureDeletes = "deletes"
+	MeasureFiles   = "files"
+	MeasureBytes   = "bytes"
+)
+
+// RunVolume is how much one run of a pair changed
+type RunVolume struct {
+	Files   int   // Files copied
+	Bytes   int64 // Bytes transferred
+	Deletes int   // Files deleted at the destination
+}
+
+// History is the mean volume of a pair's recent successful runs
+type History struct {
+	Runs        int
+	MeanFiles   float64
+	MeanBytes   float64
+	MeanDeletes float64
+}
+
+// Anomaly is a measure of a run far above the pair's usual
+type Anomaly struct {
+	Measure string // MeasureDeletes, MeasureFiles or MeasureBytes
+	Value   float64
+	Mean    float64
+	Planned bool // Counted in a preview before the run, not after it
+}
+
+// Factor returns how many times the usual the value is
+func (a Anomaly) Factor() float64 {
+	if a.Mean == 0 {
+		return math.Inf(1)
+	}
+	return a.Value / a.Mean
+}
+
+// String describes the anomaly, e.g. "500 files deleted (42x the usual 12)"
+func (a Anomaly) String() string {
+	var value, mean string
+	switch a.Measure {
+	case MeasureDeletes:
+		value, mean = fmt.Sprintf("%.0f files deleted", a.Value), fileCount(a.Mean)
+		if a.Planned {
+			value = fmt.Sprintf("%.0f files to delete", a.Value)
+		}
+	case MeasureBytes:
+		value, mean = humanSize(a.Value)+" transferred", humanSize(a.Mean)
+	default:
+		value, mean = fmt.Sprintf("%.0f files copied", a.Value), fileCount(a.Mean)
+	}
+	if a.Mean == 0 {
+		return value + " (usually none)"
+	}
+	return fmt.Sprintf("%s (%.0fx the usual %s)", value, a.Factor(), mean)
+}
+
+// fileCount formats a mean number of files, keeping a decimal for small ones
+func fileCount(mean float64) string {
+	if mean < 10 {
+		return fmt.Sprintf("%.1f", mean)
+	}
+	return fmt.Sprintf("%.0f", mean)
+}
+
+// PairHistory returns the mean volume of a pair's most recent successful
+// sessions, read from its log
+func (m *Manager) PairHistory(pair string) (History, error) {
+	sessions, err := m.ForPair(pair).GetSyncSessions()
+	if err != nil {
+		return History{}, err
+	}
+	return HistoryOf(sessions), nil
+}
+
+// HistoryOf returns the mean volume of the most recent successful sessions
+func HistoryOf(sessions []SyncSession) History {
+	var h History
+	for i := len(sessions) - 1; i >= 0 && h.Runs < historyRuns; i-- {
+		session := sessions[i]
+		// Runs limited to one folder are no measure of the whole pair
+		if !session.Success || session.Subpath != "" {
+			continue
+		}
+		h.Runs++
+		h.MeanFiles += float64(session.Transfers)
+		h.MeanBytes += float64(session.Bytes)
+		h.MeanDeletes += float64(session.Deletes)
+	}
+	if h.Runs > 0 {
+		h.MeanFiles /= float64(h.Runs)
+		h.MeanBytes /= float64(h.Runs)
+		h.MeanDeletes /= float64(h.Runs)
+	}
+	return h
+}
+
+// Check returns the measures of run that are far above the history. Too
+// short a history flags nothing.
+func (h History) Check(run RunVolume) []Anomaly {
+	if h.Runs < MinHistoryRuns {
+		return nil
+	}
+
+	var anomalies []Anomaly
+	add := func(measure string, value, mean, floor float64) {
+		if value > AnomalyFactor*math.Max(mean, floor) {
+			anomalies = append(anomalies, Anomaly{Measure: measure, Value: value, Mean: mean})
+		}
+	}
+	add(MeasureDeletes, float64(run.Deletes), h.MeanDeletes, deletesFloor)
+	add(MeasureFiles, float64(run.Files), h.MeanFiles, filesFloor)
+	add(MeasureBytes, float64(run.Bytes), h.MeanBytes, bytesFloor)
+	return anomalies
+}
+
+// Volume returns how much the session changed
+func (s SyncSession) Volume() RunVolume {
+	return RunVolume{Files: s.Transfers, Bytes: s.Bytes, Deletes: s.Deletes}
+}
@@ -0,0 +1,92 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+)
+
+// MaxBenchmarkRuns is how many benchmark runs the history keeps
+const MaxBenchmarkRuns = 100
+
+// BenchmarkRun is one remote's benchmark: how fast it answered and moved a
+// test object
+type BenchmarkRun struct {
+	Remote       string        `json:"remote"`
+	Target       string        `json:"target"` // Where the test object went, e.g. "b2:bucket"
+	Time         time.Time     `json:"time"`
+	Size         int64         `json:"size"`
+	Latency      time.Duration `json:"latency,omitempty"`
+	UploadRate   float64       `json:"upload_rate,omitempty"`   // Bytes per second
+	DownloadRate float64       `json:"download_rate,omitempty"` // Bytes per second
+	Error        string        `json:"error,omitempty"`         // Why the benchmark could not run
+}
+
+// Summary describes the run's outcome, e.g. "latency 120ms, up 4.2 MB/s,
+// down 8.1 MB/s"
+func (r BenchmarkRun) Summary() string {
+	if r.Error != "" {
+		return "benchmark failed: " + r.Error
+	}
+	return fmt.Sprintf("latency %s, up %.1f MB/s, down %.1f MB/s", r.Latency.Round(time.Millisecond),
+		r.UploadRate/(1<<20), r.DownloadRate/(1<<20))
+}
+
+// benchmarkPath is the file holding the benchmark history
+func (m *Manager) benchmarkPath() string {
+	return filepath.Join(m.logDir, "benchmark_history.json")
+}
+
+// BenchmarkHistory returns the recorded benchmark runs, oldest first
+func (m *Manager) BenchmarkHistory() ([]BenchmarkRun, error) {
+	data, err := os.ReadFile(m.benchmarkPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark history: %w", err)
+	}
+	var runs []BenchmarkRun
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse benchmark history: %w", jsonfile.NewCorruptError(m.benchmarkPath(), err))
+	}
+	return runs, nil
+}
+
+// LatestBenchmarks returns the most recent run of each remote, by remote
+// name
+func (m *Manager) LatestBenchmarks() (map[string]BenchmarkRun, error) {
+	runs, err := m.BenchmarkHistory()
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]BenchmarkRun)
+	for _, run := range runs {
+		latest[run.Remote] = run
+	}
+	return latest, nil
+}
+
+// RecordBenchmark adds a run to the history, dropping the oldest past
+// MaxBenchmarkRuns
+func (m *Manager) RecordBenchmark(run BenchmarkRun) error {
+	unlock, err := jsonfile.Lock(m.benchmarkPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	runs, err := m.BenchmarkHistory()
+	if err != nil {
+		return err
+	}
+	runs = append(runs, run)
+	if len(runs) > MaxBenchmarkRuns {
+		runs = runs[len(runs)-MaxBenchmarkRuns:]
+	}
+	return jsonfile.Write(m.benchmarkPath(), runs, 0600)
+}
@@ -2,17 +2,24 @@ package logs
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
 
-// Manager handles log operations
+// Manager handles log operations. Besides the main backup log it reads the
+// per-pair logs of every pair added with AddPairs and merges their entries.
 type Manager struct {
 	logFilePath string
+	logDir      string
+	pair        string   // Pair the main log belongs to, for pair managers
+	pairs       []string // Pairs whose logs are merged into the main log
 }
 
 // Transfer represents a file transfer entry
@@ -21,6 +28,7 @@ type Transfer struct {
 	Filename  string
 	Size      int64
 	Action    string // Copied, Deleted, etc.
+	Pair      string // Sync pair the transfer belongs to, empty for the main log
 }
 
 // SyncSession represents a backup sync session
@@ -28,8 +36,33 @@ type SyncSession struct {
 	StartTime time.Time
 	EndTime   time.Time
 	Success   bool
+	Cancelled bool   // Stopped by the user before it finished
 	Type      string // Manual, Automated
 	Transfers int
+	Bytes     int64  // Bytes transferred, from rclone's last stats line
+	Deletes   int    // Files deleted at the destination
+	Pair      string // Sync pair the session belongs to, empty for the main log
+	Subpath   string // Folder of the pair an ad-hoc sync was limited to
+
+	// Where the session is in its log, for GetSessionDetail
+	logPath   string
+	startLine int
+	endLine   int // Exclusive
+}
+
+// Duration returns how long the session ran, or 0 while it runs. A clock set
+// back during the session, e.g. by a time sync, does not make it negative.
+func (s SyncSession) Duration() time.Duration {
+	if s.EndTime.IsZero() || s.EndTime.Before(s.StartTime) {
+		return 0
+	}
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// logFile is one log file read by a Manager
+type logFile struct {
+	path string
+	pair string
 }
 
 // Stats represents backup statistics
@@ -44,86 +77,237 @@ type Stats struct {
 // NewManager creates a new log manager
 func NewManager(logDir string) *Manager {
 	return &Manager{
-		logFilePath: filepath.Join(logDir, "rclone_backup.log"),
+		logFilePath: filepath.Join(logDir, mainLogName),
+		logDir:      logDir,
 	}
 }
 
-// NewManagerWithPath creates a manager with custom log path
-func NewManagerWithPath(logFilePath string) *Manager {
-	return &Manager{
-		logFilePath: logFilePath,
+// mainLogName is the file name of the main backup log
+const mainLogName = "rclone_backup.log"
+
+// PairLogDir returns the directory the sync pairs' logs are kept in, apart
+// from the main log so no pair name can clash with it
+func PairLogDir(logDir string) string {
+	return filepath.Join(logDir, "pairs")
+}
+
+// PairLogPath returns the log file a sync pair's rclone output is written to
+func PairLogPath(logDir, pair string) string {
+	return filepath.Join(PairLogDir(logDir), PairLogName(pair))
+}
+
+// PairLogName returns the file name of a sync pair's log. Characters that
+// are not safe in file names are replaced, and a name that needed that gets
+// a short hash of the pair's name, so "a/b" and "a_b" have logs of their
+// own.
+func PairLogName(pair string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, pair)
+	if name != pair || name == "" {
+		sum := sha256.Sum256([]byte(pair))
+		name += "-" + hex.EncodeToString(sum[:4])
 	}
+	return name + ".log"
 }
 
-// GetLogPath returns the log file path
-func (m *Manager) GetLogPath() string {
-	return m.logFilePath
+// LegacyPairLogPath returns where a sync pair's log was kept before pair
+// logs moved to PairLogDir, or "" if that file was not the pair's alone:
+// its name had characters replaced, or it was the main log
+func LegacyPairLogPath(logDir, pair string) string {
+	name := PairLogName(pair)
+	if name != pair+".log" || name == mainLogName {
+		return ""
+	}
+	return filepath.Join(logDir, name)
 }
 
-// LogExists checks if the log file exists
-func (m *Manager) LogExists() bool {
-	_, err := os.Stat(m.logFilePath)
-	return err == nil
+// AddPairs merges the logs of the given sync pairs into this manager's
+// transfers, sessions and stats
+func (m *Manager) AddPairs(pairs ...string) {
+	for _, pair := range pairs {
+		found := false
+		for _, existing := range m.pairs {
+			if existing == pair {
+				found = true
+				break
+			}
+		}
+		if !found {
+			m.pairs = append(m.pairs, pair)
+			m.moveLegacyLog(pair)
+		}
+	}
+	sort.Strings(m.pairs)
 }
 
-// GetTodaysTransfers returns transfers from today
-func (m *Manager) GetTodaysTransfers() ([]Transfer, error) {
-	if !m.LogExists() {
-		return []Transfer{}, nil
+// moveLegacyLog moves a pair's log from where it was kept before into
+// PairLogDir, unless a log is there already. A log that cannot be moved
+// only leaves its history out of the merged logs.
+func (m *Manager) moveLegacyLog(pair string) {
+	legacy := LegacyPairLogPath(m.logDir, pair)
+	if legacy == "" {
+		return
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return
+	}
+	path := PairLogPath(m.logDir, pair)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return
 	}
+	if os.MkdirAll(filepath.Dir(path), 0755) == nil {
+		os.Rename(legacy, path)
+	}
+}
+
+// Pairs returns the sync pairs whose logs are merged
+func (m *Manager) Pairs() []string {
+	return append([]string(nil), m.pairs...)
+}
 
-	file, err := os.Open(m.logFilePath)
+// ForPair returns a manager that reads only the given sync pair's log
+func (m *Manager) ForPair(pair string) *Manager {
+	m.moveLegacyLog(pair)
+	return &Manager{
+		logFilePath: PairLogPath(m.logDir, pair),
+		logDir:      m.logDir,
+		pair:        pair,
+	}
+}
+
+// PairLogPath returns the log file of one of this manager's sync pairs
+func (m *Manager) PairLogPath(pair string) string {
+	return PairLogPath(m.logDir, pair)
+}
+
+// LogPairEvent appends a timestamped marker line, such as the start or end
+// of a sync, to a sync pair's log
+func (m *Manager) LogPairEvent(pair, message string) error {
+	if err := os.MkdirAll(PairLogDir(m.logDir), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(m.PairLogPath(pair), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+		return fmt.Errorf("failed to open pair log: %w", err)
 	}
 	defer file.Close()
 
-	today := time.Now().Format("2006/01/02")
-	var transfers []Transfer
+	if _, err := fmt.Fprintf(file, "%s NOTICE: %s\n", time.Now().Format(TimestampLayout), message); err != nil {
+		return fmt.Errorf("failed to write pair log: %w", err)
+	}
+	return nil
+}
+
+// files returns the log files this manager reads, main log first
+func (m *Manager) files() []logFile {
+	files := []logFile{{path: m.logFilePath, pair: m.pair}}
+	for _, pair := range m.pairs {
+		files = append(files, logFile{path: PairLogPath(m.logDir, pair), pair: pair})
+	}
+	return files
+}
+
+// scanFile calls fn for every line of a log file. Missing files are skipped.
+func scanFile(path string, fn func(line string)) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, today) && strings.Contains(line, "INFO") && strings.Contains(line, "Copied") {
-			if transfer := parseTransferLine(line); transfer != nil {
-				transfers = append(transfers, *transfer)
+		fn(scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading log file: %w", err)
+	}
+	return nil
+}
+
+// collectTransfers returns the transfers on lines matching keep from every
+// log file, in time order
+func (m *Manager) collectTransfers(keep func(line string) bool) ([]Transfer, error) {
+	var transfers []Transfer
+
+	for _, f := range m.files() {
+		err := scanFile(f.path, func(line string) {
+			if keep(line) {
+				if transfer := parseTransfer(line); transfer != nil {
+					transfer.Pair = f.pair
+					transfers = append(transfers, *transfer)
+				}
 			}
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading log file: %w", err)
+	if len(m.pairs) > 0 {
+		sort.SliceStable(transfers, func(i, j int) bool {
+			return transfers[i].Timestamp.Before(transfers[j].Timestamp)
+		})
 	}
 
 	return transfers, nil
 }
 
-// GetRecentTransfers returns the most recent N transfers
-func (m *Manager) GetRecentTransfers(count int) ([]Transfer, error) {
-	if !m.LogExists() {
-		return []Transfer{}, nil
+// NewManagerWithPath creates a manager with custom log path
+func NewManagerWithPath(logFilePath string) *Manager {
+	return &Manager{
+		logFilePath: logFilePath,
 	}
+}
 
-	file, err := os.Open(m.logFilePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+// GetLogPath returns the log file path
+func (m *Manager) GetLogPath() string {
+	return m.logFilePath
+}
+
+// LogExists checks if the main log or any pair log exists
+func (m *Manager) LogExists() bool {
+	for _, f := range m.files() {
+		if _, err := os.Stat(f.path); err == nil {
+			return true
+		}
 	}
-	defer file.Close()
+	return false
+}
 
-	var allTransfers []Transfer
+// GetTodaysTransfers returns transfers from today
+func (m *Manager) GetTodaysTransfers() ([]Transfer, error) {
+	if !m.LogExists() {
+		return []Transfer{}, nil
+	}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "INFO") && strings.Contains(line, "Copied") {
-			if transfer := parseTransferLine(line); transfer != nil {
-				allTransfers = append(allTransfers, *transfer)
-			}
-		}
+	// Lines may come from another zone, so compare the local day
+	today := time.Now().Format("2006-01-02")
+	return m.collectTransfers(func(line string) bool {
+		return parseTimestamp(line).Format("2006-01-02") == today
+	})
+}
+
+// GetRecentTransfers returns the most recent N transfers
+func (m *Manager) GetRecentTransfers(count int) ([]Transfer, error) {
+	if !m.LogExists() {
+		return []Transfer{}, nil
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading log file: %w", err)
+	allTransfers, err := m.GetAllTransfers()
+	if err != nil {
+		return nil, err
 	}
 
 	// Return last N transfers
@@ -140,29 +324,7 @@ func (m *Manager) GetAllTransfers() ([]Transfer, error) {
 		return []Transfer{}, nil
 	}
 
-	file, err := os.Open(m.logFilePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
-	}
-	defer file.Close()
-
-	var transfers []Transfer
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "INFO") && strings.Contains(line, "Copied") {
-			if transfer := parseTransferLine(line); transfer != nil {
-				transfers = append(transfers, *transfer)
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading log file: %w", err)
-	}
-
-	return transfers, nil
+	return m.collectTransfers(func(string) bool { return true })
 }
 
 // GetSyncSessions returns all sync sessions from the log
@@ -171,35 +333,69 @@ func (m *Manager) GetSyncSessions() ([]SyncSession, error) {
 		return []SyncSession{}, nil
 	}
 
-	file, err := os.Open(m.logFilePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+	var sessions []SyncSession
+	for _, f := range m.files() {
+		fileSessions, err := parseSessions(f)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, fileSessions...)
+	}
+
+	if len(m.pairs) > 0 {
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return sessions[i].StartTime.Before(sessions[j].StartTime)
+		})
 	}
-	defer file.Close()
 
+	return sessions, nil
+}
+
+// parseSessions returns the sync sessions recorded in one log file
+func parseSessions(f logFile) ([]SyncSession, error) {
 	var sessions []SyncSession
 	var currentSession *SyncSession
+	lineNo := -1
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+	// finish adds the current session, which ends before line end unless
+	// its end marker was seen
+	finish := func(end int) {
+		if currentSession.endLine == 0 {
+			currentSession.endLine = end
+		}
+		sessions = append(sessions, *currentSession)
+	}
+
+	err := scanFile(f.path, func(line string) {
+		lineNo++
 
 		// Detect session start
-		if strings.Contains(line, "Manual Sync Requested") {
+		if _, rest, ok := strings.Cut(line, "Manual Sync Requested"); ok {
 			if currentSession != nil {
-				sessions = append(sessions, *currentSession)
+				finish(lineNo)
+			}
+			var subpath string
+			if folder, ok := strings.CutPrefix(rest, " for "); ok {
+				subpath = strings.TrimSpace(folder)
 			}
 			currentSession = &SyncSession{
 				StartTime: parseTimestamp(line),
 				Type:      "Manual",
+				Pair:      f.pair,
+				Subpath:   subpath,
+				logPath:   f.path,
+				startLine: lineNo,
 			}
 		} else if strings.Contains(line, "Automated Check Started") {
 			if currentSession != nil {
-				sessions = append(sessions, *currentSession)
+				finish(lineNo)
 			}
 			currentSession = &SyncSession{
 				StartTime: parseTimestamp(line),
 				Type:      "Automated",
+				Pair:      f.pair,
+				logPath:   f.path,
+				startLine: lineNo,
 			}
 		}
 
@@ -208,25 +404,37 @@ func (m *Manager) GetSyncSessions() ([]SyncSession, error) {
 			if strings.Contains(line, "Manual Sync Complete: Success") || strings.Contains(line, "Backup successful") {
 				currentSession.EndTime = parseTimestamp(line)
 				currentSession.Success = true
+				currentSession.endLine = lineNo + 1
+			} else if strings.Contains(line, "Manual Sync Complete: Cancelled") {
+				currentSession.EndTime = parseTimestamp(line)
+				currentSession.Cancelled = true
+				currentSession.endLine = lineNo + 1
 			} else if strings.Contains(line, "Manual Sync Complete: Failed") || strings.Contains(line, "ERROR: Rclone sync failed") {
 				currentSession.EndTime = parseTimestamp(line)
 				currentSession.Success = false
+				currentSession.endLine = lineNo + 1
 			}
 
 			// Count transfers in this session
-			if strings.Contains(line, "INFO") && strings.Contains(line, "Copied") {
+			if parseTransfer(line) != nil {
 				currentSession.Transfers++
 			}
+			if isDeletion(line) {
+				currentSession.Deletes++
+			}
+			// Stats are cumulative, so the last line has the total
+			if bytes, ok := parseTransferredBytes(line); ok {
+				currentSession.Bytes = bytes
+			}
 		}
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Add last session if exists
 	if currentSession != nil {
-		sessions = append(sessions, *currentSession)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading log file: %w", err)
+		finish(lineNo + 1)
 	}
 
 	return sessions, nil
@@ -248,10 +456,16 @@ func (m *Manager) GetStats() (*Stats, error) {
 		TotalFiles: len(transfers),
 	}
 
-	// Calculate total size
+	// Calculate total size. Only JSON logs record the size of each file;
+	// otherwise use the totals of rclone's stats lines.
 	for _, transfer := range transfers {
 		stats.TotalSize += transfer.Size
 	}
+	if stats.TotalSize == 0 {
+		for _, session := range sessions {
+			stats.TotalSize += session.Bytes
+		}
+	}
 
 	// Find last sync and last success
 	successCount := 0
@@ -276,26 +490,15 @@ func (m *Manager) GetStats() (*Stats, error) {
 	return stats, nil
 }
 
-// TailLog returns the last N lines from the log
+// TailLog returns the last N lines from the main log. Use ForPair to tail
+// a sync pair's log.
 func (m *Manager) TailLog(lines int) ([]string, error) {
-	if !m.LogExists() {
-		return []string{}, nil
-	}
-
-	file, err := os.Open(m.logFilePath)
+	allLines := []string{}
+	err := scanFile(m.logFilePath, func(line string) {
+		allLines = append(allLines, line)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
-	}
-	defer file.Close()
-
-	var allLines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		allLines = append(allLines, scanner.Text())
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading log file: %w", err)
+		return nil, err
 	}
 
 	// Return last N lines
@@ -306,6 +509,39 @@ func (m *Manager) TailLog(lines int) ([]string, error) {
 	return allLines, nil
 }
 
+// parseTransfer parses a copied file from a text or JSON log line
+func parseTransfer(line string) *Transfer {
+	if strings.HasPrefix(line, "{") {
+		return parseJSONTransferLine(line)
+	}
+	if strings.Contains(line, "INFO") && strings.Contains(line, "Copied") {
+		return parseTransferLine(line)
+	}
+	return nil
+}
+
+// deletedPattern matches rclone's line for a file it deleted, e.g.
+// "2024/11/03 14:30:45 INFO  : old.txt: Deleted"
+var deletedPattern = regexp.MustCompile(`INFO\s+:\s+(.+?):\s+Deleted$`)
+
+// isDeletion reports whether a text or JSON log line records a deleted file
+func isDeletion(line string) bool {
+	_, ok := parseDeletion(line)
+	return ok
+}
+
+// parseDeletion returns the file a text or JSON log line records as deleted
+func parseDeletion(line string) (string, bool) {
+	if entry, ok := parseJSONLine(line); ok {
+		return entry.Object, entry.Level == "info" && entry.Msg == "Deleted" && entry.Object != ""
+	}
+	matches := deletedPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+	if matches == nil {
+		return "", false
+	}
+	return strings.TrimSpace(matches[1]), true
+}
+
 // parseTransferLine parses a log line containing transfer information
 func parseTransferLine(line string) *Transfer {
 	// Example: "2024/11/03 14:30:45 INFO  : file.txt: Copied (new)"
@@ -316,8 +552,8 @@ func parseTransferLine(line string) *Transfer {
 		return nil
 	}
 
-	timestamp, err := time.Parse("2006/01/02 15:04:05", matches[1])
-	if err != nil {
+	timestamp := parseTimestamp(line)
+	if timestamp.IsZero() {
 		return nil
 	}
 
@@ -328,52 +564,39 @@ func parseTransferLine(line string) *Transfer {
 	}
 }
 
-// parseTimestamp extracts timestamp from a log line
-func parseTimestamp(line string) time.Time {
-	// Try to find a date pattern
-	re := regexp.MustCompile(`(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2})`)
-	matches := re.FindStringSubmatch(line)
+// ClearOldLogs removes log entries older than the specified duration from
+// the main log and every pair log
+func (m *Manager) ClearOldLogs(olderThan time.Duration) error {
+	cutoffTime := time.Now().Add(-olderThan)
 
-	if len(matches) > 1 {
-		timestamp, err := time.Parse("2006/01/02 15:04:05", matches[1])
-		if err == nil {
-			return timestamp
+	for _, f := range m.files() {
+		if err := clearOldLines(f.path, cutoffTime); err != nil {
+			return err
 		}
 	}
 
-	return time.Time{}
+	return nil
 }
 
-// ClearOldLogs removes log entries older than the specified duration
-func (m *Manager) ClearOldLogs(olderThan time.Duration) error {
-	if !m.LogExists() {
+// clearOldLines rewrites a log file without the lines older than cutoffTime
+func clearOldLines(path string, cutoffTime time.Time) error {
+	if _, err := os.Stat(path); err != nil {
 		return nil
 	}
 
-	file, err := os.Open(m.logFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
-	}
-	defer file.Close()
-
-	cutoffTime := time.Now().Add(-olderThan)
 	var newLines []string
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+	err := scanFile(path, func(line string) {
 		timestamp := parseTimestamp(line)
 
 		// Keep lines without timestamps or recent lines
 		if timestamp.IsZero() || timestamp.After(cutoffTime) {
 			newLines = append(newLines, line)
 		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading log file: %w", err)
+	})
+	if err != nil {
+		return err
 	}
 
 	// Write back to file
-	return os.WriteFile(m.logFilePath, []byte(strings.Join(newLines, "\n")+"\n"), 0644)
+	return os.WriteFile(path, []byte(strings.Join(newLines, "\n")+"\n"), 0644)
 }
@@ -0,0 +1,56 @@
+package logs
+
+import (
+	"sort"
+	"time"
+)
+
+// MissedRun is a scheduled run that did not happen or did not succeed
+type MissedRun struct {
+	Expected time.Time
+	Reason   string // "did not run" or "failed"
+}
+
+// MissedRuns compares expected run times, oldest first, against the
+// automated sessions in the log. An expected run is satisfied by an
+// automated session that starts before the next expected run (launchd runs
+// jobs missed during sleep on wake). The last expected run is considered
+// up to now.
+func MissedRuns(expected []time.Time, sessions []SyncSession, now time.Time) []MissedRun {
+	starts := make([]SyncSession, 0)
+	for _, session := range sessions {
+		if session.Type == "Automated" {
+			starts = append(starts, session)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].StartTime.Before(starts[j].StartTime)
+	})
+
+	missed := make([]MissedRun, 0)
+	for i, run := range expected {
+		end := now
+		if i+1 < len(expected) {
+			end = expected[i+1]
+		}
+
+		var found *SyncSession
+		for j := range starts {
+			// Allow for log timestamps a little ahead of the scheduled time
+			start := starts[j].StartTime
+			if !start.Before(run.Add(-time.Minute)) && start.Before(end) {
+				found = &starts[j]
+				break
+			}
+		}
+
+		switch {
+		case found == nil:
+			missed = append(missed, MissedRun{Expected: run, Reason: "did not run"})
+		case !found.EndTime.IsZero() && !found.Success:
+			missed = append(missed, MissedRun{Expected: run, Reason: "failed"})
+		}
+	}
+
+	return missed
+}
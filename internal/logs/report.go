@@ -0,0 +1,382 @@
+package logs
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Report formats
+const (
+	ReportMarkdown = "markdown"
+	ReportHTML     = "html"
+)
+
+// DefaultReportsKept is how many reports of each pair are kept when the
+// configuration does not say
+const DefaultReportsKept = 20
+
+// maxReportFiles is how many files a report lists per section; the rest
+// are counted
+const maxReportFiles = 1000
+
+// reportTimeLayout starts report file names, so they sort by time
+const reportTimeLayout = "2006-01-02_150405"
+
+// reportExtensions are the file extensions of the report formats
+var reportExtensions = map[string]string{
+	ReportMarkdown: ".md",
+	ReportHTML:     ".html",
+}
+
+// ValidateReportFormat checks a report format is one cloud-sync writes.
+// An empty format means Markdown.
+func ValidateReportFormat(format string) error {
+	if _, ok := reportExtensions[format]; !ok && format != "" {
+		return fmt.Errorf("report format must be %s or %s, not '%s'", ReportMarkdown, ReportHTML, format)
+	}
+	return nil
+}
+
+// reportData is what the report templates render
+type reportData struct {
+	Pair     string
+	Subpath  string
+	Type     string
+	Result   string
+	Started  string
+	Finished string
+	Duration string
+	Bytes    string
+
+	Transferred     []reportFile
+	MoreTransferred int
+	Deleted         []string
+	MoreDeleted     int
+	Errors          []string
+	MoreErrors      int
+	Stats           []string
+}
+
+// reportFile is one transferred file of a report
+type reportFile struct {
+	Name string
+	Size string // Empty when the log does not record it
+}
+
+// newReportData summarizes a session for the report templates
+func newReportData(detail *SessionDetail) reportData {
+	session := detail.Session
+	data := reportData{
+		Pair:     session.Pair,
+		Subpath:  session.Subpath,
+		Type:     session.Type,
+		Result:   "Failed",
+		Started:  session.StartTime.Format("2006-01-02 15:04:05"),
+		Finished: "-",
+		Duration: "-",
+		Stats:    detail.Stats,
+	}
+	switch {
+	case session.Success:
+		data.Result = "Success"
+	case session.Cancelled:
+		data.Result = "Cancelled"
+	}
+	if !session.EndTime.IsZero() {
+		data.Finished = session.EndTime.Format("2006-01-02 15:04:05")
+		data.Duration = session.Duration().Round(time.Second).String()
+	}
+	if session.Bytes > 0 {
+		data.Bytes = humanSize(float64(session.Bytes))
+	}
+
+	for i, transfer := range detail.Transfers {
+		if i == maxReportFiles {
+			data.MoreTransferred = len(detail.Transfers) - maxReportFiles
+			break
+		}
+		file := reportFile{Name: transfer.Filename}
+		if transfer.Size > 0 {
+			file.Size = humanSize(float64(transfer.Size))
+		}
+		data.Transferred = append(data.Transferred, file)
+	}
+	data.Deleted, data.MoreDeleted = capReportList(detail.Deleted)
+	data.Errors, data.MoreErrors = capReportList(detail.Errors)
+	return data
+}
+
+// capReportList returns the first maxReportFiles items of a list and how
+// many were left out
+func capReportList(items []string) ([]string, int) {
+	if len(items) <= maxReportFiles {
+		return items, 0
+	}
+	return items[:maxReportFiles], len(items) - maxReportFiles
+}
+
+var markdownReport = template.Must(template.New("report").Parse(`# Sync report: {{.Pair}}
+
+| | |
+|---|---|
+| Result | {{.Result}} |
+| Type | {{.Type}} |
+{{- if .Subpath}}
+| Folder | {{.Subpath}} |
+{{- end}}
+| Started | {{.Started}} |
+| Finished | {{.Finished}} |
+| Duration | {{.Duration}} |
+{{- if .Bytes}}
+| Transferred | {{.Bytes}} |
+{{- end}}
+
+## Transferred ({{len .Transferred}}{{if .MoreTransferred}} of {{.TotalTransferred}}{{end}})
+{{range .Transferred}}
+- {{.Name}}{{if .Size}} ({{.Size}}){{end}}
+{{- else}}
+Nothing was transferred.
+{{- end}}
+{{- if .MoreTransferred}}
+- ... and {{.MoreTransferred}} more
+{{- end}}
+
+## Deleted ({{len .Deleted}}{{if .MoreDeleted}} of {{.TotalDeleted}}{{end}})
+{{range .Deleted}}
+- {{.}}
+{{- else}}
+Nothing was deleted.
+{{- end}}
+{{- if .MoreDeleted}}
+- ... and {{.MoreDeleted}} more
+{{- end}}
+
+## Errors ({{len .Errors}}{{if .MoreErrors}} of {{.TotalErrors}}{{end}})
+{{range .Errors}}
+    {{.}}
+{{- else}}
+No errors.
+{{- end}}
+{{- if .MoreErrors}}
+
+... and {{.MoreErrors}} more
+{{- end}}
+{{- if .Stats}}
+
+## rclone stats
+
+` + "```" + `
+{{range .Stats}}{{.}}
+{{end}}` + "```" + `
+{{- end}}
+`))
+
+var htmlReport = htmltemplate.Must(htmltemplate.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Sync report: {{.Pair}}</title>
+<style>
+body { font-family: -apple-system, sans-serif; margin: 2em; }
+th { text-align: left; padding-right: 1em; }
+pre { background: #f4f4f4; padding: 1em; }
+.Success { color: #1a7f37; } .Failed { color: #cf222e; } .Cancelled { color: #9a6700; }
+</style>
+</head>
+<body>
+<h1>Sync report: {{.Pair}}</h1>
+<table>
+<tr><th>Result</th><td class="{{.Result}}">{{.Result}}</td></tr>
+<tr><th>Type</th><td>{{.Type}}</td></tr>
+{{- if .Subpath}}
+<tr><th>Folder</th><td>{{.Subpath}}</td></tr>
+{{- end}}
+<tr><th>Started</th><td>{{.Started}}</td></tr>
+<tr><th>Finished</th><td>{{.Finished}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+{{- if .Bytes}}
+<tr><th>Transferred</th><td>{{.Bytes}}</td></tr>
+{{- end}}
+</table>
+<h2>Transferred ({{len .Transferred}}{{if .MoreTransferred}} of {{.TotalTransferred}}{{end}})</h2>
+{{- if .Transferred}}
+<ul>
+{{- range .Transferred}}
+<li>{{.Name}}{{if .Size}} ({{.Size}}){{end}}</li>
+{{- end}}
+{{- if .MoreTransferred}}
+<li>... and {{.MoreTransferred}} more</li>
+{{- end}}
+</ul>
+{{- else}}
+<p>Nothing was transferred.</p>
+{{- end}}
+<h2>Deleted ({{len .Deleted}}{{if .MoreDeleted}} of {{.TotalDeleted}}{{end}})</h2>
+{{- if .Deleted}}
+<ul>
+{{- range .Deleted}}
+<li>{{.}}</li>
+{{- end}}
+{{- if .MoreDeleted}}
+<li>... and {{.MoreDeleted}} more</li>
+{{- end}}
+</ul>
+{{- else}}
+<p>Nothing was deleted.</p>
+{{- end}}
+<h2>Errors ({{len .Errors}}{{if .MoreErrors}} of {{.TotalErrors}}{{end}})</h2>
+{{- if .Errors}}
+<pre>
+{{- range .Errors}}
+{{.}}
+{{- end}}
+{{- if .MoreErrors}}
+... and {{.MoreErrors}} more
+{{- end}}
+</pre>
+{{- else}}
+<p>No errors.</p>
+{{- end}}
+{{- if .Stats}}
+<h2>rclone stats</h2>
+<pre>
+{{- range .Stats}}
+{{.}}
+{{- end}}
+</pre>
+{{- end}}
+</body>
+</html>
+`))
+
+// TotalTransferred returns how many files were transferred, listed or not
+func (d reportData) TotalTransferred() int { return len(d.Transferred) + d.MoreTransferred }
+
+// TotalDeleted returns how many files were deleted, listed or not
+func (d reportData) TotalDeleted() int { return len(d.Deleted) + d.MoreDeleted }
+
+// TotalErrors returns how many errors were logged, listed or not
+func (d reportData) TotalErrors() int { return len(d.Errors) + d.MoreErrors }
+
+// RenderReport writes a human-readable report of a session in format, a
+// Markdown document or an HTML page
+func RenderReport(w io.Writer, detail *SessionDetail, format string) error {
+	data := newReportData(detail)
+	if format == ReportHTML {
+		return htmlReport.Execute(w, data)
+	}
+	return markdownReport.Execute(w, data)
+}
+
+// reportDir is the directory reports are written to
+func (m *Manager) reportDir() string {
+	return filepath.Join(m.logDir, "reports")
+}
+
+// reportPair returns the pair a report file belongs to, in the form
+// PairLogName gives it, or false for other files
+func reportPair(name string) (string, bool) {
+	ext := filepath.Ext(name)
+	if _, err := time.Parse(reportTimeLayout, name[:min(len(name), len(reportTimeLayout))]); err != nil {
+		return "", false
+	}
+	for _, known := range reportExtensions {
+		if ext == known && len(name) > len(reportTimeLayout)+len(ext)+1 {
+			return strings.TrimSuffix(name[len(reportTimeLayout)+1:], ext), true
+		}
+	}
+	return "", false
+}
+
+// reportName returns the file name a pair's report is written under
+func reportName(pair string) string {
+	return strings.TrimSuffix(PairLogName(pair), ".log")
+}
+
+// Reports returns the report files of a pair, or of every pair when pair
+// is empty, oldest first
+func (m *Manager) Reports(pair string) ([]string, error) {
+	entries, err := os.ReadDir(m.reportDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+
+	var reports []string
+	for _, entry := range entries {
+		name, ok := reportPair(entry.Name())
+		if !ok || entry.IsDir() || (pair != "" && name != reportName(pair)) {
+			continue
+		}
+		reports = append(reports, filepath.Join(m.reportDir(), entry.Name()))
+	}
+	// Names start with the time, so they sort oldest first
+	sort.Slice(reports, func(i, j int) bool {
+		return filepath.Base(reports[i]) < filepath.Base(reports[j])
+	})
+	return reports, nil
+}
+
+// LatestReport returns the newest report of this manager's pair, or of any
+// pair for the main manager, or "" when none was written
+func (m *Manager) LatestReport() (string, error) {
+	reports, err := m.Reports(m.pair)
+	if err != nil || len(reports) == 0 {
+		return "", err
+	}
+	return reports[len(reports)-1], nil
+}
+
+// WriteReport writes the report of a session into the reports directory of
+// the log directory and removes the pair's oldest reports past keep. It
+// returns the report's path.
+func (m *Manager) WriteReport(detail *SessionDetail, format string, keep int) (string, error) {
+	if err := ValidateReportFormat(format); err != nil {
+		return "", err
+	}
+	if format == "" {
+		format = ReportMarkdown
+	}
+	if keep <= 0 {
+		keep = DefaultReportsKept
+	}
+	if err := os.MkdirAll(m.reportDir(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	pair := detail.Session.Pair
+	name := detail.Session.StartTime.Format(reportTimeLayout) + "_" + reportName(pair) + reportExtensions[format]
+	path := filepath.Join(m.reportDir(), name)
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create report: %w", err)
+	}
+	if err := RenderReport(file, detail, format); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+
+	reports, err := m.Reports(pair)
+	if err != nil {
+		return path, err
+	}
+	for len(reports) > keep {
+		if err := os.Remove(reports[0]); err != nil {
+			return path, fmt.Errorf("failed to remove old report: %w", err)
+		}
+		reports = reports[1:]
+	}
+	return path, nil
+}
@@ -0,0 +1,162 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+)
+
+const (
+	// MaxScrubRuns is how many scrub runs the history keeps
+	MaxScrubRuns = 100
+	// MaxScrubFiles is how many mismatched files a run keeps, as a sample
+	MaxScrubFiles = 20
+)
+
+// ScrubRun is one pair destination's integrity scrub: how its files
+// compared with the destination's
+type ScrubRun struct {
+	Pair            string    `json:"pair"`
+	Destination     string    `json:"destination"` // e.g. "b2:bucket/photos"
+	Time            time.Time `json:"time"`
+	Matched         int       `json:"matched"`
+	Differ          int       `json:"differ,omitempty"`            // Files whose contents differ
+	MissingOnDest   int       `json:"missing_on_dest,omitempty"`   // Files only in the source
+	MissingOnSource int       `json:"missing_on_source,omitempty"` // Files only in the destination
+	Errors          int       `json:"errors,omitempty"`            // Files that could not be read
+	Files           []string  `json:"files,omitempty"`             // Up to MaxScrubFiles of the mismatched files
+	SizeOnly        bool      `json:"size_only,omitempty"`         // No common hash, so only sizes were compared
+	Error           string    `json:"error,omitempty"`             // Why the check could not run
+	Acknowledged    bool      `json:"acknowledged,omitempty"`
+}
+
+// Mismatches returns how many files did not match
+func (r ScrubRun) Mismatches() int {
+	return r.Differ + r.MissingOnDest + r.MissingOnSource + r.Errors
+}
+
+// Problem reports whether the run found mismatches or could not check
+func (r ScrubRun) Problem() bool {
+	return r.Error != "" || r.Mismatches() > 0
+}
+
+// Summary describes the run's outcome, e.g. "2 differ, 1 missing at the
+// destination (of 120 files)"
+func (r ScrubRun) Summary() string {
+	if r.Error != "" {
+		return "check failed: " + r.Error
+	}
+	if r.Mismatches() == 0 {
+		return fmt.Sprintf("all %d files match", r.Matched)
+	}
+	var parts []string
+	for _, part := range []struct {
+		count int
+		text  string
+	}{
+		{r.Differ, "differ"},
+		{r.MissingOnDest, "missing at the destination"},
+		{r.MissingOnSource, "only at the destination"},
+		{r.Errors, "unreadable"},
+	} {
+		if part.count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", part.count, part.text))
+		}
+	}
+	summary := fmt.Sprintf("%s (of %d files)", strings.Join(parts, ", "), r.Matched+r.Mismatches())
+	if r.SizeOnly {
+		summary += ", sizes only"
+	}
+	return summary
+}
+
+// scrubPath is the file holding the scrub history
+func (m *Manager) scrubPath() string {
+	return filepath.Join(m.logDir, "scrub_history.json")
+}
+
+// ScrubHistory returns the recorded scrub runs, oldest first
+func (m *Manager) ScrubHistory() ([]ScrubRun, error) {
+	data, err := os.ReadFile(m.scrubPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scrub history: %w", err)
+	}
+	var runs []ScrubRun
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse scrub history: %w", jsonfile.NewCorruptError(m.scrubPath(), err))
+	}
+	return runs, nil
+}
+
+// UnacknowledgedScrubs returns the runs with problems that have not been
+// acknowledged, oldest first
+func (m *Manager) UnacknowledgedScrubs() ([]ScrubRun, error) {
+	runs, err := m.ScrubHistory()
+	if err != nil {
+		return nil, err
+	}
+	var open []ScrubRun
+	for _, run := range runs {
+		if run.Problem() && !run.Acknowledged {
+			open = append(open, run)
+		}
+	}
+	return open, nil
+}
+
+// RecordScrub adds a run to the history, dropping the oldest past
+// MaxScrubRuns. Its file sample is cut to MaxScrubFiles.
+func (m *Manager) RecordScrub(run ScrubRun) error {
+	if len(run.Files) > MaxScrubFiles {
+		run.Files = run.Files[:MaxScrubFiles]
+	}
+	return m.updateScrubs(func(runs []ScrubRun) []ScrubRun {
+		runs = append(runs, run)
+		if len(runs) > MaxScrubRuns {
+			runs = runs[len(runs)-MaxScrubRuns:]
+		}
+		return runs
+	})
+}
+
+// AcknowledgeScrubs marks every run with problems as seen, which clears the
+// dashboard's warning, and returns how many it marked
+func (m *Manager) AcknowledgeScrubs() (int, error) {
+	count := 0
+	err := m.updateScrubs(func(runs []ScrubRun) []ScrubRun {
+		for i := range runs {
+			if runs[i].Problem() && !runs[i].Acknowledged {
+				runs[i].Acknowledged = true
+				count++
+			}
+		}
+		return runs
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// updateScrubs rewrites the scrub history with update, holding its lock
+func (m *Manager) updateScrubs(update func([]ScrubRun) []ScrubRun) error {
+	unlock, err := jsonfile.Lock(m.scrubPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	runs, err := m.ScrubHistory()
+	if err != nil {
+		return err
+	}
+	return jsonfile.Write(m.scrubPath(), update(runs), 0600)
+}
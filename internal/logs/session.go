@@ -0,0 +1,231 @@
+package logs
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SessionDetail is what a log records about one sync session
+type SessionDetail struct {
+	Session   SyncSession
+	Transfers []Transfer
+	Deleted   []string // Files deleted at the destination
+	Errors    []string // ERROR lines
+	Stats     []string // rclone's last stats block, e.g. "Transferred: 2 / 2, 100%"
+	Lines     []string // The session's raw log lines
+}
+
+// statsPrefixes start the lines of the stats block rclone prints when a
+// transfer finishes
+var statsPrefixes = []string{"Transferred:", "Errors:", "Checks:", "Deleted:", "Renamed:", "Elapsed time:"}
+
+// GetSessionDetail reads the log lines of a session returned by
+// GetSyncSessions and collects its transfers, errors and stats
+func (m *Manager) GetSessionDetail(session SyncSession) (*SessionDetail, error) {
+	if session.logPath == "" {
+		return nil, fmt.Errorf("session has no log location")
+	}
+
+	detail := &SessionDetail{Session: session}
+	lineNo := -1
+	inStats := false
+
+	err := scanFile(session.logPath, func(line string) {
+		lineNo++
+		if lineNo < session.startLine || lineNo >= session.endLine {
+			return
+		}
+		detail.Lines = append(detail.Lines, line)
+
+		if isStatsLine(line) {
+			// A new block replaces the progress printed earlier
+			if !inStats {
+				detail.Stats = nil
+			}
+			detail.Stats = append(detail.Stats, strings.TrimSpace(line))
+			inStats = true
+			return
+		}
+		inStats = false
+
+		if strings.Contains(line, "ERROR") {
+			detail.Errors = append(detail.Errors, line)
+		}
+		if transfer := parseTransfer(line); transfer != nil {
+			transfer.Pair = session.Pair
+			detail.Transfers = append(detail.Transfers, *transfer)
+		}
+		if file, ok := parseDeletion(line); ok {
+			detail.Deleted = append(detail.Deleted, file)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+// isStatsLine reports whether a line belongs to an rclone stats block
+func isStatsLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range statsPrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorGroup is a set of ERROR or NOTICE lines with the same message
+// signature
+type ErrorGroup struct {
+	Level     string // ERROR or NOTICE
+	Signature string // Message with the file name and numbers masked
+	Example   string // Most recent message in full
+	Count     int
+	LastSeen  time.Time
+	Pairs     []string      // Pairs the lines came from, empty for the main log
+	Sessions  []SyncSession // Sessions the lines occurred in, oldest first
+}
+
+// LatestSession returns the most recent session the group occurred in
+func (g ErrorGroup) LatestSession() (SyncSession, bool) {
+	if len(g.Sessions) == 0 {
+		return SyncSession{}, false
+	}
+	return g.Sessions[len(g.Sessions)-1], true
+}
+
+var (
+	levelPattern  = regexp.MustCompile(`\b(ERROR|NOTICE)\s*:\s*(.*)$`)
+	numberPattern = regexp.MustCompile(`\d+`)
+)
+
+// sessionMarkers are NOTICE lines cloud-sync writes around every sync
+var sessionMarkers = []string{"Manual Sync Requested", "Manual Sync Complete", "Automated Check Started"}
+
+// GetErrorGroups collects the ERROR and NOTICE lines of every log, grouped by
+// message signature, most recently seen first
+func (m *Manager) GetErrorGroups() ([]ErrorGroup, error) {
+	var groups []*ErrorGroup
+	byKey := make(map[string]*ErrorGroup)
+
+	for _, f := range m.files() {
+		sessions, err := parseSessions(f)
+		if err != nil {
+			return nil, err
+		}
+
+		lineNo := -1
+		err = scanFile(f.path, func(line string) {
+			lineNo++
+			level, message, ok := parseLevelLine(line)
+			if !ok {
+				return
+			}
+
+			signature := errorSignature(message)
+			key := level + "\x00" + signature
+			group := byKey[key]
+			if group == nil {
+				group = &ErrorGroup{Level: level, Signature: signature}
+				byKey[key] = group
+				groups = append(groups, group)
+			}
+
+			group.Count++
+			if ts := parseTimestamp(line); !ts.Before(group.LastSeen) {
+				group.LastSeen = ts
+				group.Example = message
+			}
+			group.addPair(f.pair)
+			if session, ok := sessionAt(sessions, lineNo); ok {
+				group.addSession(session)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]ErrorGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.SliceStable(group.Sessions, func(i, j int) bool {
+			return group.Sessions[i].StartTime.Before(group.Sessions[j].StartTime)
+		})
+		result = append(result, *group)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+	return result, nil
+}
+
+// parseLevelLine returns the level and message of an ERROR or NOTICE line,
+// skipping cloud-sync's session markers
+func parseLevelLine(line string) (level, message string, ok bool) {
+	matches := levelPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return "", "", false
+	}
+	message = strings.TrimSpace(matches[2])
+	for _, marker := range sessionMarkers {
+		if strings.HasPrefix(message, marker) {
+			return "", "", false
+		}
+	}
+	return matches[1], message, true
+}
+
+// errorSignature masks the parts of a message that differ between otherwise
+// identical errors: rclone's leading "path/to/file: " and any numbers
+func errorSignature(message string) string {
+	if object, rest, found := strings.Cut(message, ": "); found && strings.Contains(rest, ": ") && looksLikePath(object) {
+		message = "…: " + rest
+	}
+	return numberPattern.ReplaceAllString(message, "N")
+}
+
+// looksLikePath reports whether the start of a message names a file rather
+// than being part of the sentence, like "Failed to copy"
+func looksLikePath(s string) bool {
+	return !strings.Contains(s, " ") || strings.Contains(s, "/") || filepath.Ext(s) != ""
+}
+
+// sessionAt returns the session a line of its log belongs to
+func sessionAt(sessions []SyncSession, lineNo int) (SyncSession, bool) {
+	for _, session := range sessions {
+		if lineNo >= session.startLine && lineNo < session.endLine {
+			return session, true
+		}
+	}
+	return SyncSession{}, false
+}
+
+// addPair records a pair the group occurred in
+func (g *ErrorGroup) addPair(pair string) {
+	if pair == "" {
+		return
+	}
+	for _, existing := range g.Pairs {
+		if existing == pair {
+			return
+		}
+	}
+	g.Pairs = append(g.Pairs, pair)
+}
+
+// addSession records a session the group occurred in
+func (g *ErrorGroup) addSession(session SyncSession) {
+	for _, existing := range g.Sessions {
+		if existing.logPath == session.logPath && existing.startLine == session.startLine {
+			return
+		}
+	}
+	g.Sessions = append(g.Sessions, session)
+}
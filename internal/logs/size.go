@@ -0,0 +1,129 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnits maps the unit suffixes rclone prints to their size in bytes.
+// Current versions print binary units ("1.234 MiB"), older ones "1.234M" or
+// "1.234 MBytes".
+var sizeUnits = map[string]float64{
+	"":       1,
+	"b":      1,
+	"bytes":  1,
+	"k":      1 << 10,
+	"kib":    1 << 10,
+	"kbytes": 1 << 10,
+	"m":      1 << 20,
+	"mib":    1 << 20,
+	"mbytes": 1 << 20,
+	"g":      1 << 30,
+	"gib":    1 << 30,
+	"gbytes": 1 << 30,
+	"t":      1 << 40,
+	"tib":    1 << 40,
+	"tbytes": 1 << 40,
+	"p":      1 << 50,
+	"pib":    1 << 50,
+	"pbytes": 1 << 50,
+}
+
+var sizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([A-Za-z]*)$`)
+
+// ParseSize parses a size as rclone prints it, such as "512 B", "1.234 MiB"
+// or "10.5k"
+func ParseSize(s string) (int64, error) {
+	matches := sizePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s': %w", s, err)
+	}
+	unit, ok := sizeUnits[strings.ToLower(matches[2])]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit '%s'", matches[2])
+	}
+	return int64(value * unit), nil
+}
+
+// transferredPattern matches the bytes line of rclone's stats block, e.g.
+// "Transferred:   	    1.234 MiB / 1.234 MiB, 100%, 0 B/s, ETA -". The
+// files line ("Transferred: 2 / 2, 100%") has no unit and does not match.
+var transferredPattern = regexp.MustCompile(`Transferred:\s+([\d.]+\s*[A-Za-z]+)\s*/`)
+
+// humanSize formats a byte count with a binary unit, e.g. "1.5 GiB"
+func humanSize(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	i := 0
+	for bytes >= 1024 && i < len(units)-1 {
+		bytes /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", bytes, units[i])
+}
+
+// parseTransferredBytes returns the bytes transferred so far from a stats
+// line, in text or JSON log format
+func parseTransferredBytes(line string) (int64, bool) {
+	if entry, ok := parseJSONLine(line); ok {
+		if entry.Stats == nil {
+			return 0, false
+		}
+		return entry.Stats.Bytes, true
+	}
+
+	matches := transferredPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return 0, false
+	}
+	size, err := ParseSize(matches[1])
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+// jsonLogEntry is a line written by rclone with --use-json-log
+type jsonLogEntry struct {
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Msg    string    `json:"msg"`
+	Object string    `json:"object"`
+	Size   int64     `json:"size"`
+	Stats  *struct {
+		Bytes int64 `json:"bytes"`
+	} `json:"stats"`
+}
+
+// parseJSONLine parses a JSON log line
+func parseJSONLine(line string) (jsonLogEntry, bool) {
+	var entry jsonLogEntry
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &entry) != nil {
+		return jsonLogEntry{}, false
+	}
+	return entry, true
+}
+
+// parseJSONTransferLine parses a copied file from a JSON log line
+func parseJSONTransferLine(line string) *Transfer {
+	entry, ok := parseJSONLine(line)
+	if !ok || entry.Level != "info" || !strings.HasPrefix(entry.Msg, "Copied") || entry.Object == "" {
+		return nil
+	}
+	return &Transfer{
+		Timestamp: entry.Time.Local(),
+		Filename:  entry.Object,
+		Size:      entry.Size,
+		Action:    "Copied",
+	}
+}
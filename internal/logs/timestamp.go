@@ -0,0 +1,49 @@
+package logs
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TimestampLayout is how cloud-sync stamps the lines it writes to a log. The
+// offset keeps a line's time right when the zone changes after it was
+// written, or the log is read on a machine in another zone.
+const TimestampLayout = "2006/01/02 15:04:05 -0700"
+
+// timestampPattern matches the timestamps found in logs: rclone's text
+// format, 2006/01/02 15:04:05 with optional microseconds, cloud-sync's,
+// which adds the offset, and RFC 3339 as in rclone's JSON logs
+var timestampPattern = regexp.MustCompile(`(\d{4})[/-](\d{2})[/-](\d{2})[ T](\d{2}:\d{2}:\d{2})(\.\d+)?(?: ?(Z|[+-]\d{2}:?\d{2})\b)?`)
+
+// parseTimestamp returns the first timestamp of a log line, or the zero time
+// if it has none. A timestamp without an offset was written in local time,
+// as rclone does. The result is in local time, so days and months group
+// the way the user sees them whatever zone the line was written in.
+func parseTimestamp(line string) time.Time {
+	m := timestampPattern.FindStringSubmatch(line)
+	if m == nil {
+		return time.Time{}
+	}
+
+	value := m[1] + "-" + m[2] + "-" + m[3] + "T" + m[4] + m[5]
+	switch zone := m[6]; {
+	case zone == "":
+		t, err := time.ParseInLocation("2006-01-02T15:04:05", value, time.Local)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	case zone == "Z":
+		value += "Z"
+	case !strings.Contains(zone, ":"):
+		value += zone[:3] + ":" + zone[3:]
+	default:
+		value += zone
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.Local()
+}
@@ -0,0 +1,206 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+)
+
+// DefaultProbeURL answers "Success" on an open connection; a captive portal
+// answers with its sign-in page instead
+const DefaultProbeURL = "http://captive.apple.com/hotspot-detect.html"
+
+// hotspotGateway is the router address of an iPhone's Personal Hotspot
+const hotspotGateway = "172.20.10.1"
+
+// Reasons a network does not allow a sync
+var (
+	ErrOffline       = errors.New("there is no network connection")
+	ErrCaptivePortal = errors.New("the network needs a sign-in first")
+	ErrHotspot       = errors.New("the Mac is on a personal hotspot")
+)
+
+// Status describes the network the Mac uses to reach the internet
+type Status struct {
+	Known         bool // False when the network could not be inspected, e.g. not on macOS
+	Online        bool
+	CaptivePortal bool   // Connected, but a sign-in page intercepts requests
+	Interface     string // e.g. en0
+	Port          string // Hardware port of the interface, e.g. Wi-Fi or iPhone USB
+	Gateway       string
+	SSID          string // Name of the Wi-Fi network, empty when not on Wi-Fi
+	Hotspot       bool   // On a phone's personal hotspot, which likely uses mobile data
+}
+
+// Rules limit the networks a pair may sync on
+type Rules struct {
+	WiFiNetworks  []string // Only sync on one of these Wi-Fi networks
+	SkipOnHotspot bool
+}
+
+// Allows returns nil if a sync may run on this network, and otherwise why
+// not. An unknown network allows everything, so the check never stops a
+// sync on a Mac it cannot inspect.
+func (s Status) Allows(rules Rules) error {
+	if !s.Known {
+		return nil
+	}
+	if !s.Online {
+		return ErrOffline
+	}
+	if s.CaptivePortal {
+		return ErrCaptivePortal
+	}
+	if rules.SkipOnHotspot && s.Hotspot {
+		return ErrHotspot
+	}
+	if len(rules.WiFiNetworks) > 0 {
+		for _, ssid := range rules.WiFiNetworks {
+			if ssid == s.SSID {
+				return nil
+			}
+		}
+		return fmt.Errorf("the Mac is not on Wi-Fi network %s", strings.Join(rules.WiFiNetworks, " or "))
+	}
+	return nil
+}
+
+// Checker inspects the current network with macOS's route, networksetup and
+// ipconfig, and an HTTP request for captive portals
+type Checker struct {
+	RoutePath        string
+	NetworksetupPath string
+	IpconfigPath     string
+	ProbeURL         string
+	Timeout          time.Duration // Of the captive portal probe
+}
+
+// NewChecker creates a checker using the system tools
+func NewChecker() *Checker {
+	return &Checker{
+		RoutePath:        "/sbin/route",
+		NetworksetupPath: "/usr/sbin/networksetup",
+		IpconfigPath:     "/usr/sbin/ipconfig",
+		ProbeURL:         DefaultProbeURL,
+		Timeout:          5 * time.Second,
+	}
+}
+
+// Status inspects the network. Simulated runs do not, and report an
+// unknown network.
+func (c *Checker) Status() Status {
+	if simulate.Enabled() {
+		return Status{}
+	}
+
+	output, err := simulate.Command(c.RoutePath, "-n", "get", "default").CombinedOutput()
+	if strings.Contains(string(output), "not in table") {
+		// macOS has no default route while disconnected
+		return Status{Known: true}
+	}
+	route := fields(output)
+	if err != nil || route["interface"] == "" {
+		return Status{}
+	}
+
+	status := Status{
+		Known:     true,
+		Interface: route["interface"],
+		Gateway:   route["gateway"],
+		Port:      c.hardwarePorts()[route["interface"]],
+	}
+	if status.Port == "Wi-Fi" {
+		status.SSID = c.ssid(status.Interface)
+	}
+	status.Hotspot = status.Gateway == hotspotGateway ||
+		strings.Contains(status.Port, "iPhone") ||
+		status.Port == "Bluetooth PAN" ||
+		c.metered(status.Interface)
+
+	status.Online, status.CaptivePortal = c.probe()
+	return status
+}
+
+// hardwarePorts maps interfaces to their hardware port names, e.g. en0 to
+// Wi-Fi
+func (c *Checker) hardwarePorts() map[string]string {
+	output, err := simulate.Command(c.NetworksetupPath, "-listallhardwareports").Output()
+	if err != nil {
+		return nil
+	}
+	ports := map[string]string{}
+	port := ""
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "Hardware Port: "); ok {
+			port = name
+		} else if device, ok := strings.CutPrefix(line, "Device: "); ok {
+			ports[device] = port
+		}
+	}
+	return ports
+}
+
+// ssid returns the name of the Wi-Fi network the interface is on. Newer
+// macOS versions no longer tell networksetup, so ipconfig is asked as well.
+func (c *Checker) ssid(iface string) string {
+	output, err := simulate.Command(c.NetworksetupPath, "-getairportnetwork", iface).Output()
+	if err == nil {
+		if ssid, ok := strings.CutPrefix(strings.TrimSpace(string(output)), "Current Wi-Fi Network: "); ok {
+			return ssid
+		}
+	}
+	output, err = simulate.Command(c.IpconfigPath, "getsummary", iface).Output()
+	if err != nil {
+		return ""
+	}
+	return fields(output)["SSID"]
+}
+
+// metered reports whether the DHCP server marked the connection as metered,
+// as Android hotspots do
+func (c *Checker) metered(iface string) bool {
+	output, err := simulate.Command(c.IpconfigPath, "getpacket", iface).Output()
+	return err == nil && bytes.Contains(output, []byte("ANDROID_METERED"))
+}
+
+// probe requests ProbeURL, reporting whether it could be reached and
+// whether a captive portal answered in its place
+func (c *Checker) probe() (online bool, captive bool) {
+	client := &http.Client{
+		Timeout: c.Timeout,
+		// A portal often redirects to its sign-in page
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Get(c.ProbeURL)
+	if err != nil {
+		return false, false
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return false, false
+	}
+	return true, resp.StatusCode != http.StatusOK || !bytes.Contains(body, []byte("Success"))
+}
+
+// fields parses "key: value" lines, as route and ipconfig print them
+func fields(output []byte) map[string]string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,220 @@
+package orphans
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// Orphan is a file or folder in a bucket the pairs sync into that no pair
+// syncs to, e.g. what is left of a pair that was deleted
+type Orphan struct {
+	RemoteName string
+	Path       string // Path on the remote, relative to the remote root
+	IsDir      bool
+	Size       int64
+}
+
+// String formats the orphan as remote:path
+func (o Orphan) String() string {
+	return o.RemoteName + ":" + o.Path
+}
+
+// Report lists the orphans found in the scanned buckets
+type Report struct {
+	Buckets []string // remote:bucket of each bucket scanned
+	Skipped []string // remote:path of each destination on a local remote
+	Orphans []Orphan // Largest first
+}
+
+// Size returns the bytes the orphans take up
+func (r *Report) Size() int64 {
+	var total int64
+	for _, orphan := range r.Orphans {
+		total += orphan.Size
+	}
+	return total
+}
+
+// mapping is what the pairs sync to on one remote
+type mapping struct {
+	paths []string        // Destinations, cleaned
+	trash map[string]bool // Trash folders of the pairs, by path
+}
+
+// covers reports whether p is a destination or lies inside one
+func (m *mapping) covers(p string) bool {
+	for _, dest := range m.paths {
+		if dest == "" || p == dest || strings.HasPrefix(p, dest+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// leadsTo reports whether a destination lies inside folder p
+func (m *mapping) leadsTo(p string) bool {
+	for _, dest := range m.paths {
+		if strings.HasPrefix(dest, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan lists the buckets the pairs sync into, the first folder of each
+// destination on remotes without buckets, and reports what in them no pair
+// syncs to. Trash folders of pairs that still exist and the destinations
+// monitor pairs check are not orphans; the trash of a deleted pair is. A
+// destination with variables, such as {hostname}, covers the folder before
+// the first variable. Destinations on local remotes are folders on this
+// Mac, e.g. /Volumes/NAS/Docs, whose first folder is not a bucket but
+// /Volumes, so they are skipped. Folders are listed through cache, which
+// may be nil to list them all.
+func Scan(mgr *rclone.Manager, cache *rclone.ListingCache, pairs []syncconfig.SyncPair) (*Report, error) {
+	report := &Report{}
+	mappings := make(map[string]*mapping)
+	add := func(pair syncconfig.SyncPair, dest syncconfig.Destination) {
+		if remoteType, _ := mgr.GetRemoteType(dest.RemoteName); remoteType == "local" {
+			report.Skipped = append(report.Skipped, dest.RemoteName+":"+dest.RemotePath)
+			return
+		}
+		m, ok := mappings[dest.RemoteName]
+		if !ok {
+			m = &mapping{trash: make(map[string]bool)}
+			mappings[dest.RemoteName] = m
+		}
+		// Other machines and days expand the variables to other folders
+		m.paths = append(m.paths, strings.Trim(path.Clean("/"+syncconfig.StaticPrefix(dest.RemotePath)), "/"))
+		m.trash[mgr.TrashRootFor(dest.RemoteName, dest.RemotePath, pair.Name)] = true
+	}
+	for _, pair := range pairs {
+		for _, dest := range pair.AllDestinations() {
+			add(pair, dest)
+		}
+		if pair.IsMonitor() && pair.TargetPath == "" && pair.RemoteName != "" {
+			add(pair, syncconfig.Destination{RemoteName: pair.RemoteName, RemotePath: pair.RemotePath})
+		}
+	}
+
+	remotes := make([]string, 0, len(mappings))
+	for name := range mappings {
+		remotes = append(remotes, name)
+	}
+	sort.Strings(remotes)
+
+	for _, name := range remotes {
+		m := mappings[name]
+		buckets := make(map[string]bool)
+		for _, dest := range m.paths {
+			bucket, _, _ := strings.Cut(dest, "/")
+			buckets[bucket] = true
+		}
+		sorted := make([]string, 0, len(buckets))
+		for bucket := range buckets {
+			sorted = append(sorted, bucket)
+		}
+		sort.Strings(sorted)
+
+		for _, bucket := range sorted {
+			report.Buckets = append(report.Buckets, name+":"+bucket)
+			found, err := scanBucket(mgr, cache, name, bucket, m)
+			if err != nil {
+				return nil, err
+			}
+			report.Orphans = append(report.Orphans, found...)
+		}
+	}
+
+	sort.SliceStable(report.Orphans, func(i, j int) bool {
+		return report.Orphans[i].Size > report.Orphans[j].Size
+	})
+	return report, nil
+}
+
+// scanBucket walks a bucket down to the destinations in it and returns
+// what lies beside them
+func scanBucket(mgr *rclone.Manager, cache *rclone.ListingCache, remoteName, bucket string, m *mapping) ([]Orphan, error) {
+	var found []Orphan
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, _, err := mgr.CachedListDir(cache, remoteName+":"+dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			p := path.Join(dir, entry.Name)
+			switch {
+			case dir == bucket && entry.Name == rclone.TrashDirName && entry.IsDir:
+				trashed, err := scanTrash(mgr, cache, remoteName, p, m)
+				if err != nil {
+					return err
+				}
+				found = append(found, trashed...)
+			case m.covers(p):
+			case entry.IsDir && m.leadsTo(p):
+				if err := walk(p); err != nil {
+					return err
+				}
+			default:
+				orphan, err := newOrphan(mgr, remoteName, p, entry)
+				if err != nil {
+					return err
+				}
+				found = append(found, orphan)
+			}
+		}
+		return nil
+	}
+	return found, walk(bucket)
+}
+
+// scanTrash returns the trash folders of pairs that no longer exist
+func scanTrash(mgr *rclone.Manager, cache *rclone.ListingCache, remoteName, trashDir string, m *mapping) ([]Orphan, error) {
+	entries, _, err := mgr.CachedListDir(cache, remoteName+":"+trashDir)
+	if err != nil {
+		return nil, err
+	}
+	var found []Orphan
+	for _, entry := range entries {
+		p := path.Join(trashDir, entry.Name)
+		if m.trash[p] {
+			continue
+		}
+		orphan, err := newOrphan(mgr, remoteName, p, entry)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, orphan)
+	}
+	return found, nil
+}
+
+// newOrphan sizes a listed file or folder
+func newOrphan(mgr *rclone.Manager, remoteName, p string, entry rclone.DirEntry) (Orphan, error) {
+	orphan := Orphan{RemoteName: remoteName, Path: p, IsDir: entry.IsDir, Size: entry.Size}
+	if entry.IsDir {
+		size, err := mgr.Size(orphan.String())
+		if err != nil {
+			return Orphan{}, err
+		}
+		orphan.Size = size
+	}
+	return orphan, nil
+}
+
+// Remove permanently deletes an orphan from its remote, and drops the
+// cached listing of the folder it was in
+func Remove(mgr *rclone.Manager, cache *rclone.ListingCache, orphan Orphan) error {
+	var err error
+	if orphan.IsDir {
+		err = mgr.Purge(orphan.String())
+	} else {
+		err = mgr.DeleteFile(orphan.String())
+	}
+	cache.Forget(orphan.RemoteName + ":" + path.Dir(orphan.Path))
+	return err
+}
@@ -0,0 +1,64 @@
+package power
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+)
+
+// chargePattern finds the charge in pmset's battery line, e.g.
+// " -InternalBattery-0 (id=1234)	42%; discharging; 3:01 remaining"
+var chargePattern = regexp.MustCompile(`\t(\d+)%;`)
+
+// Status describes the Mac's power source
+type Status struct {
+	Known     bool // False when pmset could not be read, e.g. not on macOS
+	OnBattery bool
+	Percent   int // Battery charge, -1 without a battery
+}
+
+// Defers reports whether a pair needing min percent charge has to wait:
+// the Mac runs on battery below min. An unknown power source defers nothing.
+func (s Status) Defers(min int) bool {
+	return s.Known && min > 0 && s.OnBattery && s.Percent >= 0 && s.Percent < min
+}
+
+// Checker reads the power source with pmset
+type Checker struct {
+	PmsetPath string
+}
+
+// NewChecker creates a checker using the system's pmset
+func NewChecker() *Checker {
+	return &Checker{PmsetPath: "/usr/bin/pmset"}
+}
+
+// Status reads the power source. Simulated runs report an unknown one.
+func (c *Checker) Status() Status {
+	if simulate.Enabled() {
+		return Status{}
+	}
+	output, err := simulate.Command(c.PmsetPath, "-g", "batt").Output()
+	if err != nil {
+		return Status{}
+	}
+	return ParseBatt(string(output))
+}
+
+// ParseBatt parses the output of 'pmset -g batt'
+func ParseBatt(output string) Status {
+	if !strings.Contains(output, "Now drawing from") {
+		return Status{}
+	}
+	status := Status{
+		Known:     true,
+		OnBattery: strings.Contains(output, "'Battery Power'"),
+		Percent:   -1,
+	}
+	if match := chargePattern.FindStringSubmatch(output); match != nil {
+		status.Percent, _ = strconv.Atoi(match[1])
+	}
+	return status
+}
@@ -0,0 +1,309 @@
+// Package queue orders the syncs asked for by every trigger — syncs started
+// by hand, scheduled runs and the drive and power agents — so one process
+// runs them one at a time, the most urgent first. The queue is a file in
+// the log directory, shared by every cloud-sync process.
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+)
+
+// FileName is the name of the queue in the log directory
+const FileName = "sync_queue.json"
+
+// Priority decides which waiting job runs first
+type Priority int
+
+const (
+	// Low is for scheduled runs, which can wait
+	Low Priority = iota
+	// Normal is for the drive and power agents
+	Normal
+	// High is for syncs started by hand, which someone is waiting for
+	High
+)
+
+// String implements fmt.Stringer
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case High:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// Triggers, which name what queued a job
+const (
+	TriggerManual    = "manual"
+	TriggerScheduled = "scheduled"
+	TriggerMount     = "mount"
+	TriggerPower     = "power"
+)
+
+// ErrNotFound reports a job that is no longer in the queue, usually
+// because it has finished
+var ErrNotFound = errors.New("job is no longer queued")
+
+// Job is a sync of one pair waiting in the queue or running
+type Job struct {
+	ID           int       `json:"id"`
+	Pair         string    `json:"pair"`
+	Subpath      string    `json:"subpath,omitempty"` // Only sync this folder of the pair
+	Priority     Priority  `json:"priority"`
+	Trigger      string    `json:"trigger"`
+	AllowDeletes bool      `json:"allow_deletes,omitempty"`
+	Scheduled    bool      `json:"scheduled,omitempty"` // Deferred on low battery
+	Queued       time.Time `json:"queued"`
+	Started      time.Time `json:"started,omitzero"`
+	PID          int       `json:"pid,omitempty"`        // Process running the job, 0 while waiting
+	Cancelling   bool      `json:"cancelling,omitempty"` // Asked to stop while running
+}
+
+// Running reports whether a process has claimed the job
+func (j Job) Running() bool {
+	return j.PID != 0
+}
+
+// Target describes what the job syncs, e.g. "2024 of Photos"
+func (j Job) Target() string {
+	if j.Subpath != "" {
+		return fmt.Sprintf("%s of %s", j.Subpath, j.Pair)
+	}
+	return j.Pair
+}
+
+// file is the queue as saved
+type file struct {
+	NextID int   `json:"next_id"`
+	Jobs   []Job `json:"jobs"` // Running jobs first, then the waiting ones in the order they run
+}
+
+// Queue is the sync queue of a log directory
+type Queue struct {
+	path string
+}
+
+// New returns the queue kept in logDir
+func New(logDir string) *Queue {
+	return &Queue{path: filepath.Join(logDir, FileName)}
+}
+
+// Path returns the queue file's path
+func (q *Queue) Path() string {
+	return q.path
+}
+
+// Jobs returns the running job, if any, and the waiting jobs in the order
+// they will run
+func (q *Queue) Jobs() ([]Job, error) {
+	f, err := q.read()
+	if err != nil {
+		return nil, err
+	}
+	return live(f.Jobs), nil
+}
+
+// Add queues a job behind the waiting jobs of its priority or higher and
+// returns it. A job for a pair and folder already waiting is not queued
+// twice: the waiting job is returned, moved up if the new one is more
+// urgent, and added is false.
+func (q *Queue) Add(job Job) (queued Job, added bool, err error) {
+	err = q.update(func(f *file) error {
+		for i, waiting := range f.Jobs {
+			if waiting.Running() || waiting.Pair != job.Pair || waiting.Subpath != job.Subpath {
+				continue
+			}
+			if job.Priority > waiting.Priority {
+				f.Jobs = append(f.Jobs[:i], f.Jobs[i+1:]...)
+				waiting.Priority = job.Priority
+				f.Jobs = insert(f.Jobs, waiting)
+			}
+			queued = waiting
+			return nil
+		}
+
+		f.NextID++
+		job.ID = f.NextID
+		job.Queued = time.Now()
+		job.PID, job.Started, job.Cancelling = 0, time.Time{}, false
+		f.Jobs = insert(f.Jobs, job)
+		queued, added = job, true
+		return nil
+	})
+	return queued, added, err
+}
+
+// insert puts a waiting job after the running jobs and the waiting jobs of
+// its priority or higher
+func insert(jobs []Job, job Job) []Job {
+	at := len(jobs)
+	for i, other := range jobs {
+		if !other.Running() && other.Priority < job.Priority {
+			at = i
+			break
+		}
+	}
+	return append(jobs[:at], append([]Job{job}, jobs[at:]...)...)
+}
+
+// Claim hands the first waiting job to the process pid to run. It returns
+// false when nothing is waiting or another live process is running a job,
+// since that process runs the waiting jobs after its own.
+func (q *Queue) Claim(pid int) (claimed Job, ok bool, err error) {
+	err = q.update(func(f *file) error {
+		for i := range f.Jobs {
+			if f.Jobs[i].Running() {
+				if f.Jobs[i].PID != pid {
+					return nil
+				}
+				continue
+			}
+			f.Jobs[i].PID = pid
+			f.Jobs[i].Started = time.Now()
+			claimed, ok = f.Jobs[i], true
+			return nil
+		}
+		return nil
+	})
+	return claimed, ok, err
+}
+
+// Finish removes a job that has run
+func (q *Queue) Finish(id int) error {
+	return q.update(func(f *file) error {
+		i := indexOf(f.Jobs, id)
+		if i < 0 {
+			return nil
+		}
+		f.Jobs = append(f.Jobs[:i], f.Jobs[i+1:]...)
+		return nil
+	})
+}
+
+// Cancel removes a waiting job. A running job is asked to stop instead;
+// the process running it notices with Cancelling.
+func (q *Queue) Cancel(id int) error {
+	return q.update(func(f *file) error {
+		i := indexOf(f.Jobs, id)
+		if i < 0 {
+			return ErrNotFound
+		}
+		if f.Jobs[i].Running() {
+			f.Jobs[i].Cancelling = true
+			return nil
+		}
+		f.Jobs = append(f.Jobs[:i], f.Jobs[i+1:]...)
+		return nil
+	})
+}
+
+// Cancelling reports whether the running job id was asked to stop
+func (q *Queue) Cancelling(id int) (bool, error) {
+	f, err := q.read()
+	if err != nil {
+		return false, err
+	}
+	i := indexOf(f.Jobs, id)
+	return i >= 0 && f.Jobs[i].Cancelling, nil
+}
+
+// Move moves a waiting job up (delta < 0) or down among the waiting jobs,
+// whatever their priority. It cannot pass the running job.
+func (q *Queue) Move(id, delta int) error {
+	return q.update(func(f *file) error {
+		i := indexOf(f.Jobs, id)
+		if i < 0 {
+			return ErrNotFound
+		}
+		if f.Jobs[i].Running() {
+			return fmt.Errorf("'%s' is already running", f.Jobs[i].Target())
+		}
+		to := i + delta
+		for to >= 0 && to < len(f.Jobs) && f.Jobs[to].Running() {
+			to++
+		}
+		if to < 0 || to >= len(f.Jobs) {
+			return nil
+		}
+		job := f.Jobs[i]
+		f.Jobs = append(f.Jobs[:i], f.Jobs[i+1:]...)
+		f.Jobs = append(f.Jobs[:to], append([]Job{job}, f.Jobs[to:]...)...)
+		return nil
+	})
+}
+
+// indexOf returns the position of job id, or -1
+func indexOf(jobs []Job, id int) int {
+	for i, job := range jobs {
+		if job.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// live drops the running jobs whose process has exited without finishing
+// them, e.g. because it was killed
+func live(jobs []Job) []Job {
+	kept := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Running() && !alive(job.PID) {
+			continue
+		}
+		kept = append(kept, job)
+	}
+	return kept
+}
+
+// alive reports whether process pid exists
+func alive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// read loads the queue; a missing file is an empty queue
+func (q *Queue) read() (file, error) {
+	var f file
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return f, fmt.Errorf("failed to read sync queue: %w", err)
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("failed to parse sync queue: %w", jsonfile.NewCorruptError(q.path, err))
+	}
+	return f, nil
+}
+
+// update rewrites the queue with fn, holding its lock. Jobs whose process
+// died are dropped first.
+func (q *Queue) update(fn func(*file) error) error {
+	unlock, err := jsonfile.Lock(q.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := q.read()
+	if err != nil {
+		return err
+	}
+	f.Jobs = live(f.Jobs)
+	if err := fn(&f); err != nil {
+		return err
+	}
+	return jsonfile.Write(q.path, f, 0600)
+}
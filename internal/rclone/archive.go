@@ -0,0 +1,113 @@
+package rclone
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveExtension is the extension of the archives archive-mode pairs upload
+const ArchiveExtension = ".tar.zst"
+
+// archiveLayout is the layout of the dated archive names
+const archiveLayout = "2006-01-02-150405"
+
+// Archive represents one dated archive of an archive-mode pair
+type Archive struct {
+	Date time.Time
+	Path string // Path on the remote, relative to the remote root
+	Size int64
+}
+
+// ArchiveName returns the file name of an archive created at t
+func ArchiveName(t time.Time) string {
+	return t.Format(archiveLayout) + ArchiveExtension
+}
+
+// ListArchives lists the archives directly under remotePath, newest first.
+// A missing remotePath is treated as empty.
+func (m *Manager) ListArchives(remoteName, remotePath string) ([]Archive, error) {
+	cmd := m.command("lsjson", remoteName+":"+remotePath, "--files-only", "--config", m.configPath)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 3 {
+			return []Archive{}, nil
+		}
+		return nil, fmt.Errorf("failed to list archives: %w", ClassifyError(err, nil))
+	}
+
+	var items []struct {
+		Name string `json:"Name"`
+		Size int64  `json:"Size"`
+	}
+	if err := json.Unmarshal(output, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse listing: %w", err)
+	}
+
+	archives := make([]Archive, 0, len(items))
+	for _, item := range items {
+		stamp, ok := strings.CutSuffix(item.Name, ArchiveExtension)
+		if !ok {
+			continue
+		}
+		date, err := time.ParseInLocation(archiveLayout, stamp, time.Local)
+		if err != nil {
+			continue // Not one of our archives
+		}
+		archives = append(archives, Archive{Date: date, Path: path.Join(remotePath, item.Name), Size: item.Size})
+	}
+
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].Date.After(archives[j].Date)
+	})
+
+	return archives, nil
+}
+
+// UploadArchive copies a local archive into remotePath under its own name.
+// The options' filters and command are not used: the archive is one file
+// and is always copied.
+func (m *Manager) UploadArchive(archivePath, remoteName, remotePath string, opts SyncOptions) error {
+	if _, err := os.Stat(archivePath); err != nil {
+		return fmt.Errorf("archive does not exist: %w", err)
+	}
+
+	opts.Excludes, opts.FilterFrom = nil, ""
+	dest := remoteName + ":" + path.Join(remotePath, filepath.Base(archivePath))
+	return m.runTransfer("copyto", m.buildTransferArgs("copyto", archivePath, dest, opts))
+}
+
+// PruneArchives deletes the archives under remotePath beyond the newest
+// keep and returns how many were removed. A zero keep keeps them all.
+func (m *Manager) PruneArchives(remoteName, remotePath string, keep int) (int, error) {
+	archives, err := m.ListArchives(remoteName, remotePath)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, archive := range ArchivesToPrune(archives, keep) {
+		output, err := m.command("deletefile", remoteName+":"+archive.Path, "--config", m.configPath).CombinedOutput()
+		if err != nil {
+			return pruned, fmt.Errorf("failed to delete archive %s: %w (output: %s)", archive.Path, ClassifyError(err, output), string(output))
+		}
+		pruned++
+	}
+
+	return pruned, nil
+}
+
+// ArchivesToPrune returns the archives, sorted newest first, beyond the
+// newest keep. A zero keep disables the limit.
+func ArchivesToPrune(archives []Archive, keep int) []Archive {
+	if keep <= 0 || len(archives) <= keep {
+		return []Archive{}
+	}
+	return archives[keep:]
+}
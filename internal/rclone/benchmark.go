@@ -0,0 +1,105 @@
+package rclone
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BenchmarkSize is the size of the test object Benchmark moves: big enough
+// to show throughput, small enough to cost nothing on metered storage
+const BenchmarkSize = 4 << 20
+
+// benchmarkPrefix starts the name of the test object, so one left behind
+// by an interrupted benchmark is recognizable
+const benchmarkPrefix = ".cloud-sync-benchmark-"
+
+// BenchmarkResult is how fast a remote answered and moved a test object.
+// The times include starting rclone, as every backup does.
+type BenchmarkResult struct {
+	Size     int64
+	Latency  time.Duration // Of a stat call on the test object
+	Upload   time.Duration
+	Download time.Duration
+}
+
+// UploadRate returns the upload throughput in bytes per second
+func (r BenchmarkResult) UploadRate() float64 {
+	return rate(r.Size, r.Upload)
+}
+
+// DownloadRate returns the download throughput in bytes per second
+func (r BenchmarkResult) DownloadRate() float64 {
+	return rate(r.Size, r.Download)
+}
+
+// rate returns size bytes per d in bytes per second
+func rate(size int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(size) / d.Seconds()
+}
+
+// Benchmark uploads a test object of size random bytes into dir, e.g.
+// "b2:bucket", stats it and downloads it again, timing each step. The
+// object is deleted afterwards, also when a step fails.
+func (m *Manager) Benchmark(dir string, size int64) (*BenchmarkResult, error) {
+	tmp, err := os.MkdirTemp("", "cloud-sync-benchmark")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create benchmark directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	local := filepath.Join(tmp, "upload")
+	if err := writeRandom(local, size); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s%d", benchmarkPrefix, time.Now().UnixNano())
+	object := dir + name
+	if !strings.HasSuffix(dir, ":") {
+		object = strings.TrimSuffix(dir, "/") + "/" + name
+	}
+
+	result := &BenchmarkResult{Size: size}
+	if result.Upload, err = m.timed("upload", "copyto", local, object); err != nil {
+		return nil, err
+	}
+	defer m.command("deletefile", object, "--config", m.configPath).Run()
+
+	if result.Latency, err = m.timed("stat", "lsjson", "--stat", object); err != nil {
+		return nil, err
+	}
+	if result.Download, err = m.timed("download", "copyto", object, filepath.Join(tmp, "download")); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// timed runs an rclone command and returns how long it took
+func (m *Manager) timed(step string, args ...string) (time.Duration, error) {
+	start := time.Now()
+	output, err := m.command(append(args, "--config", m.configPath)...).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("benchmark %s failed: %w", step, ClassifyError(err, output))
+	}
+	return time.Since(start), nil
+}
+
+// writeRandom writes size random bytes to path, which compression or
+// deduplication on the way cannot shrink
+func writeRandom(path string, size int64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create benchmark file: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.CopyN(f, rand.Reader, size); err != nil {
+		return fmt.Errorf("failed to write benchmark file: %w", err)
+	}
+	return nil
+}
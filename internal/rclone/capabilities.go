@@ -0,0 +1,114 @@
+package rclone
+
+import (
+	"os"
+	"strings"
+)
+
+// Capabilities describes what a storage backend supports, as listed in
+// rclone's overview of remotes
+type Capabilities struct {
+	ServerSideCopy bool   // Copies and moves within the remote without downloading
+	ModTime        bool   // Keeps modification times, so changes are found without reading files
+	Hash           string // Checksum the remote stores, e.g. "md5", empty if none
+	Versioning     bool   // Can keep old versions of overwritten and deleted files
+	FastList       bool   // Lists a whole tree in few calls, so --fast-list saves requests
+	Buckets        bool   // The first element of a path is a bucket
+}
+
+// providerCapabilities are the capabilities of the backends cloud-sync is
+// used with, by rclone remote type. Unknown types get none.
+var providerCapabilities = map[string]Capabilities{
+	"local":     {ModTime: true, Hash: "md5"},
+	"s3":        {ServerSideCopy: true, ModTime: true, Hash: "md5", Versioning: true, FastList: true, Buckets: true},
+	"b2":        {ServerSideCopy: true, ModTime: true, Hash: "sha1", Versioning: true, FastList: true, Buckets: true},
+	"gcs":       {ServerSideCopy: true, ModTime: true, Hash: "md5", Versioning: true, FastList: true, Buckets: true},
+	"azureblob": {ServerSideCopy: true, ModTime: true, Hash: "md5", Versioning: true, FastList: true, Buckets: true},
+	"swift":     {ServerSideCopy: true, ModTime: true, Hash: "md5", FastList: true, Buckets: true},
+	"oos":       {ServerSideCopy: true, ModTime: true, Hash: "md5", Versioning: true, FastList: true, Buckets: true},
+	"qingstor":  {ServerSideCopy: true, Hash: "md5", FastList: true, Buckets: true},
+	"drive":     {ServerSideCopy: true, ModTime: true, Hash: "md5", FastList: true},
+	"dropbox":   {ServerSideCopy: true, ModTime: true, Hash: "dropbox"},
+	"onedrive":  {ServerSideCopy: true, ModTime: true, Hash: "quickxor"},
+	"box":       {ServerSideCopy: true, ModTime: true, Hash: "sha1"},
+	"pcloud":    {ServerSideCopy: true, ModTime: true, Hash: "sha1"},
+	"sftp":      {ModTime: true, Hash: "md5"},
+	"webdav":    {ServerSideCopy: true},
+	"ftp":       {},
+}
+
+// CapabilitiesFor returns the capabilities of a remote type
+func CapabilitiesFor(remoteType string) Capabilities {
+	return providerCapabilities[remoteType]
+}
+
+// IsBucketBased reports whether a remote type keeps files in buckets, so the
+// first element of a path must be an existing bucket
+func IsBucketBased(remoteType string) bool {
+	return CapabilitiesFor(remoteType).Buckets
+}
+
+// DefaultFlags returns the flags that suit a transfer between source and
+// dest: --fast-list where a side can list a tree at once, and --checksum
+// where a side does not keep modification times but both store the same
+// checksum. Local paths count as type "local".
+func (m *Manager) DefaultFlags(source, dest string) []string {
+	src, dst := m.endpointCapabilities(source), m.endpointCapabilities(dest)
+
+	var flags []string
+	if src.FastList || dst.FastList {
+		flags = append(flags, "--fast-list")
+	}
+	if (!src.ModTime || !dst.ModTime) && sharedHash(source, src, dest, dst) {
+		flags = append(flags, "--checksum")
+	}
+	return flags
+}
+
+// sharedHash reports whether both sides of a transfer store a checksum rclone
+// can compare. Local files can be hashed with any algorithm.
+func sharedHash(source string, src Capabilities, dest string, dst Capabilities) bool {
+	if src.Hash == "" || dst.Hash == "" {
+		return false
+	}
+	_, srcRemote := remoteOf(source)
+	_, dstRemote := remoteOf(dest)
+	return !srcRemote || !dstRemote || src.Hash == dst.Hash
+}
+
+// endpointCapabilities returns the capabilities of the remote a transfer
+// path is on, or for an alias those of the remote it stands for
+func (m *Manager) endpointCapabilities(path string) Capabilities {
+	name, ok := remoteOf(path)
+	if !ok {
+		return CapabilitiesFor("local")
+	}
+	return CapabilitiesFor(m.backendType(name))
+}
+
+// remoteOf returns the remote name of a "remote:path" argument, and false
+// for a local path
+func remoteOf(path string) (string, bool) {
+	name, _, found := strings.Cut(path, ":")
+	if !found || name == "" || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	return name, true
+}
+
+// remoteType returns the type of a remote from the config file, or from the
+// RCLONE_CONFIG_<NAME>_TYPE variable for remotes defined in the environment.
+// It returns an empty string when the type is unknown.
+func (m *Manager) remoteType(name string) string {
+	if remoteType, err := m.GetRemoteType(name); err == nil {
+		return remoteType
+	}
+
+	key := "RCLONE_CONFIG_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name)) + "_TYPE="
+	for _, env := range m.env {
+		if strings.HasPrefix(env, key) {
+			return strings.TrimPrefix(env, key)
+		}
+	}
+	return os.Getenv(strings.TrimSuffix(key, "="))
+}
@@ -0,0 +1,90 @@
+package rclone
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// noCommonHash is what rclone logs when the two sides share no hash type,
+// so files can only be compared by size
+const noCommonHash = "No common hash found"
+
+// CheckResult is what 'rclone check' found comparing a source with its
+// destination. The file lists hold paths relative to the source.
+type CheckResult struct {
+	Matched         int      // Files identical on both sides
+	Differ          []string // Files on both sides whose contents differ
+	MissingOnDest   []string // Files only in the source
+	MissingOnSource []string // Files only in the destination
+	Errors          []string // Files that could not be read or hashed
+	SizeOnly        bool     // No common hash, so only sizes were compared
+}
+
+// Mismatches returns how many files did not match
+func (r CheckResult) Mismatches() int {
+	return len(r.Differ) + len(r.MissingOnDest) + len(r.MissingOnSource) + len(r.Errors)
+}
+
+// Check compares every file in source with dest by hash, using the filters
+// in opts. Files only in the destination are not counted when opts.Command
+// is "copy" or "move", since those modes leave them there on purpose.
+// Finding mismatches is not an error.
+func (m *Manager) Check(source, dest string, opts SyncOptions) (CheckResult, error) {
+	args := []string{"check", source, dest, "--combined", "-", "--config", m.configPath}
+	if opts.command() != "sync" {
+		args = append(args, "--one-way")
+	}
+	args = append(args, opts.filterArgs()...)
+
+	var stdout, stderr bytes.Buffer
+	cmd := m.command(args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	run, err := DefaultRuns.Start("check", cmd)
+	if err != nil {
+		return CheckResult{}, fmt.Errorf("check failed: %w", ClassifyError(err, nil))
+	}
+	waitErr := run.Wait()
+
+	result := parseCombined(stdout.Bytes())
+	result.SizeOnly = bytes.Contains(stderr.Bytes(), []byte(noCommonHash))
+
+	// rclone exits 1 when it finds differences, which it has listed
+	var exitErr *exec.ExitError
+	if waitErr != nil && !(errors.As(waitErr, &exitErr) && exitErr.ExitCode() == 1 && result.Mismatches() > 0) {
+		return CheckResult{}, fmt.Errorf("check failed: %w", ClassifyError(waitErr, stderr.Bytes()))
+	}
+	return result, nil
+}
+
+// parseCombined reads the report of 'rclone check --combined', a line per
+// file marked "=" (identical), "*" (different), "+" (only in the source),
+// "-" (only in the destination) or "!" (error)
+func parseCombined(output []byte) CheckResult {
+	var result CheckResult
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		mark, path, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		switch mark {
+		case "=":
+			result.Matched++
+		case "*":
+			result.Differ = append(result.Differ, path)
+		case "+":
+			result.MissingOnDest = append(result.MissingOnDest, path)
+		case "-":
+			result.MissingOnSource = append(result.MissingOnSource, path)
+		case "!":
+			result.Errors = append(result.Errors, path)
+		}
+	}
+	return result
+}
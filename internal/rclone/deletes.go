@@ -0,0 +1,97 @@
+package rclone
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// skippedDelete is what rclone logs for each file a dry run would delete
+const skippedDelete = "Skipped delete as --dry-run is set"
+
+// DeletePlan is what a sync would delete at its destination
+type DeletePlan struct {
+	Deletes   int // Files the sync would delete
+	DestFiles int // Files at the destination before the sync
+}
+
+// Percent returns the share of the destination's files the sync would delete
+func (p DeletePlan) Percent() float64 {
+	if p.DestFiles == 0 {
+		return 0
+	}
+	return float64(p.Deletes) * 100 / float64(p.DestFiles)
+}
+
+// Exceeds reports whether the sync would delete more than limit percent of
+// the destination's files. A limit of 100 or more is never exceeded.
+func (p DeletePlan) Exceeds(limit int) bool {
+	return limit < 100 && p.Deletes > 0 && p.Percent() > float64(limit)
+}
+
+// PreviewDeletes dry-runs a sync from source to dest with the given options
+// and counts the files it would delete. The destination is only sized when
+// something would be deleted.
+func (m *Manager) PreviewDeletes(source, dest string, opts SyncOptions) (DeletePlan, error) {
+	opts.Command = "sync"
+	plan, err := m.PreviewTransfer(source, dest, opts)
+	if err != nil {
+		return DeletePlan{}, err
+	}
+	return m.PlanDeletes(plan, dest, opts)
+}
+
+// PlanDeletes returns the deletes of a transfer previewed with
+// PreviewTransfer against the files at dest, sizing dest only when
+// something would be deleted
+func (m *Manager) PlanDeletes(plan TransferPlan, dest string, opts SyncOptions) (DeletePlan, error) {
+	if plan.Deletes == 0 {
+		return DeletePlan{}, nil
+	}
+	destFiles, err := m.countFiles(dest, opts)
+	if err != nil {
+		return DeletePlan{}, err
+	}
+	return DeletePlan{Deletes: plan.Deletes, DestFiles: destFiles}, nil
+}
+
+// noticeLogArgs replaces the verbosity and log file flags of a dry run,
+// including those among a pair's extra flags such as -q or --log-level
+// ERROR, with --log-level NOTICE, so every delete it skips is printed where
+// PreviewTransfer counts it
+func noticeLogArgs(args []string) []string {
+	kept := make([]string, 0, len(args)+2)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-q" || arg == "--quiet" || arg == "--verbose" || strings.HasPrefix(arg, "--verbose="):
+		case len(arg) >= 2 && strings.Trim(arg[1:], "v") == "" && arg[0] == '-':
+		case arg == "--log-level" || arg == "--log-file":
+			i++ // Drop the value too
+		case strings.HasPrefix(arg, "--log-level=") || strings.HasPrefix(arg, "--log-file="):
+		default:
+			kept = append(kept, arg)
+		}
+	}
+	return append(kept, "--log-level", "NOTICE")
+}
+
+// countFiles returns how many files are under path, leaving out those the
+// options filter out, the pair's ignore file among them
+func (m *Manager) countFiles(path string, opts SyncOptions) (int, error) {
+	args := []string{"size", path, "--json", "--config", m.configPath}
+	args = append(args, opts.filterArgs()...)
+
+	output, err := m.command(args...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count files in %s: %w", path, ClassifyError(err, nil))
+	}
+
+	var result struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse size output: %w", err)
+	}
+	return result.Count, nil
+}
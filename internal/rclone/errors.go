@@ -0,0 +1,200 @@
+package rclone
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+)
+
+// exitTemporary is the exit code rclone uses for errors more retries might
+// fix, which are nearly always network failures
+const exitTemporary = 5
+
+// maxErrorOutput is how much of a command's output, from the end, is kept
+// to classify its failure
+const maxErrorOutput = 64 << 10
+
+// errorMessages map the messages of rclone and of the providers' APIs onto
+// kinds of failure. They are matched lowercased, on lines that report an
+// error, and the first kind with a matching message wins; the HTTP status
+// codes are only tried when no message matched, so e.g. a 403 about a
+// full Drive is a quota failure.
+var errorMessages = []struct {
+	kind     error
+	messages []string
+	codes    []string // HTTP status codes, matched as in "HTTP 507" or "error 507"
+}{
+	{errkind.ErrHostKey, []string{
+		"knownhosts: key is unknown", "knownhosts: key mismatch",
+	}, nil},
+	{errkind.ErrRemoteAuth, []string{
+		"unauthorized", "invalid_grant", "token expired",
+		"expired_auth_token", "bad_auth_token", "accessdenied", "access denied", "invalidaccesskeyid",
+		"signaturedoesnotmatch", "couldn't fetch token", "authentication failed", "invalid credentials",
+		"unable to authenticate",
+	}, []string{"401", "403"}},
+	{errkind.ErrQuotaExceeded, []string{
+		"quota", "cap_exceeded", "insufficient storage", "insufficient_storage",
+		"no space left on device", "storage limit",
+	}, []string{"507"}},
+	{errkind.ErrNetwork, []string{
+		"no such host", "connection refused", "connection reset", "network is unreachable",
+		"i/o timeout", "tls handshake timeout", "temporary failure in name resolution",
+		"context deadline exceeded", "dial tcp",
+	}, nil},
+}
+
+// statusCode matches an HTTP status code in a lowercased error message,
+// e.g. "http error 507" or "status code: 403", but not a size like "507 b"
+var statusCode = regexp.MustCompile(`\b(?:http|error|status|code):? (\d{3})\b`)
+
+// ClassifyError marks err, returned by an rclone command, with the kind of
+// failure its output and exit code point to: errkind.ErrRcloneMissing when
+// rclone could not be started, and otherwise an SSH host key,
+// authentication, quota or network failure. The standard error a failed
+// Output call captured is read too. Errors of no known kind, and cancelled
+// runs, are returned as they are.
+func ClassifyError(err error, output []byte) error {
+	if err == nil || errors.Is(err, ErrCancelled) {
+		return err
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+			return errkind.Wrap(errkind.ErrRcloneMissing, err)
+		}
+	} else if len(exitErr.Stderr) > 0 {
+		output = append(append([]byte{}, output...), exitErr.Stderr...)
+	}
+
+	text := string(errorLines(output))
+	for _, kind := range errorMessages {
+		for _, message := range kind.messages {
+			if strings.Contains(text, message) {
+				return errkind.Wrap(kind.kind, err)
+			}
+		}
+	}
+	for _, match := range statusCode.FindAllStringSubmatch(text, -1) {
+		for _, kind := range errorMessages {
+			if slices.Contains(kind.codes, match[1]) {
+				return errkind.Wrap(kind.kind, err)
+			}
+		}
+	}
+	if exitErr != nil && exitErr.ExitCode() == exitTemporary {
+		return errkind.Wrap(errkind.ErrNetwork, err)
+	}
+	return err
+}
+
+// logLine matches a line of rclone's log, e.g.
+// "2025/01/02 10:00:00 ERROR : a.txt: Failed to copy: ...", capturing its
+// level and message
+var logLine = regexp.MustCompile(`^(?:\d{4}/\d\d/\d\d \d\d:\d\d:\d\d )?([A-Z]+) *: (.*)$`)
+
+// transferMessages start rclone's messages about one file, which follow
+// the file's path and ": "
+var transferMessages = []string{
+	"failed to copy", "failed to move", "failed to delete", "couldn't delete",
+	"failed to set modification time", "failed to open", "failed to read",
+	"failed to hash", "corrupted on transfer", "not deleting",
+}
+
+// errorLines returns the lines of output that report an error, lowercased,
+// leaving out the file names of the lines about transfers so that a name
+// like quota-2025.xlsx cannot look like a failure
+func errorLines(output []byte) []byte {
+	var b bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxErrorOutput)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := logLine.FindStringSubmatch(line); match != nil {
+			if match[1] != "ERROR" && match[1] != "CRITICAL" {
+				continue
+			}
+			line = withoutPath(strings.ToLower(match[2]))
+		} else {
+			// Not a log line, e.g. "Failed to sync: ..." on its own
+			line = strings.ToLower(line)
+			if !strings.Contains(line, "error") && !strings.Contains(line, "failed") && !strings.Contains(line, "critical") {
+				continue
+			}
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// withoutPath returns a lowercased log message without the path of the
+// file it is about, if it is about a transfer
+func withoutPath(message string) string {
+	for _, transfer := range transferMessages {
+		if i := strings.Index(message, ": "+transfer); i >= 0 {
+			return message[i+2:]
+		}
+	}
+	return message
+}
+
+// tailBuffer keeps the last maxErrorOutput bytes written to it
+type tailBuffer struct {
+	data []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.data = append(t.data, p...)
+	if len(t.data) > maxErrorOutput {
+		t.data = t.data[len(t.data)-maxErrorOutput:]
+	}
+	return len(p), nil
+}
+
+// logFileArg returns the file a command's --log-file flag names, or ""
+func logFileArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--log-file" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// fileSize returns the size of path, or 0 if it cannot be read
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// readFrom returns up to the last maxErrorOutput bytes written to path
+// after offset
+func readFrom(path string, offset int64) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	if size := fileSize(path); size-offset > maxErrorOutput {
+		offset = size - maxErrorOutput
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil
+	}
+	data, _ := io.ReadAll(f)
+	return data
+}
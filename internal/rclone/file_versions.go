@@ -0,0 +1,163 @@
+package rclone
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// versionsFlags are the flags that list the old versions of files next to
+// the current ones, by remote type
+var versionsFlags = map[string]string{
+	"b2": "--b2-versions",
+	"s3": "--s3-versions",
+}
+
+// versionNameRe matches the names rclone lists old versions under, the
+// upload time inserted before the extension:
+// "report-v2026-10-01-143000-000.pdf"
+var versionNameRe = regexp.MustCompile(`^(.*)-v(\d{4}-\d{2}-\d{2}-\d{6})-(\d{3})(\.[^.]*)?$`)
+
+// FileVersion is one version of a file on a versioned remote
+type FileVersion struct {
+	Path    string    // Path on the remote; old versions carry their upload time in the name
+	Size    int64     // Size in bytes
+	Time    time.Time // When an old version was uploaded, or when the current one was modified
+	Current bool      // The version a plain listing shows
+}
+
+// parseVersionName returns the file an old version's name belongs to and
+// when the version was uploaded, or false for a name without a version
+func parseVersionName(name string) (string, time.Time, bool) {
+	match := versionNameRe.FindStringSubmatch(name)
+	if match == nil {
+		return "", time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02-150405.000", match[2]+"."+match[3], time.UTC)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return match[1] + match[4], t, true
+}
+
+// versionsFlag returns the flag that lists old versions on a remote
+func (m *Manager) versionsFlag(remoteName string) (string, error) {
+	remoteType := m.remoteType(remoteName)
+	flag, ok := versionsFlags[remoteType]
+	if !ok {
+		if remoteType == "" {
+			remoteType = "of an unknown type"
+		}
+		return "", fmt.Errorf("file versions need a B2 or S3 remote, and '%s' is %s", remoteName, remoteType)
+	}
+	return flag, nil
+}
+
+// ListFiles lists every file under path, e.g. "b2:bucket/docs", sorted by
+// path
+func (m *Manager) ListFiles(path string) ([]IndexEntry, error) {
+	output, err := m.command("lsjson", path, "-R", "--files-only", "--no-mimetype", "--config", m.configPath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", path, ClassifyError(err, nil))
+	}
+
+	var entries []IndexEntry
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse file list: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// ListFileVersions lists the versions of filePath on a B2 or S3 remote,
+// newest first
+func (m *Manager) ListFileVersions(remoteName, filePath string) ([]FileVersion, error) {
+	flag, err := m.versionsFlag(remoteName)
+	if err != nil {
+		return nil, err
+	}
+	dir, name := path.Split(strings.Trim(filePath, "/"))
+	output, err := m.command("lsjson", remoteName+":"+dir, "--files-only", "--no-mimetype", flag, "--config", m.configPath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of %s: %w", filePath, ClassifyError(err, nil))
+	}
+
+	var entries []struct {
+		Name    string    `json:"Name"`
+		Size    int64     `json:"Size"`
+		ModTime time.Time `json:"ModTime"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse version list: %w", err)
+	}
+
+	versions := make([]FileVersion, 0)
+	for _, entry := range entries {
+		version := FileVersion{Path: dir + entry.Name, Size: entry.Size, Time: entry.ModTime}
+		if entry.Name == name {
+			version.Current = true
+		} else if base, uploaded, ok := parseVersionName(entry.Name); ok && base == name {
+			version.Time = uploaded
+		} else {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%s:%s has no versions", remoteName, filePath)
+	}
+
+	// The current version is the newest; old ones by upload time
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Current != versions[j].Current {
+			return versions[i].Current
+		}
+		return versions[i].Time.After(versions[j].Time)
+	})
+	return versions, nil
+}
+
+// DownloadVersion copies a version of a file to localPath
+func (m *Manager) DownloadVersion(remoteName string, version FileVersion, localPath string) error {
+	flag, err := m.versionsFlag(remoteName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("failed to create local directory: %w", err)
+	}
+
+	output, err := m.command("copyto", remoteName+":"+version.Path, localPath, flag, "--config", m.configPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w (output: %s)", version.Path, ClassifyError(err, output), string(output))
+	}
+	return nil
+}
+
+// PromoteVersion makes an old version of filePath the current one by
+// uploading it again; the version it replaces is kept as an old one.
+// Remotes refuse writes while listing versions, so the old version goes
+// through a temporary local copy.
+func (m *Manager) PromoteVersion(remoteName string, version FileVersion, filePath string) error {
+	tmp, err := os.MkdirTemp("", "cloud-sync-version")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	local := filepath.Join(tmp, path.Base(filePath))
+	if err := m.DownloadVersion(remoteName, version, local); err != nil {
+		return err
+	}
+	output, err := m.command("copyto", local, remoteName+":"+filePath, "--config", m.configPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to promote %s: %w (output: %s)", version.Path, ClassifyError(err, output), string(output))
+	}
+	return nil
+}
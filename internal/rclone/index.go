@@ -0,0 +1,202 @@
+package rclone
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// indexProgressEvery is how many files are listed between progress reports
+const indexProgressEvery = 1000
+
+// IndexEntry is one file of a listing, as rclone lsjson prints it
+type IndexEntry struct {
+	Path    string            `json:"Path"`
+	Size    int64             `json:"Size"`
+	ModTime time.Time         `json:"ModTime"`
+	Hashes  map[string]string `json:"Hashes,omitempty"`
+}
+
+// Index summarizes a listing of every file under a source. The listing
+// itself is kept next to it in the cache, one IndexEntry per line.
+type Index struct {
+	Source  string    `json:"source"`
+	Files   int       `json:"files"`
+	Bytes   int64     `json:"bytes"`
+	Created time.Time `json:"created"`
+}
+
+// IndexProgressFunc receives the running totals of an index pass
+type IndexProgressFunc func(files int, bytes int64)
+
+// IndexCache keeps the indexes of sources in a directory
+type IndexCache struct {
+	dir string
+}
+
+// NewIndexCache creates a cache in dir
+func NewIndexCache(dir string) *IndexCache {
+	return &IndexCache{dir: dir}
+}
+
+// DefaultIndexDir returns where indexes are cached,
+// ~/.cache/cloud-sync/index
+func DefaultIndexDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cache", "cloud-sync", "index"), nil
+}
+
+// key names a source's files in the cache. Sources contain characters
+// that are awkward in file names, so they are hashed.
+func (c *IndexCache) key(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ListingPath returns the file holding a source's listing
+func (c *IndexCache) ListingPath(source string) string {
+	return filepath.Join(c.dir, c.key(source)+".jsonl")
+}
+
+// summaryPath returns the file holding a source's Index
+func (c *IndexCache) summaryPath(source string) string {
+	return filepath.Join(c.dir, c.key(source)+".json")
+}
+
+// Load returns the cached index of a source, or nil if there is none
+func (c *IndexCache) Load(source string) (*Index, error) {
+	data, err := os.ReadFile(c.summaryPath(source))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse index: %w", err)
+	}
+	return &index, nil
+}
+
+// ReadListing calls onEntry with each file of a source's cached listing
+func (c *IndexCache) ReadListing(source string, onEntry func(IndexEntry) error) error {
+	file, err := os.Open(c.ListingPath(source))
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer file.Close()
+
+	dec := json.NewDecoder(file)
+	for dec.More() {
+		var entry IndexEntry
+		if err := dec.Decode(&entry); err != nil {
+			return fmt.Errorf("failed to read index: %w", err)
+		}
+		if err := onEntry(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildIndex lists every file under source with
+// 'rclone lsjson --recursive --files-only --hash' and stores the listing
+// in cache. The listing is streamed, so trees with millions of files do not
+// have to fit in memory, and onProgress, if set, gets the running totals.
+func (m *Manager) BuildIndex(source string, cache *IndexCache, onProgress IndexProgressFunc) (*Index, error) {
+	if err := os.MkdirAll(cache.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+	listing, err := os.CreateTemp(cache.dir, ".listing-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create index: %w", err)
+	}
+	// Removing fails harmlessly once the listing has been renamed
+	defer os.Remove(listing.Name())
+	defer listing.Close()
+
+	reader, writer := io.Pipe()
+	cmd := m.command("lsjson", source, "--recursive", "--files-only", "--hash", "--config", m.configPath)
+	cmd.Stdout = writer
+	run, err := DefaultRuns.Start("lsjson", cmd)
+	if err != nil {
+		return nil, fmt.Errorf("lsjson failed: %w", err)
+	}
+	go func() {
+		writer.CloseWithError(run.Wait())
+	}()
+
+	index := &Index{Source: source, Created: time.Now()}
+	out := bufio.NewWriter(listing)
+	err = decodeListing(reader, func(entry IndexEntry) error {
+		index.Files++
+		index.Bytes += entry.Size
+		if onProgress != nil && index.Files%indexProgressEvery == 0 {
+			onProgress(index.Files, index.Bytes)
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		out.Write(line)
+		return out.WriteByte('\n')
+	})
+	// Drain the pipe so rclone can exit if decoding stopped early
+	io.Copy(io.Discard, reader)
+	if waitErr := run.Wait(); waitErr != nil {
+		return nil, fmt.Errorf("lsjson failed: %w", waitErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read listing: %w", err)
+	}
+	if onProgress != nil {
+		onProgress(index.Files, index.Bytes)
+	}
+
+	if err := out.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := listing.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(listing.Name(), cache.ListingPath(source)); err != nil {
+		return nil, fmt.Errorf("failed to write index: %w", err)
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := os.WriteFile(cache.summaryPath(source), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write index: %w", err)
+	}
+	return index, nil
+}
+
+// decodeListing reads the JSON array lsjson prints one entry at a time
+func decodeListing(r io.Reader, onEntry func(IndexEntry) error) error {
+	dec := json.NewDecoder(r)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		var entry IndexEntry
+		if err := dec.Decode(&entry); err != nil {
+			return err
+		}
+		if err := onEntry(entry); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
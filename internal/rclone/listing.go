@@ -0,0 +1,55 @@
+package rclone
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+)
+
+// DirEntry is a file or folder directly under a listed folder
+type DirEntry struct {
+	Name  string `json:"Name"`
+	Size  int64  `json:"Size"` // -1 for folders
+	IsDir bool   `json:"IsDir"`
+}
+
+// ListDir lists the files and folders directly under path, e.g.
+// "b2:bucket", sorted by name. A path that does not exist holds nothing.
+func (m *Manager) ListDir(path string) ([]DirEntry, error) {
+	output, err := m.command("lsjson", path, "--no-mimetype", "--no-modtime", "--config", m.configPath).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == exitDirNotFound {
+		return []DirEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", path, ClassifyError(err, nil))
+	}
+
+	var entries []DirEntry
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse listing: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Purge permanently deletes a folder and everything in it, e.g.
+// "b2:bucket/old"
+func (m *Manager) Purge(path string) error {
+	output, err := m.command("purge", path, "--config", m.configPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to purge %s: %w (output: %s)", path, ClassifyError(err, output), string(output))
+	}
+	return nil
+}
+
+// DeleteFile permanently deletes one file, e.g. "b2:bucket/old.zip"
+func (m *Manager) DeleteFile(path string) error {
+	output, err := m.command("deletefile", path, "--config", m.configPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w (output: %s)", path, ClassifyError(err, output), string(output))
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package rclone
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultListingTTL is how long a cached listing is used before the remote
+// is listed again
+const DefaultListingTTL = 10 * time.Minute
+
+// ListingCache keeps recent bucket and folder listings of remotes in a
+// directory, so views that list a slow remote on every visit do not wait
+// for it each time
+type ListingCache struct {
+	dir     string
+	ttl     time.Duration
+	refresh bool // Set by Refreshed
+}
+
+// cachedListing is a listing as it is stored in the cache
+type cachedListing struct {
+	Source  string          `json:"source"`
+	Listed  time.Time       `json:"listed"`
+	Entries json.RawMessage `json:"entries"`
+}
+
+// NewListingCache creates a cache in dir whose listings expire after ttl
+func NewListingCache(dir string, ttl time.Duration) *ListingCache {
+	return &ListingCache{dir: dir, ttl: ttl}
+}
+
+// DefaultListingDir returns where listings are cached,
+// ~/.cache/cloud-sync/listings
+func DefaultListingDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cache", "cloud-sync", "listings"), nil
+}
+
+// Refreshed returns a cache over the same directory that lists every path
+// again instead of loading it, and stores the new listings for later
+// lookups, e.g. when the user asks for a fresh scan
+func (c *ListingCache) Refreshed() *ListingCache {
+	if c == nil {
+		return nil
+	}
+	refreshed := *c
+	refreshed.refresh = true
+	return &refreshed
+}
+
+// path returns the file holding a listing. Sources contain characters that
+// are awkward in file names, so they are hashed.
+func (c *ListingCache) path(command, source string) string {
+	sum := sha256.Sum256([]byte(command + " " + source))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// load reads an unexpired listing into entries and returns when it was
+// made, or the zero time if there is none
+func (c *ListingCache) load(command, source string, entries any) time.Time {
+	if c.refresh {
+		return time.Time{}
+	}
+	data, err := os.ReadFile(c.path(command, source))
+	if err != nil {
+		return time.Time{}
+	}
+	var listing cachedListing
+	if err := json.Unmarshal(data, &listing); err != nil || listing.Source != source {
+		return time.Time{}
+	}
+	if time.Since(listing.Listed) > c.ttl {
+		return time.Time{}
+	}
+	if err := json.Unmarshal(listing.Entries, entries); err != nil {
+		return time.Time{}
+	}
+	return listing.Listed
+}
+
+// store saves a listing made now. A cache that cannot be written only
+// means the next visit lists the remote again.
+func (c *ListingCache) store(command, source string, entries any) time.Time {
+	listed := time.Now()
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return listed
+	}
+	data, err := json.Marshal(cachedListing{Source: source, Listed: listed, Entries: raw})
+	if err != nil {
+		return listed
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return listed
+	}
+	os.WriteFile(c.path(command, source), data, 0600)
+	return listed
+}
+
+// Forget drops the cached listings of a path, e.g. "b2:" for the buckets
+// of b2 or "b2:bucket/docs" for a folder, so the next lookup lists it again
+func (c *ListingCache) Forget(path string) {
+	if c == nil {
+		return
+	}
+	os.Remove(c.path("lsd", path))
+	os.Remove(c.path("lsjson", path))
+	os.Remove(c.path("lsjson -R", path))
+}
+
+// CachedListBuckets lists the buckets of a remote like ListBuckets, using
+// a listing from cache while it is fresh. It also returns when the listing
+// was made. A nil cache always lists the remote.
+func (m *Manager) CachedListBuckets(cache *ListingCache, remoteName string) ([]Bucket, time.Time, error) {
+	source := remoteName + ":"
+	var buckets []Bucket
+	if cache != nil {
+		if listed := cache.load("lsd", source, &buckets); !listed.IsZero() {
+			return buckets, listed, nil
+		}
+	}
+	buckets, err := m.ListBuckets(remoteName)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if cache == nil {
+		return buckets, time.Now(), nil
+	}
+	return buckets, cache.store("lsd", source, buckets), nil
+}
+
+// CachedListDir lists a folder like ListDir, using a listing from cache
+// while it is fresh. It also returns when the listing was made. A nil
+// cache always lists the remote.
+func (m *Manager) CachedListDir(cache *ListingCache, path string) ([]DirEntry, time.Time, error) {
+	var entries []DirEntry
+	if cache != nil {
+		if listed := cache.load("lsjson", path, &entries); !listed.IsZero() {
+			return entries, listed, nil
+		}
+	}
+	entries, err := m.ListDir(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if cache == nil {
+		return entries, time.Now(), nil
+	}
+	return entries, cache.store("lsjson", path, entries), nil
+}
+
+// CachedListFiles lists every file under path like ListFiles, using a
+// listing from cache while it is fresh. It also returns when the listing
+// was made. A nil cache always lists the remote.
+func (m *Manager) CachedListFiles(cache *ListingCache, path string) ([]IndexEntry, time.Time, error) {
+	var entries []IndexEntry
+	if cache != nil {
+		if listed := cache.load("lsjson -R", path, &entries); !listed.IsZero() {
+			return entries, listed, nil
+		}
+	}
+	entries, err := m.ListFiles(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if cache == nil {
+		return entries, time.Now(), nil
+	}
+	return entries, cache.store("lsjson -R", path, entries), nil
+}
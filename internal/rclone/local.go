@@ -0,0 +1,69 @@
+package rclone
+
+import (
+	"path"
+	"path/filepath"
+)
+
+// maxAliasDepth bounds how many aliases are followed to the remote they
+// stand for, so aliases of each other do not loop
+const maxAliasDepth = 8
+
+// LocalFolder returns the folder on this Mac that remotePath on a remote
+// is, following aliases, e.g. /Volumes/NAS/Backups/Documents for
+// nas:Documents when nas is an alias of /Volumes/NAS/Backups. It returns
+// false for paths on other backends, and for relative paths on local
+// remotes, which rclone reads from its working directory.
+func (m *Manager) LocalFolder(remoteName, remotePath string) (string, bool) {
+	config, err := m.ParseConfig()
+	if err != nil {
+		return "", false
+	}
+	for depth := 0; depth < maxAliasDepth; depth++ {
+		section := config[remoteName]
+		switch section["type"] {
+		case "local":
+			if !filepath.IsAbs(remotePath) {
+				return "", false
+			}
+			return filepath.Clean(remotePath), true
+		case "alias":
+			target := section["remote"]
+			name, ok := remoteOf(target)
+			if !ok {
+				if !filepath.IsAbs(target) {
+					return "", false
+				}
+				return filepath.Join(target, remotePath), true
+			}
+			remoteName, remotePath = name, path.Join(target[len(name)+1:], remotePath)
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// backendType returns the type of the remote that stores a remote's files:
+// its own type, or for an alias the type of the remote it stands for, which
+// is "local" for an alias of a folder. It returns an empty string when the
+// type is unknown.
+func (m *Manager) backendType(name string) string {
+	remoteType := m.remoteType(name)
+	config, _ := m.ParseConfig()
+	for depth := 0; remoteType == "alias" && depth < maxAliasDepth; depth++ {
+		target := config[name]["remote"]
+		if target == "" {
+			return ""
+		}
+		next, ok := remoteOf(target)
+		if !ok {
+			return "local"
+		}
+		name, remoteType = next, m.remoteType(next)
+	}
+	if remoteType == "alias" {
+		return ""
+	}
+	return remoteType
+}
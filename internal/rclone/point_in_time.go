@@ -0,0 +1,84 @@
+package rclone
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// versionAtFlags are the flags that show a remote as it was at a time, by
+// remote type. Only B2 and S3 let rclone read old versions this way.
+var versionAtFlags = map[string]string{
+	"b2": "--b2-version-at",
+	"s3": "--s3-version-at",
+}
+
+// versionAtFlag returns the flag that shows a remote as it was at t
+func (m *Manager) versionAtFlag(remoteName string, t time.Time) (string, error) {
+	remoteType := m.remoteType(remoteName)
+	flag, ok := versionAtFlags[remoteType]
+	if !ok {
+		if remoteType == "" {
+			remoteType = "of an unknown type"
+		}
+		return "", fmt.Errorf("point-in-time restore needs a B2 or S3 remote, and '%s' is %s", remoteName, remoteType)
+	}
+	return flag + "=" + t.Format(time.RFC3339), nil
+}
+
+// CheckPointInTime reports why remotePath on a remote cannot be restored as
+// of a time: a remote type without versions, an rclone too old to read them,
+// or an S3 bucket without versioning. B2 buckets keep every version unless
+// lifecycle rules remove them.
+func (m *Manager) CheckPointInTime(remoteName, remotePath string) error {
+	if _, err := m.versionAtFlag(remoteName, time.Now()); err != nil {
+		return err
+	}
+	if err := m.Require(FeatureVersionAt); err != nil {
+		return err
+	}
+	if m.remoteType(remoteName) != "s3" {
+		return nil
+	}
+
+	bucket, _, _ := strings.Cut(strings.Trim(remotePath, "/"), "/")
+	output, err := m.command("backend", "versioning", remoteName+":"+bucket, "--config", m.configPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to read versioning of bucket '%s': %w (output: %s)", bucket, ClassifyError(err, output), string(output))
+	}
+	if status := strings.TrimSpace(string(output)); status != "Enabled" {
+		return fmt.Errorf("versioning is not enabled on bucket '%s', so it keeps no old versions to restore", bucket)
+	}
+	return nil
+}
+
+// FileSizesAt returns the size of every file remotePath held at t
+func (m *Manager) FileSizesAt(remoteName, remotePath string, t time.Time) ([]int64, error) {
+	flag, err := m.versionAtFlag(remoteName, t)
+	if err != nil {
+		return nil, err
+	}
+	return m.FileSizes(remoteName+":"+remotePath, flag)
+}
+
+// RestoreAt copies remotePath, as it was at t, into localPath. Files changed
+// since are overwritten with the versions of then; files added since are
+// left alone. The download is tuned as set.
+func (m *Manager) RestoreAt(remoteName, remotePath, localPath string, t time.Time, tuning RestoreTuning) error {
+	flag, err := m.versionAtFlag(remoteName, t)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return fmt.Errorf("failed to create local directory: %w", err)
+	}
+
+	args := append([]string{"copy", remoteName + ":" + remotePath, localPath, flag, "--config", m.configPath}, tuning.Flags()...)
+	output, err := m.command(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to restore as of %s: %w (output: %s)", t.Format("2006-01-02 15:04"), ClassifyError(err, output), string(output))
+	}
+
+	return nil
+}
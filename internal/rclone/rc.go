@@ -0,0 +1,160 @@
+package rclone
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultRCAddr is where scheduled backups serve rclone's remote control
+// API unless the launch agent's rc_addr sets another address
+const DefaultRCAddr = "localhost:5572"
+
+// RCClient talks to the remote control API of a running rclone process
+type RCClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// RCTransfer is a file currently being transferred
+type RCTransfer struct {
+	Name       string  `json:"name"`
+	Size       int64   `json:"size"`
+	Bytes      int64   `json:"bytes"`
+	Percentage int     `json:"percentage"`
+	Speed      float64 `json:"speed"`
+}
+
+// RCStats is the response of core/stats
+type RCStats struct {
+	Bytes          int64        `json:"bytes"`
+	TotalBytes     int64        `json:"totalBytes"`
+	Transfers      int          `json:"transfers"`
+	TotalTransfers int          `json:"totalTransfers"`
+	Checks         int          `json:"checks"` // Files compared with the destination
+	Errors         int          `json:"errors"`
+	Speed          float64      `json:"speed"` // Bytes per second
+	ETA            *float64     `json:"eta"`   // Seconds, nil when unknown
+	ElapsedTime    float64      `json:"elapsedTime"`
+	Transferring   []RCTransfer `json:"transferring"`
+}
+
+// NewRCClient creates a client for the rclone remote control server at addr
+func NewRCClient(addr string) *RCClient {
+	return &RCClient{
+		baseURL: "http://" + addr,
+		client:  &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// call invokes a remote control method and decodes its response into out
+func (c *RCClient) call(method string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode rc request: %w", err)
+	}
+
+	resp, err := c.client.Post(c.baseURL+"/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to reach rclone rc: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rclone rc %s returned %s", method, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode rc response: %w", err)
+	}
+
+	return nil
+}
+
+// FreeRCAddr returns a local address no other process is listening on, for
+// an rclone process to serve its remote control API on
+func FreeRCAddr() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to find a free port for rclone rc: %w", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String(), nil
+}
+
+// Stats returns the transfer statistics of the running rclone process
+func (c *RCClient) Stats() (*RCStats, error) {
+	var stats RCStats
+	if err := c.call("core/stats", struct{}{}, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Running reports whether an rclone process is serving the API
+func (c *RCClient) Running() bool {
+	_, err := c.Stats()
+	return err == nil
+}
+
+// pausedRate is the bandwidth limit that pauses transfers. rclone has no
+// pause of its own; at one byte per second a transfer stalls once the few
+// megabytes rclone lets through in a burst are sent.
+const pausedRate = "1B"
+
+// RCBandwidth is the response of core/bwlimit
+type RCBandwidth struct {
+	BytesPerSecond int64  `json:"bytesPerSecond"` // -1 when unlimited
+	Rate           string `json:"rate"`           // As rclone formats it, e.g. "2Mi" or "off"
+}
+
+// Unlimited reports whether transfers run at full speed
+func (b RCBandwidth) Unlimited() bool {
+	return b.BytesPerSecond < 0
+}
+
+// Paused reports whether the limit is the one Pause sets
+func (b RCBandwidth) Paused() bool {
+	return b.BytesPerSecond == 1
+}
+
+// BandwidthLimit returns the running rclone's bandwidth limit
+func (c *RCClient) BandwidthLimit() (*RCBandwidth, error) {
+	return c.callBandwidth(struct{}{})
+}
+
+// SetBandwidthLimit changes the running rclone's bandwidth limit without
+// restarting it. rate is a size per second as --bwlimit reads it, e.g.
+// "2M", or "off".
+func (c *RCClient) SetBandwidthLimit(rate string) (*RCBandwidth, error) {
+	return c.callBandwidth(map[string]string{"rate": rate})
+}
+
+// callBandwidth invokes core/bwlimit
+func (c *RCClient) callBandwidth(in interface{}) (*RCBandwidth, error) {
+	var bw RCBandwidth
+	if err := c.call("core/bwlimit", in, &bw); err != nil {
+		return nil, err
+	}
+	if bw.Rate == "" {
+		return nil, fmt.Errorf("rclone rc core/bwlimit did not report a limit")
+	}
+	return &bw, nil
+}
+
+// Pause stalls the running rclone's transfers until Resume
+func (c *RCClient) Pause() (*RCBandwidth, error) {
+	return c.SetBandwidthLimit(pausedRate)
+}
+
+// Resume restores the bandwidth limit in effect before Pause, or lifts the
+// limit when that is not known
+func (c *RCClient) Resume(before *RCBandwidth) (*RCBandwidth, error) {
+	if before == nil || before.Unlimited() || before.Paused() || before.BytesPerSecond == 0 {
+		return c.SetBandwidthLimit("off")
+	}
+	return c.SetBandwidthLimit(fmt.Sprintf("%dB", before.BytesPerSecond))
+}
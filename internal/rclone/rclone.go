@@ -4,17 +4,29 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
 )
 
 // Manager handles rclone operations
 type Manager struct {
 	configPath string
 	rclonePath string
+	env        []string  // Extra environment, e.g. resolved RCLONE_CONFIG_* credentials
+	stdout     io.Writer // Where commands attached to the terminal print, os.Stdout if nil
+	stderr     io.Writer // os.Stderr if nil
+
+	// The installed version, read on first use (see InstalledVersion)
+	versionOnce sync.Once
+	version     Version
+	versionErr  error
 }
 
 // Remote represents an rclone remote configuration
@@ -50,6 +62,38 @@ func NewManagerWithConfig(rclonePath, configPath string) *Manager {
 	}
 }
 
+// SetEnv sets extra environment variables passed to every rclone command
+func (m *Manager) SetEnv(env []string) {
+	m.env = env
+}
+
+// SetOutput sets where the commands attached to the terminal, transfers and
+// interactive configuration, print instead of os.Stdout and os.Stderr
+func (m *Manager) SetOutput(stdout, stderr io.Writer) {
+	m.stdout, m.stderr = stdout, stderr
+}
+
+// output returns the writers set by SetOutput, or the terminal's
+func (m *Manager) output() (stdout, stderr io.Writer) {
+	stdout, stderr = m.stdout, m.stderr
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	return stdout, stderr
+}
+
+// command builds an rclone command with the manager's environment
+func (m *Manager) command(args ...string) *exec.Cmd {
+	cmd := simulate.Command(m.rclonePath, args...)
+	if len(m.env) > 0 {
+		cmd.Env = append(os.Environ(), m.env...)
+	}
+	return cmd
+}
+
 // GetConfigPath returns the rclone config file path
 func (m *Manager) GetConfigPath() string {
 	return m.configPath
@@ -61,12 +105,25 @@ func (m *Manager) ConfigExists() bool {
 	return err == nil
 }
 
+// Version returns the first line of 'rclone version', e.g. "rclone v1.68.0"
+func (m *Manager) Version() (string, error) {
+	output, err := m.command("version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get rclone version: %w", ClassifyError(err, nil))
+	}
+	line, _, _ := strings.Cut(string(output), "\n")
+	if line = strings.TrimSpace(line); line == "" {
+		return "", fmt.Errorf("could not parse rclone version")
+	}
+	return line, nil
+}
+
 // ListRemotes lists all configured remotes
 func (m *Manager) ListRemotes() ([]Remote, error) {
-	cmd := exec.Command(m.rclonePath, "listremotes", "--config", m.configPath)
+	cmd := m.command("listremotes", "--config", m.configPath)
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list remotes: %w", err)
+		return nil, fmt.Errorf("failed to list remotes: %w", ClassifyError(err, nil))
 	}
 
 	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
@@ -89,10 +146,10 @@ func (m *Manager) ListRemotes() ([]Remote, error) {
 
 // ListBuckets lists all buckets for a remote
 func (m *Manager) ListBuckets(remoteName string) ([]Bucket, error) {
-	cmd := exec.Command(m.rclonePath, "lsd", remoteName+":", "--config", m.configPath)
+	cmd := m.command("lsd", remoteName+":", "--config", m.configPath)
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list buckets: %w", err)
+		return nil, fmt.Errorf("failed to list buckets: %w", ClassifyError(err, nil))
 	}
 
 	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
@@ -115,21 +172,45 @@ func (m *Manager) ListBuckets(remoteName string) ([]Bucket, error) {
 	return buckets, nil
 }
 
+// CreateBucket creates a bucket on a remote
+func (m *Manager) CreateBucket(remoteName, bucket string) error {
+	if err := ValidateBucketName(bucket); err != nil {
+		return err
+	}
+
+	cmd := m.command("mkdir", remoteName+":"+bucket, "--config", m.configPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create bucket: %w (output: %s)", ClassifyError(err, output), strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
 // TestRemote tests connectivity to a remote
 func (m *Manager) TestRemote(remoteName string) error {
-	cmd := exec.Command(m.rclonePath, "lsd", remoteName+":", "--config", m.configPath, "--max-depth", "1")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("remote test failed: %w", err)
+	cmd := m.command("lsd", remoteName+":", "--config", m.configPath, "--max-depth", "1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("remote test failed: %w", ClassifyError(err, output))
+	}
+	return nil
+}
+
+// TestBucket checks that the remote's credentials can list the objects of
+// bucket, for accounts that may not list the buckets themselves
+func (m *Manager) TestBucket(remoteName, bucket string) error {
+	cmd := m.command("lsf", remoteName+":"+bucket, "--config", m.configPath, "--max-depth", "1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("bucket test failed: %w", ClassifyError(err, output))
 	}
 	return nil
 }
 
 // ConfigureRemote runs interactive rclone config
 func (m *Manager) ConfigureRemote() error {
-	cmd := exec.Command(m.rclonePath, "config", "--config", m.configPath)
+	cmd := m.command("config", "--config", m.configPath)
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout, cmd.Stderr = m.output()
 
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to configure remote: %w", err)
@@ -207,24 +288,148 @@ func (m *Manager) GetRemoteType(remoteName string) (string, error) {
 	return remoteType, nil
 }
 
+// SyncOptions holds optional settings for a sync operation
+type SyncOptions struct {
+	Progress  bool
+	DryRun    bool
+	BackupDir    string   // Move deleted or overwritten files here instead of removing them
+	Excludes     []string // Filter patterns passed as --exclude
+	FilterFrom   string   // File of filter rules passed as --filter-from
+	CompareDests []string // Skip files already identical in these remote paths
+	LogFile      string   // Append rclone's log to this file instead of stderr
+	Command      string   // Transfer command: "sync" (default), "copy" or "move"
+	ExtraFlags   []string // Passed through as-is after the other flags
+	RCAddr       string   // Serve the remote control API here, to follow progress
+	SkipHidden   bool     // Leave out files and folders whose names start with a dot
+	SkipLinks    bool     // Leave out symbolic links without a notice for each
+	MaxSize      string   // Leave out files larger than this, e.g. "2G"
+}
+
 // Sync performs a sync operation
 func (m *Manager) Sync(source, dest string, progress bool, dryRun bool) error {
-	args := []string{"sync", source, dest, "--config", m.configPath, "--fast-list", "-v"}
+	return m.SyncWithOptions(source, dest, SyncOptions{Progress: progress, DryRun: dryRun})
+}
+
+// BuildSyncArgs returns the rclone arguments for a sync operation
+func (m *Manager) BuildSyncArgs(source, dest string, opts SyncOptions) []string {
+	return m.buildTransferArgs("sync", source, dest, opts)
+}
+
+// BuildCopyArgs returns the rclone arguments for a copy operation
+func (m *Manager) BuildCopyArgs(source, dest string, opts SyncOptions) []string {
+	return m.buildTransferArgs("copy", source, dest, opts)
+}
+
+// BuildTransferArgs returns the rclone arguments for the transfer command
+// set in the options
+func (m *Manager) BuildTransferArgs(source, dest string, opts SyncOptions) []string {
+	return m.buildTransferArgs(opts.command(), source, dest, opts)
+}
+
+// filterArgs returns the flags leaving files out of the transfer
+func (o SyncOptions) filterArgs() []string {
+	var args []string
+	for _, pattern := range o.Excludes {
+		args = append(args, "--exclude", pattern)
+	}
+	if o.FilterFrom != "" {
+		args = append(args, "--filter-from", o.FilterFrom)
+	}
+	if o.SkipHidden {
+		args = append(args, "--exclude", ".*", "--exclude", ".*/**")
+	}
+	if o.SkipLinks {
+		args = append(args, "--skip-links")
+	}
+	if o.MaxSize != "" {
+		args = append(args, "--max-size", o.MaxSize)
+	}
+	return args
+}
+
+// command returns the transfer command, defaulting to sync
+func (o SyncOptions) command() string {
+	if o.Command == "" {
+		return "sync"
+	}
+	return o.Command
+}
+
+// buildTransferArgs returns the rclone arguments for a sync or copy
+func (m *Manager) buildTransferArgs(command, source, dest string, opts SyncOptions) []string {
+	args := []string{command, source, dest, "--config", m.configPath}
+	args = append(args, m.DefaultFlags(source, dest)...)
+	args = append(args, "-v")
+
+	if opts.BackupDir != "" {
+		args = append(args, "--backup-dir", opts.BackupDir)
+	}
+
+	args = append(args, opts.filterArgs()...)
+
+	for _, dir := range opts.CompareDests {
+		args = append(args, "--compare-dest", dir)
+	}
+
+	if opts.LogFile != "" {
+		args = append(args, "--log-file", opts.LogFile)
+	}
+
+	args = append(args, opts.ExtraFlags...)
+
+	if opts.RCAddr != "" {
+		args = append(args, "--rc", "--rc-addr", opts.RCAddr)
+	}
 
-	if progress {
+	if opts.Progress {
 		args = append(args, "-P")
 	}
 
-	if dryRun {
+	if opts.DryRun {
 		args = append(args, "--dry-run")
 	}
 
-	cmd := exec.Command(m.rclonePath, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	return args
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("sync failed: %w", err)
+// SyncWithOptions performs a sync operation with the given options
+func (m *Manager) SyncWithOptions(source, dest string, opts SyncOptions) error {
+	return m.runTransfer("sync", m.BuildSyncArgs(source, dest, opts))
+}
+
+// CopyWithOptions copies source to dest without deleting anything at dest
+func (m *Manager) CopyWithOptions(source, dest string, opts SyncOptions) error {
+	return m.runTransfer("copy", m.BuildCopyArgs(source, dest, opts))
+}
+
+// TransferWithOptions runs the transfer command set in the options
+func (m *Manager) TransferWithOptions(source, dest string, opts SyncOptions) error {
+	return m.runTransfer(opts.command(), m.BuildTransferArgs(source, dest, opts))
+}
+
+// runTransfer runs a transfer command attached to the terminal
+func (m *Manager) runTransfer(command string, args []string) error {
+	cmd := m.command(args...)
+	stdout, terminal := m.output()
+	cmd.Stdout = stdout
+	var stderr tailBuffer
+	cmd.Stderr = io.MultiWriter(terminal, &stderr)
+
+	// rclone reports errors in its log file instead when it has one
+	logFile := logFileArg(args)
+	logStart := fileSize(logFile)
+
+	// Track the process so cancelling the backup can stop it
+	run, err := DefaultRuns.Start(command, cmd)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", command, ClassifyError(err, nil))
+	}
+	if err := run.Wait(); err != nil {
+		output := stderr.data
+		if logFile != "" {
+			output = append(readFrom(logFile, logStart), output...)
+		}
+		return fmt.Errorf("%s failed: %w", command, ClassifyError(err, output))
 	}
 
 	return nil
@@ -232,6 +437,12 @@ func (m *Manager) Sync(source, dest string, progress bool, dryRun bool) error {
 
 // SyncLocalToRemote syncs a local folder to a remote location
 func (m *Manager) SyncLocalToRemote(localPath, remoteName, remotePath string, progress bool, dryRun bool) error {
+	return m.SyncLocalToRemoteWithOptions(localPath, remoteName, remotePath, SyncOptions{Progress: progress, DryRun: dryRun})
+}
+
+// SyncLocalToRemoteWithOptions transfers a local folder to a remote location
+// with the given options, using sync unless they set another command
+func (m *Manager) SyncLocalToRemoteWithOptions(localPath, remoteName, remotePath string, opts SyncOptions) error {
 	// Validate local path exists
 	if _, err := os.Stat(localPath); err != nil {
 		return fmt.Errorf("local path does not exist: %w", err)
@@ -240,11 +451,17 @@ func (m *Manager) SyncLocalToRemote(localPath, remoteName, remotePath string, pr
 	// Build remote destination
 	dest := fmt.Sprintf("%s:%s", remoteName, remotePath)
 	
-	return m.Sync(localPath, dest, progress, dryRun)
+	return m.TransferWithOptions(localPath, dest, opts)
 }
 
 // SyncRemoteToLocal syncs a remote location to a local folder
 func (m *Manager) SyncRemoteToLocal(remoteName, remotePath, localPath string, progress bool, dryRun bool) error {
+	return m.SyncRemoteToLocalWithOptions(remoteName, remotePath, localPath, SyncOptions{Progress: progress, DryRun: dryRun})
+}
+
+// SyncRemoteToLocalWithOptions transfers a remote location to a local folder
+// with the given options, using sync unless they set another command
+func (m *Manager) SyncRemoteToLocalWithOptions(remoteName, remotePath, localPath string, opts SyncOptions) error {
 	// Ensure local directory exists
 	if err := os.MkdirAll(localPath, 0755); err != nil {
 		return fmt.Errorf("failed to create local directory: %w", err)
@@ -253,7 +470,7 @@ func (m *Manager) SyncRemoteToLocal(remoteName, remotePath, localPath string, pr
 	// Build remote source
 	source := fmt.Sprintf("%s:%s", remoteName, remotePath)
 	
-	return m.Sync(source, localPath, progress, dryRun)
+	return m.TransferWithOptions(source, localPath, opts)
 }
 
 // ListLocalFiles lists files in a local directory (for preview)
@@ -264,7 +481,7 @@ func (m *Manager) ListLocalFiles(localPath string, maxDepth int) ([]string, erro
 		args = append(args, "--max-depth", fmt.Sprintf("%d", maxDepth))
 	}
 
-	cmd := exec.Command(m.rclonePath, args...)
+	cmd := m.command(args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list local files: %w", err)
@@ -292,7 +509,7 @@ func (m *Manager) ListLocalFiles(localPath string, maxDepth int) ([]string, erro
 func (m *Manager) GetLocalDirSize(localPath string) (int64, error) {
 	args := []string{"size", localPath, "--json"}
 
-	cmd := exec.Command(m.rclonePath, args...)
+	cmd := m.command(args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get directory size: %w", err)
@@ -329,7 +546,19 @@ func ValidateRemoteName(name string) error {
 	return nil
 }
 
-// ValidateBucketName validates a bucket name
+// ValidateBucketPath validates the bucket a remote path starts with, e.g.
+// "photos" of "photos/2024", on remotes that keep buckets. On other remotes
+// the path is made of folders, which are not checked.
+func ValidateBucketPath(remoteType, path string) error {
+	if !IsBucketBased(remoteType) {
+		return nil
+	}
+	bucket, _, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	return ValidateBucketName(bucket)
+}
+
+// ValidateBucketName validates a bucket name with the rules S3 and B2 have
+// in common; see ValidateBucketPath for remote paths
 func ValidateBucketName(name string) error {
 	if name == "" {
 		return fmt.Errorf("bucket name cannot be empty")
@@ -343,7 +572,23 @@ func ValidateBucketName(name string) error {
 		if char == ' ' {
 			return fmt.Errorf("bucket name cannot contain spaces")
 		}
+		if !((char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '-' || char == '.') {
+			return fmt.Errorf("bucket name contains invalid character: %c", char)
+		}
+	}
+
+	// The rules S3 and B2 have in common
+	if len(name) < 3 || len(name) > 63 {
+		return fmt.Errorf("bucket name must be 3 to 63 characters long")
+	}
+	if !isAlphanumeric(name[0]) || !isAlphanumeric(name[len(name)-1]) {
+		return fmt.Errorf("bucket name must start and end with a letter or number")
 	}
 	
 	return nil
+}
+
+// isAlphanumeric reports whether c is a lowercase letter or a digit
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
 }
\ No newline at end of file
@@ -0,0 +1,140 @@
+package rclone
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MultiThreadCutoff is the size above which rclone downloads a file in
+// several streams, its default --multi-thread-cutoff
+const MultiThreadCutoff = 256 << 20
+
+// maxRestoreParallelism bounds transfers and streams, past which more
+// connections only get throttled by the providers
+const maxRestoreParallelism = 64
+
+// Bandwidths, in bytes per second, past which restores are tuned
+// differently
+const (
+	slowBandwidth = 2 << 20  // More connections only add overhead
+	fastBandwidth = 50 << 20 // Single streams cannot fill the link
+)
+
+// RestoreTuning is how a restore downloads: how many files at once, and how
+// many streams each file above MultiThreadCutoff is split into. Zero values
+// keep rclone's defaults of 4 and 4.
+type RestoreTuning struct {
+	Transfers          int `json:"transfers,omitempty"`
+	MultiThreadStreams int `json:"multi_thread_streams,omitempty"`
+}
+
+// IsZero reports whether no setting is changed from rclone's defaults
+func (t RestoreTuning) IsZero() bool {
+	return t == RestoreTuning{}
+}
+
+// Validate checks the settings are within what rclone and the providers
+// handle
+func (t RestoreTuning) Validate() error {
+	for _, setting := range []struct {
+		name  string
+		value int
+	}{{"transfers", t.Transfers}, {"multi-thread streams", t.MultiThreadStreams}} {
+		if setting.value < 0 || setting.value > maxRestoreParallelism {
+			return fmt.Errorf("%s must be between 0 and %d", setting.name, maxRestoreParallelism)
+		}
+	}
+	return nil
+}
+
+// String describes the settings, e.g. "2 files at once, 8 streams per
+// large file"
+func (t RestoreTuning) String() string {
+	var parts []string
+	if t.Transfers > 0 {
+		parts = append(parts, fmt.Sprintf("%d files at once", t.Transfers))
+	}
+	if t.MultiThreadStreams > 0 {
+		parts = append(parts, fmt.Sprintf("%d streams per large file", t.MultiThreadStreams))
+	}
+	if len(parts) == 0 {
+		return "rclone defaults"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Flags returns the settings as rclone flags
+func (t RestoreTuning) Flags() []string {
+	var flags []string
+	if t.Transfers > 0 {
+		flags = append(flags, "--transfers", strconv.Itoa(t.Transfers))
+	}
+	if t.MultiThreadStreams > 0 {
+		flags = append(flags, "--multi-thread-streams", strconv.Itoa(t.MultiThreadStreams))
+	}
+	return flags
+}
+
+// TuneRestore picks the settings for downloading files of the given sizes
+// over a link of bandwidth bytes per second, 0 when it was not measured.
+// Large files get more streams each and fewer files at once, and many small
+// files, which are bound by latency rather than bandwidth, more at once.
+func TuneRestore(sizes []int64, bandwidth float64) RestoreTuning {
+	if len(sizes) == 0 {
+		return RestoreTuning{}
+	}
+	large := 0
+	for _, size := range sizes {
+		if size >= MultiThreadCutoff {
+			large++
+		}
+	}
+
+	t := RestoreTuning{Transfers: 4, MultiThreadStreams: 4}
+	switch {
+	case large == len(sizes):
+		t = RestoreTuning{Transfers: 2, MultiThreadStreams: 8}
+	case large > 0:
+		t.MultiThreadStreams = 8
+	case len(sizes) > 100:
+		t.Transfers = 16
+	}
+
+	switch {
+	case bandwidth > 0 && bandwidth < slowBandwidth:
+		t.Transfers = min(t.Transfers, 4)
+		t.MultiThreadStreams = min(t.MultiThreadStreams, 4)
+	case bandwidth >= fastBandwidth:
+		t.MultiThreadStreams *= 2
+		if large == 0 {
+			t.Transfers *= 2
+		}
+	}
+	t.Transfers = max(1, min(t.Transfers, len(sizes), maxRestoreParallelism))
+	t.MultiThreadStreams = min(t.MultiThreadStreams, maxRestoreParallelism)
+	return t
+}
+
+// FileSizes returns the size of every file under path, e.g.
+// "b2:bucket/docs", listed with the extra flags
+func (m *Manager) FileSizes(path string, flags ...string) ([]int64, error) {
+	args := append([]string{"lsjson", path, "-R", "--files-only", "--no-mimetype", "--no-modtime", "--config", m.configPath}, flags...)
+	output, err := m.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", path, ClassifyError(err, nil))
+	}
+
+	var files []struct {
+		Size int64 `json:"Size"`
+	}
+	if err := json.Unmarshal(output, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse file list: %w", err)
+	}
+	sizes := make([]int64, 0, len(files))
+	for _, f := range files {
+		sizes = append(sizes, f.Size)
+	}
+	return sizes, nil
+}
@@ -0,0 +1,169 @@
+package rclone
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CancelGrace is how long a cancelled rclone process gets to exit after
+// SIGINT before it is killed
+const CancelGrace = 5 * time.Second
+
+// ErrCancelled is returned by transfers whose rclone process was cancelled
+var ErrCancelled = errors.New("cancelled")
+
+// Run is an rclone process started by a Manager
+type Run struct {
+	ID      int
+	Command string // e.g. sync, copy
+	Args    []string
+	PID     int
+	Started time.Time
+
+	cmd       *exec.Cmd
+	done      chan struct{}
+	err       error
+	mu        sync.Mutex
+	cancelled bool
+}
+
+// Wait waits for the process to exit. It returns ErrCancelled if the run
+// was cancelled, and the process's error otherwise.
+func (r *Run) Wait() error {
+	<-r.done
+	if r.Cancelled() {
+		return ErrCancelled
+	}
+	return r.err
+}
+
+// Cancelled reports whether Cancel was called on the run
+func (r *Run) Cancelled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cancelled
+}
+
+// Cancel sends the process SIGINT, so rclone can finish writing its log and
+// remove partial uploads, and kills it if it has not exited after grace
+func (r *Run) Cancel(grace time.Duration) {
+	r.mu.Lock()
+	r.cancelled = true
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+
+	if err := r.cmd.Process.Signal(os.Interrupt); err != nil {
+		r.cmd.Process.Kill()
+	}
+	select {
+	case <-r.done:
+	case <-time.After(grace):
+		r.cmd.Process.Kill()
+		<-r.done
+	}
+}
+
+// RunRegistry tracks the rclone processes that are running so they can be
+// cancelled
+type RunRegistry struct {
+	mu     sync.Mutex
+	runs   map[int]*Run
+	nextID int
+	closed bool // Set by Shutdown; no more processes are started
+}
+
+// DefaultRuns is the registry every Manager starts its transfers in
+var DefaultRuns = NewRunRegistry()
+
+// NewRunRegistry creates an empty registry
+func NewRunRegistry() *RunRegistry {
+	return &RunRegistry{runs: make(map[int]*Run)}
+}
+
+// Start starts cmd and tracks it until it exits. It returns ErrCancelled
+// without starting cmd once the registry has been shut down.
+func (g *RunRegistry) Start(command string, cmd *exec.Cmd) (*Run, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return nil, ErrCancelled
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	g.nextID++
+	run := &Run{
+		ID:      g.nextID,
+		Command: command,
+		Args:    cmd.Args[1:],
+		PID:     cmd.Process.Pid,
+		Started: time.Now(),
+		cmd:     cmd,
+		done:    make(chan struct{}),
+	}
+	g.runs[run.ID] = run
+
+	go func() {
+		run.err = cmd.Wait()
+		g.mu.Lock()
+		delete(g.runs, run.ID)
+		g.mu.Unlock()
+		close(run.done)
+	}()
+
+	return run, nil
+}
+
+// Running returns the runs that have not exited, oldest first
+func (g *RunRegistry) Running() []*Run {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	runs := make([]*Run, 0, len(g.runs))
+	for _, run := range g.runs {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].ID < runs[j].ID
+	})
+	return runs
+}
+
+// CancelAll cancels every running process and waits for them to exit. It
+// returns how many were cancelled.
+func (g *RunRegistry) CancelAll(grace time.Duration) int {
+	runs := g.Running()
+
+	var wg sync.WaitGroup
+	for _, run := range runs {
+		wg.Add(1)
+		go func(run *Run) {
+			defer wg.Done()
+			run.Cancel(grace)
+		}(run)
+	}
+	wg.Wait()
+
+	return len(runs)
+}
+
+// Shutdown cancels every running process and refuses to start new ones, so
+// a batch of syncs stops instead of moving on to its next pair. It returns
+// how many processes were cancelled.
+func (g *RunRegistry) Shutdown(grace time.Duration) int {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	return g.CancelAll(grace)
+}
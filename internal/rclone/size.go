@@ -0,0 +1,109 @@
+package rclone
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// skippedPattern matches what rclone logs for each file a dry run would
+// copy, move or delete, e.g. "Skipped copy as --dry-run is set (size
+// 1.500Mi)". Older versions write the units without the i.
+var skippedPattern = regexp.MustCompile(`Skipped (copy|move|delete) as --dry-run is set \(size ([\d.]+)([KMGTP]i?|k)?\)`)
+
+// TransferPlan is how much a transfer would add to and remove from its
+// destination
+type TransferPlan struct {
+	CopyBytes   int64 // New and changed files it would upload
+	DeleteBytes int64 // Files it would delete
+	Deletes     int   // Number of files it would delete
+}
+
+// PreviewTransfer dry-runs the transfer set in the options from source to
+// dest and sums the sizes of the files it would copy and delete. One
+// preview answers both the delete limit and the size budget checks.
+func (m *Manager) PreviewTransfer(source, dest string, opts SyncOptions) (TransferPlan, error) {
+	// A backup dir turns deletes into moves; count them as deletes here
+	opts.DryRun = true
+	opts.Progress = false
+	opts.LogFile = ""
+	opts.BackupDir = ""
+	opts.RCAddr = ""
+
+	var output bytes.Buffer
+	cmd := m.command(noticeLogArgs(m.BuildTransferArgs(source, dest, opts))...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	run, err := DefaultRuns.Start(opts.command(), cmd)
+	if err != nil {
+		return TransferPlan{}, fmt.Errorf("dry run failed: %w", ClassifyError(err, nil))
+	}
+	if err := run.Wait(); err != nil {
+		return TransferPlan{}, fmt.Errorf("dry run failed: %w", ClassifyError(err, output.Bytes()))
+	}
+
+	var plan TransferPlan
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), skippedDelete) {
+			plan.Deletes++
+		}
+		match := skippedPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		size := parseSizeSuffix(match[2], match[3])
+		if match[1] == "delete" {
+			plan.DeleteBytes += size
+		} else {
+			plan.CopyBytes += size
+		}
+	}
+	return plan, nil
+}
+
+// parseSizeSuffix converts a size rclone printed, a number and a binary
+// unit such as "Mi", to bytes
+func parseSizeSuffix(number, unit string) int64 {
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0
+	}
+	if unit != "" {
+		shift := 10 * (strings.Index("KMGTP", strings.ToUpper(unit[:1])) + 1)
+		value *= float64(int64(1) << shift)
+	}
+	return int64(value)
+}
+
+// exitDirNotFound is the exit code rclone uses when a directory it was
+// given does not exist
+const exitDirNotFound = 3
+
+// Size returns the bytes stored under path, e.g. "b2:bucket/docs". A path
+// that does not exist yet holds nothing.
+func (m *Manager) Size(path string) (int64, error) {
+	output, err := m.command("size", path, "--json", "--config", m.configPath).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == exitDirNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to size %s: %w", path, ClassifyError(err, nil))
+	}
+
+	var result struct {
+		Bytes int64 `json:"bytes"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse size output: %w", err)
+	}
+	return result.Bytes, nil
+}
@@ -0,0 +1,114 @@
+package rclone
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// Snapshot represents one dated snapshot folder of a snapshot-mode pair
+type Snapshot struct {
+	Date time.Time
+	Path string // Path on the remote, relative to the remote root
+}
+
+// SnapshotPath returns the folder a snapshot taken at t is copied into
+func SnapshotPath(remotePath string, t time.Time) string {
+	return path.Join(remotePath, t.Format(datedDirLayout))
+}
+
+// ListSnapshots lists the snapshot folders under remotePath, newest first
+func (m *Manager) ListSnapshots(remoteName, remotePath string) ([]Snapshot, error) {
+	dirs, err := m.listDatedDirs(remoteName, remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	snapshots := make([]Snapshot, 0, len(dirs))
+	for _, dir := range dirs {
+		snapshots = append(snapshots, Snapshot{Date: dir.date, Path: dir.path})
+	}
+
+	return snapshots, nil
+}
+
+// CopySnapshot copies a local folder into the snapshot folder for t. Files
+// identical to those in an earlier snapshot are skipped with --compare-dest,
+// so each snapshot only holds what changed since the ones before it.
+func (m *Manager) CopySnapshot(localPath, remoteName, remotePath string, t time.Time, opts SyncOptions) error {
+	if _, err := os.Stat(localPath); err != nil {
+		return fmt.Errorf("local path does not exist: %w", err)
+	}
+
+	snapshots, err := m.ListSnapshots(remoteName, remotePath)
+	if err != nil {
+		return err
+	}
+
+	dest := SnapshotPath(remotePath, t)
+	for _, snapshot := range snapshots {
+		if snapshot.Path != dest {
+			opts.CompareDests = append(opts.CompareDests, remoteName+":"+snapshot.Path)
+		}
+	}
+
+	return m.CopyWithOptions(localPath, remoteName+":"+dest, opts)
+}
+
+// PruneSnapshots deletes snapshots outside the keep/max-age policy and
+// returns how many were removed. Because snapshots are incremental, files
+// in a pruned snapshot are first merged into the next newer one so that
+// every remaining snapshot can still be restored.
+func (m *Manager) PruneSnapshots(remoteName, remotePath string, keep int, maxAge time.Duration) (int, error) {
+	snapshots, err := m.ListSnapshots(remoteName, remotePath)
+	if err != nil {
+		return 0, err
+	}
+
+	prune := SnapshotsToPrune(snapshots, keep, maxAge, time.Now())
+	if len(prune) == 0 {
+		return 0, nil
+	}
+
+	// The oldest retained snapshot absorbs everything being pruned
+	base := snapshots[len(snapshots)-len(prune)-1]
+
+	pruned := 0
+	for _, snapshot := range prune {
+		args := m.BuildCopyArgs(remoteName+":"+snapshot.Path, remoteName+":"+base.Path, SyncOptions{})
+		args = append(args, "--ignore-existing")
+		output, err := m.command(args...).CombinedOutput()
+		if err != nil {
+			return pruned, fmt.Errorf("failed to merge snapshot %s: %w (output: %s)", snapshot.Path, ClassifyError(err, output), string(output))
+		}
+
+		output, err = m.command("purge", remoteName+":"+snapshot.Path, "--config", m.configPath).CombinedOutput()
+		if err != nil {
+			return pruned, fmt.Errorf("failed to purge snapshot %s: %w (output: %s)", snapshot.Path, ClassifyError(err, output), string(output))
+		}
+		pruned++
+	}
+
+	return pruned, nil
+}
+
+// SnapshotsToPrune returns the snapshots, sorted newest first, that fall
+// outside the policy: beyond the newest keep snapshots, or older than
+// maxAge. A zero keep or maxAge disables that limit. The newest snapshot
+// is never pruned.
+func SnapshotsToPrune(snapshots []Snapshot, keep int, maxAge time.Duration, now time.Time) []Snapshot {
+	prune := make([]Snapshot, 0)
+	for i, snapshot := range snapshots {
+		if i == 0 {
+			continue
+		}
+		tooMany := keep > 0 && i >= keep
+		tooOld := maxAge > 0 && snapshot.Date.Before(now.Add(-maxAge))
+		if tooMany || tooOld {
+			// Everything older goes too, keeping the retained set contiguous
+			return append(prune, snapshots[i:]...)
+		}
+	}
+	return prune
+}
@@ -0,0 +1,184 @@
+package rclone
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TrashDirName is the folder, at the bucket root, that holds soft-deleted files
+const TrashDirName = ".cloud-sync-trash"
+
+// datedDirLayout is the layout of dated trash and snapshot folders
+const datedDirLayout = "2006-01-02"
+
+// TrashEntry represents one dated trash folder for a sync pair
+type TrashEntry struct {
+	Date time.Time
+	Path string // Path on the remote, relative to the remote root
+}
+
+// TrashRoot returns the trash folder for a sync pair, placed at the root of
+// the bucket that remotePath points into
+func TrashRoot(remotePath, pairName string) string {
+	bucket := strings.Trim(remotePath, "/")
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket = bucket[:i]
+	}
+	return path.Join(bucket, TrashDirName, pairName)
+}
+
+// TrashPath returns the dated trash folder that deletions made at t are moved to
+func TrashPath(remotePath, pairName string, t time.Time) string {
+	return path.Join(TrashRoot(remotePath, pairName), t.Format(datedDirLayout))
+}
+
+// TrashRootFor returns the trash folder for a sync pair on a remote. A
+// folder given by its full path on a local remote, e.g. /Volumes/NAS/Docs,
+// keeps its trash inside it, on the same disk: without the leading / rclone
+// would read the path from its working directory. Other destinations have
+// it at the root of their bucket, as TrashRoot places it.
+func (m *Manager) TrashRootFor(remoteName, remotePath, pairName string) string {
+	if _, ok := m.LocalFolder(remoteName, remotePath); ok && path.IsAbs(remotePath) {
+		return path.Join(remotePath, TrashDirName, pairName)
+	}
+	return TrashRoot(remotePath, pairName)
+}
+
+// TrashPathFor returns the dated trash folder on a remote that deletions
+// made at t are moved to
+func (m *Manager) TrashPathFor(remoteName, remotePath, pairName string, t time.Time) string {
+	return path.Join(m.TrashRootFor(remoteName, remotePath, pairName), t.Format(datedDirLayout))
+}
+
+// TrashExclude returns the filter that keeps the trash folder out of a sync
+// whose destination is the bucket root
+func TrashExclude() string {
+	return "/" + TrashDirName + "/**"
+}
+
+// ListTrash lists the dated trash folders for a sync pair, newest first
+func (m *Manager) ListTrash(remoteName, remotePath, pairName string) ([]TrashEntry, error) {
+	dirs, err := m.listDatedDirs(remoteName, m.TrashRootFor(remoteName, remotePath, pairName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	entries := make([]TrashEntry, 0, len(dirs))
+	for _, dir := range dirs {
+		entries = append(entries, TrashEntry{Date: dir.date, Path: dir.path})
+	}
+
+	return entries, nil
+}
+
+// datedDir is a folder named after the date it was created for
+type datedDir struct {
+	date time.Time
+	path string
+}
+
+// listDatedDirs lists the YYYY-MM-DD folders directly under root, newest
+// first. A missing root is treated as empty.
+func (m *Manager) listDatedDirs(remoteName, root string) ([]datedDir, error) {
+	cmd := m.command("lsjson", remoteName+":"+root, "--dirs-only", "--config", m.configPath)
+	output, err := cmd.Output()
+	if err != nil {
+		// Nothing has been written under root yet
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 3 {
+			return []datedDir{}, nil
+		}
+		return nil, ClassifyError(err, nil)
+	}
+
+	var items []struct {
+		Name  string `json:"Name"`
+		IsDir bool   `json:"IsDir"`
+	}
+	if err := json.Unmarshal(output, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse listing: %w", err)
+	}
+
+	dirs := make([]datedDir, 0, len(items))
+	for _, item := range items {
+		if !item.IsDir {
+			continue
+		}
+		date, err := time.ParseInLocation(datedDirLayout, item.Name, time.Local)
+		if err != nil {
+			continue // Not one of our dated folders
+		}
+		dirs = append(dirs, datedDir{date: date, path: path.Join(root, item.Name)})
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].date.After(dirs[j].date)
+	})
+
+	return dirs, nil
+}
+
+// RestoreTrash copies a trash folder back into the pair's local folder, so
+// the next upload does not delete the restored files again. The download
+// is tuned as set.
+func (m *Manager) RestoreTrash(remoteName string, entry TrashEntry, localPath string, tuning RestoreTuning) error {
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return fmt.Errorf("failed to create local directory: %w", err)
+	}
+
+	args := append([]string{"copy", remoteName + ":" + entry.Path, localPath, "--config", m.configPath}, tuning.Flags()...)
+	cmd := m.command(args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to restore trash: %w (output: %s)", ClassifyError(err, output), string(output))
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently deletes a trash folder
+func (m *Manager) PurgeTrash(remoteName string, entry TrashEntry) error {
+	cmd := m.command("purge", remoteName+":"+entry.Path, "--config", m.configPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to purge trash: %w (output: %s)", ClassifyError(err, output), string(output))
+	}
+
+	return nil
+}
+
+// PurgeTrashOlderThan permanently deletes trash folders older than the
+// retention period and returns how many were removed
+func (m *Manager) PurgeTrashOlderThan(remoteName, remotePath, pairName string, retention time.Duration) (int, error) {
+	entries, err := m.ListTrash(remoteName, remotePath, pairName)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, entry := range ExpiredTrash(entries, retention, time.Now()) {
+		if err := m.PurgeTrash(remoteName, entry); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// ExpiredTrash returns the entries older than the retention period at now
+func ExpiredTrash(entries []TrashEntry, retention time.Duration, now time.Time) []TrashEntry {
+	cutoff := now.Add(-retention)
+	expired := make([]TrashEntry, 0)
+	for _, entry := range entries {
+		if entry.Date.Before(cutoff) {
+			expired = append(expired, entry)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,105 @@
+package rclone
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Version is an rclone release
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// String returns the version as rclone prints it, e.g. "v1.68.0"
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less reports whether v is older than other
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+var versionRe = regexp.MustCompile(`v(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion reads a version from 'rclone version' output such as
+// "rclone v1.68.0" or "rclone v1.69.0-beta.8353"
+func ParseVersion(output string) (Version, error) {
+	match := versionRe.FindStringSubmatch(output)
+	if match == nil {
+		return Version{}, fmt.Errorf("could not parse rclone version from %q", output)
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(match[1])
+	v.Minor, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		v.Patch, _ = strconv.Atoi(match[3])
+	}
+	return v, nil
+}
+
+// Features cloud-sync gates on the installed rclone version
+const (
+	FeatureBaseline  = "cloud-sync"            // Everything every run relies on
+	FeatureBisync    = "bisync"                // Two-way sync with conflict handling
+	FeatureCheck     = "check"                 // 'rclone check --combined', for the integrity scrub
+	FeatureVersionAt = "point-in-time restore" // --b2-version-at and --s3-version-at
+)
+
+// MinVersion is the oldest rclone cloud-sync runs with
+var MinVersion = Version{1, 50, 0}
+
+// featureVersions are the rclone releases that added each feature
+var featureVersions = map[string]Version{
+	FeatureBaseline:  MinVersion,
+	FeatureBisync:    {1, 58, 0},
+	FeatureCheck:     {1, 52, 0},
+	FeatureVersionAt: {1, 65, 0}, // --s3-version-at came in 1.59, --b2-version-at in 1.65
+}
+
+// VersionError reports an rclone too old for a feature
+type VersionError struct {
+	Feature   string
+	Installed Version
+	Required  Version
+}
+
+// Error implements the error interface
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%s needs rclone %s or newer, but %s is installed; update it with 'brew upgrade rclone'",
+		e.Feature, e.Required, e.Installed)
+}
+
+// InstalledVersion returns the version of the manager's rclone binary. It
+// is read once and remembered.
+func (m *Manager) InstalledVersion() (Version, error) {
+	m.versionOnce.Do(func() {
+		var line string
+		if line, m.versionErr = m.Version(); m.versionErr == nil {
+			m.version, m.versionErr = ParseVersion(line)
+		}
+	})
+	return m.version, m.versionErr
+}
+
+// Require returns a VersionError if the installed rclone is older than the
+// release that added feature. A version that cannot be read is not an
+// error here; running rclone reports the actual problem.
+func (m *Manager) Require(feature string) error {
+	required, ok := featureVersions[feature]
+	if !ok {
+		return fmt.Errorf("unknown rclone feature '%s'", feature)
+	}
+	installed, err := m.InstalledVersion()
+	if err != nil || !installed.Less(required) {
+		return nil
+	}
+	return &VersionError{Feature: feature, Installed: installed, Required: required}
+}
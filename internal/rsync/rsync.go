@@ -0,0 +1,199 @@
+package rsync
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+)
+
+// logFormat makes rsync log each transferred file like rclone does, so the
+// log viewer and statistics read both the same way
+const logFormat = "INFO  : %n: Copied"
+
+// deletingPrefix starts each line --itemize-changes prints for a deletion
+const deletingPrefix = "*deleting "
+
+// Manager runs rsync for local pairs, which mirror one folder to another,
+// e.g. on an external drive
+type Manager struct {
+	rsyncPath string
+	stdout    io.Writer // Where mirrors print, os.Stdout if nil
+	stderr    io.Writer // os.Stderr if nil
+}
+
+// NewManager creates a new rsync manager
+func NewManager(rsyncPath string) *Manager {
+	if rsyncPath == "" {
+		rsyncPath = "rsync" // Resolved via PATH
+	}
+	return &Manager{rsyncPath: rsyncPath}
+}
+
+// SetOutput sets where mirrors print instead of os.Stdout and os.Stderr
+func (m *Manager) SetOutput(stdout, stderr io.Writer) {
+	m.stdout, m.stderr = stdout, stderr
+}
+
+// Options holds optional settings for a mirror
+type Options struct {
+	Progress   bool
+	DryRun     bool
+	LogFile    string   // Append rsync's log to this file
+	FilterFrom string   // File of + and - filter rules, merged with --filter
+	Command    string   // "sync" (default) deletes extra files at the target, "copy" never deletes, "move" removes transferred files from the source
+	ExtraFlags []string // Passed through as-is after the other flags
+	SkipHidden bool     // Leave out files and folders whose names start with a dot
+	SkipLinks  bool     // Leave out symbolic links instead of copying them
+	MaxSize    string   // Leave out files larger than this, e.g. "2G"
+}
+
+// BuildArgs returns the rsync arguments for mirroring the contents of
+// source into target. Files are copied in archive mode (-a), keeping
+// permissions, times and symlinks; parts of it can be turned off with
+// extra flags such as --no-perms.
+func (m *Manager) BuildArgs(source, target string, opts Options) []string {
+	args := []string{"-a"}
+
+	switch opts.Command {
+	case "copy":
+	case "move":
+		args = append(args, "--remove-source-files")
+	default:
+		args = append(args, "--delete")
+	}
+
+	if opts.LogFile != "" {
+		args = append(args, "--log-file", opts.LogFile, "--log-file-format", logFormat)
+	}
+
+	if opts.FilterFrom != "" {
+		args = append(args, "--filter", "merge "+opts.FilterFrom)
+	}
+	if opts.SkipHidden {
+		args = append(args, "--exclude", ".*")
+	}
+	if opts.SkipLinks {
+		args = append(args, "--no-links")
+	}
+	if opts.MaxSize != "" {
+		args = append(args, "--max-size", opts.MaxSize)
+	}
+
+	args = append(args, opts.ExtraFlags...)
+
+	if opts.Progress {
+		args = append(args, "--progress")
+	}
+
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+
+	// The trailing slash copies the folder's contents rather than the folder
+	return append(args, strings.TrimSuffix(source, "/")+"/", target)
+}
+
+// Mirror copies source into target with the given options. The target
+// folder is created if needed, but not its parent, so an external drive
+// that is not mounted fails instead of filling the startup disk.
+func (m *Manager) Mirror(source, target string, opts Options) error {
+	if _, err := os.Stat(source); err != nil {
+		return fmt.Errorf("local path does not exist: %w", err)
+	}
+	if _, err := os.Stat(filepath.Dir(target)); err != nil {
+		return fmt.Errorf("target is not available: %w", err)
+	}
+
+	cmd := simulate.Command(m.rsyncPath, m.BuildArgs(source, target, opts)...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if m.stdout != nil {
+		cmd.Stdout = m.stdout
+	}
+	if m.stderr != nil {
+		cmd.Stderr = m.stderr
+	}
+
+	// Tracked with the rclone processes so cancelling a backup stops it too
+	run, err := rclone.DefaultRuns.Start("rsync", cmd)
+	if err != nil {
+		return fmt.Errorf("rsync failed: %w", err)
+	}
+	if err := run.Wait(); err != nil {
+		return fmt.Errorf("rsync failed: %w", err)
+	}
+	return nil
+}
+
+// PreviewDeletes dry-runs a mirror of source to target and counts the files
+// it would delete. The target is only counted when something would be
+// deleted, and a target that does not exist yet has nothing to delete.
+func (m *Manager) PreviewDeletes(source, target string, opts Options) (rclone.DeletePlan, error) {
+	if _, err := os.Stat(target); os.IsNotExist(err) {
+		return rclone.DeletePlan{}, nil
+	}
+
+	opts.DryRun = true
+	opts.Progress = false
+	opts.LogFile = ""
+	opts.Command = "sync"
+	args := m.BuildArgs(source, target, opts)
+	args = append([]string{"--itemize-changes"}, args...)
+
+	var output bytes.Buffer
+	cmd := simulate.Command(m.rsyncPath, args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	run, err := rclone.DefaultRuns.Start("rsync", cmd)
+	if err != nil {
+		return rclone.DeletePlan{}, fmt.Errorf("dry run failed: %w", err)
+	}
+	if err := run.Wait(); err != nil {
+		return rclone.DeletePlan{}, fmt.Errorf("dry run failed: %w", err)
+	}
+
+	var plan rclone.DeletePlan
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Folders are listed with a trailing slash; only files are counted
+		if strings.HasPrefix(line, deletingPrefix) && !strings.HasSuffix(line, "/") {
+			plan.Deletes++
+		}
+	}
+	if plan.Deletes == 0 {
+		return plan, nil
+	}
+
+	plan.DestFiles, err = countFiles(target)
+	if err != nil {
+		return rclone.DeletePlan{}, err
+	}
+	return plan, nil
+}
+
+// countFiles returns how many files are under dir
+func countFiles(dir string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count files in %s: %w", dir, err)
+	}
+	return count, nil
+}
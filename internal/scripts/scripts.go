@@ -3,6 +3,7 @@ package scripts
 import (
 	"embed"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"text/template"
@@ -13,7 +14,33 @@ var scriptTemplates embed.FS
 
 // Generator handles script generation from templates
 type Generator struct {
-	templateFS embed.FS
+	templateFS  fs.FS
+	overrideDir string // Templates here replace the built-in ones, if set
+}
+
+// Template is a script the generator writes to the bin directory
+type Template struct {
+	Name        string // Script file name, e.g. sync_now.sh
+	Description string
+}
+
+// Templates is the registry of scripts, in the order they are generated.
+// Each is rendered from <name>.tmpl.
+var Templates = []Template{
+	{Name: "run_rclone_sync.sh", Description: "Core rclone execution script"},
+	{Name: "monthly_backup.sh", Description: "Monthly check with deduplication, run by the LaunchAgent"},
+	{Name: "sync_now.sh", Description: "On-demand sync with progress"},
+	{Name: "show_transfers.sh", Description: "Display recent transfers from log"},
+}
+
+// DefaultTemplateDir returns the directory users put their own versions of
+// the templates in, ~/.config/cloud-sync/templates
+func DefaultTemplateDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "cloud-sync", "templates"), nil
 }
 
 // Config holds configuration for script generation
@@ -27,22 +54,101 @@ type Config struct {
 	DestBucket   string
 	LogDir       string
 	BinDir       string
+	RCAddr       string // Where rclone serves its remote control API; none if empty
+	CloudSync    string // The cloud-sync binary the scheduled backup runs the sync pairs with; none if empty
 }
 
-// NewGenerator creates a new script generator
+// NewGenerator creates a new script generator that uses the templates in
+// DefaultTemplateDir in place of the built-in ones
 func NewGenerator() *Generator {
+	overrideDir, _ := DefaultTemplateDir()
+	return NewGeneratorWithOverrides(overrideDir)
+}
+
+// NewGeneratorWithOverrides creates a generator that uses the templates in
+// overrideDir in place of the built-in ones. An empty dir disables overrides.
+func NewGeneratorWithOverrides(overrideDir string) *Generator {
 	return &Generator{
-		templateFS: scriptTemplates,
+		templateFS:  scriptTemplates,
+		overrideDir: overrideDir,
 	}
 }
 
 // NewGeneratorWithFS creates a generator with custom filesystem (for testing)
-func NewGeneratorWithFS(fs embed.FS) *Generator {
+func NewGeneratorWithFS(fs fs.FS) *Generator {
 	return &Generator{
 		templateFS: fs,
 	}
 }
 
+// OverridePath returns the user template that replaces a script's built-in
+// template, and false if there is none
+func (g *Generator) OverridePath(scriptName string) (string, bool) {
+	if g.overrideDir == "" {
+		return "", false
+	}
+	path := filepath.Join(g.overrideDir, scriptName+".tmpl")
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// Overrides returns the names of the scripts rendered from user templates
+func (g *Generator) Overrides() []string {
+	var names []string
+	for _, t := range Templates {
+		if _, ok := g.OverridePath(t.Name); ok {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// ExportTemplates copies the built-in templates into dir as a starting
+// point for overrides. Existing files are kept. It returns the paths written.
+func (g *Generator) ExportTemplates(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create template directory: %w", err)
+	}
+
+	var written []string
+	for _, t := range Templates {
+		path := filepath.Join(dir, t.Name+".tmpl")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		content, err := fs.ReadFile(g.templateFS, t.Name+".tmpl")
+		if err != nil {
+			return written, fmt.Errorf("failed to read template %s: %w", t.Name, err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return written, fmt.Errorf("failed to write template %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// readTemplate returns a script's template and where it came from, the
+// user's override if there is one and the built-in template otherwise
+func (g *Generator) readTemplate(scriptName string) ([]byte, string, error) {
+	if path, ok := g.OverridePath(scriptName); ok {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, path, fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+		return content, path, nil
+	}
+
+	templateName := scriptName + ".tmpl"
+	content, err := fs.ReadFile(g.templateFS, templateName)
+	if err != nil {
+		return nil, templateName, fmt.Errorf("failed to read template %s: %w", templateName, err)
+	}
+	return content, templateName, nil
+}
+
 // CreateDirectories creates required directories for scripts and logs
 func (g *Generator) CreateDirectories(config *Config) error {
 	dirs := []string{
@@ -79,16 +185,21 @@ func (g *Generator) GenerateShowTransfersScript(config *Config) error {
 	return g.generateScript("show_transfers.sh", config)
 }
 
-// GenerateAllScripts generates all scripts
-func (g *Generator) GenerateAllScripts(config *Config) error {
-	scripts := []string{
-		"run_rclone_sync.sh",
-		"monthly_backup.sh",
-		"sync_now.sh",
-		"show_transfers.sh",
+// ScriptNames lists the scripts written to the bin directory
+var ScriptNames = templateNames()
+
+// templateNames returns the script names in the registry
+func templateNames() []string {
+	names := make([]string, len(Templates))
+	for i, t := range Templates {
+		names[i] = t.Name
 	}
+	return names
+}
 
-	for _, script := range scripts {
+// GenerateAllScripts generates all scripts
+func (g *Generator) GenerateAllScripts(config *Config) error {
+	for _, script := range ScriptNames {
 		if err := g.generateScript(script, config); err != nil {
 			return fmt.Errorf("failed to generate %s: %w", script, err)
 		}
@@ -97,13 +208,30 @@ func (g *Generator) GenerateAllScripts(config *Config) error {
 	return nil
 }
 
+// Install validates the configuration, creates the bin and log directories
+// and writes every script
+func (g *Generator) Install(config *Config) error {
+	if err := ValidateConfig(config); err != nil {
+		return fmt.Errorf("invalid script configuration: %w", err)
+	}
+
+	if err := g.CreateDirectories(config); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+
+	if err := g.GenerateAllScripts(config); err != nil {
+		return fmt.Errorf("failed to generate scripts: %w", err)
+	}
+
+	return nil
+}
+
 // generateScript generates a script from template
 func (g *Generator) generateScript(scriptName string, config *Config) error {
 	// Read template
-	templateName := scriptName + ".tmpl"
-	tmplContent, err := g.templateFS.ReadFile(templateName)
+	tmplContent, templateName, err := g.readTemplate(scriptName)
 	if err != nil {
-		return fmt.Errorf("failed to read template %s: %w", templateName, err)
+		return err
 	}
 
 	// Parse template
@@ -0,0 +1,134 @@
+package simulate
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Flag is the global option that turns simulation on
+const Flag = "--simulate"
+
+// Action is an external command that was not run because simulation is on
+type Action struct {
+	Name string
+	Args []string
+}
+
+// String returns the command line, e.g. "brew install rclone"
+func (a Action) String() string {
+	return strings.Join(append([]string{a.Name}, a.Args...), " ")
+}
+
+// stub is what a simulated command prints and exits with
+type stub struct {
+	output string
+	exit   string
+}
+
+// stubs answer the queries whose output is parsed, so the wizard and views
+// can carry on; other commands print nothing and succeed. Keys are the
+// program name and its first argument.
+var stubs = map[string]stub{
+	"rclone version":   {output: "rclone v1.68.0\n- os/version: simulated\n", exit: "0"},
+	"rsync --version":  {output: "rsync  version 3.3.0  protocol version 31\n", exit: "0"},
+	"launchctl list":   {output: "Could not find service in domain for port\n", exit: "113"},
+	"launchctl print":  {output: "Could not find service in domain for system\n", exit: "113"},
+	"brew install":     {output: "Simulated: nothing was installed\n", exit: "0"},
+	"brew upgrade":     {output: "Simulated: nothing was upgraded\n", exit: "0"},
+	"launchctl load":   {exit: "0"},
+	"launchctl unload": {exit: "0"},
+}
+
+var (
+	mu      sync.Mutex
+	enabled bool
+	actions []Action
+	custom  map[string]stub // Answers set with Stub, by the same keys
+)
+
+// Enable turns simulation on for the rest of the process
+func Enable() {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = true
+}
+
+// Enabled reports whether external commands are simulated
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Reset turns simulation off and forgets the recorded actions
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = false
+	actions = nil
+	custom = nil
+}
+
+// Stub makes a simulated command, keyed like the canned answers by its
+// program name and first argument, e.g. "cloud-sync sync", print output
+// and exit with code, until Reset
+func Stub(command, output string, code int) {
+	mu.Lock()
+	defer mu.Unlock()
+	if custom == nil {
+		custom = make(map[string]stub)
+	}
+	custom[command] = stub{output: output, exit: strconv.Itoa(code)}
+}
+
+// Actions returns the commands that would have run, in order
+func Actions() []Action {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Action(nil), actions...)
+}
+
+// Command returns exec.Command(name, arg...), or while simulating a
+// stand-in that prints a canned answer instead of running it. The stand-in
+// is recorded as an Action.
+func Command(name string, arg ...string) *exec.Cmd {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return exec.Command(name, arg...)
+	}
+
+	program := filepath.Base(name)
+	actions = append(actions, Action{Name: program, Args: append([]string(nil), arg...)})
+
+	key := program
+	if len(arg) > 0 {
+		key += " " + arg[0]
+	}
+	s, ok := custom[key]
+	if !ok {
+		s, ok = stubs[key]
+	}
+	if !ok {
+		s = stub{exit: "0"}
+	}
+	return exec.Command("/bin/sh", "-c", `printf '%s' "$1"; exit "$2"`, "sh", s.output, s.exit)
+}
+
+// TakeFlag removes Flag from args, reporting whether it was there, so it
+// can be given anywhere on the command line
+func TakeFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == Flag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
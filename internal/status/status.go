@@ -0,0 +1,277 @@
+package status
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/cost"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// StaleLockAge is how old the lockfile must be before it is reported
+const StaleLockAge = 6 * time.Hour
+
+// Summary is the overall backup status shown by the dashboard and
+// `cloud-sync status`
+type Summary struct {
+	Pairs        int
+	EnabledPairs int
+	LastRun      *logs.SyncSession // Most recent finished session, nil if none
+	NextRun      time.Time         // Zero when no schedule is active
+	Schedule     string            // Schedule description, empty when disabled
+	Warnings     []string
+	ScrubIssues  []logs.ScrubRun // Scrub runs with problems not yet acknowledged
+
+	// Remotes the pairs sync to, in the order they first appear
+	Remotes []config.RemoteConfig
+}
+
+// addRemote adds a remote to the remotes the pairs sync to, once. Remotes
+// set up with 'rclone config' are not stored and only have a name.
+func (s *Summary) addRemote(appConfig *config.AppConfig, name string) {
+	for _, remote := range s.Remotes {
+		if remote.Name == name {
+			return
+		}
+	}
+	remote := config.RemoteConfig{Name: name}
+	for _, r := range appConfig.Remotes {
+		if r.Name == name {
+			remote = r
+		}
+	}
+	s.Remotes = append(s.Remotes, remote)
+}
+
+// Load builds the status summary. Sources that cannot be read are reported
+// as warnings rather than failing the whole summary.
+func Load(launchdMgr *launchd.Manager, now time.Time) Summary {
+	var summary Summary
+
+	configManager, err := config.NewManager()
+	if err != nil {
+		summary.Warnings = append(summary.Warnings, err.Error())
+		return summary
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("failed to load config: %v", err))
+		return summary
+	}
+
+	logManager := logs.NewManager(appConfig.LogDir)
+	if syncConfigMgr, err := syncconfig.NewDefaultManager(); err == nil {
+		if pairs, err := syncConfigMgr.ListSyncPairs(); err == nil {
+			summary.Pairs = len(pairs)
+			for _, pair := range pairs {
+				if pair.Enabled {
+					summary.EnabledPairs++
+				}
+				logManager.AddPairs(pair.Name)
+				for _, dest := range pair.AllDestinations() {
+					summary.addRemote(appConfig, dest.RemoteName)
+				}
+			}
+		}
+	}
+	if summary.Pairs == 0 {
+		summary.Warnings = append(summary.Warnings, "No sync pairs configured yet")
+	}
+
+	if sessions, err := logManager.GetSyncSessions(); err == nil {
+		for i := len(sessions) - 1; i >= 0; i-- {
+			if !sessions[i].EndTime.IsZero() {
+				summary.LastRun = &sessions[i]
+				break
+			}
+		}
+	}
+
+	if issues, err := logManager.UnacknowledgedScrubs(); err == nil {
+		summary.ScrubIssues = issues
+	} else {
+		summary.Warnings = append(summary.Warnings, err.Error())
+	}
+
+	if appConfig.LaunchAgent.Enabled {
+		schedule := appConfig.LaunchAgent.EffectiveSchedule()
+		summary.Schedule = schedule.String()
+		summary.NextRun = NextScheduledRun(schedule, summary.LastRun, now)
+		// The installed plist is what launchd goes by, edited or not
+		if next, err := launchdMgr.NextRun(now); err == nil && !next.IsZero() {
+			summary.NextRun = next
+		}
+
+		if status, err := launchdMgr.GetStatus(); err == nil && !status.Loaded {
+			summary.Warnings = append(summary.Warnings, launchdMgr.Kind()+" is not loaded; scheduled backups will not run")
+		}
+	}
+
+	lock := lockfile.NewManager(appConfig.LogDir)
+	if lock.IsStale(StaleLockAge) {
+		age, _ := lock.GetAge()
+		summary.Warnings = append(summary.Warnings,
+			fmt.Sprintf("Stale lockfile (%s old) is blocking backups: %s", age.Round(time.Minute), lock.GetPath()))
+	}
+
+	return summary
+}
+
+// AcknowledgeScrubs marks the scrub problems in the summary's ScrubIssues
+// as seen and returns how many it marked
+func AcknowledgeScrubs() (int, error) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return 0, err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load config: %w", err)
+	}
+	return logs.NewManager(appConfig.LogDir).AcknowledgeScrubs()
+}
+
+// NextScheduledRun returns when the schedule fires next. Interval schedules
+// count from the last run, or fire on the next wake if there was none.
+func NextScheduledRun(schedule launchd.Schedule, lastRun *logs.SyncSession, now time.Time) time.Time {
+	if schedule.UsesInterval() {
+		if lastRun == nil {
+			return now
+		}
+		next := lastRun.StartTime.Add(time.Duration(schedule.IntervalHours) * time.Hour)
+		if next.Before(now) {
+			return now
+		}
+		return next
+	}
+
+	return launchd.NextCalendarRun(schedule.Intervals(), now)
+}
+
+// ProtectedSize adds up the size of every enabled pair's local folder.
+// Walking large folders is slow, so callers should run it in the background.
+func ProtectedSize() (int64, error) {
+	sizes, err := PairSizes()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, size := range sizes {
+		total += size
+	}
+	return total, nil
+}
+
+// PairSizes returns the size of every enabled pair's local folder by pair
+// name. It is as slow as ProtectedSize.
+func PairSizes() (map[string]int64, error) {
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := syncConfigMgr.ListEnabledSyncPairs()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]int64, len(pairs))
+	for _, pair := range pairs {
+		sizes[pair.Name] = folderSize(pair.LocalPath)
+	}
+	return sizes, nil
+}
+
+// egressDays is the period of downloads counted as a month's egress
+const egressDays = 30
+
+// EstimateCost estimates the monthly bill of the enabled pairs' remotes. A
+// remote stores what its pairs' folders hold, as given in sizes by
+// PairSizes, and serves what its download pairs transferred in the last 30
+// days.
+func EstimateCost(sizes map[string]int64, now time.Time) (*cost.Estimate, error) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := syncConfigMgr.ListEnabledSyncPairs()
+	if err != nil {
+		return nil, err
+	}
+	remotes, err := rclone.NewManagerWithConfig(appConfig.RclonePath, appConfig.RcloneConfig).ParseConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	logManager := logs.NewManager(appConfig.LogDir)
+	for _, pair := range pairs {
+		logManager.AddPairs(pair.Name)
+	}
+	egress := map[string]int64{}
+	if stats, err := logManager.GetStatsByPair(now.AddDate(0, 0, -egressDays)); err == nil {
+		for _, row := range stats {
+			egress[row.Key] = row.Bytes
+		}
+	}
+
+	var names []string
+	usage := map[string]cost.Usage{}
+	for _, pair := range pairs {
+		if pair.RemoteName == "" {
+			continue
+		}
+		if _, ok := usage[pair.RemoteName]; !ok {
+			names = append(names, pair.RemoteName)
+		}
+		u := usage[pair.RemoteName]
+		u.StoredBytes += sizes[pair.Name]
+		if pair.Direction == "download" {
+			u.EgressBytes += egress[pair.Name]
+		}
+		usage[pair.RemoteName] = u
+	}
+
+	estimate := &cost.Estimate{}
+	for _, name := range names {
+		plan, ok := cost.PlanFor(remotes[name])
+		if !ok {
+			estimate.Unpriced = append(estimate.Unpriced, name)
+			continue
+		}
+		estimate.Add(name, plan, usage[name])
+	}
+	return estimate, nil
+}
+
+// folderSize returns the total size of the regular files under root,
+// skipping anything that cannot be read
+func folderSize(root string) int64 {
+	var total int64
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
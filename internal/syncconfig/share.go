@@ -0,0 +1,256 @@
+package syncconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/fileformat"
+)
+
+// PairFileVersion is the version of the pair files written by Export
+const PairFileVersion = 1
+
+// PairFile holds sync pairs shared between machines, as written by
+// 'cloud-sync pairs export' and read by 'cloud-sync pairs import'
+type PairFile struct {
+	Version int        `json:"version"`
+	Pairs   []SyncPair `json:"pairs"`
+}
+
+// Export returns the pairs as a pair file in format "yaml" or "json". Paths
+// in home are written as ~/..., so they point to the same folders on a
+// machine where the user's home is elsewhere.
+func Export(pairs []SyncPair, home, format string) ([]byte, error) {
+	file := PairFile{Version: PairFileVersion, Pairs: make([]SyncPair, 0, len(pairs))}
+	for _, pair := range pairs {
+		pair.LocalPath = tildePath(pair.LocalPath, home)
+		pair.TargetPath = tildePath(pair.TargetPath, home)
+		pair.StagingPath = tildePath(pair.StagingPath, home)
+		file.Pairs = append(file.Pairs, pair)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sync pairs: %w", err)
+	}
+	switch format {
+	case "json":
+		return append(data, '\n'), nil
+	case "yaml":
+		return fileformat.YAMLFromJSON(data)
+	default:
+		return nil, fmt.Errorf("invalid format '%s', must be 'yaml' or 'json'", format)
+	}
+}
+
+// tildePath writes a path in home as ~/...
+func tildePath(path, home string) string {
+	if path == "" || home == "" || !within(path, home) {
+		return path
+	}
+	rel, _ := filepath.Rel(home, path)
+	if rel == "." {
+		return "~"
+	}
+	return "~/" + filepath.ToSlash(rel)
+}
+
+// ParsePairFile reads a pair file in YAML or JSON. Unknown keys are
+// rejected, so a misspelled setting is not silently dropped.
+func ParsePairFile(data []byte) (*PairFile, error) {
+	data, err := fileformat.JSONFromYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var file PairFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid pair file: %w", err)
+	}
+	if file.Version > PairFileVersion {
+		return nil, fmt.Errorf("pair file version %d is newer than this cloud-sync supports (%d)", file.Version, PairFileVersion)
+	}
+	if len(file.Pairs) == 0 {
+		return nil, fmt.Errorf("the pair file has no pairs")
+	}
+	return &file, nil
+}
+
+// ConflictPolicy decides what happens to an imported pair whose name or
+// local folder is already configured
+type ConflictPolicy string
+
+// Conflict policies
+const (
+	ConflictFail    ConflictPolicy = "fail"    // Import nothing
+	ConflictSkip    ConflictPolicy = "skip"    // Keep the configured pair
+	ConflictReplace ConflictPolicy = "replace" // Replace it with the imported one
+	ConflictRename  ConflictPolicy = "rename"  // Add the imported one under a free name
+)
+
+// ParseConflictPolicy parses a conflict policy name
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(s); policy {
+	case ConflictFail, ConflictSkip, ConflictReplace, ConflictRename:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid conflict policy '%s', must be fail, skip, replace or rename", s)
+}
+
+// What Import did with a pair
+const (
+	ImportAdded     = "added"
+	ImportUnchanged = "unchanged"
+	ImportReplaced  = "replaced"
+	ImportRenamed   = "renamed"
+	ImportSkipped   = "skipped"
+)
+
+// ImportResult is what Import did with one pair of the file
+type ImportResult struct {
+	Name   string // Name in the file
+	Action string // One of the Import* constants
+	Detail string // New name of a renamed pair, or why a pair was skipped
+}
+
+// Import adds the pairs of a pair file, resolving conflicts with the
+// configured pairs by policy. Every pair is validated first, and if any is
+// invalid, or the policy is fail and any conflicts, nothing is imported.
+// With dryRun the results are returned without saving them.
+func (m *Manager) Import(pairs []SyncPair, policy ConflictPolicy, dryRun bool) ([]ImportResult, error) {
+	valid := make([]SyncPair, 0, len(pairs))
+	var errs []error
+	seen := make(map[string]bool)
+	for _, pair := range pairs {
+		if seen[pair.Name] {
+			errs = append(errs, fmt.Errorf("pair '%s' appears more than once", pair.Name))
+			continue
+		}
+		seen[pair.Name] = true
+		if err := ValidateSyncPair(&pair); err != nil {
+			errs = append(errs, fmt.Errorf("pair '%s': %w", pair.Name, err))
+			continue
+		}
+		valid = append(valid, pair)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if dryRun {
+		config, err := m.Load()
+		if err != nil {
+			return nil, err
+		}
+		results, _, err := planImport(config.SyncPairs, valid, policy)
+		return results, err
+	}
+
+	var results []ImportResult
+	err := m.update(func(config *Config) error {
+		var err error
+		results, config.SyncPairs, err = planImport(config.SyncPairs, valid, policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// planImport returns what importing the validated pairs into existing does
+// and the resulting list of pairs
+func planImport(existing, pairs []SyncPair, policy ConflictPolicy) ([]ImportResult, []SyncPair, error) {
+	merged := append([]SyncPair{}, existing...)
+	results := make([]ImportResult, 0, len(pairs))
+	var conflicts []error
+
+	for _, pair := range pairs {
+		byName, byPath := -1, -1
+		for i, other := range merged {
+			if other.Name == pair.Name {
+				byName = i
+			} else if other.LocalPath == pair.LocalPath {
+				byPath = i
+			}
+		}
+
+		result := ImportResult{Name: pair.Name}
+		switch {
+		case byName < 0 && byPath < 0:
+			result.Action = ImportAdded
+			merged = append(merged, pair)
+		case byName >= 0 && byPath < 0 && reflect.DeepEqual(merged[byName], pair):
+			result.Action = ImportUnchanged
+		case policy == ConflictFail:
+			conflicts = append(conflicts, conflictError(pair, merged, byName, byPath))
+			continue
+		case policy == ConflictSkip:
+			result.Action = ImportSkipped
+			result.Detail = conflictError(pair, merged, byName, byPath).Error()
+		case policy == ConflictReplace:
+			result.Action = ImportReplaced
+			if byPath >= 0 {
+				// The pair syncing the same folder goes, so the folder is
+				// not synced twice
+				result.Detail = fmt.Sprintf("replaces '%s'", merged[byPath].Name)
+				merged = append(merged[:byPath], merged[byPath+1:]...)
+				if byName > byPath {
+					byName--
+				}
+			}
+			if byName >= 0 {
+				merged[byName] = pair
+			} else {
+				merged = append(merged, pair)
+			}
+		case byPath >= 0 || merged[byName].LocalPath == pair.LocalPath:
+			// A new name does not stop the folder from being synced twice
+			if byPath < 0 {
+				byPath = byName
+			}
+			result.Action = ImportSkipped
+			result.Detail = conflictError(pair, merged, -1, byPath).Error()
+		default:
+			pair.Name = freeName(merged, pair.Name)
+			result.Action = ImportRenamed
+			result.Detail = pair.Name
+			merged = append(merged, pair)
+		}
+		results = append(results, result)
+	}
+
+	if len(conflicts) > 0 {
+		return nil, nil, errors.Join(conflicts...)
+	}
+	return results, merged, nil
+}
+
+// conflictError describes why pair conflicts with the configured pairs
+func conflictError(pair SyncPair, pairs []SyncPair, byName, byPath int) error {
+	if byName >= 0 {
+		return fmt.Errorf("a different sync pair named '%s' already exists", pair.Name)
+	}
+	return fmt.Errorf("local path '%s' is already synced by '%s'", pair.LocalPath, pairs[byPath].Name)
+}
+
+// freeName returns name with the first number appended that no pair uses
+func freeName(pairs []SyncPair, name string) string {
+	taken := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		taken[strings.ToLower(pair.Name)] = true
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s-%d", name, n)
+		if !taken[strings.ToLower(candidate)] {
+			return candidate
+		}
+	}
+}
@@ -1,12 +1,42 @@
 package syncconfig
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/fileformat"
+	"github.com/andreisuslov/cloud-sync/internal/filewatch"
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+	"github.com/andreisuslov/cloud-sync/internal/network"
+	"github.com/andreisuslov/cloud-sync/internal/volume"
 )
 
+// Destination is a remote location that a sync pair is pushed to
+type Destination struct {
+	RemoteName string `json:"remote_name"` // Rclone remote name
+	RemotePath string `json:"remote_path"` // Path on remote (bucket/folder)
+}
+
+// String returns the destination in rclone's remote:path form
+func (d Destination) String() string {
+	return d.RemoteName + ":" + d.RemotePath
+}
+
+// ParseDestination parses a destination written as remote:path
+func ParseDestination(s string) (Destination, error) {
+	s = strings.TrimSpace(s)
+	i := strings.Index(s, ":")
+	if i <= 0 || i == len(s)-1 {
+		return Destination{}, fmt.Errorf("invalid destination '%s', expected remote:path", s)
+	}
+	return Destination{RemoteName: s[:i], RemotePath: s[i+1:]}, nil
+}
+
 // SyncPair represents a local folder to remote sync configuration
 type SyncPair struct {
 	Name         string `json:"name"`          // User-friendly name for this sync
@@ -15,6 +45,287 @@ type SyncPair struct {
 	RemotePath   string `json:"remote_path"`   // Path on remote (bucket/folder)
 	Direction    string `json:"direction"`     // "upload", "download", or "bidirectional"
 	Enabled      bool   `json:"enabled"`       // Whether this sync is active
+
+	// Tags label related pairs, e.g. ["photos", "family"], so they can be
+	// synced together with 'cloud-sync sync --tag photos'. Group is the
+	// heading the pair is listed under; without one, its first tag is used.
+	Tags  []string `json:"tags,omitempty"`
+	Group string   `json:"group,omitempty"`
+
+	// Type is where the files go: "remote" (default) transfers them with
+	// rclone, and "local" mirrors LocalPath to TargetPath with rsync, e.g. to
+	// a folder on an external drive. Local pairs have no remote. "monitor"
+	// never transfers anything; it only checks LocalPath against the remote,
+	// or TargetPath when set, e.g. to watch a backup another tool makes.
+	Type       string `json:"type,omitempty"`
+	TargetPath string `json:"target_path,omitempty"` // Destination folder of a local or monitor pair
+
+	// SyncOnMount runs the pair whenever one of its drives in /Volumes is
+	// connected, through the agent 'cloud-sync watch-drives' installs
+	SyncOnMount bool `json:"sync_on_mount,omitempty"`
+
+	// WiFiNetworks, when set, only lets the pair sync on one of these Wi-Fi
+	// networks, and SkipOnHotspot skips it on a phone's personal hotspot, so
+	// a large upload does not use mobile data
+	WiFiNetworks  []string `json:"wifi_networks,omitempty"`
+	SkipOnHotspot bool     `json:"skip_on_hotspot,omitempty"`
+
+	// MinBatteryPercent defers scheduled runs while the Mac is on battery
+	// below this charge, until power is connected (0 = never defer)
+	MinBatteryPercent int `json:"min_battery_percent,omitempty"`
+
+	// IndexFirst lists every file of the source with rclone lsjson before
+	// each run and caches the listing, so runs over trees with millions of
+	// files know their real size up front
+	IndexFirst bool `json:"index_first,omitempty"`
+
+	// Mode is how files reach the destination: "sync" (default) mirrors the
+	// source and deletes extra files, "copy" never deletes, and "move"
+	// removes files from the source once transferred
+	Mode string `json:"mode,omitempty"`
+
+	// Soft delete moves files deleted during an upload into a dated trash
+	// folder on the remote instead of removing them
+	SoftDelete         bool `json:"soft_delete,omitempty"`
+	TrashRetentionDays int  `json:"trash_retention_days,omitempty"` // Days to keep trashed files (0 = default)
+
+	// Destinations lists extra remotes an upload pair is replicated to, in
+	// addition to RemoteName:RemotePath
+	Destinations []Destination `json:"destinations,omitempty"`
+
+	// Snapshot copies each upload run into a dated folder under the remote
+	// path instead of syncing in place
+	Snapshot           bool `json:"snapshot,omitempty"`
+	SnapshotKeep       int  `json:"snapshot_keep,omitempty"`         // Snapshots to keep (0 = no limit)
+	SnapshotMaxAgeDays int  `json:"snapshot_max_age_days,omitempty"` // Days to keep snapshots (0 = no limit)
+
+	// Archive compresses the folder into a dated .tar.zst in a staging
+	// folder and uploads that one file instead of the individual files
+	Archive     bool   `json:"archive,omitempty"`
+	ArchiveKeep int    `json:"archive_keep,omitempty"` // Archives to keep (0 = no limit)
+	StagingPath string `json:"staging_path,omitempty"` // Where archives are built (default ~/.cache/cloud-sync/staging)
+
+	// ExtraFlags are passed to rclone, or rsync for local pairs, as-is after
+	// the flags cloud-sync sets, e.g. ["--track-renames", "--transfers", "8"]
+	ExtraFlags []string `json:"extra_flags,omitempty"`
+
+	// SkipHidden, SkipSymlinks and SkipLargerThan leave hidden files such as
+	// .DS_Store, symbolic links, and files over a size such as "2G" out of
+	// every transfer, without writing filter rules
+	SkipHidden     bool   `json:"skip_hidden,omitempty"`
+	SkipSymlinks   bool   `json:"skip_symlinks,omitempty"`
+	SkipLargerThan string `json:"skip_larger_than,omitempty"`
+
+	// MaxDeletePercent stops a sync that would delete more than this share of
+	// the destination's files (0 = default, 100 = no limit)
+	MaxDeletePercent int `json:"max_delete_percent,omitempty"`
+
+	// MaxRemoteGB stops an upload that would grow a destination past this
+	// many gigabytes (10^9 bytes, as providers bill them), e.g. to stay in
+	// a free tier (0 = no budget). BudgetWarnOnly warns and syncs anyway.
+	MaxRemoteGB    float64 `json:"max_remote_gb,omitempty"`
+	BudgetWarnOnly bool    `json:"budget_warn_only,omitempty"`
+
+	// Scrub includes the pair in the integrity scrub, which compares the
+	// folder with its destinations by checksum (see 'cloud-sync scrub')
+	Scrub bool `json:"scrub,omitempty"`
+
+	// Subpath is the folder below the pair's own folder that a copy made by
+	// Subtree syncs; it is never saved
+	Subpath string `json:"-"`
+	root    string
+}
+
+// Transfer modes of a sync pair, named after the rclone commands they run
+const (
+	ModeSync = "sync"
+	ModeCopy = "copy"
+	ModeMove = "move"
+)
+
+// Pair types
+const (
+	TypeRemote  = "remote"
+	TypeLocal   = "local"
+	TypeMonitor = "monitor"
+)
+
+// PairType returns the pair's type, defaulting to remote
+func (p SyncPair) PairType() string {
+	if p.Type == "" {
+		return TypeRemote
+	}
+	return p.Type
+}
+
+// IsLocal reports whether the pair mirrors to a local folder with rsync
+func (p SyncPair) IsLocal() bool {
+	return p.PairType() == TypeLocal
+}
+
+// IsMonitor reports whether the pair only checks two locations for drift
+// and never transfers files
+func (p SyncPair) IsMonitor() bool {
+	return p.PairType() == TypeMonitor
+}
+
+// ListGroup returns the heading the pair is listed under, or "" for none
+func (p SyncPair) ListGroup() string {
+	if p.Group != "" {
+		return p.Group
+	}
+	if len(p.Tags) > 0 {
+		return p.Tags[0]
+	}
+	return ""
+}
+
+// HasTag reports whether the pair has tag as one of its tags or as its
+// group, ignoring case
+func (p SyncPair) HasTag(tag string) bool {
+	if strings.EqualFold(p.Group, tag) {
+		return true
+	}
+	for _, t := range p.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Target returns where the pair's files go, or what a monitor pair checks
+// its folder against: the target folder of a local pair, and remote:path
+// otherwise
+func (p SyncPair) Target() string {
+	if p.TargetPath != "" {
+		return p.TargetPath
+	}
+	return p.RemoteName + ":" + p.RemotePath
+}
+
+// Volumes returns the external drives the pair's folders are on, such as
+// /Volumes/Backup. A pair cannot sync while one of them is disconnected.
+func (p SyncPair) Volumes() []string {
+	paths := []string{p.LocalPath}
+	if p.TargetPath != "" {
+		paths = append(paths, p.TargetPath)
+	}
+
+	var volumes []string
+	for _, path := range paths {
+		if v, ok := volume.Of(path); ok && (len(volumes) == 0 || volumes[0] != v) {
+			volumes = append(volumes, v)
+		}
+	}
+	return volumes
+}
+
+// NetworkRules returns the networks the pair may sync on
+func (p SyncPair) NetworkRules() network.Rules {
+	return network.Rules{WiFiNetworks: p.WiFiNetworks, SkipOnHotspot: p.SkipOnHotspot}
+}
+
+// TransferMode returns the pair's mode, defaulting to sync
+func (p SyncPair) TransferMode() string {
+	if p.Mode == "" {
+		return ModeSync
+	}
+	return p.Mode
+}
+
+// SnapshotMaxAge returns how long snapshots are kept, or zero for no limit
+func (p SyncPair) SnapshotMaxAge() time.Duration {
+	return time.Duration(p.SnapshotMaxAgeDays) * 24 * time.Hour
+}
+
+// AllDestinations returns the primary destination followed by any extra
+// ones. Local pairs and monitor pairs have no remote destinations.
+func (p SyncPair) AllDestinations() []Destination {
+	if p.IsLocal() || p.IsMonitor() {
+		return nil
+	}
+	dests := make([]Destination, 0, 1+len(p.Destinations))
+	dests = append(dests, Destination{RemoteName: p.RemoteName, RemotePath: p.RemotePath})
+	return append(dests, p.Destinations...)
+}
+
+// Subtree returns a copy of the pair that syncs only the folder subpath,
+// relative to LocalPath, to the same place below the target and each
+// destination. Archive and snapshot pairs always sync their whole folder.
+func (p SyncPair) Subtree(subpath string) (SyncPair, error) {
+	clean, err := CleanSubpath(subpath)
+	if err != nil {
+		return SyncPair{}, err
+	}
+	if p.Archive || p.Snapshot {
+		return SyncPair{}, fmt.Errorf("sync pair '%s' uploads its whole folder as an archive or snapshot; sync it without a subpath", p.Name)
+	}
+
+	slashed := filepath.ToSlash(clean)
+	sub := p
+	sub.Subpath = clean
+	sub.root = p.LocalPath
+	sub.LocalPath = filepath.Join(p.LocalPath, clean)
+	if p.TargetPath != "" {
+		sub.TargetPath = filepath.Join(p.TargetPath, clean)
+	} else {
+		sub.RemotePath = path.Join(p.RemotePath, slashed)
+	}
+	sub.Destinations = nil
+	for _, dest := range p.Destinations {
+		sub.Destinations = append(sub.Destinations, Destination{RemoteName: dest.RemoteName, RemotePath: path.Join(dest.RemotePath, slashed)})
+	}
+	return sub, nil
+}
+
+// Root returns the pair's own folder, which differs from LocalPath for a
+// copy made by Subtree
+func (p SyncPair) Root() string {
+	if p.Subpath == "" {
+		return p.LocalPath
+	}
+	return p.root
+}
+
+// CleanSubpath cleans a folder given relative to a pair's folder and
+// rejects one that is absolute or leads out of it
+func CleanSubpath(subpath string) (string, error) {
+	clean := filepath.Clean(strings.TrimSpace(subpath))
+	if clean == "." || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid subpath '%s': expected a folder inside the pair, e.g. projects/app", subpath)
+	}
+	return clean, nil
+}
+
+// DefaultTrashRetentionDays is used when a pair does not set its own retention
+const DefaultTrashRetentionDays = 30
+
+// TrashRetention returns how long soft-deleted files are kept before purging
+func (p SyncPair) TrashRetention() time.Duration {
+	days := p.TrashRetentionDays
+	if days <= 0 {
+		days = DefaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// SizeBudget returns the most bytes a destination of the pair may hold
+// after a sync, or 0 when the pair has no budget
+func (p SyncPair) SizeBudget() int64 {
+	return int64(p.MaxRemoteGB * 1e9)
+}
+
+// DefaultMaxDeletePercent is used when a pair does not set its own delete limit
+const DefaultMaxDeletePercent = 50
+
+// DeleteLimit returns the largest share of the destination's files, in
+// percent, one sync may delete. 100 means there is no limit.
+func (p SyncPair) DeleteLimit() int {
+	if p.MaxDeletePercent <= 0 {
+		return DefaultMaxDeletePercent
+	}
+	return p.MaxDeletePercent
 }
 
 // Config holds all sync configurations
@@ -42,12 +353,19 @@ func NewDefaultManager() (*Manager, error) {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 	
-	configPath := filepath.Join(homeDir, ".config", "cloud-sync", "sync-config.json")
 	return &Manager{
-		configPath: configPath,
+		configPath: DefaultPath(homeDir),
 	}, nil
 }
 
+// DefaultPath returns the path of the sync config file of the user whose
+// home is homeDir: sync-config.json, or its YAML or TOML version if that is
+// the one that exists. A new file is created in the format of config.json.
+func DefaultPath(homeDir string) string {
+	dir := filepath.Join(homeDir, ".config", "cloud-sync")
+	return fileformat.Find(dir, "sync-config", fileformat.Of(fileformat.Find(dir, "config", fileformat.JSON)))
+}
+
 // GetConfigPath returns the configuration file path
 func (m *Manager) GetConfigPath() string {
 	return m.configPath
@@ -59,6 +377,12 @@ func (m *Manager) ConfigExists() bool {
 	return err == nil
 }
 
+// Watch returns a watcher that reports changes to the sync config file,
+// including edits made by other programs
+func (m *Manager) Watch() *filewatch.Watcher {
+	return filewatch.NewWatcher(m.configPath)
+}
+
 // Load loads the sync configuration from file
 func (m *Manager) Load() (*Config, error) {
 	if !m.ConfigExists() {
@@ -75,115 +399,127 @@ func (m *Manager) Load() (*Config, error) {
 	}
 
 	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := jsonfile.Unmarshal(m.configPath, data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", jsonfile.NewCorruptError(m.configPath, err))
 	}
 
 	return &config, nil
 }
 
-// Save saves the sync configuration to file
+// Save saves the sync configuration to file, replacing it in one step and
+// keeping the previous version as a .bak file
 func (m *Manager) Save(config *Config) error {
-	// Ensure directory exists
-	configDir := filepath.Dir(m.configPath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
-
 	// Set version if not set
 	if config.Version == "" {
 		config.Version = "1.0"
 	}
 
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
-	}
+	return jsonfile.Write(m.configPath, config, 0644)
+}
 
-	if err := os.WriteFile(m.configPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
-	}
+// RestoreBackup replaces the sync config file with the version saved before
+// the last change
+func (m *Manager) RestoreBackup() error {
+	return jsonfile.Restore(m.configPath)
+}
 
-	return nil
+// Lock takes the lock on the sync config file that update holds, for
+// callers that change it together with other files. It returns the
+// function releasing it.
+func (m *Manager) Lock() (func(), error) {
+	return jsonfile.Lock(m.configPath)
 }
 
-// AddSyncPair adds a new sync pair to the configuration
-func (m *Manager) AddSyncPair(pair SyncPair) error {
+// update loads the sync configuration, applies change and saves the result
+// while holding the file's lock, so a scheduled run and the TUI cannot drop
+// each other's changes
+func (m *Manager) update(change func(*Config) error) error {
+	unlock, err := m.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	config, err := m.Load()
 	if err != nil {
 		return err
 	}
+	if err := change(config); err != nil {
+		return err
+	}
+	return m.Save(config)
+}
 
+// AddSyncPair adds a new sync pair to the configuration
+func (m *Manager) AddSyncPair(pair SyncPair) error {
 	// Validate the sync pair
 	if err := ValidateSyncPair(&pair); err != nil {
 		return err
 	}
 
-	// Check for duplicates
-	for _, existing := range config.SyncPairs {
-		if existing.Name == pair.Name {
-			return fmt.Errorf("sync pair with name '%s' already exists", pair.Name)
+	return m.update(func(config *Config) error {
+		// Check for duplicates
+		for _, existing := range config.SyncPairs {
+			if existing.Name == pair.Name {
+				return fmt.Errorf("sync pair with name '%s' already exists", pair.Name)
+			}
+			if existing.LocalPath == pair.LocalPath {
+				return fmt.Errorf("local path '%s' is already configured", pair.LocalPath)
+			}
 		}
-		if existing.LocalPath == pair.LocalPath {
-			return fmt.Errorf("local path '%s' is already configured", pair.LocalPath)
-		}
-	}
 
-	config.SyncPairs = append(config.SyncPairs, pair)
-	return m.Save(config)
+		config.SyncPairs = append(config.SyncPairs, pair)
+		return nil
+	})
 }
 
 // RemoveSyncPair removes a sync pair by name
 func (m *Manager) RemoveSyncPair(name string) error {
-	config, err := m.Load()
-	if err != nil {
-		return err
-	}
-
-	found := false
-	newPairs := make([]SyncPair, 0, len(config.SyncPairs))
-	for _, pair := range config.SyncPairs {
-		if pair.Name != name {
-			newPairs = append(newPairs, pair)
-		} else {
-			found = true
+	return m.update(func(config *Config) error {
+		found := false
+		newPairs := make([]SyncPair, 0, len(config.SyncPairs))
+		for _, pair := range config.SyncPairs {
+			if pair.Name != name {
+				newPairs = append(newPairs, pair)
+			} else {
+				found = true
+			}
 		}
-	}
 
-	if !found {
-		return fmt.Errorf("sync pair '%s' not found", name)
-	}
+		if !found {
+			return &PairNotFoundError{Name: name}
+		}
 
-	config.SyncPairs = newPairs
-	return m.Save(config)
+		config.SyncPairs = newPairs
+		return nil
+	})
 }
 
 // UpdateSyncPair updates an existing sync pair
 func (m *Manager) UpdateSyncPair(name string, updatedPair SyncPair) error {
-	config, err := m.Load()
-	if err != nil {
-		return err
-	}
-
 	// Validate the updated sync pair
 	if err := ValidateSyncPair(&updatedPair); err != nil {
 		return err
 	}
 
-	found := false
-	for i, pair := range config.SyncPairs {
-		if pair.Name == name {
-			config.SyncPairs[i] = updatedPair
-			found = true
-			break
+	return m.update(func(config *Config) error {
+		for i, pair := range config.SyncPairs {
+			if pair.Name == name {
+				config.SyncPairs[i] = updatedPair
+				return nil
+			}
 		}
-	}
+		return &PairNotFoundError{Name: name}
+	})
+}
 
-	if !found {
-		return fmt.Errorf("sync pair '%s' not found", name)
-	}
+// PairNotFoundError is returned for a sync pair that is not configured
+type PairNotFoundError struct {
+	Name string
+}
 
-	return m.Save(config)
+func (e *PairNotFoundError) Error() string {
+	return fmt.Sprintf("sync pair '%s' not found", e.Name)
 }
 
 // GetSyncPair retrieves a sync pair by name
@@ -199,7 +535,7 @@ func (m *Manager) GetSyncPair(name string) (*SyncPair, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("sync pair '%s' not found", name)
+	return nil, &PairNotFoundError{Name: name}
 }
 
 // ListSyncPairs returns all sync pairs
@@ -231,25 +567,15 @@ func (m *Manager) ListEnabledSyncPairs() ([]SyncPair, error) {
 
 // ToggleEnabled toggles the enabled status of a sync pair
 func (m *Manager) ToggleEnabled(name string) error {
-	config, err := m.Load()
-	if err != nil {
-		return err
-	}
-
-	found := false
-	for i, pair := range config.SyncPairs {
-		if pair.Name == name {
-			config.SyncPairs[i].Enabled = !pair.Enabled
-			found = true
-			break
+	return m.update(func(config *Config) error {
+		for i, pair := range config.SyncPairs {
+			if pair.Name == name {
+				config.SyncPairs[i].Enabled = !pair.Enabled
+				return nil
+			}
 		}
-	}
-
-	if !found {
-		return fmt.Errorf("sync pair '%s' not found", name)
-	}
-
-	return m.Save(config)
+		return &PairNotFoundError{Name: name}
+	})
 }
 
 // ValidateSyncPair validates a sync pair configuration
@@ -262,21 +588,37 @@ func ValidateSyncPair(pair *SyncPair) error {
 		return fmt.Errorf("local path cannot be empty")
 	}
 
-	// Expand home directory if needed
-	if pair.LocalPath[0] == '~' {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to expand home directory: %w", err)
-		}
-		pair.LocalPath = filepath.Join(homeDir, pair.LocalPath[1:])
-	}
-
-	// Convert to absolute path
-	absPath, err := filepath.Abs(pair.LocalPath)
+	localPath, err := absPath(pair.LocalPath)
 	if err != nil {
 		return fmt.Errorf("invalid local path: %w", err)
 	}
-	pair.LocalPath = absPath
+	pair.LocalPath = localPath
+
+	if err := validateTags(pair); err != nil {
+		return err
+	}
+
+	if err := validateSkips(pair); err != nil {
+		return err
+	}
+
+	if err := validateRemotePaths(pair); err != nil {
+		return err
+	}
+
+	switch pair.PairType() {
+	case TypeRemote:
+	case TypeLocal:
+		return validateLocalPair(pair)
+	case TypeMonitor:
+		return validateMonitorPair(pair)
+	default:
+		return fmt.Errorf("invalid type '%s', must be 'remote', 'local' or 'monitor'", pair.Type)
+	}
+
+	if pair.TargetPath != "" {
+		return fmt.Errorf("target path is only used by local pairs")
+	}
 
 	if pair.RemoteName == "" {
 		return fmt.Errorf("remote name cannot be empty")
@@ -296,9 +638,405 @@ func ValidateSyncPair(pair *SyncPair) error {
 		return fmt.Errorf("invalid direction '%s', must be 'upload', 'download', or 'bidirectional'", pair.Direction)
 	}
 
+	switch pair.TransferMode() {
+	case ModeSync, ModeCopy:
+	case ModeMove:
+		if pair.Direction == "bidirectional" {
+			return fmt.Errorf("move mode is not supported for bidirectional pairs")
+		}
+		if pair.Snapshot {
+			return fmt.Errorf("snapshot mode and move mode cannot be combined")
+		}
+		// The first destination would leave nothing to send to the others
+		if len(pair.Destinations) > 0 {
+			return fmt.Errorf("move mode cannot be combined with multiple destinations")
+		}
+	default:
+		return fmt.Errorf("invalid mode '%s', must be 'sync', 'copy', or 'move'", pair.Mode)
+	}
+
+	// Only sync deletes files from the destination
+	if pair.SoftDelete && pair.TransferMode() != ModeSync {
+		return fmt.Errorf("soft delete is only supported in sync mode")
+	}
+
+	if pair.TrashRetentionDays < 0 {
+		return fmt.Errorf("trash retention days cannot be negative")
+	}
+
+	if pair.Snapshot {
+		if pair.Direction != "upload" {
+			return fmt.Errorf("snapshot mode is only supported for upload pairs")
+		}
+		if pair.SoftDelete {
+			return fmt.Errorf("snapshot mode and soft delete cannot be combined")
+		}
+	}
+
+	if pair.SnapshotKeep < 0 || pair.SnapshotMaxAgeDays < 0 {
+		return fmt.Errorf("snapshot retention cannot be negative")
+	}
+
+	if err := validateArchive(pair); err != nil {
+		return err
+	}
+
+	// Their destinations hold dated copies, not a mirror of the folder
+	if pair.Scrub && (pair.Snapshot || pair.Archive) {
+		return fmt.Errorf("the integrity scrub is not supported for snapshot or archive pairs")
+	}
+
+	if pair.MaxDeletePercent < 0 || pair.MaxDeletePercent > 100 {
+		return fmt.Errorf("max delete percent must be between 0 and 100")
+	}
+
+	if err := validateBudget(pair); err != nil {
+		return err
+	}
+
+	if len(pair.Destinations) > 0 && pair.Direction != "upload" {
+		return fmt.Errorf("multiple destinations are only supported for upload pairs")
+	}
+
+	if err := ValidateExtraFlags(pair.ExtraFlags); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, dest := range pair.AllDestinations() {
+		if dest.RemoteName == "" || dest.RemotePath == "" {
+			return fmt.Errorf("destination remote name and path cannot be empty")
+		}
+		if seen[dest.String()] {
+			return fmt.Errorf("destination '%s' is listed more than once", dest)
+		}
+		seen[dest.String()] = true
+	}
+
+	if pair.MinBatteryPercent < 0 || pair.MinBatteryPercent > 100 {
+		return fmt.Errorf("min battery percent must be between 0 and 100")
+	}
+
+	for _, ssid := range pair.WiFiNetworks {
+		if strings.TrimSpace(ssid) == "" {
+			return fmt.Errorf("Wi-Fi network names cannot be empty")
+		}
+	}
+
+	return validateSyncOnMount(pair)
+}
+
+// validateTags trims the pair's tags and group and checks that each tag is
+// a usable, distinct name
+func validateTags(pair *SyncPair) error {
+	pair.Group = strings.TrimSpace(pair.Group)
+	if strings.Contains(pair.Group, ",") {
+		return fmt.Errorf("group '%s' cannot contain a comma", pair.Group)
+	}
+
+	seen := make(map[string]bool)
+	for i, tag := range pair.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return fmt.Errorf("tags cannot be empty")
+		}
+		if strings.Contains(tag, ",") {
+			return fmt.Errorf("tag '%s' cannot contain a comma", tag)
+		}
+		if seen[strings.ToLower(tag)] {
+			return fmt.Errorf("tag '%s' is listed more than once", tag)
+		}
+		seen[strings.ToLower(tag)] = true
+		pair.Tags[i] = tag
+	}
+	return nil
+}
+
+// validateArchive checks the settings of a pair uploading its folder as
+// one archive
+func validateArchive(pair *SyncPair) error {
+	if pair.ArchiveKeep < 0 {
+		return fmt.Errorf("archive retention cannot be negative")
+	}
+	if !pair.Archive {
+		if pair.StagingPath != "" {
+			return fmt.Errorf("staging path is only used in archive mode")
+		}
+		return nil
+	}
+
+	if pair.Direction != "upload" {
+		return fmt.Errorf("archive mode is only supported for upload pairs")
+	}
+	if pair.Snapshot || pair.SoftDelete {
+		return fmt.Errorf("archive mode cannot be combined with snapshot mode or soft delete")
+	}
+	if pair.TransferMode() == ModeMove {
+		return fmt.Errorf("archive mode and move mode cannot be combined")
+	}
+
+	if pair.StagingPath != "" {
+		stagingPath, err := absPath(pair.StagingPath)
+		if err != nil {
+			return fmt.Errorf("invalid staging path: %w", err)
+		}
+		pair.StagingPath = stagingPath
+		// The archive would end up archiving itself
+		if within(pair.StagingPath, pair.LocalPath) {
+			return fmt.Errorf("staging path cannot be inside the local path")
+		}
+	}
+	return nil
+}
+
+// skipSizePattern matches the sizes both rclone and rsync read, e.g. "500M"
+// or "1.5G"
+var skipSizePattern = regexp.MustCompile(`^\d+(\.\d+)?[KMGT]$`)
+
+// ParseSkipSize checks a size to skip files larger than and returns it in
+// the form rclone and rsync read, e.g. "1.5g" as "1.5G"
+func ParseSkipSize(s string) (string, error) {
+	size := strings.ToUpper(strings.TrimSpace(s))
+	if !skipSizePattern.MatchString(size) {
+		return "", fmt.Errorf("'%s' is not a size like 500M or 2G", s)
+	}
+	return size, nil
+}
+
+// validateSkips checks the kinds of file a pair leaves out
+func validateSkips(pair *SyncPair) error {
+	if pair.SkipLargerThan != "" {
+		size, err := ParseSkipSize(pair.SkipLargerThan)
+		if err != nil {
+			return fmt.Errorf("invalid size to skip files larger than: %w", err)
+		}
+		pair.SkipLargerThan = size
+	}
+	// The archive holds the whole folder
+	if pair.Archive && (pair.SkipHidden || pair.SkipSymlinks || pair.SkipLargerThan != "") {
+		return fmt.Errorf("skipping files is not supported in archive mode")
+	}
 	return nil
 }
 
+// validateBudget checks the size budget of a pair, which only uploads in
+// place can keep to
+func validateBudget(pair *SyncPair) error {
+	if pair.MaxRemoteGB < 0 {
+		return fmt.Errorf("max remote GB cannot be negative")
+	}
+	if pair.MaxRemoteGB == 0 {
+		return nil
+	}
+	if pair.Direction == "download" {
+		return fmt.Errorf("a size budget is only supported for upload and bidirectional pairs")
+	}
+	// Their uploads add dated copies rather than change a mirror
+	if pair.Snapshot || pair.Archive {
+		return fmt.Errorf("a size budget is not supported for snapshot or archive pairs")
+	}
+	return nil
+}
+
+// validateSyncOnMount checks that a pair synced when a drive is connected
+// has a folder on one
+func validateSyncOnMount(pair *SyncPair) error {
+	if pair.SyncOnMount && len(pair.Volumes()) == 0 {
+		return fmt.Errorf("syncing on mount needs a folder on a drive in %s", volume.Root)
+	}
+	return nil
+}
+
+// validateLocalPair checks the settings of a local pair, which mirrors its
+// folder to another one with rsync
+func validateLocalPair(pair *SyncPair) error {
+	if pair.TargetPath == "" {
+		return fmt.Errorf("target path cannot be empty")
+	}
+	targetPath, err := absPath(pair.TargetPath)
+	if err != nil {
+		return fmt.Errorf("invalid target path: %w", err)
+	}
+	pair.TargetPath = targetPath
+
+	// rsync would copy the target into itself, or mirror over the source
+	if within(pair.TargetPath, pair.LocalPath) || within(pair.LocalPath, pair.TargetPath) {
+		return fmt.Errorf("local path and target path cannot contain each other")
+	}
+
+	if pair.RemoteName != "" || pair.RemotePath != "" || len(pair.Destinations) > 0 {
+		return fmt.Errorf("local pairs cannot have a remote")
+	}
+
+	if pair.Direction != "upload" {
+		return fmt.Errorf("local pairs only support the 'upload' direction")
+	}
+
+	switch pair.TransferMode() {
+	case ModeSync, ModeCopy, ModeMove:
+	default:
+		return fmt.Errorf("invalid mode '%s', must be 'sync', 'copy', or 'move'", pair.Mode)
+	}
+
+	if pair.Snapshot || pair.SoftDelete {
+		return fmt.Errorf("snapshot mode and soft delete are not supported for local pairs")
+	}
+
+	if pair.Archive || pair.StagingPath != "" {
+		return fmt.Errorf("archive mode is not supported for local pairs")
+	}
+
+	if len(pair.WiFiNetworks) > 0 || pair.SkipOnHotspot {
+		return fmt.Errorf("network rules are not supported for local pairs")
+	}
+
+	if pair.IndexFirst {
+		return fmt.Errorf("indexing first is not supported for local pairs")
+	}
+
+	if pair.MaxRemoteGB != 0 {
+		return fmt.Errorf("a size budget is not supported for local pairs")
+	}
+
+	if pair.MinBatteryPercent < 0 || pair.MinBatteryPercent > 100 {
+		return fmt.Errorf("min battery percent must be between 0 and 100")
+	}
+
+	if pair.MaxDeletePercent < 0 || pair.MaxDeletePercent > 100 {
+		return fmt.Errorf("max delete percent must be between 0 and 100")
+	}
+
+	if err := ValidateRsyncFlags(pair.ExtraFlags); err != nil {
+		return err
+	}
+	return validateSyncOnMount(pair)
+}
+
+// validateMonitorPair checks the settings of a monitor pair, which compares
+// its folder with a remote or another folder and never transfers files
+func validateMonitorPair(pair *SyncPair) error {
+	if pair.TargetPath != "" {
+		targetPath, err := absPath(pair.TargetPath)
+		if err != nil {
+			return fmt.Errorf("invalid target path: %w", err)
+		}
+		pair.TargetPath = targetPath
+		if within(pair.TargetPath, pair.LocalPath) || within(pair.LocalPath, pair.TargetPath) {
+			return fmt.Errorf("local path and target path cannot contain each other")
+		}
+		if pair.RemoteName != "" || pair.RemotePath != "" {
+			return fmt.Errorf("a monitor pair checks either a remote or a target path, not both")
+		}
+	} else if pair.RemoteName == "" || pair.RemotePath == "" {
+		return fmt.Errorf("a monitor pair needs a remote name and path, or a target path")
+	}
+
+	if pair.Direction != "" || pair.Mode != "" {
+		return fmt.Errorf("monitor pairs never transfer files, so they have no direction or mode")
+	}
+	if len(pair.Destinations) > 0 || pair.Snapshot || pair.SoftDelete || pair.Archive || pair.StagingPath != "" || pair.MaxRemoteGB != 0 {
+		return fmt.Errorf("monitor pairs never transfer files, so they cannot have destinations, snapshots, soft delete, archives or a size budget")
+	}
+	if pair.SyncOnMount || pair.MinBatteryPercent != 0 || len(pair.WiFiNetworks) > 0 || pair.SkipOnHotspot || pair.IndexFirst || len(pair.ExtraFlags) > 0 {
+		return fmt.Errorf("monitor pairs are only checked, so transfer settings such as sync on mount, network rules or extra flags do not apply")
+	}
+	return nil
+}
+
+// absPath expands a leading ~ and makes path absolute
+func absPath(path string) (string, error) {
+	if path[0] == '~' {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, path[1:])
+	}
+	return filepath.Abs(path)
+}
+
+// within reports whether path is dir or inside it
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// managedFlags are set by cloud-sync itself and cannot be passed as extra flags
+var managedFlags = []string{"--config", "--log-file", "--backup-dir", "--compare-dest", "--dry-run"}
+
+// managedRsyncFlags are set by cloud-sync for local pairs; the pair's mode
+// decides whether files are deleted
+var managedRsyncFlags = []string{"--log-file", "--log-file-format", "--dry-run", "--delete", "--remove-source-files"}
+
+// ValidateExtraFlags checks the extra rclone flags of a sync pair. The list
+// must start with a flag, and flags cloud-sync sets itself are rejected.
+func ValidateExtraFlags(flags []string) error {
+	return validateFlags(flags, managedFlags)
+}
+
+// ValidateRsyncFlags checks the extra rsync flags of a local pair like
+// ValidateExtraFlags
+func ValidateRsyncFlags(flags []string) error {
+	return validateFlags(flags, managedRsyncFlags)
+}
+
+// validateFlags checks extra flags against the flags cloud-sync manages
+func validateFlags(flags, managed []string) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	if !strings.HasPrefix(flags[0], "-") {
+		return fmt.Errorf("extra flags must start with a flag, got '%s'", flags[0])
+	}
+	for _, flag := range flags {
+		name, _, _ := strings.Cut(flag, "=")
+		for _, m := range managed {
+			if name == m {
+				return fmt.Errorf("flag '%s' is set by cloud-sync and cannot be overridden", m)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseExtraFlags splits a command line of rclone flags into arguments.
+// Single or double quotes group values containing spaces.
+func ParseExtraFlags(s string) ([]string, error) {
+	var (
+		flags   []string
+		current strings.Builder
+		quote   rune
+		inArg   bool
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				flags = append(flags, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in flags")
+	}
+	if inArg {
+		flags = append(flags, current.String())
+	}
+	return flags, nil
+}
+
 // ValidateLocalPath checks if a local path exists and is accessible
 func ValidateLocalPath(path string) error {
 	info, err := os.Stat(path)
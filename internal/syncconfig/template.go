@@ -0,0 +1,104 @@
+package syncconfig
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+)
+
+// Variables a remote path can hold, e.g. "backups/{hostname}/docs". They
+// are expanded each time the pair runs, so one configuration deployed to
+// several Macs backs each of them up into a prefix of its own.
+const (
+	VarHostname = "{hostname}" // The Mac's name, without .local
+	VarUser     = "{user}"     // The user running the sync
+	VarDate     = "{date}"     // The day of the run, as YYYY-MM-DD
+)
+
+// pathVariablePattern matches a variable in a remote path
+var pathVariablePattern = regexp.MustCompile(`\{[^{}/]*\}`)
+
+// PathVars are the values the variables of a remote path expand to
+type PathVars struct {
+	Hostname string
+	User     string
+	Date     time.Time
+}
+
+// CurrentPathVars returns the values for a run on this Mac, by this user,
+// now
+func CurrentPathVars() PathVars {
+	hostname, _ := os.Hostname()
+	hostname, _, _ = strings.Cut(hostname, ".")
+	return PathVars{Hostname: hostname, User: launchd.CurrentUsername(), Date: time.Now()}
+}
+
+// HasPathVariables reports whether a remote path holds variables
+func HasPathVariables(remotePath string) bool {
+	return pathVariablePattern.MatchString(remotePath)
+}
+
+// ValidatePathVariables rejects variables other than {hostname}, {user}
+// and {date}
+func ValidatePathVariables(remotePath string) error {
+	for _, variable := range pathVariablePattern.FindAllString(remotePath, -1) {
+		switch variable {
+		case VarHostname, VarUser, VarDate:
+		default:
+			return fmt.Errorf("unknown variable %s in remote path '%s'; use %s, %s or %s", variable, remotePath, VarHostname, VarUser, VarDate)
+		}
+	}
+	return nil
+}
+
+// ExpandRemotePath replaces the variables in a remote path with their
+// values
+func ExpandRemotePath(remotePath string, vars PathVars) string {
+	return strings.NewReplacer(
+		VarHostname, vars.Hostname,
+		VarUser, vars.User,
+		VarDate, vars.Date.Format("2006-01-02"),
+	).Replace(remotePath)
+}
+
+// StaticPrefix returns the folders of a remote path before the first one
+// holding a variable, which every machine and day shares, e.g. "backups"
+// for "backups/{hostname}/docs"
+func StaticPrefix(remotePath string) string {
+	parts := strings.Split(remotePath, "/")
+	for i, part := range parts {
+		if HasPathVariables(part) {
+			return strings.Join(parts[:i], "/")
+		}
+	}
+	return remotePath
+}
+
+// Expand returns a copy of the pair whose remote paths have their
+// variables replaced, as the pair runs with vars
+func (p SyncPair) Expand(vars PathVars) SyncPair {
+	expanded := p
+	expanded.RemotePath = ExpandRemotePath(p.RemotePath, vars)
+	expanded.Destinations = nil
+	for _, dest := range p.Destinations {
+		expanded.Destinations = append(expanded.Destinations, Destination{RemoteName: dest.RemoteName, RemotePath: ExpandRemotePath(dest.RemotePath, vars)})
+	}
+	return expanded
+}
+
+// validateRemotePaths checks the variables in the pair's remote paths
+func validateRemotePaths(pair *SyncPair) error {
+	if err := ValidatePathVariables(pair.RemotePath); err != nil {
+		return err
+	}
+	for _, dest := range pair.Destinations {
+		if err := ValidatePathVariables(dest.RemotePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
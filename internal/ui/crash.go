@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/crash"
+)
+
+// guardedModel runs a model so that a panic in it, or in a command it
+// returns, writes a crash report and quits the program cleanly
+type guardedModel struct {
+	model    tea.Model
+	reporter *crash.Reporter
+}
+
+// Guard wraps model so its panics are reported by reporter. The messages it
+// receives are recorded as the report's recent events.
+func Guard(model tea.Model, reporter *crash.Reporter) tea.Model {
+	return guardedModel{model: model, reporter: reporter}
+}
+
+// Init implements tea.Model
+func (g guardedModel) Init() (cmd tea.Cmd) {
+	defer g.recover(func() { cmd = tea.Quit })
+	return g.guard(g.model.Init())
+}
+
+// Update implements tea.Model
+func (g guardedModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	// The report of a crash in View is on screen; any message quits
+	if g.reporter.Crashed() {
+		return g, tea.Quit
+	}
+	g.reporter.Record(describeMsg(msg))
+
+	defer g.recover(func() { model, cmd = g, tea.Quit })
+	model, cmd = g.model.Update(msg)
+	return guardedModel{model: model, reporter: g.reporter}, g.guard(cmd)
+}
+
+// View implements tea.Model
+func (g guardedModel) View() (view string) {
+	defer g.recover(func() {
+		view = g.reporter.Message() + "\nPress any key to quit.\n"
+	})
+	return g.model.View()
+}
+
+// recover reports a panic and then runs after, which sets what the
+// panicking method returns
+func (g guardedModel) recover(after func()) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	g.reporter.Report(r, debug.Stack())
+	after()
+}
+
+// guard wraps cmd, and the commands of a batch it returns, so a panic in
+// them is reported and quits the program
+func (g guardedModel) guard(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer g.recover(func() { msg = tea.QuitMsg{} })
+		msg = cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			guarded := make(tea.BatchMsg, len(batch))
+			for i, cmd := range batch {
+				guarded[i] = g.guard(cmd)
+			}
+			return guarded
+		}
+		return msg
+	}
+}
+
+// describeMsg names a message for the recent events of a crash report.
+// Typed text is left out, since forms take keys and passwords.
+func describeMsg(msg tea.Msg) string {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			return "key: (text)"
+		}
+		return "key: " + msg.String()
+	case tea.MouseMsg:
+		return "mouse: " + msg.String()
+	case tea.WindowSizeMsg:
+		return fmt.Sprintf("resize: %dx%d", msg.Width, msg.Height)
+	default:
+		return fmt.Sprintf("%T", msg)
+	}
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/debuglog"
+)
+
+// Flags that start the TUI with its debug log on
+const (
+	DebugFlag   = "--debug" // Every message, at debuglog.Debug
+	VerboseFlag = "-v"      // Keys, mouse and resizes, at debuglog.Info
+)
+
+// DebugToggleKey turns the debug log on and off while the TUI runs
+const DebugToggleKey = "ctrl+g"
+
+// TakeDebugFlag removes DebugFlag and VerboseFlag from args, wherever they
+// appear, and returns the level they ask for: debuglog.Off when neither is
+// given, and the higher level when both are
+func TakeDebugFlag(args []string) ([]string, debuglog.Level) {
+	rest := make([]string, 0, len(args))
+	level := debuglog.Off
+	for _, arg := range args {
+		switch arg {
+		case DebugFlag:
+			level = debuglog.Debug
+		case VerboseFlag:
+			level = max(level, debuglog.Info)
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, level
+}
+
+// DebugModel logs the messages the main model receives, and how they move
+// the menu, to a debug log. DebugToggleKey turns the log on and off.
+type DebugModel struct {
+	Model   Model
+	log     *debuglog.Logger
+	on      debuglog.Level // Level the toggle turns the log on at
+	updates int
+}
+
+// NewDebugModel wraps model so it logs to log, at the logger's level
+func NewDebugModel(model Model, log *debuglog.Logger) DebugModel {
+	on := log.Level()
+	if on == debuglog.Off {
+		on = debuglog.Debug
+	}
+	return DebugModel{Model: model, log: log, on: on}
+}
+
+// Init implements tea.Model
+func (m DebugModel) Init() tea.Cmd {
+	m.log.Printf(debuglog.Info, "=== Session started, logging at %s ===", m.log.Level())
+	return m.Model.Init()
+}
+
+// Update implements tea.Model
+func (m DebugModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m.updates++
+
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == DebugToggleKey {
+		if m.log.Level() == debuglog.Off {
+			m.log.SetLevel(m.on)
+			m.log.Printf(debuglog.Info, "=== Logging turned on at %s ===", m.on)
+			m.Model.Message = fmt.Sprintf("Debug log on (%s): %s", m.on, m.log.Path())
+		} else {
+			m.log.Printf(debuglog.Info, "=== Logging turned off ===")
+			m.log.SetLevel(debuglog.Off)
+			m.Model.Message = "Debug log off"
+		}
+		m.Model.ShowMessage = true
+		return m, nil
+	}
+
+	inMenu := m.Model.State == StateMainMenu
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// Typed text is left out, since forms take keys and passwords
+		key := msg.String()
+		if msg.Type == tea.KeyRunes && m.Model.ActiveView() != nil {
+			key = "(text)"
+		}
+		m.log.Printf(debuglog.Info, "[Update #%d] KeyMsg: %q (Type: %d)", m.updates, key, msg.Type)
+		if inMenu {
+			m.log.Printf(debuglog.Info, "  List Index Before: %d, Total Items: %d", m.Model.List.Index(), len(m.Model.List.Items()))
+		}
+	case tea.WindowSizeMsg:
+		m.log.Printf(debuglog.Info, "[Update #%d] WindowSizeMsg: %dx%d", m.updates, msg.Width, msg.Height)
+	case tea.MouseMsg:
+		m.log.Printf(debuglog.Info, "[Update #%d] MouseMsg: Type=%d, X=%d, Y=%d", m.updates, msg.Type, msg.X, msg.Y)
+	default:
+		m.log.Printf(debuglog.Debug, "[Update #%d] %T", m.updates, msg)
+	}
+
+	model, cmd := m.Model.Update(msg)
+	m.Model = model.(Model)
+
+	if _, ok := msg.(tea.KeyMsg); ok && inMenu && m.Model.State == StateMainMenu {
+		m.log.Printf(debuglog.Info, "  List Index After: %d", m.Model.List.Index())
+	}
+	if m.Model.Quitting {
+		m.log.Printf(debuglog.Info, "=== Session ended ===")
+	}
+	return m, cmd
+}
+
+// View implements tea.Model
+func (m DebugModel) View() string {
+	view := m.Model.View()
+	if m.updates%10 == 0 {
+		m.log.Printf(debuglog.Debug, "[View #%d] Generated view, length: %d bytes", m.updates, len(view))
+	}
+	return view
+}
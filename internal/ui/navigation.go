@@ -0,0 +1,89 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// The open sub-views form a stack above the main menu. Menu items push the
+// first view and views open further views with views.OpenViewCmd. Views
+// close themselves with views.BackCmd, or views.DoneCmd when they finished a
+// task, which pops one level: to the parent view, or to the main menu from
+// the first one.
+
+// ActiveView returns the innermost open sub-view, or nil on the main menu
+func (m Model) ActiveView() tea.Model {
+	if len(m.stack) == 0 {
+		return nil
+	}
+	return m.stack[len(m.stack)-1]
+}
+
+// Depth returns the number of open sub-views
+func (m Model) Depth() int {
+	return len(m.stack)
+}
+
+// openView pushes a sub-view and starts it at the current window size
+func (m Model) openView(state AppState, view tea.Model) (Model, tea.Cmd) {
+	m.State = state
+	// Copy so models returned earlier keep their own stack
+	m.stack = append(m.stack[:len(m.stack):len(m.stack)], view)
+	return m, tea.Batch(view.Init(), m.sizeCmd())
+}
+
+// back pops one level
+func (m Model) back() (Model, tea.Cmd) {
+	if len(m.stack) == 0 {
+		return m, nil
+	}
+
+	m.stack = m.stack[:len(m.stack)-1]
+	if len(m.stack) == 0 {
+		m.State = StateMainMenu
+		return m, m.refreshDashboard()
+	}
+	// The parent may have been resized while it was hidden
+	return m, m.sizeCmd()
+}
+
+// done pops one level and passes the result to the parent view, or shows it
+// on the main menu
+func (m Model) done(msg views.DoneMsg) (Model, tea.Cmd) {
+	m, cmd := m.back()
+	if m.ActiveView() == nil {
+		m.Message = msg.Message
+		m.ShowMessage = msg.Message != ""
+		return m, cmd
+	}
+	m, parentCmd := m.updateActiveView(msg)
+	return m, tea.Batch(cmd, parentCmd)
+}
+
+// updateActiveView passes a message to the innermost sub-view
+func (m Model) updateActiveView(msg tea.Msg) (Model, tea.Cmd) {
+	view := m.ActiveView()
+	if view == nil {
+		return m, nil
+	}
+	view, cmd := view.Update(msg)
+	m.stack = append(m.stack[:len(m.stack)-1:len(m.stack)-1], view)
+	return m, cmd
+}
+
+// broadcast passes a message to every open view, not only the innermost,
+// or reloads the dashboard when no view is open
+func (m Model) broadcast(msg tea.Msg) (Model, tea.Cmd) {
+	if len(m.stack) == 0 {
+		return m, m.refreshDashboard()
+	}
+
+	stack := make([]tea.Model, len(m.stack))
+	cmds := make([]tea.Cmd, len(m.stack))
+	for i, view := range m.stack {
+		stack[i], cmds[i] = view.Update(msg)
+	}
+	m.stack = stack
+	return m, tea.Batch(cmds...)
+}
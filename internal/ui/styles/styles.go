@@ -13,107 +13,142 @@ var (
 	ErrorColor     = lipgloss.Color("#FF5555")
 	MutedColor     = lipgloss.Color("#888888")
 	BorderColor    = lipgloss.Color("#3C3C3C")
+	TextColor      = lipgloss.Color("#CCCCCC")
+	SelectedColor  = lipgloss.Color("#FFFFFF") // Text on PrimaryColor
+	HighlightColor = lipgloss.Color("57")      // Background of selected table rows
+)
+
+var (
+	TitleStyle        lipgloss.Style
+	SubtitleStyle     lipgloss.Style
+	SelectedItemStyle lipgloss.Style
+	NormalItemStyle   lipgloss.Style
+	DimmedItemStyle   lipgloss.Style
+	SuccessStyle      lipgloss.Style
+	ErrorStyle        lipgloss.Style
+	WarningStyle      lipgloss.Style
+	InfoStyle         lipgloss.Style
+	BoxStyle          lipgloss.Style
+	HelpStyle         lipgloss.Style
+	ProgressBarStyle  lipgloss.Style
+	LogLineStyle      lipgloss.Style
+	LogErrorStyle     lipgloss.Style
+	LogWarningStyle   lipgloss.Style
+	LogInfoStyle      lipgloss.Style
+	SpinnerStyle      lipgloss.Style
+	ViewportStyle     lipgloss.Style
+	HighlightStyle    lipgloss.Style
+	MutedStyle        lipgloss.Style
+	FocusedStyle      lipgloss.Style
+	NoStyle           lipgloss.Style
+)
 
+func init() {
+	buildStyles()
+}
+
+// buildStyles creates the styles from the current colors
+func buildStyles() {
 	// Title style
 	TitleStyle = lipgloss.NewStyle().
-			Foreground(PrimaryColor).
-			Bold(true).
-			PaddingLeft(2).
-			PaddingBottom(1)
+		Foreground(PrimaryColor).
+		Bold(true).
+		PaddingLeft(2).
+		PaddingBottom(1)
 
 	// Subtitle style
 	SubtitleStyle = lipgloss.NewStyle().
-			Foreground(SecondaryColor).
-			PaddingLeft(2)
+		Foreground(SecondaryColor).
+		PaddingLeft(2)
 
 	// Menu item styles
 	SelectedItemStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FFFFFF")).
-				Background(PrimaryColor).
-				PaddingLeft(2).
-				PaddingRight(2).
-				Bold(true)
+		Foreground(SelectedColor).
+		Background(PrimaryColor).
+		PaddingLeft(2).
+		PaddingRight(2).
+		Bold(true)
 
 	NormalItemStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#CCCCCC")).
-				PaddingLeft(2).
-				PaddingRight(2)
+		Foreground(TextColor).
+		PaddingLeft(2).
+		PaddingRight(2)
 
 	DimmedItemStyle = lipgloss.NewStyle().
-				Foreground(MutedColor).
-				PaddingLeft(2).
-				PaddingRight(2)
+		Foreground(MutedColor).
+		PaddingLeft(2).
+		PaddingRight(2)
 
 	// Status styles
 	SuccessStyle = lipgloss.NewStyle().
-			Foreground(SuccessColor).
-			Bold(true)
+		Foreground(SuccessColor).
+		Bold(true)
 
 	ErrorStyle = lipgloss.NewStyle().
-			Foreground(ErrorColor).
-			Bold(true)
+		Foreground(ErrorColor).
+		Bold(true)
 
 	WarningStyle = lipgloss.NewStyle().
-			Foreground(WarningColor).
-			Bold(true)
+		Foreground(WarningColor).
+		Bold(true)
 
 	InfoStyle = lipgloss.NewStyle().
-			Foreground(SecondaryColor)
+		Foreground(SecondaryColor)
 
 	// Box styles
 	BoxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(BorderColor).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(BorderColor).
+		Padding(1, 2)
 
 	HelpStyle = lipgloss.NewStyle().
-			Foreground(MutedColor).
-			PaddingTop(1).
-			PaddingLeft(2)
+		Foreground(MutedColor).
+		PaddingTop(1).
+		PaddingLeft(2)
 
 	// Progress bar style
 	ProgressBarStyle = lipgloss.NewStyle().
-				Foreground(PrimaryColor)
+		Foreground(PrimaryColor)
 
 	// Log viewer styles
 	LogLineStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#DDDDDD"))
+		Foreground(TextColor)
 
 	LogErrorStyle = lipgloss.NewStyle().
-				Foreground(ErrorColor).
-				Bold(true)
+		Foreground(ErrorColor).
+		Bold(true)
 
 	LogWarningStyle = lipgloss.NewStyle().
-				Foreground(WarningColor)
+		Foreground(WarningColor)
 
 	LogInfoStyle = lipgloss.NewStyle().
-				Foreground(SecondaryColor)
+		Foreground(SecondaryColor)
 
 	// Spinner style
 	SpinnerStyle = lipgloss.NewStyle().
-				Foreground(PrimaryColor)
+		Foreground(PrimaryColor)
 
 	// Viewport style
 	ViewportStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(BorderColor).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(BorderColor).
+		Padding(1, 2)
 
 	// Highlight style
 	HighlightStyle = lipgloss.NewStyle().
-			Foreground(PrimaryColor).
-			Bold(true)
+		Foreground(PrimaryColor).
+		Bold(true)
 
 	// Muted style
 	MutedStyle = lipgloss.NewStyle().
-			Foreground(MutedColor)
-	
+		Foreground(MutedColor)
+
 	// Input field styles
 	FocusedStyle = lipgloss.NewStyle().
-			Foreground(PrimaryColor)
-	
+		Foreground(PrimaryColor)
+
 	NoStyle = lipgloss.NewStyle()
-)
+}
 
 // RenderTitle renders a styled title
 func RenderTitle(title string) string {
@@ -163,4 +198,30 @@ func RenderHighlight(text string) string {
 // RenderMuted renders muted text
 func RenderMuted(text string) string {
 	return MutedStyle.Render(text)
-}
\ No newline at end of file
+}
+
+// labelColors are the named colors of remote labels, mid-tones readable on
+// dark and light backgrounds alike
+var labelColors = map[string]lipgloss.Color{
+	"red":    "#E5484D",
+	"orange": "#F76808",
+	"yellow": "#D4A72C",
+	"green":  "#30A46C",
+	"cyan":   "#05A2C2",
+	"blue":   "#3E63DD",
+	"purple": "#8E4EC6",
+	"pink":   "#D6409F",
+	"gray":   "#8B8D98",
+}
+
+// RenderLabel renders a remote's label in bold, in a named color or hex
+// value; without a color it is shown in the primary color
+func RenderLabel(text, color string) string {
+	c := PrimaryColor
+	if named, ok := labelColors[color]; ok {
+		c = named
+	} else if hexColor.MatchString(color) {
+		c = lipgloss.Color(color)
+	}
+	return lipgloss.NewStyle().Foreground(c).Bold(true).Render(text)
+}
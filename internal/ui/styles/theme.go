@@ -0,0 +1,195 @@
+package styles
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme names accepted in the "ui.theme" config setting
+const (
+	ThemeAuto         = "auto" // Dark or light, following the terminal background
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeCustom       = "custom" // The auto theme with "ui.palette" overrides
+)
+
+// Theme is a color palette for the TUI
+type Theme struct {
+	Name      string
+	Primary   lipgloss.Color
+	Secondary lipgloss.Color
+	Success   lipgloss.Color
+	Warning   lipgloss.Color
+	Error     lipgloss.Color
+	Muted     lipgloss.Color
+	Border    lipgloss.Color
+	Text      lipgloss.Color
+	Selected  lipgloss.Color // Text on Primary and Highlight backgrounds
+	Highlight lipgloss.Color // Background of selected table rows
+}
+
+// DarkTheme is the default palette, for dark terminal backgrounds
+var DarkTheme = Theme{
+	Name:      ThemeDark,
+	Primary:   "#00ADD8",
+	Secondary: "#5DC9E2",
+	Success:   "#00D787",
+	Warning:   "#FFA500",
+	Error:     "#FF5555",
+	Muted:     "#888888",
+	Border:    "#3C3C3C",
+	Text:      "#CCCCCC",
+	Selected:  "#FFFFFF",
+	Highlight: "57",
+}
+
+// LightTheme is readable on light terminal backgrounds
+var LightTheme = Theme{
+	Name:      ThemeLight,
+	Primary:   "#005F87",
+	Secondary: "#0087AF",
+	Success:   "#00875F",
+	Warning:   "#AF5F00",
+	Error:     "#D70000",
+	Muted:     "#6C6C6C",
+	Border:    "#BCBCBC",
+	Text:      "#262626",
+	Selected:  "#FFFFFF",
+	Highlight: "#005F87",
+}
+
+// HighContrastTheme uses saturated colors on black for low-vision users
+var HighContrastTheme = Theme{
+	Name:      ThemeHighContrast,
+	Primary:   "#FFFF00",
+	Secondary: "#00FFFF",
+	Success:   "#00FF00",
+	Warning:   "#FFAF00",
+	Error:     "#FF0000",
+	Muted:     "#D0D0D0",
+	Border:    "#FFFFFF",
+	Text:      "#FFFFFF",
+	Selected:  "#000000",
+	Highlight: "#FFFF00",
+}
+
+var current = DarkTheme
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ThemeNames returns the accepted theme names
+func ThemeNames() []string {
+	return []string{ThemeAuto, ThemeDark, ThemeLight, ThemeHighContrast, ThemeCustom}
+}
+
+// CurrentTheme returns the theme in use
+func CurrentTheme() Theme {
+	return current
+}
+
+// ApplyTheme switches every color and style to the theme
+func ApplyTheme(theme Theme) {
+	current = theme
+
+	PrimaryColor = theme.Primary
+	SecondaryColor = theme.Secondary
+	SuccessColor = theme.Success
+	WarningColor = theme.Warning
+	ErrorColor = theme.Error
+	MutedColor = theme.Muted
+	BorderColor = theme.Border
+	TextColor = theme.Text
+	SelectedColor = theme.Selected
+	HighlightColor = theme.Highlight
+
+	buildStyles()
+}
+
+// ResolveTheme returns the theme for a config setting. An empty name means
+// auto. Palette entries, keyed by color name ("primary", "error", ...) with
+// hex values, override the theme's colors.
+func ResolveTheme(name string, palette map[string]string) (Theme, error) {
+	var theme Theme
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", ThemeAuto, ThemeCustom:
+		theme = DetectTheme()
+	case ThemeDark:
+		theme = DarkTheme
+	case ThemeLight:
+		theme = LightTheme
+	case ThemeHighContrast:
+		theme = HighContrastTheme
+	default:
+		return DarkTheme, fmt.Errorf("unknown theme '%s' (expected one of: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+
+	if len(palette) > 0 {
+		return theme.WithPalette(palette)
+	}
+	return theme, nil
+}
+
+// DetectTheme returns the light theme on light terminal backgrounds and the
+// dark theme otherwise
+func DetectTheme() Theme {
+	if lipgloss.HasDarkBackground() {
+		return DarkTheme
+	}
+	return LightTheme
+}
+
+// WithPalette returns the theme with some colors replaced by hex values
+func (t Theme) WithPalette(palette map[string]string) (Theme, error) {
+	colors := map[string]*lipgloss.Color{
+		"primary":   &t.Primary,
+		"secondary": &t.Secondary,
+		"success":   &t.Success,
+		"warning":   &t.Warning,
+		"error":     &t.Error,
+		"muted":     &t.Muted,
+		"border":    &t.Border,
+		"text":      &t.Text,
+		"selected":  &t.Selected,
+		"highlight": &t.Highlight,
+	}
+
+	for key, value := range palette {
+		color, ok := colors[strings.ToLower(key)]
+		if !ok {
+			names := make([]string, 0, len(colors))
+			for name := range colors {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return t, fmt.Errorf("unknown palette color '%s' (expected one of: %s)", key, strings.Join(names, ", "))
+		}
+		if !hexColor.MatchString(value) {
+			return t, fmt.Errorf("palette color %s must be a hex value like #00ADD8, got '%s'", key, value)
+		}
+		*color = lipgloss.Color(value)
+	}
+
+	t.Name = ThemeCustom
+	return t, nil
+}
+
+// TableStyles returns table styles in the current theme's colors
+func TableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(BorderColor).
+		BorderBottom(true).
+		Bold(false)
+	s.Selected = s.Selected.
+		Foreground(SelectedColor).
+		Background(HighlightColor).
+		Bold(false)
+	return s
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -12,6 +13,16 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/cost"
+	"github.com/andreisuslov/cloud-sync/internal/filewatch"
+	"github.com/andreisuslov/cloud-sync/internal/installer"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/status"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
 	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
 	"github.com/andreisuslov/cloud-sync/internal/ui/views"
 )
@@ -27,6 +38,8 @@ const (
 	StateLogViewer
 	StateLaunchdManager
 	StateMaintenance
+	StateSyncPairs
+	StateTour
 	StateHelp
 	StateExiting
 )
@@ -99,9 +112,15 @@ type Model struct {
 	ShowMessage   bool
 	Quitting      bool
 	HelpReady     bool
+	MissedRuns    []logs.MissedRun
+	Dashboard     *status.Summary // nil until loaded
+	ProtectedSize int64           // Negative until calculated
+	Cost          *cost.Estimate  // nil until calculated
+	Clicks        views.ClickTracker
+	watcher       *filewatch.Watcher // Reports changes to the config files; nil if unavailable
 	
-	// Active sub-view (when navigated to a specific view)
-	ActiveSubView tea.Model
+	// Open sub-views, innermost last (see navigation.go)
+	stack []tea.Model
 }
 
 // MenuItem represents a menu item
@@ -124,7 +143,23 @@ func NewModel() Model {
 			description: "Install required tools and configure remotes",
 		},
 		MenuItem{
-			title:       "2. Help",
+			title:       "2. Sync Pairs",
+			description: "Manage folders synced to your remotes",
+		},
+		MenuItem{
+			title:       "3. Scheduling & Maintenance",
+			description: "Manage the LaunchAgent and backup schedule, or uninstall",
+		},
+		MenuItem{
+			title:       "4. Backup Operations",
+			description: "Follow the scheduled backup while it runs",
+		},
+		MenuItem{
+			title:       "5. Guided Tour",
+			description: "Make a first backup to a practice remote, no cloud account needed",
+		},
+		MenuItem{
+			title:       "6. Help",
 			description: "View keyboard shortcuts and documentation",
 		},
 	}
@@ -142,18 +177,44 @@ func NewModel() Model {
 	h := help.New()
 	h.ShowAll = false
 
+	var watcher *filewatch.Watcher
+	if configManager, err := config.NewManager(); err == nil {
+		watcher = configManager.Watch()
+	}
+
 	return Model{
-		State:   StateMainMenu,
-		List:    l,
-		Spinner: s,
-		Help:    h,
-		Keys:    defaultKeyMap(),
+		State:         StateMainMenu,
+		List:          l,
+		Spinner:       s,
+		Help:          h,
+		Keys:          defaultKeyMap(),
+		ProtectedSize: -1,
+		watcher:       watcher,
 	}
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return m.Spinner.Tick
+	cmds := []tea.Cmd{m.Spinner.Tick, views.CheckMissedRunsCmd(m.launchdManager()), m.refreshDashboard()}
+	if m.watcher != nil {
+		cmds = append(cmds, views.WatchConfigCmd(m.watcher))
+	}
+	return tea.Batch(cmds...)
+}
+
+// refreshDashboard returns a command that reloads the dashboard in the background
+func (m Model) refreshDashboard() tea.Cmd {
+	return tea.Batch(views.LoadDashboardCmd(m.launchdManager()), views.ProtectedSizeCmd())
+}
+
+// resizeList fits the menu list below the dashboard
+func (m *Model) resizeList() {
+	// Give the list most of the vertical space, leaving room for title and help
+	listHeight := m.Height - 8 - lipgloss.Height(views.RenderDashboard(m.Dashboard, m.ProtectedSize, m.Cost, m.Width))
+	if listHeight < 5 {
+		listHeight = 5 // Minimum height
+	}
+	m.List.SetSize(m.Width-4, listHeight)
 }
 
 // Update handles messages and updates the model
@@ -162,12 +223,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
 		m.Height = msg.Height
-		// Give the list most of the vertical space, leaving room for title and help
-		listHeight := msg.Height - 8
-		if listHeight < 5 {
-			listHeight = 5 // Minimum height
-		}
-		m.List.SetSize(msg.Width-4, listHeight)
+		m.resizeList()
 		
 		// Update help viewport if in help state
 		if m.State == StateHelp && m.HelpReady {
@@ -176,12 +232,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		
 		// Also update active sub-view if present
-		if m.ActiveSubView != nil {
-			var cmd tea.Cmd
-			m.ActiveSubView, cmd = m.ActiveSubView.Update(msg)
-			return m, cmd
-		}
-		return m, nil
+		return m.updateActiveView(msg)
 
 	case tea.KeyMsg:
 		// Handle special keys that should be intercepted
@@ -198,6 +249,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if key.Matches(msg, m.Keys.Enter) {
 				return m.handleMenuSelection()
 			}
+			if msg.String() == "b" && len(m.MissedRuns) > 0 {
+				if views.ReadOnly() {
+					m.Message = "Catch-up backup is disabled in read-only mode"
+					m.ShowMessage = true
+					return m, nil
+				}
+				m.Message = "Starting catch-up backup..."
+				m.ShowMessage = true
+				return m, views.CatchUpCmd(m.launchdManager())
+			}
+			if msg.String() == "i" && m.Dashboard != nil && len(m.Dashboard.ScrubIssues) > 0 {
+				if views.ReadOnly() {
+					m.Message = "Acknowledging scrub problems is disabled in read-only mode"
+					m.ShowMessage = true
+					return m, nil
+				}
+				return m, views.AcknowledgeScrubsCmd()
+			}
 			// Let the list handle navigation keys (up, down, j, k, etc.)
 			var cmd tea.Cmd
 			m.List, cmd = m.List.Update(msg)
@@ -223,6 +292,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.MouseMsg:
+		// Click selects a menu item and double-click opens it
+		if m.State == StateMainMenu && views.IsLeftClick(msg) {
+			return m.handleMenuClick(msg)
+		}
 		// Pass mouse events to the list for scrolling in main menu
 		if m.State == StateMainMenu {
 			var cmd tea.Cmd
@@ -236,16 +309,72 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 		// Pass mouse events to active sub-view
-		if m.ActiveSubView != nil {
-			var cmd tea.Cmd
-			m.ActiveSubView, cmd = m.ActiveSubView.Update(msg)
-			return m, cmd
-		}
+		return m.updateActiveView(msg)
 
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.Spinner, cmd = m.Spinner.Update(msg)
-		return m, cmd
+		// Sub-views run their own spinners; each ignores ticks for the others
+		var subCmd tea.Cmd
+		m, subCmd = m.updateActiveView(msg)
+		return m, tea.Batch(cmd, subCmd)
+
+	case views.MissedRunsMsg:
+		m.MissedRuns = msg.Runs
+
+	case views.DashboardMsg:
+		m.Dashboard = &msg.Summary
+		m.resizeList()
+		return m, nil
+
+	case views.ProtectedSizeMsg:
+		m.ProtectedSize = msg.Bytes
+		m.Cost = msg.Cost
+		m.resizeList()
+		return m, nil
+
+	case views.ScrubsAcknowledgedMsg:
+		if msg.Err != nil {
+			m.Message = "Failed to acknowledge scrub problems: " + msg.Err.Error()
+		} else {
+			m.Message = fmt.Sprintf("Acknowledged %d scrub run(s) with problems", msg.Count)
+		}
+		m.ShowMessage = true
+		return m, views.LoadDashboardCmd(m.launchdManager())
+
+	case views.CatchUpDoneMsg:
+		if m.State == StateMainMenu {
+			if msg.Err != nil {
+				m.Message = "Catch-up backup failed: " + msg.Err.Error()
+			} else {
+				m.Message = "Catch-up backup started"
+				m.MissedRuns = nil
+			}
+			m.ShowMessage = true
+			return m, nil
+		}
+
+	case views.ConfigWatchMsg:
+		// Check again later, and reload the views if a file changed
+		next := views.WatchConfigCmd(m.watcher)
+		if len(msg.Changed) == 0 {
+			return m, next
+		}
+		m, cmd := m.broadcast(views.ConfigChangedMsg{Paths: msg.Changed})
+		return m, tea.Batch(next, cmd)
+
+	case views.ConfigChangedMsg:
+		return m.broadcast(msg)
+
+	case views.BackMsg:
+		return m.back()
+
+	case views.DoneMsg:
+		return m.done(msg)
+
+	case views.OpenViewMsg:
+		// A sub-view opened another view on top of itself
+		return m.openView(m.State, msg.View)
 	}
 
 	// Update help viewport for any remaining messages when in help state
@@ -257,24 +386,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Delegate to active sub-view if present
-	if m.ActiveSubView != nil && m.State != StateMainMenu {
-		var cmd tea.Cmd
-		m.ActiveSubView, cmd = m.ActiveSubView.Update(msg)
-		return m, cmd
-	}
-
-	return m, nil
+	return m.updateActiveView(msg)
 }
 
 // handleKeyPress handles keyboard input for non-main-menu states
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Handle 'q' or 'esc' to go back to main menu
-	if msg.String() == "q" || msg.String() == "esc" {
-		m.State = StateMainMenu
-		m.ActiveSubView = nil
-		return m, nil
-	}
-	
 	// Handle ctrl+c to force quit
 	if msg.String() == "ctrl+c" {
 		m.Quitting = true
@@ -282,12 +398,27 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	// If we have an active sub-view, let it handle the key
-	if m.ActiveSubView != nil {
-		var cmd tea.Cmd
-		m.ActiveSubView, cmd = m.ActiveSubView.Update(msg)
-		return m, cmd
+	return m.updateActiveView(msg)
+}
+
+// handleMenuClick selects the clicked menu item, and opens it on a
+// double-click
+func (m Model) handleMenuClick(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	items := m.List.VisibleItems()
+	start, end := m.List.Paginator.GetSliceBounds(len(items))
+	labels := make([]string, 0, end-start)
+	for _, item := range items[start:end] {
+		labels = append(labels, item.(MenuItem).Title())
 	}
 
+	i, ok := views.ItemAtLine(m.viewMainMenu(), msg.Y, labels)
+	if !ok {
+		return m, nil
+	}
+	m.List.Select(start + i)
+	if m.Clicks.Click(start+i, time.Now()) {
+		return m.handleMenuSelection()
+	}
 	return m, nil
 }
 
@@ -303,12 +434,39 @@ func (m Model) handleMenuSelection() (tea.Model, tea.Cmd) {
 
 	switch {
 	case strings.HasPrefix(title, "1."):
-		m.State = StateInstallation
 		// Initialize configuration setup wizard
-		configModel := views.NewConfigurationSetupModel()
-		m.ActiveSubView = configModel
-		return m, configModel.Init()
+		return m.openView(StateInstallation, views.NewConfigurationSetupModel())
 	case strings.HasPrefix(title, "2."):
+		syncConfigMgr, err := syncconfig.NewDefaultManager()
+		if err != nil {
+			m.Err = err
+			return m, nil
+		}
+		rcloneMgr, credentialsErr := rcloneManager()
+		return m.openView(StateSyncPairs, views.NewSyncPairsModel(syncConfigMgr, rcloneMgr).WithCredentialsError(credentialsErr))
+	case strings.HasPrefix(title, "3."):
+		return m.openView(StateLaunchdManager, views.NewLaunchdManagerModel(m.launchdManager(), m.Width, m.Height))
+	case strings.HasPrefix(title, "4."):
+		view, err := views.NewScheduledBackupModel(m.Width, m.Height)
+		if err != nil {
+			m.Err = err
+			return m, nil
+		}
+		return m.openView(StateBackupRunning, view)
+	case strings.HasPrefix(title, "5."):
+		configManager, err := config.NewManager()
+		if err != nil {
+			m.Err = err
+			return m, nil
+		}
+		syncConfigMgr, err := syncconfig.NewDefaultManager()
+		if err != nil {
+			m.Err = err
+			return m, nil
+		}
+		rcloneMgr, credentialsErr := rcloneManager()
+		return m.openView(StateTour, views.NewTourModel(configManager, syncConfigMgr, rcloneMgr).WithCredentialsError(credentialsErr))
+	case strings.HasPrefix(title, "6."):
 		m.State = StateHelp
 		// Initialize help viewport with content
 		m.HelpViewport = viewport.New(m.Width-4, m.Height-6)
@@ -321,6 +479,103 @@ func (m Model) handleMenuSelection() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// ApplyTheme switches the TUI to the theme saved in the configuration. It
+// must run before NewModel, and before the program starts so the terminal
+// background can be detected. On error the default theme is kept.
+func ApplyTheme() error {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return err
+	}
+
+	theme, err := styles.ResolveTheme(appConfig.UI.Theme, appConfig.UI.Palette)
+	if err != nil {
+		return err
+	}
+	styles.ApplyTheme(theme)
+	return nil
+}
+
+// ReadOnlyFlag starts the TUI in read-only mode
+const ReadOnlyFlag = "--read-only"
+
+// TakeReadOnlyFlag removes ReadOnlyFlag from args, wherever it appears, and
+// reports whether it was present
+func TakeReadOnlyFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == ReadOnlyFlag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// ApplyReadOnly turns on read-only mode when the flag was given or
+// "ui.read_only" is set, and reports whether it is on. Views can still be
+// opened to review the setup, but installs, configuration changes, syncs and
+// LaunchAgent actions are refused. It must run before NewModel. When the
+// configuration cannot be read only the flag counts.
+func ApplyReadOnly(flag bool) bool {
+	readOnly := flag
+	if configManager, err := config.NewManager(); err == nil {
+		if appConfig, err := configManager.Load(); err == nil && appConfig.UI.ReadOnly {
+			readOnly = true
+		}
+	}
+	views.SetReadOnly(readOnly)
+	return readOnly
+}
+
+// ProgramOptions returns the program options for the saved preferences.
+// Mouse support takes over the terminal with the alternate screen, which
+// disables selecting text to copy, so it is off unless "ui.mouse" is set.
+func ProgramOptions() []tea.ProgramOption {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return nil
+	}
+	appConfig, err := configManager.Load()
+	if err != nil || !appConfig.UI.Mouse {
+		return nil
+	}
+	return []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+}
+
+// launchdManager returns the manager of the current user's scheduled
+// backup. A LaunchDaemon needs sudo, which cannot ask for a password while
+// the TUI owns the terminal.
+func (m Model) launchdManager() *launchd.Manager {
+	return config.BackupScheduler(launchd.CurrentUsername()).NonInteractive()
+}
+
+// rcloneManager returns an rclone manager for the installed binary, with any
+// referenced credentials resolved into its environment, and the references
+// that did not resolve, see views.NewRcloneManager
+func rcloneManager() (*rclone.Manager, error) {
+	rclonePath, err := installer.NewInstaller().GetRclonePath()
+	if err != nil {
+		rclonePath = "rclone" // Resolved via PATH
+	}
+	return views.NewRcloneManager(rclonePath)
+}
+
+// sizeCmd returns a command that re-sends the current window size, so a
+// newly opened view can lay itself out
+func (m Model) sizeCmd() tea.Cmd {
+	width, height := m.Width, m.Height
+	return func() tea.Msg {
+		return tea.WindowSizeMsg{Width: width, Height: height}
+	}
+}
+
 // View renders the UI
 func (m Model) View() string {
 	if m.Quitting {
@@ -328,8 +583,8 @@ func (m Model) View() string {
 	}
 
 	// If we have an active sub-view, render it
-	if m.ActiveSubView != nil && m.State != StateMainMenu {
-		return m.ActiveSubView.View()
+	if view := m.ActiveView(); view != nil {
+		return view.View()
 	}
 
 	var content string
@@ -362,9 +617,28 @@ func (m Model) View() string {
 func (m Model) viewMainMenu() string {
 	var b strings.Builder
 
+	b.WriteString("\n")
+	b.WriteString(views.RenderDashboard(m.Dashboard, m.ProtectedSize, m.Cost, m.Width))
 	b.WriteString("\n")
 	b.WriteString(m.List.View())
 	b.WriteString("\n\n")
+
+	if len(m.MissedRuns) > 0 {
+		b.WriteString(styles.RenderWarning("⚠ " + views.MissedRunsSummary(m.MissedRuns) + " • b: Run catch-up backup now"))
+		b.WriteString("\n")
+	}
+	if m.ShowMessage && m.Message != "" {
+		b.WriteString(styles.RenderInfo(m.Message))
+		b.WriteString("\n")
+	}
+	if views.ReadOnly() {
+		b.WriteString(styles.RenderWarning("Read-only: installs, configuration changes and syncs are disabled"))
+		b.WriteString("\n")
+	}
+	if simulate.Enabled() {
+		b.WriteString(styles.RenderWarning("Simulation: brew, rclone and launchctl commands are listed on exit instead of run"))
+		b.WriteString("\n")
+	}
 	
 	// Render help using the help component
 	helpView := m.Help.View(m.Keys)
@@ -403,58 +677,63 @@ func (m Model) viewPlaceholder(title string) string {
 	return b.String()
 }
 
+// KeyReference returns the keys of the interface: the global ones from
+// the key map, those of the main menu and those of each view. The help
+// screen and 'cloud-sync docs' both list them.
+func KeyReference() []views.KeySection {
+	var global []views.ViewKey
+	for _, binding := range defaultKeyMap().ShortHelp() {
+		global = append(global, views.ViewKey{Key: binding.Help().Key, Help: binding.Help().Desc})
+	}
+	global = append(global,
+		views.ViewKey{Key: "ctrl+g", Help: "turn the debug log on / off"},
+		views.ViewKey{Key: "pgup/pgdn", Help: "page up / page down"},
+		views.ViewKey{Key: "home/end", Help: "jump to start / end"},
+	)
+
+	return append([]views.KeySection{
+		{Title: "Global Shortcuts", Keys: global},
+		{Title: "Main Menu", Keys: []views.ViewKey{
+			{Key: "b", Help: "Catch-up backup, when scheduled runs were missed", Writes: true},
+			{Key: "i", Help: "Acknowledge integrity scrub problems", Writes: true},
+		}},
+	}, views.KeyReference()...)
+}
+
 // getHelpContent returns the help text content
 func (m Model) getHelpContent() string {
-	return `Keyboard Shortcuts & Help
-========================
-
-Global Shortcuts:
-  q, esc       - Return to main menu / quit
-  ctrl+c       - Force quit
-  ↑/↓, j/k     - Navigate lists / scroll content
-  enter        - Select / confirm
-  pgup/pgdn    - Page up / page down
-  home/end     - Jump to start / end
-
-Main Menu:
-  1-7          - Quick access to menu items
-
-Log Viewer:
-  /            - Search
-  n            - Next search result
-  N            - Previous search result
-  g            - Go to top
-  G            - Go to bottom
-  tab          - Switch between viewing modes
-  e            - Export logs
-
-Backup Operations:
-  ctrl+c       - Cancel running backup
-  r            - Retry failed backup
-
-LaunchAgent Manager:
-  l            - Load agent
-  u            - Unload agent
-  s            - Start agent
-  t            - Stop agent
-  r            - Remove agent
+	var b strings.Builder
+	b.WriteString("Keyboard Shortcuts & Help\n")
+	b.WriteString("========================\n")
+	for _, section := range KeyReference() {
+		fmt.Fprintf(&b, "\n%s:\n", section.Title)
+		for _, k := range section.Keys {
+			fmt.Fprintf(&b, "  %-18s - %s\n", k.Key, k.Help)
+		}
+	}
 
+	b.WriteString(`
 Installation & Configuration:
   Follow on-screen prompts
   Tab to navigate between fields
   Enter to confirm selections
 
 Tips:
+  - New here? The Guided Tour makes a first backup without a cloud account
   - Use arrow keys or j/k to scroll in any view
   - Mouse scrolling is supported throughout
   - Press 'q' or 'esc' to return to previous screen
   - All changes are saved automatically
+  - Start with --read-only to review a setup without changing it
+  - Start with --debug (or -v for keys only) to write a debug log
+  - Run 'cloud-sync docs' for this reference and every command's flags
 
 Project Information:
   GitHub: https://github.com/andreisuslov/cloud-sync
   Author: andreisuslov
-  
-For more information and documentation, visit the GitHub repository.`
+
+For more information and documentation, visit the GitHub repository.`)
+	return b.String()
 }
 
 // viewHelp renders the help screen with scrollable viewport
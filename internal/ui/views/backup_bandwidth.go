@@ -0,0 +1,170 @@
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// bandwidthMsg carries the attached backup's bandwidth limit after it was
+// read or changed
+type bandwidthMsg struct {
+	bandwidth *rclone.RCBandwidth
+	err       error
+}
+
+// newLimitInput creates the input the bandwidth limit is typed into
+func newLimitInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "Bandwidth limit: "
+	input.Placeholder = "2M, 512K or off"
+	input.CharLimit = 10
+	input.Width = 20
+	return input
+}
+
+// parseBandwidthLimit reads a limit typed as a size per second, e.g. "2M",
+// or "off", in the form rclone reads it
+func parseBandwidthLimit(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "off") {
+		return "off", nil
+	}
+	bytes, err := config.ParseSize(s)
+	if err != nil || bytes <= 0 {
+		return "", fmt.Errorf("'%s' is not a limit like 2M, 512K or off", s)
+	}
+	return config.FormatSize(bytes), nil
+}
+
+// readBandwidth returns a command that reads the attached backup's limit
+func readBandwidth(rc *rclone.RCClient) tea.Cmd {
+	return func() tea.Msg {
+		bw, err := rc.BandwidthLimit()
+		return bandwidthMsg{bandwidth: bw, err: err}
+	}
+}
+
+// setBandwidth returns a command that changes the attached backup's limit
+func setBandwidth(rc *rclone.RCClient, rate string) tea.Cmd {
+	return func() tea.Msg {
+		bw, err := rc.SetBandwidthLimit(rate)
+		return bandwidthMsg{bandwidth: bw, err: err}
+	}
+}
+
+// pausedLimitPath returns the file beside the backup's lockfile that keeps
+// the limit a paused backup resumes at. It is shared by every session
+// attached to the backup, so any of them resumes at the right limit.
+func pausedLimitPath(lock *lockfile.Manager) string {
+	return lock.GetPath() + ".paused"
+}
+
+// savePausedLimit records the limit to resume at before a pause
+func savePausedLimit(path string, bw *rclone.RCBandwidth) error {
+	data, err := json.Marshal(bw)
+	if err != nil {
+		return fmt.Errorf("failed to encode bandwidth limit: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save bandwidth limit: %w", err)
+	}
+	return nil
+}
+
+// loadPausedLimit returns the limit recorded by the pause, or nil when
+// there is none, which Resume takes as full speed
+func loadPausedLimit(path string) *rclone.RCBandwidth {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var bw rclone.RCBandwidth
+	if err := json.Unmarshal(data, &bw); err != nil {
+		return nil
+	}
+	return &bw
+}
+
+// togglePause returns a command that pauses the attached backup's
+// transfers, or resumes them at the limit they had before. The limit is
+// read afresh, as another session may have changed it or paused the backup.
+func (m BackupOpsModel) togglePause() tea.Cmd {
+	rc, path := m.rc, pausedLimitPath(m.lock)
+	return func() tea.Msg {
+		bw, err := rc.BandwidthLimit()
+		if err != nil {
+			return bandwidthMsg{err: err}
+		}
+		if bw.Paused() {
+			bw, err = rc.Resume(loadPausedLimit(path))
+			if err == nil {
+				os.Remove(path)
+			}
+			return bandwidthMsg{bandwidth: bw, err: err}
+		}
+		if err := savePausedLimit(path, bw); err != nil {
+			return bandwidthMsg{err: err}
+		}
+		bw, err = rc.Pause()
+		return bandwidthMsg{bandwidth: bw, err: err}
+	}
+}
+
+// updateLimitInput handles a key while the bandwidth limit is typed
+func (m BackupOpsModel) updateLimitInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.editingLimit = false
+		m.limitInput.Blur()
+		m.bandwidthErr = nil
+		return m, nil
+	case "enter":
+		rate, err := parseBandwidthLimit(m.limitInput.Value())
+		if err != nil {
+			m.bandwidthErr = err
+			return m, nil
+		}
+		m.editingLimit = false
+		m.limitInput.Blur()
+		m.bandwidthErr = nil
+		return m, setBandwidth(m.rc, rate)
+	}
+	var cmd tea.Cmd
+	m.limitInput, cmd = m.limitInput.Update(msg)
+	return m, cmd
+}
+
+// renderBandwidth renders the attached backup's limit, or the input to
+// change it
+func (m BackupOpsModel) renderBandwidth() string {
+	var b strings.Builder
+	switch {
+	case m.editingLimit:
+		b.WriteString(m.limitInput.View())
+		b.WriteString("\n")
+		b.WriteString(styles.RenderMuted("A size per second, or off for full speed • enter: Apply • esc: Cancel"))
+	case m.bandwidth == nil:
+		return ""
+	case m.bandwidth.Paused():
+		b.WriteString(styles.RenderWarning("Paused: transfers stall until resumed with p"))
+	case m.bandwidth.Unlimited():
+		b.WriteString("Bandwidth limit: off")
+	default:
+		b.WriteString(fmt.Sprintf("Bandwidth limit: %s/s", formatBytes(m.bandwidth.BytesPerSecond)))
+	}
+	if m.bandwidthErr != nil {
+		b.WriteString("\n")
+		b.WriteString(renderError(m.bandwidthErr))
+	}
+	return b.String()
+}
@@ -5,9 +5,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
 	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -17,6 +22,8 @@ type BackupOperation int
 const (
 	BackupManual BackupOperation = iota
 	BackupAutomated
+	BackupAttached // Following a scheduled backup that was already running
+	BackupPair     // Running 'cloud-sync sync' for one sync pair
 )
 
 // BackupStatus represents the current status of the backup
@@ -54,6 +61,21 @@ type BackupOpsModel struct {
 	width     int
 	height    int
 	canceling bool
+	pair      string // Set by RunPair
+	summary   string // Last line the pair's sync printed
+	hint      string // What to do about its failure, if it said
+
+	// Set by AttachRunningBackup
+	lock          *lockfile.Manager
+	rc            *rclone.RCClient
+	rcUnavailable bool // Lockfile held but rclone's API is not answering
+	waiting       bool // No scheduled backup is running yet
+	queue         TransferQueue
+	queueTable    table.Model
+	bandwidth     *rclone.RCBandwidth // Limit of the attached rclone, once read
+	limitInput    textinput.Model
+	editingLimit  bool
+	bandwidthErr  error
 }
 
 // NewBackupOpsModel creates a new backup operations model
@@ -70,16 +92,59 @@ func NewBackupOpsModel(operation BackupOperation, width, height int) BackupOpsMo
 			Status:    BackupIdle,
 			StartTime: time.Now(),
 		},
-		spinner:   s,
-		progBar:   p,
-		width:     width,
-		height:    height,
-		canceling: false,
+		spinner:    s,
+		progBar:    p,
+		queueTable: newTransferTable(),
+		limitInput: newLimitInput(),
+		width:      width,
+		height:     height,
+		canceling:  false,
 	}
 }
 
+// AttachRunningBackup makes the view check for a scheduled backup when it
+// opens. If one holds the lockfile and answers on rclone's remote control
+// API, its progress is shown; otherwise the view waits for one to start.
+func (m BackupOpsModel) AttachRunningBackup(lock *lockfile.Manager, rc *rclone.RCClient) BackupOpsModel {
+	m.lock = lock
+	m.rc = rc
+	return m
+}
+
+// NewScheduledBackupModel creates the Backup Operations view, which follows
+// the scheduled backup of the saved configuration while it runs
+func NewScheduledBackupModel(width, height int) (BackupOpsModel, error) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return BackupOpsModel{}, err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return BackupOpsModel{}, err
+	}
+
+	view := NewBackupOpsModel(BackupAutomated, width, height).
+		AttachRunningBackup(lockfile.NewManager(appConfig.LogDir), rclone.NewRCClient(appConfig.LaunchAgent.RCAddress()))
+	return view, nil
+}
+
+// RunPair makes the view sync one pair with 'cloud-sync sync' instead of
+// starting a backup, e.g. to re-run a session that failed
+func (m BackupOpsModel) RunPair(pair string) BackupOpsModel {
+	m.operation = BackupPair
+	m.pair = pair
+	m.progress.Status = BackupRunning
+	return m
+}
+
 // Init implements tea.Model
 func (m BackupOpsModel) Init() tea.Cmd {
+	if m.operation == BackupPair {
+		return tea.Batch(m.spinner.Tick, runSyncCmd(m.pair, m.pair))
+	}
+	if m.lock != nil && m.rc != nil {
+		return tea.Batch(m.spinner.Tick, m.detectRunningBackup())
+	}
 	return tea.Batch(m.spinner.Tick, m.startBackup())
 }
 
@@ -87,18 +152,48 @@ func (m BackupOpsModel) Init() tea.Cmd {
 func (m BackupOpsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.editingLimit && msg.String() != "ctrl+c" {
+			return m.updateLimitInput(msg)
+		}
 		switch msg.String() {
-		case "ctrl+c", "q":
+		case "ctrl+c":
+			return m, tea.Quit
+		case "l", "p":
+			// Only an attached rclone can be controlled while it runs
+			if m.operation != BackupAttached || m.progress.Status != BackupRunning || m.rcUnavailable {
+				return m, nil
+			}
+			if err := refuseReadOnly("Changing a running backup's bandwidth"); err != nil {
+				m.bandwidthErr = err
+				return m, nil
+			}
+			if msg.String() == "p" {
+				return m, m.togglePause()
+			}
+			m.editingLimit = true
+			m.bandwidthErr = nil
+			m.limitInput.SetValue("")
+			return m, m.limitInput.Focus()
+		case "q", "esc":
+			// Leaving an attached view detaches; the scheduled backup keeps
+			// running, as does the sync of a pair
+			if m.operation == BackupAttached || m.operation == BackupPair {
+				return m, BackCmd()
+			}
 			if m.progress.Status == BackupRunning {
 				m.canceling = true
 				return m, m.cancelBackup()
 			}
-			return m, tea.Quit
+			return m, BackCmd()
+		case "up", "k", "down", "j", "pgup", "pgdown":
+			var cmd tea.Cmd
+			m.queueTable, cmd = m.queueTable.Update(msg)
+			return m, cmd
 		case "enter":
 			if m.progress.Status == BackupCompleted || 
 			   m.progress.Status == BackupFailed || 
 			   m.progress.Status == BackupCancelled {
-				return m, tea.Quit
+				return m, DoneCmd(m.resultMessage())
 			}
 		}
 
@@ -113,6 +208,66 @@ func (m BackupOpsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Batch(m.spinner.Tick, m.tickProgress())
 		}
 
+	case pairsSynced:
+		m.progress.ElapsedTime = time.Since(m.progress.StartTime)
+		m.summary = msg.summary
+		m.hint = msg.hint
+		if msg.err != nil {
+			m.progress.Status = BackupFailed
+			m.progress.ErrorMessage = msg.summary
+		} else {
+			m.progress.Status = BackupCompleted
+		}
+		return m, nil
+
+	case noBackupRunningMsg:
+		m.waiting = true
+		detect := m.detectRunningBackup()
+		return m, tea.Tick(backupCheckInterval, func(time.Time) tea.Msg { return detect() })
+
+	case backupAttachedMsg:
+		m.waiting = false
+		m.operation = BackupAttached
+		m.progress.Status = BackupRunning
+		m.rcUnavailable = msg.stats == nil
+		cmd := m.pollRunningBackup()
+		if msg.stats != nil {
+			m.progress = progressFromStats(msg.stats)
+			m.updateQueue(msg.stats)
+			cmd = tea.Batch(cmd, readBandwidth(m.rc))
+		}
+		return m, cmd
+
+	case bandwidthMsg:
+		if msg.err != nil {
+			m.bandwidthErr = msg.err
+			return m, nil
+		}
+		m.bandwidth = msg.bandwidth
+		m.bandwidthErr = nil
+		return m, nil
+
+	case rcStatsMsg:
+		if msg.err == nil {
+			cmd := m.pollRunningBackup()
+			if m.rcUnavailable {
+				cmd = tea.Batch(cmd, readBandwidth(m.rc))
+			}
+			m.rcUnavailable = false
+			m.progress = progressFromStats(msg.stats)
+			m.updateQueue(msg.stats)
+			return m, cmd
+		}
+		// rclone stops serving the API when it exits, and scripts generated
+		// before progress reporting never serve it. Keep polling while the
+		// lockfile shows the backup is still running.
+		if m.lock.Exists() {
+			m.rcUnavailable = true
+			return m, m.pollRunningBackup()
+		}
+		m.progress = finishedProgress(m.progress)
+		return m, nil
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -127,6 +282,29 @@ func (m BackupOpsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// resultMessage summarizes a finished backup for the view below this one
+func (m BackupOpsModel) resultMessage() string {
+	switch m.progress.Status {
+	case BackupCompleted:
+		if m.operation == BackupPair && m.summary != "" {
+			return fmt.Sprintf("Synced '%s': %s", m.pair, m.summary)
+		} else if m.operation == BackupPair {
+			return fmt.Sprintf("Synced '%s'", m.pair)
+		}
+		return "Backup completed successfully"
+	case BackupFailed:
+		if m.operation == BackupPair {
+			return fmt.Sprintf("Syncing '%s' failed: %s", m.pair, m.progress.ErrorMessage)
+		}
+		if m.progress.ErrorMessage != "" {
+			return "Backup failed: " + m.progress.ErrorMessage
+		}
+		return "Backup failed"
+	default:
+		return "Backup cancelled"
+	}
+}
+
 // View implements tea.Model
 func (m BackupOpsModel) View() string {
 	var b strings.Builder
@@ -141,12 +319,25 @@ func (m BackupOpsModel) View() string {
 		subtitle = "Manual sync in progress"
 	case BackupAutomated:
 		subtitle = "Automated backup"
+	case BackupAttached:
+		subtitle = "Scheduled backup in progress"
+	case BackupPair:
+		subtitle = fmt.Sprintf("Sync pair '%s'", m.pair)
 	}
 	b.WriteString(helper.RenderHeader(title, subtitle))
 
 	// Status
 	switch m.progress.Status {
 	case BackupIdle:
+		if m.waiting {
+			b.WriteString(styles.RenderInfo("No scheduled backup is running."))
+			b.WriteString("\n")
+			b.WriteString(styles.RenderMuted("Its progress shows here as soon as the next one starts."))
+			b.WriteString("\n\n")
+			b.WriteString(m.spinner.View())
+			b.WriteString(" Waiting...")
+			break
+		}
 		b.WriteString(styles.RenderInfo("Preparing backup..."))
 		b.WriteString("\n")
 		b.WriteString(m.spinner.View())
@@ -155,6 +346,16 @@ func (m BackupOpsModel) View() string {
 		if m.canceling {
 			b.WriteString(styles.RenderWarning("Cancelling backup..."))
 			b.WriteString("\n")
+		} else if m.operation == BackupPair {
+			b.WriteString(m.spinner.View())
+			b.WriteString(fmt.Sprintf(" Syncing '%s'...", m.pair))
+		} else if m.rcUnavailable {
+			b.WriteString(styles.RenderInfo("A scheduled backup is running, but rclone's remote control API is not answering."))
+			b.WriteString("\n")
+			b.WriteString(styles.RenderMuted("Regenerate the backup scripts to see live progress of future runs."))
+			b.WriteString("\n\n")
+			b.WriteString(m.spinner.View())
+			b.WriteString(" Waiting...")
 		} else {
 			// Progress bar
 			percent := 0.0
@@ -176,6 +377,14 @@ func (m BackupOpsModel) View() string {
 			// Statistics
 			b.WriteString(m.renderStats())
 			b.WriteString("\n")
+			if line := m.renderBandwidth(); line != "" {
+				b.WriteString(line)
+				b.WriteString("\n\n")
+			}
+			if queue := m.renderQueue(); queue != "" {
+				b.WriteString(queue)
+				b.WriteString("\n\n")
+			}
 
 			// Spinner
 			b.WriteString(m.spinner.View())
@@ -185,15 +394,25 @@ func (m BackupOpsModel) View() string {
 	case BackupCompleted:
 		b.WriteString(styles.RenderSuccess("✓ Backup completed successfully!"))
 		b.WriteString("\n\n")
+		if m.operation == BackupPair {
+			b.WriteString(m.summary)
+			b.WriteString("\n")
+			break
+		}
 		b.WriteString(m.renderSummary())
 
 	case BackupFailed:
 		b.WriteString(styles.RenderError("✗ Backup failed"))
 		b.WriteString("\n\n")
 		if m.progress.ErrorMessage != "" {
-			b.WriteString(styles.RenderError("Error: " + m.progress.ErrorMessage))
+			b.WriteString(renderErrorHint(m.progress.ErrorMessage, m.hint))
 			b.WriteString("\n\n")
 		}
+		if m.operation == BackupPair {
+			b.WriteString(styles.RenderMuted("The new session in the pair's log has the details."))
+			b.WriteString("\n")
+			break
+		}
 		b.WriteString(m.renderSummary())
 
 	case BackupCancelled:
@@ -204,10 +423,20 @@ func (m BackupOpsModel) View() string {
 
 	// Footer
 	helpText := ""
-	if m.progress.Status == BackupRunning {
+	if m.operation == BackupAttached && m.progress.Status == BackupRunning {
+		keys := runningBackupKeys
+		if m.rcUnavailable {
+			keys = withoutKey(withoutKey(keys, "l"), "p")
+		}
+		helpText = keyFooter(keys)
+	} else if m.operation == BackupPair && m.progress.Status == BackupRunning {
+		helpText = "q: Back (the sync keeps running)"
+	} else if m.progress.Status == BackupRunning {
 		helpText = "ctrl+c/q: Cancel backup"
 	} else if m.progress.Status != BackupIdle {
 		helpText = "enter: Return to menu • q: Quit"
+	} else if m.waiting {
+		helpText = "q/esc: Back"
 	}
 	b.WriteString(helper.RenderFooter(helpText))
 
@@ -242,6 +471,34 @@ func (m BackupOpsModel) renderStats() string {
 	return b.String()
 }
 
+// renderQueue renders the files in flight and recently completed, once an
+// attached backup has reported any, with the failures as an error
+func (m BackupOpsModel) renderQueue() string {
+	if len(m.queue.Active) == 0 && len(m.queue.Completed) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	header := fmt.Sprintf("Transfers: %d in flight, %d recently completed",
+		len(m.queue.Active), len(m.queue.Completed))
+	if m.queue.Errors > 0 {
+		b.WriteString(styles.RenderError(fmt.Sprintf("%s, %d failed", header, m.queue.Errors)))
+	} else {
+		b.WriteString(styles.RenderInfo(header))
+	}
+	b.WriteString("\n")
+	b.WriteString(m.queueTable.View())
+	return b.String()
+}
+
+// updateQueue follows the files of an attached backup in the transfer queue
+func (m *BackupOpsModel) updateQueue(stats *rclone.RCStats) {
+	m.queue = m.queue.Update(stats.Transferring, stats.Errors)
+	m.queueTable.SetRows(m.queue.Rows())
+	if m.queueTable.Cursor() >= len(m.queueTable.Rows()) {
+		m.queueTable.SetCursor(0)
+	}
+}
+
 // renderSummary renders post-backup summary
 func (m BackupOpsModel) renderSummary() string {
 	var b strings.Builder
@@ -313,10 +570,89 @@ func (m BackupOpsModel) tickProgress() tea.Cmd {
 	})
 }
 
-// cancelBackup returns a command to cancel the backup
+// backupCheckInterval is how often a view waiting for a scheduled backup
+// checks whether one has started
+const backupCheckInterval = 2 * time.Second
+
+// detectRunningBackup returns a command that attaches to a scheduled backup
+// if one is running
+func (m BackupOpsModel) detectRunningBackup() tea.Cmd {
+	lock, rc := m.lock, m.rc
+	return func() tea.Msg {
+		if !lock.Exists() {
+			return noBackupRunningMsg{}
+		}
+		stats, err := rc.Stats()
+		if err != nil {
+			return backupAttachedMsg{}
+		}
+		return backupAttachedMsg{stats: stats}
+	}
+}
+
+// pollRunningBackup returns a command that fetches the attached backup's
+// stats after a second
+func (m BackupOpsModel) pollRunningBackup() tea.Cmd {
+	rc := m.rc
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		stats, err := rc.Stats()
+		return rcStatsMsg{stats: stats, err: err}
+	})
+}
+
+// progressFromStats converts rclone's core/stats into backup progress
+func progressFromStats(stats *rclone.RCStats) BackupProgress {
+	elapsed := time.Duration(stats.ElapsedTime * float64(time.Second))
+	progress := BackupProgress{
+		Status:      BackupRunning,
+		FilesTotal:  stats.TotalTransfers,
+		FilesCopied: stats.Transfers,
+		BytesTotal:  stats.TotalBytes,
+		BytesCopied: stats.Bytes,
+		Speed:       stats.Speed / (1024 * 1024),
+		StartTime:   time.Now().Add(-elapsed),
+		ElapsedTime: elapsed,
+	}
+
+	if stats.ETA != nil {
+		progress.ETA = time.Duration(*stats.ETA * float64(time.Second))
+	}
+	if len(stats.Transferring) > 0 {
+		progress.CurrentFile = stats.Transferring[0].Name
+	}
+	if stats.Errors > 0 {
+		progress.ErrorMessage = fmt.Sprintf("%d error(s), see rclone_backup.log", stats.Errors)
+	}
+
+	return progress
+}
+
+// finishedProgress marks the last progress of an attached backup as done
+// once rclone has exited
+func finishedProgress(last BackupProgress) BackupProgress {
+	last.ETA = 0
+	last.CurrentFile = ""
+	last.ElapsedTime = time.Since(last.StartTime)
+	if last.ErrorMessage != "" {
+		last.Status = BackupFailed
+	} else {
+		last.Status = BackupCompleted
+	}
+	return last
+}
+
+// cancelBackup returns a command that stops the running rclone processes
+// and removes the lockfile they leave behind
 func (m BackupOpsModel) cancelBackup() tea.Cmd {
+	lock, rc := m.lock, m.rc
 	return func() tea.Msg {
-		// This would actually cancel the rclone process
+		rclone.DefaultRuns.CancelAll(rclone.CancelGrace)
+
+		// Nothing answering rclone's API means the lockfile is left over
+		if lock != nil && lock.Exists() && !rc.Running() {
+			lock.ForceRemove()
+		}
+
 		return BackupProgress{
 			Status:       BackupCancelled,
 			FilesTotal:   m.progress.FilesTotal,
@@ -360,3 +696,18 @@ func formatDuration(d time.Duration) string {
 	}
 	return fmt.Sprintf("%ds", s)
 }
+
+// noBackupRunningMsg is sent when no scheduled backup holds the lockfile
+type noBackupRunningMsg struct{}
+
+// backupAttachedMsg is sent when a running scheduled backup is found. stats
+// is nil when its rclone does not serve the remote control API.
+type backupAttachedMsg struct {
+	stats *rclone.RCStats
+}
+
+// rcStatsMsg carries the attached backup's latest stats
+type rcStatsMsg struct {
+	stats *rclone.RCStats
+	err   error
+}
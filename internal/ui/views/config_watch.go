@@ -0,0 +1,29 @@
+package views
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/filewatch"
+)
+
+// ConfigWatchMsg is the result of one check of the configuration files
+type ConfigWatchMsg struct {
+	Changed []string // Files written since the previous check
+}
+
+// ConfigChangedMsg is sent to every open view when config.json or
+// sync-config.json was changed, by this or another program, so views
+// showing their contents can reload them
+type ConfigChangedMsg struct {
+	Paths []string
+}
+
+// WatchConfigCmd returns a command that checks the watched files after
+// filewatch.Interval
+func WatchConfigCmd(w *filewatch.Watcher) tea.Cmd {
+	return tea.Tick(filewatch.Interval, func(time.Time) tea.Msg {
+		return ConfigWatchMsg{Changed: w.Changed()}
+	})
+}
@@ -29,22 +29,23 @@ const (
 
 // ConfigurationModel represents the configuration wizard state
 type ConfigurationModel struct {
-	rclone       *rclone.Manager
-	currentStep  ConfigurationStep
-	spinner      spinner.Model
-	textInput    textinput.Model
-	bucketList   list.Model
-	sourceRemote string
-	sourceBucket string
-	destRemote   string
-	destBucket   string
-	remotes      []rclone.Remote
-	buckets      []rclone.Bucket
-	loading      bool
-	error        error
-	complete     bool
-	width        int
-	height       int
+	rclone         *rclone.Manager
+	currentStep    ConfigurationStep
+	spinner        spinner.Model
+	textInput      textinput.Model
+	bucketList     list.Model
+	sourceRemote   string
+	sourceBucket   string
+	destRemote     string
+	destBucket     string
+	remotes        []rclone.Remote
+	buckets        []rclone.Bucket
+	loading        bool
+	error          error
+	credentialsErr error // Credential references that did not resolve
+	complete       bool
+	width          int
+	height         int
 }
 
 // NewConfigurationModel creates a new configuration wizard model
@@ -57,11 +58,14 @@ func NewConfigurationModel(rclonePath string) ConfigurationModel {
 	ti.Placeholder = "Enter value..."
 	ti.Focus()
 
+	rcloneMgr, credentialsErr := NewRcloneManager(rclonePath)
+
 	return ConfigurationModel{
-		rclone:      rclone.NewManager(rclonePath),
-		currentStep: StepWelcome,
-		spinner:     s,
-		textInput:   ti,
+		rclone:         rcloneMgr,
+		credentialsErr: credentialsErr,
+		currentStep:    StepWelcome,
+		spinner:        s,
+		textInput:      ti,
 	}
 }
 
@@ -82,9 +86,11 @@ func (m ConfigurationModel) Update(msg tea.Msg) (ConfigurationModel, tea.Cmd) {
 		switch msg.String() {
 		case "enter":
 			return m.handleEnter()
-		case "ctrl+c", "q":
+		case "ctrl+c":
+			return m, tea.Quit
+		case "q", "esc":
 			if m.currentStep == StepWelcome || m.complete {
-				return m, tea.Quit
+				return m, BackCmd()
 			}
 		}
 
@@ -127,6 +133,7 @@ func (m ConfigurationModel) View() string {
 	var b strings.Builder
 
 	b.WriteString(helper.RenderHeader("Configuration Wizard", "Set up your backup remotes and buckets"))
+	b.WriteString(renderCredentialsWarning(m.credentialsErr))
 
 	// Show current step
 	b.WriteString(m.renderCurrentStep())
@@ -134,7 +141,7 @@ func (m ConfigurationModel) View() string {
 
 	if m.error != nil {
 		b.WriteString("\n")
-		b.WriteString(styles.RenderError(fmt.Sprintf("Error: %v", m.error)))
+		b.WriteString(renderError(m.error))
 		b.WriteString("\n")
 	}
 
@@ -284,6 +291,7 @@ func (m ConfigurationModel) handleEnter() (ConfigurationModel, tea.Cmd) {
 			m.currentStep = StepSelectSourceBucket
 			m.loading = true
 			m.textInput.Reset()
+			m.textInput.SetValue(defaultBucket(m.sourceRemote))
 			return m, m.loadBuckets(m.sourceRemote)
 		}
 
@@ -299,6 +307,7 @@ func (m ConfigurationModel) handleEnter() (ConfigurationModel, tea.Cmd) {
 		if m.destRemote == "" {
 			m.destRemote = m.textInput.Value()
 			m.textInput.Reset()
+			m.textInput.SetValue(defaultBucket(m.destRemote))
 			return m, nil
 		} else {
 			m.destBucket = m.textInput.Value()
@@ -0,0 +1,29 @@
+package views
+
+import (
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// NewRcloneManager returns an rclone manager for rclonePath with the
+// credential references of the saved remotes resolved into its
+// environment. The references that resolve are used even when others fail;
+// the error then names the failed ones, for the view to show.
+func NewRcloneManager(rclonePath string) (*rclone.Manager, error) {
+	mgr := rclone.NewManager(rclonePath)
+	env, err := config.RcloneEnv()
+	mgr.SetEnv(env)
+	return mgr, err
+}
+
+// renderCredentialsWarning renders the credential references that did not
+// resolve, whose remotes fail to authenticate until they do, or nothing
+// when there is no error
+func renderCredentialsWarning(err error) string {
+	if err == nil {
+		return ""
+	}
+	return styles.RenderWarning("⚠ "+err.Error()) + "\n" +
+		styles.RenderMuted("Remotes using these references cannot authenticate; check them in Installation & Setup > Manage Remotes.") + "\n\n"
+}
@@ -0,0 +1,147 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/andreisuslov/cloud-sync/internal/cost"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/status"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// DashboardMsg carries a freshly loaded dashboard summary
+type DashboardMsg struct {
+	Summary status.Summary
+}
+
+// ProtectedSizeMsg carries the total size of the enabled pairs' folders and
+// the monthly bill estimated from it
+type ProtectedSizeMsg struct {
+	Bytes int64
+	Cost  *cost.Estimate // nil if it could not be estimated
+}
+
+// LoadDashboardCmd returns a command that gathers the dashboard summary from
+// the sync pairs, the logs and the LaunchAgent
+func LoadDashboardCmd(launchdMgr *launchd.Manager) tea.Cmd {
+	return func() tea.Msg {
+		return DashboardMsg{Summary: status.Load(launchdMgr, time.Now())}
+	}
+}
+
+// ProtectedSizeCmd returns a command that adds up the size of every enabled
+// pair's local folder and estimates what storing them costs. Walking large
+// folders is slow, so it is loaded separately from the rest of the dashboard.
+func ProtectedSizeCmd() tea.Cmd {
+	return func() tea.Msg {
+		sizes, _ := status.PairSizes()
+		var msg ProtectedSizeMsg
+		for _, size := range sizes {
+			msg.Bytes += size
+		}
+		msg.Cost, _ = status.EstimateCost(sizes, time.Now())
+		return msg
+	}
+}
+
+// ScrubsAcknowledgedMsg reports the result of acknowledging scrub problems
+type ScrubsAcknowledgedMsg struct {
+	Count int
+	Err   error
+}
+
+// AcknowledgeScrubsCmd returns a command that clears the dashboard's scrub
+// warning
+func AcknowledgeScrubsCmd() tea.Cmd {
+	return func() tea.Msg {
+		count, err := status.AcknowledgeScrubs()
+		return ScrubsAcknowledgedMsg{Count: count, Err: err}
+	}
+}
+
+// ScrubIssuesSummary describes unacknowledged scrub problems in one line
+func ScrubIssuesSummary(issues []logs.ScrubRun) string {
+	if len(issues) == 1 {
+		issue := issues[0]
+		return fmt.Sprintf("Integrity: '%s' at %s: %s", issue.Pair, issue.Destination, issue.Summary())
+	}
+	var pairs []string
+	for _, issue := range issues {
+		if !slices.Contains(pairs, issue.Pair) {
+			pairs = append(pairs, issue.Pair)
+		}
+	}
+	return fmt.Sprintf("Integrity: %d scrub runs found problems (%s)", len(issues), strings.Join(pairs, ", "))
+}
+
+// RenderDashboard renders the dashboard panel. A negative size means it is
+// still being calculated; estimate is nil until then.
+func RenderDashboard(summary *status.Summary, protectedSize int64, estimate *cost.Estimate, width int) string {
+	var b strings.Builder
+
+	if summary == nil {
+		b.WriteString(styles.RenderMuted("Loading status..."))
+	} else {
+		size := "calculating..."
+		if protectedSize >= 0 {
+			size = formatBytes(protectedSize)
+		}
+		b.WriteString(fmt.Sprintf("Pairs: %d (%d enabled)   Protected: %s",
+			summary.Pairs, summary.EnabledPairs, size))
+		if estimate != nil && estimate.Total() != "" {
+			b.WriteString("   Est. cost: ~" + estimate.Total())
+		}
+		b.WriteString("\n")
+
+		if len(summary.Remotes) > 0 {
+			labels := newRemoteLabels(summary.Remotes)
+			names := make([]string, 0, len(summary.Remotes))
+			for _, remote := range summary.Remotes {
+				names = append(names, labels.render(remote.Name))
+			}
+			b.WriteString("Remotes: " + strings.Join(names, " • ") + "\n")
+		}
+
+		lastRun := styles.RenderMuted("never")
+		if summary.LastRun != nil {
+			result := styles.RenderSuccess("✓ Success")
+			if !summary.LastRun.Success {
+				result = styles.RenderError("✗ Failed")
+			}
+			lastRun = summary.LastRun.EndTime.Format("Mon Jan 2 15:04") + " " + result
+		}
+
+		nextRun := styles.RenderMuted("not scheduled")
+		if !summary.NextRun.IsZero() {
+			nextRun = summary.NextRun.Format("Mon Jan 2 15:04")
+		}
+		b.WriteString(fmt.Sprintf("Last run: %s   Next run: %s", lastRun, nextRun))
+
+		for _, warning := range summary.Warnings {
+			b.WriteString("\n")
+			b.WriteString(styles.RenderWarning("⚠ " + warning))
+		}
+		if len(summary.ScrubIssues) > 0 {
+			b.WriteString("\n")
+			b.WriteString(styles.RenderError("✗ " + ScrubIssuesSummary(summary.ScrubIssues) + " • i: acknowledge"))
+		}
+	}
+
+	boxWidth := width - 6
+	if boxWidth < 40 {
+		boxWidth = 40
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.BorderColor).
+		Padding(0, 1).
+		Width(boxWidth).
+		Render(b.String())
+}
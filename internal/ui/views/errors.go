@@ -0,0 +1,36 @@
+package views
+
+import (
+	"errors"
+
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// hintedError is an error reported by a cloud-sync subprocess, with the
+// hint it printed about it
+type hintedError struct {
+	error
+	hint string
+}
+
+// renderError renders err for a view, followed by what to do about it when
+// it is of a kind the user can fix
+func renderError(err error) string {
+	hint := errkind.Hint(err)
+	var hinted *hintedError
+	if errors.As(err, &hinted) {
+		hint = hinted.hint
+	}
+	return renderErrorHint(err.Error(), hint)
+}
+
+// renderErrorHint renders an error a view only has the message of, with
+// hint when there is one
+func renderErrorHint(message, hint string) string {
+	s := styles.RenderError("Error: " + message)
+	if hint != "" {
+		s += "\n" + styles.RenderMuted(hint)
+	}
+	return s
+}
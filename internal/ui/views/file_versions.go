@@ -0,0 +1,355 @@
+package views
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// versionRows is how many files or versions the browser shows at once
+const versionRows = 15
+
+// VersionsModel browses the files of a sync pair's remote folder and the
+// versions a B2 or S3 bucket keeps of each
+type VersionsModel struct {
+	rclone     *rclone.Manager
+	pair       syncconfig.SyncPair
+	files      []rclone.IndexEntry
+	file       string               // Selected file, relative to the pair's remote path; "" while picking
+	versions   []rclone.FileVersion // Versions of file, newest first
+	cursor     int                  // Row of the list shown: files, or versions of file
+	fileCursor int                  // Row of file, to return to
+	confirming bool                 // Waiting for y/n before promoting the selected version
+	loading    bool
+	processing bool
+	message    string
+	err        error
+	width      int
+	height     int
+}
+
+// NewVersionsModel creates a version browser for a sync pair
+func NewVersionsModel(rcloneMgr *rclone.Manager, pair syncconfig.SyncPair) VersionsModel {
+	return VersionsModel{
+		rclone:  rcloneMgr,
+		pair:    pair,
+		loading: true,
+	}
+}
+
+// Init implements tea.Model
+func (m VersionsModel) Init() tea.Cmd {
+	return m.loadFiles(false)
+}
+
+// Update implements tea.Model
+func (m VersionsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case versionFilesLoaded:
+		m.loading = false
+		m.files = msg.files
+		m.err = msg.err
+		if m.cursor >= len(m.files) {
+			m.cursor = 0
+		}
+		return m, nil
+
+	case fileVersionsLoaded:
+		m.loading = false
+		m.err = msg.err
+		if msg.err != nil {
+			m.file = ""
+			m.cursor = m.fileCursor
+			return m, nil
+		}
+		m.versions = msg.versions
+		if m.cursor >= len(m.versions) {
+			m.cursor = 0
+		}
+		return m, nil
+
+	case versionActionDone:
+		m.processing = false
+		m.err = msg.err
+		m.message = ""
+		if msg.err != nil {
+			return m, nil
+		}
+		m.message = msg.message
+		if msg.reload {
+			m.loading = true
+			return m, m.loadVersions()
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading || m.processing {
+			return m, nil
+		}
+		if m.confirming {
+			m.confirming = false
+			if msg.String() == "y" {
+				m.processing = true
+				return m, m.promote(m.versions[m.cursor])
+			}
+			m.message = "Promote cancelled"
+			return m, nil
+		}
+
+		rows := len(m.files)
+		if m.file != "" {
+			rows = len(m.versions)
+		}
+		switch msg.String() {
+		case "q", "esc":
+			if m.file == "" {
+				return m, BackCmd()
+			}
+			m.file, m.versions = "", nil
+			m.cursor = m.fileCursor
+			m.message = ""
+			m.err = nil
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < rows-1 {
+				m.cursor++
+			}
+		case "enter":
+			if m.file == "" && rows > 0 {
+				m.file = m.files[m.cursor].Path
+				m.fileCursor, m.cursor = m.cursor, 0
+				m.message = ""
+				m.err = nil
+				m.loading = true
+				return m, m.loadVersions()
+			}
+		case "d":
+			if m.file == "" || rows == 0 {
+				break
+			}
+			if err := refuseReadOnly("Downloading versions"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			version := m.versions[m.cursor]
+			if version.Current {
+				m.err = fmt.Errorf("the current version is the one syncs keep; pick an old version to download")
+				return m, nil
+			}
+			m.processing = true
+			m.message = ""
+			m.err = nil
+			return m, m.download(version)
+		case "p":
+			if m.file == "" || rows == 0 {
+				break
+			}
+			if err := refuseReadOnly("Promoting versions"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			if m.versions[m.cursor].Current {
+				m.err = fmt.Errorf("this is already the current version")
+				return m, nil
+			}
+			m.err = nil
+			m.message = ""
+			m.confirming = true
+		case "r":
+			m.loading = true
+			if m.file != "" {
+				return m, m.loadVersions()
+			}
+			return m, m.loadFiles(true)
+		}
+	}
+
+	return m, nil
+}
+
+// View implements tea.Model
+func (m VersionsModel) View() string {
+	helper := NewViewHelper(m.width, m.height)
+	var b strings.Builder
+
+	subtitle := fmt.Sprintf("Files of '%s' on %s:%s", m.pair.Name, m.pair.RemoteName, m.pair.RemotePath)
+	if m.file != "" {
+		subtitle = fmt.Sprintf("Versions of %s", m.file)
+	}
+	b.WriteString(helper.RenderHeader("File Versions", subtitle))
+
+	if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	} else if m.message != "" {
+		b.WriteString(styles.RenderSuccess(m.message))
+		b.WriteString("\n\n")
+	}
+
+	switch {
+	case m.loading:
+		b.WriteString(styles.RenderInfo("Listing..."))
+		b.WriteString("\n")
+	case m.processing:
+		b.WriteString(styles.RenderInfo("Processing..."))
+		b.WriteString("\n")
+	case m.file != "":
+		b.WriteString(m.renderVersions())
+	case len(m.files) == 0:
+		b.WriteString("No files on the remote.\n")
+	default:
+		b.WriteString(m.renderFiles())
+	}
+
+	if m.confirming {
+		b.WriteString("\n")
+		b.WriteString(styles.RenderWarning(fmt.Sprintf("Make the version of %s current? The current one is kept as an old version. (y/n)",
+			m.versions[m.cursor].Time.Local().Format("2006-01-02 15:04:05"))))
+		b.WriteString("\n")
+	}
+
+	if m.file == "" {
+		b.WriteString(helper.RenderFooter(keyFooter(versionFileKeys)))
+	} else {
+		b.WriteString(helper.RenderFooter(keyFooter(versionKeys)))
+	}
+
+	return b.String()
+}
+
+// window returns the rows of a list of n to show around the cursor
+func (m VersionsModel) window(n int) (int, int) {
+	start := 0
+	if m.cursor >= versionRows {
+		start = m.cursor - versionRows + 1
+	}
+	return start, min(n, start+versionRows)
+}
+
+// renderFiles renders the files of the pair's remote folder
+func (m VersionsModel) renderFiles() string {
+	var b strings.Builder
+	start, end := m.window(len(m.files))
+	for i := start; i < end; i++ {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = styles.RenderHighlight("> ")
+		}
+		b.WriteString(fmt.Sprintf("%s%s  %s\n", cursor, m.files[i].Path, styles.RenderMuted(formatBytes(m.files[i].Size))))
+	}
+	if len(m.files) > versionRows {
+		b.WriteString(styles.RenderMuted(fmt.Sprintf("%d-%d of %d files", start+1, end, len(m.files))))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderVersions renders the versions of the selected file
+func (m VersionsModel) renderVersions() string {
+	var b strings.Builder
+	start, end := m.window(len(m.versions))
+	for i := start; i < end; i++ {
+		version := m.versions[i]
+		cursor := "  "
+		if i == m.cursor {
+			cursor = styles.RenderHighlight("> ")
+		}
+		line := fmt.Sprintf("%s  %10s", version.Time.Local().Format("2006-01-02 15:04:05"), formatBytes(version.Size))
+		if version.Current {
+			line += "  " + styles.RenderSuccess("current")
+		}
+		b.WriteString(cursor + line + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("Old versions are shown by upload time, the current one by modification time."))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// remoteFile returns the path of a file of the pair on its remote
+func (m VersionsModel) remoteFile(file string) string {
+	return path.Join(m.pair.RemotePath, file)
+}
+
+// loadFiles returns a command that lists the files of the pair's remote
+// folder, from the listing cache unless refresh is set
+func (m VersionsModel) loadFiles(refresh bool) tea.Cmd {
+	folder := m.pair.RemoteName + ":" + m.pair.RemotePath
+	return func() tea.Msg {
+		cache := listingCache()
+		if refresh {
+			cache.Forget(folder)
+		}
+		files, _, err := m.rclone.CachedListFiles(cache, folder)
+		return versionFilesLoaded{files: files, err: err}
+	}
+}
+
+// loadVersions returns a command that lists the versions of the selected
+// file
+func (m VersionsModel) loadVersions() tea.Cmd {
+	file := m.remoteFile(m.file)
+	return func() tea.Msg {
+		versions, err := m.rclone.ListFileVersions(m.pair.RemoteName, file)
+		return fileVersionsLoaded{versions: versions, err: err}
+	}
+}
+
+// download returns a command that copies an old version into the pair's
+// local folder, next to the file and under the version's name, so nothing
+// is overwritten
+func (m VersionsModel) download(version rclone.FileVersion) tea.Cmd {
+	local := filepath.Join(m.pair.LocalPath, filepath.FromSlash(path.Dir(m.file)), path.Base(version.Path))
+	return func() tea.Msg {
+		err := m.rclone.DownloadVersion(m.pair.RemoteName, version, local)
+		return versionActionDone{message: fmt.Sprintf("Downloaded to %s", local), err: err}
+	}
+}
+
+// promote returns a command that makes an old version the current one
+func (m VersionsModel) promote(version rclone.FileVersion) tea.Cmd {
+	file := m.remoteFile(m.file)
+	folder := m.pair.RemoteName + ":" + m.pair.RemotePath
+	return func() tea.Msg {
+		err := m.rclone.PromoteVersion(m.pair.RemoteName, version, file)
+		// The file's size changes with its current version
+		listingCache().Forget(folder)
+		return versionActionDone{
+			message: fmt.Sprintf("Promoted the version of %s", version.Time.Local().Format("2006-01-02 15:04:05")),
+			err:     err,
+			reload:  true,
+		}
+	}
+}
+
+// Message types
+type versionFilesLoaded struct {
+	files []rclone.IndexEntry
+	err   error
+}
+
+type fileVersionsLoaded struct {
+	versions []rclone.FileVersion
+	err      error
+}
+
+type versionActionDone struct {
+	message string
+	err     error
+	reload  bool // The versions changed
+}
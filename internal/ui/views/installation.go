@@ -2,33 +2,54 @@ package views
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/config"
 	"github.com/andreisuslov/cloud-sync/internal/installer"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/scripts"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Style definitions using lipgloss
+// Styles for the setup wizard, built from the active theme by
+// buildInstallationStyles
 var (
-	// Color palette
-	colorPrimary   = lipgloss.Color("62")
-	colorSecondary = lipgloss.Color("241")
-	colorSuccess   = lipgloss.Color("42")
-	colorWarning   = lipgloss.Color("214")
-	colorError     = lipgloss.Color("196")
-	colorMuted     = lipgloss.Color("243")
-	colorWhite     = lipgloss.Color("255")
+	baseStyle          lipgloss.Style
+	helpStyle          lipgloss.Style
+	statusStyle        lipgloss.Style
+	successStatusStyle lipgloss.Style
+	warningStatusStyle lipgloss.Style
+	errorStatusStyle   lipgloss.Style
+	pendingStyle       lipgloss.Style
+	inProgressStyle    lipgloss.Style
+	completeStyle      lipgloss.Style
+	failedStyle        lipgloss.Style
+	skippedStyle       lipgloss.Style
+	outputBoxStyle     lipgloss.Style
+	outputTitleStyle   lipgloss.Style
+	outputContentStyle lipgloss.Style
+)
 
+// buildInstallationStyles creates the wizard styles from the current theme
+func buildInstallationStyles() {
 	// Base styles
 	baseStyle = lipgloss.NewStyle().
 			Padding(1, 2).
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorPrimary)
+			BorderForeground(styles.PrimaryColor)
 
 	helpStyle = lipgloss.NewStyle().
-			Foreground(colorSecondary).
+			Foreground(styles.MutedColor).
 			MarginTop(1)
 
 	statusStyle = lipgloss.NewStyle().
@@ -36,45 +57,45 @@ var (
 			MarginTop(1)
 
 	successStatusStyle = statusStyle.Copy().
-				Foreground(colorSuccess)
+				Foreground(styles.SuccessColor)
 
 	warningStatusStyle = statusStyle.Copy().
-				Foreground(colorWarning)
+				Foreground(styles.WarningColor)
 
 	errorStatusStyle = statusStyle.Copy().
-				Foreground(colorError)
+				Foreground(styles.ErrorColor)
 
 	// Status icon styles
 	pendingStyle = lipgloss.NewStyle().
-			Foreground(colorSecondary)
+			Foreground(styles.MutedColor)
 
 	inProgressStyle = lipgloss.NewStyle().
-			Foreground(colorWarning)
+			Foreground(styles.WarningColor)
 
 	completeStyle = lipgloss.NewStyle().
-			Foreground(colorSuccess)
+			Foreground(styles.SuccessColor)
 
 	failedStyle = lipgloss.NewStyle().
-			Foreground(colorError)
+			Foreground(styles.ErrorColor)
 
 	skippedStyle = lipgloss.NewStyle().
-			Foreground(colorMuted)
+			Foreground(styles.MutedColor)
 
 	// Output box styles
 	outputBoxStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorPrimary).
+			BorderForeground(styles.PrimaryColor).
 			Padding(1, 2).
 			MarginTop(1)
 
 	outputTitleStyle = lipgloss.NewStyle().
-			Foreground(colorPrimary).
+			Foreground(styles.PrimaryColor).
 			Bold(true).
 			MarginBottom(1)
 
 	outputContentStyle = lipgloss.NewStyle().
-			Foreground(colorSecondary)
-)
+			Foreground(styles.MutedColor)
+}
 
 // InstallationItem represents an installation step
 type InstallationItem struct {
@@ -106,15 +127,27 @@ type ConfigurationSetupModel struct {
 	width        int
 	height       int
 	err          error
-	quitting     bool
 	installer    *installer.Installer
 	statusMsg    string
 	outputBuffer []string // Buffer to store command output
 	showOutput   bool     // Whether to show the output box
+
+	// Install log panel for long-running installs
+	spinner    spinner.Model
+	installLog viewport.Model
+	logLines   []string
+	installing string // Title of the step being installed, empty when idle
+	logSummary string // Final summary once the install finishes
+
+	// Saved progress so a relaunch resumes where the user left off
+	configManager *config.Manager
+	progress      config.SetupProgress
 }
 
 // NewConfigurationSetupModel creates a new configuration setup model
 func NewConfigurationSetupModel() ConfigurationSetupModel {
+	buildInstallationStyles()
+
 	items := []InstallationItem{
 		{
 			title:       "1. Check rclone Installation",
@@ -146,6 +179,11 @@ func NewConfigurationSetupModel() ConfigurationSetupModel {
 			description: "Verify connectivity to configured remotes",
 			status:      StatusPending,
 		},
+		{
+			title:       "7. Generate Backup Scripts",
+			description: "Write the backup scripts to your bin directory",
+			status:      StatusPending,
+		},
 	}
 
 	// Convert to list items
@@ -160,7 +198,12 @@ func NewConfigurationSetupModel() ConfigurationSetupModel {
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
 
-	return ConfigurationSetupModel{
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+
+	m := ConfigurationSetupModel{
+		spinner:      s,
+		installLog:   viewport.New(76, installLogHeight),
 		list:         l,
 		items:        items,
 		currentStep:  0,
@@ -170,6 +213,77 @@ func NewConfigurationSetupModel() ConfigurationSetupModel {
 		outputBuffer: make([]string, 0),
 		showOutput:   false,
 	}
+
+	if configManager, err := config.NewManager(); err == nil {
+		m.configManager = configManager
+		if appConfig, err := configManager.Load(); err == nil {
+			m.resume(appConfig.Setup)
+		}
+	}
+
+	return m
+}
+
+// resume marks previously completed steps and selects the first one left
+func (m *ConfigurationSetupModel) resume(progress config.SetupProgress) {
+	m.progress = progress
+	if len(progress.CompletedSteps) == 0 {
+		return
+	}
+
+	next := -1
+	for i := range m.items {
+		if progress.StepDone(i + 1) {
+			m.items[i].status = StatusComplete
+		} else if next < 0 {
+			next = i
+		}
+	}
+	m.UpdateStepStatus(0, m.items[0].status)
+
+	if next < 0 {
+		m.statusMsg = "✓ Setup complete. Press R to start over."
+		return
+	}
+	m.list.Select(next)
+	m.statusMsg = fmt.Sprintf("Resuming setup at step %d. Press R to start over.", next+1)
+}
+
+// recordProgress saves the outcome of a step so setup can resume later
+func (m *ConfigurationSetupModel) recordProgress(step int, success bool) {
+	m.progress.SetStep(step, success)
+
+	switch step {
+	case 1, 3:
+		m.progress.ToolsInstalled = success
+	case 4, 6:
+		m.progress.RemotesConfigured = success
+	case 7:
+		m.progress.ScriptsGenerated = success
+	}
+
+	if m.configManager != nil {
+		if err := m.configManager.UpdateSetupProgress(m.progress); err != nil {
+			m.statusMsg = fmt.Sprintf("Error: failed to save setup progress: %v", err)
+		}
+	}
+}
+
+// resetProgress clears saved progress and starts setup from the first step
+func (m *ConfigurationSetupModel) resetProgress() {
+	m.progress = config.SetupProgress{}
+	for i := range m.items {
+		m.items[i].status = StatusPending
+	}
+	m.UpdateStepStatus(0, StatusPending)
+	m.list.Select(0)
+	m.statusMsg = "Setup progress cleared"
+
+	if m.configManager != nil {
+		if err := m.configManager.UpdateSetupProgress(m.progress); err != nil {
+			m.statusMsg = fmt.Sprintf("Error: failed to clear setup progress: %v", err)
+		}
+	}
 }
 
 // Init initializes the configuration setup model
@@ -184,16 +298,35 @@ func (m ConfigurationSetupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		// Adjust list size based on whether output is shown
-		listHeight := msg.Height - 4
+		// Adjust list size based on whether output is shown, leaving room
+		// for the border, padding and the wrapped help below the list
+		listHeight := msg.Height - 9
 		if m.showOutput {
 			// Split screen: 60% for list, 40% for output
 			listHeight = int(float64(msg.Height) * 0.5)
 		}
 		m.list.SetSize(msg.Width-4, listHeight)
+		m.installLog.Width = msg.Width - 8
 		return m, nil
 
+	case spinner.TickMsg:
+		if m.installing == "" {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case installLogMsg:
+		m.appendLogLine(msg.line)
+		return m, waitForInstallLog(msg.stream)
+
 	case installStepCompleteMsg:
+		if msg.step == m.installing {
+			m.installing = ""
+			m.logSummary = installLogSummary(msg, len(m.logLines))
+		}
+
 		// Update the step status based on the result
 		for i := range m.items {
 			if m.items[i].title == msg.step {
@@ -209,11 +342,20 @@ func (m ConfigurationSetupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.statusMsg = msg.message
 					} else if msg.err != nil {
 						m.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+						if hint := errkind.Hint(msg.err); hint != "" {
+							m.statusMsg += "\n" + hint
+						}
 					} else {
 						m.statusMsg = "Error: Unknown error occurred"
 					}
 				}
 				m.UpdateStepStatus(i, m.items[i].status)
+				m.recordProgress(i+1, msg.success)
+				for j := range m.items {
+					if msg.suggest != "" && m.items[j].title == msg.suggest {
+						m.list.Select(j)
+					}
+				}
 				
 				// Add output to buffer if present
 				if msg.output != "" {
@@ -231,18 +373,54 @@ func (m ConfigurationSetupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "q", "ctrl+c":
-			m.quitting = true
+		case "ctrl+c":
 			return m, tea.Quit
 
+		case "q", "esc":
+			return m, BackCmd()
+
 		case "enter":
+			// Only one install can stream into the log at a time
+			if m.installing != "" {
+				return m, nil
+			}
+			if err := refuseReadOnly("Running setup steps"); err != nil {
+				m.statusMsg = fmt.Sprintf("Error: %v", err)
+				return m, nil
+			}
 			// Execute the selected installation step
 			selectedItem := m.list.SelectedItem()
 			if selectedItem != nil {
 				item := selectedItem.(InstallationItem)
+				if strings.Contains(item.title, "Install/Update rclone") {
+					return m.startInstall(item)
+				}
 				return m, m.executeStep(item)
 			}
 
+		case "R":
+			if err := refuseReadOnly("Starting setup over"); err != nil {
+				m.statusMsg = fmt.Sprintf("Error: %v", err)
+				return m, nil
+			}
+			if m.installing == "" {
+				m.resetProgress()
+			}
+			return m, nil
+
+		case "e":
+			// Edit or rename the remotes stored in the configuration
+			if m.installing == "" && m.configManager != nil {
+				return m, OpenViewCmd(NewRemotesModel(m.configManager))
+			}
+			return m, nil
+
+		case "pgup", "pgdown":
+			// Scroll the install log
+			var cmd tea.Cmd
+			m.installLog, cmd = m.installLog.Update(msg)
+			return m, cmd
+
 		case "up", "k":
 			// Wrap around to bottom when at top
 			if m.list.Index() == 0 {
@@ -266,11 +444,19 @@ func (m ConfigurationSetupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // View renders the configuration setup view
 func (m ConfigurationSetupModel) View() string {
-	if m.quitting {
-		return "Configuration cancelled.\n"
+	// Wrap the text below the list inside the border and padding
+	textWidth := m.width - 6
+	if textWidth < 20 {
+		textWidth = 20
 	}
 
-	helpText := helpStyle.Render("\n↑/↓ or j/k: navigate (wrap-around) • enter: execute step • q: quit")
+	helpText := helpStyle.Width(textWidth).Render("↑/↓ or j/k: navigate (wrap-around) • enter: execute step • e: edit remotes • R: start over • q: back")
+	if len(m.logLines) > 0 {
+		helpText = helpStyle.Width(textWidth).Render("↑/↓ or j/k: navigate (wrap-around) • enter: execute step • e: edit remotes • pgup/pgdn: scroll log • R: start over • q: back")
+	}
+	if ReadOnly() {
+		helpText = helpStyle.Width(textWidth).Render("Read-only • ↑/↓ or j/k: navigate (wrap-around) • e: view remotes • q: back")
+	}
 
 	statusText := ""
 	if m.statusMsg != "" {
@@ -283,10 +469,19 @@ func (m ConfigurationSetupModel) View() string {
 		} else {
 			msgStyle = warningStatusStyle
 		}
-		statusText = msgStyle.Render("\n" + m.statusMsg)
+		statusText = msgStyle.Width(textWidth).Render(m.statusMsg)
 	}
 
-	mainContent := baseStyle.Render(m.list.View() + helpText + statusText)
+	content := lipgloss.JoinVertical(lipgloss.Left, m.list.View(), helpText)
+	if statusText != "" {
+		content = lipgloss.JoinVertical(lipgloss.Left, content, statusText)
+	}
+	mainContent := baseStyle.Render(content)
+
+	// The install log replaces the output box while it has content
+	if m.installing != "" || len(m.logLines) > 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, mainContent, m.renderInstallLog())
+	}
 
 	// Add output box if there's output to show
 	if m.showOutput && len(m.outputBuffer) > 0 {
@@ -308,6 +503,12 @@ func (m ConfigurationSetupModel) View() string {
 
 // executeStep executes a specific configuration step
 func (m ConfigurationSetupModel) executeStep(item InstallationItem) tea.Cmd {
+	// Interactive steps hand the terminal over to a child process, so they
+	// must return the exec command itself rather than run inside a tea.Cmd
+	if strings.Contains(item.title, "Manage Remotes") {
+		return m.manageRemotes(item)
+	}
+
 	return func() tea.Msg {
 		// Add panic recovery to prevent UI crashes
 		defer func() {
@@ -327,14 +528,12 @@ func (m ConfigurationSetupModel) executeStep(item InstallationItem) tea.Cmd {
 			return m.checkRcloneInstallation(item)
 		case strings.Contains(item.title, "Check rclone Version"):
 			return m.checkRcloneVersion(item)
-		case strings.Contains(item.title, "Install/Update rclone"):
-			return m.installOrUpdateRclone(item)
-		case strings.Contains(item.title, "Manage Remotes"):
-			return m.manageRemotes(item)
 		case strings.Contains(item.title, "List Configured Remotes"):
 			return m.listRemotes(item)
 		case strings.Contains(item.title, "Test Remote Connection"):
 			return m.testRemoteConnection(item)
+		case strings.Contains(item.title, "Generate Backup Scripts"):
+			return m.generateScripts(item)
 		default:
 			return installStepCompleteMsg{
 				step:    item.title,
@@ -367,7 +566,7 @@ func (m ConfigurationSetupModel) checkRcloneInstallation(item InstallationItem)
 	return installStepCompleteMsg{
 		step:    item.title,
 		success: false,
-		err:     fmt.Errorf("rclone is not installed"),
+		err:     errkind.ErrRcloneMissing,
 		message: "✗ rclone is not installed",
 	}
 }
@@ -384,6 +583,19 @@ func (m ConfigurationSetupModel) checkRcloneVersion(item InstallationItem) insta
 			output:  output,
 		}
 	}
+
+	// Too old a release cannot run every sync; offer the upgrade step
+	if parsed, err := rclone.ParseVersion(version); err == nil && parsed.Less(rclone.MinVersion) {
+		return installStepCompleteMsg{
+			step:    item.title,
+			success: false,
+			err:     &rclone.VersionError{Feature: rclone.FeatureBaseline, Installed: parsed, Required: rclone.MinVersion},
+			message: fmt.Sprintf("✗ rclone %s is older than %s. Press enter to upgrade it with Homebrew.", parsed, rclone.MinVersion),
+			output:  output,
+			suggest: m.items[2].title,
+		}
+	}
+
 	return installStepCompleteMsg{
 		step:    item.title,
 		success: true,
@@ -392,79 +604,139 @@ func (m ConfigurationSetupModel) checkRcloneVersion(item InstallationItem) insta
 	}
 }
 
-// installOrUpdateRclone installs or updates rclone
-func (m ConfigurationSetupModel) installOrUpdateRclone(item InstallationItem) installStepCompleteMsg {
+// startInstall clears the install log and starts streaming an install into it
+func (m ConfigurationSetupModel) startInstall(item InstallationItem) (tea.Model, tea.Cmd) {
+	m.installing = item.title
+	m.logSummary = ""
+	m.logLines = nil
+	m.installLog.SetContent("")
+	m.showOutput = false
+	m.statusMsg = ""
+
+	for i := range m.items {
+		if m.items[i].title == item.title {
+			m.UpdateStepStatus(i, StatusInProgress)
+		}
+	}
+
+	stream := &installStream{
+		lines: make(chan string, 64),
+		done:  make(chan installStepCompleteMsg, 1),
+	}
+	go func() {
+		result := m.installOrUpdateRclone(item, func(line string) { stream.lines <- line })
+		close(stream.lines)
+		stream.done <- result
+	}()
+
+	return m, tea.Batch(m.spinner.Tick, waitForInstallLog(stream))
+}
+
+// installOrUpdateRclone installs or updates rclone, installing Homebrew
+// first if needed, and streams all command output to onLine
+func (m ConfigurationSetupModel) installOrUpdateRclone(item InstallationItem, onLine installer.LineFunc) installStepCompleteMsg {
 	if !m.installer.CheckHomebrewInstalled() {
-		return installStepCompleteMsg{
-			step:    item.title,
-			success: false,
-			err:     fmt.Errorf("homebrew is required but not installed"),
-			message: "✗ Homebrew is required. Please install Homebrew first.",
+		onLine("==> Homebrew not found, installing it first")
+		if err := m.installer.InstallHomebrewStreaming(onLine); err != nil {
+			return installStepCompleteMsg{
+				step:    item.title,
+				success: false,
+				err:     err,
+				message: fmt.Sprintf("✗ Failed to install Homebrew: %v", err),
+			}
 		}
 	}
 
 	if m.installer.CheckRcloneInstalled() {
 		// rclone is installed, try to update it
-		output, err := m.installer.UpdateRcloneWithOutput()
-		if err != nil {
-			// Update might fail if already up-to-date
-			if strings.Contains(err.Error(), "already installed") {
-				return installStepCompleteMsg{
-					step:    item.title,
-					success: true,
-					message: "✓ rclone is already up-to-date",
-					output:  output,
-				}
-			}
+		if err := m.installer.UpdateRcloneStreaming(onLine); err != nil {
 			return installStepCompleteMsg{
 				step:    item.title,
 				success: false,
 				err:     err,
 				message: fmt.Sprintf("✗ Failed to update rclone: %v", err),
-				output:  output,
 			}
 		}
 		return installStepCompleteMsg{
 			step:    item.title,
 			success: true,
-			message: "✓ rclone updated successfully via Homebrew",
-			output:  output,
+			message: "✓ rclone is up-to-date via Homebrew",
 		}
 	}
 
 	// rclone is not installed, install it
-	output, err := m.installer.InstallRcloneWithOutput()
-	if err != nil {
+	if err := m.installer.InstallRcloneStreaming(onLine); err != nil {
 		return installStepCompleteMsg{
 			step:    item.title,
 			success: false,
 			err:     err,
 			message: fmt.Sprintf("✗ Failed to install rclone: %v", err),
-			output:  output,
 		}
 	}
 	return installStepCompleteMsg{
 		step:    item.title,
 		success: true,
 		message: "✓ rclone installed successfully via Homebrew",
-		output:  output,
 	}
 }
 
+// appendLogLine adds a line to the install log and keeps it scrolled to the end
+func (m *ConfigurationSetupModel) appendLogLine(line string) {
+	m.logLines = append(m.logLines, line)
+	if len(m.logLines) > installLogLimit {
+		m.logLines = m.logLines[len(m.logLines)-installLogLimit:]
+	}
+	atBottom := m.installLog.AtBottom()
+	m.installLog.SetContent(strings.Join(m.logLines, "\n"))
+	if atBottom {
+		m.installLog.GotoBottom()
+	}
+}
+
+// renderInstallLog renders the install log panel with its spinner or summary
+func (m ConfigurationSetupModel) renderInstallLog() string {
+	var title string
+	if m.installing != "" {
+		title = outputTitleStyle.Render(fmt.Sprintf("%s Installing... (%d lines)", m.spinner.View(), len(m.logLines)))
+	} else {
+		title = outputTitleStyle.Render("Install Log:")
+	}
+
+	body := outputContentStyle.Render(m.installLog.View())
+	if len(m.logLines) == 0 {
+		body = outputContentStyle.Render("Waiting for output...")
+	}
+	if m.logSummary != "" {
+		body += "\n\n" + m.logSummary
+	}
+
+	return outputBoxStyle.Width(m.width - 8).Render(title + "\n" + body)
+}
+
+// installLogSummary describes how a streamed install finished
+func installLogSummary(msg installStepCompleteMsg, lines int) string {
+	if msg.success {
+		return completeStyle.Render(fmt.Sprintf("✓ Finished successfully • %d lines of output", lines))
+	}
+	return failedStyle.Render(fmt.Sprintf("✗ Failed • %d lines of output, scroll up for details", lines))
+}
+
 // manageRemotes opens the rclone config interactive wizard
-func (m ConfigurationSetupModel) manageRemotes(item InstallationItem) tea.Msg {
+func (m ConfigurationSetupModel) manageRemotes(item InstallationItem) tea.Cmd {
 	if !m.installer.CheckRcloneInstalled() {
-		return installStepCompleteMsg{
-			step:    item.title,
-			success: false,
-			err:     fmt.Errorf("rclone is not installed"),
-			message: "✗ rclone must be installed first",
+		return func() tea.Msg {
+			return installStepCompleteMsg{
+				step:    item.title,
+				success: false,
+				err:     errkind.ErrRcloneMissing,
+				message: "✗ rclone must be installed first",
+			}
 		}
 	}
 
 	// Return a tea.Exec command to run rclone config interactively
 	// This will suspend the Bubbletea program and give control to rclone
-	return tea.Exec(m.installer.GetRcloneConfigCmd(), func(err error) tea.Msg {
+	return tea.Exec(execFunc(m.installer.GetRcloneConfigCmd()), func(err error) tea.Msg {
 		if err != nil {
 			return installStepCompleteMsg{
 				step:    item.title,
@@ -487,7 +759,7 @@ func (m ConfigurationSetupModel) listRemotes(item InstallationItem) installStepC
 		return installStepCompleteMsg{
 			step:    item.title,
 			success: false,
-			err:     fmt.Errorf("rclone is not installed"),
+			err:     errkind.ErrRcloneMissing,
 			message: "✗ rclone must be installed first",
 		}
 	}
@@ -525,7 +797,7 @@ func (m ConfigurationSetupModel) testRemoteConnection(item InstallationItem) ins
 		return installStepCompleteMsg{
 			step:    item.title,
 			success: false,
-			err:     fmt.Errorf("rclone is not installed"),
+			err:     errkind.ErrRcloneMissing,
 			message: "✗ rclone must be installed first",
 		}
 	}
@@ -560,6 +832,148 @@ func (m ConfigurationSetupModel) testRemoteConnection(item InstallationItem) ins
 	}
 }
 
+// generateScripts writes the backup scripts from the saved configuration
+func (m ConfigurationSetupModel) generateScripts(item InstallationItem) installStepCompleteMsg {
+	if m.configManager == nil {
+		return installStepCompleteMsg{
+			step:    item.title,
+			success: false,
+			message: "✗ Configuration is unavailable",
+		}
+	}
+
+	appConfig, err := m.configManager.Load()
+	if err != nil {
+		return installStepCompleteMsg{
+			step:    item.title,
+			success: false,
+			err:     err,
+			message: fmt.Sprintf("✗ Failed to load configuration: %v", err),
+		}
+	}
+
+	rclonePath := appConfig.RclonePath
+	if path, err := m.installer.GetRclonePath(); err == nil {
+		rclonePath = path
+	}
+
+	scriptConfig := backupScriptConfig(appConfig, rclonePath)
+	if err := scripts.ValidateConfig(scriptConfig); err != nil {
+		return installStepCompleteMsg{
+			step:    item.title,
+			success: false,
+			err:     err,
+			message: fmt.Sprintf("✗ Configure the backup source and destination first: %v", err),
+		}
+	}
+
+	generator := scripts.NewGenerator()
+	if err := generator.Install(scriptConfig); err != nil {
+		return installStepCompleteMsg{
+			step:    item.title,
+			success: false,
+			err:     err,
+			message: fmt.Sprintf("✗ %v", err),
+		}
+	}
+
+	message := fmt.Sprintf("✓ Backup scripts written to %s", appConfig.BinDir)
+	if overrides := generator.Overrides(); len(overrides) > 0 {
+		message += fmt.Sprintf(" (custom templates: %s)", strings.Join(overrides, ", "))
+	}
+	return installStepCompleteMsg{
+		step:    item.title,
+		success: true,
+		message: message,
+	}
+}
+
+// backupScriptConfig returns the settings the backup scripts are generated
+// from, running rclone at rclonePath
+func backupScriptConfig(appConfig *config.AppConfig, rclonePath string) *scripts.Config {
+	// Remotes picked without a bucket use their default one
+	syncConfig := appConfig.SyncConfig
+	if syncConfig.SourceBucket == "" {
+		syncConfig.SourceBucket = appConfig.DefaultBucket(syncConfig.SourceRemote)
+	}
+	if syncConfig.DestBucket == "" {
+		syncConfig.DestBucket = appConfig.DefaultBucket(syncConfig.DestRemote)
+	}
+
+	// The scheduled backup runs the sync pairs with this binary
+	program, _ := os.Executable()
+	return &scripts.Config{
+		HomeDir:      appConfig.HomeDir,
+		Username:     launchd.CurrentUsername(),
+		RclonePath:   rclonePath,
+		SourceRemote: syncConfig.SourceRemote,
+		SourceBucket: syncConfig.SourceBucket,
+		DestRemote:   syncConfig.DestRemote,
+		DestBucket:   syncConfig.DestBucket,
+		LogDir:       appConfig.LogDir,
+		BinDir:       appConfig.BinDir,
+		RCAddr:       appConfig.LaunchAgent.RCAddress(),
+		CloudSync:    program,
+	}
+}
+
+// regenerateScripts rewrites the backup scripts in the bin directory, if
+// they were generated, after a change to the settings they were made from.
+// The LaunchAgent runs monthly_backup.sh, so it picks up the new scripts.
+func regenerateScripts(appConfig *config.AppConfig) error {
+	installed := false
+	for _, name := range scripts.ScriptNames {
+		if _, err := os.Stat(filepath.Join(appConfig.BinDir, name)); err == nil {
+			installed = true
+			break
+		}
+	}
+	if !installed {
+		return nil
+	}
+	if err := scripts.NewGenerator().Install(backupScriptConfig(appConfig, appConfig.RclonePath)); err != nil {
+		return fmt.Errorf("failed to regenerate backup scripts: %w", err)
+	}
+	return nil
+}
+
+// execFunc adapts a blocking function to tea.ExecCommand. The wrapped
+// function wires its own stdio, so the setters are no-ops.
+type execFunc func() error
+
+func (f execFunc) Run() error           { return f() }
+func (f execFunc) SetStdin(io.Reader)  {}
+func (f execFunc) SetStdout(io.Writer) {}
+func (f execFunc) SetStderr(io.Writer) {}
+
+const (
+	installLogHeight = 10   // Visible lines in the install log panel
+	installLogLimit  = 1000 // Lines kept in the install log
+)
+
+// installStream carries output lines and the final result of a running install
+type installStream struct {
+	lines chan string
+	done  chan installStepCompleteMsg
+}
+
+// installLogMsg delivers one line of install output
+type installLogMsg struct {
+	line   string
+	stream *installStream
+}
+
+// waitForInstallLog waits for the next output line, or the final result once
+// the output is exhausted
+func waitForInstallLog(stream *installStream) tea.Cmd {
+	return func() tea.Msg {
+		if line, ok := <-stream.lines; ok {
+			return installLogMsg{line: line, stream: stream}
+		}
+		return <-stream.done
+	}
+}
+
 // installStepCompleteMsg is sent when a configuration step completes
 type installStepCompleteMsg struct {
 	step    string
@@ -567,6 +981,7 @@ type installStepCompleteMsg struct {
 	err     error
 	message string
 	output  string // Command output to display in the output box
+	suggest string // Title of a step to select next, e.g. the one fixing a failure
 }
 
 // UpdateStepStatus updates the status of a specific step
@@ -615,7 +1030,7 @@ func GetStatusStyle(status InstallStatus) lipgloss.Style {
 	case StatusSkipped:
 		return skippedStyle
 	default:
-		return lipgloss.NewStyle().Foreground(colorWhite)
+		return lipgloss.NewStyle().Foreground(styles.TextColor)
 	}
 }
 
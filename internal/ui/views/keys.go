@@ -0,0 +1,179 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ViewKey is a key of a view and what it does, as the view's footer shows
+// it. Keys that change something are left out in read-only mode.
+type ViewKey struct {
+	Key    string
+	Help   string
+	Writes bool
+}
+
+// KeySection is the keys of one view, for the help screen and the key
+// reference of 'cloud-sync docs'
+type KeySection struct {
+	Title string
+	Keys  []ViewKey
+}
+
+// keyFooter joins keys into a footer, e.g. "r: Refresh • q: Back". In
+// read-only mode the keys that change something are left out.
+func keyFooter(keys []ViewKey) string {
+	parts := make([]string, 0, len(keys)+1)
+	if ReadOnly() {
+		parts = append(parts, "Read-only")
+	}
+	for _, k := range keys {
+		if k.Writes && ReadOnly() {
+			continue
+		}
+		parts = append(parts, k.Key+": "+k.Help)
+	}
+	return strings.Join(parts, " • ")
+}
+
+// withoutKey returns keys without the one shown as key
+func withoutKey(keys []ViewKey, key string) []ViewKey {
+	rest := make([]ViewKey, 0, len(keys))
+	for _, k := range keys {
+		if k.Key != key {
+			rest = append(rest, k)
+		}
+	}
+	return rest
+}
+
+// syncPairKeys are the keys of the sync pairs list
+var syncPairKeys = []ViewKey{
+	{Key: "↑/↓/click", Help: "Select"},
+	{Key: "double-click/t", Help: "Toggle", Writes: true},
+	{Key: "c", Help: "Fold group"},
+	{Key: "s", Help: "Sync", Writes: true},
+	{Key: "p", Help: "Sync folder", Writes: true},
+	{Key: "u", Help: "Queue"},
+	{Key: "a", Help: "Add", Writes: true},
+	{Key: "d", Help: "Delete", Writes: true},
+	{Key: "x", Help: "Trash"},
+	{Key: "v", Help: "Restore as of", Writes: true},
+	{Key: "h", Help: "Versions"},
+	{Key: "r", Help: "Refresh"},
+	{Key: "q", Help: "Back"},
+}
+
+// syncQueueKeys are the keys of the sync queue
+var syncQueueKeys = []ViewKey{
+	{Key: "↑/↓", Help: "Navigate"},
+	{Key: "K/J", Help: "Move up/down", Writes: true},
+	{Key: "x", Help: "Cancel", Writes: true},
+	{Key: "r", Help: "Refresh"},
+	{Key: "q/esc", Help: "Back"},
+}
+
+// trashKeys are the keys of a pair's trash
+var trashKeys = []ViewKey{
+	{Key: "↑/↓", Help: "Navigate"},
+	{Key: "enter", Help: "Restore to local folder", Writes: true},
+	{Key: "d", Help: "Delete", Writes: true},
+	{Key: "p", Help: "Purge expired", Writes: true},
+	{Key: "r", Help: "Refresh"},
+	{Key: "q", Help: "Back"},
+}
+
+// versionFileKeys are the keys of the version browser's list of files
+var versionFileKeys = []ViewKey{
+	{Key: "↑/↓", Help: "Navigate"},
+	{Key: "enter", Help: "Versions"},
+	{Key: "r", Help: "Refresh"},
+	{Key: "q", Help: "Back"},
+}
+
+// versionKeys are the keys of the versions of one file
+var versionKeys = []ViewKey{
+	{Key: "↑/↓", Help: "Navigate"},
+	{Key: "d", Help: "Download", Writes: true},
+	{Key: "p", Help: "Promote", Writes: true},
+	{Key: "r", Help: "Refresh"},
+	{Key: "esc", Help: "Files"},
+}
+
+// remoteKeys are the keys of the remotes list
+var remoteKeys = []ViewKey{
+	{Key: "↑/↓", Help: "Navigate"},
+	{Key: "enter", Help: "Edit", Writes: true},
+	{Key: "l", Help: "Label", Writes: true},
+	{Key: "b", Help: "Benchmark", Writes: true},
+	{Key: "B", Help: "Benchmark all", Writes: true},
+	{Key: "r", Help: "Refresh"},
+	{Key: "q", Help: "Back"},
+}
+
+// launchdManagerKeys are the keys of the LaunchAgent manager. b is only
+// shown when scheduled runs were missed.
+var launchdManagerKeys = []ViewKey{
+	{Key: "↑/↓/click", Help: "Navigate"},
+	{Key: "enter/double-click", Help: "Execute"},
+	{Key: "b", Help: "Catch-up backup", Writes: true},
+	{Key: "a", Help: "All agents"},
+	{Key: "c", Help: "Edit schedule", Writes: true},
+	{Key: "u", Help: "Uninstall", Writes: true},
+	{Key: "r", Help: "Refresh"},
+	{Key: "q/esc", Help: "Back"},
+}
+
+// runningBackupKeys are the keys of the progress of a scheduled backup
+// that was already running. l and p are left out while rclone's remote
+// control API is not answering.
+var runningBackupKeys = []ViewKey{
+	{Key: "↑/↓", Help: "scroll transfers"},
+	{Key: "l", Help: "Limit bandwidth", Writes: true},
+	{Key: "p", Help: "Pause/Resume", Writes: true},
+	{Key: "q", Help: "Detach (backup keeps running)"},
+}
+
+// launchdJobKeys are the keys of the list of all agents
+var launchdJobKeys = []ViewKey{
+	{Key: "↑/↓", Help: "Navigate"},
+	{Key: "l/u", Help: "Load/Unload", Writes: true},
+	{Key: "s/x", Help: "Start/Stop", Writes: true},
+	{Key: "space", Help: "Mark", Writes: true},
+	{Key: "e/d", Help: "Enable/Disable marked or all", Writes: true},
+	{Key: "r", Help: "Refresh"},
+	{Key: "q/esc", Help: "Back"},
+}
+
+// logViewerKeys returns the keys of the log viewer, with one number key
+// per view
+func logViewerKeys() []ViewKey {
+	var keys []ViewKey
+	for mode := LogViewAll; mode <= LogViewAgentOutput; mode++ {
+		keys = append(keys, ViewKey{Key: fmt.Sprint(int(mode) + 1), Help: mode.description()})
+	}
+	return append(keys,
+		ViewKey{Key: "p", Help: "Filter pair"},
+		ViewKey{Key: "↑/↓", Help: "Scroll, or select a session or error"},
+		ViewKey{Key: "enter", Help: "Details of a session, or the latest session of an error"},
+		ViewKey{Key: "r", Help: "Refresh, or re-run the pair of a failed session", Writes: true},
+		ViewKey{Key: "q/esc", Help: "Back"},
+	)
+}
+
+// KeyReference returns the keys of the views, in the order the main menu
+// reaches them
+func KeyReference() []KeySection {
+	return []KeySection{
+		{Title: "Remotes", Keys: remoteKeys},
+		{Title: "Sync Pairs", Keys: syncPairKeys},
+		{Title: "Sync Queue", Keys: syncQueueKeys},
+		{Title: "Trash", Keys: trashKeys},
+		{Title: "File Versions", Keys: versionFileKeys},
+		{Title: "Versions of a File", Keys: versionKeys},
+		{Title: "LaunchAgent Manager", Keys: launchdManagerKeys},
+		{Title: "Running Backup", Keys: runningBackupKeys},
+		{Title: "All Agents", Keys: launchdJobKeys},
+		{Title: "Log Viewer", Keys: logViewerKeys()},
+	}
+}
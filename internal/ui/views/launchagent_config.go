@@ -25,6 +25,7 @@ type LaunchAgentConfigModel struct {
 	configManager *config.Manager
 	launchConfig  config.LaunchAgentConfig
 	launchdMgr    *launchd.Manager
+	validation    FormValidator
 }
 
 // NewLaunchAgentConfigModel creates a new LaunchAgent configuration model
@@ -32,7 +33,13 @@ func NewLaunchAgentConfigModel(configManager *config.Manager, launchdMgr *launch
 	m := LaunchAgentConfigModel{
 		configManager: configManager,
 		launchdMgr:    launchdMgr,
-		inputs:        make([]textinput.Model, 2),
+		inputs:        make([]textinput.Model, 4),
+		validation: NewFormValidator(
+			scheduleField(launchd.ParseTimes),
+			scheduleField(launchd.ParseWeekdays),
+			scheduleField(launchd.ParseDaysOfMonth),
+			Optional(IntRange(1, 999)),
+		),
 	}
 	
 	m.initInputs()
@@ -57,13 +64,19 @@ func (m LaunchAgentConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c":
 			return m, tea.Quit
 
-		case "esc", "q":
+		case "esc":
+			return m, BackCmd()
+
+		case "q":
+			// q is typed into the form's text fields
 			if m.complete {
-				return m, nil
+				return m, BackCmd()
 			}
-			return m, nil
 
 		case "tab", "shift+tab", "up", "down":
+			if m.focusIndex < len(m.inputs) {
+				m.validation.Recheck(m.focusIndex, m.inputs[m.focusIndex].Value())
+			}
 			s := msg.String()
 			if s == "up" || s == "shift+tab" {
 				m.focusIndex--
@@ -93,7 +106,7 @@ func (m LaunchAgentConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "enter":
 			if m.complete {
-				return m, nil
+				return m, DoneCmd("LaunchAgent schedule saved")
 			}
 			return m.handleSave()
 		}
@@ -124,14 +137,14 @@ func (m LaunchAgentConfigModel) View() string {
 
 	if m.err != nil {
 		b.WriteString("\n")
-		b.WriteString(styles.RenderError(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString(renderError(m.err))
 		b.WriteString("\n")
 	}
 
 	if m.complete {
 		b.WriteString(helper.RenderFooter("Press Enter to continue • q: Back"))
 	} else {
-		b.WriteString(helper.RenderFooter("Tab: Next field • Enter: Save & Install • q: Back"))
+		b.WriteString(helper.RenderFooter("Tab/↑/↓: Next field • Enter: Save & Install • esc: Back"))
 	}
 
 	return b.String()
@@ -147,20 +160,66 @@ func (m LaunchAgentConfigModel) renderForm() string {
 	b.WriteString("\n\n")
 	
 	for i, input := range m.inputs {
-		b.WriteString(input.View())
+		b.WriteString(m.validation.RenderField(i, input))
 		if i < len(m.inputs)-1 {
 			b.WriteString("\n\n")
 		}
 	}
 	
 	b.WriteString("\n\n")
-	b.WriteString(styles.RenderMuted("The backup will run daily at the specified time"))
+	b.WriteString(styles.RenderMuted("Times: one or more HH:MM, e.g. 09:00, 18:30"))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("Weekdays: names, numbers (1 = Mon) or ranges, e.g. mon-fri"))
 	b.WriteString("\n")
-	b.WriteString(styles.RenderMuted("Example: Hour: 10, Minute: 5 = runs at 10:05 AM daily"))
+	b.WriteString(styles.RenderMuted("Days of month: numbers or ranges, e.g. 1,15. Leave both empty to run daily"))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("Every N hours: wake-safe interval that replaces the fields above."))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("Set times are skipped while the Mac is off; an interval runs on the next wake."))
+	b.WriteString("\n\n")
+
+	// Live preview of the schedule being built
+	schedule, err := m.parseSchedule()
+	switch {
+	case err != nil:
+		b.WriteString(styles.RenderWarning("Schedule: " + err.Error()))
+	case schedule.UsesInterval():
+		b.WriteString(styles.RenderInfo(fmt.Sprintf("Schedule: %s (StartInterval)", schedule)))
+	default:
+		b.WriteString(styles.RenderInfo(fmt.Sprintf("Schedule: %s (%d calendar entries)",
+			schedule, len(schedule.Intervals()))))
+	}
 	
 	return b.String()
 }
 
+// parseSchedule builds a schedule from the form inputs
+func (m LaunchAgentConfigModel) parseSchedule() (launchd.Schedule, error) {
+	var schedule launchd.Schedule
+	var err error
+
+	if value := strings.TrimSpace(m.inputs[3].Value()); value != "" {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 1 {
+			return schedule, fmt.Errorf("interval must be a positive number of hours")
+		}
+		schedule.IntervalHours = hours
+		return schedule, schedule.Validate()
+	}
+
+	if schedule.Times, err = launchd.ParseTimes(m.inputs[0].Value()); err != nil {
+		return schedule, err
+	}
+	if schedule.Weekdays, err = launchd.ParseWeekdays(m.inputs[1].Value()); err != nil {
+		return schedule, err
+	}
+	if schedule.DaysOfMonth, err = launchd.ParseDaysOfMonth(m.inputs[2].Value()); err != nil {
+		return schedule, err
+	}
+
+	return schedule, schedule.Validate()
+}
+
 // renderComplete renders the completion message
 func (m LaunchAgentConfigModel) renderComplete() string {
 	box := lipgloss.NewStyle().
@@ -170,7 +229,11 @@ func (m LaunchAgentConfigModel) renderComplete() string {
 		Width(60)
 
 	content := styles.RenderSuccess("✓ LaunchAgent configured and installed!\n\n")
-	content += fmt.Sprintf("Schedule: Daily at %02d:%02d\n", m.launchConfig.Hour, m.launchConfig.Minute)
+	if m.launchConfig.Schedule != nil {
+		content += fmt.Sprintf("Schedule: %s\n", m.launchConfig.Schedule)
+	} else {
+		content += fmt.Sprintf("Schedule: Daily at %02d:%02d\n", m.launchConfig.Hour, m.launchConfig.Minute)
+	}
 	content += fmt.Sprintf("Label: %s\n", m.launchConfig.Label)
 	content += "\nThe backup will run automatically according to this schedule."
 
@@ -179,45 +242,94 @@ func (m LaunchAgentConfigModel) renderComplete() string {
 
 // initInputs initializes the input fields
 func (m *LaunchAgentConfigModel) initInputs() {
-	// Hour
+	// Times
 	m.inputs[0] = textinput.New()
-	m.inputs[0].Placeholder = "10"
+	m.inputs[0].Placeholder = "10:05"
 	m.inputs[0].Focus()
 	m.inputs[0].PromptStyle = styles.FocusedStyle
 	m.inputs[0].TextStyle = styles.FocusedStyle
-	m.inputs[0].CharLimit = 2
+	m.inputs[0].CharLimit = 100
 	m.inputs[0].Width = 50
-	m.inputs[0].Prompt = "Hour (0-23): "
-	m.inputs[0].SetValue("10")
+	m.inputs[0].Prompt = "Times: "
+	m.inputs[0].SetValue("10:05")
 
-	// Minute
+	// Weekdays
 	m.inputs[1] = textinput.New()
-	m.inputs[1].Placeholder = "5"
-	m.inputs[1].CharLimit = 2
+	m.inputs[1].Placeholder = "every day"
+	m.inputs[1].CharLimit = 50
 	m.inputs[1].Width = 50
-	m.inputs[1].Prompt = "Minute (0-59): "
-	m.inputs[1].SetValue("5")
+	m.inputs[1].Prompt = "Weekdays: "
+
+	// Days of month
+	m.inputs[2] = textinput.New()
+	m.inputs[2].Placeholder = "any"
+	m.inputs[2].CharLimit = 100
+	m.inputs[2].Width = 50
+	m.inputs[2].Prompt = "Days of month: "
+
+	// Interval
+	m.inputs[3] = textinput.New()
+	m.inputs[3].Placeholder = "off"
+	m.inputs[3].CharLimit = 3
+	m.inputs[3].Width = 50
+	m.inputs[3].Prompt = "Every N hours: "
+
+	// Start from the saved schedule, if any
+	if m.configManager == nil {
+		return
+	}
+	appConfig, err := m.configManager.Load()
+	if err != nil {
+		return
+	}
+	if schedule := appConfig.LaunchAgent.Schedule; schedule != nil {
+		m.inputs[0].SetValue(joinTimes(schedule.Times))
+		m.inputs[1].SetValue(joinInts(schedule.Weekdays))
+		m.inputs[2].SetValue(joinInts(schedule.DaysOfMonth))
+		if schedule.UsesInterval() {
+			m.inputs[3].SetValue(strconv.Itoa(schedule.IntervalHours))
+		}
+	} else {
+		m.inputs[0].SetValue(launchd.TimeOfDay{Hour: appConfig.LaunchAgent.Hour, Minute: appConfig.LaunchAgent.Minute}.String())
+	}
 }
 
-// handleSave validates and saves the LaunchAgent configuration
-func (m LaunchAgentConfigModel) handleSave() (tea.Model, tea.Cmd) {
-	hourStr := strings.TrimSpace(m.inputs[0].Value())
-	minuteStr := strings.TrimSpace(m.inputs[1].Value())
+// joinTimes formats times as a comma-separated list
+func joinTimes(times []launchd.TimeOfDay) string {
+	parts := make([]string, 0, len(times))
+	for _, t := range times {
+		parts = append(parts, t.String())
+	}
+	return strings.Join(parts, ", ")
+}
 
-	if hourStr == "" || minuteStr == "" {
-		m.err = fmt.Errorf("all fields are required")
-		return m, nil
+// joinInts formats numbers as a comma-separated list
+func joinInts(numbers []int) string {
+	parts := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		parts = append(parts, strconv.Itoa(n))
 	}
+	return strings.Join(parts, ",")
+}
 
-	hour, err := strconv.Atoi(hourStr)
-	if err != nil || hour < 0 || hour > 23 {
-		m.err = fmt.Errorf("hour must be between 0 and 23")
+// scheduleField validates a schedule field with its launchd parser
+func scheduleField[T any](parse func(string) (T, error)) Validator {
+	return func(value string) error {
+		_, err := parse(value)
+		return err
+	}
+}
+
+// handleSave validates and saves the LaunchAgent configuration
+func (m LaunchAgentConfigModel) handleSave() (tea.Model, tea.Cmd) {
+	if !m.validation.Validate(m.inputs) {
+		m.err = nil
 		return m, nil
 	}
 
-	minute, err := strconv.Atoi(minuteStr)
-	if err != nil || minute < 0 || minute > 59 {
-		m.err = fmt.Errorf("minute must be between 0 and 59")
+	schedule, err := m.parseSchedule()
+	if err != nil {
+		m.err = err
 		return m, nil
 	}
 
@@ -228,6 +340,11 @@ func (m LaunchAgentConfigModel) handleSave() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	hour, minute := appConfig.LaunchAgent.Hour, appConfig.LaunchAgent.Minute
+	if len(schedule.Times) > 0 {
+		hour, minute = schedule.Times[0].Hour, schedule.Times[0].Minute
+	}
+
 	m.launchConfig = config.LaunchAgentConfig{
 		Enabled:    true,
 		Label:      "com.cloud-sync.backup",
@@ -235,6 +352,10 @@ func (m LaunchAgentConfigModel) handleSave() (tea.Model, tea.Cmd) {
 		Minute:     minute,
 		RunAtLoad:  true,
 		ScriptPath: appConfig.BinDir + "/monthly_backup.sh",
+		Schedule:   &schedule,
+		System:     appConfig.LaunchAgent.System,
+		StdoutPath: appConfig.LaunchAgent.StdoutPath,
+		StderrPath: appConfig.LaunchAgent.StderrPath,
 	}
 
 	// Save to config
@@ -245,12 +366,15 @@ func (m LaunchAgentConfigModel) handleSave() (tea.Model, tea.Cmd) {
 
 	// Generate plist file
 	launchdConfig := &launchd.Config{
-		Label:      m.launchConfig.Label,
-		ScriptPath: m.launchConfig.ScriptPath,
-		Hour:       hour,
-		Minute:     minute,
-		RunAtLoad:  m.launchConfig.RunAtLoad,
+		Label:         m.launchConfig.Label,
+		ScriptPath:    m.launchConfig.ScriptPath,
+		Hour:          hour,
+		Minute:        minute,
+		RunAtLoad:     m.launchConfig.RunAtLoad,
+		Intervals:     schedule.Intervals(),
+		StartInterval: schedule.StartInterval(),
 	}
+	launchdConfig.StdoutPath, launchdConfig.StderrPath = m.launchConfig.OutputPaths(appConfig.LogDir)
 	
 	if err := m.launchdMgr.GeneratePlist(launchdConfig); err != nil {
 		m.err = fmt.Errorf("failed to generate plist: %w", err)
@@ -0,0 +1,335 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// launchdJob is a row of the jobs table
+type launchdJob struct {
+	manager *launchd.Manager
+	status  *launchd.Status // nil when launchctl could not be asked
+	err     error
+}
+
+// LaunchdJobsModel lists every LaunchAgent and LaunchDaemon cloud-sync
+// installed, with actions on the row under the cursor and on the marked
+// rows at once
+type LaunchdJobsModel struct {
+	launchdManager *launchd.Manager
+	jobs           []launchdJob
+	marked         map[string]bool // By label
+	jobsTable      table.Model
+	width          int
+	height         int
+	err            error
+	message        string
+	processing     bool
+}
+
+// NewLaunchdJobsModel creates the jobs view of the user whose scheduled
+// backup launchdManager handles
+func NewLaunchdJobsModel(launchdManager *launchd.Manager, width, height int) LaunchdJobsModel {
+	columns := []table.Column{
+		{Title: " ", Width: 3},
+		{Title: "Job", Width: 40},
+		{Title: "Kind", Width: 12},
+		{Title: "Loaded", Width: 8},
+		{Title: "Running", Width: 12},
+		{Title: "Last Exit", Width: 9},
+	}
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithHeight(8),
+		table.WithFocused(true),
+	)
+	t.SetStyles(styles.TableStyles())
+
+	return LaunchdJobsModel{
+		launchdManager: launchdManager,
+		marked:         make(map[string]bool),
+		jobsTable:      t,
+		width:          width,
+		height:         height,
+	}
+}
+
+// Init implements tea.Model
+func (m LaunchdJobsModel) Init() tea.Cmd {
+	return m.loadJobs()
+}
+
+// Update implements tea.Model
+func (m LaunchdJobsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.processing {
+			return m, nil // Ignore input while processing
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			return m, BackCmd()
+
+		case "r":
+			return m, m.loadJobs()
+
+		case " ":
+			if job, ok := m.selected(); ok {
+				label := job.manager.GetLabel()
+				m.marked[label] = !m.marked[label]
+				m.updateJobsTable()
+			}
+			return m, nil
+
+		case "l", "u", "s", "x":
+			return m.runOnSelected(msg.String())
+
+		case "e":
+			return m.runOnMarked("Enabling jobs", true)
+
+		case "d":
+			return m.runOnMarked("Disabling jobs", false)
+		}
+
+		var cmd tea.Cmd
+		m.jobsTable, cmd = m.jobsTable.Update(msg)
+		return m, cmd
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case launchdJobsLoaded:
+		m.processing = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.jobs = msg.jobs
+		m.updateJobsTable()
+		return m, nil
+
+	case ActionResult:
+		m.processing = false
+		m.err = msg.Error
+		m.message = msg.Message
+		return m, m.loadJobs()
+	}
+
+	return m, nil
+}
+
+// selected returns the job under the cursor
+func (m LaunchdJobsModel) selected() (launchdJob, bool) {
+	i := m.jobsTable.Cursor()
+	if i < 0 || i >= len(m.jobs) {
+		return launchdJob{}, false
+	}
+	return m.jobs[i], true
+}
+
+// runOnSelected loads (l), unloads (u), starts (s) or stops (x) the job
+// under the cursor
+func (m LaunchdJobsModel) runOnSelected(key string) (tea.Model, tea.Cmd) {
+	job, ok := m.selected()
+	if !ok || job.status == nil {
+		return m, nil
+	}
+	action := map[string]string{"l": "Load", "u": "Unload", "s": "Start", "x": "Stop"}[key]
+	if err := refuseReadOnly(action + " " + job.manager.Kind()); err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	label, status := job.manager.GetLabel(), job.status
+	var run func() error
+	var verb string
+	switch key {
+	case "l":
+		if status.Loaded {
+			m.err = fmt.Errorf("%s is already loaded", label)
+			return m, nil
+		}
+		run, verb = job.manager.Load, "loaded"
+	case "u":
+		if !status.Loaded {
+			m.err = fmt.Errorf("%s is not loaded", label)
+			return m, nil
+		}
+		run, verb = job.manager.Unload, "unloaded"
+	case "s":
+		if !status.Loaded || status.Running {
+			m.err = fmt.Errorf("%s must be loaded and not running to start it", label)
+			return m, nil
+		}
+		run, verb = job.manager.Start, "started"
+	case "x":
+		if !status.Running {
+			m.err = fmt.Errorf("%s is not running", label)
+			return m, nil
+		}
+		run, verb = job.manager.Stop, "stopped"
+	}
+
+	m.processing = true
+	return m, func() tea.Msg {
+		if err := run(); err != nil {
+			return ActionResult{Error: err}
+		}
+		return ActionResult{Message: fmt.Sprintf("%s %s", label, verb)}
+	}
+}
+
+// runOnMarked loads (enable) or unloads the marked jobs, or every job when
+// none is marked. Jobs already in that state are left alone, and a job
+// that fails does not stop the others.
+func (m LaunchdJobsModel) runOnMarked(action string, enable bool) (tea.Model, tea.Cmd) {
+	if err := refuseReadOnly(action); err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	var targets []*launchd.Manager
+	for _, job := range m.jobs {
+		if len(m.marked) > 0 && !m.marked[job.manager.GetLabel()] {
+			continue
+		}
+		if job.status != nil && job.status.Loaded != enable {
+			targets = append(targets, job.manager)
+		}
+	}
+	if len(targets) == 0 {
+		m.err = nil
+		m.message = "Nothing to do: the jobs are already unloaded"
+		if enable {
+			m.message = "Nothing to do: the jobs are already loaded"
+		}
+		return m, nil
+	}
+
+	m.processing = true
+	m.marked = make(map[string]bool)
+	return m, func() tea.Msg {
+		var errs []error
+		for _, manager := range targets {
+			run := manager.Unload
+			if enable {
+				run = manager.Load
+			}
+			if err := run(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", manager.GetLabel(), err))
+			}
+		}
+		verb := "Disabled"
+		if enable {
+			verb = "Enabled"
+		}
+		return ActionResult{
+			Error:   errors.Join(errs...),
+			Message: fmt.Sprintf("%s %d of %d job(s)", verb, len(targets)-len(errs), len(targets)),
+		}
+	}
+}
+
+// View implements tea.Model
+func (m LaunchdJobsModel) View() string {
+	var b strings.Builder
+
+	helper := NewViewHelper(m.width, m.height)
+	b.WriteString(helper.RenderHeader("LaunchAgents", "Every job cloud-sync installed"))
+
+	if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	}
+	if m.message != "" {
+		b.WriteString(styles.RenderSuccess(m.message))
+		b.WriteString("\n\n")
+	}
+
+	switch {
+	case m.processing:
+		b.WriteString(styles.RenderInfo("Processing..."))
+		b.WriteString("\n")
+	case m.jobs == nil:
+		b.WriteString(styles.RenderInfo("Loading jobs..."))
+		b.WriteString("\n")
+	case len(m.jobs) == 0:
+		b.WriteString(styles.RenderMuted("No LaunchAgents are installed. Set up the scheduled backup from the LaunchAgent manager."))
+		b.WriteString("\n")
+	default:
+		b.WriteString(m.jobsTable.View())
+		b.WriteString("\n\n")
+		if job, ok := m.selected(); ok {
+			b.WriteString(fmt.Sprintf("Label: %s\n", styles.RenderMuted(job.manager.GetLabel())))
+			b.WriteString(fmt.Sprintf("Plist: %s\n", styles.RenderMuted(job.manager.GetPlistPath())))
+			if job.err != nil {
+				b.WriteString(styles.RenderWarning("Status unknown: " + job.err.Error()))
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	b.WriteString(helper.RenderFooter(keyFooter(launchdJobKeys)))
+
+	return b.String()
+}
+
+// updateJobsTable fills the table with the jobs and their status
+func (m *LaunchdJobsModel) updateJobsTable() {
+	rows := make([]table.Row, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		mark := ""
+		if m.marked[job.manager.GetLabel()] {
+			mark = "✓"
+		}
+		loaded, running, lastExit := "?", "?", "-"
+		if status := job.status; status != nil {
+			loaded, running = "✗", "✗"
+			if status.Loaded {
+				loaded = "✓"
+			}
+			if status.Running {
+				running = "✓"
+				if status.PID > 0 {
+					running = fmt.Sprintf("✓ %d", status.PID)
+				}
+			}
+			if status.Loaded || status.LastExit != 0 {
+				lastExit = fmt.Sprintf("%d", status.LastExit)
+			}
+		}
+		rows = append(rows, table.Row{mark, job.manager.Description(), job.manager.Kind(), loaded, running, lastExit})
+	}
+	m.jobsTable.SetRows(rows)
+}
+
+// loadJobs returns a command that lists the jobs and asks launchctl for
+// the status of each
+func (m LaunchdJobsModel) loadJobs() tea.Cmd {
+	return func() tea.Msg {
+		managers, err := m.launchdManager.Jobs()
+		if err != nil {
+			return launchdJobsLoaded{err: err}
+		}
+		jobs := make([]launchdJob, 0, len(managers))
+		for _, manager := range managers {
+			status, err := manager.GetStatus()
+			jobs = append(jobs, launchdJob{manager: manager, status: status, err: err})
+		}
+		return launchdJobsLoaded{jobs: jobs}
+	}
+}
+
+// Message types
+type launchdJobsLoaded struct {
+	jobs []launchdJob
+	err  error
+}
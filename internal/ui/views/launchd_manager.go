@@ -3,12 +3,15 @@ package views
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/andreisuslov/cloud-sync/internal/config"
 	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
 	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+	"github.com/andreisuslov/cloud-sync/internal/uninstall"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // LaunchdAction represents an action that can be performed on a LaunchAgent
@@ -35,6 +38,14 @@ type LaunchdManagerModel struct {
 	message        string
 	selectedAction int
 	actions        []string
+	missedRuns     []logs.MissedRun
+	nextRun        time.Time // Zero when the job has no calendar schedule
+	clicks         ClickTracker
+}
+
+// NextRunMsg carries when the scheduled job runs next
+type NextRunMsg struct {
+	Time time.Time
 }
 
 // NewLaunchdManagerModel creates a new LaunchAgent manager model
@@ -49,17 +60,7 @@ func NewLaunchdManagerModel(launchdManager *launchd.Manager, width, height int)
 		table.WithHeight(5),
 	)
 
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
-		Bold(false)
-	t.SetStyles(s)
+	t.SetStyles(styles.TableStyles())
 
 	return LaunchdManagerModel{
 		launchdManager: launchdManager,
@@ -80,7 +81,7 @@ func NewLaunchdManagerModel(launchdManager *launchd.Manager, width, height int)
 
 // Init implements tea.Model
 func (m LaunchdManagerModel) Init() tea.Cmd {
-	return m.refreshStatus()
+	return tea.Batch(m.refreshStatus(), CheckMissedRunsCmd(m.launchdManager))
 }
 
 // Update implements tea.Model
@@ -93,7 +94,7 @@ func (m LaunchdManagerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch msg.String() {
 		case "q", "esc":
-			return m, tea.Quit
+			return m, BackCmd()
 
 		case "up", "k":
 			if m.selectedAction > 0 {
@@ -109,7 +110,49 @@ func (m LaunchdManagerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.executeAction()
 
 		case "r":
-			return m, m.refreshStatus()
+			return m, tea.Batch(m.refreshStatus(), CheckMissedRunsCmd(m.launchdManager))
+
+		case "b":
+			if err := refuseReadOnly("Catch-up backup"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			if len(m.missedRuns) > 0 {
+				m.processing = true
+				return m, CatchUpCmd(m.launchdManager)
+			}
+
+		case "c":
+			if err := refuseReadOnly("Editing the schedule"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			configManager, err := config.NewManager()
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			return m, OpenViewCmd(NewLaunchAgentConfigModel(configManager, m.launchdManager))
+
+		case "a":
+			return m, OpenViewCmd(NewLaunchdJobsModel(m.launchdManager, m.width, m.height))
+
+		case "u":
+			if err := refuseReadOnly("Uninstalling"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			opts, err := uninstall.DefaultOptions(m.launchdManager)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			return m, OpenViewCmd(NewUninstallModel(opts))
+		}
+
+	case tea.MouseMsg:
+		if !m.processing && IsLeftClick(msg) {
+			return m.handleClick(msg)
 		}
 
 	case tea.WindowSizeMsg:
@@ -123,6 +166,33 @@ func (m LaunchdManagerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateStatusTable()
 		return m, nil
 
+	case MissedRunsMsg:
+		m.missedRuns = msg.Runs
+		return m, nil
+
+	case NextRunMsg:
+		m.nextRun = msg.Time
+		m.updateStatusTable()
+		return m, nil
+
+	case CatchUpDoneMsg:
+		m.processing = false
+		if msg.Err != nil {
+			m.err = msg.Err
+			m.message = ""
+		} else {
+			m.err = nil
+			m.message = "Catch-up backup started"
+			m.missedRuns = nil
+		}
+		return m, m.refreshStatus()
+
+	case DoneMsg:
+		// A view opened from here finished, e.g. the schedule was saved
+		m.err = nil
+		m.message = msg.Message
+		return m, m.refreshStatus()
+
 	case ActionResult:
 		m.processing = false
 		if msg.Error != nil {
@@ -143,6 +213,27 @@ func (m LaunchdManagerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleClick selects the clicked action, and executes it on a double-click
+func (m LaunchdManagerModel) handleClick(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	// Only look below the "Actions:" heading, so status values that happen
+	// to contain an action name are not mistaken for it
+	above, actions, found := strings.Cut(m.View(), "Actions:")
+	if !found {
+		return m, nil
+	}
+	offset := strings.Count(above, "\n")
+
+	i, ok := ItemAtLine(actions, msg.Y-offset, m.actions)
+	if !ok {
+		return m, nil
+	}
+	m.selectedAction = i
+	if m.clicks.Click(i, time.Now()) {
+		return m, m.executeAction()
+	}
+	return m, nil
+}
+
 // View implements tea.Model
 func (m LaunchdManagerModel) View() string {
 	var b strings.Builder
@@ -156,7 +247,7 @@ func (m LaunchdManagerModel) View() string {
 
 	// Error or success message
 	if m.err != nil {
-		b.WriteString(styles.RenderError("Error: " + m.err.Error()))
+		b.WriteString(renderError(m.err))
 		b.WriteString("\n\n")
 	} else if m.message != "" {
 		b.WriteString(styles.RenderSuccess(m.message))
@@ -174,6 +265,11 @@ func (m LaunchdManagerModel) View() string {
 		b.WriteString("\n")
 	}
 
+	if len(m.missedRuns) > 0 {
+		b.WriteString("\n")
+		b.WriteString(m.renderMissedRuns())
+	}
+
 	b.WriteString("\n")
 
 	// Actions menu
@@ -186,9 +282,10 @@ func (m LaunchdManagerModel) View() string {
 			cursor = styles.RenderHighlight("> ")
 		}
 
-		// Disable certain actions based on status
-		disabled := false
-		if m.status != nil {
+		// Disable certain actions based on status; only refreshing is
+		// allowed in read-only mode
+		disabled := ReadOnly() && i != 5
+		if m.status != nil && !disabled {
 			switch i {
 			case 0: // Load
 				disabled = m.status.Loaded
@@ -212,8 +309,11 @@ func (m LaunchdManagerModel) View() string {
 	}
 
 	// Footer
-	helpText := "↑/↓: Navigate • enter: Execute • r: Refresh • q/esc: Back"
-	b.WriteString(helper.RenderFooter(helpText))
+	keys := launchdManagerKeys
+	if len(m.missedRuns) == 0 {
+		keys = withoutKey(keys, "b")
+	}
+	b.WriteString(helper.RenderFooter(keyFooter(keys)))
 
 	return b.String()
 }
@@ -236,6 +336,29 @@ func (m LaunchdManagerModel) renderStatus() string {
 	return b.String()
 }
 
+// renderMissedRuns renders the most recent missed scheduled runs
+func (m LaunchdManagerModel) renderMissedRuns() string {
+	var b strings.Builder
+
+	b.WriteString(styles.RenderWarning("⚠ Missed Runs:"))
+	b.WriteString("\n\n")
+
+	runs := m.missedRuns
+	if len(runs) > 5 {
+		b.WriteString(styles.RenderMuted(fmt.Sprintf("  ... and %d earlier\n", len(runs)-5)))
+		runs = runs[len(runs)-5:]
+	}
+	for _, run := range runs {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", run.Expected.Format("Mon Jan 2 15:04"), run.Reason))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("The Mac may have been off, or the job failed. Press 'b' to run a catch-up backup now."))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
 // updateStatusTable populates the table with current status
 func (m *LaunchdManagerModel) updateStatusTable() {
 	if m.status == nil {
@@ -270,18 +393,30 @@ func (m *LaunchdManagerModel) updateStatusTable() {
 		rows = append(rows, table.Row{"Last Exit", "0 (success)"})
 	}
 
+	// Next scheduled run
+	if m.status.Loaded && !m.nextRun.IsZero() {
+		rows = append(rows, table.Row{"Next Run", m.nextRun.Format("Mon Jan 2 15:04")})
+	}
+
 	m.statusTable.SetRows(rows)
 }
 
-// refreshStatus returns a command to refresh the LaunchAgent status
+// refreshStatus returns a command to refresh the LaunchAgent status and
+// its next run
 func (m LaunchdManagerModel) refreshStatus() tea.Cmd {
-	return func() tea.Msg {
+	return tea.Batch(func() tea.Msg {
 		status, err := m.launchdManager.GetStatus()
 		if err != nil {
 			return err
 		}
 		return status
-	}
+	}, func() tea.Msg {
+		next, err := m.launchdManager.NextRun(time.Now())
+		if err != nil {
+			return err
+		}
+		return NextRunMsg{Time: next}
+	})
 }
 
 // executeAction returns a command to execute the selected action
@@ -289,6 +424,11 @@ func (m LaunchdManagerModel) executeAction() tea.Cmd {
 	if m.status == nil {
 		return nil
 	}
+	if m.selectedAction != 5 {
+		if err := refuseReadOnly(m.actions[m.selectedAction]); err != nil {
+			return func() tea.Msg { return ActionResult{Error: err} }
+		}
+	}
 
 	m.processing = true
 
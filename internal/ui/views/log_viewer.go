@@ -5,12 +5,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/andreisuslov/cloud-sync/internal/config"
 	"github.com/andreisuslov/cloud-sync/internal/logs"
 	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // LogViewMode represents different log viewing modes
@@ -22,18 +22,34 @@ const (
 	LogViewRecent
 	LogViewSessions
 	LogViewStats
+	LogViewErrors
+	LogViewByDay
+	LogViewByPair
+	LogViewAgentOutput
 )
 
+// aggregateDays is how far back the daily and per-pair totals go
+const aggregateDays = 30
+
+// agentOutputLines is how much of each agent output file is shown
+const agentOutputLines = 200
+
 // LogViewerModel represents the log viewer model
 type LogViewerModel struct {
 	logManager    *logs.Manager
 	mode          LogViewMode
+	pair          string // Only show this sync pair's log, empty for all logs
 	viewport      viewport.Model
 	sessionsTable table.Model
+	sessions      []logs.SyncSession  // Newest first, like the table rows
+	errorGroups   []logs.ErrorGroup   // Most recent first
+	errorCursor   int
+	detail        *logs.SessionDetail // Open session, nil when none is open
 	content       string
 	width         int
 	height        int
 	ready         bool
+	message       string // Result of a re-run session
 	err           error
 }
 
@@ -45,6 +61,7 @@ func NewLogViewerModel(logManager *logs.Manager, mode LogViewMode, width, height
 	// Create sessions table
 	columns := []table.Column{
 		{Title: "Date/Time", Width: 20},
+		{Title: "Pair", Width: 16},
 		{Title: "Type", Width: 12},
 		{Title: "Status", Width: 10},
 		{Title: "Files", Width: 8},
@@ -56,17 +73,7 @@ func NewLogViewerModel(logManager *logs.Manager, mode LogViewMode, width, height
 		table.WithHeight(10),
 	)
 
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
-		Bold(false)
-	t.SetStyles(s)
+	t.SetStyles(styles.TableStyles())
 
 	return LogViewerModel{
 		logManager:    logManager,
@@ -90,9 +97,13 @@ func (m LogViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if model, cmd, handled := m.handleSelectionKey(msg); handled {
+			return model, cmd
+		}
+
 		switch msg.String() {
 		case "q", "esc":
-			return m, tea.Quit
+			return m, BackCmd()
 		case "1":
 			m.mode = LogViewAll
 			return m, m.loadContent()
@@ -108,10 +119,35 @@ func (m LogViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "5":
 			m.mode = LogViewStats
 			return m, m.loadContent()
+		case "6":
+			m.mode = LogViewErrors
+			return m, m.loadContent()
+		case "7":
+			m.mode = LogViewByDay
+			return m, m.loadContent()
+		case "8":
+			m.mode = LogViewByPair
+			return m, m.loadContent()
+		case "9":
+			m.mode = LogViewAgentOutput
+			return m, m.loadContent()
+		case "p":
+			m.pair = m.nextPair()
+			return m, m.loadContent()
 		case "r":
+			if session, ok := m.failedSession(); ok {
+				return m.rerun(session)
+			}
+			m.message = ""
 			return m, m.loadContent()
 		}
 
+	case DoneMsg:
+		// A re-run session finished; it is the newest session now
+		m.err = nil
+		m.message = msg.Message
+		return m, m.loadContent()
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -128,11 +164,36 @@ func (m LogViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case string:
 		// Content loaded
+		m.detail = nil
 		m.content = msg
 		m.viewport.SetContent(m.content)
 		m.viewport.GotoTop()
 		return m, nil
 
+	case logSessionsLoaded:
+		m.detail = nil
+		m.sessions = msg.sessions
+		m.sessionsTable.SetRows(sessionRows(msg.sessions))
+		if m.sessionsTable.Cursor() >= len(msg.sessions) {
+			m.sessionsTable.SetCursor(0)
+		}
+		m.setContent(m.renderSessionsTable())
+		return m, nil
+
+	case errorGroupsLoaded:
+		m.detail = nil
+		m.errorGroups = msg.groups
+		if m.errorCursor >= len(msg.groups) {
+			m.errorCursor = 0
+		}
+		m.setContent(m.renderErrorGroups())
+		return m, nil
+
+	case sessionDetailLoaded:
+		m.detail = msg.detail
+		m.setContent(m.renderSessionDetail(*msg.detail))
+		return m, nil
+
 	case error:
 		m.err = msg
 		return m, nil
@@ -155,7 +216,10 @@ func (m LogViewerModel) View() string {
 
 	// Error display
 	if m.err != nil {
-		b.WriteString(styles.RenderError("Error: " + m.err.Error()))
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n")
+	} else if m.message != "" {
+		b.WriteString(styles.RenderInfo(m.message))
 		b.WriteString("\n")
 	}
 
@@ -167,15 +231,75 @@ func (m LogViewerModel) View() string {
 	}
 
 	// Footer
-	helpText := "1-5: Switch view • r: Refresh • ↑/↓: Scroll • q/esc: Back"
+	helpText := "1-9: Switch view • p: Filter pair • r: Refresh • ↑/↓: Scroll • q/esc: Back"
+	switch {
+	case m.detail != nil:
+		helpText = "↑/↓: Scroll • esc: Back"
+	case m.mode == LogViewSessions && len(m.sessions) > 0:
+		helpText = "↑/↓: Select • enter: Details • 1-9: Switch view • p: Filter pair • r: Refresh • q/esc: Back"
+		if _, ok := m.failedSession(); ok {
+			helpText = "↑/↓: Select • enter: Details • r: Re-run pair • 1-9: Switch view • p: Filter pair • q/esc: Back"
+		}
+	case m.mode == LogViewErrors && len(m.errorGroups) > 0:
+		helpText = "↑/↓: Select • enter: Open latest session • 1-9: Switch view • p: Filter pair • r: Refresh • q/esc: Back"
+	}
 	b.WriteString(helper.RenderFooter(helpText))
 
 	return b.String()
 }
 
+// nextPair returns the pair filter after the current one, cycling through
+// all logs and then each sync pair in turn
+func (m LogViewerModel) nextPair() string {
+	pairs := m.logManager.Pairs()
+	if m.pair == "" {
+		if len(pairs) == 0 {
+			return ""
+		}
+		return pairs[0]
+	}
+
+	for i, pair := range pairs {
+		if pair == m.pair && i+1 < len(pairs) {
+			return pairs[i+1]
+		}
+	}
+	return ""
+}
+
+// source returns the log manager for the current pair filter
+func (m LogViewerModel) source() *logs.Manager {
+	if m.pair == "" {
+		return m.logManager
+	}
+	return m.logManager.ForPair(m.pair)
+}
+
+// transferLabel returns a transfer's file name, prefixed with its pair when
+// the logs of several pairs are shown together
+func (m LogViewerModel) transferLabel(t logs.Transfer) string {
+	if m.pair == "" && t.Pair != "" {
+		return fmt.Sprintf("[%s] %s", t.Pair, t.Filename)
+	}
+	return t.Filename
+}
+
 // getModeDescription returns a description of the current view mode
 func (m LogViewerModel) getModeDescription() string {
-	switch m.mode {
+	if m.pair != "" {
+		return m.modeDescription() + " • " + m.pair
+	}
+	return m.modeDescription()
+}
+
+// modeDescription returns the name of the current view mode
+func (m LogViewerModel) modeDescription() string {
+	return m.mode.description()
+}
+
+// description returns the name of a view mode
+func (mode LogViewMode) description() string {
+	switch mode {
 	case LogViewAll:
 		return "All transfers"
 	case LogViewToday:
@@ -186,6 +310,14 @@ func (m LogViewerModel) getModeDescription() string {
 		return "Sync sessions"
 	case LogViewStats:
 		return "Statistics"
+	case LogViewErrors:
+		return "Errors and notices"
+	case LogViewByDay:
+		return fmt.Sprintf("Daily totals, last %d days", aggregateDays)
+	case LogViewByPair:
+		return "Totals by sync pair"
+	case LogViewAgentOutput:
+		return "Agent output"
 	default:
 		return ""
 	}
@@ -205,6 +337,18 @@ func (m LogViewerModel) loadContent() tea.Cmd {
 			return m.renderSessions()
 		case LogViewStats:
 			return m.renderStats()
+		case LogViewErrors:
+			groups, err := m.source().GetErrorGroups()
+			if err != nil {
+				return err
+			}
+			return errorGroupsLoaded{groups: groups}
+		case LogViewByDay:
+			return m.renderStatsByDay()
+		case LogViewByPair:
+			return m.renderStatsByPair()
+		case LogViewAgentOutput:
+			return m.renderAgentOutput()
 		default:
 			return "Unknown view mode"
 		}
@@ -213,7 +357,7 @@ func (m LogViewerModel) loadContent() tea.Cmd {
 
 // renderAllTransfers renders all transfers
 func (m LogViewerModel) renderAllTransfers() tea.Msg {
-	transfers, err := m.logManager.GetAllTransfers()
+	transfers, err := m.source().GetAllTransfers()
 	if err != nil {
 		return err
 	}
@@ -241,7 +385,7 @@ func (m LogViewerModel) renderAllTransfers() tea.Msg {
 		for _, t := range dateTransfers {
 			b.WriteString(fmt.Sprintf("  %s  %s\n", 
 				t.Timestamp.Format("15:04:05"),
-				t.Filename,
+				m.transferLabel(t),
 			))
 		}
 		b.WriteString("\n")
@@ -252,7 +396,7 @@ func (m LogViewerModel) renderAllTransfers() tea.Msg {
 
 // renderTodaysTransfers renders today's transfers
 func (m LogViewerModel) renderTodaysTransfers() tea.Msg {
-	transfers, err := m.logManager.GetTodaysTransfers()
+	transfers, err := m.source().GetTodaysTransfers()
 	if err != nil {
 		return err
 	}
@@ -269,7 +413,7 @@ func (m LogViewerModel) renderTodaysTransfers() tea.Msg {
 		b.WriteString(fmt.Sprintf("%s  %s  %s\n", 
 			t.Timestamp.Format("15:04:05"),
 			styles.RenderSuccess("✓"),
-			t.Filename,
+			m.transferLabel(t),
 		))
 	}
 
@@ -278,7 +422,7 @@ func (m LogViewerModel) renderTodaysTransfers() tea.Msg {
 
 // renderRecentTransfers renders recent transfers
 func (m LogViewerModel) renderRecentTransfers() tea.Msg {
-	transfers, err := m.logManager.GetRecentTransfers(50)
+	transfers, err := m.source().GetRecentTransfers(50)
 	if err != nil {
 		return err
 	}
@@ -297,57 +441,316 @@ func (m LogViewerModel) renderRecentTransfers() tea.Msg {
 		b.WriteString(fmt.Sprintf("%s  %s  %s\n", 
 			relativeTime,
 			styles.RenderSuccess("✓"),
-			t.Filename,
+			m.transferLabel(t),
 		))
 	}
 
 	return b.String()
 }
 
-// renderSessions renders sync sessions using table
+// renderAgentOutput renders the end of the files launchd writes the
+// scheduled backup's output to, standard error first since that is where
+// failures of the script show up
+func (m LogViewerModel) renderAgentOutput() tea.Msg {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return err
+	}
+	stdoutPath, stderrPath := appConfig.LaunchAgent.OutputPaths(appConfig.LogDir)
+
+	var b strings.Builder
+	for _, file := range []struct{ title, path string }{
+		{"Standard error", stderrPath},
+		{"Standard output", stdoutPath},
+	} {
+		lines, err := logs.NewManagerWithPath(file.path).TailLog(agentOutputLines)
+		if err != nil {
+			return err
+		}
+
+		b.WriteString(styles.RenderInfo(file.title) + "  " + styles.RenderMuted(file.path))
+		b.WriteString("\n\n")
+		if len(lines) == 0 {
+			b.WriteString(styles.RenderMuted("Nothing written yet."))
+			b.WriteString("\n\n")
+			continue
+		}
+		for _, line := range lines {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// handleSelectionKey handles the keys that select and open sessions in the
+// sessions and errors views, and closes an open session. It reports whether
+// the key was handled.
+func (m LogViewerModel) handleSelectionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if m.detail != nil {
+		switch msg.String() {
+		case "q", "esc":
+			m.detail = nil
+			m.setContent(m.renderSelectionList())
+			return m, nil, true
+		}
+		return m, nil, false
+	}
+	if m.mode != LogViewSessions && m.mode != LogViewErrors {
+		return m, nil, false
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		m.moveSelection(-1)
+	case "down", "j":
+		m.moveSelection(1)
+	case "enter":
+		if session, ok := m.selectedSession(); ok {
+			return m, m.loadSessionDetail(session), true
+		}
+		return m, nil, true
+	default:
+		return m, nil, false
+	}
+	m.content = m.renderSelectionList()
+	m.viewport.SetContent(m.content)
+	return m, nil, true
+}
+
+// moveSelection moves the session or error group cursor
+func (m *LogViewerModel) moveSelection(delta int) {
+	if m.mode == LogViewErrors {
+		m.errorCursor = max(0, min(len(m.errorGroups)-1, m.errorCursor+delta))
+		return
+	}
+	if delta < 0 {
+		m.sessionsTable.MoveUp(-delta)
+	} else {
+		m.sessionsTable.MoveDown(delta)
+	}
+}
+
+// renderSelectionList renders the sessions table or the error groups
+func (m LogViewerModel) renderSelectionList() string {
+	if m.mode == LogViewErrors {
+		return m.renderErrorGroups()
+	}
+	return m.renderSessionsTable()
+}
+
+// selectedSession returns the session under the table cursor, or the latest
+// session of the selected error group
+func (m LogViewerModel) selectedSession() (logs.SyncSession, bool) {
+	if m.mode == LogViewErrors {
+		if m.errorCursor >= len(m.errorGroups) {
+			return logs.SyncSession{}, false
+		}
+		return m.errorGroups[m.errorCursor].LatestSession()
+	}
+
+	i := m.sessionsTable.Cursor()
+	if i < 0 || i >= len(m.sessions) {
+		return logs.SyncSession{}, false
+	}
+	return m.sessions[i], true
+}
+
+// failedSession returns the selected session of the sessions view if it
+// failed and belongs to a sync pair, which 'r' re-runs instead of
+// refreshing. Sessions of the main log have no pair to run.
+func (m LogViewerModel) failedSession() (logs.SyncSession, bool) {
+	if m.mode != LogViewSessions || m.detail != nil {
+		return logs.SyncSession{}, false
+	}
+	session, ok := m.selectedSession()
+	if !ok || session.Success || session.Cancelled || session.Pair == "" {
+		return logs.SyncSession{}, false
+	}
+	return session, true
+}
+
+// rerun syncs the session's pair again in the backup progress view
+func (m LogViewerModel) rerun(session logs.SyncSession) (tea.Model, tea.Cmd) {
+	if err := refuseReadOnly("Syncing"); err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.err = nil
+	m.message = ""
+	return m, OpenViewCmd(NewBackupOpsModel(BackupManual, m.width, m.height).RunPair(session.Pair))
+}
+
+// setContent shows new content in the viewport from the top
+func (m *LogViewerModel) setContent(content string) {
+	m.content = content
+	m.viewport.SetContent(content)
+	m.viewport.GotoTop()
+}
+
+// loadSessionDetail returns a command that reads a session's log lines
+func (m LogViewerModel) loadSessionDetail(session logs.SyncSession) tea.Cmd {
+	return func() tea.Msg {
+		detail, err := m.source().GetSessionDetail(session)
+		if err != nil {
+			return err
+		}
+		return sessionDetailLoaded{detail: detail}
+	}
+}
+
+// renderSessions loads the sync sessions, newest first
 func (m LogViewerModel) renderSessions() tea.Msg {
-	sessions, err := m.logManager.GetSyncSessions()
+	sessions, err := m.source().GetSyncSessions()
 	if err != nil {
 		return err
 	}
 
-	if len(sessions) == 0 {
+	newestFirst := make([]logs.SyncSession, 0, len(sessions))
+	for i := len(sessions) - 1; i >= 0; i-- {
+		newestFirst = append(newestFirst, sessions[i])
+	}
+	return logSessionsLoaded{sessions: newestFirst}
+}
+
+// renderSessionsTable renders the loaded sessions as a table
+func (m LogViewerModel) renderSessionsTable() string {
+	if len(m.sessions) == 0 {
 		return "No sync sessions found."
 	}
 
-	// Build table rows
+	var b strings.Builder
+	b.WriteString(styles.RenderInfo(fmt.Sprintf("Total sessions: %d", len(m.sessions))))
+	b.WriteString("\n\n")
+	b.WriteString(m.sessionsTable.View())
+
+	return b.String()
+}
+
+// sessionRows returns a table row per session
+func sessionRows(sessions []logs.SyncSession) []table.Row {
 	rows := []table.Row{}
-	for i := len(sessions) - 1; i >= 0; i-- {
-		session := sessions[i]
+	for _, session := range sessions {
 		
 		dateTime := session.StartTime.Format("2006-01-02 15:04")
 		
 		status := "✗ Failed"
 		if session.Success {
 			status = "✓ Success"
+		} else if session.Cancelled {
+			status = "⊘ Cancelled"
 		}
 		
 		duration := "In progress"
 		if !session.EndTime.IsZero() {
-			d := session.EndTime.Sub(session.StartTime)
-			duration = formatDuration(d)
+			duration = formatDuration(session.Duration())
 		}
 		
 		rows = append(rows, table.Row{
 			dateTime,
+			session.Pair,
 			session.Type,
 			status,
 			fmt.Sprintf("%d", session.Transfers),
 			duration,
 		})
 	}
+	return rows
+}
 
-	m.sessionsTable.SetRows(rows)
+// renderErrorGroups renders the error groups with the selected one highlighted
+func (m LogViewerModel) renderErrorGroups() string {
+	if len(m.errorGroups) == 0 {
+		return "No errors or notices found."
+	}
 
 	var b strings.Builder
-	b.WriteString(styles.RenderInfo(fmt.Sprintf("Total sessions: %d", len(sessions))))
+	b.WriteString(styles.RenderInfo(fmt.Sprintf("Distinct messages: %d", len(m.errorGroups))))
 	b.WriteString("\n\n")
-	b.WriteString(m.sessionsTable.View())
+
+	for i, group := range m.errorGroups {
+		cursor := "  "
+		if i == m.errorCursor {
+			cursor = styles.RenderHighlight("> ")
+		}
+
+		level := styles.RenderWarning(group.Level)
+		if group.Level == "ERROR" {
+			level = styles.RenderError(group.Level)
+		}
+		b.WriteString(fmt.Sprintf("%s%s ×%d  %s\n", cursor, level, group.Count, group.Signature))
+
+		lastSeen := "unknown"
+		if !group.LastSeen.IsZero() {
+			lastSeen = group.LastSeen.Format("2006-01-02 15:04:05")
+		}
+		b.WriteString(fmt.Sprintf("    Last seen: %s", lastSeen))
+		if len(group.Pairs) > 0 {
+			b.WriteString(fmt.Sprintf(" • %s", strings.Join(group.Pairs, ", ")))
+		}
+		b.WriteString("\n")
+		b.WriteString(styles.RenderMuted("    e.g. "+group.Example) + "\n")
+
+		if session, ok := group.LatestSession(); ok {
+			b.WriteString(fmt.Sprintf("    Sessions: %d, latest %s %s\n",
+				len(group.Sessions), session.Type, session.StartTime.Format("2006-01-02 15:04")))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// renderSessionDetail renders a session's transfers, errors, stats and raw log
+func (m LogViewerModel) renderSessionDetail(detail logs.SessionDetail) string {
+	var b strings.Builder
+	if detail.Session.Pair != "" {
+		b.WriteString(styles.RenderSubtitle("Pair: " + detail.Session.Pair))
+		b.WriteString("\n")
+	}
+	b.WriteString(m.renderSession(detail.Session))
+
+	b.WriteString("\n")
+	b.WriteString(styles.RenderSubtitle(fmt.Sprintf("Transfers (%d)", len(detail.Transfers))))
+	b.WriteString("\n")
+	if len(detail.Transfers) == 0 {
+		b.WriteString("  None\n")
+	}
+	for _, t := range detail.Transfers {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", t.Timestamp.Format("15:04:05"), t.Filename))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.RenderSubtitle(fmt.Sprintf("Errors (%d)", len(detail.Errors))))
+	b.WriteString("\n")
+	if len(detail.Errors) == 0 {
+		b.WriteString("  None\n")
+	}
+	for _, line := range detail.Errors {
+		b.WriteString("  " + styles.RenderError(line) + "\n")
+	}
+
+	if len(detail.Stats) > 0 {
+		b.WriteString("\n")
+		b.WriteString(styles.RenderSubtitle("rclone Stats"))
+		b.WriteString("\n")
+		for _, line := range detail.Stats {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.RenderSubtitle("Log"))
+	b.WriteString("\n")
+	for _, line := range detail.Lines {
+		b.WriteString(styles.RenderMuted(line) + "\n")
+	}
 
 	return b.String()
 }
@@ -362,6 +765,9 @@ func (m LogViewerModel) renderSession(session logs.SyncSession) string {
 	if session.Success {
 		statusIcon = "✓"
 		statusStyle = styles.RenderSuccess
+	} else if session.Cancelled {
+		statusIcon = "⊘"
+		statusStyle = styles.RenderWarning
 	}
 
 	b.WriteString(statusStyle(fmt.Sprintf("%s %s Sync", statusIcon, session.Type)))
@@ -371,19 +777,21 @@ func (m LogViewerModel) renderSession(session logs.SyncSession) string {
 	b.WriteString(fmt.Sprintf("  Started:  %s\n", session.StartTime.Format("2006-01-02 15:04:05")))
 	if !session.EndTime.IsZero() {
 		b.WriteString(fmt.Sprintf("  Ended:    %s\n", session.EndTime.Format("2006-01-02 15:04:05")))
-		duration := session.EndTime.Sub(session.StartTime)
-		b.WriteString(fmt.Sprintf("  Duration: %s\n", formatDuration(duration)))
+		b.WriteString(fmt.Sprintf("  Duration: %s\n", formatDuration(session.Duration())))
 	} else {
 		b.WriteString("  Status:   In progress\n")
 	}
 	b.WriteString(fmt.Sprintf("  Files:    %d\n", session.Transfers))
+	if session.Bytes > 0 {
+		b.WriteString(fmt.Sprintf("  Size:     %s\n", formatBytes(session.Bytes)))
+	}
 
 	return b.String()
 }
 
 // renderStats renders backup statistics
 func (m LogViewerModel) renderStats() tea.Msg {
-	stats, err := m.logManager.GetStats()
+	stats, err := m.source().GetStats()
 	if err != nil {
 		return err
 	}
@@ -427,6 +835,10 @@ func (m LogViewerModel) renderStats() tea.Msg {
 			successColor(fmt.Sprintf("%.1f%%", stats.SuccessRate))))
 	}
 
+	if report, err := m.source().LatestReport(); err == nil && report != "" {
+		b.WriteString(fmt.Sprintf("Latest report:          %s\n", styles.RenderHighlight(report)))
+	}
+
 	b.WriteString("\n")
 
 	// Recent activity
@@ -460,6 +872,105 @@ func (m LogViewerModel) renderStats() tea.Msg {
 	return b.String()
 }
 
+// Message types
+type logSessionsLoaded struct {
+	sessions []logs.SyncSession
+}
+
+type errorGroupsLoaded struct {
+	groups []logs.ErrorGroup
+}
+
+type sessionDetailLoaded struct {
+	detail *logs.SessionDetail
+}
+
+// daysAgo returns the start of the day n days before now, in now's zone
+func daysAgo(now time.Time, n int) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day()-n, 0, 0, 0, 0, now.Location())
+}
+
+// renderStatsByDay renders the totals of each day with sessions
+func (m LogViewerModel) renderStatsByDay() tea.Msg {
+	days, err := m.source().GetStatsByDay(daysAgo(time.Now(), aggregateDays-1))
+	if err != nil {
+		return err
+	}
+	if len(days) == 0 {
+		return fmt.Sprintf("No sync sessions in the last %d days.", aggregateDays)
+	}
+
+	return renderAggregateTable("Day", days, func(key string) string { return key })
+}
+
+// renderStatsByPair renders the totals of each sync pair, recent and all time
+func (m LogViewerModel) renderStatsByPair() tea.Msg {
+	recent, err := m.source().GetStatsByPair(daysAgo(time.Now(), aggregateDays-1))
+	if err != nil {
+		return err
+	}
+	all, err := m.source().GetStatsByPair(time.Time{})
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		return "No sync sessions found."
+	}
+
+	pairName := func(key string) string {
+		if key == "" {
+			return "(main backup)"
+		}
+		return key
+	}
+
+	var b strings.Builder
+	b.WriteString(styles.RenderSubtitle(fmt.Sprintf("Last %d days", aggregateDays)))
+	b.WriteString("\n")
+	if len(recent) == 0 {
+		b.WriteString("No sync sessions.\n")
+	} else {
+		b.WriteString(renderAggregateTable("Pair", recent, pairName))
+	}
+	b.WriteString("\n")
+	b.WriteString(styles.RenderSubtitle("All time"))
+	b.WriteString("\n")
+	b.WriteString(renderAggregateTable("Pair", all, pairName))
+	return b.String()
+}
+
+// renderAggregateTable renders aggregated stats as aligned columns with a
+// total row
+func renderAggregateTable(keyTitle string, rows []logs.AggregateStats, label func(string) string) string {
+	var b strings.Builder
+	format := "%-20s %9s %9s %12s %9s\n"
+	b.WriteString(fmt.Sprintf(format, keyTitle, "Sessions", "Files", "Size", "Success"))
+	b.WriteString(strings.Repeat("─", 63))
+	b.WriteString("\n")
+
+	var total logs.AggregateStats
+	for _, row := range rows {
+		b.WriteString(fmt.Sprintf(format, label(row.Key),
+			fmt.Sprintf("%d", row.Sessions),
+			fmt.Sprintf("%d", row.Files),
+			formatBytes(row.Bytes),
+			fmt.Sprintf("%.0f%%", row.SuccessRate)))
+		total.Sessions += row.Sessions
+		total.Successful += row.Successful
+		total.Files += row.Files
+		total.Bytes += row.Bytes
+	}
+
+	b.WriteString(strings.Repeat("─", 63))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf(format, "Total",
+		fmt.Sprintf("%d", total.Sessions),
+		fmt.Sprintf("%d", total.Files),
+		formatBytes(total.Bytes),
+		fmt.Sprintf("%.0f%%", float64(total.Successful)/float64(total.Sessions)*100)))
+	return b.String()
+}
+
 // formatRelativeTime formats a duration as relative time
 func formatRelativeTime(d time.Duration) string {
 	if d < time.Minute {
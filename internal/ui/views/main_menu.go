@@ -28,6 +28,43 @@ func MessageCmd(msg string) tea.Cmd {
 	}
 }
 
+// OpenViewMsg asks the application to switch to another view
+type OpenViewMsg struct {
+	View tea.Model
+}
+
+// OpenViewCmd returns a command that opens the given view
+func OpenViewCmd(view tea.Model) tea.Cmd {
+	return func() tea.Msg {
+		return OpenViewMsg{View: view}
+	}
+}
+
+// BackMsg asks the application to close the current view and return to the
+// one that opened it. Views send it instead of tea.Quit, which would exit
+// the whole application.
+type BackMsg struct{}
+
+// BackCmd returns a command that closes the current view
+func BackCmd() tea.Cmd {
+	return func() tea.Msg {
+		return BackMsg{}
+	}
+}
+
+// DoneMsg reports that a view finished its task. The view is closed and the
+// message is passed on to the view below it, or shown on the main menu.
+type DoneMsg struct {
+	Message string
+}
+
+// DoneCmd returns a command that closes the current view with a message
+func DoneCmd(message string) tea.Cmd {
+	return func() tea.Msg {
+		return DoneMsg{Message: message}
+	}
+}
+
 // ViewHelper provides common view rendering utilities
 type ViewHelper struct {
 	Width  int
@@ -0,0 +1,80 @@
+package views
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+)
+
+// missedRunsWindow is how far back scheduled runs are checked
+const missedRunsWindow = 14 * 24 * time.Hour
+
+// MissedRunsMsg reports scheduled runs that did not happen or failed
+type MissedRunsMsg struct {
+	Runs []logs.MissedRun
+	Err  error
+}
+
+// CatchUpDoneMsg reports the result of starting a catch-up backup
+type CatchUpDoneMsg struct {
+	Err error
+}
+
+// CheckMissedRunsCmd returns a command that compares the LaunchAgent
+// schedule with the automated runs recorded in the backup log
+func CheckMissedRunsCmd(launchdMgr *launchd.Manager) tea.Cmd {
+	return func() tea.Msg {
+		runs, err := FindMissedRuns(launchdMgr, time.Now())
+		return MissedRunsMsg{Runs: runs, Err: err}
+	}
+}
+
+// FindMissedRuns returns the missed runs of an enabled, installed
+// LaunchAgent since it was installed, looking back at most two weeks
+func FindMissedRuns(launchdMgr *launchd.Manager, now time.Time) ([]logs.MissedRun, error) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	installedAt := launchdMgr.InstalledAt()
+	if !appConfig.LaunchAgent.Enabled || installedAt.IsZero() {
+		return nil, nil
+	}
+
+	from := now.Add(-missedRunsWindow)
+	if installedAt.After(from) {
+		from = installedAt
+	}
+
+	sessions, err := logs.NewManager(appConfig.LogDir).GetSyncSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	expected := appConfig.LaunchAgent.EffectiveSchedule().ExpectedRuns(from, now)
+	return logs.MissedRuns(expected, sessions, now), nil
+}
+
+// CatchUpCmd returns a command that starts the LaunchAgent job now
+func CatchUpCmd(launchdMgr *launchd.Manager) tea.Cmd {
+	return func() tea.Msg {
+		return CatchUpDoneMsg{Err: launchdMgr.Start()}
+	}
+}
+
+// MissedRunsSummary describes missed runs in one line
+func MissedRunsSummary(runs []logs.MissedRun) string {
+	last := runs[len(runs)-1]
+	return fmt.Sprintf("%d missed scheduled run(s), most recent %s (%s)",
+		len(runs), last.Expected.Format("Mon Jan 2 15:04"), last.Reason)
+}
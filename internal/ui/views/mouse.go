@@ -0,0 +1,79 @@
+package views
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// doubleClickInterval is the longest gap between two clicks on the same item
+// that still counts as a double-click
+const doubleClickInterval = 500 * time.Millisecond
+
+// ansiSequence matches the color escape sequences in rendered views
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// IsLeftClick reports whether a mouse message is a left button press
+func IsLeftClick(msg tea.MouseMsg) bool {
+	return msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+}
+
+// ItemAtLine returns the index of the item drawn on screen line y of a
+// rendered view. Items are found by the first line, after the previous
+// item, that contains their label; each item covers the lines up to the
+// next one, and the last item is as tall as the one before it.
+func ItemAtLine(view string, y int, labels []string) (int, bool) {
+	lines := strings.Split(view, "\n")
+
+	starts := make([]int, 0, len(labels))
+	line := 0
+	for _, label := range labels {
+		for line < len(lines) && !strings.Contains(ansiSequence.ReplaceAllString(lines[line], ""), label) {
+			line++
+		}
+		if line == len(lines) {
+			break
+		}
+		starts = append(starts, line)
+		line++
+	}
+
+	for i := len(starts) - 1; i >= 0; i-- {
+		if y < starts[i] {
+			continue
+		}
+		end := starts[i] + 1
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		} else if i > 0 {
+			end = starts[i] + starts[i] - starts[i-1]
+		}
+		if y < end {
+			return i, true
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// ClickTracker turns clicks on list items into selections and double-clicks
+type ClickTracker struct {
+	index int
+	at    time.Time
+}
+
+// Click records a click on an item and reports whether it completes a
+// double-click on that item
+func (c *ClickTracker) Click(index int, now time.Time) bool {
+	double := index == c.index && !c.at.IsZero() && now.Sub(c.at) <= doubleClickInterval
+	if double {
+		// A third click starts a new double-click
+		c.at = time.Time{}
+	} else {
+		c.at = now
+	}
+	c.index = index
+	return double
+}
@@ -0,0 +1,115 @@
+package views
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PathShortcuts are the folders offered before anything has been typed in a
+// local path field
+var PathShortcuts = []string{"~/Documents", "~/Desktop", "~/Pictures"}
+
+// maxPathSuggestions limits how many directories are listed under the input
+const maxPathSuggestions = 8
+
+// ExpandHome replaces a leading ~ with the home directory
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, path[1:]), nil
+}
+
+// CompletePath returns the subdirectories matching a partially typed path,
+// written the way the user typed it (with ~ kept) and ending in a slash so
+// the next level completes too. Hidden directories are only listed when the
+// typed name starts with a dot.
+func CompletePath(value string) []string {
+	return completePath(value, nil)
+}
+
+// CompleteFile completes a path like CompletePath, listing the files that
+// end in ext besides the directories, e.g. to pick a key file
+func CompleteFile(value, ext string) []string {
+	return completePath(value, func(name string) bool {
+		return strings.HasSuffix(strings.ToLower(name), ext)
+	})
+}
+
+// completePath lists the directories, and the files accepted by file if it
+// is set, matching a partially typed path
+func completePath(value string, file func(name string) bool) []string {
+	if value == "" {
+		return nil
+	}
+	if value == "~" {
+		value = "~/"
+	}
+
+	// Split into the typed directory and the start of the next name
+	dir, prefix := value[:strings.LastIndex(value, "/")+1], value[strings.LastIndex(value, "/")+1:]
+	listDir, err := ExpandHome(dir)
+	if err != nil {
+		return nil
+	}
+	if listDir == "" {
+		listDir = "."
+	}
+
+	entries, err := os.ReadDir(listDir)
+	if err != nil {
+		return nil
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+			continue
+		}
+		if !isDir(filepath.Join(listDir, name), entry) {
+			if file != nil && file(name) {
+				matches = append(matches, dir+name)
+			}
+			continue
+		}
+		matches = append(matches, dir+name+"/")
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// CompleteSubpath returns the subdirectories of root matching a partially
+// typed path relative to it, written relative to root like CompletePath's
+func CompleteSubpath(root, value string) []string {
+	if strings.HasPrefix(value, "/") || strings.HasPrefix(value, "~") {
+		return nil
+	}
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	matches := CompletePath(prefix + value)
+	for i, match := range matches {
+		matches[i] = strings.TrimPrefix(match, prefix)
+	}
+	return matches
+}
+
+// isDir reports whether an entry is a directory, following symlinks
+func isDir(path string, entry os.DirEntry) bool {
+	if entry.IsDir() {
+		return true
+	}
+	if entry.Type()&os.ModeSymlink == 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
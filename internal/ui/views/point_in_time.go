@@ -0,0 +1,275 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// restoreTimeLayouts are the layouts a point-in-time restore's time is
+// typed in. A date alone means the start of that day.
+var restoreTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02"}
+
+// PointInTimeModel restores a sync pair's remote folder as it was at a
+// chosen time, from the old versions a B2 or S3 bucket keeps
+type PointInTimeModel struct {
+	rclone      *rclone.Manager
+	pair        syncconfig.SyncPair
+	input       textinput.Model
+	at          time.Time
+	sizes       []int64 // Files the folder held at 'at', nil until listed
+	tuning      rclone.RestoreTuning
+	checking    bool  // Checking the remote keeps versions
+	unavailable error // Why the remote cannot be restored as of a time
+	processing  bool
+	message     string
+	err         error
+	width       int
+	height      int
+}
+
+// NewPointInTimeModel creates a point-in-time restore for a sync pair
+func NewPointInTimeModel(rcloneMgr *rclone.Manager, pair syncconfig.SyncPair) PointInTimeModel {
+	input := textinput.New()
+	input.Prompt = "Restore as of: "
+	input.Placeholder = "YYYY-MM-DD or YYYY-MM-DD HH:MM"
+	input.CharLimit = 16
+	input.Width = 32
+	input.Focus()
+
+	return PointInTimeModel{
+		rclone:   rcloneMgr,
+		pair:     pair,
+		input:    input,
+		checking: true,
+	}
+}
+
+// Init implements tea.Model
+func (m PointInTimeModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.checkRemote())
+}
+
+// Update implements tea.Model
+func (m PointInTimeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case pointInTimeChecked:
+		m.checking = false
+		m.unavailable = msg.err
+		return m, nil
+
+	case pointInTimeListed:
+		m.processing = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.sizes = msg.sizes
+			m.tuning = msg.tuning
+		}
+		return m, nil
+
+	case pointInTimeRestored:
+		m.processing = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.message = msg.message
+			m.sizes = nil
+			m.input.Reset()
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.checking || m.processing {
+			return m, nil
+		}
+		if m.unavailable != nil {
+			if key := msg.String(); key == "esc" || key == "q" || key == "enter" {
+				return m, BackCmd()
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "esc":
+			if m.sizes != nil {
+				m.sizes = nil
+				m.err = nil
+				return m, nil
+			}
+			return m, BackCmd()
+		case "enter":
+			if m.sizes != nil {
+				m.processing = true
+				m.err = nil
+				return m, m.restore()
+			}
+			at, err := parseRestoreTime(m.input.Value(), time.Now())
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.at = at
+			m.processing = true
+			m.message = ""
+			m.err = nil
+			return m, m.listAt()
+		}
+
+		if m.sizes == nil {
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+	}
+
+	return m, nil
+}
+
+// parseRestoreTime reads the time a restore goes back to, which must be
+// before now
+func parseRestoreTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range restoreTimeLayouts {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		if !t.Before(now) {
+			return time.Time{}, fmt.Errorf("%s is not in the past", value)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not a date like 2026-10-01 or 2026-10-01 14:30", value)
+}
+
+// View implements tea.Model
+func (m PointInTimeModel) View() string {
+	helper := NewViewHelper(m.width, m.height)
+	var b strings.Builder
+
+	b.WriteString(helper.RenderHeader("Point-in-Time Restore", fmt.Sprintf("Recover '%s' as it was at an earlier time", m.pair.Name)))
+
+	if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	} else if m.message != "" {
+		b.WriteString(styles.RenderSuccess(m.message))
+		b.WriteString("\n\n")
+	}
+
+	source := m.pair.RemoteName + ":" + m.pair.RemotePath
+	switch {
+	case m.checking:
+		b.WriteString(styles.RenderInfo("Checking the remote keeps old versions..."))
+		b.WriteString("\n")
+	case m.unavailable != nil:
+		b.WriteString(renderError(m.unavailable))
+		b.WriteString("\n")
+	case m.processing:
+		b.WriteString(styles.RenderInfo("Processing..."))
+		b.WriteString("\n")
+	case m.sizes != nil:
+		var bytes int64
+		for _, size := range m.sizes {
+			bytes += size
+		}
+		b.WriteString(fmt.Sprintf("At %s, %s held %d files, %s.\n\n", m.at.Format("2006-01-02 15:04"), source, len(m.sizes), formatBytes(bytes)))
+		b.WriteString(fmt.Sprintf("Restore them into %s with %s?\n\n", m.pair.LocalPath, m.tuning))
+		b.WriteString(styles.RenderWarning("Files changed since are overwritten with their versions of then."))
+		b.WriteString("\n")
+		b.WriteString(styles.RenderMuted("Files added since are kept; the next sync uploads the restored files as new versions."))
+		b.WriteString("\n")
+	default:
+		b.WriteString(m.input.View())
+		b.WriteString("\n\n")
+		b.WriteString(styles.RenderMuted(fmt.Sprintf("Lists %s as it was then, from the versions the bucket keeps.", source)))
+		b.WriteString("\n")
+		b.WriteString(styles.RenderMuted("A date alone means the start of that day."))
+		b.WriteString("\n")
+	}
+
+	switch {
+	case m.unavailable != nil:
+		b.WriteString(helper.RenderFooter("esc: Back"))
+	case m.sizes != nil:
+		b.WriteString(helper.RenderFooter("enter: Restore • esc: Pick another time"))
+	default:
+		b.WriteString(helper.RenderFooter("enter: List • esc: Back"))
+	}
+
+	return b.String()
+}
+
+// checkRemote returns a command that checks the pair's remote keeps old
+// versions rclone can read
+func (m PointInTimeModel) checkRemote() tea.Cmd {
+	return func() tea.Msg {
+		return pointInTimeChecked{err: m.rclone.CheckPointInTime(m.pair.RemoteName, m.pair.RemotePath)}
+	}
+}
+
+// listAt returns a command that lists the files the pair's remote folder
+// held at the chosen time, and picks the settings to download them with:
+// the remote's saved profile, or ones tuned to them
+func (m PointInTimeModel) listAt() tea.Cmd {
+	remote, at := m.pair.RemoteName, m.at
+	return func() tea.Msg {
+		sizes, err := m.rclone.FileSizesAt(remote, m.pair.RemotePath, at)
+		if err != nil {
+			return pointInTimeListed{err: err}
+		}
+		if len(sizes) == 0 {
+			return pointInTimeListed{err: fmt.Errorf("%s:%s held no files at %s", remote, m.pair.RemotePath, at.Format("2006-01-02 15:04"))}
+		}
+
+		var bandwidth float64
+		appConfig := loadAppConfig()
+		if appConfig != nil {
+			if saved := appConfig.RestoreTuning(remote); saved != nil {
+				return pointInTimeListed{sizes: sizes, tuning: *saved}
+			}
+			bandwidth, _ = latestDownloadRate(appConfig, remote)
+		}
+		return pointInTimeListed{sizes: sizes, tuning: rclone.TuneRestore(sizes, bandwidth)}
+	}
+}
+
+// restore returns a command that copies the pair's remote folder, as it was
+// at the chosen time, into the local folder
+func (m PointInTimeModel) restore() tea.Cmd {
+	at, tuning := m.at, m.tuning
+	return func() tea.Msg {
+		err := m.rclone.RestoreAt(m.pair.RemoteName, m.pair.RemotePath, m.pair.LocalPath, at, tuning)
+		return pointInTimeRestored{
+			message: fmt.Sprintf("Restored %s:%s as of %s into %s", m.pair.RemoteName, m.pair.RemotePath, at.Format("2006-01-02 15:04"), m.pair.LocalPath),
+			err:     err,
+		}
+	}
+}
+
+// Message types
+type pointInTimeChecked struct {
+	err error
+}
+
+type pointInTimeListed struct {
+	sizes  []int64
+	tuning rclone.RestoreTuning
+	err    error
+}
+
+type pointInTimeRestored struct {
+	message string
+	err     error
+}
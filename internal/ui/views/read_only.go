@@ -0,0 +1,27 @@
+package views
+
+import "fmt"
+
+// readOnly disables the actions that change anything: installs, edits of
+// the configuration, syncs and LaunchAgent actions. Views stay open so a
+// setup can be reviewed, e.g. by an admin on a shared machine.
+var readOnly bool
+
+// SetReadOnly turns read-only mode on or off for every view
+func SetReadOnly(on bool) {
+	readOnly = on
+}
+
+// ReadOnly reports whether actions that change anything are disabled
+func ReadOnly() bool {
+	return readOnly
+}
+
+// refuseReadOnly returns the error shown in place of an action while
+// read-only mode is on, or nil when the action may run
+func refuseReadOnly(action string) error {
+	if !readOnly {
+		return nil
+	}
+	return fmt.Errorf("%s is disabled in read-only mode", action)
+}
@@ -0,0 +1,158 @@
+package views
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// Azure login modes, typed into the form's Auth field
+const (
+	azureAuthKey = "key"
+	azureAuthSAS = "sas"
+)
+
+// initAzureInputs initializes input fields for Azure Blob Storage
+// configuration
+func (m *RemoteConfigModel) initAzureInputs() tea.Cmd {
+	inputs := make([]textinput.Model, 6)
+
+	// Remote name
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "azure"
+	inputs[0].Focus()
+	inputs[0].PromptStyle = styles.FocusedStyle
+	inputs[0].TextStyle = styles.FocusedStyle
+	inputs[0].CharLimit = 32
+	inputs[0].Width = 50
+	inputs[0].Prompt = "Remote Name: "
+
+	// Login mode
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "key or sas"
+	inputs[1].CharLimit = 3
+	inputs[1].Width = 50
+	inputs[1].Prompt = "Auth (key/sas): "
+	inputs[1].SetValue(azureAuthKey)
+
+	// Storage account
+	inputs[2] = textinput.New()
+	inputs[2].Placeholder = "mystorageaccount"
+	inputs[2].CharLimit = 24
+	inputs[2].Width = 50
+	inputs[2].Prompt = "Storage Account: "
+
+	// Account key
+	inputs[3] = textinput.New()
+	inputs[3].Placeholder = "key mode only"
+	inputs[3].CharLimit = 100
+	inputs[3].Width = 50
+	inputs[3].Prompt = "Account Key: "
+	inputs[3].EchoMode = textinput.EchoPassword
+	inputs[3].EchoCharacter = '•'
+
+	// SAS URL
+	inputs[4] = textinput.New()
+	inputs[4].Placeholder = "sas mode only, https://account.blob.core.windows.net/container?sv=..."
+	inputs[4].CharLimit = 1000
+	inputs[4].Width = 50
+	inputs[4].Prompt = "SAS URL: "
+	inputs[4].EchoMode = textinput.EchoPassword
+	inputs[4].EchoCharacter = '•'
+
+	// Endpoint suffix
+	inputs[5] = textinput.New()
+	inputs[5].Placeholder = config.AzurePublicSuffix
+	inputs[5].CharLimit = 100
+	inputs[5].Width = 50
+	inputs[5].Prompt = "Endpoint Suffix: "
+	inputs[5].SetValue(config.AzurePublicSuffix)
+
+	m.inputs = inputs
+	m.focusIndex = 0
+	m.validation = NewFormValidator(RemoteName, OneOf(azureAuthKey, azureAuthSAS), nil, nil, nil, Optional(EndpointURL))
+
+	return inputs[0].Focus()
+}
+
+// setAzureInputs prefills the Azure form with a stored remote
+func (m *RemoteConfigModel) setAzureInputs(remote config.RemoteConfig) {
+	auth := azureAuthKey
+	if remote.SASURL != "" {
+		auth = azureAuthSAS
+	}
+	suffix := remote.EndpointSuffix
+	if suffix == "" {
+		suffix = config.AzurePublicSuffix
+	}
+	m.inputs[0].SetValue(remote.Name)
+	m.inputs[1].SetValue(auth)
+	m.inputs[2].SetValue(remote.AccountID)
+	m.inputs[3].SetValue(remote.ApplicationKey)
+	m.inputs[4].SetValue(remote.SASURL)
+	m.inputs[5].SetValue(suffix)
+}
+
+// azureAuth returns the login mode typed into the form
+func (m RemoteConfigModel) azureAuth() string {
+	return strings.ToLower(strings.TrimSpace(m.inputs[1].Value()))
+}
+
+// azureRemote returns the remote the Azure form describes. Only the
+// credential of the chosen mode is kept, and the public cloud's suffix is
+// left unset.
+func (m RemoteConfigModel) azureRemote() config.RemoteConfig {
+	remote := config.RemoteConfig{
+		Name:           strings.TrimSpace(m.inputs[0].Value()),
+		Type:           "azureblob",
+		Provider:       "Azure",
+		AccountID:      strings.TrimSpace(m.inputs[2].Value()),
+		ApplicationKey: strings.TrimSpace(m.inputs[3].Value()),
+		SASURL:         strings.TrimSpace(m.inputs[4].Value()),
+		EndpointSuffix: strings.TrimSpace(m.inputs[5].Value()),
+	}
+	if m.azureAuth() == azureAuthSAS {
+		remote.ApplicationKey = ""
+	} else {
+		remote.SASURL = ""
+	}
+	if remote.EndpointSuffix == config.AzurePublicSuffix {
+		remote.EndpointSuffix = ""
+	}
+	return remote
+}
+
+// renderAzureConfig renders the Azure Blob Storage configuration form, with
+// hints for the login mode typed in
+func (m RemoteConfigModel) renderAzureConfig() string {
+	var b strings.Builder
+
+	b.WriteString(styles.RenderInfo("Azure Blob Storage Configuration"))
+	b.WriteString("\n\n")
+
+	for i, input := range m.inputs {
+		b.WriteString(m.validation.RenderField(i, input))
+		if i < len(m.inputs)-1 {
+			b.WriteString("\n\n")
+		}
+	}
+
+	b.WriteString("\n\n")
+	if m.azureAuth() == azureAuthSAS {
+		b.WriteString(styles.RenderMuted("Generate a SAS URL for the account or one container, with read, write, delete and list permissions."))
+		b.WriteString("\n")
+		b.WriteString(styles.RenderMuted("A container's SAS URL makes it the default container; the account key and suffix are not used."))
+	} else {
+		b.WriteString(styles.RenderMuted("Find the key under the storage account's Security + networking → Access keys."))
+	}
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("Sovereign clouds: core.chinacloudapi.cn (Azure China), core.usgovcloudapi.net (Azure Government)."))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted(secretRefHint))
+
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package views
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+)
+
+// remoteBenchmarked carries the benchmark run of one remote
+type remoteBenchmarked struct {
+	run logs.BenchmarkRun
+	err error // Recording the run failed
+}
+
+// benchmarkTarget returns where a remote's test object goes: the root of
+// servers, aliases and unions, and the default bucket of remotes with buckets
+func benchmarkTarget(appConfig *config.AppConfig, remote config.RemoteConfig) (string, error) {
+	if remote.IsSFTP() || remote.IsWebDAV() || remote.IsAlias() || remote.IsUnion() {
+		return remote.Name + ":", nil
+	}
+	if remote.IsLocal() {
+		return "", fmt.Errorf("remote '%s' has no folder to test in; benchmark an alias of the share instead", remote.Name)
+	}
+	bucket := appConfig.DefaultBucket(remote.Name)
+	if bucket == "" {
+		return "", fmt.Errorf("remote '%s' has no default bucket to test in; pick one by editing it", remote.Name)
+	}
+	return remote.Name + ":" + bucket, nil
+}
+
+// benchmark uploads, stats and downloads a test object on a remote, and
+// records how fast it went
+func (m RemotesModel) benchmark(remote config.RemoteConfig) tea.Cmd {
+	configManager := m.configManager
+	return func() tea.Msg {
+		run := logs.BenchmarkRun{Remote: remote.Name, Time: time.Now(), Size: rclone.BenchmarkSize}
+		appConfig, err := configManager.Load()
+		if err != nil {
+			return remoteBenchmarked{run: run, err: err}
+		}
+
+		run.Target, err = benchmarkTarget(appConfig, remote)
+		if err == nil {
+			mgr := rclone.NewManagerWithConfig(appConfig.RclonePath, appConfig.RcloneConfig)
+			env, _ := configManager.SecretEnv()
+			mgr.SetEnv(env)
+			var result *rclone.BenchmarkResult
+			if result, err = mgr.Benchmark(run.Target, rclone.BenchmarkSize); err == nil {
+				run.Latency = result.Latency
+				run.UploadRate = result.UploadRate()
+				run.DownloadRate = result.DownloadRate()
+			}
+		}
+		if err != nil {
+			run.Error = err.Error()
+		}
+		return remoteBenchmarked{run: run, err: logs.NewManager(appConfig.LogDir).RecordBenchmark(run)}
+	}
+}
+
+// startBenchmarks benchmarks the named remotes one after another
+func (m RemotesModel) startBenchmarks(names ...string) (RemotesModel, tea.Cmd) {
+	m.message = ""
+	m.err = nil
+	m.benchmarkQueue = names
+	return m.nextBenchmark()
+}
+
+// nextBenchmark starts the next queued benchmark, if any
+func (m RemotesModel) nextBenchmark() (RemotesModel, tea.Cmd) {
+	m.benchmarking = ""
+	for len(m.benchmarkQueue) > 0 {
+		name := m.benchmarkQueue[0]
+		m.benchmarkQueue = m.benchmarkQueue[1:]
+		for _, remote := range m.remotes {
+			if remote.Name == name {
+				m.benchmarking = name
+				return m, tea.Batch(m.spinner.Tick, m.benchmark(remote))
+			}
+		}
+	}
+	return m, nil
+}
+
+// fastestRemote returns the remote whose latest benchmark uploaded fastest,
+// or "" when fewer than two remotes were benchmarked
+func (m RemotesModel) fastestRemote() string {
+	fastest, rate, count := "", 0.0, 0
+	for _, remote := range m.remotes {
+		run, ok := m.benchmarks[remote.Name]
+		if !ok || run.Error != "" {
+			continue
+		}
+		count++
+		if run.UploadRate > rate {
+			fastest, rate = remote.Name, run.UploadRate
+		}
+	}
+	if count < 2 {
+		return ""
+	}
+	return fastest
+}
@@ -0,0 +1,332 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/b2"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// bucketPicker is the last step of the remote wizard: the remote's buckets
+// are listed, which also proves the credentials work, and the one picked or
+// created becomes the remote's default bucket
+type bucketPicker struct {
+	formStep RemoteConfigStep // Step esc returns to, to fix the credentials
+	loading  bool
+	listErr  error
+	buckets  []string
+	cursor   int // len(buckets) is "Create a new bucket"
+	naming   bool
+	creating bool
+	input    textinput.Model
+
+	keyWarnings []string // What a restricted B2 key will break, see b2KeyWarnings
+}
+
+// Message types
+type remoteBucketsListed struct {
+	buckets     []string
+	err         error
+	keyWarnings []string
+}
+
+type remoteBucketCreated struct {
+	bucket string
+	err    error
+}
+
+// chooseBucket moves on to the bucket step after the remote was saved
+func (m RemoteConfigModel) chooseBucket() (tea.Model, tea.Cmd) {
+	m.picker = bucketPicker{formStep: m.currentStep, loading: true}
+	m.currentStep = RemoteStepSelectBucket
+	return m, m.listBuckets()
+}
+
+// b2KeyWarnings asks B2 what a B2 remote's application key may do, and
+// returns what syncs will fail with it. A key that cannot be checked is
+// left to the bucket list to report.
+func b2KeyWarnings(remote config.RemoteConfig) []string {
+	keyID, err := config.ResolveSecret(remote.AccountID)
+	if err != nil {
+		return nil
+	}
+	appKey, err := config.ResolveSecret(remote.ApplicationKey)
+	if err != nil {
+		return nil
+	}
+	key, err := b2.Authorize(keyID, appKey)
+	if err != nil {
+		return nil
+	}
+	return key.Warnings()
+}
+
+// rcloneManager returns an rclone manager for the generated rclone.conf,
+// with the secret references of the remotes resolved and extra variables
+// added. A reference of another remote that cannot be resolved must not
+// block this one, and this remote's own shows up as rclone failing to log
+// in.
+func (m RemoteConfigModel) rcloneManager(extra ...string) (*rclone.Manager, error) {
+	appConfig, err := m.configManager.Load()
+	if err != nil {
+		return nil, err
+	}
+	mgr := rclone.NewManagerWithConfig(appConfig.RclonePath, appConfig.RcloneConfig)
+	env, _ := m.configManager.SecretEnv()
+	mgr.SetEnv(append(env, extra...))
+	return mgr, nil
+}
+
+// listBuckets lists the saved remote's buckets. The application key of a
+// B2 remote is checked as well. The credentials may just have changed, so
+// the remote is always listed, and the listing is cached for the other
+// views.
+func (m RemoteConfigModel) listBuckets() tea.Cmd {
+	remote := m.remoteConfig
+	return func() tea.Msg {
+		var warnings []string
+		if remote.Type == "b2" {
+			warnings = b2KeyWarnings(remote)
+		}
+		mgr, err := m.rcloneManager()
+		if err != nil {
+			return remoteBucketsListed{err: err, keyWarnings: warnings}
+		}
+		cache := listingCache()
+		cache.Forget(remote.Name + ":")
+		buckets, _, err := mgr.CachedListBuckets(cache, remote.Name)
+		if err != nil {
+			return remoteBucketsListed{err: err, keyWarnings: warnings}
+		}
+		names := make([]string, 0, len(buckets))
+		for _, b := range buckets {
+			names = append(names, b.Name)
+		}
+		return remoteBucketsListed{buckets: names, keyWarnings: warnings}
+	}
+}
+
+// createBucket creates a bucket on the saved remote
+func (m RemoteConfigModel) createBucket(bucket string) tea.Cmd {
+	name := m.remoteConfig.Name
+	return func() tea.Msg {
+		mgr, err := m.rcloneManager()
+		if err != nil {
+			return remoteBucketCreated{bucket: bucket, err: err}
+		}
+		if err := mgr.CreateBucket(name, bucket); err != nil {
+			return remoteBucketCreated{bucket: bucket, err: err}
+		}
+		listingCache().Forget(name + ":")
+		return remoteBucketCreated{bucket: bucket}
+	}
+}
+
+// updateBucketStep handles the messages of the bucket step
+func (m RemoteConfigModel) updateBucketStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case remoteBucketsListed:
+		m.picker.loading = false
+		m.picker.listErr = msg.err
+		m.picker.buckets = msg.buckets
+		m.picker.keyWarnings = msg.keyWarnings
+		// Start on the current default when editing
+		m.picker.cursor = 0
+		for i, bucket := range msg.buckets {
+			if bucket == m.remoteConfig.Bucket {
+				m.picker.cursor = i
+			}
+		}
+		return m, nil
+
+	case remoteBucketCreated:
+		m.picker.creating = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		return m.setDefaultBucket(msg.bucket)
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.picker.loading || m.picker.creating {
+			return m, nil
+		}
+		if m.picker.naming {
+			return m.updateBucketName(msg)
+		}
+
+		switch msg.String() {
+		case "esc":
+			m.currentStep = m.picker.formStep
+			m.err = nil
+			return m, nil
+		case "s":
+			return m.finish()
+		case "r":
+			m.picker.loading = true
+			m.picker.listErr = nil
+			return m, m.listBuckets()
+		case "up", "k":
+			if m.picker.listErr == nil && m.picker.cursor > 0 {
+				m.picker.cursor--
+			}
+		case "down", "j":
+			if m.picker.listErr == nil && m.picker.cursor < len(m.picker.buckets) {
+				m.picker.cursor++
+			}
+		case "enter":
+			if m.picker.listErr != nil {
+				return m, nil
+			}
+			if m.picker.cursor < len(m.picker.buckets) {
+				return m.setDefaultBucket(m.picker.buckets[m.picker.cursor])
+			}
+			m.picker.naming = true
+			m.picker.input = textinput.New()
+			m.picker.input.Placeholder = "my-backups"
+			m.picker.input.CharLimit = 63
+			m.picker.input.Width = 40
+			m.picker.input.Prompt = "Bucket name: "
+			return m, m.picker.input.Focus()
+		}
+	}
+	return m, nil
+}
+
+// updateBucketName handles the keys while a new bucket is named
+func (m RemoteConfigModel) updateBucketName(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.picker.naming = false
+		m.err = nil
+		return m, nil
+	case "enter":
+		bucket := strings.TrimSpace(m.picker.input.Value())
+		if err := rclone.ValidateBucketName(bucket); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.err = nil
+		m.picker.naming = false
+		m.picker.creating = true
+		return m, m.createBucket(bucket)
+	}
+
+	var cmd tea.Cmd
+	m.picker.input, cmd = m.picker.input.Update(msg)
+	return m, cmd
+}
+
+// setDefaultBucket stores the remote's default bucket and finishes
+func (m RemoteConfigModel) setDefaultBucket(bucket string) (tea.Model, tea.Cmd) {
+	if err := m.configManager.SetDefaultBucket(m.remoteConfig.Name, bucket); err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.remoteConfig.Bucket = bucket
+	return m.finish()
+}
+
+// finish shows that the remote was saved
+func (m RemoteConfigModel) finish() (tea.Model, tea.Cmd) {
+	m.err = nil
+	m.currentStep = RemoteStepComplete
+	m.complete = true
+	return m, nil
+}
+
+// renderSelectBucket renders the bucket step
+func (m RemoteConfigModel) renderSelectBucket() string {
+	var b strings.Builder
+	b.WriteString(styles.RenderSubtitle("Default Bucket"))
+	b.WriteString("\n\n")
+
+	if len(m.picker.keyWarnings) > 0 {
+		b.WriteString(styles.RenderWarning("This application key is restricted, and syncs may fail later:"))
+		b.WriteString("\n")
+		for _, warning := range m.picker.keyWarnings {
+			b.WriteString(styles.RenderWarning("  • " + warning))
+			b.WriteString("\n")
+		}
+		b.WriteString(styles.RenderMuted("Press esc to enter a key with Read and Write access; ctrl+o on the form shows how to create one."))
+		b.WriteString("\n\n")
+	}
+
+	switch {
+	case m.picker.loading:
+		b.WriteString(fmt.Sprintf("Checking the credentials by listing the buckets of '%s'...\n", m.remoteConfig.Name))
+	case m.picker.listErr != nil:
+		b.WriteString(styles.RenderError(fmt.Sprintf("Could not list the buckets of '%s': %v", m.remoteConfig.Name, m.picker.listErr)))
+		b.WriteString("\n\n")
+		b.WriteString(styles.RenderMuted("The remote was saved, but the keys or endpoint may be wrong. Press esc to fix them."))
+		b.WriteString("\n")
+	case m.picker.creating:
+		b.WriteString(fmt.Sprintf("Creating bucket '%s'...\n", strings.TrimSpace(m.picker.input.Value())))
+	case m.picker.naming:
+		b.WriteString(m.picker.input.View())
+		b.WriteString("\n\n")
+		b.WriteString(styles.RenderMuted("3-63 lowercase letters, digits, dots and hyphens"))
+		b.WriteString("\n")
+	default:
+		b.WriteString("Sync pairs and backup scripts on this remote start from its default bucket.\n\n")
+		for i, bucket := range append(append([]string{}, m.picker.buckets...), "+ Create a new bucket") {
+			cursor := "  "
+			if i == m.picker.cursor {
+				cursor = styles.RenderHighlight("> ")
+			}
+			if bucket == m.remoteConfig.Bucket && i < len(m.picker.buckets) {
+				bucket += styles.RenderMuted(" (current)")
+			}
+			b.WriteString(cursor + bucket + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// bucketStepHelp returns the footer of the bucket step
+func (m RemoteConfigModel) bucketStepHelp() string {
+	switch {
+	case m.picker.loading || m.picker.creating:
+		return "Please wait..."
+	case m.picker.listErr != nil:
+		return "r: Retry • s: Skip • esc: Edit settings"
+	case m.picker.naming:
+		return "Enter: Create • esc: Cancel"
+	default:
+		return "↑/↓: Select • Enter: Use as default • r: Refresh • s: Skip • esc: Edit settings"
+	}
+}
+
+// defaultBucket returns the default bucket of a remote stored in the
+// configuration, which pre-fills the bucket of new sync pairs and scripts
+func defaultBucket(remote string) string {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return ""
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return ""
+	}
+	return appConfig.DefaultBucket(remote)
+}
+
+// listingCache returns the cache of remote listings, or nil, which lists
+// the remotes every time, if the home directory is unknown
+func listingCache() *rclone.ListingCache {
+	dir, err := rclone.DefaultListingDir()
+	if err != nil {
+		return nil
+	}
+	return rclone.NewListingCache(dir, rclone.DefaultListingTTL)
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
@@ -19,13 +20,25 @@ const (
 	RemoteStepSelectType RemoteConfigStep = iota
 	RemoteStepB2Config
 	RemoteStepScalewayConfig
+	RemoteStepMinioConfig
+	RemoteStepSftpConfig
+	RemoteStepWebdavConfig
+	RemoteStepGcsConfig
+	RemoteStepAzureConfig
+	RemoteStepLocalConfig
+	RemoteStepUnionConfig
+	RemoteStepSelectBucket
+	RemoteStepTestConnection
 	RemoteStepComplete
 )
 
+// secretRefHint explains how to keep keys out of the config files
+const secretRefHint = "To keep a key off disk, enter env:VAR_NAME or cmd:<command>, e.g. cmd:op read op://vault/item/key"
+
 // RemoteConfigModel represents the remote configuration wizard
 type RemoteConfigModel struct {
 	currentStep   RemoteConfigStep
-	remoteType    string // "b2" or "s3"
+	remoteType    string // "b2", "s3", "sftp", "webdav", "azureblob", config.TypeGCS, config.TypeLocal or config.TypeUnion
 	providerName  string // Display name of the provider
 	inputs        []textinput.Model
 	focusIndex    int
@@ -35,6 +48,19 @@ type RemoteConfigModel struct {
 	complete      bool
 	configManager *config.Manager
 	remoteConfig  config.RemoteConfig
+	validation    FormValidator
+
+	// Set when editing a stored remote instead of adding one
+	editing  string
+	original config.RemoteConfig
+	added    bool // editing is the remote this wizard added
+
+	picker bucketPicker   // The default bucket step, see remote_bucket.go
+	tester connectionTest // The connection test of forms without a bucket step, see remote_sftp.go
+
+	// The setup guide of the form, see remote_guide.go
+	guide     viewport.Model
+	guideOpen bool
 }
 
 // NewRemoteConfigModel creates a new remote configuration model
@@ -59,39 +85,128 @@ func NewRemoteConfigModelWithProvider(configManager *config.Manager, providerNam
 	case "Backblaze B2":
 		model.currentStep = RemoteStepB2Config
 		model.remoteType = "b2"
+		model.initB2Inputs()
 	case "Scaleway Object Storage":
 		model.currentStep = RemoteStepScalewayConfig
 		model.remoteType = "s3"
+		model.initScalewayInputs()
+	case "MinIO":
+		model.currentStep = RemoteStepMinioConfig
+		model.remoteType = "s3"
+		model.initMinioInputs()
+	case "SFTP":
+		model.currentStep = RemoteStepSftpConfig
+		model.remoteType = "sftp"
+		model.initSftpInputs()
+	case "Nextcloud", "ownCloud", "WebDAV":
+		model.currentStep = RemoteStepWebdavConfig
+		model.remoteType = "webdav"
+		model.initWebdavInputs(webdavPresets[providerName])
+	case "Google Cloud Storage":
+		model.currentStep = RemoteStepGcsConfig
+		model.remoteType = config.TypeGCS
+		model.initGcsInputs()
+	case "Microsoft Azure Blob Storage":
+		model.currentStep = RemoteStepAzureConfig
+		model.remoteType = "azureblob"
+		model.initAzureInputs()
+	case "Local Folder":
+		model.currentStep = RemoteStepLocalConfig
+		model.remoteType = config.TypeLocal
+		model.initLocalInputs()
+	case "Union":
+		model.currentStep = RemoteStepUnionConfig
+		model.remoteType = config.TypeUnion
+		model.initUnionInputs()
 	default:
 		// For other providers, we'll use a generic configuration
 		// For now, default to S3-compatible configuration
 		model.currentStep = RemoteStepScalewayConfig
 		model.remoteType = "s3"
+		model.initScalewayInputs()
 	}
 	
 	return model
 }
 
+// NewEditRemoteModel creates a form that edits a stored remote, prefilled
+// with its settings. Changing the name renames the remote everywhere it is
+// used.
+func NewEditRemoteModel(configManager *config.Manager, remote config.RemoteConfig) RemoteConfigModel {
+	var m RemoteConfigModel
+	if remote.IsSFTP() || remote.IsWebDAV() || remote.IsGCS() || remote.IsAzureBlob() || remote.IsLocal() || remote.IsAlias() || remote.IsUnion() {
+		switch {
+		case remote.IsSFTP():
+			m = NewRemoteConfigModelWithProvider(configManager, "SFTP")
+			m.setSftpInputs(remote)
+		case remote.IsWebDAV():
+			m = NewRemoteConfigModelWithProvider(configManager, "WebDAV")
+			m.setWebdavInputs(remote)
+		case remote.IsGCS():
+			m = NewRemoteConfigModelWithProvider(configManager, "Google Cloud Storage")
+			m.setGcsInputs(remote)
+		case remote.IsLocal(), remote.IsAlias():
+			m = NewRemoteConfigModelWithProvider(configManager, "Local Folder")
+			m.setLocalInputs(remote)
+		case remote.IsUnion():
+			m = NewRemoteConfigModelWithProvider(configManager, "Union")
+			m.setUnionInputs(remote)
+		default:
+			m = NewRemoteConfigModelWithProvider(configManager, "Microsoft Azure Blob Storage")
+			m.setAzureInputs(remote)
+		}
+		m.editing = remote.Name
+		m.original = remote
+		return m
+	}
+	if remote.Type == "b2" {
+		m = NewRemoteConfigModelWithProvider(configManager, "Backblaze B2")
+	} else if remote.IsSelfHosted() {
+		m = NewRemoteConfigModelWithProvider(configManager, "MinIO")
+		m.inputs[3].SetValue(remote.Endpoint)
+		m.inputs[4].SetValue(remote.Region)
+		m.inputs[5].SetValue(yesNo(!remote.VirtualHostStyle))
+		m.inputs[6].SetValue(yesNo(remote.SkipTLSVerify))
+	} else {
+		m = NewRemoteConfigModelWithProvider(configManager, remote.Provider)
+		m.inputs[3].SetValue(remote.Region)
+		m.inputs[4].SetValue(remote.Endpoint)
+	}
+	m.inputs[0].SetValue(remote.Name)
+	m.inputs[1].SetValue(remote.AccountID)
+	m.inputs[2].SetValue(remote.ApplicationKey)
+
+	m.editing = remote.Name
+	m.original = remote
+	return m
+}
+
 // Init initializes the remote configuration wizard
 func (m RemoteConfigModel) Init() tea.Cmd {
-	// If we have a provider name set, initialize inputs automatically
-	if m.providerName != "" {
-		switch m.currentStep {
-		case RemoteStepB2Config:
-			return m.initB2Inputs()
-		case RemoteStepScalewayConfig:
-			return m.initScalewayInputs()
-		}
+	// The inputs of a preselected provider are set up by the constructor
+	if len(m.inputs) > 0 {
+		return textinput.Blink
 	}
 	return nil
 }
 
 // Update handles messages for the remote configuration wizard
 func (m RemoteConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, resize := msg.(tea.WindowSizeMsg); !resize {
+		switch m.currentStep {
+		case RemoteStepSelectBucket:
+			return m.updateBucketStep(msg)
+		case RemoteStepTestConnection:
+			return m.updateConnectionStep(msg)
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.guide.Width = m.guideWidth()
+		m.refreshGuide()
 		return m, nil
 
 	case tea.KeyMsg:
@@ -100,17 +215,44 @@ func (m RemoteConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "esc", "q":
-			if m.currentStep == RemoteStepSelectType {
-				return m, nil
+			if m.currentStep == RemoteStepSelectType || m.complete || (m.editing != "" && msg.String() == "esc") {
+				return m, BackCmd()
 			}
-			// Go back to previous step
-			if m.currentStep > RemoteStepSelectType {
-				m.currentStep--
+			// q is typed into the text fields; only esc goes back a step
+			if msg.String() == "q" {
+				break
 			}
+			m.currentStep = RemoteStepSelectType
+			m.inputs = nil
+			m.err = nil
+			m.guideOpen = false
+			return m, nil
+
+		case guideKey:
+			m.toggleGuide()
 			return m, nil
 
+		case "pgup", "pgdown":
+			if m.guideOpen {
+				if msg.String() == "pgup" {
+					m.guide.ViewUp()
+				} else {
+					m.guide.ViewDown()
+				}
+				return m, nil
+			}
+
 		case "tab", "shift+tab", "up", "down":
+			if cmd, ok := m.completeKeyFile(msg); ok {
+				return m, cmd
+			}
+			if cmd, ok := m.completeFolder(msg); ok {
+				return m, cmd
+			}
 			if len(m.inputs) > 0 {
+				if m.focusIndex < len(m.inputs) {
+					m.validation.Recheck(m.focusIndex, m.inputs[m.focusIndex].Value())
+				}
 				s := msg.String()
 				if s == "up" || s == "shift+tab" {
 					m.focusIndex--
@@ -146,14 +288,72 @@ func (m RemoteConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.currentStep == RemoteStepSelectType {
 				m.remoteType = "b2"
 				m.currentStep = RemoteStepB2Config
-				return m, m.initB2Inputs()
+				cmd := m.initB2Inputs()
+				return m, cmd
 			}
 
 		case "2":
 			if m.currentStep == RemoteStepSelectType {
 				m.remoteType = "s3"
 				m.currentStep = RemoteStepScalewayConfig
-				return m, m.initScalewayInputs()
+				cmd := m.initScalewayInputs()
+				return m, cmd
+			}
+
+		case "3":
+			if m.currentStep == RemoteStepSelectType {
+				m.remoteType = "s3"
+				m.currentStep = RemoteStepMinioConfig
+				cmd := m.initMinioInputs()
+				return m, cmd
+			}
+
+		case "4":
+			if m.currentStep == RemoteStepSelectType {
+				m.remoteType = "sftp"
+				m.currentStep = RemoteStepSftpConfig
+				cmd := m.initSftpInputs()
+				return m, cmd
+			}
+
+		case "5":
+			if m.currentStep == RemoteStepSelectType {
+				m.remoteType = "webdav"
+				m.currentStep = RemoteStepWebdavConfig
+				cmd := m.initWebdavInputs(webdavPresets["Nextcloud"])
+				return m, cmd
+			}
+
+		case "6":
+			if m.currentStep == RemoteStepSelectType {
+				m.remoteType = config.TypeGCS
+				m.currentStep = RemoteStepGcsConfig
+				cmd := m.initGcsInputs()
+				return m, cmd
+			}
+
+		case "7":
+			if m.currentStep == RemoteStepSelectType {
+				m.remoteType = "azureblob"
+				m.currentStep = RemoteStepAzureConfig
+				cmd := m.initAzureInputs()
+				return m, cmd
+			}
+
+		case "8":
+			if m.currentStep == RemoteStepSelectType {
+				m.remoteType = config.TypeLocal
+				m.currentStep = RemoteStepLocalConfig
+				cmd := m.initLocalInputs()
+				return m, cmd
+			}
+
+		case "9":
+			if m.currentStep == RemoteStepSelectType {
+				m.remoteType = config.TypeUnion
+				m.currentStep = RemoteStepUnionConfig
+				cmd := m.initUnionInputs()
+				return m, cmd
 			}
 		}
 	}
@@ -162,6 +362,14 @@ func (m RemoteConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if len(m.inputs) > 0 && m.focusIndex < len(m.inputs) {
 		var cmd tea.Cmd
 		m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
+		if m.inputs[m.focusIndex].ShowSuggestions {
+			if m.currentStep == RemoteStepLocalConfig {
+				m.inputs[m.focusIndex].SetSuggestions(CompletePath(m.inputs[m.focusIndex].Value()))
+			} else {
+				m.inputs[m.focusIndex].SetSuggestions(CompleteFile(m.inputs[m.focusIndex].Value(), ".json"))
+			}
+		}
+		m.refreshGuide()
 		return m, cmd
 	}
 
@@ -173,7 +381,11 @@ func (m RemoteConfigModel) View() string {
 	helper := NewViewHelper(m.width, m.height)
 	var b strings.Builder
 
-	b.WriteString(helper.RenderHeader("Configure Rclone Remote", "Set up cloud storage credentials"))
+	if m.editing != "" && !m.added {
+		b.WriteString(helper.RenderHeader("Edit Rclone Remote", fmt.Sprintf("Change the settings of '%s'", m.editing)))
+	} else {
+		b.WriteString(helper.RenderHeader("Configure Rclone Remote", "Set up cloud storage credentials"))
+	}
 
 	switch m.currentStep {
 	case RemoteStepSelectType:
@@ -182,22 +394,48 @@ func (m RemoteConfigModel) View() string {
 		b.WriteString(m.renderB2Config())
 	case RemoteStepScalewayConfig:
 		b.WriteString(m.renderScalewayConfig())
+	case RemoteStepMinioConfig:
+		b.WriteString(m.renderMinioConfig())
+	case RemoteStepSftpConfig:
+		b.WriteString(m.renderSftpConfig())
+	case RemoteStepWebdavConfig:
+		b.WriteString(m.renderWebdavConfig())
+	case RemoteStepGcsConfig:
+		b.WriteString(m.renderGcsConfig())
+	case RemoteStepAzureConfig:
+		b.WriteString(m.renderAzureConfig())
+	case RemoteStepLocalConfig:
+		b.WriteString(m.renderLocalConfig())
+	case RemoteStepUnionConfig:
+		b.WriteString(m.renderUnionConfig())
+	case RemoteStepSelectBucket:
+		b.WriteString(m.renderSelectBucket())
+	case RemoteStepTestConnection:
+		b.WriteString(m.renderTestConnection())
 	case RemoteStepComplete:
 		b.WriteString(m.renderComplete())
 	}
+	if m.guideProvider() != "" {
+		b.WriteString("\n\n")
+		b.WriteString(m.renderGuide())
+	}
 
 	if m.err != nil {
 		b.WriteString("\n")
-		b.WriteString(styles.RenderError(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString(renderError(m.err))
 		b.WriteString("\n")
 	}
 
 	if m.currentStep == RemoteStepSelectType {
-		b.WriteString(helper.RenderFooter("1: Backblaze B2 • 2: Scaleway • q: Back"))
+		b.WriteString(helper.RenderFooter("1: Backblaze B2 • 2: Scaleway • 3: MinIO • 4: SFTP • 5: WebDAV • 6: GCS • 7: Azure • 8: Local • 9: Union • q: Back"))
+	} else if m.currentStep == RemoteStepSelectBucket {
+		b.WriteString(helper.RenderFooter(m.bucketStepHelp()))
+	} else if m.currentStep == RemoteStepTestConnection {
+		b.WriteString(helper.RenderFooter(m.connectionStepHelp()))
 	} else if m.complete {
-		b.WriteString(helper.RenderFooter("Press Enter to continue • q: Back to menu"))
+		b.WriteString(helper.RenderFooter("Press Enter to continue • q: Back"))
 	} else {
-		b.WriteString(helper.RenderFooter("Tab: Next field • Enter: Save • q: Back"))
+		b.WriteString(helper.RenderFooter("Tab: Next field • Enter: Save • " + m.guideHelp() + " • esc: Back"))
 	}
 
 	return b.String()
@@ -215,7 +453,21 @@ func (m RemoteConfigModel) renderSelectType() string {
 	content += "1. Backblaze B2\n"
 	content += "   - Source storage for your data\n\n"
 	content += "2. Scaleway Object Storage\n"
-	content += "   - Destination for backups\n"
+	content += "   - Destination for backups\n\n"
+	content += "3. MinIO / self-hosted S3\n"
+	content += "   - Your own S3-compatible server\n\n"
+	content += "4. SFTP server\n"
+	content += "   - A NAS or server you can log into over SSH\n\n"
+	content += "5. Nextcloud / ownCloud / WebDAV\n"
+	content += "   - A file server that speaks WebDAV\n\n"
+	content += "6. Google Cloud Storage\n"
+	content += "   - A bucket reached with a service account key\n\n"
+	content += "7. Azure Blob Storage\n"
+	content += "   - A storage account, by account key or SAS URL\n\n"
+	content += "8. Local folder or network share\n"
+	content += "   - A disk or SMB/NFS share mounted under /Volumes\n\n"
+	content += "9. Union of remotes\n"
+	content += "   - Several remotes used as one, e.g. two free tiers\n"
 
 	return box.Render(content)
 }
@@ -228,14 +480,14 @@ func (m RemoteConfigModel) renderB2Config() string {
 	b.WriteString("\n\n")
 	
 	for i, input := range m.inputs {
-		b.WriteString(input.View())
+		b.WriteString(m.validation.RenderField(i, input))
 		if i < len(m.inputs)-1 {
 			b.WriteString("\n\n")
 		}
 	}
 	
 	b.WriteString("\n\n")
-	b.WriteString(styles.RenderMuted("Get your credentials from: https://www.backblaze.com/b2/cloud-storage.html"))
+	b.WriteString(styles.RenderMuted(secretRefHint))
 	
 	return b.String()
 }
@@ -246,50 +498,66 @@ func (m RemoteConfigModel) renderScalewayConfig() string {
 	
 	// Use provider name if set, otherwise default to Scaleway
 	title := "Scaleway Object Storage Configuration"
-	helpURL := "https://console.scaleway.com/"
-	
 	if m.providerName != "" {
 		title = fmt.Sprintf("%s Configuration", m.providerName)
-		// Provide help URLs for known providers
-		switch m.providerName {
-		case "Amazon S3":
-			helpURL = "https://aws.amazon.com/s3/"
-		case "Google Cloud Storage":
-			helpURL = "https://cloud.google.com/storage"
-		case "Microsoft Azure Blob Storage":
-			helpURL = "https://azure.microsoft.com/en-us/services/storage/blobs/"
-		case "DigitalOcean Spaces":
-			helpURL = "https://www.digitalocean.com/products/spaces"
-		case "Wasabi":
-			helpURL = "https://wasabi.com/"
-		default:
-			helpURL = "https://rclone.org/docs/"
-		}
 	}
 	
 	b.WriteString(styles.RenderInfo(title))
 	b.WriteString("\n\n")
 	
 	for i, input := range m.inputs {
-		b.WriteString(input.View())
+		b.WriteString(m.validation.RenderField(i, input))
 		if i < len(m.inputs)-1 {
 			b.WriteString("\n\n")
 		}
 	}
 	
 	b.WriteString("\n\n")
-	b.WriteString(styles.RenderMuted(fmt.Sprintf("Get your credentials from: %s", helpURL)))
+	b.WriteString(styles.RenderMuted(secretRefHint))
 	
 	return b.String()
 }
 
+// renderMinioConfig renders the MinIO configuration form
+func (m RemoteConfigModel) renderMinioConfig() string {
+	var b strings.Builder
+
+	b.WriteString(styles.RenderInfo("MinIO / Self-Hosted S3 Configuration"))
+	b.WriteString("\n\n")
+
+	for i, input := range m.inputs {
+		b.WriteString(m.validation.RenderField(i, input))
+		if i < len(m.inputs)-1 {
+			b.WriteString("\n\n")
+		}
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(styles.RenderMuted("Keys are created in the MinIO console under Access Keys. Most servers use path-style addressing;"))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("skip TLS verification only for servers with a self-signed certificate."))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted(secretRefHint))
+
+	return b.String()
+}
+
 // renderComplete renders the completion message
 func (m RemoteConfigModel) renderComplete() string {
-	return styles.RenderSuccess(fmt.Sprintf("✓ Remote '%s' configured successfully!\n\nConfiguration saved.", m.remoteConfig.Name))
+	var bucket string
+	if m.remoteConfig.Bucket != "" {
+		bucket = fmt.Sprintf("\nDefault bucket: %s", m.remoteConfig.Bucket)
+	} else if (m.remoteConfig.IsSFTP() || m.remoteConfig.IsWebDAV() || m.remoteConfig.IsAlias() || m.remoteConfig.IsUnion()) && m.tester.err == nil {
+		bucket = fmt.Sprintf("\nConnected to %s.", m.connectionTarget())
+	}
+	if m.editing != "" && !m.added {
+		return styles.RenderSuccess(fmt.Sprintf("✓ Remote '%s' updated!\n\nSync pairs and rclone.conf use the new settings.%s", m.remoteConfig.Name, bucket))
+	}
+	return styles.RenderSuccess(fmt.Sprintf("✓ Remote '%s' configured successfully!\n\nConfiguration saved.%s", m.remoteConfig.Name, bucket))
 }
 
 // initB2Inputs initializes input fields for B2 configuration
-func (m RemoteConfigModel) initB2Inputs() tea.Cmd {
+func (m *RemoteConfigModel) initB2Inputs() tea.Cmd {
 	inputs := make([]textinput.Model, 3)
 
 	// Remote name
@@ -320,12 +588,13 @@ func (m RemoteConfigModel) initB2Inputs() tea.Cmd {
 
 	m.inputs = inputs
 	m.focusIndex = 0
+	m.validation = NewFormValidator(RemoteName, Required, Required)
 
 	return inputs[0].Focus()
 }
 
 // initScalewayInputs initializes input fields for Scaleway configuration
-func (m RemoteConfigModel) initScalewayInputs() tea.Cmd {
+func (m *RemoteConfigModel) initScalewayInputs() tea.Cmd {
 	inputs := make([]textinput.Model, 5)
 
 	// Remote name
@@ -372,14 +641,104 @@ func (m RemoteConfigModel) initScalewayInputs() tea.Cmd {
 
 	m.inputs = inputs
 	m.focusIndex = 0
+	m.validation = NewFormValidator(RemoteName, Required, Required, nil, Optional(EndpointURL))
 
 	return inputs[0].Focus()
 }
 
+// initMinioInputs initializes input fields for MinIO and other self-hosted
+// S3 servers
+func (m *RemoteConfigModel) initMinioInputs() tea.Cmd {
+	inputs := make([]textinput.Model, 7)
+
+	// Remote name
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "minio"
+	inputs[0].Focus()
+	inputs[0].PromptStyle = styles.FocusedStyle
+	inputs[0].TextStyle = styles.FocusedStyle
+	inputs[0].CharLimit = 32
+	inputs[0].Width = 50
+	inputs[0].Prompt = "Remote Name: "
+
+	// Access Key ID
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "Your MinIO Access Key"
+	inputs[1].CharLimit = 100
+	inputs[1].Width = 50
+	inputs[1].Prompt = "Access Key: "
+
+	// Secret Access Key
+	inputs[2] = textinput.New()
+	inputs[2].Placeholder = "Your MinIO Secret Key"
+	inputs[2].CharLimit = 100
+	inputs[2].Width = 50
+	inputs[2].Prompt = "Secret Key: "
+	inputs[2].EchoMode = textinput.EchoPassword
+	inputs[2].EchoCharacter = '•'
+
+	// Endpoint
+	inputs[3] = textinput.New()
+	inputs[3].Placeholder = "https://minio.local:9000"
+	inputs[3].CharLimit = 100
+	inputs[3].Width = 50
+	inputs[3].Prompt = "Endpoint: "
+
+	// Region
+	inputs[4] = textinput.New()
+	inputs[4].Placeholder = "optional, e.g. us-east-1"
+	inputs[4].CharLimit = 20
+	inputs[4].Width = 50
+	inputs[4].Prompt = "Region: "
+
+	// Path-style addressing
+	inputs[5] = textinput.New()
+	inputs[5].Placeholder = "y/n"
+	inputs[5].CharLimit = 3
+	inputs[5].Width = 50
+	inputs[5].Prompt = "Path-style addressing (y/n): "
+	inputs[5].SetValue("y")
+
+	// TLS verification
+	inputs[6] = textinput.New()
+	inputs[6].Placeholder = "y/n"
+	inputs[6].CharLimit = 3
+	inputs[6].Width = 50
+	inputs[6].Prompt = "Skip TLS verification (y/n): "
+	inputs[6].SetValue("n")
+
+	m.inputs = inputs
+	m.focusIndex = 0
+	m.validation = NewFormValidator(RemoteName, Required, Required, All(Required, EndpointURL), nil,
+		OneOf("y", "n", "yes", "no"), OneOf("y", "n", "yes", "no"))
+
+	return inputs[0].Focus()
+}
+
+// yesNo returns the y/n answer of a toggle field
+func yesNo(value bool) string {
+	if value {
+		return "y"
+	}
+	return "n"
+}
+
+// isYes reports whether a y/n field is answered yes
+func isYes(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
 // handleEnter handles the Enter key press
 func (m RemoteConfigModel) handleEnter() (tea.Model, tea.Cmd) {
 	if m.currentStep == RemoteStepComplete {
-		return m, nil
+		if m.editing != "" && !m.added {
+			return m, DoneCmd(fmt.Sprintf("Remote '%s' updated", m.remoteConfig.Name))
+		}
+		return m, DoneCmd("Remote saved")
 	}
 
 	// Validate and save configuration
@@ -393,8 +752,8 @@ func (m RemoteConfigModel) handleEnter() (tea.Model, tea.Cmd) {
 		accountID := strings.TrimSpace(m.inputs[1].Value())
 		appKey := strings.TrimSpace(m.inputs[2].Value())
 
-		if name == "" || accountID == "" || appKey == "" {
-			m.err = fmt.Errorf("all fields are required")
+		if !m.validation.Validate(m.inputs) {
+			m.err = nil
 			return m, nil
 		}
 
@@ -406,20 +765,12 @@ func (m RemoteConfigModel) handleEnter() (tea.Model, tea.Cmd) {
 			ApplicationKey: appKey,
 		}
 
-		if err := m.configManager.AddRemote(m.remoteConfig); err != nil {
-			m.err = err
-			return m, nil
-		}
-
-		// Generate rclone config
-		if err := m.configManager.GenerateRcloneConfig(); err != nil {
+		if err := m.save(); err != nil {
 			m.err = err
 			return m, nil
 		}
 
-		m.currentStep = RemoteStepComplete
-		m.complete = true
-		return m, nil
+		return m.chooseBucket()
 
 	} else if m.currentStep == RemoteStepScalewayConfig {
 		if len(m.inputs) < 5 {
@@ -433,8 +784,8 @@ func (m RemoteConfigModel) handleEnter() (tea.Model, tea.Cmd) {
 		region := strings.TrimSpace(m.inputs[3].Value())
 		endpoint := strings.TrimSpace(m.inputs[4].Value())
 
-		if name == "" || accessKey == "" || secretKey == "" {
-			m.err = fmt.Errorf("name, access key, and secret key are required")
+		if !m.validation.Validate(m.inputs) {
+			m.err = nil
 			return m, nil
 		}
 
@@ -454,21 +805,223 @@ func (m RemoteConfigModel) handleEnter() (tea.Model, tea.Cmd) {
 			Endpoint:       endpoint,
 		}
 
-		if err := m.configManager.AddRemote(m.remoteConfig); err != nil {
+		if err := m.save(); err != nil {
 			m.err = err
 			return m, nil
 		}
 
-		// Generate rclone config
-		if err := m.configManager.GenerateRcloneConfig(); err != nil {
+		return m.chooseBucket()
+
+	} else if m.currentStep == RemoteStepMinioConfig {
+		if len(m.inputs) < 7 {
+			m.err = fmt.Errorf("invalid input configuration")
+			return m, nil
+		}
+
+		if !m.validation.Validate(m.inputs) {
+			m.err = nil
+			return m, nil
+		}
+
+		m.remoteConfig = config.RemoteConfig{
+			Name:             strings.TrimSpace(m.inputs[0].Value()),
+			Type:             "s3",
+			Provider:         config.ProviderMinio,
+			AccountID:        strings.TrimSpace(m.inputs[1].Value()),
+			ApplicationKey:   strings.TrimSpace(m.inputs[2].Value()),
+			Endpoint:         strings.TrimSpace(m.inputs[3].Value()),
+			Region:           strings.TrimSpace(m.inputs[4].Value()),
+			VirtualHostStyle: !isYes(m.inputs[5].Value()),
+			SkipTLSVerify:    isYes(m.inputs[6].Value()),
+		}
+
+		if err := m.save(); err != nil {
 			m.err = err
 			return m, nil
 		}
 
-		m.currentStep = RemoteStepComplete
-		m.complete = true
-		return m, nil
+		return m.chooseBucket()
+
+	} else if m.currentStep == RemoteStepSftpConfig {
+		if len(m.inputs) < 7 {
+			m.err = fmt.Errorf("invalid input configuration")
+			return m, nil
+		}
+
+		if !m.validation.Validate(m.inputs) {
+			m.err = nil
+			return m, nil
+		}
+
+		m.remoteConfig = m.sftpRemote()
+
+		if err := m.save(); err != nil {
+			m.err = err
+			return m, nil
+		}
+
+		return m.testConnection(connectionTest{})
+
+	} else if m.currentStep == RemoteStepWebdavConfig {
+		if len(m.inputs) < 5 {
+			m.err = fmt.Errorf("invalid input configuration")
+			return m, nil
+		}
+
+		if !m.validation.Validate(m.inputs) {
+			m.err = nil
+			return m, nil
+		}
+
+		m.remoteConfig = m.webdavRemote()
+		if err := config.ValidateWebDAVURL(m.remoteConfig.Vendor, m.remoteConfig.URL); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.err = nil
+
+		// Unlike the other forms, nothing is saved until the server answers
+		return m.testConnection(connectionTest{unsaved: true})
+
+	} else if m.currentStep == RemoteStepGcsConfig {
+		if len(m.inputs) < 5 {
+			m.err = fmt.Errorf("invalid input configuration")
+			return m, nil
+		}
+
+		if !m.validation.Validate(m.inputs) {
+			m.err = nil
+			return m, nil
+		}
+
+		var bucket string
+		m.remoteConfig, bucket = m.gcsRemote()
+
+		if err := m.save(); err != nil {
+			m.err = err
+			return m, nil
+		}
+
+		return m.testConnection(connectionTest{bucket: bucket})
+
+	} else if m.currentStep == RemoteStepAzureConfig {
+		if len(m.inputs) < 6 {
+			m.err = fmt.Errorf("invalid input configuration")
+			return m, nil
+		}
+
+		if !m.validation.Validate(m.inputs) {
+			m.err = nil
+			return m, nil
+		}
+
+		m.remoteConfig = m.azureRemote()
+
+		if err := m.save(); err != nil {
+			m.err = err
+			return m, nil
+		}
+
+		// A container's SAS URL cannot list the account's containers
+		if container := m.remoteConfig.SASContainer(); container != "" {
+			return m.testConnection(connectionTest{bucket: container})
+		}
+		return m.chooseBucket()
+
+	} else if m.currentStep == RemoteStepLocalConfig {
+		if len(m.inputs) < 2 {
+			m.err = fmt.Errorf("invalid input configuration")
+			return m, nil
+		}
+
+		if !m.validation.Validate(m.inputs) {
+			m.err = nil
+			return m, nil
+		}
+
+		m.remoteConfig = m.localRemote()
+
+		if err := m.save(); err != nil {
+			m.err = err
+			return m, nil
+		}
+
+		// A local remote is every folder of this Mac; there is nothing
+		// to connect to
+		if m.remoteConfig.IsLocal() {
+			return m.finish()
+		}
+		return m.testConnection(connectionTest{})
+
+	} else if m.currentStep == RemoteStepUnionConfig {
+		if len(m.inputs) < 3 {
+			m.err = fmt.Errorf("invalid input configuration")
+			return m, nil
+		}
+
+		if !m.validation.Validate(m.inputs) {
+			m.err = nil
+			return m, nil
+		}
+
+		m.remoteConfig = m.unionRemote()
+
+		if err := m.save(); err != nil {
+			m.err = err
+			return m, nil
+		}
+
+		return m.testConnection(connectionTest{})
 	}
 
 	return m, nil
 }
+
+// save stores the remote and regenerates rclone.conf. An edited remote is
+// renamed first if its name changed, which is undone if the remote cannot
+// be updated, and the backup scripts are regenerated for the new name.
+func (m *RemoteConfigModel) save() error {
+	renamed := false
+	if m.editing == "" {
+		if err := m.configManager.AddRemote(m.remoteConfig); err != nil {
+			return err
+		}
+		// Going back from the bucket step to fix the credentials edits
+		// the remote that was just added
+		m.editing, m.original, m.added = m.remoteConfig.Name, m.remoteConfig, true
+	} else {
+		// Keep the settings the form does not show
+		m.remoteConfig.Bucket = m.original.Bucket
+		m.remoteConfig.Tuning = m.original.Tuning
+		m.remoteConfig.Label, m.remoteConfig.Icon, m.remoteConfig.Color = m.original.Label, m.original.Icon, m.original.Color
+		renamed = m.editing != m.remoteConfig.Name
+		if err := m.configManager.RenameRemote(m.editing, m.remoteConfig.Name); err != nil {
+			return err
+		}
+		if err := m.configManager.UpdateRemote(m.remoteConfig.Name, m.remoteConfig); err != nil {
+			// Put the old name back, so the remote is left as it was
+			if renamed {
+				if undoErr := m.configManager.RenameRemote(m.remoteConfig.Name, m.editing); undoErr != nil {
+					return fmt.Errorf("%w (and failed to undo the rename: %v)", err, undoErr)
+				}
+			}
+			return err
+		}
+		m.editing = m.remoteConfig.Name
+	}
+
+	// Generate rclone config
+	if err := m.configManager.GenerateRcloneConfig(); err != nil {
+		return err
+	}
+
+	// The backup scripts name the source and destination remotes
+	if renamed {
+		appConfig, err := m.configManager.Load()
+		if err != nil {
+			return err
+		}
+		return regenerateScripts(appConfig)
+	}
+	return nil
+}
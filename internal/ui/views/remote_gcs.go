@@ -0,0 +1,149 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// gcsKeyField is the index of the service account key in the GCS form,
+// which completes paths to .json files
+const gcsKeyField = 1
+
+// initGcsInputs initializes input fields for Google Cloud Storage
+// configuration
+func (m *RemoteConfigModel) initGcsInputs() tea.Cmd {
+	inputs := make([]textinput.Model, 5)
+
+	// Remote name
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "gcs"
+	inputs[0].Focus()
+	inputs[0].PromptStyle = styles.FocusedStyle
+	inputs[0].TextStyle = styles.FocusedStyle
+	inputs[0].CharLimit = 32
+	inputs[0].Width = 50
+	inputs[0].Prompt = "Remote Name: "
+
+	// Service account key
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "~/Downloads/my-project-1a2b3c.json"
+	inputs[1].CharLimit = 255
+	inputs[1].Width = 50
+	inputs[1].Prompt = "Service Account Key: "
+	inputs[1].ShowSuggestions = true
+
+	// Bucket
+	inputs[2] = textinput.New()
+	inputs[2].Placeholder = "my-backups"
+	inputs[2].CharLimit = 222
+	inputs[2].Width = 50
+	inputs[2].Prompt = "Bucket: "
+
+	// Project number
+	inputs[3] = textinput.New()
+	inputs[3].Placeholder = "optional, to list and create buckets"
+	inputs[3].CharLimit = 30
+	inputs[3].Width = 50
+	inputs[3].Prompt = "Project Number: "
+
+	// Uniform bucket-level access
+	inputs[4] = textinput.New()
+	inputs[4].Placeholder = "y/n"
+	inputs[4].CharLimit = 3
+	inputs[4].Width = 50
+	inputs[4].Prompt = "Uniform bucket-level access (y/n): "
+	inputs[4].SetValue("y")
+
+	m.inputs = inputs
+	m.focusIndex = 0
+	m.validation = NewFormValidator(RemoteName, All(Required, ServiceAccountKey), Required, nil,
+		OneOf("y", "n", "yes", "no"))
+
+	return inputs[0].Focus()
+}
+
+// setGcsInputs prefills the GCS form with a stored remote
+func (m *RemoteConfigModel) setGcsInputs(remote config.RemoteConfig) {
+	m.inputs[0].SetValue(remote.Name)
+	m.inputs[1].SetValue(remote.ServiceAccountFile)
+	m.inputs[2].SetValue(remote.Bucket)
+	m.inputs[3].SetValue(remote.ProjectNumber)
+	m.inputs[4].SetValue(yesNo(remote.BucketPolicyOnly))
+}
+
+// gcsRemote returns the remote the GCS form describes, and the bucket to
+// test it on
+func (m RemoteConfigModel) gcsRemote() (config.RemoteConfig, string) {
+	return config.RemoteConfig{
+		Name:               strings.TrimSpace(m.inputs[0].Value()),
+		Type:               config.TypeGCS,
+		Provider:           "Google",
+		ServiceAccountFile: strings.TrimSpace(m.inputs[1].Value()),
+		ProjectNumber:      strings.TrimSpace(m.inputs[3].Value()),
+		BucketPolicyOnly:   isYes(m.inputs[4].Value()),
+	}, strings.TrimSpace(m.inputs[2].Value())
+}
+
+// completeKeyFile accepts the suggested path of the key field on tab, and
+// reports whether it did
+func (m *RemoteConfigModel) completeKeyFile(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if m.currentStep != RemoteStepGcsConfig || m.focusIndex != gcsKeyField || msg.String() != "tab" {
+		return nil, false
+	}
+	input := &m.inputs[gcsKeyField]
+	if len(input.AvailableSuggestions()) == 0 || input.CurrentSuggestion() == input.Value() {
+		return nil, false
+	}
+	var cmd tea.Cmd
+	*input, cmd = input.Update(msg)
+	input.SetSuggestions(CompleteFile(input.Value(), ".json"))
+	return cmd, true
+}
+
+// renderGcsConfig renders the GCS configuration form
+func (m RemoteConfigModel) renderGcsConfig() string {
+	var b strings.Builder
+
+	b.WriteString(styles.RenderInfo("Google Cloud Storage Configuration"))
+	b.WriteString("\n\n")
+
+	for i, input := range m.inputs {
+		b.WriteString(m.validation.RenderField(i, input))
+		if i == gcsKeyField {
+			if account := m.serviceAccount(); account != nil {
+				b.WriteString("\n")
+				b.WriteString(styles.RenderMuted(fmt.Sprintf("  %s (project %s)", account.ClientEmail, account.ProjectID)))
+			}
+		}
+		if i < len(m.inputs)-1 {
+			b.WriteString("\n\n")
+		}
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(styles.RenderMuted("Create a key under IAM & Admin → Service Accounts → Keys → Add key → JSON, and give the account"))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("the Storage Object Admin role on the bucket. Tab completes the key's path."))
+
+	return b.String()
+}
+
+// serviceAccount returns the account of the key field, or nil while it
+// does not name a readable key
+func (m RemoteConfigModel) serviceAccount() *config.ServiceAccount {
+	path, err := ExpandHome(strings.TrimSpace(m.inputs[gcsKeyField].Value()))
+	if err != nil || path == "" {
+		return nil
+	}
+	account, err := config.ReadServiceAccount(path)
+	if err != nil {
+		return nil
+	}
+	return account
+}
@@ -0,0 +1,409 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// guideKey opens and closes the setup guide of a remote form. Letters and
+// the keys the text fields edit with are typed into the form.
+const guideKey = "ctrl+o"
+
+// guideHeight is how many lines of the setup guide are visible at once
+const guideHeight = 10
+
+// setupGuide tells how to create the credentials a remote form asks for
+type setupGuide struct {
+	title string
+	steps []string
+	needs []string // Capabilities, scopes or roles the credentials need
+	least []string // Least-privilege recommendations
+	link  string
+}
+
+// setupGuides are the guides of the providers, keyed by provider name
+var setupGuides = map[string]setupGuide{
+	"Backblaze B2": {
+		title: "Creating a Backblaze B2 application key",
+		steps: []string{
+			"Sign in at backblaze.com and open Application Keys under Account.",
+			"Click Add a New Application Key and name it, e.g. cloud-sync.",
+			"Under Allow access to Bucket(s), pick the bucket to back up to.",
+			"Set Type of Access to Read and Write, then create the key.",
+			"Copy the keyID into Account ID and the applicationKey into Application Key; the key is shown only once.",
+		},
+		needs: []string{
+			"listBuckets, to pick the bucket",
+			"listFiles, readFiles, writeFiles and deleteFiles on the bucket",
+		},
+		least: []string{
+			"Never use the master application key; limit the key to one bucket.",
+			"Set a file name prefix to confine the key to one folder of the bucket.",
+			"Create one key per machine, so a lost machine's key can be deleted alone.",
+		},
+		link: "https://www.backblaze.com/docs/cloud-storage-create-and-manage-app-keys",
+	},
+	"Scaleway": {
+		title: "Creating a Scaleway API key",
+		steps: []string{
+			"Open IAM in the Scaleway console and create an application for cloud-sync.",
+			"Attach a policy to the application, scoped to the project holding the bucket.",
+			"Open the application's API keys tab and click Generate API key.",
+			"Choose the project as the preferred Object Storage project.",
+			"Copy the access key and secret key; the secret key is shown only once.",
+		},
+		needs: []string{
+			"ObjectStorageBucketsRead, to list buckets",
+			"ObjectStorageObjectsRead, ObjectStorageObjectsWrite and ObjectStorageObjectsDelete",
+		},
+		least: []string{
+			"Give the key to an application rather than to your own user.",
+			"Scope the policy to one project instead of the whole organization.",
+			"Use the region of the bucket: fr-par, nl-ams or pl-waw.",
+		},
+		link: "https://www.scaleway.com/en/docs/iam/how-to/create-api-keys/",
+	},
+	"Amazon S3": {
+		title: "Creating an AWS access key",
+		steps: []string{
+			"Open IAM in the AWS console and create a user without console access.",
+			"Attach an inline policy that grants the actions below on the bucket.",
+			"Open the user's Security credentials tab and click Create access key.",
+			"Choose Application running outside AWS and copy both keys.",
+		},
+		needs: []string{
+			"s3:ListBucket on arn:aws:s3:::BUCKET",
+			"s3:GetObject, s3:PutObject and s3:DeleteObject on arn:aws:s3:::BUCKET/*",
+			"s3:ListAllMyBuckets, only to pick the bucket from a list",
+		},
+		least: []string{
+			"Never use the root account's keys.",
+			"Name the bucket in the policy's Resource instead of using *.",
+			"Rotate the key from time to time; a user can hold two keys while you switch.",
+		},
+		link: "https://docs.aws.amazon.com/IAM/latest/UserGuide/id_credentials_access-keys.html",
+	},
+	"DigitalOcean Spaces": {
+		title: "Creating a DigitalOcean Spaces key",
+		steps: []string{
+			"Open API in the DigitalOcean control panel and switch to the Spaces Keys tab.",
+			"Click Generate New Key and name it, e.g. cloud-sync.",
+			"Copy the access key and secret key; the secret is shown only once.",
+			"Use the endpoint of the Space's region, e.g. nyc3.digitaloceanspaces.com.",
+		},
+		needs: []string{
+			"Read, write and delete on the Space to back up to",
+		},
+		least: []string{
+			"Choose Limited Access and grant Read/Write/Delete on one Space only.",
+		},
+		link: "https://docs.digitalocean.com/products/spaces/how-to/manage-access/",
+	},
+	"Wasabi": {
+		title: "Creating a Wasabi access key",
+		steps: []string{
+			"Open Users in the Wasabi console and create a user with programmatic access.",
+			"Attach a policy that grants the actions below on the bucket.",
+			"Download or copy the user's access key and secret key.",
+		},
+		needs: []string{
+			"s3:ListBucket on the bucket",
+			"s3:GetObject, s3:PutObject and s3:DeleteObject on its objects",
+		},
+		least: []string{
+			"Use a sub-user rather than the root user's keys.",
+			"Name the bucket in the policy instead of using WasabiFullAccess.",
+		},
+		link: "https://docs.wasabi.com/docs/creating-a-user-account-and-access-key",
+	},
+	"S3": {
+		title: "Creating S3-compatible access keys",
+		steps: []string{
+			"Create an access key in your provider's console, for a user or service account.",
+			"Copy the access key ID and secret key.",
+			"Enter the provider's region and endpoint from its documentation.",
+		},
+		needs: []string{
+			"List the bucket, and read, write and delete its objects",
+		},
+		least: []string{
+			"Limit the key to the one bucket you back up to.",
+		},
+		link: "https://rclone.org/s3/",
+	},
+	"MinIO": {
+		title: "Creating a MinIO access key",
+		steps: []string{
+			"Sign in to the MinIO console and open Access Keys.",
+			"Click Create access key; or run mc admin user svcacct add ALIAS USER.",
+			"Turn on Restrict beyond user policy and paste a policy for the bucket.",
+			"Copy the access key and secret key; the secret is shown only once.",
+		},
+		needs: []string{
+			"s3:ListBucket on arn:aws:s3:::BUCKET",
+			"s3:GetObject, s3:PutObject and s3:DeleteObject on arn:aws:s3:::BUCKET/*",
+		},
+		least: []string{
+			"Create the key for a user other than the root user.",
+			"Restrict the key to one bucket rather than inheriting consoleAdmin.",
+		},
+		link: "https://min.io/docs/minio/linux/administration/identity-access-management/minio-user-management.html",
+	},
+	"SFTP": {
+		title: "Setting up key-based SSH login",
+		steps: []string{
+			`Create a key without a passphrase: ssh-keygen -t ed25519 -f ~/.ssh/cloud-sync -N ""`,
+			"Install it on the server: ssh-copy-id -i ~/.ssh/cloud-sync.pub USER@HOST",
+			"Trust the server: ssh-keyscan HOST >> ~/.ssh/known_hosts, after checking its fingerprint.",
+			"Enter ~/.ssh/cloud-sync as the key file.",
+		},
+		needs: []string{
+			"The SFTP subsystem enabled on the server",
+			"Write access to the folder you back up to",
+		},
+		least: []string{
+			"Log in as a user made for backups, not your own account.",
+			"Confine it with ChrootDirectory and ForceCommand internal-sftp in sshd_config.",
+			`Prefix the key in authorized_keys with "restrict" to turn off forwarding and ttys.`,
+		},
+		link: "https://rclone.org/sftp/",
+	},
+	"Nextcloud": {
+		title: "Creating a Nextcloud app password",
+		steps: []string{
+			"Open Settings → Security → Devices & sessions.",
+			"Enter an app name, e.g. cloud-sync, and click Create new app password.",
+			"Copy the login name and password it shows into User and Password.",
+			"Copy the WebDAV URL from Files → Files settings, at the bottom left.",
+		},
+		needs: []string{
+			"Read and write access to the folder you back up to",
+		},
+		least: []string{
+			"Use an app password, never your login password; it can be revoked alone.",
+			"Consider a user made for backups, with one shared folder.",
+		},
+		link: "https://docs.nextcloud.com/server/latest/user_manual/en/files/access_webdav.html",
+	},
+	"ownCloud": {
+		title: "Creating an ownCloud app password",
+		steps: []string{
+			"Open Settings → Security → App passwords.",
+			"Enter an app name, e.g. cloud-sync, and click Create new app passcode.",
+			"Copy the user name and passcode it shows into User and Password.",
+			"Copy the WebDAV URL from the Files app's settings, at the bottom left.",
+		},
+		needs: []string{
+			"Read and write access to the folder you back up to",
+		},
+		least: []string{
+			"Use an app passcode, never your login password; it can be revoked alone.",
+			"Consider a user made for backups, with one shared folder.",
+		},
+		link: "https://doc.owncloud.com/server/next/user_manual/files/access_webdav.html",
+	},
+	"WebDAV": {
+		title: "Logging in to a WebDAV server",
+		steps: []string{
+			"Find the server's WebDAV address in its documentation or web interface.",
+			"Create an app or device password if the server offers them.",
+			"Enter the address, your user name and the password.",
+		},
+		needs: []string{
+			"Read and write access to the folder you back up to",
+		},
+		least: []string{
+			"Prefer an app password that can be revoked without changing your login.",
+			"Use https:// addresses; plain http sends the password in the clear.",
+		},
+		link: "https://rclone.org/webdav/",
+	},
+	"Google Cloud Storage": {
+		title: "Creating a Google Cloud service account key",
+		steps: []string{
+			"Open IAM & Admin → Service Accounts and click Create service account.",
+			"Skip the project-wide roles and finish creating it.",
+			"Open the bucket's Permissions tab, click Grant access and add the account's email.",
+			"Give it the Storage Object Admin role on that bucket.",
+			"Back on the account, open Keys → Add key → Create new key → JSON and save the file.",
+		},
+		needs: []string{
+			"storage.objects.list, get, create and delete on the bucket (Storage Object Admin)",
+			"storage.buckets.list on the project, only to pick the bucket from a list",
+		},
+		least: []string{
+			"Grant the role on the bucket, not on the project.",
+			"Keep the key file readable only by you: chmod 600.",
+			"Delete keys you no longer use; organizations may forbid key creation.",
+		},
+		link: "https://cloud.google.com/iam/docs/keys-create-delete",
+	},
+	"Microsoft Azure Blob Storage": {
+		title: "Getting Azure Blob Storage credentials",
+		steps: []string{
+			"Account key: open the storage account's Security + networking → Access keys, show key1 and copy it.",
+			"SAS URL: open the container, then Shared access tokens.",
+			"Pick Read, Add, Create, Write, Delete and List, set an expiry and click Generate.",
+			"Copy the Blob SAS URL into the form.",
+		},
+		needs: []string{
+			"Read, write, delete and list on the container",
+		},
+		least: []string{
+			"An account key opens every container of the account; prefer a container SAS URL.",
+			"Give the SAS URL an expiry and note when to renew it.",
+			"Rotate the account key you use after sharing it anywhere.",
+		},
+		link: "https://learn.microsoft.com/en-us/azure/storage/common/storage-sas-overview",
+	},
+	"Network Share": {
+		title: "Mounting a NAS share on this Mac",
+		steps: []string{
+			"In Finder, choose Go → Connect to Server (⌘K).",
+			"Enter the share's address, e.g. smb://nas.local/Backups, and click Connect.",
+			"Log in, and save the password in your keychain so the share mounts without asking.",
+			"The share appears under /Volumes, e.g. /Volumes/Backups; enter that folder in the form.",
+			"To mount it at login, add it to System Settings → General → Login Items.",
+		},
+		needs: []string{
+			"Read and write access to the folder you back up to",
+		},
+		least: []string{
+			"Log in to the NAS with an account made for backups, with one shared folder.",
+			"Turn on the NAS's snapshots, so a sync of deleted files can be undone.",
+		},
+		link: "https://rclone.org/alias/",
+	},
+	"Union": {
+		title: "Combining remotes into a union",
+		steps: []string{
+			"Add each remote the union uses first, e.g. a B2 and a Google Drive remote.",
+			"List a folder on each as an upstream, e.g. b2:backups, gdrive:backups.",
+			"Pick a policy: ff fills the first upstream listed, mfs the one with the most free space.",
+			"Buckets on B2 and S3 do not report free space; with them, pick ff or rand.",
+			"Sync pairs on the union see the files of every upstream as one folder.",
+		},
+		needs: []string{
+			"Read and write access on each upstream, read only on those ending in :ro",
+		},
+		least: []string{
+			"The union logs in with the keys of its upstreams; limit each key to its bucket.",
+			"A union spreads files over its upstreams; it does not keep a second copy of them.",
+		},
+		link: "https://rclone.org/union/",
+	},
+}
+
+// guideProvider returns the name of the guide for the form being filled
+// in, or "" on steps without a form
+func (m RemoteConfigModel) guideProvider() string {
+	switch m.currentStep {
+	case RemoteStepB2Config:
+		return "Backblaze B2"
+	case RemoteStepScalewayConfig:
+		switch m.providerName {
+		case "", "Scaleway Object Storage":
+			return "Scaleway"
+		case "Amazon S3", "DigitalOcean Spaces", "Wasabi":
+			return m.providerName
+		default:
+			return "S3"
+		}
+	case RemoteStepMinioConfig:
+		return "MinIO"
+	case RemoteStepSftpConfig:
+		return "SFTP"
+	case RemoteStepWebdavConfig:
+		switch strings.ToLower(strings.TrimSpace(m.inputs[1].Value())) {
+		case "nextcloud":
+			return "Nextcloud"
+		case "owncloud":
+			return "ownCloud"
+		default:
+			return "WebDAV"
+		}
+	case RemoteStepGcsConfig:
+		return "Google Cloud Storage"
+	case RemoteStepAzureConfig:
+		return "Microsoft Azure Blob Storage"
+	case RemoteStepLocalConfig:
+		return "Network Share"
+	case RemoteStepUnionConfig:
+		return "Union"
+	}
+	return ""
+}
+
+// toggleGuide opens the setup guide of the form, or closes it
+func (m *RemoteConfigModel) toggleGuide() {
+	if m.guideOpen {
+		m.guideOpen = false
+		return
+	}
+	if m.guideProvider() == "" {
+		return
+	}
+	m.guideOpen = true
+	m.guide = viewport.New(m.guideWidth(), guideHeight)
+	m.refreshGuide()
+}
+
+// refreshGuide fills the open guide, which follows the WebDAV vendor
+// typed in
+func (m *RemoteConfigModel) refreshGuide() {
+	if !m.guideOpen {
+		return
+	}
+	guide := setupGuides[m.guideProvider()]
+	m.guide.SetContent(lipgloss.NewStyle().Width(m.guide.Width).Render(guide.render()))
+}
+
+// guideWidth returns the width of the guide's text for the window size
+func (m RemoteConfigModel) guideWidth() int {
+	if m.width > 0 && m.width < 84 {
+		return m.width - 8
+	}
+	return 76
+}
+
+// render returns the text of the guide
+func (g setupGuide) render() string {
+	var b strings.Builder
+	b.WriteString(styles.HighlightStyle.Render(g.title))
+	b.WriteString("\n\nSteps\n")
+	for i, step := range g.steps {
+		b.WriteString(fmt.Sprintf("  %d. %s\n", i+1, step))
+	}
+	b.WriteString("\nPermissions needed\n")
+	for _, need := range g.needs {
+		b.WriteString("  • " + need + "\n")
+	}
+	b.WriteString("\nLeast privilege\n")
+	for _, tip := range g.least {
+		b.WriteString("  • " + tip + "\n")
+	}
+	b.WriteString("\nMore: " + g.link)
+	return b.String()
+}
+
+// renderGuide renders the open setup guide in a scrollable panel, or a
+// line that says how to open it
+func (m RemoteConfigModel) renderGuide() string {
+	if !m.guideOpen {
+		return styles.RenderMuted(fmt.Sprintf("Press %s for a step-by-step guide to creating the credentials.", guideKey))
+	}
+	return styles.ViewportStyle.Padding(0, 1).Render(m.guide.View())
+}
+
+// guideHelp returns the footer's keys for the setup guide
+func (m RemoteConfigModel) guideHelp() string {
+	if m.guideOpen {
+		return "pgup/pgdn: Scroll guide • " + guideKey + ": Close guide"
+	}
+	return guideKey + ": Setup guide"
+}
@@ -0,0 +1,209 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// remoteLabels are the stored remotes by name, to show remotes under their
+// labels. Remotes set up with 'rclone config' are not among them and are
+// shown by name.
+type remoteLabels map[string]config.RemoteConfig
+
+// newRemoteLabels indexes remotes by name
+func newRemoteLabels(remotes []config.RemoteConfig) remoteLabels {
+	labels := make(remoteLabels, len(remotes))
+	for _, remote := range remotes {
+		labels[remote.Name] = remote
+	}
+	return labels
+}
+
+// loadRemoteLabels reads the labels of the stored remotes; none when the
+// configuration cannot be read
+func loadRemoteLabels() remoteLabels {
+	appConfig := loadAppConfig()
+	if appConfig == nil {
+		return nil
+	}
+	return newRemoteLabels(appConfig.Remotes)
+}
+
+// render renders a remote under its label in its color, or its name when
+// it has no label
+func (l remoteLabels) render(name string) string {
+	remote, ok := l[name]
+	if !ok || !remote.HasLabel() {
+		return name
+	}
+	return styles.RenderLabel(remote.DisplayName(), remote.Color)
+}
+
+// renderPath renders remote:path with the remote under its label, and the
+// path as rclone names it after it, e.g. "📷 Photos (b2:photos/2026)"
+func (l remoteLabels) renderPath(name, path string) string {
+	remote, ok := l[name]
+	if !ok || !remote.HasLabel() {
+		return name + ":" + path
+	}
+	if remote.DisplayName() == name {
+		return l.render(name) + ":" + path
+	}
+	return l.render(name) + " " + styles.RenderMuted("("+name+":"+path+")")
+}
+
+// RemoteLabelModel sets the label, icon and color a remote is shown under
+type RemoteLabelModel struct {
+	configManager *config.Manager
+	remote        config.RemoteConfig
+	inputs        []textinput.Model // Label, icon and color
+	focusIndex    int
+	validation    FormValidator
+	err           error
+	width         int
+	height        int
+}
+
+// NewRemoteLabelModel creates the label form of a stored remote
+func NewRemoteLabelModel(configManager *config.Manager, remote config.RemoteConfig) RemoteLabelModel {
+	inputs := make([]textinput.Model, 3)
+	for i, field := range []struct {
+		prompt, placeholder, value string
+		limit                      int
+	}{
+		{"Label: ", "Photos archive", remote.Label, 40},
+		{"Icon: ", "📷", remote.Icon, 8},
+		{"Color: ", strings.Join(config.LabelColors, ", "), remote.Color, 7},
+	} {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = field.prompt
+		inputs[i].Placeholder = field.placeholder
+		inputs[i].CharLimit = field.limit
+		inputs[i].Width = 50
+		inputs[i].SetValue(field.value)
+	}
+	inputs[0].Focus()
+	inputs[0].PromptStyle = styles.FocusedStyle
+	inputs[0].TextStyle = styles.FocusedStyle
+
+	return RemoteLabelModel{
+		configManager: configManager,
+		remote:        remote,
+		inputs:        inputs,
+		validation:    NewFormValidator(nil, nil, Optional(LabelColor)),
+	}
+}
+
+// Init implements tea.Model
+func (m RemoteLabelModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update implements tea.Model
+func (m RemoteLabelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			return m, BackCmd()
+		case "tab", "shift+tab", "up", "down":
+			m.validation.Recheck(m.focusIndex, m.inputs[m.focusIndex].Value())
+			if s := msg.String(); s == "up" || s == "shift+tab" {
+				m.focusIndex = (m.focusIndex + len(m.inputs) - 1) % len(m.inputs)
+			} else {
+				m.focusIndex = (m.focusIndex + 1) % len(m.inputs)
+			}
+			cmds := make([]tea.Cmd, len(m.inputs))
+			for i := range m.inputs {
+				if i == m.focusIndex {
+					cmds[i] = m.inputs[i].Focus()
+					m.inputs[i].PromptStyle = styles.FocusedStyle
+					m.inputs[i].TextStyle = styles.FocusedStyle
+				} else {
+					m.inputs[i].Blur()
+					m.inputs[i].PromptStyle = styles.NoStyle
+					m.inputs[i].TextStyle = styles.NoStyle
+				}
+			}
+			return m, tea.Batch(cmds...)
+		case "enter":
+			return m.save()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
+	return m, cmd
+}
+
+// labelled returns the remote with the label, icon and color typed in
+func (m RemoteLabelModel) labelled() config.RemoteConfig {
+	remote := m.remote
+	remote.Label = strings.TrimSpace(m.inputs[0].Value())
+	remote.Icon = strings.TrimSpace(m.inputs[1].Value())
+	remote.Color = strings.ToLower(strings.TrimSpace(m.inputs[2].Value()))
+	return remote
+}
+
+// save stores the label and closes the form
+func (m RemoteLabelModel) save() (tea.Model, tea.Cmd) {
+	if !m.validation.Validate(m.inputs) {
+		return m, nil
+	}
+	remote := m.labelled()
+	if err := m.configManager.SetRemoteLabel(remote.Name, remote.Label, remote.Icon, remote.Color); err != nil {
+		m.err = err
+		return m, nil
+	}
+	if !remote.HasLabel() {
+		return m, DoneCmd(fmt.Sprintf("Remote '%s' is shown by its name", remote.Name))
+	}
+	return m, DoneCmd(fmt.Sprintf("Remote '%s' is shown as %s", remote.Name, remote.DisplayName()))
+}
+
+// View implements tea.Model
+func (m RemoteLabelModel) View() string {
+	helper := NewViewHelper(m.width, m.height)
+	var b strings.Builder
+
+	b.WriteString(helper.RenderHeader("Remote Label", fmt.Sprintf("How '%s' is shown in lists and on the dashboard", m.remote.Name)))
+
+	if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	}
+
+	for i, input := range m.inputs {
+		b.WriteString(m.validation.RenderField(i, input))
+		b.WriteString("\n\n")
+	}
+
+	remote := m.labelled()
+	if config.ValidateLabelColor(remote.Color) != nil {
+		remote.Color = ""
+	}
+	b.WriteString("Preview: ")
+	b.WriteString(newRemoteLabels([]config.RemoteConfig{remote}).renderPath(remote.Name, "path"))
+	b.WriteString("\n\n")
+	b.WriteString(styles.RenderMuted("The label only changes what cloud-sync shows; rclone, scripts and the"))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted(fmt.Sprintf("command line keep naming the remote '%s'. Leave all three empty to show the name.", m.remote.Name)))
+	b.WriteString("\n")
+
+	b.WriteString(helper.RenderFooter("Tab/↑/↓: Next field • Enter: Save • esc: Cancel"))
+
+	return b.String()
+}
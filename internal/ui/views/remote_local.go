@@ -0,0 +1,123 @@
+package views
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+	"github.com/andreisuslov/cloud-sync/internal/volume"
+)
+
+// localFolderField is the index of the folder in the local form
+const localFolderField = 1
+
+// initLocalInputs initializes input fields for a local folder or network
+// share. A folder makes the remote an alias of it; without one it is a
+// local remote whose pairs name full paths.
+func (m *RemoteConfigModel) initLocalInputs() tea.Cmd {
+	inputs := make([]textinput.Model, 2)
+
+	// Remote name
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "nas"
+	inputs[0].Focus()
+	inputs[0].PromptStyle = styles.FocusedStyle
+	inputs[0].TextStyle = styles.FocusedStyle
+	inputs[0].CharLimit = 32
+	inputs[0].Width = 50
+	inputs[0].Prompt = "Remote Name: "
+
+	// Folder
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "/Volumes/Backups, or empty for any folder"
+	inputs[1].CharLimit = 255
+	inputs[1].Width = 50
+	inputs[1].Prompt = "Folder: "
+	inputs[1].ShowSuggestions = true
+
+	m.inputs = inputs
+	m.focusIndex = 0
+	m.validation = NewFormValidator(RemoteName, Optional(AliasTarget))
+
+	return inputs[0].Focus()
+}
+
+// setLocalInputs prefills the local form with a stored remote
+func (m *RemoteConfigModel) setLocalInputs(remote config.RemoteConfig) {
+	m.inputs[0].SetValue(remote.Name)
+	m.inputs[1].SetValue(remote.Target)
+}
+
+// localRemote returns the remote the local form describes: an alias of the
+// folder or remote path, or a local remote when it is empty
+func (m RemoteConfigModel) localRemote() config.RemoteConfig {
+	remote := config.RemoteConfig{
+		Name: strings.TrimSpace(m.inputs[0].Value()),
+		Type: config.TypeLocal,
+	}
+	target := strings.TrimSpace(m.inputs[1].Value())
+	if target == "" {
+		return remote
+	}
+	if isFolderPath(target) {
+		// Already validated
+		path, _ := ExpandHome(target)
+		target = filepath.Clean(path)
+	}
+	remote.Type, remote.Target = config.TypeAlias, target
+	return remote
+}
+
+// completeFolder completes the folder of the local form on tab
+func (m *RemoteConfigModel) completeFolder(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if m.currentStep != RemoteStepLocalConfig || m.focusIndex != localFolderField || msg.String() != "tab" {
+		return nil, false
+	}
+	input := &m.inputs[localFolderField]
+	if len(input.AvailableSuggestions()) == 0 || input.CurrentSuggestion() == input.Value() {
+		return nil, false
+	}
+	var cmd tea.Cmd
+	*input, cmd = input.Update(msg)
+	input.SetSuggestions(CompletePath(input.Value()))
+	return cmd, true
+}
+
+// renderLocalConfig renders the local folder form, with a note on the
+// drive or share the folder is on
+func (m RemoteConfigModel) renderLocalConfig() string {
+	var b strings.Builder
+
+	b.WriteString(styles.RenderInfo("Local Folder / Network Share Configuration"))
+	b.WriteString("\n\n")
+
+	for i, input := range m.inputs {
+		b.WriteString(m.validation.RenderField(i, input))
+		if i < len(m.inputs)-1 {
+			b.WriteString("\n\n")
+		}
+	}
+
+	b.WriteString("\n\n")
+	remote := m.localRemote()
+	if v, ok := volume.Of(remote.Target); ok && remote.IsAlias() {
+		if !volume.Mounted(v) {
+			b.WriteString(styles.RenderWarning(fmt.Sprintf("%s is not mounted; connect to it in Finder (⌘K) to test the remote.", v)))
+			b.WriteString("\n")
+		}
+		b.WriteString(styles.RenderMuted(fmt.Sprintf("Syncs to this remote are skipped while %s is not mounted,", v)))
+		b.WriteString("\n")
+		b.WriteString(styles.RenderMuted("so nothing is written to the startup disk in its place."))
+		b.WriteString("\n")
+	}
+	b.WriteString(styles.RenderMuted("Sync pairs name folders below the folder. Leave it empty to name full paths, e.g. /Volumes/NAS/Photos;"))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("enter remote:path to give a path on another remote a name of its own."))
+
+	return b.String()
+}
@@ -0,0 +1,324 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// defaultKnownHosts is where OpenSSH keeps the host keys of the servers
+// the user has connected to
+const defaultKnownHosts = "~/.ssh/known_hosts"
+
+// untestedRemote is the name a remote is defined under while its settings
+// are tested before saving, so the test cannot pick up the options of a
+// remote in rclone.conf
+const untestedRemote = "cloudsynctest"
+
+// connectionTest is the last step of the forms that cannot pick a bucket.
+// SFTP and WebDAV servers, aliases and unions have none, so the remote is checked
+// by listing its top folder instead. A GCS service account or an Azure container's SAS
+// URL may only reach one bucket, which is listed and becomes the default.
+type connectionTest struct {
+	formStep RemoteConfigStep // Step esc returns to, to fix the settings
+	unsaved  bool             // The remote is saved once the test passes
+	bucket   string           // Tested instead of the top folder
+	testing  bool
+	err      error
+}
+
+// remoteConnectionTested carries the result of a connection test
+type remoteConnectionTested struct {
+	err error
+}
+
+// initSftpInputs initializes input fields for SFTP configuration
+func (m *RemoteConfigModel) initSftpInputs() tea.Cmd {
+	inputs := make([]textinput.Model, 7)
+
+	// Remote name
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "nas"
+	inputs[0].Focus()
+	inputs[0].PromptStyle = styles.FocusedStyle
+	inputs[0].TextStyle = styles.FocusedStyle
+	inputs[0].CharLimit = 32
+	inputs[0].Width = 50
+	inputs[0].Prompt = "Remote Name: "
+
+	// Host
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "nas.local or 192.168.1.10"
+	inputs[1].CharLimit = 253
+	inputs[1].Width = 50
+	inputs[1].Prompt = "Host: "
+
+	// Port
+	inputs[2] = textinput.New()
+	inputs[2].Placeholder = "22"
+	inputs[2].CharLimit = 5
+	inputs[2].Width = 50
+	inputs[2].Prompt = "Port: "
+	inputs[2].SetValue("22")
+
+	// User
+	inputs[3] = textinput.New()
+	inputs[3].Placeholder = "Your login on the server"
+	inputs[3].CharLimit = 64
+	inputs[3].Width = 50
+	inputs[3].Prompt = "User: "
+
+	// Password
+	inputs[4] = textinput.New()
+	inputs[4].Placeholder = "optional with a private key"
+	inputs[4].CharLimit = 100
+	inputs[4].Width = 50
+	inputs[4].Prompt = "Password: "
+	inputs[4].EchoMode = textinput.EchoPassword
+	inputs[4].EchoCharacter = '•'
+
+	// Private key
+	inputs[5] = textinput.New()
+	inputs[5].Placeholder = "~/.ssh/id_ed25519"
+	inputs[5].CharLimit = 255
+	inputs[5].Width = 50
+	inputs[5].Prompt = "Private Key File: "
+
+	// Known hosts
+	inputs[6] = textinput.New()
+	inputs[6].Placeholder = "empty accepts any host key"
+	inputs[6].CharLimit = 255
+	inputs[6].Width = 50
+	inputs[6].Prompt = "Known Hosts File: "
+	inputs[6].SetValue(defaultKnownHosts)
+
+	m.inputs = inputs
+	m.focusIndex = 0
+	m.validation = NewFormValidator(RemoteName, Required, IntRange(1, 65535), Required, nil,
+		Optional(FileExists), Optional(FileExists))
+
+	return inputs[0].Focus()
+}
+
+// setSftpInputs prefills the SFTP form with a stored remote
+func (m *RemoteConfigModel) setSftpInputs(remote config.RemoteConfig) {
+	port := remote.Port
+	if port == 0 {
+		port = 22
+	}
+	m.inputs[0].SetValue(remote.Name)
+	m.inputs[1].SetValue(remote.Host)
+	m.inputs[2].SetValue(strconv.Itoa(port))
+	m.inputs[3].SetValue(remote.User)
+	m.inputs[4].SetValue(remote.Password)
+	m.inputs[5].SetValue(remote.KeyFile)
+	m.inputs[6].SetValue(remote.KnownHostsFile)
+}
+
+// sftpRemote returns the remote the SFTP form describes. The port is left
+// unset when it is the default.
+func (m RemoteConfigModel) sftpRemote() config.RemoteConfig {
+	remote := config.RemoteConfig{
+		Name:           strings.TrimSpace(m.inputs[0].Value()),
+		Type:           "sftp",
+		Host:           strings.TrimSpace(m.inputs[1].Value()),
+		User:           strings.TrimSpace(m.inputs[3].Value()),
+		Password:       strings.TrimSpace(m.inputs[4].Value()),
+		KeyFile:        strings.TrimSpace(m.inputs[5].Value()),
+		KnownHostsFile: strings.TrimSpace(m.inputs[6].Value()),
+	}
+	if port, _ := strconv.Atoi(strings.TrimSpace(m.inputs[2].Value())); port != 22 {
+		remote.Port = port
+	}
+	return remote
+}
+
+// renderSftpConfig renders the SFTP configuration form
+func (m RemoteConfigModel) renderSftpConfig() string {
+	var b strings.Builder
+
+	b.WriteString(styles.RenderInfo("SFTP Server Configuration"))
+	b.WriteString("\n\n")
+
+	for i, input := range m.inputs {
+		b.WriteString(m.validation.RenderField(i, input))
+		if i < len(m.inputs)-1 {
+			b.WriteString("\n\n")
+		}
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(styles.RenderMuted("Log in with a password, a private key, or both. Keys protected by a passphrase are not supported;"))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("an empty known hosts file accepts any server, so only clear it on a network you trust."))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted(secretRefHint))
+
+	return b.String()
+}
+
+// testConnection moves on to the connection test. An unsaved remote is
+// tested first and saved once it connects.
+func (m RemoteConfigModel) testConnection(test connectionTest) (tea.Model, tea.Cmd) {
+	test.formStep, test.testing = m.currentStep, true
+	m.tester = test
+	m.currentStep = RemoteStepTestConnection
+	return m, m.runConnectionTest()
+}
+
+// runConnectionTest lists the remote's top folder. An unsaved remote is
+// defined for rclone through its environment.
+func (m RemoteConfigModel) runConnectionTest() tea.Cmd {
+	remote := m.remoteConfig
+	unsaved, bucket := m.tester.unsaved, m.tester.bucket
+	return func() tea.Msg {
+		if !unsaved {
+			mgr, err := m.rcloneManager()
+			if err != nil {
+				return remoteConnectionTested{err: err}
+			}
+			if bucket != "" {
+				return remoteConnectionTested{err: mgr.TestBucket(remote.Name, bucket)}
+			}
+			return remoteConnectionTested{err: mgr.TestRemote(remote.Name)}
+		}
+
+		env, err := remote.Env(untestedRemote)
+		if err != nil {
+			return remoteConnectionTested{err: err}
+		}
+		mgr, err := m.rcloneManager(env...)
+		if err != nil {
+			return remoteConnectionTested{err: err}
+		}
+		return remoteConnectionTested{err: mgr.TestRemote(untestedRemote)}
+	}
+}
+
+// saveTested saves a remote that was tested before saving and finishes
+func (m RemoteConfigModel) saveTested() (tea.Model, tea.Cmd) {
+	if err := m.save(); err != nil {
+		m.err = err
+		return m, nil
+	}
+	return m.finish()
+}
+
+// connectionTarget returns the server the connection test connects to
+func (m RemoteConfigModel) connectionTarget() string {
+	if m.tester.bucket != "" {
+		return fmt.Sprintf("bucket '%s'", m.tester.bucket)
+	}
+	if m.remoteConfig.IsWebDAV() {
+		return m.remoteConfig.URL
+	}
+	if m.remoteConfig.IsAlias() {
+		return m.remoteConfig.Target
+	}
+	if m.remoteConfig.IsUnion() {
+		return strings.Join(m.remoteConfig.Upstreams, " + ")
+	}
+	return m.remoteConfig.User + "@" + m.remoteConfig.Host
+}
+
+// updateConnectionStep handles the messages of the connection test step
+func (m RemoteConfigModel) updateConnectionStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case remoteConnectionTested:
+		m.tester.testing = false
+		m.tester.err = msg.err
+		if msg.err != nil {
+			return m, nil
+		}
+		if m.tester.unsaved {
+			return m.saveTested()
+		}
+		if m.tester.bucket != "" {
+			return m.setDefaultBucket(m.tester.bucket)
+		}
+		return m.finish()
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.tester.testing {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "esc":
+			m.currentStep = m.tester.formStep
+			m.err = nil
+			return m, nil
+		case "r":
+			m.tester.testing = true
+			m.tester.err = nil
+			m.err = nil
+			return m, m.runConnectionTest()
+		case "s":
+			if m.tester.unsaved {
+				return m.saveTested()
+			}
+			return m.finish()
+		}
+	}
+	return m, nil
+}
+
+// renderTestConnection renders the connection test step
+func (m RemoteConfigModel) renderTestConnection() string {
+	var b strings.Builder
+	b.WriteString(styles.RenderSubtitle("Connection Test"))
+	b.WriteString("\n\n")
+
+	server := m.connectionTarget()
+	if m.tester.testing {
+		b.WriteString(fmt.Sprintf("Connecting to %s...\n", server))
+		return b.String()
+	}
+	if m.tester.err == nil {
+		// Connected, but saving failed; the error is shown below
+		b.WriteString(fmt.Sprintf("Connected to %s.\n", server))
+		return b.String()
+	}
+
+	b.WriteString(styles.RenderError(fmt.Sprintf("Could not connect to %s: %v", server, m.tester.err)))
+	b.WriteString("\n\n")
+	if errors.Is(m.tester.err, errkind.ErrHostKey) {
+		port := m.remoteConfig.Port
+		if port == 0 {
+			port = 22
+		}
+		b.WriteString(styles.RenderMuted(fmt.Sprintf("The server's host key is not in %s. After checking its fingerprint, add it with:", m.remoteConfig.KnownHostsFile)))
+		b.WriteString("\n")
+		b.WriteString(styles.RenderMuted(fmt.Sprintf("  ssh-keyscan -p %d %s >> %s", port, m.remoteConfig.Host, m.remoteConfig.KnownHostsFile)))
+		b.WriteString("\n")
+	}
+	if m.tester.unsaved {
+		b.WriteString(styles.RenderMuted("Nothing was saved yet. Press esc to fix the settings, or s to save them anyway."))
+	} else {
+		b.WriteString(styles.RenderMuted("The remote was saved, but its settings may be wrong. Press esc to fix them."))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// connectionStepHelp returns the footer of the connection test step
+func (m RemoteConfigModel) connectionStepHelp() string {
+	if m.tester.testing {
+		return "Please wait..."
+	}
+	if m.tester.unsaved {
+		return "r: Retry • s: Save anyway • esc: Edit settings"
+	}
+	return "r: Retry • s: Skip • esc: Edit settings"
+}
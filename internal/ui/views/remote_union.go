@@ -0,0 +1,113 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// initUnionInputs initializes input fields for a union of remotes
+func (m *RemoteConfigModel) initUnionInputs() tea.Cmd {
+	inputs := make([]textinput.Model, 3)
+
+	// Remote name
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "free"
+	inputs[0].Focus()
+	inputs[0].PromptStyle = styles.FocusedStyle
+	inputs[0].TextStyle = styles.FocusedStyle
+	inputs[0].CharLimit = 32
+	inputs[0].Width = 50
+	inputs[0].Prompt = "Remote Name: "
+
+	// Upstreams
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "b2:backups, gdrive:backups"
+	inputs[1].CharLimit = 500
+	inputs[1].Width = 50
+	inputs[1].Prompt = "Upstreams: "
+
+	// Create policy
+	inputs[2] = textinput.New()
+	inputs[2].Placeholder = "epmfs"
+	inputs[2].CharLimit = 10
+	inputs[2].Width = 50
+	inputs[2].Prompt = "Policy: "
+
+	m.inputs = inputs
+	m.focusIndex = 0
+	m.validation = NewFormValidator(RemoteName, All(Required, Upstreams), Optional(OneOf(config.UnionPolicies...)))
+
+	return inputs[0].Focus()
+}
+
+// parseUpstreams splits a comma-separated list of upstreams, expanding a
+// leading ~ of folders
+func parseUpstreams(value string) ([]string, error) {
+	var upstreams []string
+	for _, upstream := range strings.Split(value, ",") {
+		upstream = strings.TrimSpace(upstream)
+		if upstream == "" {
+			continue
+		}
+		path, err := ExpandHome(upstream)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand home directory: %w", err)
+		}
+		if err := config.ValidateUpstream(path); err != nil {
+			return nil, err
+		}
+		upstreams = append(upstreams, path)
+	}
+	return upstreams, nil
+}
+
+// setUnionInputs prefills the union form with a stored remote
+func (m *RemoteConfigModel) setUnionInputs(remote config.RemoteConfig) {
+	m.inputs[0].SetValue(remote.Name)
+	m.inputs[1].SetValue(strings.Join(remote.Upstreams, ", "))
+	m.inputs[2].SetValue(remote.Policy)
+}
+
+// unionRemote returns the remote the union form describes
+func (m RemoteConfigModel) unionRemote() config.RemoteConfig {
+	// Already validated
+	upstreams, _ := parseUpstreams(m.inputs[1].Value())
+	return config.RemoteConfig{
+		Name:      strings.TrimSpace(m.inputs[0].Value()),
+		Type:      config.TypeUnion,
+		Upstreams: upstreams,
+		Policy:    strings.ToLower(strings.TrimSpace(m.inputs[2].Value())),
+	}
+}
+
+// renderUnionConfig renders the union configuration form
+func (m RemoteConfigModel) renderUnionConfig() string {
+	var b strings.Builder
+
+	b.WriteString(styles.RenderInfo("Union of Remotes Configuration"))
+	b.WriteString("\n\n")
+
+	for i, input := range m.inputs {
+		b.WriteString(m.validation.RenderField(i, input))
+		if i < len(m.inputs)-1 {
+			b.WriteString("\n\n")
+		}
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(styles.RenderMuted("Upstreams are remote:path or folders; end one in :ro to only read from it, or :nc to never create files on it."))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("The policy picks the upstream of new files: ff the first listed, rand any of them, and mfs the one"))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("with the most free space. Policies by free space, epmfs (the default) among them, need upstreams that"))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("report it, such as Google Drive; B2 and S3 buckets do not, so use ff or rand with them."))
+
+	return b.String()
+}
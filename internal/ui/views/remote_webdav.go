@@ -0,0 +1,143 @@
+package views
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// webdavPresets map the WebDAV providers onto the vendor the form starts
+// with
+var webdavPresets = map[string]string{
+	"Nextcloud": "nextcloud",
+	"ownCloud":  "owncloud",
+	"WebDAV":    "other",
+}
+
+// webdavURLHint returns an example address for a WebDAV vendor
+func webdavURLHint(vendor string) string {
+	switch vendor {
+	case "nextcloud", "owncloud":
+		return "https://cloud.example.com/remote.php/dav/files/USERNAME/"
+	case "sharepoint", "sharepoint-ntlm":
+		return "https://example.sharepoint.com/sites/team/Shared%20Documents"
+	default:
+		return "https://dav.example.com/backups/"
+	}
+}
+
+// initWebdavInputs initializes input fields for WebDAV configuration,
+// starting with vendor
+func (m *RemoteConfigModel) initWebdavInputs(vendor string) tea.Cmd {
+	inputs := make([]textinput.Model, 5)
+
+	// Remote name
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "cloud"
+	inputs[0].Focus()
+	inputs[0].PromptStyle = styles.FocusedStyle
+	inputs[0].TextStyle = styles.FocusedStyle
+	inputs[0].CharLimit = 32
+	inputs[0].Width = 50
+	inputs[0].Prompt = "Remote Name: "
+
+	// Vendor
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = strings.Join(config.WebDAVVendors, ", ")
+	inputs[1].CharLimit = 20
+	inputs[1].Width = 50
+	inputs[1].Prompt = "Vendor: "
+	inputs[1].SetValue(vendor)
+
+	// URL
+	inputs[2] = textinput.New()
+	inputs[2].Placeholder = webdavURLHint(vendor)
+	inputs[2].CharLimit = 255
+	inputs[2].Width = 50
+	inputs[2].Prompt = "URL: "
+
+	// User
+	inputs[3] = textinput.New()
+	inputs[3].Placeholder = "Your login on the server"
+	inputs[3].CharLimit = 100
+	inputs[3].Width = 50
+	inputs[3].Prompt = "User: "
+
+	// Password
+	inputs[4] = textinput.New()
+	inputs[4].Placeholder = "An app password, if the server has them"
+	inputs[4].CharLimit = 100
+	inputs[4].Width = 50
+	inputs[4].Prompt = "Password: "
+	inputs[4].EchoMode = textinput.EchoPassword
+	inputs[4].EchoCharacter = '•'
+
+	m.inputs = inputs
+	m.focusIndex = 0
+	m.validation = NewFormValidator(RemoteName, OneOf(config.WebDAVVendors...), All(Required, WebDAVURL), nil, nil)
+
+	return inputs[0].Focus()
+}
+
+// setWebdavInputs prefills the WebDAV form with a stored remote
+func (m *RemoteConfigModel) setWebdavInputs(remote config.RemoteConfig) {
+	vendor := remote.Vendor
+	if vendor == "" {
+		vendor = "other"
+	}
+	m.inputs[0].SetValue(remote.Name)
+	m.inputs[1].SetValue(vendor)
+	m.inputs[2].SetValue(remote.URL)
+	m.inputs[3].SetValue(remote.User)
+	m.inputs[4].SetValue(remote.Password)
+}
+
+// webdavRemote returns the remote the WebDAV form describes
+func (m RemoteConfigModel) webdavRemote() config.RemoteConfig {
+	return config.RemoteConfig{
+		Name:     strings.TrimSpace(m.inputs[0].Value()),
+		Type:     "webdav",
+		Vendor:   strings.ToLower(strings.TrimSpace(m.inputs[1].Value())),
+		URL:      strings.TrimSpace(m.inputs[2].Value()),
+		User:     strings.TrimSpace(m.inputs[3].Value()),
+		Password: strings.TrimSpace(m.inputs[4].Value()),
+	}
+}
+
+// renderWebdavConfig renders the WebDAV configuration form, with hints for
+// the vendor typed in
+func (m RemoteConfigModel) renderWebdavConfig() string {
+	var b strings.Builder
+
+	b.WriteString(styles.RenderInfo("Nextcloud / ownCloud / WebDAV Configuration"))
+	b.WriteString("\n\n")
+
+	for i, input := range m.inputs {
+		b.WriteString(m.validation.RenderField(i, input))
+		if i < len(m.inputs)-1 {
+			b.WriteString("\n\n")
+		}
+	}
+
+	vendor := strings.ToLower(strings.TrimSpace(m.inputs[1].Value()))
+	b.WriteString("\n\n")
+	b.WriteString(styles.RenderMuted("URL like: " + webdavURLHint(vendor)))
+	b.WriteString("\n")
+	switch vendor {
+	case "nextcloud":
+		b.WriteString(styles.RenderMuted("Create an app password under Settings → Security → Devices & sessions; it is required with two-factor login."))
+		b.WriteString("\n")
+	case "owncloud":
+		b.WriteString(styles.RenderMuted("Create an app password under Settings → Security → App passwords; it is required with two-factor login."))
+		b.WriteString("\n")
+	}
+	b.WriteString(styles.RenderMuted("The connection is tested before anything is saved."))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted(secretRefHint))
+
+	return b.String()
+}
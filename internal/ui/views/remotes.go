@@ -0,0 +1,291 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// RemotesModel lists the remotes stored in the configuration and opens them
+// for editing
+type RemotesModel struct {
+	configManager *config.Manager
+	remotes       []config.RemoteConfig
+	usedBy        map[string][]string          // Sync pairs using each remote
+	benchmarks    map[string]logs.BenchmarkRun // Latest benchmark of each remote
+	spinner       spinner.Model
+	loading       bool
+	cursor        int
+	width         int
+	height        int
+	message       string
+	err           error
+
+	benchmarking   string   // Remote being benchmarked, see remote_benchmark.go
+	benchmarkQueue []string // Remotes to benchmark after it
+}
+
+// NewRemotesModel creates a new remotes list. The remotes are read by the
+// command Init returns.
+func NewRemotesModel(configManager *config.Manager) RemotesModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = styles.SpinnerStyle
+
+	return RemotesModel{configManager: configManager, spinner: s, loading: true}
+}
+
+// remotesListLoaded carries the remotes and the sync pairs that use them
+type remotesListLoaded struct {
+	remotes    []config.RemoteConfig
+	usedBy     map[string][]string
+	benchmarks map[string]logs.BenchmarkRun
+	err        error
+}
+
+// loadRemotes reads the remotes and the sync pairs that use them
+func (m RemotesModel) loadRemotes() tea.Cmd {
+	configManager := m.configManager
+	return func() tea.Msg {
+		appConfig, err := configManager.Load()
+		if err != nil {
+			return remotesListLoaded{err: err}
+		}
+
+		usedBy := make(map[string][]string)
+		pairs, err := syncconfig.NewManager(configManager.SyncConfigPath()).ListSyncPairs()
+		if err == nil {
+			for _, pair := range pairs {
+				for _, dest := range pair.AllDestinations() {
+					usedBy[dest.RemoteName] = appendUnique(usedBy[dest.RemoteName], pair.Name)
+				}
+			}
+		}
+		benchmarks, _ := logs.NewManager(appConfig.LogDir).LatestBenchmarks()
+		return remotesListLoaded{remotes: appConfig.Remotes, usedBy: usedBy, benchmarks: benchmarks}
+	}
+}
+
+// refresh reloads the remotes, keeping the current list on screen meanwhile
+func (m RemotesModel) refresh() (RemotesModel, tea.Cmd) {
+	m.loading = true
+	return m, tea.Batch(m.spinner.Tick, m.loadRemotes())
+}
+
+// appendUnique appends s unless list already contains it
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// Init implements tea.Model
+func (m RemotesModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.loadRemotes())
+}
+
+// Update implements tea.Model
+func (m RemotesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case spinner.TickMsg:
+		if !m.loading && m.benchmarking == "" {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case remotesListLoaded:
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.remotes = msg.remotes
+			m.usedBy = msg.usedBy
+			m.benchmarks = msg.benchmarks
+		}
+		if m.cursor >= len(m.remotes) {
+			m.cursor = 0
+		}
+		return m, nil
+
+	case remoteBenchmarked:
+		if m.benchmarks == nil {
+			m.benchmarks = make(map[string]logs.BenchmarkRun)
+		}
+		m.benchmarks[msg.run.Remote] = msg.run
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to record the benchmark: %w", msg.err)
+		}
+		return m.nextBenchmark()
+
+	case ConfigChangedMsg:
+		return m.refresh()
+
+	case DoneMsg:
+		// The edit form saved the remote
+		m.err = nil
+		m.message = msg.Message
+		return m.refresh()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "q", "esc":
+			return m, BackCmd()
+		case "r":
+			m.message = ""
+			return m.refresh()
+		case "b", "B":
+			if err := refuseReadOnly("Benchmarking remotes"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			if m.benchmarking != "" || len(m.remotes) == 0 {
+				return m, nil
+			}
+			if msg.String() == "b" {
+				return m.startBenchmarks(m.remotes[m.cursor].Name)
+			}
+			names := make([]string, 0, len(m.remotes))
+			for _, remote := range m.remotes {
+				names = append(names, remote.Name)
+			}
+			return m.startBenchmarks(names...)
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.remotes)-1 {
+				m.cursor++
+			}
+		case "enter", "e":
+			if err := refuseReadOnly("Editing remotes"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			if len(m.remotes) > 0 {
+				m.message = ""
+				return m, OpenViewCmd(NewEditRemoteModel(m.configManager, m.remotes[m.cursor]))
+			}
+		case "l":
+			if err := refuseReadOnly("Labelling remotes"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			if len(m.remotes) > 0 {
+				m.message = ""
+				return m, OpenViewCmd(NewRemoteLabelModel(m.configManager, m.remotes[m.cursor]))
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// View implements tea.Model
+func (m RemotesModel) View() string {
+	helper := NewViewHelper(m.width, m.height)
+	var b strings.Builder
+
+	b.WriteString(helper.RenderHeader("Remotes", "Edit, rename, label or benchmark the remotes cloud-sync configured"))
+
+	if m.loading {
+		b.WriteString(fmt.Sprintf("%s Loading remotes...\n\n", m.spinner.View()))
+	} else if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	} else if m.message != "" {
+		b.WriteString(styles.RenderSuccess(m.message))
+		b.WriteString("\n\n")
+	}
+
+	if len(m.remotes) == 0 && !m.loading {
+		b.WriteString("No remotes are stored in the configuration.\n")
+		b.WriteString(styles.RenderMuted("Remotes set up with 'rclone config' are edited there."))
+		b.WriteString("\n")
+	}
+
+	fastest := m.fastestRemote()
+	for i, remote := range m.remotes {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = styles.RenderHighlight("> ")
+		}
+		provider := remote.Provider
+		switch {
+		case remote.IsSFTP():
+			provider = remote.User + "@" + remote.Host
+		case remote.IsWebDAV():
+			provider = remote.Vendor
+		case remote.IsAlias():
+			provider = remote.Target
+		case remote.IsUnion():
+			provider = strings.Join(remote.Upstreams, " + ")
+		case remote.IsLocal():
+			provider = "any folder on this Mac"
+		}
+		name := remote.Name
+		if remote.HasLabel() {
+			name = newRemoteLabels([]config.RemoteConfig{remote}).render(remote.Name)
+			if remote.DisplayName() != remote.Name {
+				name += " · " + remote.Name
+			}
+		}
+		b.WriteString(fmt.Sprintf("%s%s (%s, %s)\n", cursor, name, remote.Type, provider))
+
+		used := "not used by any sync pair"
+		if pairs := m.usedBy[remote.Name]; len(pairs) > 0 {
+			used = "used by " + strings.Join(pairs, ", ")
+		}
+		b.WriteString(styles.RenderMuted("    " + used))
+		b.WriteString("\n")
+		if remote.Tuning != nil && !remote.Tuning.IsZero() {
+			b.WriteString(styles.RenderMuted("    uploads with " + remote.Tuning.String()))
+			b.WriteString("\n")
+		}
+		b.WriteString(m.renderBenchmark(remote.Name, fastest))
+	}
+
+	b.WriteString(helper.RenderFooter(keyFooter(remoteKeys)))
+
+	return b.String()
+}
+
+// renderBenchmark renders the line of a remote's latest benchmark, marking
+// the fastest remote
+func (m RemotesModel) renderBenchmark(name, fastest string) string {
+	if name == m.benchmarking {
+		return fmt.Sprintf("    %s Benchmarking with a %d MB test object...\n", m.spinner.View(), rclone.BenchmarkSize>>20)
+	}
+	run, ok := m.benchmarks[name]
+	if !ok {
+		return ""
+	}
+	line := fmt.Sprintf("    %s (%s)", run.Summary(), run.Time.Format("2006-01-02 15:04"))
+	switch {
+	case run.Error != "":
+		return styles.RenderWarning(line) + "\n"
+	case name == fastest:
+		return styles.RenderSuccess(line+" • fastest") + "\n"
+	default:
+		return styles.RenderMuted(line) + "\n"
+	}
+}
@@ -0,0 +1,216 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// restorePlan is a restore waiting for its download settings: what it
+// downloads, how fast the remote was measured, and the settings picked for
+// it
+type restorePlan struct {
+	entry     rclone.TrashEntry
+	files     int
+	large     int // Files above rclone.MultiThreadCutoff
+	bytes     int64
+	bandwidth float64   // Measured download rate in bytes per second, 0 if unknown
+	measured  time.Time // When the bandwidth was measured
+	auto      rclone.RestoreTuning
+	saved     *rclone.RestoreTuning // The remote's profile, nil if it has none
+	inputs    []textinput.Model     // Transfers and multi-thread streams
+	focus     int
+}
+
+// restorePlanned carries the plan of a restore, once its files were listed
+type restorePlanned struct {
+	plan *restorePlan
+	err  error
+}
+
+// planRestore lists the files of a trash folder and picks the settings to
+// download them with: the remote's saved profile, or ones tuned to the file
+// sizes and the bandwidth its latest benchmark measured
+func (m TrashModel) planRestore(entry rclone.TrashEntry) tea.Cmd {
+	remote := m.pair.RemoteName
+	return func() tea.Msg {
+		sizes, err := m.rclone.FileSizes(remote + ":" + entry.Path)
+		if err != nil {
+			return restorePlanned{err: err}
+		}
+		plan := &restorePlan{entry: entry, files: len(sizes)}
+		for _, size := range sizes {
+			plan.bytes += size
+			if size >= rclone.MultiThreadCutoff {
+				plan.large++
+			}
+		}
+
+		if appConfig := loadAppConfig(); appConfig != nil {
+			plan.saved = appConfig.RestoreTuning(remote)
+			plan.bandwidth, plan.measured = latestDownloadRate(appConfig, remote)
+		}
+		plan.auto = rclone.TuneRestore(sizes, plan.bandwidth)
+
+		tuning := plan.auto
+		if plan.saved != nil {
+			tuning = *plan.saved
+		}
+		plan.setInputs(tuning)
+		return restorePlanned{plan: plan}
+	}
+}
+
+// loadAppConfig returns the configuration, or nil when it cannot be read
+func loadAppConfig() *config.AppConfig {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return nil
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return nil
+	}
+	return appConfig
+}
+
+// latestDownloadRate returns the download rate, in bytes per second, the
+// latest benchmark of a remote measured and when, or 0 if it has none
+func latestDownloadRate(appConfig *config.AppConfig, remote string) (float64, time.Time) {
+	runs, err := logs.NewManager(appConfig.LogDir).LatestBenchmarks()
+	if err != nil {
+		return 0, time.Time{}
+	}
+	if run, ok := runs[remote]; ok && run.Error == "" {
+		return run.DownloadRate, run.Time
+	}
+	return 0, time.Time{}
+}
+
+// setInputs fills the plan's fields with tuning
+func (p *restorePlan) setInputs(tuning rclone.RestoreTuning) {
+	p.inputs = make([]textinput.Model, 2)
+	for i, field := range []struct {
+		prompt string
+		value  int
+	}{{"Files at once (--transfers): ", tuning.Transfers}, {"Streams per large file (--multi-thread-streams): ", tuning.MultiThreadStreams}} {
+		p.inputs[i] = textinput.New()
+		p.inputs[i].Prompt = field.prompt
+		p.inputs[i].Placeholder = "4"
+		p.inputs[i].CharLimit = 2
+		p.inputs[i].Width = 4
+		if field.value > 0 {
+			p.inputs[i].SetValue(strconv.Itoa(field.value))
+		}
+	}
+	p.focus = 0
+	p.inputs[0].Focus()
+}
+
+// tuning returns the settings typed into the plan's fields
+func (p *restorePlan) tuning() (rclone.RestoreTuning, error) {
+	var values [2]int
+	for i, input := range p.inputs {
+		value := strings.TrimSpace(input.Value())
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return rclone.RestoreTuning{}, fmt.Errorf("'%s' is not a number", value)
+		}
+		values[i] = n
+	}
+	tuning := rclone.RestoreTuning{Transfers: values[0], MultiThreadStreams: values[1]}
+	return tuning, tuning.Validate()
+}
+
+// updateRestorePlan handles the keys while a restore's settings are chosen
+func (m TrashModel) updateRestorePlan(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	plan := m.plan
+	switch msg.String() {
+	case "esc":
+		m.plan = nil
+		m.err = nil
+		m.message = "Restore cancelled"
+		return m, nil
+	case "a":
+		plan.setInputs(plan.auto)
+		return m, nil
+	case "tab", "shift+tab", "up", "down":
+		plan.inputs[plan.focus].Blur()
+		plan.focus = 1 - plan.focus
+		return m, plan.inputs[plan.focus].Focus()
+	case "enter":
+		tuning, err := plan.tuning()
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.err = nil
+		m.plan = nil
+		m.processing = true
+		return m, m.restoreEntry(plan.entry, tuning)
+	}
+
+	var cmd tea.Cmd
+	plan.inputs[plan.focus], cmd = plan.inputs[plan.focus].Update(msg)
+	return m, cmd
+}
+
+// saveRestoreTuning stores the settings a restore used as the remote's
+// profile. Remotes set up with 'rclone config' are not stored, and keep
+// being tuned afresh.
+func saveRestoreTuning(remote string, tuning rclone.RestoreTuning) bool {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return false
+	}
+	return configManager.SetRestoreTuning(remote, tuning) == nil
+}
+
+// renderRestorePlan renders the download settings of the restore being
+// planned
+func (m TrashModel) renderRestorePlan() string {
+	plan := m.plan
+	var b strings.Builder
+
+	b.WriteString(styles.RenderSubtitle(fmt.Sprintf("Restore %s into %s", plan.entry.Date.Format("2006-01-02"), m.pair.LocalPath)))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("%d files, %s, %d over %s\n", plan.files, formatBytes(plan.bytes), plan.large, formatBytes(rclone.MultiThreadCutoff)))
+	if plan.bandwidth > 0 {
+		b.WriteString(fmt.Sprintf("Measured download: %.1f MB/s (benchmark of %s)\n", plan.bandwidth/(1<<20), plan.measured.Format("2006-01-02")))
+	} else {
+		b.WriteString(styles.RenderMuted("Download speed not measured; benchmark the remote under Remotes to tune for it."))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	for i, input := range plan.inputs {
+		b.WriteString(input.View())
+		b.WriteString("\n")
+		if i < len(plan.inputs)-1 {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n")
+	if plan.saved != nil {
+		b.WriteString(styles.RenderMuted(fmt.Sprintf("Starting from the profile saved for '%s'. Auto-tuned: %s.", m.pair.RemoteName, plan.auto)))
+	} else {
+		b.WriteString(styles.RenderMuted("Auto-tuned to the file sizes and download speed."))
+	}
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted("Few large files download fastest in many streams each; many small files, many at once."))
+	b.WriteString("\n")
+
+	return b.String()
+}
@@ -22,6 +22,7 @@ type SyncConfigModel struct {
 	complete      bool
 	configManager *config.Manager
 	syncConfig    config.SyncConfig
+	validation    FormValidator
 }
 
 // NewSyncConfigModel creates a new sync configuration model
@@ -29,6 +30,7 @@ func NewSyncConfigModel(configManager *config.Manager) SyncConfigModel {
 	m := SyncConfigModel{
 		configManager: configManager,
 		inputs:        make([]textinput.Model, 4),
+		validation:    NewFormValidator(RemoteName, BucketPath(""), RemoteName, BucketPath("")),
 	}
 	
 	m.initInputs()
@@ -53,13 +55,20 @@ func (m SyncConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c":
 			return m, tea.Quit
 
-		case "esc", "q":
+		case "esc":
+			return m, BackCmd()
+
+		case "q":
+			// q is typed into the form's text fields
 			if m.complete {
-				return m, nil
+				return m, BackCmd()
 			}
-			return m, nil
 
 		case "tab", "shift+tab", "up", "down":
+			if m.focusIndex < len(m.inputs) {
+				m.setBucketRules()
+				m.validation.Recheck(m.focusIndex, m.inputs[m.focusIndex].Value())
+			}
 			s := msg.String()
 			if s == "up" || s == "shift+tab" {
 				m.focusIndex--
@@ -89,7 +98,7 @@ func (m SyncConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "enter":
 			if m.complete {
-				return m, nil
+				return m, DoneCmd("Sync configuration saved")
 			}
 			return m.handleSave()
 		}
@@ -120,14 +129,14 @@ func (m SyncConfigModel) View() string {
 
 	if m.err != nil {
 		b.WriteString("\n")
-		b.WriteString(styles.RenderError(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString(renderError(m.err))
 		b.WriteString("\n")
 	}
 
 	if m.complete {
 		b.WriteString(helper.RenderFooter("Press Enter to continue • q: Back"))
 	} else {
-		b.WriteString(helper.RenderFooter("Tab: Next field • Enter: Save • q: Back"))
+		b.WriteString(helper.RenderFooter("Tab: Next field • Enter: Save • esc: Back"))
 	}
 
 	return b.String()
@@ -143,7 +152,7 @@ func (m SyncConfigModel) renderForm() string {
 	b.WriteString("\n\n")
 	
 	for i, input := range m.inputs {
-		b.WriteString(input.View())
+		b.WriteString(m.validation.RenderField(i, input))
 		if i < len(m.inputs)-1 {
 			b.WriteString("\n\n")
 		}
@@ -206,6 +215,19 @@ func (m *SyncConfigModel) initInputs() {
 	m.inputs[3].Prompt = "Dest Bucket: "
 }
 
+// setBucketRules checks each bucket field by the type of the remote entered
+// before it. Only buckets of bucket-based remotes follow bucket rules; a
+// remote not saved in the configuration is not checked.
+func (m *SyncConfigModel) setBucketRules() {
+	for _, field := range []int{1, 3} {
+		remoteType := ""
+		if remote, err := m.configManager.GetRemote(strings.TrimSpace(m.inputs[field-1].Value())); err == nil {
+			remoteType = remote.Type
+		}
+		m.validation.SetRule(field, BucketPath(remoteType))
+	}
+}
+
 // handleSave validates and saves the sync configuration
 func (m SyncConfigModel) handleSave() (tea.Model, tea.Cmd) {
 	sourceRemote := strings.TrimSpace(m.inputs[0].Value())
@@ -213,8 +235,9 @@ func (m SyncConfigModel) handleSave() (tea.Model, tea.Cmd) {
 	destRemote := strings.TrimSpace(m.inputs[2].Value())
 	destBucket := strings.TrimSpace(m.inputs[3].Value())
 
-	if sourceRemote == "" || sourceBucket == "" || destRemote == "" || destBucket == "" {
-		m.err = fmt.Errorf("all fields are required")
+	m.setBucketRules()
+	if !m.validation.Validate(m.inputs) {
+		m.err = nil
 		return m, nil
 	}
 
@@ -0,0 +1,69 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// deleteOverride is a sync from the list that a pair's delete limit
+// stopped. 'cloud-sync sync' cannot ask without a terminal, so the list
+// asks and runs it again with --allow-deletes.
+type deleteOverride struct {
+	target  string   // What the sync again syncs, e.g. "'Docs'"
+	args    []string // Arguments of the sync again
+	reasons []string // What each stopped pair would have deleted
+}
+
+// newDeleteOverride returns the sync to run again for the pairs the delete
+// limit stopped. A folder of a pair is synced again as it was; of a group,
+// only the stopped pairs are.
+func (m SyncPairsModel) newDeleteOverride(msg pairsSynced) *deleteOverride {
+	override := &deleteOverride{target: msg.target, reasons: msg.deleteLimits}
+	if slices.Contains(m.syncArgs, "--path") {
+		override.args = append([]string{"--allow-deletes"}, m.syncArgs...)
+		return override
+	}
+	names := make([]string, 0, len(msg.blocked))
+	for _, name := range msg.blocked {
+		names = append(names, fmt.Sprintf("'%s'", name))
+	}
+	override.target = strings.Join(names, ", ")
+	override.args = append([]string{"--allow-deletes"}, msg.blocked...)
+	return override
+}
+
+// updateDeleteOverride handles a key while a stopped sync waits for y/n
+func (m SyncPairsModel) updateDeleteOverride(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "y", "Y":
+		override := m.deletes
+		m.deletes = nil
+		m.syncing = override.target
+		m.syncArgs = override.args
+		m.message = ""
+		m.results = nil
+		m.error = nil
+		return m, tea.Batch(m.spinner.Tick, streamSyncCmd(override.target, override.args...))
+	case "n", "N", "esc":
+		m.deletes = nil
+	}
+	return m, nil
+}
+
+// render asks whether the stopped sync may delete after all
+func (o *deleteOverride) render() string {
+	var b strings.Builder
+	for _, reason := range o.reasons {
+		b.WriteString(styles.RenderWarning(reason))
+		b.WriteString("\n")
+	}
+	b.WriteString(fmt.Sprintf("Delete them anyway and sync %s again? (y/n)", o.target))
+	return b.String()
+}
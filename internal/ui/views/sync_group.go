@@ -0,0 +1,185 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/archive"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/installer"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/network"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// newBackupManager creates a backup manager from the saved configuration
+// for syncing in this process. What rclone and rsync print is discarded;
+// the view shows the progress instead.
+func newBackupManager() (*backup.Manager, error) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	rclonePath := appConfig.RclonePath
+	if path, err := installer.NewInstaller().GetRclonePath(); err == nil {
+		rclonePath = path
+	}
+	zstdPath, _ := archive.FindZstd()
+
+	return backup.NewManager(&backup.Config{
+		Username:     launchd.CurrentUsername(),
+		HomeDir:      appConfig.HomeDir,
+		SourceRemote: appConfig.SyncConfig.SourceRemote,
+		SourceBucket: appConfig.SyncConfig.SourceBucket,
+		DestRemote:   appConfig.SyncConfig.DestRemote,
+		DestBucket:   appConfig.SyncConfig.DestBucket,
+		RclonePath:   rclonePath,
+		ZstdPath:     zstdPath,
+		Network:      network.NewChecker(),
+		LogDir:       appConfig.LogDir,
+		BinDir:       appConfig.BinDir,
+		RCAddr:       appConfig.LaunchAgent.RCAddress(),
+		ReportFormat: appConfig.Reports.Format,
+		ReportsKept:  appConfig.Reports.Keep,
+		Stdout:       io.Discard,
+		Stderr:       io.Discard,
+	})
+}
+
+// syncGroupCmd syncs the enabled pairs listed under a group with a backup
+// manager in this process, reporting their progress as they go and then,
+// like streamSyncCmd, the outcome
+func syncGroupCmd(target, group string) tea.Cmd {
+	stream := &syncStream{
+		progress: make(chan syncProgressMsg, 1),
+		done:     make(chan pairsSynced, 1),
+	}
+	go func() {
+		defer close(stream.progress)
+		manager, err := newBackupManager()
+		if err != nil {
+			stream.done <- pairsSynced{target: target, summary: err.Error(), hint: errkind.Hint(err), err: err}
+			return
+		}
+
+		// The transfer progress comes from another goroutine than the
+		// pairs progress
+		var mu sync.Mutex
+		var latest syncProgressMsg
+		report := func(update func(*syncProgressMsg)) {
+			mu.Lock()
+			defer mu.Unlock()
+			update(&latest)
+			if latest.pair == "" {
+				return
+			}
+			// Only the latest progress matters; drop an update the view
+			// has not picked up yet
+			select {
+			case <-stream.progress:
+			default:
+			}
+			msg := latest
+			msg.stream = stream
+			stream.progress <- msg
+		}
+		manager.SetPairsProgress(func(pair string, done, total int) {
+			report(func(msg *syncProgressMsg) {
+				*msg = syncProgressMsg{done: done, total: total, pair: pair}
+			})
+		})
+		manager.SetTransferProgress(func(pair string, progress backup.TransferProgress) {
+			report(func(msg *syncProgressMsg) {
+				msg.pair = pair
+				msg.files, msg.totalFiles = progress.Files, progress.TotalFiles
+				msg.bytes, msg.totalBytes = progress.Bytes, progress.TotalBytes
+			})
+		})
+
+		results, err := manager.SyncGroup(group, false, false)
+		stream.done <- groupOutcome(target, results, err)
+	}()
+	return waitForSync(stream)
+}
+
+// groupOutcome summarizes the results of a group sync like the output of
+// 'cloud-sync sync' that syncOutcome reads: how many pairs synced, the
+// first hint on a failure and, for several pairs, a table of how each
+// ended
+func groupOutcome(target string, results []backup.PairResult, err error) pairsSynced {
+	if len(results) == 0 {
+		summary := "nothing was synced"
+		if err != nil {
+			summary = err.Error()
+		}
+		return pairsSynced{target: target, summary: summary, hint: errkind.Hint(err), err: err}
+	}
+
+	outcome := pairsSynced{target: target, err: err}
+	synced, skipped := 0, 0
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PAIR\tRESULT\tTIME")
+	for _, result := range results {
+		status := pairResultStatus(result)
+		switch status {
+		case "done":
+			synced++
+		case "failed":
+			if outcome.hint == "" {
+				outcome.hint = errkind.Hint(result.Err)
+			}
+			var limitErr *backup.DeleteLimitError
+			if errors.As(result.Err, &limitErr) {
+				outcome.deleteLimits = append(outcome.deleteLimits, limitErr.Error())
+				outcome.blocked = append(outcome.blocked, result.Pair)
+			}
+		default:
+			skipped++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Pair, status, result.Duration.Round(time.Second))
+	}
+	w.Flush()
+	if len(results) > 1 {
+		outcome.results = strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	}
+
+	if skipped > 0 {
+		outcome.summary = fmt.Sprintf("Synced %d of %d pair(s), %d skipped.", synced, len(results), skipped)
+	} else {
+		outcome.summary = fmt.Sprintf("Synced %d of %d pair(s).", synced, len(results))
+	}
+	return outcome
+}
+
+// pairResultStatus returns how a pair of a group sync ended, in the words
+// of the table 'cloud-sync sync' prints
+func pairResultStatus(result backup.PairResult) string {
+	var powerErr *backup.PowerError
+	switch {
+	case result.Err == nil:
+		return "done"
+	case errors.As(result.Err, &powerErr):
+		return "deferred"
+	case result.Skipped():
+		return "skipped"
+	case errors.Is(result.Err, rclone.ErrCancelled):
+		return "cancelled"
+	default:
+		return "failed"
+	}
+}
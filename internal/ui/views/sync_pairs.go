@@ -2,13 +2,23 @@ package views
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/queue"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
 	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
 	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
 )
@@ -23,16 +33,35 @@ const (
 	SyncPairsStepAddLocalPath
 	SyncPairsStepAddRemoteName
 	SyncPairsStepAddRemotePath
+	SyncPairsStepAddCreateBucket
 	SyncPairsStepAddDirection
+	SyncPairsStepAddMode
+	SyncPairsStepAddDestinations
+	SyncPairsStepAddSnapshot
+	SyncPairsStepAddSnapshotKeep
+	SyncPairsStepAddSnapshotMaxAge
+	SyncPairsStepAddSoftDelete
+	SyncPairsStepAddDeleteLimit
+	SyncPairsStepAddSkipHidden
+	SyncPairsStepAddSkipSymlinks
+	SyncPairsStepAddSkipLarger
+	SyncPairsStepAddExtraFlags
+	SyncPairsStepAddSyncOnMount
 	SyncPairsStepConfirm
 	SyncPairsStepComplete
+	SyncPairsStepSyncSubpath // Folder of the selected pair to sync now
 )
 
 // SyncPairsModel represents the sync pairs management view
 type SyncPairsModel struct {
 	syncConfig  *syncconfig.Manager
+	rclone      *rclone.Manager
 	currentStep SyncPairsStep
 	syncPairs   []syncconfig.SyncPair
+	labels      remoteLabels // Labels of the remotes the pairs sync to
+	spinner     spinner.Model
+	loading     bool // Sync pairs are being read; syncPairs holds the last list
+	cursor      int
 	list        list.Model
 	textInput   textinput.Model
 	newPair     syncconfig.SyncPair
@@ -40,24 +69,64 @@ type SyncPairsModel struct {
 	width       int
 	height      int
 	complete    bool
+	clicks      ClickTracker
+	fieldErr    string // Validation error of the current wizard step
+	credentials error  // Credential references that did not resolve, see WithCredentialsError
+
+	// The list shows pairs under their group; the cursor is an index of
+	// rows(), which includes the group headings
+	collapsed map[string]bool  // Groups folded to their heading
+	syncing   string           // Group or pair being synced from the list
+	syncArgs  []string         // Arguments of that sync to 'cloud-sync sync', nil for a group
+	progress  *syncProgressMsg // Latest progress of that sync, once known
+	deletes   *deleteOverride  // Sync the delete limit stopped, to confirm
+	progBar   progress.Model
+	message   string   // Outcome of the last sync started here
+	results   []string // How each pair of that sync ended
+
+	// Buckets of the chosen remote, known once it has been listed. Only
+	// set for bucket-based remotes.
+	buckets       []string
+	bucketsListed bool
+	bucketsAt     time.Time // When the buckets were listed, perhaps by an earlier visit
+	pendingBucket string    // Missing bucket the user is asked to create
+	creating      bool
+	localRemote   bool   // The chosen remote is a local remote, whose paths are full paths
+	remoteType    string // Type of the chosen remote, once looked up
 }
 
 // NewSyncPairsModel creates a new sync pairs management model
-func NewSyncPairsModel(syncConfigMgr *syncconfig.Manager) SyncPairsModel {
+func NewSyncPairsModel(syncConfigMgr *syncconfig.Manager, rcloneMgr *rclone.Manager) SyncPairsModel {
 	ti := textinput.New()
 	ti.Placeholder = "Enter value..."
 	ti.Focus()
 
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = styles.SpinnerStyle
+
 	return SyncPairsModel{
 		syncConfig:  syncConfigMgr,
+		rclone:      rcloneMgr,
 		currentStep: SyncPairsStepList,
 		textInput:   ti,
+		spinner:     s,
+		progBar:     progress.New(progress.WithDefaultGradient()),
+		loading:     true,
+		collapsed:   make(map[string]bool),
 	}
 }
 
+// WithCredentialsError makes the view warn that the credential references
+// err names did not resolve, as returned by NewRcloneManager
+func (m SyncPairsModel) WithCredentialsError(err error) SyncPairsModel {
+	m.credentials = err
+	return m
+}
+
 // Init initializes the sync pairs view
 func (m SyncPairsModel) Init() tea.Cmd {
-	return m.loadSyncPairs()
+	return tea.Batch(m.spinner.Tick, m.loadSyncPairs())
 }
 
 // Update handles messages for the sync pairs view
@@ -69,14 +138,22 @@ func (m SyncPairsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.deletes != nil {
+			return m.updateDeleteOverride(msg)
+		}
 		switch msg.String() {
 		case "enter":
 			return m.handleEnter()
 		case "a":
 			if m.currentStep == SyncPairsStepList {
+				if err := refuseReadOnly("Adding sync pairs"); err != nil {
+					m.error = err
+					return m, nil
+				}
 				m.currentStep = SyncPairsStepAddName
 				m.newPair = syncconfig.SyncPair{Enabled: true}
 				m.textInput.Reset()
+				m.fieldErr = ""
 				return m, nil
 			}
 		case "d":
@@ -89,20 +166,152 @@ func (m SyncPairsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Toggle selected sync pair
 				return m.handleToggle()
 			}
+		case "x":
+			if m.currentStep == SyncPairsStepList && len(m.syncPairs) > 0 {
+				return m.handleOpenTrash()
+			}
+		case "v":
+			if m.currentStep == SyncPairsStepList && len(m.syncPairs) > 0 {
+				return m.handleOpenPointInTime()
+			}
+		case "h":
+			if m.currentStep == SyncPairsStepList && len(m.syncPairs) > 0 {
+				return m.handleOpenVersions()
+			}
+		case "c", " ":
+			if m.currentStep == SyncPairsStepList && len(m.syncPairs) > 0 {
+				return m.handleCollapse()
+			}
+		case "s":
+			if m.currentStep == SyncPairsStepList && len(m.syncPairs) > 0 {
+				return m.handleSync()
+			}
+		case "p":
+			if m.currentStep == SyncPairsStepList && len(m.syncPairs) > 0 {
+				return m.handleSyncSubpath()
+			}
+		case "u":
+			if m.currentStep == SyncPairsStepList {
+				return m.handleOpenQueue()
+			}
+		case "r":
+			if m.currentStep == SyncPairsStepList {
+				m.loading = true
+				return m, tea.Batch(m.spinner.Tick, m.loadSyncPairs())
+			}
+		case "ctrl+r":
+			if m.currentStep == SyncPairsStepAddRemotePath && m.bucketsListed {
+				m.buckets = nil
+				m.bucketsListed = false
+				return m, m.loadBuckets(m.newPair.RemoteName, true)
+			}
+		case "1", "2", "3":
+			// Shortcuts for common folders in an empty local path field
+			if m.currentStep == SyncPairsStepAddLocalPath && m.textInput.Value() == "" {
+				i, _ := strconv.Atoi(msg.String())
+				m.textInput.SetValue(PathShortcuts[i-1] + "/")
+				m.textInput.CursorEnd()
+				m.textInput.SetSuggestions(CompletePath(m.textInput.Value()))
+				return m, nil
+			}
+		case "up", "k":
+			if m.currentStep == SyncPairsStepList && m.cursor > 0 {
+				m.cursor--
+				return m, nil
+			}
+		case "down", "j":
+			if m.currentStep == SyncPairsStepList && m.cursor < len(m.rows())-1 {
+				m.cursor++
+				return m, nil
+			}
 		case "esc", "q":
-			if m.currentStep == SyncPairsStepList || m.complete {
-				return m, tea.Quit
-			} else {
-				// Go back to list
-				m.currentStep = SyncPairsStepList
-				m.error = nil
-				return m, m.loadSyncPairs()
+			if m.currentStep == SyncPairsStepList {
+				return m, BackCmd()
 			}
+			// q is typed into the wizard's text fields; only esc leaves them
+			if msg.String() == "q" && !m.complete {
+				break
+			}
+			// Go back to list
+			m.currentStep = SyncPairsStepList
+			m.complete = false
+			m.error = nil
+			m.fieldErr = ""
+			m.setPathCompletion(false)
+			return m, m.loadSyncPairs()
+		}
+
+	case tea.MouseMsg:
+		if m.currentStep == SyncPairsStepList && IsLeftClick(msg) {
+			return m.handleClick(msg)
+		}
+
+	case remoteBucketsLoaded:
+		// Ignore listings of a remote the user has since changed
+		if msg.remote == m.newPair.RemoteName {
+			m.buckets = msg.buckets
+			m.bucketsListed = msg.listed
+			m.bucketsAt = msg.listedAt
+			m.localRemote = msg.remoteType == "local"
+			m.remoteType = msg.remoteType
+		}
+		return m, nil
+
+	case bucketCreated:
+		m.creating = false
+		if msg.err != nil {
+			m.fieldErr = msg.err.Error()
+			return m, nil
+		}
+		m.buckets = append(m.buckets, msg.bucket)
+		m.pendingBucket = ""
+		m.currentStep = SyncPairsStepAddDirection
+		m.textInput.Reset()
+		return m, nil
+
+	case spinner.TickMsg:
+		if !m.loading && m.syncing == "" {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case ConfigChangedMsg:
+		// Pairs edited elsewhere; the wizard reloads when it returns to the list
+		if m.currentStep == SyncPairsStepList {
+			return m, m.loadSyncPairs()
 		}
+		return m, nil
 
 	case syncPairsLoaded:
+		m.loading = false
 		m.syncPairs = msg.pairs
+		m.labels = msg.labels
 		m.error = msg.err
+		if m.cursor >= len(m.rows()) {
+			m.cursor = 0
+		}
+		return m, nil
+
+	case syncProgressMsg:
+		m.progress = &msg
+		return m, waitForSync(msg.stream)
+
+	case pairsSynced:
+		m.syncing = ""
+		m.progress = nil
+		m.results = msg.results
+		if msg.err != nil {
+			m.message = ""
+			m.error = &hintedError{fmt.Errorf("syncing %s failed: %s", msg.target, msg.summary), msg.hint}
+			if len(msg.blocked) > 0 {
+				m.deletes = m.newDeleteOverride(msg)
+			}
+		} else {
+			m.error = nil
+			m.message = fmt.Sprintf("%s: %s", msg.target, msg.summary)
+		}
 		return m, nil
 	}
 
@@ -110,6 +319,12 @@ func (m SyncPairsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	if m.textInput.Focused() {
 		m.textInput, cmd = m.textInput.Update(msg)
+		if m.currentStep == SyncPairsStepAddLocalPath {
+			m.textInput.SetSuggestions(CompletePath(m.textInput.Value()))
+		}
+		if pair, ok := m.selectedPair(); ok && m.currentStep == SyncPairsStepSyncSubpath {
+			m.textInput.SetSuggestions(CompleteSubpath(pair.LocalPath, m.textInput.Value()))
+		}
 	}
 
 	return m, cmd
@@ -121,6 +336,7 @@ func (m SyncPairsModel) View() string {
 	var b strings.Builder
 
 	b.WriteString(helper.RenderHeader("Sync Pairs Management", "Configure local folders to sync with cloud storage"))
+	b.WriteString(renderCredentialsWarning(m.credentials))
 
 	// Show current step
 	b.WriteString(m.renderCurrentStep())
@@ -128,7 +344,12 @@ func (m SyncPairsModel) View() string {
 
 	if m.error != nil {
 		b.WriteString("\n")
-		b.WriteString(styles.RenderError(fmt.Sprintf("Error: %v", m.error)))
+		b.WriteString(renderError(m.error))
+		b.WriteString("\n")
+	}
+	if m.deletes != nil {
+		b.WriteString("\n")
+		b.WriteString(m.deletes.render())
 		b.WriteString("\n")
 	}
 
@@ -160,30 +381,154 @@ func (m SyncPairsModel) renderCurrentStep() string {
 
 	case SyncPairsStepAddName:
 		content = "Enter a name for this sync pair:\n\n"
-		content += m.textInput.View()
+		content += RenderInput(m.textInput, m.fieldErr)
 
 	case SyncPairsStepAddLocalPath:
 		content = "Enter the local folder path to sync:\n\n"
-		content += m.textInput.View()
-		content += "\n\nExample: /Users/username/Documents"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\n" + m.renderPathSuggestions()
 
 	case SyncPairsStepAddRemoteName:
 		content = "Enter the rclone remote name:\n\n"
-		content += m.textInput.View()
+		content += RenderInput(m.textInput, m.fieldErr)
 		content += "\n\nExample: backblaze, s3, gdrive"
+		content += "\nOr enter a folder, e.g. /Volumes/Backup/Documents, to mirror"
+		content += "\nto another disk with rsync."
 
 	case SyncPairsStepAddRemotePath:
+		if m.localRemote {
+			content = "Enter the full path of the folder to sync to:\n\n"
+			content += RenderInput(m.textInput, m.fieldErr)
+			content += "\n\nExample: /Volumes/NAS/Documents"
+			break
+		}
 		content = "Enter the remote path (bucket/folder):\n\n"
-		content += m.textInput.View()
+		content += RenderInput(m.textInput, m.fieldErr)
 		content += "\n\nExample: my-bucket/documents"
+		content += "\n{hostname}, {user} and {date} are filled in when the pair runs,"
+		content += "\ne.g. my-bucket/{hostname}/documents"
+		if m.bucketsListed {
+			content += "\n\n" + m.renderBucketHint()
+		}
+
+	case SyncPairsStepAddCreateBucket:
+		if m.creating {
+			content = fmt.Sprintf("Creating bucket '%s' on '%s'...", m.pendingBucket, m.newPair.RemoteName)
+			break
+		}
+		content = fmt.Sprintf("Bucket '%s' does not exist on remote '%s'.\n", m.pendingBucket, m.newPair.RemoteName)
+		content += "Create it now? (y/n)\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nAnswer n to enter a different path."
 
 	case SyncPairsStepAddDirection:
 		content = "Select sync direction:\n\n"
 		content += "1. upload (local → remote)\n"
 		content += "2. download (remote → local)\n"
-		content += "3. bidirectional (both ways)\n\n"
-		content += "Enter 1, 2, or 3: "
-		content += m.textInput.View()
+		content += "3. bidirectional (both ways)\n"
+		content += "4. monitor: never transfer, only check both sides for drift\n\n"
+		content += "Enter 1, 2, 3, or 4: "
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nNext you choose whether deletions are mirrored to the destination."
+
+	case SyncPairsStepAddMode:
+		content = "Select how files are transferred:\n\n"
+		content += "1. sync: make the destination match; files deleted here are deleted there\n"
+		content += "2. copy: only add and update files; nothing is ever deleted (archival)\n"
+		if m.newPair.Direction != "bidirectional" {
+			content += "3. move: copy, then delete the files from the source\n"
+		}
+		if m.newPair.IsLocal() {
+			content += "4. monitor: never transfer, only check both folders for drift\n"
+		}
+		content += "\nEnter a number: "
+		content += RenderInput(m.textInput, m.fieldErr)
+
+	case SyncPairsStepAddDestinations:
+		content = "Additional destinations (optional):\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nComma-separated remote:path entries the folder is also uploaded to."
+		content += "\nExample: nas:backups/documents, s3:mirror/documents"
+		content += "\nLeave empty to upload to the primary remote only."
+
+	case SyncPairsStepAddSnapshot:
+		content = "Use snapshot mode? (y/n)\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nEach run copies into a dated folder (remote/path/YYYY-MM-DD) instead of"
+		content += "\nsyncing in place. Unchanged files are not uploaded again."
+
+	case SyncPairsStepAddSnapshotKeep:
+		content = "How many snapshots should be kept?\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nOlder snapshots are merged into the oldest kept one and removed."
+		content += "\nLeave empty to keep all snapshots."
+
+	case SyncPairsStepAddSnapshotMaxAge:
+		content = "How many days should snapshots be kept?\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nOlder snapshots are pruned the same way; the newest one is always kept."
+		content += "\nLeave empty to keep snapshots of any age."
+
+	case SyncPairsStepAddSoftDelete:
+		content = "Keep deleted files in a trash folder on the remote? (y/n)\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += fmt.Sprintf("\n\nFiles removed locally are moved to a dated folder under %s/\n", rclone.TrashDirName)
+		content += fmt.Sprintf("and purged after %d days.", syncconfig.DefaultTrashRetentionDays)
+
+	case SyncPairsStepAddDeleteLimit:
+		content = "Largest share of the destination a sync may delete, in % (optional):\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nA sync that would delete more stops and asks first. Example: 20"
+		content += fmt.Sprintf("\nLeave empty for %d%%; 100 turns the check off.", syncconfig.DefaultMaxDeletePercent)
+
+	case SyncPairsStepAddSkipHidden:
+		content = "Skip hidden files? (y/n)\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nFiles and folders whose names start with a dot, such as .DS_Store"
+		content += "\nand .git, are left out."
+
+	case SyncPairsStepAddSkipSymlinks:
+		content = "Skip symbolic links? (y/n)\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		if m.newPair.IsLocal() {
+			content += "\n\nOtherwise links are copied as links."
+		} else {
+			content += "\n\nrclone cannot upload links and logs a notice for each one;"
+			content += "\nskipping them keeps the log quiet."
+		}
+
+	case SyncPairsStepAddSkipLarger:
+		content = "Skip files larger than (optional):\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nExample: 500M, 2G"
+		content += "\nLeave empty to sync files of any size."
+
+	case SyncPairsStepAddExtraFlags:
+		if m.newPair.IsLocal() {
+			content = "Advanced: extra rsync flags (optional):\n\n"
+			content += RenderInput(m.textInput, m.fieldErr)
+			content += "\n\nFiles are copied with rsync -a; these flags are appended."
+			content += "\nExample: --exclude .DS_Store --no-perms"
+			content += "\nLeave empty if unsure."
+			break
+		}
+		content = "Advanced: extra rclone flags (optional):\n\n"
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nAppended to every rclone command of this pair."
+		content += "\nExample: --track-renames --transfers 8"
+		content += "\nLeave empty if unsure."
+
+	case SyncPairsStepAddSyncOnMount:
+		content = fmt.Sprintf("Sync automatically when %s is connected? (y/n)\n\n", strings.Join(m.newPair.Volumes(), " and "))
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\nWhile the drive is disconnected, runs of this pair are skipped."
+		content += "\nRun 'cloud-sync watch-drives' once to start watching for drives."
+
+	case SyncPairsStepSyncSubpath:
+		pair, _ := m.selectedPair()
+		content = fmt.Sprintf("Sync one folder of '%s' now, relative to %s:\n\n", pair.Name, pair.LocalPath)
+		content += RenderInput(m.textInput, m.fieldErr)
+		content += "\n\n" + m.renderPathSuggestions()
 
 	case SyncPairsStepConfirm:
 		content = m.renderNewPairSummary()
@@ -198,44 +543,327 @@ func (m SyncPairsModel) renderCurrentStep() string {
 
 // renderSyncPairsList renders the list of sync pairs
 func (m SyncPairsModel) renderSyncPairsList() string {
+	if m.loading && len(m.syncPairs) == 0 {
+		return fmt.Sprintf("%s Loading sync pairs...", m.spinner.View())
+	}
 	if len(m.syncPairs) == 0 {
 		return "No sync pairs configured.\n\nPress 'a' to add a new sync pair."
 	}
 
 	var b strings.Builder
-	b.WriteString("Configured Sync Pairs:\n\n")
+	b.WriteString("Configured Sync Pairs:")
+	if m.loading {
+		b.WriteString(" " + m.spinner.View())
+	}
+	b.WriteString("\n\n")
+	if m.syncing != "" {
+		b.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), styles.RenderInfo(fmt.Sprintf("Syncing %s...", m.syncing))))
+		b.WriteString("\n\n")
+		if m.progress != nil {
+			b.WriteString(renderSyncProgress(m.progBar, m.progress))
+			b.WriteString("\n")
+		}
+	} else if m.message != "" {
+		b.WriteString(styles.RenderSuccess(m.message))
+		b.WriteString("\n\n")
+	}
+	if m.syncing == "" && len(m.results) > 0 {
+		b.WriteString(styles.RenderMuted(strings.Join(m.results, "\n")))
+		b.WriteString("\n\n")
+	}
 
-	for i, pair := range m.syncPairs {
+	for r, row := range m.rows() {
+		cursor := "  "
+		if r == m.cursor {
+			cursor = styles.RenderHighlight("> ")
+		}
+
+		if row.pair < 0 {
+			b.WriteString(fmt.Sprintf("%s%s\n", cursor, styles.RenderInfo(m.groupLabel(row.group))))
+			if !m.collapsed[row.group] {
+				b.WriteString("\n")
+			}
+			continue
+		}
+
+		i, pair := row.pair, m.syncPairs[row.pair]
 		status := "✓"
 		if !pair.Enabled {
 			status = "✗"
 		}
 
-		b.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, status, pair.Name))
+		b.WriteString(fmt.Sprintf("%s%d. [%s] %s\n", cursor, i+1, status, pair.Name))
 		b.WriteString(fmt.Sprintf("   Local:  %s\n", pair.LocalPath))
-		b.WriteString(fmt.Sprintf("   Remote: %s:%s\n", pair.RemoteName, pair.RemotePath))
-		b.WriteString(fmt.Sprintf("   Direction: %s\n", pair.Direction))
+		if pair.TargetPath != "" {
+			b.WriteString(fmt.Sprintf("   Target: %s\n", pair.TargetPath))
+		} else {
+			b.WriteString(fmt.Sprintf("   Remote: %s\n", m.labels.renderPath(pair.RemoteName, pair.RemotePath)))
+		}
+		for _, dest := range pair.Destinations {
+			b.WriteString(fmt.Sprintf("   Also:   %s\n", m.labels.renderPath(dest.RemoteName, dest.RemotePath)))
+		}
+		if pair.IsMonitor() {
+			b.WriteString("   Monitor: only checked for drift, never synced\n")
+		} else {
+			b.WriteString(fmt.Sprintf("   Direction: %s (%s)\n", pair.Direction, pair.TransferMode()))
+		}
+		if pair.Snapshot {
+			b.WriteString(fmt.Sprintf("   Snapshots: keep %s\n", snapshotRetention(pair)))
+		}
+		if pair.Archive {
+			keep := "all"
+			if pair.ArchiveKeep > 0 {
+				keep = fmt.Sprintf("%d", pair.ArchiveKeep)
+			}
+			b.WriteString(fmt.Sprintf("   Archives: keep %s\n", keep))
+		}
+		if pair.SoftDelete {
+			b.WriteString(fmt.Sprintf("   Trash: kept for %d days\n", int(pair.TrashRetention().Hours()/24)))
+		}
+		if pair.MaxDeletePercent > 0 {
+			limit := fmt.Sprintf("%d%% of the destination", pair.DeleteLimit())
+			if pair.DeleteLimit() >= 100 {
+				limit = "none"
+			}
+			b.WriteString(fmt.Sprintf("   Delete limit: %s\n", limit))
+		}
+		if pair.MaxRemoteGB > 0 {
+			action := "stops"
+			if pair.BudgetWarnOnly {
+				action = "warns"
+			}
+			b.WriteString(fmt.Sprintf("   Size budget: %g GB (%s when over)\n", pair.MaxRemoteGB, action))
+		}
+		if skips := skippedFiles(pair); skips != "none" {
+			b.WriteString(fmt.Sprintf("   Skips: %s\n", skips))
+		}
+		if len(pair.ExtraFlags) > 0 {
+			b.WriteString(fmt.Sprintf("   Flags: %s\n", strings.Join(pair.ExtraFlags, " ")))
+		}
+		if pair.SyncOnMount {
+			b.WriteString("   Syncs when its drive is connected\n")
+		}
+		if len(pair.WiFiNetworks) > 0 {
+			b.WriteString(fmt.Sprintf("   Only on Wi-Fi: %s\n", strings.Join(pair.WiFiNetworks, ", ")))
+		}
+		if pair.SkipOnHotspot {
+			b.WriteString("   Skipped on a personal hotspot\n")
+		}
+		if pair.MinBatteryPercent > 0 {
+			b.WriteString(fmt.Sprintf("   Scheduled runs wait for power below %d%% battery\n", pair.MinBatteryPercent))
+		}
+		if pair.IndexFirst {
+			b.WriteString("   Indexes the source before each run\n")
+		}
+		if len(pair.Tags) > 0 {
+			b.WriteString(fmt.Sprintf("   Tags: %s\n", strings.Join(pair.Tags, ", ")))
+		}
 		b.WriteString("\n")
 	}
 
 	return b.String()
 }
 
+// pairRow is one entry of the sync pairs list: a group heading, or the
+// pair at index pair of syncPairs
+type pairRow struct {
+	group string
+	pair  int // -1 for a group heading
+}
+
+// rows returns the entries of the list. Ungrouped pairs come first, in the
+// order they are stored, followed by each group in the order it first
+// appears. The pairs of a collapsed group are left out.
+func (m SyncPairsModel) rows() []pairRow {
+	var rows []pairRow
+	var groups []string
+	members := make(map[string][]int)
+	for i, pair := range m.syncPairs {
+		group := pair.ListGroup()
+		if group == "" {
+			rows = append(rows, pairRow{pair: i})
+			continue
+		}
+		if _, ok := members[group]; !ok {
+			groups = append(groups, group)
+		}
+		members[group] = append(members[group], i)
+	}
+
+	for _, group := range groups {
+		rows = append(rows, pairRow{group: group, pair: -1})
+		if m.collapsed[group] {
+			continue
+		}
+		for _, i := range members[group] {
+			rows = append(rows, pairRow{group: group, pair: i})
+		}
+	}
+	return rows
+}
+
+// groupLabel returns the heading of a group, e.g. "▾ photos (3 pairs)"
+func (m SyncPairsModel) groupLabel(group string) string {
+	count := 0
+	for _, pair := range m.syncPairs {
+		if pair.ListGroup() == group {
+			count++
+		}
+	}
+	arrow := "▾"
+	if m.collapsed[group] {
+		arrow = "▸"
+	}
+	noun := "pairs"
+	if count == 1 {
+		noun = "pair"
+	}
+	return fmt.Sprintf("%s %s (%d %s)", arrow, group, count, noun)
+}
+
+// selected returns the row under the cursor
+func (m SyncPairsModel) selected() (pairRow, bool) {
+	rows := m.rows()
+	if m.cursor < 0 || m.cursor >= len(rows) {
+		return pairRow{}, false
+	}
+	return rows[m.cursor], true
+}
+
+// selectedPair returns the pair under the cursor, if it is on a pair
+func (m SyncPairsModel) selectedPair() (syncconfig.SyncPair, bool) {
+	row, ok := m.selected()
+	if !ok || row.pair < 0 {
+		return syncconfig.SyncPair{}, false
+	}
+	return m.syncPairs[row.pair], true
+}
+
+// handleClick selects the clicked sync pair, and toggles it on a
+// double-click. Clicking a group heading folds or unfolds the group.
+func (m SyncPairsModel) handleClick(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	rows := m.rows()
+	labels := make([]string, len(rows))
+	for r, row := range rows {
+		if row.pair < 0 {
+			labels[r] = m.groupLabel(row.group)
+		} else {
+			labels[r] = fmt.Sprintf("%d. [", row.pair+1)
+		}
+	}
+
+	r, ok := ItemAtLine(m.View(), msg.Y, labels)
+	if !ok {
+		return m, nil
+	}
+	m.cursor = r
+	if rows[r].pair < 0 {
+		return m.handleCollapse()
+	}
+	if m.clicks.Click(r, time.Now()) {
+		return m.handleToggle()
+	}
+	return m, nil
+}
+
 // renderNewPairSummary renders a summary of the new sync pair
 func (m SyncPairsModel) renderNewPairSummary() string {
-	return fmt.Sprintf(`New Sync Pair Summary:
+	extra := "none"
+	if len(m.newPair.Destinations) > 0 {
+		names := make([]string, 0, len(m.newPair.Destinations))
+		for _, dest := range m.newPair.Destinations {
+			names = append(names, dest.String())
+		}
+		extra = strings.Join(names, ", ")
+	}
+
+	flags := "none"
+	if len(m.newPair.ExtraFlags) > 0 {
+		flags = strings.Join(m.newPair.ExtraFlags, " ")
+	}
+
+	destination, tool := "Remote", "rclone"
+	switch {
+	case m.newPair.IsLocal():
+		destination, tool = "Target", "rsync"
+	case m.newPair.TargetPath != "":
+		destination = "Target"
+	}
+	direction, mode := m.newPair.Direction, m.newPair.TransferMode()
+	if m.newPair.IsMonitor() {
+		direction, mode = "monitor", "check"
+	}
+
+	summary := fmt.Sprintf(`New Sync Pair Summary:
 
 Name: %s
 Local Path: %s
-Remote: %s:%s
+%s: %s
+Additional destinations: %s
 Direction: %s
+Mode: %s
+Snapshot mode: %v
+Soft delete: %v
+Skip: %s
+Extra %s flags: %s
 Enabled: %v`,
 		m.newPair.Name,
 		m.newPair.LocalPath,
-		m.newPair.RemoteName,
-		m.newPair.RemotePath,
-		m.newPair.Direction,
+		destination,
+		m.newPair.Target(),
+		extra,
+		direction,
+		mode,
+		m.newPair.Snapshot,
+		m.newPair.SoftDelete,
+		skippedFiles(m.newPair),
+		tool,
+		flags,
 		m.newPair.Enabled)
+	switch {
+	case m.newPair.MaxDeletePercent >= 100:
+		summary += "\nDelete limit: none"
+	case m.newPair.MaxDeletePercent > 0:
+		summary += fmt.Sprintf("\nDelete limit: %d%% of the destination", m.newPair.MaxDeletePercent)
+	}
+	if m.newPair.Snapshot {
+		summary += "\nSnapshots: keep " + snapshotRetention(m.newPair)
+	}
+	if len(m.newPair.Volumes()) > 0 {
+		summary += fmt.Sprintf("\nSync on mount: %v", m.newPair.SyncOnMount)
+	}
+	return summary
+}
+
+// snapshotRetention describes which snapshots a pair keeps, e.g. "7, for
+// 30 days"
+func snapshotRetention(pair syncconfig.SyncPair) string {
+	keep := "all"
+	if pair.SnapshotKeep > 0 {
+		keep = fmt.Sprintf("%d", pair.SnapshotKeep)
+	}
+	if pair.SnapshotMaxAgeDays > 0 {
+		keep += fmt.Sprintf(", for %d days", pair.SnapshotMaxAgeDays)
+	}
+	return keep
+}
+
+// skippedFiles lists the kinds of file a pair leaves out, or "none"
+func skippedFiles(pair syncconfig.SyncPair) string {
+	var skips []string
+	if pair.SkipHidden {
+		skips = append(skips, "hidden files")
+	}
+	if pair.SkipSymlinks {
+		skips = append(skips, "symbolic links")
+	}
+	if pair.SkipLargerThan != "" {
+		skips = append(skips, "files over "+pair.SkipLargerThan)
+	}
+	if len(skips) == 0 {
+		return "none"
+	}
+	return strings.Join(skips, ", ")
 }
 
 // renderFooter renders the footer with available actions
@@ -244,59 +872,315 @@ func (m SyncPairsModel) renderFooter() string {
 
 	switch m.currentStep {
 	case SyncPairsStepList:
+		if m.deletes != nil {
+			return helper.RenderFooter("y: Delete them and sync • n/esc: Keep them")
+		}
 		if len(m.syncPairs) > 0 {
-			return helper.RenderFooter("a: Add • d: Delete • t: Toggle • q: Back")
+			return helper.RenderFooter(keyFooter(syncPairKeys))
+		}
+		return helper.RenderFooter("a: Add new sync pair • u: Queue • r: Refresh • q: Back to menu")
+	case SyncPairsStepAddLocalPath:
+		return helper.RenderFooter("tab: Complete • ↑/↓: Next match • Enter: Continue • esc: Back to list")
+	case SyncPairsStepSyncSubpath:
+		return helper.RenderFooter("tab: Complete • ↑/↓: Next match • Enter: Sync • esc: Back to list")
+	case SyncPairsStepAddRemotePath:
+		if m.bucketsListed {
+			return helper.RenderFooter("Enter: Continue • ctrl+r: Refresh buckets • esc: Back to list")
 		}
-		return helper.RenderFooter("a: Add new sync pair • q: Back to menu")
+		return helper.RenderFooter("Enter: Continue • esc: Back to list")
 	default:
-		return helper.RenderFooter("Enter: Continue • Esc: Cancel • q: Back to menu")
+		return helper.RenderFooter("Enter: Continue • esc: Back to list")
 	}
 }
 
+// stepValidator returns the validator for the current wizard step's input
+func (m SyncPairsModel) stepValidator() Validator {
+	switch m.currentStep {
+	case SyncPairsStepAddName:
+		return Required
+	case SyncPairsStepAddLocalPath:
+		return All(Required, DirExists)
+	case SyncPairsStepAddRemoteName:
+		return remoteOrTarget
+	case SyncPairsStepAddRemotePath:
+		if m.localRemote {
+			return All(Required, fullPath)
+		}
+		return All(Required, RemotePathVariables)
+	case SyncPairsStepAddCreateBucket:
+		return OneOf("y", "n", "yes", "no")
+	case SyncPairsStepAddDirection:
+		return OneOf("1", "2", "3", "4")
+	case SyncPairsStepAddMode:
+		if m.newPair.Direction == "bidirectional" {
+			return OneOf("1", "2")
+		}
+		if m.newPair.IsLocal() {
+			return OneOf("1", "2", "3", "4")
+		}
+		return OneOf("1", "2", "3")
+	case SyncPairsStepAddSnapshot, SyncPairsStepAddSoftDelete, SyncPairsStepAddSyncOnMount,
+		SyncPairsStepAddSkipHidden, SyncPairsStepAddSkipSymlinks:
+		return Optional(OneOf("y", "n", "yes", "no"))
+	case SyncPairsStepAddSkipLarger:
+		return Optional(func(value string) error {
+			_, err := syncconfig.ParseSkipSize(value)
+			return err
+		})
+	case SyncPairsStepAddSnapshotKeep:
+		return Optional(IntRange(1, 10000))
+	case SyncPairsStepAddSnapshotMaxAge:
+		return Optional(IntRange(1, 36500))
+	case SyncPairsStepAddDeleteLimit:
+		return Optional(IntRange(1, 100))
+	case SyncPairsStepAddExtraFlags:
+		if m.newPair.IsLocal() {
+			return rsyncFlags
+		}
+		return extraFlags
+	case SyncPairsStepSyncSubpath:
+		return All(Required, m.pairFolder)
+	}
+	return nil
+}
+
 // handleEnter handles the Enter key press
 func (m SyncPairsModel) handleEnter() (tea.Model, tea.Cmd) {
+	if validate := m.stepValidator(); validate != nil {
+		if err := validate(strings.TrimSpace(m.textInput.Value())); err != nil {
+			m.fieldErr = err.Error()
+			return m, nil
+		}
+	}
+	m.fieldErr = ""
+
 	switch m.currentStep {
+	case SyncPairsStepSyncSubpath:
+		return m.startSubpathSync()
+
 	case SyncPairsStepAddName:
-		m.newPair.Name = m.textInput.Value()
-		if m.newPair.Name != "" {
-			m.currentStep = SyncPairsStepAddLocalPath
-			m.textInput.Reset()
-		}
+		m.newPair.Name = strings.TrimSpace(m.textInput.Value())
+		m.currentStep = SyncPairsStepAddLocalPath
+		m.textInput.Reset()
+		m.setPathCompletion(true)
 
 	case SyncPairsStepAddLocalPath:
-		m.newPair.LocalPath = m.textInput.Value()
-		if m.newPair.LocalPath != "" {
-			m.currentStep = SyncPairsStepAddRemoteName
-			m.textInput.Reset()
+		// Store the full path; the sync scripts do not expand ~
+		path, err := ExpandHome(strings.TrimSpace(m.textInput.Value()))
+		if err != nil {
+			m.fieldErr = err.Error()
+			return m, nil
 		}
+		m.newPair.LocalPath = filepath.Clean(path)
+		m.currentStep = SyncPairsStepAddRemoteName
+		m.textInput.Reset()
+		m.setPathCompletion(false)
 
 	case SyncPairsStepAddRemoteName:
-		m.newPair.RemoteName = m.textInput.Value()
-		if m.newPair.RemoteName != "" {
-			m.currentStep = SyncPairsStepAddRemotePath
+		value := strings.TrimSpace(m.textInput.Value())
+		if isFolderPath(value) {
+			// Already validated
+			path, _ := ExpandHome(value)
+			m.newPair.Type = syncconfig.TypeLocal
+			m.newPair.TargetPath = filepath.Clean(path)
+			m.newPair.RemoteName = ""
+			m.newPair.Direction = "upload"
+			m.currentStep = SyncPairsStepAddMode
 			m.textInput.Reset()
+			return m, nil
 		}
+		m.newPair.Type = ""
+		m.newPair.TargetPath = ""
+		m.newPair.RemoteName = value
+		m.currentStep = SyncPairsStepAddRemotePath
+		m.textInput.Reset()
+		m.textInput.SetValue(defaultBucket(value))
+		m.buckets = nil
+		m.bucketsListed = false
+		m.localRemote = false
+		m.remoteType = ""
+		return m, m.loadBuckets(m.newPair.RemoteName, false)
 
 	case SyncPairsStepAddRemotePath:
-		m.newPair.RemotePath = m.textInput.Value()
-		if m.newPair.RemotePath != "" {
-			m.currentStep = SyncPairsStepAddDirection
+		m.newPair.RemotePath = strings.TrimSpace(m.textInput.Value())
+		if m.localRemote {
+			// Already validated
+			path, _ := ExpandHome(m.newPair.RemotePath)
+			m.newPair.RemotePath = filepath.Clean(path)
+		}
+		// A bucket named after the Mac or user is checked as it runs here
+		expanded := syncconfig.ExpandRemotePath(m.newPair.RemotePath, syncconfig.CurrentPathVars())
+		bucket := bucketOf(expanded)
+		if m.bucketsListed && !m.hasBucket(bucket) {
+			if err := rclone.ValidateBucketPath(m.remoteType, expanded); err != nil {
+				m.fieldErr = err.Error()
+				return m, nil
+			}
+			m.pendingBucket = bucket
+			m.currentStep = SyncPairsStepAddCreateBucket
 			m.textInput.Reset()
+			return m, nil
+		}
+		m.currentStep = SyncPairsStepAddDirection
+		m.textInput.Reset()
+
+	case SyncPairsStepAddCreateBucket:
+		if m.creating {
+			return m, nil
+		}
+		switch strings.ToLower(strings.TrimSpace(m.textInput.Value())) {
+		case "y", "yes":
+			m.creating = true
+			return m, m.createBucket(m.newPair.RemoteName, m.pendingBucket)
+		default:
+			m.pendingBucket = ""
+			m.currentStep = SyncPairsStepAddRemotePath
+			m.textInput.SetValue(m.newPair.RemotePath)
 		}
 
 	case SyncPairsStepAddDirection:
-		choice := m.textInput.Value()
-		switch choice {
+		switch strings.TrimSpace(m.textInput.Value()) {
 		case "1":
 			m.newPair.Direction = "upload"
 		case "2":
 			m.newPair.Direction = "download"
 		case "3":
 			m.newPair.Direction = "bidirectional"
+		case "4":
+			return m.startMonitorPair()
+		}
+		m.textInput.Reset()
+		m.currentStep = SyncPairsStepAddMode
+
+	case SyncPairsStepAddMode:
+		switch strings.TrimSpace(m.textInput.Value()) {
+		case "1":
+			m.newPair.Mode = syncconfig.ModeSync
+		case "2":
+			m.newPair.Mode = syncconfig.ModeCopy
+		case "3":
+			m.newPair.Mode = syncconfig.ModeMove
+		case "4":
+			return m.startMonitorPair()
+		}
+		m.textInput.Reset()
+		// Extra destinations are upload-only and cannot follow a move; soft
+		// delete applies whenever the remote is a sync destination. Local
+		// pairs have neither.
+		switch {
+		case m.newPair.IsLocal():
+			m.currentStep = m.deleteLimitStep()
+		case m.newPair.Direction == "upload" && m.newPair.Mode != syncconfig.ModeMove:
+			m.currentStep = SyncPairsStepAddDestinations
+		case m.newPair.Direction == "bidirectional" && m.newPair.Mode == syncconfig.ModeSync:
+			m.currentStep = SyncPairsStepAddSoftDelete
 		default:
-			m.error = fmt.Errorf("invalid choice, please enter 1, 2, or 3")
+			m.currentStep = m.deleteLimitStep()
+		}
+
+	case SyncPairsStepAddDestinations:
+		dests, err := parseDestinations(m.textInput.Value())
+		if err != nil {
+			m.fieldErr = err.Error()
 			return m, nil
 		}
+		m.newPair.Destinations = dests
+		m.currentStep = SyncPairsStepAddSnapshot
+		m.textInput.Reset()
+
+	case SyncPairsStepAddSnapshot:
+		switch strings.ToLower(strings.TrimSpace(m.textInput.Value())) {
+		case "y", "yes":
+			m.newPair.Snapshot = true
+			m.currentStep = SyncPairsStepAddSnapshotKeep
+		default:
+			m.newPair.Snapshot = false
+			m.currentStep = SyncPairsStepAddSoftDelete
+			if m.newPair.Mode != syncconfig.ModeSync {
+				m.currentStep = SyncPairsStepAddSkipHidden
+			}
+		}
+		m.textInput.Reset()
+
+	case SyncPairsStepAddSnapshotKeep:
+		// Empty keeps every snapshot
+		m.newPair.SnapshotKeep, _ = strconv.Atoi(strings.TrimSpace(m.textInput.Value()))
+		m.currentStep = SyncPairsStepAddSnapshotMaxAge
+		m.textInput.Reset()
+
+	case SyncPairsStepAddSnapshotMaxAge:
+		// Empty keeps snapshots of any age
+		m.newPair.SnapshotMaxAgeDays, _ = strconv.Atoi(strings.TrimSpace(m.textInput.Value()))
+		// Snapshots never delete, so soft delete does not apply
+		m.currentStep = SyncPairsStepAddSkipHidden
+		m.textInput.Reset()
+
+	case SyncPairsStepAddSoftDelete:
+		switch strings.ToLower(strings.TrimSpace(m.textInput.Value())) {
+		case "y", "yes":
+			m.newPair.SoftDelete = true
+		default:
+			m.newPair.SoftDelete = false
+		}
+		m.currentStep = m.deleteLimitStep()
+		m.textInput.Reset()
+
+	case SyncPairsStepAddDeleteLimit:
+		// Already validated; empty keeps the default
+		m.newPair.MaxDeletePercent, _ = strconv.Atoi(strings.TrimSpace(m.textInput.Value()))
+		m.currentStep = SyncPairsStepAddSkipHidden
+		m.textInput.Reset()
+
+	case SyncPairsStepAddSkipHidden:
+		switch strings.ToLower(strings.TrimSpace(m.textInput.Value())) {
+		case "y", "yes":
+			m.newPair.SkipHidden = true
+		default:
+			m.newPair.SkipHidden = false
+		}
+		m.currentStep = SyncPairsStepAddSkipSymlinks
+		m.textInput.Reset()
+
+	case SyncPairsStepAddSkipSymlinks:
+		switch strings.ToLower(strings.TrimSpace(m.textInput.Value())) {
+		case "y", "yes":
+			m.newPair.SkipSymlinks = true
+		default:
+			m.newPair.SkipSymlinks = false
+		}
+		m.currentStep = SyncPairsStepAddSkipLarger
+		m.textInput.Reset()
+
+	case SyncPairsStepAddSkipLarger:
+		// Already validated; empty skips no file for its size
+		m.newPair.SkipLargerThan = ""
+		if value := strings.TrimSpace(m.textInput.Value()); value != "" {
+			m.newPair.SkipLargerThan, _ = syncconfig.ParseSkipSize(value)
+		}
+		m.currentStep = SyncPairsStepAddExtraFlags
+		// Monitor pairs run no transfers to add flags to or sync on mount
+		if m.newPair.IsMonitor() {
+			m.currentStep = SyncPairsStepConfirm
+		}
+		m.textInput.Reset()
+
+	case SyncPairsStepAddExtraFlags:
+		// Already validated
+		m.newPair.ExtraFlags, _ = syncconfig.ParseExtraFlags(m.textInput.Value())
+		m.currentStep = SyncPairsStepConfirm
+		// Only pairs on an external drive can wait for it
+		if len(m.newPair.Volumes()) > 0 {
+			m.currentStep = SyncPairsStepAddSyncOnMount
+		}
+		m.textInput.Reset()
+
+	case SyncPairsStepAddSyncOnMount:
+		switch strings.ToLower(strings.TrimSpace(m.textInput.Value())) {
+		case "y", "yes":
+			m.newPair.SyncOnMount = true
+		default:
+			m.newPair.SyncOnMount = false
+		}
 		m.currentStep = SyncPairsStepConfirm
 		m.textInput.Reset()
 
@@ -319,12 +1203,178 @@ func (m SyncPairsModel) handleEnter() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// handleDelete handles deleting a sync pair
+// deleteLimitStep returns the step after the delete settings: the delete
+// limit for pairs that mirror deletions, which their syncs check, or the
+// skip questions
+func (m SyncPairsModel) deleteLimitStep() SyncPairsStep {
+	if m.newPair.TransferMode() == syncconfig.ModeSync && !m.newPair.Snapshot {
+		return SyncPairsStepAddDeleteLimit
+	}
+	return SyncPairsStepAddSkipHidden
+}
+
+// startMonitorPair makes the new pair a monitor pair, which has no
+// transfer settings, and asks which files its checks leave out
+func (m SyncPairsModel) startMonitorPair() (tea.Model, tea.Cmd) {
+	m.newPair.Type = syncconfig.TypeMonitor
+	m.newPair.Direction = ""
+	m.newPair.Mode = ""
+	m.currentStep = SyncPairsStepAddSkipHidden
+	m.textInput.Reset()
+	return m, nil
+}
+
+// setPathCompletion turns directory suggestions for the text input on or off
+func (m *SyncPairsModel) setPathCompletion(on bool) {
+	// Clear the matches first; the input keeps them while suggestions are off
+	m.textInput.ShowSuggestions = true
+	m.textInput.SetSuggestions(nil)
+	m.textInput.ShowSuggestions = on
+}
+
+// renderPathSuggestions lists the folder shortcuts, or the directories that
+// match the typed local path
+func (m SyncPairsModel) renderPathSuggestions() string {
+	if m.textInput.Value() == "" && m.currentStep == SyncPairsStepSyncSubpath {
+		return "Type a folder; matching folders are listed here."
+	}
+	if m.textInput.Value() == "" {
+		var b strings.Builder
+		b.WriteString("Shortcuts:")
+		for i, path := range PathShortcuts {
+			b.WriteString(fmt.Sprintf("  %d. %s", i+1, path))
+		}
+		b.WriteString("\nType a path; matching folders are listed here.")
+		return b.String()
+	}
+
+	matches := m.textInput.AvailableSuggestions()
+	if len(matches) == 0 {
+		return styles.RenderMuted("No matching folders")
+	}
+	current := m.textInput.CurrentSuggestion()
+	var lines []string
+	for _, match := range matches[:min(len(matches), maxPathSuggestions)] {
+		if match == current {
+			lines = append(lines, styles.RenderHighlight("> "+match))
+		} else {
+			lines = append(lines, "  "+match)
+		}
+	}
+	if len(matches) > maxPathSuggestions {
+		lines = append(lines, styles.RenderMuted(fmt.Sprintf("  … %d more", len(matches)-maxPathSuggestions)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// extraFlags accepts an empty value or valid extra rclone flags
+func extraFlags(value string) error {
+	flags, err := syncconfig.ParseExtraFlags(value)
+	if err != nil {
+		return err
+	}
+	return syncconfig.ValidateExtraFlags(flags)
+}
+
+// rsyncFlags accepts an empty value or valid extra rsync flags
+func rsyncFlags(value string) error {
+	flags, err := syncconfig.ParseExtraFlags(value)
+	if err != nil {
+		return err
+	}
+	return syncconfig.ValidateRsyncFlags(flags)
+}
+
+// isFolderPath reports whether a destination was entered as a folder rather
+// than a remote name
+func isFolderPath(value string) bool {
+	return strings.HasPrefix(value, "/") || strings.HasPrefix(value, "~")
+}
+
+// remoteOrTarget accepts a remote name, or a folder whose parent exists, so
+// a drive that is not mounted is noticed now
+func remoteOrTarget(value string) error {
+	if !isFolderPath(value) {
+		return RemoteName(value)
+	}
+	path, err := ExpandHome(value)
+	if err != nil {
+		return fmt.Errorf("failed to expand home directory: %w", err)
+	}
+	return syncconfig.ValidateLocalPath(filepath.Dir(filepath.Clean(path)))
+}
+
+// fullPath accepts the full paths local remotes take, as rclone reads
+// relative ones from its working directory. A leading ~ is the home
+// directory.
+func fullPath(value string) error {
+	path, err := ExpandHome(value)
+	if err != nil {
+		return fmt.Errorf("failed to expand home directory: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("enter a full path, e.g. /Volumes/NAS/%s", value)
+	}
+	return nil
+}
+
+// bucketOf returns the bucket of a bucket/folder remote path
+func bucketOf(remotePath string) string {
+	bucket, _, _ := strings.Cut(strings.Trim(remotePath, "/"), "/")
+	return bucket
+}
+
+// hasBucket reports whether the chosen remote has the bucket
+func (m SyncPairsModel) hasBucket(bucket string) bool {
+	for _, b := range m.buckets {
+		if b == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+// renderBucketHint lists the remote's buckets, or says there are none, and
+// how old a cached list is
+func (m SyncPairsModel) renderBucketHint() string {
+	hint := "This remote has no buckets yet. Enter a name to create one."
+	if len(m.buckets) > 0 {
+		const shown = 5
+		hint = "Existing buckets: " + strings.Join(m.buckets[:min(len(m.buckets), shown)], ", ")
+		if len(m.buckets) > shown {
+			hint += fmt.Sprintf(" (+%d more)", len(m.buckets)-shown)
+		}
+	}
+	if age := time.Since(m.bucketsAt); age >= time.Minute {
+		hint += "\n" + styles.RenderMuted(fmt.Sprintf("Listed %d min ago; ctrl+r lists them again", int(age.Minutes())))
+	}
+	return hint
+}
+
+// parseDestinations parses a comma-separated list of remote:path entries
+func parseDestinations(input string) ([]syncconfig.Destination, error) {
+	var dests []syncconfig.Destination
+	for _, field := range strings.Split(input, ",") {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		dest, err := syncconfig.ParseDestination(field)
+		if err != nil {
+			return nil, err
+		}
+		dests = append(dests, dest)
+	}
+	return dests, nil
+}
+
+// handleDelete handles deleting the selected sync pair
 func (m SyncPairsModel) handleDelete() (tea.Model, tea.Cmd) {
-	// For simplicity, delete the first one
-	// In a real implementation, you'd use a list selector
-	if len(m.syncPairs) > 0 {
-		if err := m.syncConfig.RemoveSyncPair(m.syncPairs[0].Name); err != nil {
+	if err := refuseReadOnly("Deleting sync pairs"); err != nil {
+		m.error = err
+		return m, nil
+	}
+	if pair, ok := m.selectedPair(); ok {
+		if err := m.syncConfig.RemoveSyncPair(pair.Name); err != nil {
 			m.error = err
 			return m, nil
 		}
@@ -333,12 +1383,14 @@ func (m SyncPairsModel) handleDelete() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// handleToggle handles toggling a sync pair's enabled status
+// handleToggle handles toggling the selected sync pair's enabled status
 func (m SyncPairsModel) handleToggle() (tea.Model, tea.Cmd) {
-	// For simplicity, toggle the first one
-	// In a real implementation, you'd use a list selector
-	if len(m.syncPairs) > 0 {
-		if err := m.syncConfig.ToggleEnabled(m.syncPairs[0].Name); err != nil {
+	if err := refuseReadOnly("Enabling or disabling sync pairs"); err != nil {
+		m.error = err
+		return m, nil
+	}
+	if pair, ok := m.selectedPair(); ok {
+		if err := m.syncConfig.ToggleEnabled(pair.Name); err != nil {
 			m.error = err
 			return m, nil
 		}
@@ -347,16 +1399,243 @@ func (m SyncPairsModel) handleToggle() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleCollapse folds or unfolds the group under the cursor, leaving the
+// cursor on its heading
+func (m SyncPairsModel) handleCollapse() (tea.Model, tea.Cmd) {
+	row, ok := m.selected()
+	if !ok || row.group == "" {
+		return m, nil
+	}
+	m.collapsed[row.group] = !m.collapsed[row.group]
+	for r, heading := range m.rows() {
+		if heading.pair < 0 && heading.group == row.group {
+			m.cursor = r
+		}
+	}
+	return m, nil
+}
+
+// handleSync syncs the pair under the cursor with 'cloud-sync sync', or,
+// when the cursor is on a group's heading, every enabled pair listed under
+// it, in this process
+func (m SyncPairsModel) handleSync() (tea.Model, tea.Cmd) {
+	row, ok := m.selected()
+	if !ok || m.syncing != "" {
+		return m, nil
+	}
+	if err := refuseReadOnly("Syncing"); err != nil {
+		m.error = err
+		return m, nil
+	}
+
+	m.message = ""
+	m.results = nil
+	m.error = nil
+	if row.pair < 0 {
+		m.syncing = fmt.Sprintf("group '%s'", row.group)
+		m.syncArgs = nil
+		return m, tea.Batch(m.spinner.Tick, syncGroupCmd(m.syncing, row.group))
+	}
+	m.syncing = fmt.Sprintf("'%s'", m.syncPairs[row.pair].Name)
+	m.syncArgs = []string{m.syncPairs[row.pair].Name}
+	return m, tea.Batch(m.spinner.Tick, streamSyncCmd(m.syncing, m.syncArgs...))
+}
+
+// handleSyncSubpath asks for a folder of the selected pair to sync on its own
+func (m SyncPairsModel) handleSyncSubpath() (tea.Model, tea.Cmd) {
+	pair, ok := m.selectedPair()
+	if !ok || m.syncing != "" {
+		return m, nil
+	}
+	if err := refuseReadOnly("Syncing"); err != nil {
+		m.error = err
+		return m, nil
+	}
+	if pair.Archive || pair.Snapshot {
+		m.error = fmt.Errorf("'%s' uploads its whole folder as an archive or snapshot; press s to sync it", pair.Name)
+		return m, nil
+	}
+
+	m.currentStep = SyncPairsStepSyncSubpath
+	m.error = nil
+	m.fieldErr = ""
+	m.textInput.Reset()
+	m.setPathCompletion(true)
+	return m, nil
+}
+
+// pairFolder accepts a folder of the selected pair. A download pair creates
+// it, so it only has to exist for the other directions.
+func (m SyncPairsModel) pairFolder(value string) error {
+	pair, ok := m.selectedPair()
+	if !ok {
+		return fmt.Errorf("no sync pair selected")
+	}
+	subpath, err := syncconfig.CleanSubpath(value)
+	if err != nil {
+		return err
+	}
+	if pair.Direction == "download" {
+		return nil
+	}
+	return syncconfig.ValidateLocalPath(filepath.Join(pair.LocalPath, subpath))
+}
+
+// startSubpathSync syncs the entered folder of the selected pair with
+// 'cloud-sync sync --path' and returns to the list
+func (m SyncPairsModel) startSubpathSync() (tea.Model, tea.Cmd) {
+	pair, _ := m.selectedPair()
+	subpath, _ := syncconfig.CleanSubpath(m.textInput.Value())
+	m.currentStep = SyncPairsStepList
+	m.setPathCompletion(false)
+	m.syncing = fmt.Sprintf("%s of '%s'", subpath, pair.Name)
+	m.syncArgs = []string{"--path", subpath, pair.Name}
+	m.message = ""
+	m.results = nil
+	m.error = nil
+	return m, tea.Batch(m.spinner.Tick, streamSyncCmd(m.syncing, m.syncArgs...))
+}
+
+// handleOpenTrash opens the trash browser for the selected sync pair
+func (m SyncPairsModel) handleOpenTrash() (tea.Model, tea.Cmd) {
+	pair, ok := m.selectedPair()
+	if !ok {
+		return m, nil
+	}
+	if !pair.SoftDelete {
+		m.error = fmt.Errorf("soft delete is not enabled for '%s'", pair.Name)
+		return m, nil
+	}
+	m.error = nil
+	return m, OpenViewCmd(NewTrashModel(m.rclone, pair.Expand(syncconfig.CurrentPathVars())))
+}
+
+// handleOpenPointInTime opens the point-in-time restore for the selected
+// sync pair
+func (m SyncPairsModel) handleOpenPointInTime() (tea.Model, tea.Cmd) {
+	pair, ok := m.selectedPair()
+	if !ok {
+		return m, nil
+	}
+	if err := refuseReadOnly("Point-in-time restore"); err != nil {
+		m.error = err
+		return m, nil
+	}
+	m.error = nil
+	return m, OpenViewCmd(NewPointInTimeModel(m.rclone, pair.Expand(syncconfig.CurrentPathVars())))
+}
+
+// handleOpenVersions opens the file version browser for the selected sync
+// pair
+func (m SyncPairsModel) handleOpenVersions() (tea.Model, tea.Cmd) {
+	pair, ok := m.selectedPair()
+	if !ok {
+		return m, nil
+	}
+	m.error = nil
+	return m, OpenViewCmd(NewVersionsModel(m.rclone, pair.Expand(syncconfig.CurrentPathVars())))
+}
+
+// handleOpenQueue opens the sync queue of the configured log directory
+func (m SyncPairsModel) handleOpenQueue() (tea.Model, tea.Cmd) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		m.error = err
+		return m, nil
+	}
+	appConfig, err := configManager.Load()
+	if err != nil {
+		m.error = fmt.Errorf("failed to load config: %w", err)
+		return m, nil
+	}
+	m.error = nil
+	return m, OpenViewCmd(NewSyncQueueModel(queue.New(appConfig.LogDir), m.width, m.height))
+}
+
 // loadSyncPairs loads the list of sync pairs
 func (m SyncPairsModel) loadSyncPairs() tea.Cmd {
 	return func() tea.Msg {
 		pairs, err := m.syncConfig.ListSyncPairs()
-		return syncPairsLoaded{pairs: pairs, err: err}
+		return syncPairsLoaded{pairs: pairs, labels: loadRemoteLabels(), err: err}
+	}
+}
+
+// loadBuckets lists the buckets of a bucket-based remote, from the listing
+// cache unless refresh is set. Other remotes, and remotes that cannot be
+// listed, are not checked.
+func (m SyncPairsModel) loadBuckets(remote string, refresh bool) tea.Cmd {
+	return func() tea.Msg {
+		remoteType, err := m.rclone.GetRemoteType(remote)
+		if err != nil || !rclone.IsBucketBased(remoteType) {
+			return remoteBucketsLoaded{remote: remote, remoteType: remoteType}
+		}
+		cache := listingCache()
+		if refresh {
+			cache.Forget(remote + ":")
+		}
+		buckets, listedAt, err := m.rclone.CachedListBuckets(cache, remote)
+		if err != nil {
+			return remoteBucketsLoaded{remote: remote, remoteType: remoteType}
+		}
+		names := make([]string, 0, len(buckets))
+		for _, b := range buckets {
+			names = append(names, b.Name)
+		}
+		return remoteBucketsLoaded{remote: remote, remoteType: remoteType, buckets: names, listed: true, listedAt: listedAt}
+	}
+}
+
+// createBucket creates a bucket on a remote
+func (m SyncPairsModel) createBucket(remote, bucket string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.rclone.CreateBucket(remote, bucket); err != nil {
+			return bucketCreated{bucket: bucket, err: err}
+		}
+		listingCache().Forget(remote + ":")
+		return bucketCreated{bucket: bucket}
+	}
+}
+
+// runSyncCmd runs 'cloud-sync sync' with args in the background and
+// reports the last line it printed, with the first hint it gave on a
+// failure
+func runSyncCmd(target string, args ...string) tea.Cmd {
+	return func() tea.Msg {
+		program, err := os.Executable()
+		if err != nil {
+			return pairsSynced{target: target, summary: "cannot find the cloud-sync binary", err: err}
+		}
+		output, err := simulate.Command(program, append([]string{"sync"}, args...)...).CombinedOutput()
+		return syncOutcome(target, output, err)
 	}
 }
 
 // Message types
 type syncPairsLoaded struct {
-	pairs []syncconfig.SyncPair
-	err   error
+	pairs  []syncconfig.SyncPair
+	labels remoteLabels
+	err    error
+}
+
+type remoteBucketsLoaded struct {
+	remote     string
+	remoteType string
+	buckets    []string
+	listed     bool
+	listedAt   time.Time
+}
+
+type bucketCreated struct {
+	bucket string
+	err    error
+}
+
+type pairsSynced struct {
+	target       string   // e.g. "group 'photos'"
+	summary      string   // Last line of the sync's output
+	hint         string   // What to do about a failure, if the sync said
+	results      []string // Table of how each pair ended, when there were several
+	deleteLimits []string // Why each pair its delete limit stopped was stopped
+	blocked      []string // Names of those pairs
+	err          error
 }
@@ -0,0 +1,182 @@
+package views
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// progressLinePattern matches the progress lines of 'cloud-sync sync
+// --progress', e.g. "Progress: 1 of 3 pairs done, Photos: 12 of 40 files,
+// 1024 of 4096 bytes"
+var progressLinePattern = regexp.MustCompile(`^Progress: (\d+) of (\d+) pairs done, (.*): (\d+) of (\d+) files, (\d+) of (\d+) bytes$`)
+
+// deleteLimitPattern matches the line 'cloud-sync sync' prints for a pair
+// its delete limit stopped, e.g. "Docs: sync would delete 3 of 4 files
+// (75%) in b2:bucket/docs, more than the 50% limit of 'Docs'"
+var deleteLimitPattern = regexp.MustCompile(`^.*?: (sync would delete .* limit of '(.+)')$`)
+
+// syncProgressMsg is the latest progress of a sync started from the list
+type syncProgressMsg struct {
+	done       int // Pairs synced so far
+	total      int
+	pair       string // Pair being synced
+	files      int
+	totalFiles int
+	bytes      int64
+	totalBytes int64
+	stream     *syncStream
+}
+
+// parseProgressLine reads a progress line of 'cloud-sync sync --progress'
+func parseProgressLine(line string) (syncProgressMsg, bool) {
+	match := progressLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return syncProgressMsg{}, false
+	}
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	atoi64 := func(s string) int64 {
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+	return syncProgressMsg{
+		done:       atoi(match[1]),
+		total:      atoi(match[2]),
+		pair:       match[3],
+		files:      atoi(match[4]),
+		totalFiles: atoi(match[5]),
+		bytes:      atoi64(match[6]),
+		totalBytes: atoi64(match[7]),
+	}, true
+}
+
+// syncStream carries the progress of a 'cloud-sync sync' subprocess, then
+// its result
+type syncStream struct {
+	progress chan syncProgressMsg
+	done     chan pairsSynced
+}
+
+// streamSyncCmd runs 'cloud-sync sync --progress' with args in the
+// background, reporting its progress lines as they come and then, like
+// runSyncCmd, its outcome
+func streamSyncCmd(target string, args ...string) tea.Cmd {
+	stream := &syncStream{
+		progress: make(chan syncProgressMsg, 1),
+		done:     make(chan pairsSynced, 1),
+	}
+	go func() {
+		defer close(stream.progress)
+		program, err := os.Executable()
+		if err != nil {
+			stream.done <- pairsSynced{target: target, summary: "cannot find the cloud-sync binary", err: err}
+			return
+		}
+
+		reader, writer := io.Pipe()
+		cmd := simulate.Command(program, append([]string{"sync", "--progress"}, args...)...)
+		cmd.Stdout = writer
+		cmd.Stderr = writer
+		if err := cmd.Start(); err != nil {
+			stream.done <- syncOutcome(target, nil, err)
+			return
+		}
+		waited := make(chan error, 1)
+		go func() {
+			err := cmd.Wait()
+			writer.Close()
+			waited <- err
+		}()
+
+		var output strings.Builder
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			line := scanner.Text()
+			msg, ok := parseProgressLine(line)
+			if !ok {
+				output.WriteString(line + "\n")
+				continue
+			}
+			// Only the latest progress matters; drop an update the view
+			// has not picked up yet
+			select {
+			case <-stream.progress:
+			default:
+			}
+			msg.stream = stream
+			stream.progress <- msg
+		}
+		io.Copy(io.Discard, reader)
+		stream.done <- syncOutcome(target, []byte(output.String()), <-waited)
+	}()
+	return waitForSync(stream)
+}
+
+// waitForSync waits for the next progress of a sync, or its outcome once it
+// has finished
+func waitForSync(stream *syncStream) tea.Cmd {
+	return func() tea.Msg {
+		if msg, ok := <-stream.progress; ok {
+			return msg
+		}
+		return <-stream.done
+	}
+}
+
+// syncOutcome reads the output of 'cloud-sync sync': its last line, the
+// first hint it gave on a failure and, when it synced several pairs, the
+// table of how each ended
+func syncOutcome(target string, output []byte, err error) pairsSynced {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	summary := lines[len(lines)-1]
+	if summary == "" && err != nil {
+		summary = err.Error()
+	}
+	var hint string
+	var results, deleteLimits, blocked []string
+	for i, line := range lines {
+		if h, ok := strings.CutPrefix(strings.TrimSpace(line), "Hint: "); ok && hint == "" {
+			hint = h
+		}
+		if match := deleteLimitPattern.FindStringSubmatch(line); match != nil && err != nil {
+			deleteLimits = append(deleteLimits, match[1])
+			blocked = append(blocked, match[2])
+		}
+		if strings.HasPrefix(line, "PAIR ") && results == nil {
+			results = lines[i : len(lines)-1]
+		}
+	}
+	return pairsSynced{target: target, summary: summary, hint: hint, results: results, deleteLimits: deleteLimits, blocked: blocked, err: err}
+}
+
+// renderSyncProgress shows a bar of the pairs synced and one of the active
+// pair's bytes, with its files and bytes
+func renderSyncProgress(bar progress.Model, msg *syncProgressMsg) string {
+	var b strings.Builder
+	if msg.total > 1 {
+		b.WriteString(fmt.Sprintf("Pairs: %d of %d\n", msg.done, msg.total))
+		b.WriteString(bar.ViewAs(float64(msg.done) / float64(msg.total)))
+		b.WriteString("\n")
+	}
+	b.WriteString(styles.RenderMuted(fmt.Sprintf("%s: %d of %d files, %s of %s",
+		msg.pair, msg.files, msg.totalFiles, formatBytes(msg.bytes), formatBytes(msg.totalBytes))))
+	b.WriteString("\n")
+	if msg.totalBytes > 0 {
+		b.WriteString(bar.ViewAs(float64(msg.bytes) / float64(msg.totalBytes)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,219 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/queue"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// syncQueueRefresh is how often the queue view reloads the queue, which
+// other processes change as they run it
+const syncQueueRefresh = 2 * time.Second
+
+// syncQueueLoaded carries the jobs of the sync queue
+type syncQueueLoaded struct {
+	jobs []queue.Job
+	err  error
+}
+
+// syncQueueTick asks the queue view to reload the queue
+type syncQueueTick struct{}
+
+// SyncQueueModel lists the syncs waiting in the queue and the one running,
+// and reorders or cancels them
+type SyncQueueModel struct {
+	queue     *queue.Queue
+	jobs      []queue.Job
+	loaded    bool
+	jobsTable table.Model
+	width     int
+	height    int
+	err       error
+	message   string
+}
+
+// NewSyncQueueModel creates the view of syncQueue
+func NewSyncQueueModel(syncQueue *queue.Queue, width, height int) SyncQueueModel {
+	columns := []table.Column{
+		{Title: "#", Width: 3},
+		{Title: "Pair", Width: 32},
+		{Title: "Trigger", Width: 10},
+		{Title: "Priority", Width: 8},
+		{Title: "State", Width: 16},
+		{Title: "Queued", Width: 12},
+	}
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithHeight(10),
+		table.WithFocused(true),
+	)
+	t.SetStyles(styles.TableStyles())
+
+	return SyncQueueModel{queue: syncQueue, jobsTable: t, width: width, height: height}
+}
+
+// Init implements tea.Model
+func (m SyncQueueModel) Init() tea.Cmd {
+	return tea.Batch(m.loadJobs(), tickSyncQueue())
+}
+
+// Update implements tea.Model
+func (m SyncQueueModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			return m, BackCmd()
+		case "r":
+			return m, m.loadJobs()
+		case "K", "shift+up":
+			return m.change("Reordering the queue", func(job queue.Job) (string, error) {
+				return "", m.queue.Move(job.ID, -1)
+			})
+		case "J", "shift+down":
+			return m.change("Reordering the queue", func(job queue.Job) (string, error) {
+				return "", m.queue.Move(job.ID, 1)
+			})
+		case "x", "delete":
+			return m.change("Cancelling syncs", func(job queue.Job) (string, error) {
+				if job.Running() {
+					return fmt.Sprintf("Stopping the sync of %s", job.Target()), m.queue.Cancel(job.ID)
+				}
+				return fmt.Sprintf("Removed %s from the queue", job.Target()), m.queue.Cancel(job.ID)
+			})
+		}
+
+		var cmd tea.Cmd
+		m.jobsTable, cmd = m.jobsTable.Update(msg)
+		return m, cmd
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case syncQueueLoaded:
+		m.loaded = true
+		if msg.err != nil {
+			m.err = msg.err
+		} else {
+			m.jobs = msg.jobs
+			m.updateJobsTable()
+		}
+		return m, nil
+
+	case syncQueueTick:
+		return m, tea.Batch(m.loadJobs(), tickSyncQueue())
+	}
+
+	return m, nil
+}
+
+// change runs fn on the job under the cursor and reloads the queue. The
+// cursor follows a job moved with it.
+func (m SyncQueueModel) change(action string, fn func(job queue.Job) (string, error)) (tea.Model, tea.Cmd) {
+	job, ok := m.selected()
+	if !ok {
+		return m, nil
+	}
+	if err := refuseReadOnly(action); err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	message, err := fn(job)
+	m.err, m.message = err, message
+	jobs, loadErr := m.queue.Jobs()
+	if loadErr != nil {
+		m.err = loadErr
+		return m, nil
+	}
+	m.jobs = jobs
+	m.updateJobsTable()
+	for i, other := range jobs {
+		if other.ID == job.ID {
+			m.jobsTable.SetCursor(i)
+		}
+	}
+	return m, nil
+}
+
+// selected returns the job under the cursor
+func (m SyncQueueModel) selected() (queue.Job, bool) {
+	i := m.jobsTable.Cursor()
+	if i < 0 || i >= len(m.jobs) {
+		return queue.Job{}, false
+	}
+	return m.jobs[i], true
+}
+
+// View implements tea.Model
+func (m SyncQueueModel) View() string {
+	var b strings.Builder
+
+	helper := NewViewHelper(m.width, m.height)
+	b.WriteString(helper.RenderHeader("Sync Queue", "Syncs waiting to run, most urgent first"))
+
+	if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	}
+	if m.message != "" {
+		b.WriteString(styles.RenderSuccess(m.message))
+		b.WriteString("\n\n")
+	}
+
+	switch {
+	case !m.loaded:
+		b.WriteString(styles.RenderInfo("Loading queue..."))
+		b.WriteString("\n")
+	case len(m.jobs) == 0:
+		b.WriteString(styles.RenderMuted("Nothing is queued. Syncs started by hand, on schedule or by the drive and power agents wait here while another sync runs."))
+		b.WriteString("\n")
+	default:
+		b.WriteString(m.jobsTable.View())
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helper.RenderFooter(keyFooter(syncQueueKeys)))
+
+	return b.String()
+}
+
+// updateJobsTable fills the table with the jobs
+func (m *SyncQueueModel) updateJobsTable() {
+	rows := make([]table.Row, 0, len(m.jobs))
+	position := 0
+	for _, job := range m.jobs {
+		number, state := "▶", "Waiting"
+		switch {
+		case job.Cancelling:
+			state = "Stopping..."
+		case job.Running():
+			state = fmt.Sprintf("Running %s", time.Since(job.Started).Round(time.Second))
+		default:
+			position++
+			number = fmt.Sprintf("%d", position)
+		}
+		rows = append(rows, table.Row{number, job.Target(), job.Trigger, job.Priority.String(), state, job.Queued.Format("Jan 2 15:04")})
+	}
+	m.jobsTable.SetRows(rows)
+}
+
+// tickSyncQueue returns a command that asks for a reload after
+// syncQueueRefresh
+func tickSyncQueue() tea.Cmd {
+	return tea.Tick(syncQueueRefresh, func(time.Time) tea.Msg { return syncQueueTick{} })
+}
+
+// loadJobs returns a command that reads the queue
+func (m SyncQueueModel) loadJobs() tea.Cmd {
+	return func() tea.Msg {
+		jobs, err := m.queue.Jobs()
+		return syncQueueLoaded{jobs: jobs, err: err}
+	}
+}
@@ -0,0 +1,499 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// What the guided tour creates. It finds them again by these names, so it
+// can be taken again and remove them at the end.
+const (
+	TourDirName    = "cloud-sync-tour" // In the home directory
+	TourRemoteName = "tour"
+	TourPairName   = "Tour"
+)
+
+// tourSampleFiles are the files of the tour's practice folder
+var tourSampleFiles = []struct {
+	path    string
+	content string
+}{
+	{"README.txt", "These files were created by the Cloud Sync guided tour.\nChange them, add some, and sync the Tour pair again.\n"},
+	{"notes/ideas.txt", "- Back up the Documents folder to B2\n- Keep photos on an external drive too\n"},
+	{"notes/todo.txt", "- Take the guided tour\n- Add a real remote\n"},
+}
+
+// tourStep is where the tour is
+type tourStep int
+
+const (
+	tourIntro tourStep = iota
+	tourFolder
+	tourRemote
+	tourPair
+	tourSync
+	tourFinish
+)
+
+// TourModel walks a new user through a first backup without a cloud
+// account: it creates a practice folder, a remote that keeps its files in
+// another folder on this Mac, a sync pair between the two, and syncs it,
+// explaining each step
+type TourModel struct {
+	config      *config.Manager
+	syncConfig  *syncconfig.Manager
+	rclone      *rclone.Manager
+	dir         string // Holds the practice folder and the remote's folder
+	step        tourStep
+	checking    bool  // Checking rclone runs
+	missing     error // Why rclone cannot run
+	working     bool  // The step's action is running
+	progress    *syncProgressMsg
+	progBar     progress.Model
+	spinner     spinner.Model
+	synced      []string // Files in the remote's folder after the sync
+	message     string   // Outcome of the last step
+	err         error
+	credentials error // Credential references that did not resolve, see WithCredentialsError
+	width       int
+	height      int
+}
+
+// NewTourModel creates the guided tour, which works in the folder
+// TourDirName of the home directory
+func NewTourModel(configManager *config.Manager, syncConfigMgr *syncconfig.Manager, rcloneMgr *rclone.Manager) TourModel {
+	home, _ := os.UserHomeDir()
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = styles.SpinnerStyle
+
+	return TourModel{
+		config:     configManager,
+		syncConfig: syncConfigMgr,
+		rclone:     rcloneMgr,
+		dir:        filepath.Join(home, TourDirName),
+		checking:   true,
+		progBar:    progress.New(progress.WithDefaultGradient()),
+		spinner:    s,
+	}
+}
+
+// WithCredentialsError makes the tour warn that the credential references
+// err names did not resolve, as returned by NewRcloneManager
+func (m TourModel) WithCredentialsError(err error) TourModel {
+	m.credentials = err
+	return m
+}
+
+// localPath returns the practice folder the tour backs up
+func (m TourModel) localPath() string {
+	return filepath.Join(m.dir, "Documents")
+}
+
+// remotePath returns the folder the tour's remote keeps its files in
+func (m TourModel) remotePath() string {
+	return filepath.Join(m.dir, "Cloud")
+}
+
+// Init implements tea.Model
+func (m TourModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.checkRclone())
+}
+
+// Update implements tea.Model
+func (m TourModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case spinner.TickMsg:
+		if !m.checking && !m.working {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case tourChecked:
+		m.checking = false
+		m.missing = msg.err
+		return m, nil
+
+	case tourStepDone:
+		m.working = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.message = msg.message
+			m.step++
+		}
+		return m, nil
+
+	case syncProgressMsg:
+		m.progress = &msg
+		return m, waitForSync(msg.stream)
+
+	case pairsSynced:
+		m.working = false
+		m.progress = nil
+		if msg.err != nil {
+			m.message = ""
+			m.err = &hintedError{fmt.Errorf("syncing %s failed: %s", msg.target, msg.summary), msg.hint}
+			return m, nil
+		}
+		m.synced, m.err = listFiles(m.remotePath())
+		m.message = fmt.Sprintf("%s: %s", msg.target, msg.summary)
+		m.step = tourFinish
+		return m, nil
+
+	case tourRemoved:
+		m.working = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		return m, DoneCmd("Removed the tour's folder, remote and sync pair")
+
+	case tea.KeyMsg:
+		if m.checking || m.working {
+			return m, nil
+		}
+		switch msg.String() {
+		case "q", "esc":
+			return m, BackCmd()
+		case "enter":
+			return m.next()
+		case "d":
+			// Anything from the folder on may have been created
+			if m.step > tourFolder {
+				m.working = true
+				m.err = nil
+				return m, tea.Batch(m.spinner.Tick, m.remove())
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// next runs the action of the current step
+func (m TourModel) next() (tea.Model, tea.Cmd) {
+	if m.missing != nil {
+		return m, BackCmd()
+	}
+	if m.step == tourFinish {
+		return m, OpenViewCmd(NewSyncPairsModel(m.syncConfig, m.rclone))
+	}
+	if err := refuseReadOnly("The guided tour"); err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.err = nil
+	m.message = ""
+	m.working = true
+	var cmd tea.Cmd
+	switch m.step {
+	case tourIntro:
+		m.working = false
+		m.step = tourFolder
+		return m, nil
+	case tourFolder:
+		cmd = m.createFolder()
+	case tourRemote:
+		cmd = m.addRemote()
+	case tourPair:
+		cmd = m.addPair()
+	case tourSync:
+		cmd = streamSyncCmd(fmt.Sprintf("'%s'", TourPairName), TourPairName)
+	}
+	return m, tea.Batch(m.spinner.Tick, cmd)
+}
+
+// checkRclone returns a command that checks rclone, which does the sync,
+// can run
+func (m TourModel) checkRclone() tea.Cmd {
+	return func() tea.Msg {
+		_, err := m.rclone.Version()
+		return tourChecked{err: err}
+	}
+}
+
+// createFolder returns a command that creates the practice folder with the
+// sample files, keeping any the user changed on an earlier tour, and the
+// remote's folder
+func (m TourModel) createFolder() tea.Cmd {
+	local, remote := m.localPath(), m.remotePath()
+	return func() tea.Msg {
+		if err := os.MkdirAll(remote, 0755); err != nil {
+			return tourStepDone{err: fmt.Errorf("failed to create %s: %w", remote, err)}
+		}
+		for _, file := range tourSampleFiles {
+			path := filepath.Join(local, filepath.FromSlash(file.path))
+			if _, err := os.Stat(path); err == nil {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return tourStepDone{err: fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)}
+			}
+			if err := os.WriteFile(path, []byte(file.content), 0644); err != nil {
+				return tourStepDone{err: fmt.Errorf("failed to write %s: %w", path, err)}
+			}
+		}
+		return tourStepDone{message: fmt.Sprintf("Created %s with %d files", local, len(tourSampleFiles))}
+	}
+}
+
+// addRemote returns a command that adds the tour's remote and writes it to
+// rclone.conf. A local remote of that name from an earlier tour is kept.
+func (m TourModel) addRemote() tea.Cmd {
+	return func() tea.Msg {
+		appConfig, err := m.config.Load()
+		if err != nil {
+			return tourStepDone{err: fmt.Errorf("failed to load config: %w", err)}
+		}
+		for _, remote := range appConfig.Remotes {
+			if remote.Name != TourRemoteName {
+				continue
+			}
+			if remote.Type != config.TypeLocal {
+				return tourStepDone{err: &hintedError{
+					fmt.Errorf("a %s remote named '%s' already exists", remote.Type, TourRemoteName),
+					"Rename it in Installation & Setup → Manage Remotes to take the tour.",
+				}}
+			}
+			return tourStepDone{message: fmt.Sprintf("Remote '%s' is already set up", TourRemoteName)}
+		}
+
+		if err := m.config.AddRemote(config.RemoteConfig{Name: TourRemoteName, Type: config.TypeLocal}); err != nil {
+			return tourStepDone{err: err}
+		}
+		if err := m.config.GenerateRcloneConfig(); err != nil {
+			return tourStepDone{err: err}
+		}
+		return tourStepDone{message: fmt.Sprintf("Added remote '%s' to %s", TourRemoteName, appConfig.RcloneConfig)}
+	}
+}
+
+// addPair returns a command that adds the tour's sync pair. The pair of an
+// earlier tour is kept.
+func (m TourModel) addPair() tea.Cmd {
+	pair := syncconfig.SyncPair{
+		Name:       TourPairName,
+		LocalPath:  m.localPath(),
+		RemoteName: TourRemoteName,
+		RemotePath: m.remotePath(),
+		Direction:  "upload",
+		Enabled:    true,
+	}
+	return func() tea.Msg {
+		existing, err := m.syncConfig.GetSyncPair(TourPairName)
+		var notFound *syncconfig.PairNotFoundError
+		switch {
+		case err == nil && existing.LocalPath == pair.LocalPath && existing.RemoteName == TourRemoteName:
+			return tourStepDone{message: fmt.Sprintf("Sync pair '%s' is already set up", TourPairName)}
+		case err == nil:
+			return tourStepDone{err: &hintedError{
+				fmt.Errorf("a sync pair named '%s' already exists", TourPairName),
+				"Rename or delete it in Sync Pairs to take the tour.",
+			}}
+		case !errors.As(err, &notFound):
+			return tourStepDone{err: err}
+		}
+
+		if err := m.syncConfig.AddSyncPair(pair); err != nil {
+			return tourStepDone{err: err}
+		}
+		return tourStepDone{message: fmt.Sprintf("Added sync pair '%s'", TourPairName)}
+	}
+}
+
+// remove returns a command that removes the tour's sync pair, remote and
+// folder, whichever exist
+func (m TourModel) remove() tea.Cmd {
+	return func() tea.Msg {
+		var notFound *syncconfig.PairNotFoundError
+		if err := m.syncConfig.RemoveSyncPair(TourPairName); err != nil && !errors.As(err, &notFound) {
+			return tourRemoved{err: err}
+		}
+
+		appConfig, err := m.config.Load()
+		if err != nil {
+			return tourRemoved{err: fmt.Errorf("failed to load config: %w", err)}
+		}
+		for _, remote := range appConfig.Remotes {
+			if remote.Name != TourRemoteName || remote.Type != config.TypeLocal {
+				continue
+			}
+			if err := m.config.RemoveRemote(TourRemoteName); err != nil {
+				return tourRemoved{err: err}
+			}
+			if err := m.config.GenerateRcloneConfig(); err != nil {
+				return tourRemoved{err: err}
+			}
+		}
+
+		if err := os.RemoveAll(m.dir); err != nil {
+			return tourRemoved{err: fmt.Errorf("failed to remove %s: %w", m.dir, err)}
+		}
+		return tourRemoved{}
+	}
+}
+
+// listFiles returns the files under dir, relative to it
+func listFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// stepText returns the title of the current step, what it explains, and
+// what enter does
+func (m TourModel) stepText() (title, callout, action string) {
+	local, remote := m.localPath(), m.remotePath()
+	switch m.step {
+	case tourIntro:
+		return "Welcome",
+			fmt.Sprintf("This tour makes a first backup without a cloud account. It creates:\n\n"+
+				"• a practice folder with a few files, %s\n"+
+				"• a remote named '%s' that keeps its files in %s instead of a cloud provider\n"+
+				"• a sync pair '%s' that uploads the folder to the remote\n\n"+
+				"and then syncs it. Each step explains what it does before it runs. At the end, the tour can remove everything it created.",
+				local, TourRemoteName, remote, TourPairName),
+			"Start"
+	case tourFolder:
+		return "Step 1 of 4: A folder to back up",
+			fmt.Sprintf("A backup starts from a folder on this Mac, such as Documents or Pictures. "+
+				"The tour creates %s with %d small text files to practise on.", local, len(tourSampleFiles)),
+			"Create the folder"
+	case tourRemote:
+		return "Step 2 of 4: A remote",
+			fmt.Sprintf("A remote is where backups go: a B2 or S3 bucket, an SFTP server, a WebDAV share... "+
+				"Cloud Sync keeps remotes in its settings and writes them to rclone.conf for rclone, which does the transfers.\n\n"+
+				"The tour's remote, '%s', is of rclone's local type: its \"cloud\" is the folder %s, so it needs no account or keys. "+
+				"Real remotes are added in Installation & Setup → Manage Remotes.", TourRemoteName, remote),
+			"Add the remote"
+	case tourPair:
+		return "Step 3 of 4: A sync pair",
+			fmt.Sprintf("A sync pair joins a local folder to a path on a remote, in a direction: upload sends local changes to the remote, "+
+				"download brings the remote's files here, and bidirectional does both.\n\n"+
+				"'%s' uploads %s to %s:%s. Pairs are listed, added and synced in Sync Pairs.", TourPairName, local, TourRemoteName, remote),
+			"Add the pair"
+	case tourSync:
+		return "Step 4 of 4: The first sync",
+			"Syncing makes the remote match the folder: new and changed files are uploaded and, in the default sync mode, " +
+				"files deleted from the folder are deleted from the remote too.\n\n" +
+				fmt.Sprintf("It is the same sync s runs in Sync Pairs and 'cloud-sync sync %s' runs in a terminal. ", TourPairName) +
+				"Each run is logged, and listed in the Log Viewer.",
+			"Sync now"
+	default:
+		return "Done",
+			fmt.Sprintf("Your first backup is in %s. Try changing or adding a file in %s, then sync '%s' again from Sync Pairs: "+
+				"only the changes are uploaded.\n\n"+
+				"'%s' is enabled, so scheduled backups include it until it is removed. "+
+				"When you are ready, add a real remote in Installation & Setup.", remote, local, TourPairName, TourPairName),
+			"Open Sync Pairs"
+	}
+}
+
+// View implements tea.Model
+func (m TourModel) View() string {
+	helper := NewViewHelper(m.width, m.height)
+	var b strings.Builder
+
+	title, callout, action := m.stepText()
+	b.WriteString(helper.RenderHeader("Guided Tour", title))
+	b.WriteString(renderCredentialsWarning(m.credentials))
+
+	if m.checking {
+		b.WriteString(fmt.Sprintf("%s Checking rclone...", m.spinner.View()))
+		return b.String()
+	}
+	if m.missing != nil {
+		b.WriteString(renderErrorHint(fmt.Sprintf("rclone cannot run: %v", m.missing), "The tour syncs with rclone. Install it in Installation & Setup, then take the tour again."))
+		b.WriteString(helper.RenderFooter("q/esc: Back"))
+		return b.String()
+	}
+
+	width := m.width - 4
+	if width <= 0 || width > 80 {
+		width = 80
+	}
+	b.WriteString(styles.BoxStyle.Width(width).Render(callout))
+	b.WriteString("\n\n")
+
+	if m.step == tourFinish && len(m.synced) > 0 {
+		b.WriteString(fmt.Sprintf("Files on the remote:\n  %s\n\n", strings.Join(m.synced, "\n  ")))
+	}
+
+	switch {
+	case m.working && m.step == tourSync:
+		b.WriteString(fmt.Sprintf("%s %s\n", m.spinner.View(), styles.RenderInfo(fmt.Sprintf("Syncing '%s'...", TourPairName))))
+		if m.progress != nil {
+			b.WriteString("\n")
+			b.WriteString(renderSyncProgress(m.progBar, m.progress))
+		}
+	case m.working:
+		b.WriteString(fmt.Sprintf("%s Working...\n", m.spinner.View()))
+	case m.err != nil:
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n")
+	case m.message != "":
+		b.WriteString(styles.RenderSuccess("✓ " + m.message))
+		b.WriteString("\n")
+	}
+
+	switch {
+	case m.working:
+		b.WriteString(helper.RenderFooter("Please wait..."))
+	case m.step > tourFolder:
+		b.WriteString(helper.RenderFooter("enter: " + action + " • d: Remove what the tour created • q/esc: Leave the tour"))
+	default:
+		b.WriteString(helper.RenderFooter("enter: " + action + " • q/esc: Leave the tour"))
+	}
+
+	return b.String()
+}
+
+// Message types
+type tourChecked struct {
+	err error
+}
+
+type tourStepDone struct {
+	message string
+	err     error
+}
+
+type tourRemoved struct {
+	err error
+}
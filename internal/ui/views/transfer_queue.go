@@ -0,0 +1,129 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// maxCompletedTransfers is how many finished files the transfer queue keeps
+const maxCompletedTransfers = 50
+
+// transferNameWidth is the width of the file column of the transfer queue
+const transferNameWidth = 40
+
+// QueuedTransfer is a file in the transfer queue
+type QueuedTransfer struct {
+	Name    string
+	Size    int64
+	Bytes   int64
+	Speed   float64 // Bytes per second
+	Percent int
+	Done    bool
+	Failed  bool // Finished while rclone's error count rose
+}
+
+// TransferQueue follows the files of a running backup. rclone's core/stats
+// only lists the files in flight, so a file that drops off that list is
+// taken to have finished and kept as recently completed. Nor does it say
+// which file an error belongs to, so the files that finished while the
+// error count rose are all marked failed.
+type TransferQueue struct {
+	Active    []QueuedTransfer
+	Completed []QueuedTransfer // Newest first
+	Errors    int              // rclone's error count so far
+}
+
+// Update replaces the files in flight with rclone's transferring list and
+// moves the files that have left it to Completed, marking them failed when
+// errorCount, rclone's error count, has risen since the last update
+func (q TransferQueue) Update(transferring []rclone.RCTransfer, errorCount int) TransferQueue {
+	inFlight := make(map[string]bool, len(transferring))
+	active := make([]QueuedTransfer, 0, len(transferring))
+	for _, t := range transferring {
+		inFlight[t.Name] = true
+		active = append(active, QueuedTransfer{
+			Name:    t.Name,
+			Size:    t.Size,
+			Bytes:   t.Bytes,
+			Speed:   t.Speed,
+			Percent: t.Percentage,
+		})
+	}
+
+	var finished []QueuedTransfer
+	for _, t := range q.Active {
+		if inFlight[t.Name] {
+			continue
+		}
+		t.Done = true
+		if errorCount > q.Errors {
+			t.Failed = true
+		} else {
+			t.Bytes = t.Size
+			t.Percent = 100
+		}
+		finished = append(finished, t)
+	}
+	completed := append(finished, q.Completed...)
+	if len(completed) > maxCompletedTransfers {
+		completed = completed[:maxCompletedTransfers]
+	}
+
+	return TransferQueue{Active: active, Completed: completed, Errors: errorCount}
+}
+
+// Rows returns a table row per file, those in flight first
+func (q TransferQueue) Rows() []table.Row {
+	rows := []table.Row{}
+	for _, t := range append(append([]QueuedTransfer{}, q.Active...), q.Completed...) {
+		status := "↻ Moving"
+		speed := fmt.Sprintf("%s/s", formatBytes(int64(t.Speed)))
+		switch {
+		case t.Failed:
+			status = "✗ Failed"
+			speed = "-"
+		case t.Done:
+			status = "✓ Done"
+			speed = "-"
+		}
+		rows = append(rows, table.Row{
+			status,
+			shortenPath(t.Name, transferNameWidth),
+			formatBytes(t.Size),
+			speed,
+			fmt.Sprintf("%d%%", t.Percent),
+		})
+	}
+	return rows
+}
+
+// newTransferTable creates the table showing the transfer queue
+func newTransferTable() table.Model {
+	columns := []table.Column{
+		{Title: "Status", Width: 10},
+		{Title: "File", Width: transferNameWidth},
+		{Title: "Size", Width: 10},
+		{Title: "Speed", Width: 12},
+		{Title: "%", Width: 5},
+	}
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithHeight(8),
+		table.WithFocused(true),
+	)
+	t.SetStyles(styles.TableStyles())
+	return t
+}
+
+// shortenPath cuts the start off a path longer than width, keeping the file
+// name readable
+func shortenPath(path string, width int) string {
+	runes := []rune(path)
+	if len(runes) <= width {
+		return path
+	}
+	return "…" + string(runes[len(runes)-width+1:])
+}
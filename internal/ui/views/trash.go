@@ -0,0 +1,270 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// TrashModel represents the trash browser for a soft-delete sync pair
+type TrashModel struct {
+	rclone     *rclone.Manager
+	pair       syncconfig.SyncPair
+	entries    []rclone.TrashEntry
+	cursor     int
+	width      int
+	height     int
+	loading    bool
+	processing bool
+	confirming bool         // Waiting for y/n before deleting the selected entry
+	plan       *restorePlan // Restore whose download settings are being chosen
+	message    string
+	err        error
+}
+
+// NewTrashModel creates a new trash browser for a sync pair
+func NewTrashModel(rcloneMgr *rclone.Manager, pair syncconfig.SyncPair) TrashModel {
+	return TrashModel{
+		rclone:  rcloneMgr,
+		pair:    pair,
+		loading: true,
+	}
+}
+
+// Init implements tea.Model
+func (m TrashModel) Init() tea.Cmd {
+	return m.loadTrash()
+}
+
+// Update implements tea.Model
+func (m TrashModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case trashLoaded:
+		m.loading = false
+		m.entries = msg.entries
+		m.err = msg.err
+		if m.cursor >= len(m.entries) {
+			m.cursor = 0
+		}
+		return m, nil
+
+	case restorePlanned:
+		m.processing = false
+		m.err = msg.err
+		m.plan = msg.plan
+		return m, nil
+
+	case trashActionDone:
+		m.processing = false
+		m.err = msg.err
+		m.message = ""
+		if msg.err == nil {
+			m.message = msg.message
+		}
+		return m, m.loadTrash()
+
+	case tea.KeyMsg:
+		if m.processing || m.loading {
+			return m, nil
+		}
+		if m.plan != nil {
+			return m.updateRestorePlan(msg)
+		}
+
+		if m.confirming {
+			m.confirming = false
+			if msg.String() == "y" && len(m.entries) > 0 {
+				m.processing = true
+				return m, m.purgeEntry(m.entries[m.cursor])
+			}
+			m.message = "Delete cancelled"
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			return m, BackCmd()
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.entries)-1 {
+				m.cursor++
+			}
+		case "enter":
+			if err := refuseReadOnly("Restoring from the trash"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			if len(m.entries) > 0 {
+				m.processing = true
+				m.message = ""
+				return m, m.planRestore(m.entries[m.cursor])
+			}
+		case "d":
+			if err := refuseReadOnly("Deleting from the trash"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			if len(m.entries) > 0 {
+				m.confirming = true
+			}
+		case "p":
+			if err := refuseReadOnly("Purging the trash"); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.processing = true
+			return m, m.purgeExpired()
+		case "r":
+			m.loading = true
+			return m, m.loadTrash()
+		}
+	}
+
+	return m, nil
+}
+
+// View implements tea.Model
+func (m TrashModel) View() string {
+	helper := NewViewHelper(m.width, m.height)
+	var b strings.Builder
+
+	b.WriteString(helper.RenderHeader("Trash", fmt.Sprintf("Soft-deleted files for '%s'", m.pair.Name)))
+
+	if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	} else if m.message != "" {
+		b.WriteString(styles.RenderSuccess(m.message))
+		b.WriteString("\n\n")
+	}
+
+	switch {
+	case m.loading:
+		b.WriteString(styles.RenderInfo("Loading trash..."))
+		b.WriteString("\n")
+	case m.processing:
+		b.WriteString(styles.RenderInfo("Processing..."))
+		b.WriteString("\n")
+	case m.plan != nil:
+		b.WriteString(m.renderRestorePlan())
+	case len(m.entries) == 0:
+		b.WriteString("Trash is empty.\n")
+	default:
+		b.WriteString(m.renderEntries())
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted(fmt.Sprintf("Location: %s:%s",
+		m.pair.RemoteName, m.rclone.TrashRootFor(m.pair.RemoteName, m.pair.RemotePath, m.pair.Name))))
+	b.WriteString("\n")
+	b.WriteString(styles.RenderMuted(fmt.Sprintf("Retention: %d days",
+		int(m.pair.TrashRetention().Hours()/24))))
+
+	if m.confirming && len(m.entries) > 0 {
+		b.WriteString("\n\n")
+		b.WriteString(styles.RenderWarning(fmt.Sprintf("Permanently delete %s? (y/n)",
+			m.entries[m.cursor].Date.Format("2006-01-02"))))
+	}
+
+	if m.plan != nil {
+		b.WriteString(helper.RenderFooter("enter: Restore • tab: Next field • a: Auto-tune • esc: Cancel"))
+	} else {
+		b.WriteString(helper.RenderFooter(keyFooter(trashKeys)))
+	}
+
+	return b.String()
+}
+
+// renderEntries renders the list of dated trash folders
+func (m TrashModel) renderEntries() string {
+	var b strings.Builder
+	retention := m.pair.TrashRetention()
+	expired := rclone.ExpiredTrash(m.entries, retention, time.Now())
+
+	for i, entry := range m.entries {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = styles.RenderHighlight("> ")
+		}
+
+		line := entry.Date.Format("2006-01-02")
+		for _, e := range expired {
+			if e.Path == entry.Path {
+				line = styles.RenderMuted(line + " (expired)")
+				break
+			}
+		}
+
+		b.WriteString(fmt.Sprintf("%s%s\n", cursor, line))
+	}
+
+	return b.String()
+}
+
+// loadTrash returns a command that lists the pair's trash folders
+func (m TrashModel) loadTrash() tea.Cmd {
+	return func() tea.Msg {
+		entries, err := m.rclone.ListTrash(m.pair.RemoteName, m.pair.RemotePath, m.pair.Name)
+		return trashLoaded{entries: entries, err: err}
+	}
+}
+
+// restoreEntry returns a command that restores a trash folder locally,
+// downloading with tuning, and saves tuning as the remote's profile
+func (m TrashModel) restoreEntry(entry rclone.TrashEntry, tuning rclone.RestoreTuning) tea.Cmd {
+	return func() tea.Msg {
+		err := m.rclone.RestoreTrash(m.pair.RemoteName, entry, m.pair.LocalPath, tuning)
+		message := fmt.Sprintf("Restored %s into %s with %s", entry.Date.Format("2006-01-02"), m.pair.LocalPath, tuning)
+		if err == nil && saveRestoreTuning(m.pair.RemoteName, tuning) {
+			message += fmt.Sprintf(", saved for '%s'", m.pair.RemoteName)
+		}
+		return trashActionDone{message: message, err: err}
+	}
+}
+
+// purgeEntry returns a command that permanently deletes a trash folder
+func (m TrashModel) purgeEntry(entry rclone.TrashEntry) tea.Cmd {
+	return func() tea.Msg {
+		err := m.rclone.PurgeTrash(m.pair.RemoteName, entry)
+		return trashActionDone{
+			message: fmt.Sprintf("Deleted %s", entry.Date.Format("2006-01-02")),
+			err:     err,
+		}
+	}
+}
+
+// purgeExpired returns a command that purges trash past the retention period
+func (m TrashModel) purgeExpired() tea.Cmd {
+	return func() tea.Msg {
+		count, err := m.rclone.PurgeTrashOlderThan(m.pair.RemoteName, m.pair.RemotePath, m.pair.Name, m.pair.TrashRetention())
+		return trashActionDone{
+			message: fmt.Sprintf("Purged %d expired trash folder(s)", count),
+			err:     err,
+		}
+	}
+}
+
+// Message types
+type trashLoaded struct {
+	entries []rclone.TrashEntry
+	err     error
+}
+
+type trashActionDone struct {
+	message string
+	err     error
+}
@@ -0,0 +1,166 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+	"github.com/andreisuslov/cloud-sync/internal/uninstall"
+)
+
+// UninstallModel previews and runs a full uninstall
+type UninstallModel struct {
+	opts       uninstall.Options
+	plan       []uninstall.Item
+	result     *uninstall.Result
+	width      int
+	height     int
+	processing bool
+	confirming bool // Waiting for y/n before removing anything
+	err        error
+}
+
+// NewUninstallModel creates an uninstall view for the given options
+func NewUninstallModel(opts uninstall.Options) UninstallModel {
+	opts.DryRun = false
+	m := UninstallModel{opts: opts}
+	m.plan = uninstall.Plan(opts)
+	return m
+}
+
+// Init implements tea.Model
+func (m UninstallModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m UninstallModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case uninstallDone:
+		m.processing = false
+		m.result = msg.result
+		m.err = msg.err
+		m.plan = uninstall.Plan(m.opts)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.processing {
+			return m, nil
+		}
+
+		if m.confirming {
+			m.confirming = false
+			if msg.String() == "y" {
+				m.processing = true
+				return m, m.runUninstall()
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			return m, BackCmd()
+		case "l":
+			m.opts.RemoveLogs = !m.opts.RemoveLogs
+			m.plan = uninstall.Plan(m.opts)
+		case "c":
+			m.opts.RemoveConfig = !m.opts.RemoveConfig
+			m.plan = uninstall.Plan(m.opts)
+		case "enter":
+			if len(m.plan) > 0 {
+				m.confirming = true
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// View implements tea.Model
+func (m UninstallModel) View() string {
+	helper := NewViewHelper(m.width, m.height)
+	var b strings.Builder
+
+	b.WriteString(helper.RenderHeader("Uninstall", "Remove the LaunchAgent, generated scripts and optionally logs and config"))
+
+	if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	}
+
+	if m.result != nil {
+		b.WriteString(styles.RenderSubtitle("Result:"))
+		b.WriteString("\n\n")
+		b.WriteString(m.result.Summary())
+		b.WriteString("\n\n")
+	}
+
+	if m.processing {
+		b.WriteString(styles.RenderInfo("Removing..."))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(m.renderPlan())
+	}
+
+	if m.confirming {
+		b.WriteString("\n")
+		b.WriteString(styles.RenderWarning(fmt.Sprintf("Remove these %d item(s)? This cannot be undone. (y/n)", len(m.plan))))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helper.RenderFooter("l: Toggle logs • c: Toggle config • enter: Uninstall • q: Back"))
+
+	return b.String()
+}
+
+// renderPlan renders the dry-run preview of what will be removed
+func (m UninstallModel) renderPlan() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Remove logs:   %s\n", checkbox(m.opts.RemoveLogs)))
+	b.WriteString(fmt.Sprintf("Remove config: %s\n\n", checkbox(m.opts.RemoveConfig)))
+
+	if len(m.plan) == 0 {
+		b.WriteString(styles.RenderMuted("Nothing to remove."))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	b.WriteString(styles.RenderSubtitle("Will remove:"))
+	b.WriteString("\n\n")
+	for _, item := range m.plan {
+		b.WriteString(fmt.Sprintf("  %-12s %s\n", item.Kind, item.Path))
+	}
+
+	return b.String()
+}
+
+// checkbox renders a boolean option
+func checkbox(on bool) string {
+	if on {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// runUninstall returns a command that performs the uninstall
+func (m UninstallModel) runUninstall() tea.Cmd {
+	opts := m.opts
+	return func() tea.Msg {
+		result, err := uninstall.Run(opts)
+		return uninstallDone{result: result, err: err}
+	}
+}
+
+// uninstallDone is sent when the uninstall finishes
+type uninstallDone struct {
+	result *uninstall.Result
+	err    error
+}
@@ -0,0 +1,266 @@
+package views
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+// Validator checks the value of a form field. Values are trimmed first.
+type Validator func(value string) error
+
+// Required rejects empty values
+func Required(value string) error {
+	if value == "" {
+		return fmt.Errorf("required")
+	}
+	return nil
+}
+
+// IntRange accepts whole numbers from min to max
+func IntRange(min, max int) Validator {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("must be a whole number")
+		}
+		if n < min || n > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// DirExists accepts existing, readable directories. A leading ~ is the
+// home directory.
+func DirExists(value string) error {
+	path, err := ExpandHome(value)
+	if err != nil {
+		return fmt.Errorf("failed to expand home directory: %w", err)
+	}
+	return syncconfig.ValidateLocalPath(path)
+}
+
+// FileExists accepts existing files, such as an SSH private key. A leading
+// ~ is the home directory.
+func FileExists(value string) error {
+	path, err := ExpandHome(value)
+	if err != nil {
+		return fmt.Errorf("failed to expand home directory: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("file not found: %s", value)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a folder, not a file", value)
+	}
+	return nil
+}
+
+// ServiceAccountKey accepts Google service account key files. A leading ~
+// is the home directory.
+func ServiceAccountKey(value string) error {
+	path, err := ExpandHome(value)
+	if err != nil {
+		return fmt.Errorf("failed to expand home directory: %w", err)
+	}
+	_, err = config.ReadServiceAccount(path)
+	return err
+}
+
+// RemoteName accepts valid rclone remote names
+func RemoteName(value string) error {
+	return rclone.ValidateRemoteName(value)
+}
+
+// BucketPath accepts a non-empty path on a remote of remoteType whose
+// bucket, if the remote keeps buckets, follows the common S3 and B2 rules
+func BucketPath(remoteType string) Validator {
+	return func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("bucket name cannot be empty")
+		}
+		return rclone.ValidateBucketPath(remoteType, value)
+	}
+}
+
+// EndpointURL accepts a host name or an http(s) URL, such as
+// s3.nl-ams.scw.cloud or https://minio.local:9000
+func EndpointURL(value string) error {
+	raw := value
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || strings.ContainsAny(value, " \t") {
+		return fmt.Errorf("must be a host name or URL, e.g. s3.example.com")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("must use http or https")
+	}
+	return nil
+}
+
+// WebDAVURL accepts http(s) addresses with a host, such as
+// https://cloud.example.com/remote.php/dav/files/me/
+func WebDAVURL(value string) error {
+	return config.ValidateWebDAVURL("", value)
+}
+
+// AliasTarget accepts what an alias can stand for: a full path, where a
+// leading ~ is the home directory, or a path on a remote like nas:Backups
+func AliasTarget(value string) error {
+	path, err := ExpandHome(value)
+	if err != nil {
+		return fmt.Errorf("failed to expand home directory: %w", err)
+	}
+	return config.ValidateAliasTarget(path)
+}
+
+// Upstreams accepts a comma-separated list of at least two upstreams of a
+// union, such as b2:backups, ~/Backups
+func Upstreams(value string) error {
+	upstreams, err := parseUpstreams(value)
+	if err != nil {
+		return err
+	}
+	if len(upstreams) < 2 {
+		return fmt.Errorf("list at least two upstreams, separated by commas")
+	}
+	return nil
+}
+
+// RemotePathVariables accepts remote paths whose variables are {hostname},
+// {user} or {date}
+func RemotePathVariables(value string) error {
+	return syncconfig.ValidatePathVariables(value)
+}
+
+// LabelColor accepts a color for a remote's label: a named color such as
+// green, or a hex value like #00ADD8
+func LabelColor(value string) error {
+	return config.ValidateLabelColor(strings.ToLower(value))
+}
+
+// OneOf accepts one of the given values, ignoring case
+func OneOf(values ...string) Validator {
+	return func(value string) error {
+		for _, v := range values {
+			if strings.EqualFold(value, v) {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of: %s", strings.Join(values, ", "))
+	}
+}
+
+// Optional skips the validator for empty values
+func Optional(v Validator) Validator {
+	return func(value string) error {
+		if value == "" {
+			return nil
+		}
+		return v(value)
+	}
+}
+
+// All runs validators in order and returns the first error
+func All(validators ...Validator) Validator {
+	return func(value string) error {
+		for _, v := range validators {
+			if err := v(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// FormValidator validates the text inputs of a form and keeps the error of
+// each field for inline rendering
+type FormValidator struct {
+	rules  []Validator // Indexed like the inputs; nil fields are not checked
+	errors []string
+}
+
+// NewFormValidator creates a validator with one rule per input
+func NewFormValidator(rules ...Validator) FormValidator {
+	return FormValidator{rules: rules}
+}
+
+// SetRule replaces the rule of one field, e.g. when it depends on another
+func (f *FormValidator) SetRule(i int, rule Validator) {
+	if i < len(f.rules) {
+		f.rules[i] = rule
+	}
+}
+
+// Validate checks every field and reports whether all are valid
+func (f *FormValidator) Validate(inputs []textinput.Model) bool {
+	f.errors = make([]string, len(inputs))
+	valid := true
+	for i, input := range inputs {
+		if msg := f.check(i, input.Value()); msg != "" {
+			f.errors[i] = msg
+			valid = false
+		}
+	}
+	return valid
+}
+
+// Recheck validates one field when the user leaves it. Empty fields are only
+// flagged once the whole form has been validated, so tabbing through a new
+// form does not fill it with errors.
+func (f *FormValidator) Recheck(i int, value string) {
+	if strings.TrimSpace(value) == "" && f.Err(i) == "" {
+		return
+	}
+	errors := make([]string, max(len(f.rules), len(f.errors)))
+	copy(errors, f.errors)
+	if i < len(errors) {
+		errors[i] = f.check(i, value)
+	}
+	f.errors = errors
+}
+
+// Err returns the error of a field, or an empty string
+func (f FormValidator) Err(i int) string {
+	if i < 0 || i >= len(f.errors) {
+		return ""
+	}
+	return f.errors[i]
+}
+
+// RenderField renders an input with its error, if any, below it
+func (f FormValidator) RenderField(i int, input textinput.Model) string {
+	return RenderInput(input, f.Err(i))
+}
+
+// check runs the rule of a field and returns the error message
+func (f FormValidator) check(i int, value string) string {
+	if i >= len(f.rules) || f.rules[i] == nil {
+		return ""
+	}
+	if err := f.rules[i](strings.TrimSpace(value)); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// RenderInput renders a text input with an inline error below it
+func RenderInput(input textinput.Model, errMsg string) string {
+	if errMsg == "" {
+		return input.View()
+	}
+	return input.View() + "\n" + styles.RenderError("  ✗ "+errMsg)
+}
@@ -0,0 +1,224 @@
+package uninstall
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/scripts"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// Kinds of item removed by an uninstall
+const (
+	KindLaunchAgent = "launchagent"
+	KindScript      = "script"
+	KindLog         = "log"
+	KindConfig      = "config"
+)
+
+// logFiles are the files cloud-sync writes into the log directory, which may
+// be shared with other tools so it is never removed as a whole
+var logFiles = []string{
+	"rclone_backup.log",
+	"rclone_backup.lock",
+	"deferred_pairs.json",
+	"deferred_pairs.json.bak",
+	"deferred_pairs.json.lock",
+}
+
+// Options controls what an uninstall removes
+type Options struct {
+	Launchd      *launchd.Manager   // LaunchAgent to unload and remove (nil to skip)
+	Agents       []*launchd.Manager // Other agents, e.g. syncing pairs when a drive is connected
+	BinDir       string             // Directory holding the generated scripts
+	LogDir       string             // Directory holding the backup log
+	ConfigDir    string             // cloud-sync configuration directory
+	CacheDir     string             // Cached listings, removed with the configuration
+	Pairs        []string           // Sync pairs whose logs are removed with the backup log
+	RemoveLogs   bool               // Also remove the backup log
+	RemoveConfig bool               // Also remove the configuration directory
+	DryRun       bool               // Only report what would be removed
+}
+
+// DefaultOptions returns options for the current installation, taking the
+// bin and log directories from the saved configuration
+func DefaultOptions(launchdMgr *launchd.Manager) (Options, error) {
+	configManager, err := config.NewManager()
+	if err != nil {
+		return Options{}, err
+	}
+
+	appConfig, err := configManager.Load()
+	if err != nil {
+		return Options{}, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var pairs []string
+	if syncConfigMgr, err := syncconfig.NewDefaultManager(); err == nil {
+		if syncPairs, err := syncConfigMgr.ListSyncPairs(); err == nil {
+			for _, pair := range syncPairs {
+				pairs = append(pairs, pair.Name)
+			}
+		}
+	}
+
+	// Listings cached by pairs that index first, ~/.cache/cloud-sync
+	cacheDir := ""
+	if indexDir, err := rclone.DefaultIndexDir(); err == nil {
+		cacheDir = filepath.Dir(indexDir)
+	}
+
+	return Options{
+		Launchd:   launchdMgr,
+		Agents:    []*launchd.Manager{launchdMgr.Mount(), launchdMgr.Power()},
+		BinDir:    appConfig.BinDir,
+		LogDir:    appConfig.LogDir,
+		ConfigDir: filepath.Dir(configManager.GetConfigPath()),
+		CacheDir:  cacheDir,
+		Pairs:     pairs,
+	}, nil
+}
+
+// Item is one thing removed (or, on a dry run, that would be removed)
+type Item struct {
+	Kind string
+	Path string
+	Err  error
+}
+
+// Result summarises an uninstall
+type Result struct {
+	Items  []Item
+	DryRun bool
+}
+
+// Failed returns the items that could not be removed
+func (r *Result) Failed() []Item {
+	failed := make([]Item, 0)
+	for _, item := range r.Items {
+		if item.Err != nil {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
+
+// Summary describes what was removed, one item per line
+func (r *Result) Summary() string {
+	if len(r.Items) == 0 {
+		return "Nothing to remove"
+	}
+
+	var b strings.Builder
+	verb := "Removed"
+	if r.DryRun {
+		verb = "Would remove"
+	}
+	for _, item := range r.Items {
+		if item.Err != nil {
+			fmt.Fprintf(&b, "✗ %s %s: %v\n", item.Kind, item.Path, item.Err)
+		} else {
+			fmt.Fprintf(&b, "✓ %s %s %s\n", verb, item.Kind, item.Path)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Plan lists everything the uninstall would remove. Paths that do not exist
+// are left out.
+func Plan(opts Options) []Item {
+	items := make([]Item, 0)
+
+	if opts.Launchd != nil && exists(opts.Launchd.GetPlistPath()) {
+		items = append(items, Item{Kind: KindLaunchAgent, Path: opts.Launchd.GetPlistPath()})
+	}
+	for _, agent := range opts.Agents {
+		if exists(agent.GetPlistPath()) {
+			items = append(items, Item{Kind: KindLaunchAgent, Path: agent.GetPlistPath()})
+		}
+	}
+
+	if opts.BinDir != "" {
+		for _, name := range scripts.ScriptNames {
+			if path := filepath.Join(opts.BinDir, name); exists(path) {
+				items = append(items, Item{Kind: KindScript, Path: path})
+			}
+		}
+	}
+
+	if opts.RemoveLogs && opts.LogDir != "" {
+		for _, name := range logFiles {
+			if path := filepath.Join(opts.LogDir, name); exists(path) {
+				items = append(items, Item{Kind: KindLog, Path: path})
+			}
+		}
+		for _, pair := range opts.Pairs {
+			if path := logs.LegacyPairLogPath(opts.LogDir, pair); path != "" && exists(path) {
+				items = append(items, Item{Kind: KindLog, Path: path})
+			}
+		}
+		// The pair logs have a directory of their own
+		if dir := logs.PairLogDir(opts.LogDir); exists(dir) {
+			items = append(items, Item{Kind: KindLog, Path: dir})
+		}
+	}
+
+	if opts.RemoveConfig && opts.ConfigDir != "" && exists(opts.ConfigDir) {
+		items = append(items, Item{Kind: KindConfig, Path: opts.ConfigDir})
+	}
+	if opts.RemoveConfig && opts.CacheDir != "" && exists(opts.CacheDir) {
+		items = append(items, Item{Kind: KindConfig, Path: opts.CacheDir})
+	}
+
+	return items
+}
+
+// Run removes everything in the plan, or only reports it on a dry run. Each
+// item is attempted even if an earlier one fails.
+func Run(opts Options) (*Result, error) {
+	result := &Result{Items: Plan(opts), DryRun: opts.DryRun}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	for i, item := range result.Items {
+		switch item.Kind {
+		case KindLaunchAgent:
+			// Remove unloads the agent before deleting its plist
+			agent := opts.Launchd
+			for _, other := range opts.Agents {
+				if item.Path == other.GetPlistPath() {
+					agent = other
+				}
+			}
+			result.Items[i].Err = agent.Remove()
+		case KindConfig:
+			result.Items[i].Err = os.RemoveAll(item.Path)
+		default:
+			remove := os.Remove
+			if info, err := os.Stat(item.Path); err == nil && info.IsDir() {
+				remove = os.RemoveAll
+			}
+			if err := remove(item.Path); err != nil && !os.IsNotExist(err) {
+				result.Items[i].Err = err
+			}
+		}
+	}
+
+	if failed := result.Failed(); len(failed) > 0 {
+		return result, fmt.Errorf("failed to remove %d item(s)", len(failed))
+	}
+	return result, nil
+}
+
+// exists reports whether a path exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,39 @@
+package volume
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Root is where macOS mounts external drives
+var Root = "/Volumes"
+
+// Of returns the drive a path is on, e.g. /Volumes/Backup for
+// /Volumes/Backup/Documents. Paths on the startup disk report false.
+func Of(path string) (string, bool) {
+	rel, err := filepath.Rel(Root, filepath.Clean(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	name, _, _ := strings.Cut(rel, string(filepath.Separator))
+	return filepath.Join(Root, name), true
+}
+
+// Mounted reports whether a drive is mounted at dir, i.e. dir exists and is
+// on another device than its parent. A folder left behind in /Volumes after
+// a drive was pulled out is not a mount.
+func Mounted(dir string) bool {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	parent, err := os.Stat(filepath.Dir(dir))
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	parentStat, parentOK := parent.Sys().(*syscall.Stat_t)
+	return ok && parentOK && stat.Dev != parentStat.Dev
+}
@@ -0,0 +1,54 @@
+package backup
+
+import (
+	"fmt"
+
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// AnomalyFunc receives a warning about a run of a pair that deletes or
+// transfers far more than its earlier runs, e.g. after ransomware encrypted
+// the folder or a parent folder was deleted by mistake
+type AnomalyFunc func(pair, warning string)
+
+// SetAnomalyWarning sets the function told about runs far outside a pair's
+// history. Warnings are also written to the pair's log either way.
+func (m *Manager) SetAnomalyWarning(onAnomaly AnomalyFunc) {
+	m.onAnomaly = onAnomaly
+}
+
+// warnPlannedDeletes compares the deletes a sync to dest would make with
+// the pair's history before the sync runs
+func (m *Manager) warnPlannedDeletes(pair *syncconfig.SyncPair, dest string, plan rclone.DeletePlan) {
+	history, err := m.logs.PairHistory(pair.Name)
+	if err != nil {
+		return
+	}
+	for _, anomaly := range history.Check(logs.RunVolume{Deletes: plan.Deletes}) {
+		anomaly.Planned = true
+		m.warnAnomaly(pair, fmt.Sprintf("%s: %s", dest, anomaly))
+	}
+}
+
+// warnRunVolume compares the run in progress, the last session in the
+// pair's log, with the sessions before it
+func (m *Manager) warnRunVolume(pair *syncconfig.SyncPair) {
+	sessions, err := m.logs.ForPair(pair.Name).GetSyncSessions()
+	if err != nil || len(sessions) == 0 {
+		return
+	}
+	run := sessions[len(sessions)-1]
+	for _, anomaly := range logs.HistoryOf(sessions[:len(sessions)-1]).Check(run.Volume()) {
+		m.warnAnomaly(pair, anomaly.String())
+	}
+}
+
+// warnAnomaly notes a warning in the pair's log and passes it on
+func (m *Manager) warnAnomaly(pair *syncconfig.SyncPair, warning string) {
+	m.logs.LogPairEvent(pair.Name, "Anomaly Warning: "+warning)
+	if m.onAnomaly != nil {
+		m.onAnomaly(pair.Name, warning)
+	}
+}
@@ -0,0 +1,113 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// ArchiveProgressFunc receives how many bytes of a pair's folder have been
+// compressed so far, out of total
+type ArchiveProgressFunc func(pair string, done, total int64)
+
+// SetArchiveProgress sets the function told how the compression of
+// archive-mode pairs is getting on
+func (m *Manager) SetArchiveProgress(onProgress ArchiveProgressFunc) {
+	m.onArchive = onProgress
+}
+
+// stagingDir is the folder a pair's archives are built in before upload
+func (m *Manager) stagingDir(pair *syncconfig.SyncPair) string {
+	if pair.StagingPath != "" {
+		return pair.StagingPath
+	}
+	return filepath.Join(m.config.HomeDir, ".cache", "cloud-sync", "staging")
+}
+
+// archiveDestinations compresses an archive-mode pair's folder once and
+// uploads the archive to every destination, pruning old archives after
+// each. If compressing fails, every destination fails with it.
+func (m *Manager) archiveDestinations(pair *syncconfig.SyncPair, progress bool, dryRun bool) []DestinationResult {
+	dests := pair.AllDestinations()
+	results := make([]DestinationResult, 0, len(dests))
+	name := rclone.ArchiveName(time.Now())
+
+	if dryRun {
+		for _, dest := range dests {
+			m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Dry Run: would archive %s as %s and upload it to %s", pair.LocalPath, name, dest))
+			results = append(results, DestinationResult{Destination: dest})
+		}
+		return results
+	}
+
+	start := time.Now()
+	staged := filepath.Join(m.stagingDir(pair), name)
+	err := m.stageArchive(pair, staged)
+	if err != nil {
+		for _, dest := range dests {
+			results = append(results, DestinationResult{Destination: dest, Duration: time.Since(start), Err: err})
+		}
+		return results
+	}
+	defer os.Remove(staged)
+
+	for _, dest := range dests {
+		start := time.Now()
+		err := m.uploadArchive(pair, dest, staged, progress)
+		results = append(results, DestinationResult{
+			Destination: dest,
+			Duration:    time.Since(start),
+			Err:         err,
+		})
+	}
+	return results
+}
+
+// stageArchive compresses the pair's folder into staged and notes the
+// result in the pair's log
+func (m *Manager) stageArchive(pair *syncconfig.SyncPair, staged string) error {
+	result, err := m.compressor.Create(pair.LocalPath, staged, func(done, total int64) {
+		if m.onArchive != nil {
+			m.onArchive(pair.Name, done, total)
+		}
+	})
+	if err != nil {
+		m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Archive Failed: %v", err))
+		return fmt.Errorf("failed to archive %s: %w", pair.LocalPath, err)
+	}
+	m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Archived %d files, %d bytes into %s (%d bytes)",
+		result.Files, result.Bytes, filepath.Base(staged), result.Size))
+	return nil
+}
+
+// uploadArchive copies a staged archive to one destination and prunes the
+// archives beyond the pair's limit there
+func (m *Manager) uploadArchive(pair *syncconfig.SyncPair, dest syncconfig.Destination, staged string, progress bool) error {
+	if err := m.checkCredentials(dest.RemoteName); err != nil {
+		return err
+	}
+
+	opts := m.uploadOptions(pair, dest, progress, false)
+	if err := m.rclone.UploadArchive(staged, dest.RemoteName, dest.RemotePath, opts); err != nil {
+		return err
+	}
+
+	if _, err := m.rclone.PruneArchives(dest.RemoteName, dest.RemotePath, pair.ArchiveKeep); err != nil {
+		return fmt.Errorf("archive pruning failed: %w", err)
+	}
+	return nil
+}
+
+// ListArchives lists the archives of an archive-mode pair's primary destination
+func (m *Manager) ListArchives(name string) ([]rclone.Archive, error) {
+	pair, err := m.getPair(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.rclone.ListArchives(pair.RemoteName, pair.RemotePath)
+}
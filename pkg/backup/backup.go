@@ -1,29 +1,60 @@
 package backup
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/andreisuslov/cloud-sync/internal/archive"
+	appconfig "github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
 	"github.com/andreisuslov/cloud-sync/internal/installer"
 	"github.com/andreisuslov/cloud-sync/internal/launchd"
 	"github.com/andreisuslov/cloud-sync/internal/lockfile"
 	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/network"
+	"github.com/andreisuslov/cloud-sync/internal/power"
+	"github.com/andreisuslov/cloud-sync/internal/queue"
 	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/rsync"
 	"github.com/andreisuslov/cloud-sync/internal/scripts"
 	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/volume"
 )
 
 // Manager provides a high-level API for backup operations
 type Manager struct {
 	installer  *installer.Installer
 	rclone     *rclone.Manager
+	rsync      *rsync.Manager
+	compressor *archive.Compressor
 	scripts    *scripts.Generator
 	launchd    *launchd.Manager
 	logs       *logs.Manager
 	lockfile   *lockfile.Manager
+	queue      *queue.Queue
 	syncconfig *syncconfig.Manager
 	config     *Config
+	rcloneEnv  []string               // Resolved credential references passed to rclone
+	secretErr  *appconfig.SecretError // References that did not resolve, if any
+
+	allowDeletes    bool // Skip the delete limit check, set by AllowDeletes
+	allowOverBudget bool // Skip the size budget check, set by AllowOverBudget
+	scheduled       bool // Defer pairs on low battery, set by SetScheduled
+	onIndex         IndexProgressFunc
+	onArchive       ArchiveProgressFunc
+	onAnomaly       AnomalyFunc
+	onBudget        BudgetWarningFunc
+	onTransfer      TransferProgressFunc
+	onPairs         PairsProgressFunc
+	rcAddr          string          // Where rclone serves its API while a pair's progress is watched
+	networkStatus   *network.Status // Inspected by the first checkNetwork of the run
 }
 
 // Config holds the backup configuration
@@ -35,8 +66,17 @@ type Config struct {
 	DestRemote   string
 	DestBucket   string
 	RclonePath   string
+	RsyncPath    string           // Used by local pairs; found via PATH if empty
+	ZstdPath     string           // Used by archive-mode pairs; found via PATH if empty
+	Network      *network.Checker // Checked once, before the first remote pair syncs; nil skips the check
+	Power        *power.Checker   // Checked before scheduled runs; nil skips the check
 	LogDir       string
 	BinDir       string
+	RCAddr       string    // Where scheduled backups serve rclone's remote control API, rclone.DefaultRCAddr if empty
+	ReportFormat string    // Format of the report written after each run, Markdown if empty
+	ReportsKept  int       // Reports kept per pair; logs.DefaultReportsKept if 0
+	Stdout       io.Writer // Where the rclone and rsync transfers print, os.Stdout if nil
+	Stderr       io.Writer // os.Stderr if nil
 }
 
 // NewManager creates a new backup manager
@@ -58,20 +98,49 @@ func NewManager(config *Config) (*Manager, error) {
 	if config.RclonePath == "" {
 		config.RclonePath = "rclone" // Will be resolved via PATH
 	}
+	if config.RCAddr == "" {
+		config.RCAddr = rclone.DefaultRCAddr
+	}
 
 	// Initialize sync config manager
-	syncConfigPath := filepath.Join(config.HomeDir, ".config", "cloud-sync", "sync-config.json")
-	syncConfigMgr := syncconfig.NewManager(syncConfigPath)
+	syncConfigMgr := syncconfig.NewManager(syncconfig.DefaultPath(config.HomeDir))
+
+	// Credentials stored as env: or cmd: references are resolved now, at
+	// sync time, and handed to rclone through its environment. A remote
+	// whose references fail only fails the syncs to that remote.
+	rcloneEnv, err := appconfig.RcloneEnv()
+	var secretErr *appconfig.SecretError
+	if err != nil && !errors.As(err, &secretErr) {
+		return nil, err
+	}
+	rcloneMgr := rclone.NewManager(config.RclonePath)
+	rcloneMgr.SetEnv(rcloneEnv)
+	rcloneMgr.SetOutput(config.Stdout, config.Stderr)
+	rsyncMgr := rsync.NewManager(config.RsyncPath)
+	rsyncMgr.SetOutput(config.Stdout, config.Stderr)
+
+	// Each pair logs to its own file; merge them into stats and transfers
+	logsMgr := logs.NewManager(config.LogDir)
+	if pairs, err := syncConfigMgr.ListSyncPairs(); err == nil {
+		for _, pair := range pairs {
+			logsMgr.AddPairs(pair.Name)
+		}
+	}
 
 	return &Manager{
 		installer:  installer.NewInstaller(),
-		rclone:     rclone.NewManager(config.RclonePath),
+		rclone:     rcloneMgr,
+		rsync:      rsyncMgr,
+		compressor: archive.NewCompressor(config.ZstdPath),
 		scripts:    scripts.NewGenerator(),
 		launchd:    launchd.NewManager(config.Username),
-		logs:       logs.NewManager(config.LogDir),
+		logs:       logsMgr,
 		lockfile:   lockfile.NewManager(config.LogDir),
+		queue:      queue.New(config.LogDir),
 		syncconfig: syncConfigMgr,
 		config:     config,
+		rcloneEnv:  rcloneEnv,
+		secretErr:  secretErr,
 	}, nil
 }
 
@@ -101,6 +170,8 @@ func (m *Manager) InstallTools() error {
 	}
 	m.config.RclonePath = path
 	m.rclone = rclone.NewManager(path)
+	m.rclone.SetEnv(m.rcloneEnv)
+	m.rclone.SetOutput(m.config.Stdout, m.config.Stderr)
 
 	return nil
 }
@@ -122,6 +193,8 @@ func (m *Manager) ListBuckets(remoteName string) ([]rclone.Bucket, error) {
 
 // GenerateScripts generates all backup scripts
 func (m *Manager) GenerateScripts() error {
+	// The scheduled backup runs the sync pairs with this binary
+	program, _ := os.Executable()
 	scriptConfig := &scripts.Config{
 		HomeDir:      m.config.HomeDir,
 		Username:     m.config.Username,
@@ -132,23 +205,11 @@ func (m *Manager) GenerateScripts() error {
 		DestBucket:   m.config.DestBucket,
 		LogDir:       m.config.LogDir,
 		BinDir:       m.config.BinDir,
+		RCAddr:       m.config.RCAddr,
+		CloudSync:    program,
 	}
 
-	if err := scripts.ValidateConfig(scriptConfig); err != nil {
-		return fmt.Errorf("invalid script configuration: %w", err)
-	}
-
-	// Create directories
-	if err := m.scripts.CreateDirectories(scriptConfig); err != nil {
-		return fmt.Errorf("failed to create directories: %w", err)
-	}
-
-	// Generate all scripts
-	if err := m.scripts.GenerateAllScripts(scriptConfig); err != nil {
-		return fmt.Errorf("failed to generate scripts: %w", err)
-	}
-
-	return nil
+	return m.scripts.Install(scriptConfig)
 }
 
 // SetupLaunchAgent creates and loads the LaunchAgent
@@ -186,7 +247,7 @@ func (m *Manager) GetLaunchAgentStatus() (*launchd.Status, error) {
 // StartManualBackup triggers a manual backup
 func (m *Manager) StartManualBackup() error {
 	if m.lockfile.Exists() {
-		return fmt.Errorf("backup already running (lockfile exists)")
+		return errkind.Wrap(errkind.ErrLocked, fmt.Errorf("backup already running (lockfile exists at %s)", m.lockfile.GetPath()))
 	}
 
 	return m.launchd.Start()
@@ -202,11 +263,38 @@ func (m *Manager) GetRecentTransfers(count int) ([]logs.Transfer, error) {
 	return m.logs.GetRecentTransfers(count)
 }
 
+// CancelRunning stops the rclone processes this program started, sending
+// SIGINT and then SIGKILL, and then removes the lockfile if no backup is
+// left answering for it. It returns how many processes were cancelled.
+func (m *Manager) CancelRunning() (int, error) {
+	cancelled := rclone.DefaultRuns.CancelAll(rclone.CancelGrace)
+	if cancelled == 0 {
+		return 0, nil
+	}
+	return cancelled, CleanupLockfile(m.config.LogDir, m.config.RCAddr)
+}
+
+// CleanupLockfile removes the lockfile in logDir when no backup is answering
+// on rclone's remote control API at rcAddr, as happens after a backup is
+// stopped
+func CleanupLockfile(logDir, rcAddr string) error {
+	lock := lockfile.NewManager(logDir)
+	if !lock.Exists() || rclone.NewRCClient(rcAddr).Running() {
+		return nil
+	}
+	return lock.Remove()
+}
+
 // RemoveLockfile removes the backup lockfile
 func (m *Manager) RemoveLockfile() error {
 	return m.lockfile.ForceRemove()
 }
 
+// Queue returns the sync queue, which orders the syncs of every trigger
+func (m *Manager) Queue() *queue.Queue {
+	return m.queue
+}
+
 // AddSyncPair adds a new local folder to remote sync configuration
 func (m *Manager) AddSyncPair(name, localPath, remoteName, remotePath, direction string) error {
 	pair := syncconfig.SyncPair{
@@ -218,7 +306,12 @@ func (m *Manager) AddSyncPair(name, localPath, remoteName, remotePath, direction
 		Enabled:    true,
 	}
 
-	return m.syncconfig.AddSyncPair(pair)
+	if err := m.syncconfig.AddSyncPair(pair); err != nil {
+		return err
+	}
+
+	m.logs.AddPairs(name)
+	return nil
 }
 
 // RemoveSyncPair removes a sync pair by name
@@ -231,6 +324,17 @@ func (m *Manager) ListSyncPairs() ([]syncconfig.SyncPair, error) {
 	return m.syncconfig.ListSyncPairs()
 }
 
+// getPair returns a sync pair as it runs now, with the variables in its
+// remote paths expanded
+func (m *Manager) getPair(name string) (*syncconfig.SyncPair, error) {
+	pair, err := m.syncconfig.GetSyncPair(name)
+	if err != nil {
+		return nil, err
+	}
+	expanded := pair.Expand(syncconfig.CurrentPathVars())
+	return &expanded, nil
+}
+
 // ToggleSyncPair toggles the enabled status of a sync pair
 func (m *Manager) ToggleSyncPair(name string) error {
 	return m.syncconfig.ToggleEnabled(name)
@@ -238,7 +342,14 @@ func (m *Manager) ToggleSyncPair(name string) error {
 
 // SyncPair executes a sync operation for a specific sync pair
 func (m *Manager) SyncPair(name string, progress bool, dryRun bool) error {
-	pair, err := m.syncconfig.GetSyncPair(name)
+	return m.SyncSubpath(name, "", progress, dryRun)
+}
+
+// SyncSubpath syncs only the folder subpath below a pair's folder, e.g.
+// after editing one project, without scanning the rest of the pair. An
+// empty subpath syncs the whole pair.
+func (m *Manager) SyncSubpath(name, subpath string, progress bool, dryRun bool) error {
+	pair, err := m.getPair(name)
 	if err != nil {
 		return err
 	}
@@ -247,65 +358,611 @@ func (m *Manager) SyncPair(name string, progress bool, dryRun bool) error {
 		return fmt.Errorf("sync pair '%s' is disabled", name)
 	}
 
+	if pair.IsMonitor() {
+		return fmt.Errorf("sync pair '%s' only monitors %s and never transfers files; check it with 'cloud-sync monitor %s'", name, pair.Target(), name)
+	}
+
+	if err := m.checkVolumes(pair); err != nil {
+		return err
+	}
+
+	if err := m.checkNetwork(pair); err != nil {
+		return err
+	}
+
+	if err := m.checkPower(pair); err != nil {
+		return err
+	}
+
 	// Validate local path exists
 	if err := syncconfig.ValidateLocalPath(pair.LocalPath); err != nil {
 		return fmt.Errorf("local path validation failed: %w", err)
 	}
 
-	// Execute sync based on direction
+	started := "Manual Sync Requested"
+	if subpath != "" {
+		sub, err := pair.Subtree(subpath)
+		if err != nil {
+			return err
+		}
+		// A download creates the folder; anything else reads it
+		if sub.Direction != "download" {
+			if err := syncconfig.ValidateLocalPath(sub.LocalPath); err != nil {
+				return fmt.Errorf("subpath validation failed: %w", err)
+			}
+		}
+		pair = &sub
+		started += " for " + filepath.ToSlash(sub.Subpath)
+	}
+
+	// rclone writes the pair's log but does not create its directory
+	if err := os.MkdirAll(m.config.LogDir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	// Dry runs still log rclone's output but are not recorded as sessions
+	if dryRun {
+		return m.syncPair(pair, progress, dryRun)
+	}
+
+	if err := m.logs.LogPairEvent(pair.Name, started); err != nil {
+		return err
+	}
+
+	err = m.syncPair(pair, progress, dryRun)
+	if errors.Is(err, rclone.ErrCancelled) {
+		m.logs.LogPairEvent(pair.Name, "Manual Sync Complete: Cancelled")
+	} else if err != nil {
+		m.logs.LogPairEvent(pair.Name, "Manual Sync Complete: Failed")
+	} else {
+		if pair.Subpath == "" {
+			m.warnRunVolume(pair)
+		}
+		m.logs.LogPairEvent(pair.Name, "Manual Sync Complete: Success")
+	}
+	m.writeReport(pair)
+	return err
+}
+
+// syncPair runs a pair's transfers based on its direction
+func (m *Manager) syncPair(pair *syncconfig.SyncPair, progress bool, dryRun bool) error {
+	if err := m.writeIgnoreFilter(pair); err != nil {
+		return err
+	}
+
+	if pair.IsLocal() {
+		return m.mirror(pair, progress, dryRun)
+	}
+
+	if err := m.rclone.Require(rclone.FeatureBaseline); err != nil {
+		return err
+	}
+
+	index := m.indexSource(pair, dryRun)
+
+	stop := m.watchTransfers(pair.Name, index)
+	defer stop()
+
 	switch pair.Direction {
 	case "upload":
-		return m.rclone.SyncLocalToRemote(pair.LocalPath, pair.RemoteName, pair.RemotePath, progress, dryRun)
+		results := m.syncDestinations(pair, progress, dryRun)
+		if len(results) == 1 {
+			return results[0].Err
+		}
+		return replicationError(pair.Name, results)
 	case "download":
-		return m.rclone.SyncRemoteToLocal(pair.RemoteName, pair.RemotePath, pair.LocalPath, progress, dryRun)
+		return m.download(pair, progress, dryRun)
 	case "bidirectional":
 		// For bidirectional, we'll do upload first, then download
 		// In a production system, you'd want more sophisticated conflict resolution
-		if err := m.rclone.SyncLocalToRemote(pair.LocalPath, pair.RemoteName, pair.RemotePath, progress, dryRun); err != nil {
+		if err := m.uploadDestination(pair, pair.AllDestinations()[0], progress, dryRun); err != nil {
 			return fmt.Errorf("upload failed: %w", err)
 		}
-		return m.rclone.SyncRemoteToLocal(pair.RemoteName, pair.RemotePath, pair.LocalPath, progress, dryRun)
+		return m.download(pair, progress, dryRun)
 	default:
 		return fmt.Errorf("invalid sync direction: %s", pair.Direction)
 	}
 }
 
-// SyncAllEnabled syncs all enabled sync pairs
-func (m *Manager) SyncAllEnabled(progress bool, dryRun bool) error {
-	pairs, err := m.syncconfig.ListEnabledSyncPairs()
+// mirror copies a local pair's folder to its target folder with rsync
+func (m *Manager) mirror(pair *syncconfig.SyncPair, progress bool, dryRun bool) error {
+	opts := rsync.Options{
+		Progress:   progress,
+		DryRun:     dryRun,
+		LogFile:    m.logs.PairLogPath(pair.Name),
+		FilterFrom: m.ignoreFilter(pair),
+		Command:    pair.TransferMode(),
+		ExtraFlags: pair.ExtraFlags,
+		SkipHidden: pair.SkipHidden,
+		SkipLinks:  pair.SkipSymlinks,
+		MaxSize:    pair.SkipLargerThan,
+	}
+	err := m.checkDeletePlan(pair, pair.TargetPath, dryRun, func() (rclone.DeletePlan, error) {
+		return m.rsync.PreviewDeletes(pair.LocalPath, pair.TargetPath, opts)
+	})
 	if err != nil {
 		return err
 	}
+	return m.rsync.Mirror(pair.LocalPath, pair.TargetPath, opts)
+}
 
-	if len(pairs) == 0 {
-		return fmt.Errorf("no enabled sync pairs found")
+// checkCredentials returns the error resolving a remote's credential
+// references, if they did not resolve when the manager was created
+func (m *Manager) checkCredentials(remoteName string) error {
+	if m.secretErr == nil {
+		return nil
+	}
+	return m.secretErr.Remote(remoteName)
+}
+
+// download syncs a pair's remote down to its local folder
+func (m *Manager) download(pair *syncconfig.SyncPair, progress bool, dryRun bool) error {
+	if err := m.checkCredentials(pair.RemoteName); err != nil {
+		return err
 	}
+	opts := m.downloadOptions(pair, progress, dryRun)
+	source := fmt.Sprintf("%s:%s", pair.RemoteName, pair.RemotePath)
+	if err := m.checkDeletes(pair, source, pair.LocalPath, opts); err != nil {
+		return err
+	}
+	return m.rclone.SyncRemoteToLocalWithOptions(pair.RemoteName, pair.RemotePath, pair.LocalPath, opts)
+}
+
+// VolumeError reports a sync that was skipped because a drive the pair
+// uses is not connected
+type VolumeError struct {
+	Pair   string
+	Volume string // e.g. /Volumes/Backup
+}
+
+// Error implements the error interface
+func (e *VolumeError) Error() string {
+	return fmt.Sprintf("%s is not connected, skipped '%s'", e.Volume, e.Pair)
+}
 
-	for _, pair := range pairs {
-		if err := m.SyncPair(pair.Name, progress, dryRun); err != nil {
-			return fmt.Errorf("failed to sync '%s': %w", pair.Name, err)
+// checkVolumes returns a *VolumeError, and notes the skipped run in the
+// pair's log, if one of the pair's drives is not mounted
+func (m *Manager) checkVolumes(pair *syncconfig.SyncPair) error {
+	for _, v := range m.volumes(pair) {
+		if !volume.Mounted(v) {
+			m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Sync Skipped: %s is not mounted", v))
+			return &VolumeError{Pair: pair.Name, Volume: v}
 		}
 	}
+	return nil
+}
+
+// volumes returns the drives a pair uses: those of its folders, and those
+// of its destinations on local and alias remotes, such as a NAS share
+// mounted under /Volumes. Syncing to a share that is not mounted would
+// fill a folder of that name on the startup disk instead.
+func (m *Manager) volumes(pair *syncconfig.SyncPair) []string {
+	volumes := pair.Volumes()
+	for _, dest := range pair.AllDestinations() {
+		folder, ok := m.rclone.LocalFolder(dest.RemoteName, dest.RemotePath)
+		if !ok {
+			continue
+		}
+		if v, ok := volume.Of(folder); ok && !slices.Contains(volumes, v) {
+			volumes = append(volumes, v)
+		}
+	}
+	return volumes
+}
+
+// NetworkError reports a sync that was skipped because of the network the
+// Mac is on, e.g. a personal hotspot the pair should not use
+type NetworkError struct {
+	Pair   string
+	Reason error // e.g. network.ErrHotspot
+}
+
+// Error implements the error interface
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("%v, skipped '%s'", e.Reason, e.Pair)
+}
+
+// Unwrap returns the reason
+func (e *NetworkError) Unwrap() error {
+	return e.Reason
+}
 
+// checkNetwork returns a *NetworkError, and notes the skipped run in the
+// pair's log, if the current network does not allow a remote pair to sync.
+// Inspecting the network takes up to the probe's timeout, so the status is
+// read once and reused for the other pairs the manager syncs.
+func (m *Manager) checkNetwork(pair *syncconfig.SyncPair) error {
+	if m.config.Network == nil || pair.IsLocal() {
+		return nil
+	}
+	if m.networkStatus == nil {
+		status := m.config.Network.Status()
+		m.networkStatus = &status
+	}
+	if err := m.networkStatus.Allows(pair.NetworkRules()); err != nil {
+		m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Sync Skipped: %v", err))
+		return &NetworkError{Pair: pair.Name, Reason: err}
+	}
 	return nil
 }
 
-// validateConfig validates the manager configuration
-func validateConfig(config *Config) error {
-	if config.Username == "" {
-		return fmt.Errorf("Username is required")
+// Skipped reports whether err means a pair was skipped rather than failed,
+// because a drive is disconnected, the network does not allow the sync or
+// the run waits for power
+func Skipped(err error) bool {
+	var volumeErr *VolumeError
+	var networkErr *NetworkError
+	var powerErr *PowerError
+	return errors.As(err, &volumeErr) || errors.As(err, &networkErr) || errors.As(err, &powerErr)
+}
+
+// AllowDeletes turns the delete limit check off or back on for the syncs
+// that follow, e.g. once the user has confirmed a large delete
+func (m *Manager) AllowDeletes(allow bool) {
+	m.allowDeletes = allow
+}
+
+// DeleteLimitError reports a sync that was not run because it would delete
+// more of the destination's files than the pair's limit allows
+type DeleteLimitError struct {
+	Pair        string
+	Destination string // "remote:path" or a local folder
+	Plan        rclone.DeletePlan
+	Limit       int // Percent of the destination's files
+}
+
+// Error implements the error interface
+func (e *DeleteLimitError) Error() string {
+	return fmt.Sprintf("sync would delete %d of %d files (%.0f%%) in %s, more than the %d%% limit of '%s'",
+		e.Plan.Deletes, e.Plan.DestFiles, e.Plan.Percent(), e.Destination, e.Limit, e.Pair)
+}
+
+// checkDeletes dry-runs a sync of source to dest first and returns a
+// *DeleteLimitError if it would delete more than the pair allows. Dry runs,
+// copy and move pairs, and syncs after AllowDeletes are not checked.
+func (m *Manager) checkDeletes(pair *syncconfig.SyncPair, source, dest string, opts rclone.SyncOptions) error {
+	return m.checkDeletePlan(pair, dest, opts.DryRun, func() (rclone.DeletePlan, error) {
+		return m.rclone.PreviewDeletes(source, dest, opts)
+	})
+}
+
+// checkUpload runs the delete limit and size budget checks of an upload of
+// a pair to dest. The dry run both need is run once, by the first check
+// that needs it, and shared.
+func (m *Manager) checkUpload(pair *syncconfig.SyncPair, dest string, opts rclone.SyncOptions) error {
+	var plan *rclone.TransferPlan
+	preview := func() (rclone.TransferPlan, error) {
+		if plan == nil {
+			previewed, err := m.rclone.PreviewTransfer(pair.LocalPath, dest, opts)
+			if err != nil {
+				return rclone.TransferPlan{}, err
+			}
+			plan = &previewed
+		}
+		return *plan, nil
+	}
+
+	err := m.checkDeletePlan(pair, dest, opts.DryRun, func() (rclone.DeletePlan, error) {
+		previewed, err := preview()
+		if err != nil {
+			return rclone.DeletePlan{}, err
+		}
+		return m.rclone.PlanDeletes(previewed, dest, opts)
+	})
+	if err != nil {
+		return err
+	}
+	return m.checkBudget(pair, dest, opts.DryRun, preview)
+}
+
+// checkDeletePlan runs preview when the pair's syncs to dest are checked
+// and returns a *DeleteLimitError if the plan deletes more than allowed
+func (m *Manager) checkDeletePlan(pair *syncconfig.SyncPair, dest string, dryRun bool, preview func() (rclone.DeletePlan, error)) error {
+	limit := pair.DeleteLimit()
+	if m.allowDeletes || dryRun || limit >= 100 || pair.TransferMode() != syncconfig.ModeSync {
+		return nil
+	}
+
+	plan, err := preview()
+	if err != nil {
+		return fmt.Errorf("failed to check deletes: %w", err)
+	}
+	if plan.Exceeds(limit) {
+		return &DeleteLimitError{Pair: pair.Name, Destination: dest, Plan: plan, Limit: limit}
+	}
+	m.warnPlannedDeletes(pair, dest, plan)
+	return nil
+}
+
+// DestinationResult is the outcome of pushing a sync pair to one destination
+type DestinationResult struct {
+	Destination syncconfig.Destination
+	Duration    time.Duration
+	Err         error
+}
+
+// ReplicationError reports the destinations that failed during a
+// multi-destination sync; the other destinations were synced successfully
+type ReplicationError struct {
+	Pair    string
+	Results []DestinationResult
+}
+
+// Failed returns the results of the destinations that failed
+func (e *ReplicationError) Failed() []DestinationResult {
+	failed := make([]DestinationResult, 0)
+	for _, result := range e.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Error implements the error interface
+func (e *ReplicationError) Error() string {
+	failed := e.Failed()
+	parts := make([]string, 0, len(failed))
+	for _, result := range failed {
+		parts = append(parts, fmt.Sprintf("%s: %v", result.Destination, result.Err))
+	}
+	return fmt.Sprintf("%d of %d destinations failed for '%s': %s",
+		len(failed), len(e.Results), e.Pair, strings.Join(parts, "; "))
+}
+
+// Unwrap returns the errors of the failed destinations, so errors.As finds
+// e.g. a *DeleteLimitError among them
+func (e *ReplicationError) Unwrap() []error {
+	errs := make([]error, 0)
+	for _, result := range e.Failed() {
+		errs = append(errs, result.Err)
+	}
+	return errs
+}
+
+// replicationError returns a ReplicationError if any destination failed
+func replicationError(pairName string, results []DestinationResult) error {
+	for _, result := range results {
+		if result.Err != nil {
+			return &ReplicationError{Pair: pairName, Results: results}
+		}
+	}
+	return nil
+}
+
+// SyncPairDestinations uploads a sync pair to each of its destinations and
+// reports the outcome per destination. A failing destination does not stop
+// the remaining ones.
+func (m *Manager) SyncPairDestinations(name string, progress bool, dryRun bool) ([]DestinationResult, error) {
+	pair, err := m.getPair(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if pair.Direction != "upload" || pair.IsLocal() {
+		return nil, fmt.Errorf("sync pair '%s' is not an upload pair", name)
+	}
+
+	if err := m.checkVolumes(pair); err != nil {
+		return nil, err
+	}
+
+	if err := m.checkNetwork(pair); err != nil {
+		return nil, err
+	}
+
+	if err := m.checkPower(pair); err != nil {
+		return nil, err
+	}
+
+	if err := syncconfig.ValidateLocalPath(pair.LocalPath); err != nil {
+		return nil, fmt.Errorf("local path validation failed: %w", err)
+	}
+
+	if err := m.writeIgnoreFilter(pair); err != nil {
+		return nil, err
+	}
+
+	if err := m.rclone.Require(rclone.FeatureBaseline); err != nil {
+		return nil, err
+	}
+
+	m.indexSource(pair, dryRun)
+
+	results := m.syncDestinations(pair, progress, dryRun)
+	return results, replicationError(pair.Name, results)
+}
+
+// syncDestinations uploads a pair to every destination in order
+func (m *Manager) syncDestinations(pair *syncconfig.SyncPair, progress bool, dryRun bool) []DestinationResult {
+	if pair.Archive {
+		return m.archiveDestinations(pair, progress, dryRun)
+	}
+
+	dests := pair.AllDestinations()
+	results := make([]DestinationResult, 0, len(dests))
+
+	for _, dest := range dests {
+		start := time.Now()
+		err := m.uploadDestination(pair, dest, progress, dryRun)
+		results = append(results, DestinationResult{
+			Destination: dest,
+			Duration:    time.Since(start),
+			Err:         err,
+		})
+	}
+
+	return results
+}
+
+// uploadDestination pushes a pair to one destination, either syncing in
+// place or, in snapshot mode, copying into today's snapshot and pruning
+func (m *Manager) uploadDestination(pair *syncconfig.SyncPair, dest syncconfig.Destination, progress bool, dryRun bool) error {
+	if err := m.checkCredentials(dest.RemoteName); err != nil {
+		return err
+	}
+
+	opts := m.uploadOptions(pair, dest, progress, dryRun)
+
+	if !pair.Snapshot {
+		if err := m.checkUpload(pair, dest.String(), opts); err != nil {
+			return err
+		}
+		return m.rclone.SyncLocalToRemoteWithOptions(pair.LocalPath, dest.RemoteName, dest.RemotePath, opts)
 	}
-	if config.SourceRemote == "" {
-		return fmt.Errorf("SourceRemote is required")
+
+	if err := m.rclone.CopySnapshot(pair.LocalPath, dest.RemoteName, dest.RemotePath, time.Now(), opts); err != nil {
+		return err
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if _, err := m.rclone.PruneSnapshots(dest.RemoteName, dest.RemotePath, pair.SnapshotKeep, pair.SnapshotMaxAge()); err != nil {
+		return fmt.Errorf("snapshot pruning failed: %w", err)
+	}
+
+	return nil
+}
+
+// ListSnapshots lists the snapshots of a snapshot-mode pair's primary destination
+func (m *Manager) ListSnapshots(name string) ([]rclone.Snapshot, error) {
+	pair, err := m.getPair(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.rclone.ListSnapshots(pair.RemoteName, pair.RemotePath)
+}
+
+// uploadOptions returns the rclone options for uploading a pair to a destination
+func (m *Manager) uploadOptions(pair *syncconfig.SyncPair, dest syncconfig.Destination, progress bool, dryRun bool) rclone.SyncOptions {
+	opts := m.downloadOptions(pair, progress, dryRun)
+
+	if pair.SoftDelete {
+		// The trash mirrors the whole pair, so a subtree's files go to the
+		// same place in it as in a full sync
+		trash := path.Join(m.rclone.TrashPathFor(dest.RemoteName, dest.RemotePath, pair.Name, time.Now()), filepath.ToSlash(pair.Subpath))
+		opts.BackupDir = fmt.Sprintf("%s:%s", dest.RemoteName, trash)
+		opts.Excludes = append(opts.Excludes, rclone.TrashExclude())
+	}
+
+	return opts
+}
+
+// downloadOptions returns the rclone options for syncing a pair's remote
+// down to its local folder. rclone's log goes to the pair's own log file.
+func (m *Manager) downloadOptions(pair *syncconfig.SyncPair, progress bool, dryRun bool) rclone.SyncOptions {
+	return rclone.SyncOptions{
+		Progress:   progress,
+		DryRun:     dryRun,
+		LogFile:    m.logs.PairLogPath(pair.Name),
+		FilterFrom: m.ignoreFilter(pair),
+		Command:    pair.TransferMode(),
+		ExtraFlags: pair.ExtraFlags,
+		RCAddr:     m.rcAddr,
+		SkipHidden: pair.SkipHidden,
+		SkipLinks:  pair.SkipSymlinks,
+		MaxSize:    pair.SkipLargerThan,
+	}
+}
+
+// ListTrash lists the soft-deleted snapshots for a sync pair
+func (m *Manager) ListTrash(name string) ([]rclone.TrashEntry, error) {
+	pair, err := m.getPair(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.rclone.ListTrash(pair.RemoteName, pair.RemotePath, pair.Name)
+}
+
+// PurgeExpiredTrash removes trash older than the pair's retention period
+func (m *Manager) PurgeExpiredTrash(name string) (int, error) {
+	pair, err := m.getPair(name)
+	if err != nil {
+		return 0, err
 	}
-	if config.SourceBucket == "" {
-		return fmt.Errorf("SourceBucket is required")
+
+	return m.rclone.PurgeTrashOlderThan(pair.RemoteName, pair.RemotePath, pair.Name, pair.TrashRetention())
+}
+
+// SyncAllEnabled syncs every enabled sync pair in turn and reports the
+// outcome per pair. A failing pair does not stop the remaining ones; the
+// error is then a *SyncAllError. Pairs on a disconnected drive, a
+// disallowed network or waiting for power are skipped, not failed.
+func (m *Manager) SyncAllEnabled(progress bool, dryRun bool) ([]PairResult, error) {
+	pairs, err := m.enabledPairs(func(syncconfig.SyncPair) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no enabled sync pairs found")
+	}
+	return m.syncEach(pairs, progress, dryRun)
+}
+
+// SyncGroup syncs the enabled pairs of a group like SyncAllEnabled. The
+// pairs are those listed under the group, whose ListGroup is group with
+// the same case; tags the pairs have besides are not looked at.
+func (m *Manager) SyncGroup(group string, progress bool, dryRun bool) ([]PairResult, error) {
+	pairs, err := m.enabledPairs(func(pair syncconfig.SyncPair) bool { return pair.ListGroup() == group })
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no enabled sync pairs in group '%s'", group)
+	}
+	return m.syncEach(pairs, progress, dryRun)
+}
+
+// enabledPairs returns the enabled pairs keep accepts, leaving out monitor
+// pairs, which are only checked
+func (m *Manager) enabledPairs(keep func(syncconfig.SyncPair) bool) ([]syncconfig.SyncPair, error) {
+	enabled, err := m.syncconfig.ListEnabledSyncPairs()
+	if err != nil {
+		return nil, err
+	}
+	var pairs []syncconfig.SyncPair
+	for _, pair := range enabled {
+		if !pair.IsMonitor() && keep(pair) {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs, nil
+}
+
+// syncEach syncs pairs in turn, telling the pairs progress function, and
+// returns a *SyncAllError if any failed
+func (m *Manager) syncEach(pairs []syncconfig.SyncPair, progress bool, dryRun bool) ([]PairResult, error) {
+	results := make([]PairResult, 0, len(pairs))
+	failed := false
+	for i, pair := range pairs {
+		if m.onPairs != nil {
+			m.onPairs(pair.Name, i, len(pairs))
+		}
+		start := time.Now()
+		err := m.SyncPair(pair.Name, progress, dryRun)
+		results = append(results, PairResult{Pair: pair.Name, Duration: time.Since(start), Err: err})
+		failed = failed || err != nil && !Skipped(err)
 	}
-	if config.DestRemote == "" {
-		return fmt.Errorf("DestRemote is required")
+	if m.onPairs != nil {
+		m.onPairs("", len(pairs), len(pairs))
 	}
-	if config.DestBucket == "" {
-		return fmt.Errorf("DestBucket is required")
+
+	if failed {
+		return results, &SyncAllError{Results: results}
+	}
+	return results, nil
+}
+
+// validateConfig validates the manager configuration
+// The bucket-to-bucket remotes are only needed for the generated scripts,
+// which GenerateScripts validates, so sync pairs work without them.
+func validateConfig(config *Config) error {
+	if config.Username == "" {
+		return fmt.Errorf("Username is required")
 	}
 	return nil
 }
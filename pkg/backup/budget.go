@@ -0,0 +1,84 @@
+package backup
+
+import (
+	"fmt"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// BudgetWarningFunc receives a warning about an upload that grows a
+// destination past its pair's size budget, for pairs set to warn only
+type BudgetWarningFunc func(pair, warning string)
+
+// SetBudgetWarning sets the function told about uploads over budget that
+// go ahead. Warnings are also written to the pair's log either way.
+func (m *Manager) SetBudgetWarning(onWarning BudgetWarningFunc) {
+	m.onBudget = onWarning
+}
+
+// AllowOverBudget turns the size budget check off or back on for the
+// syncs that follow, e.g. once the user has confirmed going over it
+func (m *Manager) AllowOverBudget(allow bool) {
+	m.allowOverBudget = allow
+}
+
+// BudgetError reports an upload that was not run because it would grow
+// its destination past the pair's size budget
+type BudgetError struct {
+	Pair        string
+	Destination string // "remote:path"
+	Current     int64  // Bytes at the destination before the sync
+	Projected   int64  // Bytes expected there after it
+	Budget      int64
+}
+
+// Error implements the error interface
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("sync would grow %s from %s to %s, over the %s budget of '%s'",
+		e.Destination, formatGB(e.Current), formatGB(e.Projected), formatGB(e.Budget), e.Pair)
+}
+
+// formatGB formats bytes in the decimal gigabytes budgets are set in
+func formatGB(bytes int64) string {
+	return fmt.Sprintf("%.2f GB", float64(bytes)/1e9)
+}
+
+// checkBudget sizes dest and projects its size after the upload previewed
+// by preview, returning a *BudgetError if that is over the pair's budget.
+// Files the upload replaces are counted in full, so the projection errs
+// high. With soft delete, deleted and replaced files move to the trash in
+// the same remote path and still count. Dry runs, pairs without a budget
+// and syncs after AllowOverBudget are not checked.
+func (m *Manager) checkBudget(pair *syncconfig.SyncPair, dest string, dryRun bool, preview func() (rclone.TransferPlan, error)) error {
+	budget := pair.SizeBudget()
+	if budget == 0 || dryRun || m.allowOverBudget {
+		return nil
+	}
+
+	current, err := m.rclone.Size(dest)
+	if err != nil {
+		return fmt.Errorf("failed to check the size budget: %w", err)
+	}
+	plan, err := preview()
+	if err != nil {
+		return fmt.Errorf("failed to check the size budget: %w", err)
+	}
+	projected := current + plan.CopyBytes
+	if !pair.SoftDelete {
+		projected -= plan.DeleteBytes
+	}
+	if projected <= budget {
+		return nil
+	}
+
+	budgetErr := &BudgetError{Pair: pair.Name, Destination: dest, Current: current, Projected: projected, Budget: budget}
+	if !pair.BudgetWarnOnly {
+		return budgetErr
+	}
+	m.logs.LogPairEvent(pair.Name, "Budget Warning: "+budgetErr.Error())
+	if m.onBudget != nil {
+		m.onBudget(pair.Name, budgetErr.Error())
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// PowerError reports a scheduled sync that was deferred because the Mac is
+// on battery below the pair's threshold
+type PowerError struct {
+	Pair    string
+	Percent int // Current battery charge
+	Min     int // The pair's MinBatteryPercent
+}
+
+// Error implements the error interface
+func (e *PowerError) Error() string {
+	return fmt.Sprintf("on battery at %d%%, below the %d%% '%s' needs; deferred until power is connected", e.Percent, e.Min, e.Pair)
+}
+
+// SetScheduled marks the syncs that follow as scheduled runs, which are
+// deferred while the Mac is on battery below a pair's threshold
+func (m *Manager) SetScheduled(scheduled bool) {
+	m.scheduled = scheduled
+}
+
+// checkPower returns a *PowerError, notes the deferred run in the pair's
+// log and remembers the pair for DeferredPairs, if a scheduled run has to
+// wait for power. A pair that may run is forgotten again.
+func (m *Manager) checkPower(pair *syncconfig.SyncPair) error {
+	if !m.scheduled || m.config.Power == nil || pair.MinBatteryPercent == 0 {
+		return nil
+	}
+	status := m.config.Power.Status()
+	if !status.Defers(pair.MinBatteryPercent) {
+		return m.setDeferred(pair.Name, false)
+	}
+
+	m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Sync Deferred: on battery at %d%%", status.Percent))
+	if err := m.setDeferred(pair.Name, true); err != nil {
+		return err
+	}
+	return &PowerError{Pair: pair.Name, Percent: status.Percent, Min: pair.MinBatteryPercent}
+}
+
+// deferredPath is the file listing the pairs waiting for power
+func (m *Manager) deferredPath() string {
+	return filepath.Join(m.config.LogDir, "deferred_pairs.json")
+}
+
+// DeferredPairs returns the names of the pairs whose scheduled sync was
+// deferred until power is connected
+func (m *Manager) DeferredPairs() ([]string, error) {
+	data, err := os.ReadFile(m.deferredPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deferred pairs: %w", err)
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("failed to parse deferred pairs: %w", jsonfile.NewCorruptError(m.deferredPath(), err))
+	}
+	return names, nil
+}
+
+// setDeferred adds a pair to the deferred pairs or removes it. The file is
+// only written when that changes it.
+func (m *Manager) setDeferred(name string, deferred bool) error {
+	unlock, err := jsonfile.Lock(m.deferredPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	names, err := m.DeferredPairs()
+	if err != nil {
+		return err
+	}
+	kept := make([]string, 0, len(names)+1)
+	found := false
+	for _, n := range names {
+		if n == name {
+			found = true
+			if !deferred {
+				continue
+			}
+		}
+		kept = append(kept, n)
+	}
+	if found == deferred {
+		return nil
+	}
+	if deferred {
+		kept = append(kept, name)
+	}
+	return jsonfile.Write(m.deferredPath(), kept, 0600)
+}
@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andreisuslov/cloud-sync/internal/ignore"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// filterPath is where the filter rules translated from a pair's
+// .cloudsyncignore files are written
+func (m *Manager) filterPath(pair *syncconfig.SyncPair) string {
+	name := strings.TrimSuffix(logs.PairLogName(pair.Name), ".log")
+	return filepath.Join(m.config.HomeDir, ".cache", "cloud-sync", "filters", name+".filter")
+}
+
+// writeIgnoreFilter translates the .cloudsyncignore files in a pair's
+// folder into a filter file for rclone or rsync, relative to the folder
+// being synced. The ignore files travel with the data and can change
+// between runs, so they are read at every sync, and the filter file is
+// removed once there are none.
+func (m *Manager) writeIgnoreFilter(pair *syncconfig.SyncPair) error {
+	// Ignore files above a subtree still apply to it
+	rules, err := ignore.Load(pair.Root())
+	if err != nil {
+		return fmt.Errorf("failed to read ignore files: %w", err)
+	}
+	if pair.Subpath != "" {
+		rules = ignore.Rebase(rules, filepath.ToSlash(pair.Subpath))
+	}
+	path := m.filterPath(pair)
+	if len(rules) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove filter file: %w", err)
+		}
+		return nil
+	}
+	return ignore.WriteFilter(rules, path)
+}
+
+// ignoreFilter returns the pair's filter file written by
+// writeIgnoreFilter, or "" if its folder has no ignore files
+func (m *Manager) ignoreFilter(pair *syncconfig.SyncPair) string {
+	path := m.filterPath(pair)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
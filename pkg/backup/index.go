@@ -0,0 +1,51 @@
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// IndexProgressFunc receives the running totals of a pair's index pass
+type IndexProgressFunc func(pair string, files int, bytes int64)
+
+// SetIndexProgress sets the function told how the index pass of pairs
+// with IndexFirst is getting on
+func (m *Manager) SetIndexProgress(onProgress IndexProgressFunc) {
+	m.onIndex = onProgress
+}
+
+// IndexCache returns the cache holding the listings of IndexFirst pairs
+func (m *Manager) IndexCache() *rclone.IndexCache {
+	return rclone.NewIndexCache(filepath.Join(m.config.HomeDir, ".cache", "cloud-sync", "index"))
+}
+
+// indexSource lists every file of an IndexFirst pair's source before it
+// syncs, records the totals in the pair's log and returns the index, whose
+// totals the pair's transfer progress then reports. The index only
+// informs, so a failed listing is logged, nil is returned and the sync goes
+// ahead.
+func (m *Manager) indexSource(pair *syncconfig.SyncPair, dryRun bool) *rclone.Index {
+	if !pair.IndexFirst || dryRun {
+		return nil
+	}
+
+	source := pair.LocalPath
+	if pair.Direction == "download" {
+		source = fmt.Sprintf("%s:%s", pair.RemoteName, pair.RemotePath)
+	}
+
+	index, err := m.rclone.BuildIndex(source, m.IndexCache(), func(files int, bytes int64) {
+		if m.onIndex != nil {
+			m.onIndex(pair.Name, files, bytes)
+		}
+	})
+	if err != nil {
+		m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Index Failed: %v", err))
+		return nil
+	}
+	m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Indexed %d files, %d bytes", index.Files, index.Bytes))
+	return index
+}
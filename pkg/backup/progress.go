@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+)
+
+// TransferProgress is how far rclone has got with a transfer: the files
+// and bytes transferred so far, out of the totals it has found to transfer.
+// For a pair indexed first, TotalFiles is the number of files in its source
+// and Files counts those checked or transferred.
+type TransferProgress struct {
+	Files      int
+	TotalFiles int
+	Bytes      int64
+	TotalBytes int64
+}
+
+// TransferProgressFunc receives the progress of a pair's rclone transfer.
+// Each destination of a pair is a transfer of its own, counted from zero.
+type TransferProgressFunc func(pair string, progress TransferProgress)
+
+// PairsProgressFunc is told which pair SyncAllEnabled or SyncGroup starts
+// next and how many of the total it has synced
+type PairsProgressFunc func(pair string, done, total int)
+
+// transferPollInterval is how often a pair's transfer progress is read
+// from rclone
+const transferPollInterval = time.Second
+
+// SetTransferProgress sets the function told how the rclone transfers of
+// the pair being synced are getting on. While it is set, rclone serves its
+// remote control API on a free local port during each transfer.
+func (m *Manager) SetTransferProgress(onProgress TransferProgressFunc) {
+	m.onTransfer = onProgress
+}
+
+// SetPairsProgress sets the function told as SyncAllEnabled or SyncGroup
+// moves from one pair to the next
+func (m *Manager) SetPairsProgress(onProgress PairsProgressFunc) {
+	m.onPairs = onProgress
+}
+
+// watchTransfers makes the rclone transfers of a pair serve the remote
+// control API and reports their stats to the transfer progress function
+// until the returned function is called, counting files against index if
+// the source was indexed first. Without a progress function, or a free
+// port, it does nothing.
+func (m *Manager) watchTransfers(pair string, index *rclone.Index) (stop func()) {
+	if m.onTransfer == nil {
+		return func() {}
+	}
+	addr, err := rclone.FreeRCAddr()
+	if err != nil {
+		return func() {}
+	}
+	m.rcAddr = addr
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	onProgress := m.onTransfer
+	go func() {
+		defer close(finished)
+		client := rclone.NewRCClient(addr)
+		ticker := time.NewTicker(transferPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// Nothing answers between transfers
+				stats, err := client.Stats()
+				if err != nil {
+					continue
+				}
+				progress := TransferProgress{
+					Files:      stats.Transfers,
+					TotalFiles: stats.TotalTransfers,
+					Bytes:      stats.Bytes,
+					TotalBytes: stats.TotalBytes,
+				}
+				if index != nil {
+					progress.Files = min(stats.Checks+stats.Transfers, index.Files)
+					progress.TotalFiles = index.Files
+				}
+				onProgress(pair, progress)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		m.rcAddr = ""
+	}
+}
+
+// PairResult is the outcome of syncing one of several pairs
+type PairResult struct {
+	Pair     string
+	Duration time.Duration
+	Err      error
+}
+
+// Skipped reports whether the pair was skipped rather than failed, e.g.
+// because its drive is not connected
+func (r PairResult) Skipped() bool {
+	return r.Err != nil && Skipped(r.Err)
+}
+
+// SyncAllError reports the pairs that failed during SyncAllEnabled or
+// SyncGroup; the other pairs were synced or skipped
+type SyncAllError struct {
+	Results []PairResult
+}
+
+// Failed returns the results of the pairs that failed
+func (e *SyncAllError) Failed() []PairResult {
+	failed := make([]PairResult, 0)
+	for _, result := range e.Results {
+		if result.Err != nil && !result.Skipped() {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Error implements the error interface
+func (e *SyncAllError) Error() string {
+	failed := e.Failed()
+	parts := make([]string, 0, len(failed))
+	for _, result := range failed {
+		parts = append(parts, fmt.Sprintf("'%s': %v", result.Pair, result.Err))
+	}
+	return fmt.Sprintf("%d of %d pairs failed: %s", len(failed), len(e.Results), strings.Join(parts, "; "))
+}
+
+// Unwrap returns the errors of the failed pairs
+func (e *SyncAllError) Unwrap() []error {
+	errs := make([]error, 0)
+	for _, result := range e.Failed() {
+		errs = append(errs, result.Err)
+	}
+	return errs
+}
@@ -0,0 +1,25 @@
+package backup
+
+import (
+	"fmt"
+
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// writeReport writes the report of the run that just ended, the last
+// session in the pair's log, into the log directory. A report that cannot
+// be written is noted in the pair's log and does not fail the run.
+func (m *Manager) writeReport(pair *syncconfig.SyncPair) {
+	pairLogs := m.logs.ForPair(pair.Name)
+	sessions, err := pairLogs.GetSyncSessions()
+	if err != nil || len(sessions) == 0 {
+		return
+	}
+	detail, err := pairLogs.GetSessionDetail(sessions[len(sessions)-1])
+	if err == nil {
+		_, err = pairLogs.WriteReport(detail, m.config.ReportFormat, m.config.ReportsKept)
+	}
+	if err != nil {
+		m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Report Failed: %v", err))
+	}
+}
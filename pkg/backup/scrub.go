@@ -0,0 +1,146 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// ErrScrubUnsupported reports a pair the integrity scrub cannot check,
+// since its destination holds dated copies rather than a mirror
+var ErrScrubUnsupported = errors.New("snapshot and archive pairs cannot be scrubbed")
+
+// Scrub compares a pair's folder with each of its destinations by hash and
+// records each comparison in the scrub history, which the dashboard warns
+// about until acknowledged. Upload pairs are checked against every
+// destination, download and bidirectional pairs against the primary one,
+// local pairs against their target folder, and monitor pairs against what
+// they monitor. The returned runs include failed checks, which are
+// recorded too.
+func (m *Manager) Scrub(name string) ([]logs.ScrubRun, error) {
+	pair, err := m.getPair(name)
+	if err != nil {
+		return nil, err
+	}
+	if pair.Snapshot || pair.Archive {
+		return nil, ErrScrubUnsupported
+	}
+	if m.lockfile.Exists() {
+		return nil, errkind.Wrap(errkind.ErrLocked, fmt.Errorf("backup already running (lockfile exists at %s)", m.lockfile.GetPath()))
+	}
+	if err := m.checkVolumes(pair); err != nil {
+		return nil, err
+	}
+	if err := m.writeIgnoreFilter(pair); err != nil {
+		return nil, err
+	}
+	if err := m.rclone.Require(rclone.FeatureCheck); err != nil {
+		return nil, err
+	}
+
+	opts := rclone.SyncOptions{
+		FilterFrom: m.ignoreFilter(pair),
+		Command:    pair.TransferMode(),
+		SkipHidden: pair.SkipHidden,
+		SkipLinks:  pair.SkipSymlinks,
+		MaxSize:    pair.SkipLargerThan,
+	}
+	if pair.SoftDelete {
+		opts.Excludes = append(opts.Excludes, rclone.TrashExclude())
+	}
+
+	var checks [][2]string // Source and destination of each comparison
+	switch {
+	case pair.IsLocal(), pair.IsMonitor():
+		checks = append(checks, [2]string{pair.LocalPath, pair.Target()})
+	case pair.Direction == "download":
+		checks = append(checks, [2]string{fmt.Sprintf("%s:%s", pair.RemoteName, pair.RemotePath), pair.LocalPath})
+	case pair.Direction == "upload":
+		for _, dest := range pair.AllDestinations() {
+			checks = append(checks, [2]string{pair.LocalPath, dest.String()})
+		}
+	default:
+		checks = append(checks, [2]string{pair.LocalPath, pair.AllDestinations()[0].String()})
+	}
+
+	m.logs.LogPairEvent(pair.Name, "Scrub Started")
+	runs := make([]logs.ScrubRun, 0, len(checks))
+	for _, check := range checks {
+		run := m.scrubOne(pair, check[0], check[1], opts)
+		if err := m.logs.RecordScrub(run); err != nil {
+			return runs, err
+		}
+		m.logs.LogPairEvent(pair.Name, fmt.Sprintf("Scrub %s: %s", check[1], run.Summary()))
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// scrubOne compares source with dest for a pair
+func (m *Manager) scrubOne(pair *syncconfig.SyncPair, source, dest string, opts rclone.SyncOptions) logs.ScrubRun {
+	run := logs.ScrubRun{Pair: pair.Name, Destination: dest, Time: time.Now()}
+	result, err := m.rclone.Check(source, dest, opts)
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+
+	run.Matched = result.Matched
+	run.Differ = len(result.Differ)
+	run.MissingOnDest = len(result.MissingOnDest)
+	run.MissingOnSource = len(result.MissingOnSource)
+	run.Errors = len(result.Errors)
+	run.SizeOnly = result.SizeOnly
+	for _, files := range [][]string{result.Differ, result.MissingOnDest, result.MissingOnSource, result.Errors} {
+		run.Files = append(run.Files, files...)
+	}
+	return run
+}
+
+// ScrubPairs returns the names of the enabled pairs with Scrub set, which
+// a scheduled scrub checks
+func (m *Manager) ScrubPairs() ([]string, error) {
+	pairs, err := m.syncconfig.ListEnabledSyncPairs()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pair := range pairs {
+		if pair.Scrub {
+			names = append(names, pair.Name)
+		}
+	}
+	return names, nil
+}
+
+// MonitorPairs returns the names of the enabled monitor pairs, which a
+// scheduled check compares
+func (m *Manager) MonitorPairs() ([]string, error) {
+	pairs, err := m.syncconfig.ListEnabledSyncPairs()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pair := range pairs {
+		if pair.IsMonitor() {
+			names = append(names, pair.Name)
+		}
+	}
+	return names, nil
+}
+
+// ScrubHistory returns the recorded scrub runs, oldest first
+func (m *Manager) ScrubHistory() ([]logs.ScrubRun, error) {
+	return m.logs.ScrubHistory()
+}
+
+// AcknowledgeScrubs clears the dashboard's scrub warning and returns how
+// many runs with problems it marked as seen
+func (m *Manager) AcknowledgeScrubs() (int, error) {
+	return m.logs.AcknowledgeScrubs()
+}
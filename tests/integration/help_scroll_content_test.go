@@ -124,7 +124,7 @@ func TestHelpMenuScrollToBottomAndBack(t *testing.T) {
 	m = mAny.(ui.Model)
 
 	// Scroll to bottom by pressing down many times
-	for i := 0; i < 50; i++ {
+	for i := 0; i < 200; i++ {
 		mAny, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
 		m = mAny.(ui.Model)
 	}
@@ -136,7 +136,7 @@ func TestHelpMenuScrollToBottomAndBack(t *testing.T) {
 	assert.Equal(100, scrollPercent, "At bottom, scroll should be 100%")
 
 	// Scroll back to top by pressing up many times
-	for i := 0; i < 50; i++ {
+	for i := 0; i < 200; i++ {
 		mAny, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
 		m = mAny.(ui.Model)
 	}
@@ -0,0 +1,62 @@
+package testutil
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update rewrites golden files with the current output instead of comparing:
+//
+//	go test ./tests/unit -run Golden -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// GoldenPath returns the golden file for name, relative to the test's
+// package directory
+func GoldenPath(name string) string {
+	return filepath.Join("testdata", name+".golden")
+}
+
+// AssertGolden compares got with the golden file for name, reporting the
+// first line that differs. With -update the file is written instead.
+func AssertGolden(t testing.TB, name, got string) {
+	t.Helper()
+	path := GoldenPath(name)
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create testdata directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if string(want) == got {
+		return
+	}
+
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; ; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			t.Errorf("%s differs from %s at line %d (run with -update to accept):\nwant: %q\ngot:  %q\n\nfull output:\n%s",
+				name, path, i+1, w, g, got)
+			return
+		}
+	}
+}
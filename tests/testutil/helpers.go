@@ -1,3 +0,0 @@
-package testutil
-
-// Placeholder for test utility functions
@@ -0,0 +1,186 @@
+package testutil
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CmdTimeout is how long the harness waits for a command's message.
+// Commands that take longer, such as timers and file watches, are dropped.
+const CmdTimeout = 500 * time.Millisecond
+
+// maxCmdDepth limits how many rounds of follow-up commands Run delivers
+const maxCmdDepth = 4
+
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]`)
+
+// Harness drives a Bubble Tea model without a terminal: it sends messages
+// and keys, delivers the messages the resulting commands produce and
+// renders the view as plain text
+type Harness struct {
+	t     testing.TB
+	model tea.Model
+}
+
+// NewHarness wraps model and sizes it to width x height. Its Init command
+// is not run; call Init for views that load their content that way.
+func NewHarness(t testing.TB, model tea.Model, width, height int) *Harness {
+	t.Helper()
+	h := &Harness{t: t, model: model}
+	h.Send(tea.WindowSizeMsg{Width: width, Height: height})
+	return h
+}
+
+// Model returns the current model
+func (h *Harness) Model() tea.Model {
+	return h.model
+}
+
+// Init runs the model's Init command
+func (h *Harness) Init() *Harness {
+	h.t.Helper()
+	h.Run(h.model.Init())
+	return h
+}
+
+// Send delivers msg and then the messages of the commands it returns
+func (h *Harness) Send(msg tea.Msg) *Harness {
+	h.t.Helper()
+	var cmd tea.Cmd
+	h.model, cmd = h.model.Update(msg)
+	h.Run(cmd)
+	return h
+}
+
+// Press sends each key in turn, named as tea.KeyMsg.String() names them:
+// "enter", "esc", "up", "ctrl+c" or a single character
+func (h *Harness) Press(keys ...string) *Harness {
+	h.t.Helper()
+	for _, key := range keys {
+		h.Send(KeyMsg(key))
+	}
+	return h
+}
+
+// Type sends text one character at a time
+func (h *Harness) Type(text string) *Harness {
+	h.t.Helper()
+	for _, r := range text {
+		h.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return h
+}
+
+// Run executes cmd and delivers its messages, following the commands they
+// return a few levels deep. Spinner ticks are dropped so that snapshots
+// do not depend on animation frames.
+func (h *Harness) Run(cmd tea.Cmd) {
+	h.t.Helper()
+	h.run(cmd, 0)
+}
+
+func (h *Harness) run(cmd tea.Cmd, depth int) {
+	if cmd == nil || depth >= maxCmdDepth {
+		return
+	}
+
+	for _, msg := range collect(cmd) {
+		if _, ok := msg.(spinner.TickMsg); ok {
+			continue
+		}
+		var next tea.Cmd
+		h.model, next = h.model.Update(msg)
+		h.run(next, depth+1)
+	}
+}
+
+// collect runs cmd, flattening batches and sequences, and returns the
+// messages that arrive within CmdTimeout. Quit messages are not delivered.
+func collect(cmd tea.Cmd) []tea.Msg {
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(CmdTimeout):
+		return nil
+	}
+
+	switch msg := msg.(type) {
+	case nil, tea.QuitMsg:
+		return nil
+	case tea.BatchMsg:
+		return collectAll(msg)
+	}
+	// tea.Sequence's message type is unexported but is a slice of commands
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+		cmds := make([]tea.Cmd, v.Len())
+		for i := range cmds {
+			cmds[i], _ = v.Index(i).Interface().(tea.Cmd)
+		}
+		return collectAll(cmds)
+	}
+	return []tea.Msg{msg}
+}
+
+// collectAll collects the messages of each command in turn
+func collectAll(cmds []tea.Cmd) []tea.Msg {
+	var msgs []tea.Msg
+	for _, c := range cmds {
+		if c != nil {
+			msgs = append(msgs, collect(c)...)
+		}
+	}
+	return msgs
+}
+
+// View renders the model as plain text: colours and other escape codes are
+// removed, as is trailing space on each line
+func (h *Harness) View() string {
+	return PlainText(h.model.View())
+}
+
+// PlainText removes escape codes and trailing space from rendered output
+func PlainText(view string) string {
+	lines := strings.Split(ansiRe.ReplaceAllString(view, ""), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// KeyMsg returns the key message for a key name
+func KeyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "left":
+		return tea.KeyMsg{Type: tea.KeyLeft}
+	case "right":
+		return tea.KeyMsg{Type: tea.KeyRight}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case "pgdown":
+		return tea.KeyMsg{Type: tea.KeyPgDown}
+	case "pgup":
+		return tea.KeyMsg{Type: tea.KeyPgUp}
+	case "ctrl+c":
+		return tea.KeyMsg{Type: tea.KeyCtrlC}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
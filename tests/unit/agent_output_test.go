@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestAgentOutputPlist(t *testing.T) {
+	data, err := launchd.RenderPlist(&launchd.Config{
+		Label:      "com.tester.rclonebackup",
+		ScriptPath: "/backup.sh",
+		StdoutPath: "/Users/tester/logs/agent_stdout.log",
+		StderrPath: "/Users/tester/logs/agent_stderr.log",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<key>StandardOutPath</key>\n\t<string>/Users/tester/logs/agent_stdout.log</string>")
+	assert.Contains(t, string(data), "<key>StandardErrorPath</key>\n\t<string>/Users/tester/logs/agent_stderr.log</string>")
+
+	data, err = launchd.RenderPlist(&launchd.Config{Label: "com.tester.rclonebackup", ScriptPath: "/backup.sh"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "StandardOutPath")
+}
+
+func TestAgentOutputPaths(t *testing.T) {
+	stdout, stderr := config.LaunchAgentConfig{}.OutputPaths("/logs")
+	assert.Equal(t, "/logs/agent_stdout.log", stdout)
+	assert.Equal(t, "/logs/agent_stderr.log", stderr)
+
+	stdout, stderr = config.LaunchAgentConfig{StderrPath: "/var/log/backup.err"}.OutputPaths("/logs")
+	assert.Equal(t, "/logs/agent_stdout.log", stdout)
+	assert.Equal(t, "/var/log/backup.err", stderr)
+}
+
+func TestLogViewerAgentOutput(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeFiles(t, filepath.Join(home, "logs"), map[string]string{
+		config.AgentStderrName: "monthly_backup.sh:12: command not found: rclone\n",
+	})
+
+	var model tea.Model = views.NewLogViewerModel(logs.NewManager(filepath.Join(home, "logs")), views.LogViewAll, 120, 60)
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 120, Height: 60})
+	model, cmd := model.Update(keyPress("9"))
+	model, _ = model.Update(cmd())
+
+	view := model.View()
+	assert.Contains(t, view, "Agent output")
+	assert.Contains(t, view, "command not found: rclone")
+	assert.Contains(t, view, "Nothing written yet.", "standard output is empty")
+}
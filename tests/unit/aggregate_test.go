@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// aggregateLogs writes two pair logs with sessions on two days
+func aggregateLogs(t *testing.T) *logs.Manager {
+	t.Helper()
+	dir := t.TempDir()
+	createTestLogFile(t, logs.PairLogPath(dir, "photos"), `2024/11/01 09:00:00 NOTICE: Manual Sync Requested
+2024/11/01 09:00:10 INFO  : a.jpg: Copied (new)
+2024/11/01 09:00:20 INFO  : b.jpg: Copied (new)
+Transferred:   	    2.000 MiB / 2.000 MiB, 100%, 1 MiB/s, ETA 0s
+2024/11/01 09:01:00 NOTICE: Manual Sync Complete: Success
+2024/11/02 09:00:00 NOTICE: Manual Sync Requested
+2024/11/02 09:01:00 NOTICE: Manual Sync Complete: Failed
+`)
+	createTestLogFile(t, logs.PairLogPath(dir, "docs"), `2024/11/02 10:00:00 NOTICE: Manual Sync Requested
+2024/11/02 10:00:10 INFO  : report.pdf: Copied (new)
+Transferred:   	      1.000 KiB / 1.000 KiB, 100%, 1 KiB/s, ETA 0s
+2024/11/02 10:01:00 NOTICE: Manual Sync Complete: Success
+`)
+	manager := logs.NewManager(dir)
+	manager.AddPairs("photos", "docs")
+	return manager
+}
+
+func TestLogsGetStatsByDay(t *testing.T) {
+	manager := aggregateLogs(t)
+
+	days, err := manager.GetStatsByDay(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, logs.AggregateStats{Key: "2024-11-01", Sessions: 1, Successful: 1, Files: 2, Bytes: 2 << 20, SuccessRate: 100}, days[0])
+	assert.Equal(t, "2024-11-02", days[1].Key)
+	assert.Equal(t, 2, days[1].Sessions)
+	assert.Equal(t, int64(1024), days[1].Bytes)
+	assert.Equal(t, 50.0, days[1].SuccessRate)
+
+	since := time.Date(2024, 11, 2, 0, 0, 0, 0, time.UTC)
+	days, err = manager.GetStatsByDay(since)
+	require.NoError(t, err)
+	require.Len(t, days, 1)
+	assert.Equal(t, "2024-11-02", days[0].Key)
+}
+
+func TestLogsGetStatsByPair(t *testing.T) {
+	manager := aggregateLogs(t)
+
+	pairs, err := manager.GetStatsByPair(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, pairs, 2)
+	assert.Equal(t, "docs", pairs[0].Key)
+	assert.Equal(t, 1, pairs[0].Files)
+	assert.Equal(t, "photos", pairs[1].Key)
+	assert.Equal(t, 2, pairs[1].Sessions)
+	assert.Equal(t, int64(2<<20), pairs[1].Bytes)
+
+	photos, err := manager.ForPair("photos").GetStatsByDay(time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, photos, 2)
+}
+
+func TestLogViewerStatsByPair(t *testing.T) {
+	var model tea.Model = views.NewLogViewerModel(aggregateLogs(t), views.LogViewAll, 120, 60)
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 120, Height: 60})
+	model, cmd := model.Update(keyPress("8"))
+	model, _ = model.Update(cmd())
+
+	view := model.View()
+	assert.Contains(t, view, "Totals by sync pair")
+	assert.Contains(t, view, "All time")
+	assert.Contains(t, view, "photos")
+	assert.Contains(t, view, "2.0 MB")
+}
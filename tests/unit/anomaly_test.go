@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// writeRunHistory writes runs successful sessions to a pair's log, each
+// deleting one file and copying ten
+func writeRunHistory(t *testing.T, logDir, pair string, runs int) {
+	t.Helper()
+	var b strings.Builder
+	for i := 0; i < runs; i++ {
+		stamp := fmt.Sprintf("2026/10/%02d 03:00:00", i+1)
+		b.WriteString(stamp + " NOTICE: Manual Sync Requested\n")
+		for f := 0; f < 10; f++ {
+			b.WriteString(fmt.Sprintf("%s INFO  : file%d.txt: Copied (new)\n", stamp, f))
+		}
+		b.WriteString(stamp + " INFO  : old.txt: Deleted\n")
+		b.WriteString(stamp + " NOTICE: Manual Sync Complete: Success\n")
+	}
+	require.NoError(t, os.MkdirAll(logs.PairLogDir(logDir), 0755))
+	require.NoError(t, os.WriteFile(logs.PairLogPath(logDir, pair), []byte(b.String()), 0644))
+}
+
+func TestSessionsCountDeletes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"docs.log": `2026/10/14 03:00:00 NOTICE: Manual Sync Requested
+2026/10/14 03:00:01 INFO  : a.txt: Copied (new)
+2026/10/14 03:00:01 INFO  : b.txt: Deleted
+{"time":"2026-10-14T03:00:02Z","level":"info","msg":"Deleted","object":"c.txt"}
+2026/10/14 03:00:03 NOTICE: Manual Sync Complete: Success
+`})
+
+	sessions, err := logs.NewManager(dir).ForPair("docs").GetSyncSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, 2, sessions[0].Deletes)
+	assert.Equal(t, 1, sessions[0].Transfers)
+}
+
+func TestHistoryCheck(t *testing.T) {
+	sessions := []logs.SyncSession{{Success: false, Deletes: 5000}}
+	for i := 0; i < 5; i++ {
+		sessions = append(sessions, logs.SyncSession{Success: true, Transfers: 200, Bytes: 1 << 30, Deletes: 20})
+	}
+	history := logs.HistoryOf(sessions)
+	assert.Equal(t, 5, history.Runs)
+	assert.Equal(t, 20.0, history.MeanDeletes)
+
+	assert.Empty(t, history.Check(logs.RunVolume{Files: 1900, Bytes: 9 << 30, Deletes: 199}))
+
+	anomalies := history.Check(logs.RunVolume{Files: 2500, Bytes: 12 << 30, Deletes: 840})
+	require.Len(t, anomalies, 3)
+	assert.Equal(t, "840 files deleted (42x the usual 20)", anomalies[0].String())
+	assert.Equal(t, "2500 files copied (12x the usual 200)", anomalies[1].String())
+	assert.Equal(t, "12.0 GiB transferred (12x the usual 1.0 GiB)", anomalies[2].String())
+
+	// Small means are compared against a floor, not flagged for every file
+	quiet := logs.HistoryOf(sessions[:1])
+	assert.Empty(t, quiet.Check(logs.RunVolume{Deletes: 5000}), "too little history")
+	for i := range sessions {
+		sessions[i].Deletes = 0
+	}
+	history = logs.HistoryOf(sessions)
+	assert.Empty(t, history.Check(logs.RunVolume{Deletes: 50}))
+	anomalies = history.Check(logs.RunVolume{Deletes: 101})
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, "101 files deleted (usually none)", anomalies[0].String())
+}
+
+func TestBackupWarnsOnUnusualDeletes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	docs := filepath.Join(home, "Documents")
+	writeFiles(t, docs, map[string]string{"a.txt": "a"})
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Documents", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true,
+	}))
+	logDir := filepath.Join(home, "logs")
+	writeRunHistory(t, logDir, "Documents", 5)
+
+	// The fake rclone would delete 150 of 1000 files, within the delete
+	// limit but far more than the one file each earlier run deleted
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte(`#!/bin/sh
+case "$1" in
+size) echo '{"count":1000,"bytes":1000}' ;;
+sync)
+	log=""
+	prev=""
+	for arg in "$@"; do
+		if [ "$arg" = "--dry-run" ]; then
+			i=0; while [ $i -lt 150 ]; do echo "NOTICE: f$i.txt: Skipped delete as --dry-run is set (size 1)"; i=$((i+1)); done
+			exit 0
+		fi
+		[ "$prev" = "--log-file" ] && log="$arg"
+		prev="$arg"
+	done
+	i=0; while [ $i -lt 150 ]; do echo "2026/10/15 03:00:00 INFO  : f$i.txt: Deleted" >> "$log"; i=$((i+1)); done ;;
+esac
+`), 0755))
+	manager, err := backup.NewManager(&backup.Config{Username: "tester", HomeDir: home, RclonePath: rcloneBin, LogDir: logDir})
+	require.NoError(t, err)
+	var warnings []string
+	manager.SetAnomalyWarning(func(pair, warning string) {
+		warnings = append(warnings, pair+": "+warning)
+	})
+
+	require.NoError(t, manager.SyncPair("Documents", false, false))
+	assert.Equal(t, []string{
+		"Documents: b2:bucket/docs: 150 files to delete (150x the usual 1.0)",
+		"Documents: 150 files deleted (150x the usual 1.0)",
+	}, warnings)
+
+	logData, err := os.ReadFile(logs.PairLogPath(logDir, "Documents"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(logData), "NOTICE: Anomaly Warning: "))
+
+	// Dry runs are not recorded, so they neither warn nor count as history
+	warnings = nil
+	require.NoError(t, manager.SyncPair("Documents", false, true))
+	assert.Empty(t, warnings)
+}
@@ -0,0 +1,238 @@
+package unit
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/archive"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// catZstd writes a stand-in for zstd that passes the tarball through, so
+// tests can read archives without zstd installed
+func catZstd(t *testing.T) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "zstd")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\nexec cat\n"), 0755))
+	return bin
+}
+
+// tarNames returns the entry names of an uncompressed tarball
+func tarNames(t *testing.T, data []byte) []string {
+	t.Helper()
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+}
+
+func TestArchiveCreate(t *testing.T) {
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{
+		"a.txt":        strings.Repeat("a", 3000),
+		"docs/b.txt":   "bbb",
+		"docs/c/d.txt": "",
+	})
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(source, "link")))
+	staging := filepath.Join(source, ".staging")
+	writeFiles(t, staging, map[string]string{"old.tar.zst": "stale"})
+
+	var reports [][2]int64
+	dest := filepath.Join(staging, "docs.tar.zst")
+	result, err := archive.NewCompressor(catZstd(t)).Create(source, dest, func(done, total int64) {
+		reports = append(reports, [2]int64{done, total})
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Files)
+	assert.Equal(t, int64(3003), result.Bytes)
+	require.NotEmpty(t, reports)
+	assert.Equal(t, [2]int64{3003, 3003}, reports[len(reports)-1])
+	assert.NoFileExists(t, dest+".partial")
+
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), result.Size)
+	assert.Equal(t, []string{"a.txt", "docs/", "docs/b.txt", "docs/c/", "docs/c/d.txt", "link"}, tarNames(t, data))
+}
+
+func TestArchiveCreateWithZstd(t *testing.T) {
+	zstd, err := archive.FindZstd()
+	if err != nil {
+		t.Skip("zstd is not installed")
+	}
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{"notes.txt": strings.Repeat("notes ", 10000)})
+	dest := filepath.Join(t.TempDir(), "notes.tar.zst")
+
+	result, err := archive.NewCompressor(zstd).Create(source, dest, nil)
+	require.NoError(t, err)
+	assert.Less(t, result.Size, result.Bytes)
+
+	data, err := exec.Command(zstd, "-dc", dest).Output()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes.txt"}, tarNames(t, data))
+}
+
+func TestArchiveCreateFailure(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "zstd")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\necho 'out of space' >&2\nexit 1\n"), 0755))
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{"a.txt": "a"})
+	dest := filepath.Join(t.TempDir(), "a.tar.zst")
+
+	_, err := archive.NewCompressor(bin).Create(source, dest, nil)
+	assert.ErrorContains(t, err, "out of space")
+	assert.NoFileExists(t, dest)
+	assert.NoFileExists(t, dest+".partial")
+}
+
+func TestArchiveCreateFailsWhenAFileChangesSize(t *testing.T) {
+	for name, content := range map[string]string{
+		"grew":   strings.Repeat("b", 20),
+		"shrank": "b",
+	} {
+		t.Run(name, func(t *testing.T) {
+			source := t.TempDir()
+			writeFiles(t, source, map[string]string{"a.txt": "aaa", "b.txt": "bbbbb"})
+			dest := filepath.Join(t.TempDir(), "docs.tar.zst")
+
+			// a.txt is read first; b.txt changes before its turn
+			_, err := archive.NewCompressor(catZstd(t)).Create(source, dest, func(done, total int64) {
+				if done == 3 {
+					require.NoError(t, os.WriteFile(filepath.Join(source, "b.txt"), []byte(content), 0644))
+				}
+			})
+			assert.ErrorContains(t, err, name)
+			assert.NoFileExists(t, dest)
+			assert.NoFileExists(t, dest+".partial")
+		})
+	}
+}
+
+func TestListAndPruneArchives(t *testing.T) {
+	dir := t.TempDir()
+	called := filepath.Join(dir, "called")
+	manager := fakeRclone(t, `case "$1" in
+lsjson)
+	echo '[{"Name":"2026-10-13-030000.tar.zst","Size":10},{"Name":"2026-10-15-030000.tar.zst","Size":30},{"Name":"notes.txt","Size":1},{"Name":"2026-10-14-030000.tar.zst","Size":20}]' ;;
+deletefile)
+	echo "$2" >> `+called+` ;;
+esac
+`)
+
+	archives, err := manager.ListArchives("b2", "bucket/docs")
+	require.NoError(t, err)
+	require.Len(t, archives, 3)
+	assert.Equal(t, "bucket/docs/2026-10-15-030000.tar.zst", archives[0].Path)
+	assert.Equal(t, int64(30), archives[0].Size)
+	assert.Equal(t, time.Date(2026, 10, 13, 3, 0, 0, 0, time.Local), archives[2].Date)
+
+	assert.Empty(t, rclone.ArchivesToPrune(archives, 0))
+	assert.Empty(t, rclone.ArchivesToPrune(archives, 3))
+	assert.Equal(t, archives[1:], rclone.ArchivesToPrune(archives, 1))
+
+	pruned, err := manager.PruneArchives("b2", "bucket/docs", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	deleted, err := os.ReadFile(called)
+	require.NoError(t, err)
+	assert.Equal(t, "b2:bucket/docs/2026-10-13-030000.tar.zst\n", string(deleted))
+
+	assert.Equal(t, "2026-10-15-143005.tar.zst", rclone.ArchiveName(time.Date(2026, 10, 15, 14, 30, 5, 0, time.Local)))
+}
+
+func TestBackupArchiveMode(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	docs := filepath.Join(home, "Documents")
+	writeFiles(t, docs, map[string]string{"report.txt": "report", "data/table.csv": "1,2,3"})
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Documents", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload",
+		Enabled: true, Archive: true, ArchiveKeep: 1,
+		Destinations: []syncconfig.Destination{{RemoteName: "b2", RemotePath: "mirror/docs"}},
+	}))
+
+	// The fake rclone keeps what it is asked to upload and lists an older
+	// archive next to it
+	called := filepath.Join(home, "args")
+	uploaded := filepath.Join(home, "uploaded.tar")
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte(`#!/bin/sh
+echo "$@" >> `+called+`
+case "$1" in
+copyto) cp "$2" `+uploaded+` ;;
+lsjson) echo '[{"Name":"2000-01-01-000000.tar.zst","Size":1},{"Name":"2100-01-01-000000.tar.zst","Size":1}]' ;;
+esac
+`), 0755))
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: rcloneBin,
+		ZstdPath:   catZstd(t),
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+	var done, total int64
+	manager.SetArchiveProgress(func(pair string, d, tot int64) {
+		assert.Equal(t, "Documents", pair)
+		done, total = d, tot
+	})
+
+	require.NoError(t, manager.SyncPair("Documents", false, false))
+	assert.Equal(t, int64(11), done)
+	assert.Equal(t, int64(11), total)
+
+	args, err := os.ReadFile(called)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(args)), "\n")
+	var copies, deletes []string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "copyto":
+			copies = append(copies, fields[2])
+			assert.Equal(t, filepath.Join(home, ".cache", "cloud-sync", "staging"), filepath.Dir(fields[1]))
+		case "deletefile":
+			deletes = append(deletes, fields[1])
+		}
+	}
+	require.Len(t, copies, 2)
+	assert.Regexp(t, `^b2:bucket/docs/\d{4}-\d{2}-\d{2}-\d{6}\.tar\.zst$`, copies[0])
+	assert.True(t, strings.HasPrefix(copies[1], "b2:mirror/docs/"))
+	assert.Equal(t, []string{"b2:bucket/docs/2000-01-01-000000.tar.zst", "b2:mirror/docs/2000-01-01-000000.tar.zst"}, deletes)
+
+	data, err := os.ReadFile(uploaded)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"data/", "data/table.csv", "report.txt"}, tarNames(t, data))
+
+	// The staged archive is removed once uploaded
+	staged, err := os.ReadDir(filepath.Join(home, ".cache", "cloud-sync", "staging"))
+	require.NoError(t, err)
+	assert.Empty(t, staged)
+
+	logData, err := os.ReadFile(filepath.Join(home, "logs", "pairs", "Documents.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(logData), "Archived 2 files, 11 bytes into ")
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/b2"
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// b2Server serves b2_authorize_account, answering the key "id"/"secret"
+// with allowed and rejecting any other
+func b2Server(t *testing.T, allowed string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, key, ok := r.BasicAuth(); !ok || id != "id" || key != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"accountId":"acc","apiInfo":{"storageApi":{"allowed":` + allowed + `}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	url := b2.AuthorizeURL
+	b2.AuthorizeURL = server.URL
+	t.Cleanup(func() { b2.AuthorizeURL = url })
+}
+
+func TestB2Authorize(t *testing.T) {
+	b2Server(t, `{"capabilities":["listFiles","readFiles"],"buckets":[{"id":"1","name":"photos"},{"id":"2","name":null}],"namePrefix":"mac/"}`)
+
+	key, err := b2.Authorize("id", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, &b2.Key{Capabilities: []string{"listFiles", "readFiles"}, Buckets: []string{"photos"}, NamePrefix: "mac/"}, key)
+
+	_, err = b2.Authorize("id", "wrong")
+	assert.ErrorIs(t, err, errkind.ErrRemoteAuth)
+}
+
+func TestB2KeyWarnings(t *testing.T) {
+	full := []string{"listBuckets", "listFiles", "readFiles", "writeFiles", "deleteFiles", "shareFiles"}
+	assert.Empty(t, b2.Key{Capabilities: full}.Missing())
+	assert.Empty(t, b2.Key{Capabilities: full}.Warnings())
+
+	// A bucket-restricted key gets by without listBuckets
+	readOnly := b2.Key{Capabilities: []string{"listFiles", "readFiles"}, Buckets: []string{"photos"}}
+	assert.Equal(t, []string{"deleteFiles", "writeFiles"}, readOnly.Missing())
+	warnings := readOnly.Warnings()
+	require.Len(t, warnings, 3)
+	assert.Contains(t, warnings[1], "No writeFiles: files cannot be uploaded")
+	assert.Contains(t, warnings[2], "only opens bucket photos")
+
+	assert.Equal(t, []string{"listBuckets"}, b2.Key{Capabilities: full[1:]}.Missing())
+}
+
+func TestRemoteWizardWarnsOfRestrictedB2Key(t *testing.T) {
+	t.Setenv("B2_KEY", "secret")
+	b2Server(t, `{"capabilities":["listBuckets","listFiles","readFiles"],"buckets":null,"namePrefix":null}`)
+
+	mgr, _ := remoteFixture(t)
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = filepath.Join(filepath.Dir(fakeRclone(t, `[ "$1" = lsd ] && printf '          -1 2026-10-15 12:00:00        -1 photos\n'
+exit 0
+`).GetConfigPath()), "rclone")
+	require.NoError(t, mgr.Save(appConfig))
+	remote, err := mgr.GetRemote("b2")
+	require.NoError(t, err)
+
+	// Listing the buckets after saving checks the key too
+	form, cmd := views.NewEditRemoteModel(mgr, *remote).Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	form, _ = form.Update(cmd())
+
+	view := form.View()
+	assert.Contains(t, view, "This application key is restricted")
+	assert.Contains(t, view, "No writeFiles: files cannot be uploaded, so nothing is backed up.")
+	assert.Contains(t, view, "No deleteFiles")
+	assert.NotContains(t, view, "No readFiles")
+	assert.Contains(t, view, "photos")
+}
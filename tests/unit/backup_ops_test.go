@@ -0,0 +1,260 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+// runBatch runs every command of a batch, including nested batches, and
+// returns their messages
+func runBatch(cmd tea.Cmd) []tea.Msg {
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		return []tea.Msg{msg}
+	}
+
+	var msgs []tea.Msg
+	for _, c := range batch {
+		if c != nil {
+			msgs = append(msgs, runBatch(c)...)
+		}
+	}
+	return msgs
+}
+
+func TestBackupOpsAttachesToRunningBackup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bytes":1024,"totalBytes":4096,"transfers":1,"totalTransfers":4,"elapsedTime":3,"transferring":[{"name":"docs/report.pdf"}]}`))
+	}))
+	defer server.Close()
+
+	lock := lockfile.NewManagerWithPath(filepath.Join(t.TempDir(), "rclone_backup.lock"))
+	require.NoError(t, lock.Create())
+
+	model := views.NewBackupOpsModel(views.BackupAutomated, 80, 30).
+		AttachRunningBackup(lock, rclone.NewRCClient(strings.TrimPrefix(server.URL, "http://")))
+
+	var current tea.Model = model
+	for _, msg := range runBatch(model.Init()) {
+		current, _ = current.Update(msg)
+	}
+
+	view := current.View()
+	assert.Contains(t, view, "Scheduled backup in progress")
+	assert.Contains(t, view, "docs/report.pdf")
+	assert.Contains(t, view, "Files: 1 / 4 copied")
+	assert.Contains(t, view, "Detach")
+}
+
+func TestBackupOpsWaitsWhenRCUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	lock := lockfile.NewManagerWithPath(filepath.Join(t.TempDir(), "rclone_backup.lock"))
+	require.NoError(t, lock.Create())
+
+	model := views.NewBackupOpsModel(views.BackupAutomated, 80, 30).
+		AttachRunningBackup(lock, rclone.NewRCClient(strings.TrimPrefix(server.URL, "http://")))
+
+	var current tea.Model = model
+	for _, msg := range runBatch(model.Init()) {
+		current, _ = current.Update(msg)
+	}
+
+	assert.Contains(t, current.View(), "remote control API is not answering")
+}
+
+// bwlimitServer serves core/stats and a core/bwlimit that keeps the limit
+// it is given, like a running rclone. The rates set are appended to rates.
+func bwlimitServer(t *testing.T, rates *[]string) *httptest.Server {
+	t.Helper()
+	limit := int64(-1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/core/stats" {
+			w.Write([]byte(`{"bytes":1024,"totalBytes":4096,"transfers":1,"totalTransfers":4,"elapsedTime":3}`))
+			return
+		}
+		var in struct {
+			Rate string `json:"rate"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		switch {
+		case in.Rate == "":
+		case in.Rate == "off":
+			limit = -1
+		case strings.HasSuffix(in.Rate, "B"):
+			limit, _ = strconv.ParseInt(strings.TrimSuffix(in.Rate, "B"), 10, 64)
+		default:
+			size, err := config.ParseSize(in.Rate)
+			require.NoError(t, err)
+			limit = size
+		}
+		if in.Rate != "" {
+			*rates = append(*rates, in.Rate)
+		}
+		rate := "off"
+		if limit >= 0 {
+			rate = strconv.FormatInt(limit, 10)
+		}
+		fmt.Fprintf(w, `{"bytesPerSecond":%d,"rate":"%s"}`, limit, rate)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// pressKey sends a key to the view and feeds it the message of the command
+// the key returns
+func pressKey(model tea.Model, key string) tea.Model {
+	model, cmd := model.Update(keyPress(key))
+	if cmd != nil {
+		model, _ = model.Update(cmd())
+	}
+	return model
+}
+
+// attachBandwidthView attaches a backup view to the rclone at server and
+// feeds it the first poll and limit
+func attachBandwidthView(server *httptest.Server, lock *lockfile.Manager) tea.Model {
+	model := views.NewBackupOpsModel(views.BackupAutomated, 100, 40).
+		AttachRunningBackup(lock, rclone.NewRCClient(strings.TrimPrefix(server.URL, "http://")))
+	var current tea.Model = model
+	var attached tea.Cmd
+	for _, msg := range runBatch(model.Init()) {
+		var cmd tea.Cmd
+		current, cmd = current.Update(msg)
+		if _, ok := msg.(spinner.TickMsg); !ok {
+			attached = cmd
+		}
+	}
+	for _, msg := range runBatch(attached) {
+		current, _ = current.Update(msg)
+	}
+	return current
+}
+
+func TestBackupOpsControlsBandwidth(t *testing.T) {
+	var rates []string
+	server := bwlimitServer(t, &rates)
+	lock := lockfile.NewManagerWithPath(filepath.Join(t.TempDir(), "rclone_backup.lock"))
+	require.NoError(t, lock.Create())
+
+	// Attaching reads the limit along with the first poll
+	current := attachBandwidthView(server, lock)
+	view := testutil.PlainText(current.View())
+	assert.Contains(t, view, "Bandwidth limit: off")
+	assert.Contains(t, view, "l: Limit bandwidth • p: Pause/Resume")
+
+	// A limit that is not a size is not sent
+	current = pressKey(current, "l")
+	for _, r := range "fast" {
+		current, _ = current.Update(keyPress(string(r)))
+	}
+	current = pressKey(current, "enter")
+	assert.Contains(t, current.View(), "'fast' is not a limit like 2M, 512K or off")
+	for range "fast" {
+		current, _ = current.Update(keyPress("backspace"))
+	}
+	for _, r := range "1.5m" {
+		current, _ = current.Update(keyPress(string(r)))
+	}
+	current = pressKey(current, "enter")
+	assert.Contains(t, testutil.PlainText(current.View()), "Bandwidth limit: 1.5 MB/s")
+
+	// Pausing keeps the limit to resume at
+	current = pressKey(current, "p")
+	assert.Contains(t, current.View(), "Paused: transfers stall until resumed with p")
+	current = pressKey(current, "p")
+	assert.Contains(t, testutil.PlainText(current.View()), "Bandwidth limit: 1.5 MB/s")
+	assert.Equal(t, []string{"1536K", "1B", "1572864B"}, rates)
+
+	// esc while typing a limit cancels it instead of detaching
+	current = pressKey(current, "l")
+	current, cmd := current.Update(keyPress("esc"))
+	assert.Nil(t, cmd)
+	assert.Contains(t, testutil.PlainText(current.View()), "Bandwidth limit: 1.5 MB/s")
+}
+
+func TestBackupOpsResumesAPauseFromAnotherSession(t *testing.T) {
+	var rates []string
+	server := bwlimitServer(t, &rates)
+	lock := lockfile.NewManagerWithPath(filepath.Join(t.TempDir(), "rclone_backup.lock"))
+	require.NoError(t, lock.Create())
+
+	first := attachBandwidthView(server, lock)
+	first = pressKey(first, "l")
+	for _, r := range "2m" {
+		first, _ = first.Update(keyPress(string(r)))
+	}
+	first = pressKey(first, "enter")
+	first = pressKey(first, "p")
+	assert.Contains(t, first.View(), "Paused: transfers stall until resumed with p")
+
+	// Another session finds the backup paused and resumes it at 2 MB/s
+	second := attachBandwidthView(server, lock)
+	assert.Contains(t, second.View(), "Paused: transfers stall until resumed with p")
+	second = pressKey(second, "p")
+	assert.Contains(t, testutil.PlainText(second.View()), "Bandwidth limit: 2.0 MB/s")
+	assert.Equal(t, []string{"2M", "1B", "2097152B"}, rates)
+	assert.NoFileExists(t, lock.GetPath()+".paused")
+}
+
+func TestBackupOperationsWaitsForScheduledBackup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bytes":1024,"totalBytes":4096,"transfers":1,"totalTransfers":4,"elapsedTime":3}`))
+	}))
+	defer server.Close()
+
+	// The scheduled backup serves rclone's API where the config says
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.LogDir = t.TempDir()
+	appConfig.LaunchAgent.RCAddr = strings.TrimPrefix(server.URL, "http://")
+	require.NoError(t, configManager.Save(appConfig))
+
+	// Backup Operations is on the main menu
+	var menu tea.Model = ui.NewModel()
+	menu, _ = menu.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	for _, key := range []string{"down", "down", "down", "enter"} {
+		menu, _ = menu.Update(keyPress(key))
+	}
+	require.IsType(t, views.BackupOpsModel{}, menu.(ui.Model).ActiveView())
+
+	model, err := views.NewScheduledBackupModel(80, 30)
+	require.NoError(t, err)
+	var current tea.Model = model
+	var wait tea.Cmd
+	for _, msg := range runBatch(model.Init()) {
+		current, wait = current.Update(msg)
+	}
+	assert.Contains(t, current.View(), "No scheduled backup is running")
+	require.NotNil(t, wait)
+
+	// Once a backup starts, the view attaches to it
+	require.NoError(t, lockfile.NewManager(appConfig.LogDir).Create())
+	current, _ = current.Update(wait())
+	assert.Contains(t, current.View(), "Scheduled backup in progress")
+	assert.Contains(t, current.View(), "Files: 1 / 4 copied")
+}
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// fakeRclone writes an rclone stand-in that runs the given shell script body
+// and a config with a b2 remote, and returns a manager using both
+func fakeRclone(t *testing.T, body string) *rclone.Manager {
+	t.Helper()
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\n"+body), 0755))
+	conf := filepath.Join(dir, "rclone.conf")
+	require.NoError(t, os.WriteFile(conf, []byte("[b2]\ntype = b2\naccount = id\nkey = secret\n"), 0600))
+	return rclone.NewManagerWithConfig(bin, conf)
+}
+
+// typeText types a value and presses enter, running the command it returns
+func typeText(model tea.Model, text string) tea.Model {
+	for _, r := range text {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd != nil {
+		model, _ = model.Update(cmd())
+	}
+	return model
+}
+
+func TestRcloneCreateBucket(t *testing.T) {
+	called := filepath.Join(t.TempDir(), "called")
+	manager := fakeRclone(t, `echo "$@" > `+called+"\n")
+
+	require.NoError(t, manager.CreateBucket("b2", "new-bucket"))
+	args, err := os.ReadFile(called)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "mkdir b2:new-bucket --config")
+
+	assert.Error(t, manager.CreateBucket("b2", "Bad_Bucket"))
+}
+
+func TestRcloneCreateBucketFailure(t *testing.T) {
+	manager := fakeRclone(t, "echo 'access denied' >&2; exit 1\n")
+
+	err := manager.CreateBucket("b2", "new-bucket")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "access denied")
+}
+
+func TestRcloneIsBucketBased(t *testing.T) {
+	assert.True(t, rclone.IsBucketBased("b2"))
+	assert.True(t, rclone.IsBucketBased("s3"))
+	assert.False(t, rclone.IsBucketBased("drive"))
+	assert.False(t, rclone.IsBucketBased("local"))
+}
+
+// wizardAtRemotePath runs the sync pair wizard up to the remote path step
+func wizardAtRemotePath(t *testing.T, manager *rclone.Manager) tea.Model {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+
+	var model tea.Model = views.NewSyncPairsModel(mgr, manager)
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	model = typeText(model, "docs")
+	model = typeText(model, t.TempDir())
+	model = typeText(model, "b2")
+	require.Contains(t, model.View(), "Enter the remote path")
+	return model
+}
+
+func TestSyncPairsWizardOffersToCreateMissingBucket(t *testing.T) {
+	created := filepath.Join(t.TempDir(), "created")
+	manager := fakeRclone(t, `case "$1" in
+lsd) echo "          -1 2026-10-15 12:00:00        -1 photos" ;;
+mkdir) echo "$2" > `+created+` ;;
+esac
+`)
+	model := wizardAtRemotePath(t, manager)
+	assert.Contains(t, model.View(), "Existing buckets: photos")
+
+	// An existing bucket goes straight on
+	model = typeText(model, "photos/2026")
+	assert.Contains(t, model.View(), "Select sync direction")
+
+	model = wizardAtRemotePath(t, manager)
+	model = typeText(model, "Bad_Bucket/docs")
+	assert.Contains(t, model.View(), "Enter the remote path")
+	assert.Contains(t, model.View(), "uppercase")
+
+	model = wizardAtRemotePath(t, manager)
+	model = typeText(model, "new-bucket/docs")
+	assert.Contains(t, model.View(), "Bucket 'new-bucket' does not exist on remote 'b2'")
+
+	model = typeText(model, "y")
+	assert.Contains(t, model.View(), "Select sync direction")
+	target, err := os.ReadFile(created)
+	require.NoError(t, err)
+	assert.Equal(t, "b2:new-bucket\n", string(target))
+}
+
+func TestSyncPairsWizardDeclinesBucketCreation(t *testing.T) {
+	manager := fakeRclone(t, "exit 0\n")
+	model := wizardAtRemotePath(t, manager)
+	assert.Contains(t, model.View(), "no buckets yet")
+
+	model = typeText(model, "backups/docs")
+	require.Contains(t, model.View(), "Create it now?")
+
+	model = typeText(model, "n")
+	view := model.View()
+	assert.Contains(t, view, "Enter the remote path")
+	assert.Contains(t, view, "backups/docs")
+}
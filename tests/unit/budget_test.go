@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// growingRclone is a fake rclone whose destinations hold 9 GB and whose dry
+// runs would upload 1.5 GiB more. Real syncs touch the synced file.
+func growingRclone(synced string) string {
+	return `case "$1" in
+version) echo 'rclone v1.66.0' ;;
+size) echo '{"count":10,"bytes":9000000000}' ;;
+sync)
+	for arg in "$@"; do
+		if [ "$arg" = "--dry-run" ]; then
+			echo "NOTICE: video.mov: Skipped copy as --dry-run is set (size 1.500Gi)"
+			exit 0
+		fi
+	done
+	touch ` + synced + ` ;;
+esac
+`
+}
+
+func TestSyncPairSizeBudget(t *testing.T) {
+	pair := syncconfig.SyncPair{Name: "docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket", Direction: "upload", MaxRemoteGB: 10}
+	assert.Equal(t, int64(10_000_000_000), pair.SizeBudget())
+	require.NoError(t, syncconfig.ValidateSyncPair(&pair))
+
+	pair.MaxRemoteGB = -1
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "cannot be negative")
+
+	pair.MaxRemoteGB = 10
+	pair.Direction = "download"
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "only supported for upload and bidirectional pairs")
+
+	pair.Direction = "upload"
+	pair.Snapshot = true
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "not supported for snapshot or archive pairs")
+}
+
+func TestRclonePreviewTransfer(t *testing.T) {
+	args := filepath.Join(t.TempDir(), "args")
+	manager := fakeRclone(t, `echo "$@" >> `+args+`
+echo "NOTICE: a.txt: Skipped copy as --dry-run is set (size 1.500Mi)"
+echo "NOTICE: b.txt: Skipped copy as --dry-run is set (size 2k)"
+echo "NOTICE: c.txt: Skipped delete as --dry-run is set (size 512)"
+echo "NOTICE: d.txt: Skipped delete as --dry-run is set (size 1Ki)"
+`)
+
+	plan, err := manager.PreviewTransfer("/data", "b2:bucket", rclone.SyncOptions{
+		BackupDir: "b2:bucket/.trash",
+		RCAddr:    "127.0.0.1:5999",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rclone.TransferPlan{CopyBytes: 1572864 + 2048, DeleteBytes: 512 + 1024, Deletes: 2}, plan)
+
+	calls, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Contains(t, string(calls), "--dry-run")
+	assert.NotContains(t, string(calls), "--backup-dir")
+	assert.NotContains(t, string(calls), "--rc")
+}
+
+func TestRcloneSize(t *testing.T) {
+	manager := fakeRclone(t, `echo '{"count":2,"bytes":2048}'`)
+	size, err := manager.Size("b2:bucket")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2048), size)
+
+	// A destination not created yet holds nothing
+	manager = fakeRclone(t, `echo 'directory not found' >&2; exit 3`)
+	size, err = manager.Size("b2:bucket/new")
+	require.NoError(t, err)
+	assert.Zero(t, size)
+
+	manager = fakeRclone(t, `exit 1`)
+	_, err = manager.Size("b2:bucket")
+	assert.Error(t, err)
+}
+
+func TestCLISyncSizeBudget(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	synced := filepath.Join(home, "synced")
+
+	bin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\n"+growingRclone(synced)), 0755))
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = bin
+	appConfig.LogDir = filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(appConfig))
+	addPlainPair(t, "Photos", true)
+
+	pairs, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	pair, err := pairs.GetSyncPair("Photos")
+	require.NoError(t, err)
+	pair.MaxRemoteGB = 10
+	require.NoError(t, pairs.UpdateSyncPair("Photos", *pair))
+
+	// Without a terminal to ask on, the pair fails
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, cli.Run([]string{"sync", "Photos"}, &stdout, &stderr))
+	out := stdout.String()
+	assert.Contains(t, out, "sync would grow b2:bucket/Photos from 9.00 GB to 10.61 GB, over the 10.00 GB budget of 'Photos'")
+	assert.Contains(t, out, "stopped at the size budget of b2:bucket/Photos")
+	assert.NoFileExists(t, synced)
+
+	// Confirming runs the sync
+	stdout.Reset()
+	require.Equal(t, 0, cli.RunPlain(strings.NewReader("2\nPhotos\ny\nq\n"), &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "Sync anyway? [y/N]")
+	assert.FileExists(t, synced)
+
+	// A pair set to warn only syncs and says so
+	require.NoError(t, os.Remove(synced))
+	pair.BudgetWarnOnly = true
+	require.NoError(t, pairs.UpdateSyncPair("Photos", *pair))
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"sync", "Photos"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Photos: warning: sync would grow b2:bucket/Photos")
+	assert.FileExists(t, synced)
+
+	logData, err := os.ReadFile(logs.PairLogPath(appConfig.LogDir, "Photos"))
+	require.NoError(t, err)
+	assert.Contains(t, string(logData), "NOTICE: Budget Warning: sync would grow")
+}
+
+func TestCLISyncChecksDeletesAndBudgetFromOneDryRun(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	synced := filepath.Join(home, "synced")
+	calls := filepath.Join(home, "calls")
+
+	bin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\necho \"$@\" >> "+calls+"\n"+growingRclone(synced)), 0755))
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = bin
+	appConfig.LogDir = filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(appConfig))
+	addPlainPair(t, "Photos", true)
+
+	pairs, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	pair, err := pairs.GetSyncPair("Photos")
+	require.NoError(t, err)
+	pair.MaxRemoteGB = 100
+	require.NoError(t, pairs.UpdateSyncPair("Photos", *pair))
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"sync", "Photos"}, &stdout, &stderr), stderr.String())
+	assert.FileExists(t, synced)
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "--dry-run"), string(data))
+}
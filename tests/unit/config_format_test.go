@@ -0,0 +1,220 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/fileformat"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+const formatSample = `{
+  "version": "1.0",
+  "note": "say \"hi\"\n\ttabbed, ünïcode",
+  "hour": 10,
+  "ratio": 1.5,
+  "enabled": false,
+  "missing": null,
+  "tags": ["a", "b"],
+  "empty": [],
+  "ui": {"theme": "dark", "palette": {"primary": "#005F87"}},
+  "remotes": [
+    {"name": "b2", "tuning": {"chunk_size": "96M"}, "flags": []},
+    {"name": "s3 backup", "tuning": null}
+  ]
+}`
+
+func TestTOMLRoundTrip(t *testing.T) {
+	data, err := fileformat.TOMLFromJSON([]byte(formatSample))
+	require.NoError(t, err)
+	assert.Equal(t, `empty = []
+enabled = false
+hour = 10
+note = "say \"hi\"\n\ttabbed, ünïcode"
+ratio = 1.5
+tags = ['a', 'b']
+version = '1.0'
+
+[[remotes]]
+flags = []
+name = 'b2'
+
+[remotes.tuning]
+chunk_size = '96M'
+
+[[remotes]]
+name = 's3 backup'
+
+[ui]
+theme = 'dark'
+
+[ui.palette]
+primary = '#005F87'
+`, string(data))
+
+	// Keys set to null are left out, which decodes to the same value
+	back, err := fileformat.JSONFromTOML(data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "1.0", "note": "say \"hi\"\n\ttabbed, ünïcode", "hour": 10, "ratio": 1.5, "enabled": false,
+		"tags": ["a", "b"], "empty": [], "ui": {"theme": "dark", "palette": {"primary": "#005F87"}},
+		"remotes": [{"name": "b2", "tuning": {"chunk_size": "96M"}, "flags": []}, {"name": "s3 backup"}]
+	}`, string(back))
+}
+
+func TestTOMLHandWritten(t *testing.T) {
+	data, err := fileformat.JSONFromTOML([]byte(`# Backups of the Mac mini
+version = '1.0'   # literal string
+launch_agent.hour = 2
+launch_agent.minute = 0x1E
+size = 1_000
+created = 2026-10-15T10:05:00Z
+paths = [
+  "~/Documents",  # trailing comments and commas are fine
+  '~/Pictures',
+]
+inline = { name = "b2", keys = { id = "k" } }
+text = """
+first \
+  second"""
+
+[ui]
+"theme name" = "auto"
+`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "1.0", "launch_agent": {"hour": 2, "minute": 30}, "size": 1000,
+		"created": "2026-10-15T10:05:00Z", "paths": ["~/Documents", "~/Pictures"],
+		"inline": {"name": "b2", "keys": {"id": "k"}}, "text": "first second", "ui": {"theme name": "auto"}
+	}`, string(data))
+
+	for doc, msg := range map[string]string{
+		"a = 1\na = 2\n":               "key a is already defined",
+		"[ui]\n[ui]\n":                 "table ui already exists",
+		"a = \"open\nb = 1\n":          "line 1: toml: basic strings cannot have new lines",
+		"a = 1 b = 2\n":                "line 1: toml: expected newline",
+		"a = inf\n":                    "a: +Inf cannot be stored",
+		"a = [1, 2\n":                  "expected character ]",
+		"[[p]]\n[p.x]\n[[p]]\n[p.x]\n": "",
+	} {
+		_, err := fileformat.JSONFromTOML([]byte(doc))
+		if msg == "" {
+			assert.NoError(t, err, doc)
+		} else {
+			assert.ErrorContains(t, err, msg, doc)
+		}
+	}
+}
+
+func TestYAMLKeepsComments(t *testing.T) {
+	previous := []byte(`# Pairs of the Mac mini
+sync_pairs:
+  # Nightly notes backup
+  - name: Notes
+    remote_path: bucket/notes # moved in May
+  - name: Old
+    remote_path: bucket/old
+version: "1.0"
+`)
+	data, err := fileformat.FromJSON(fileformat.YAML, []byte(`{"sync_pairs": [
+		{"name": "New", "remote_path": "bucket/new"},
+		{"name": "Notes", "remote_path": "bucket/notes-2026"}
+	], "version": "1.0"}`), previous)
+	require.NoError(t, err)
+	assert.Equal(t, `# Pairs of the Mac mini
+sync_pairs:
+  - name: New
+    remote_path: bucket/new
+  # Nightly notes backup
+  - name: Notes
+    remote_path: bucket/notes-2026 # moved in May
+version: "1.0"
+`, string(data))
+}
+
+func TestConfigInYAMLAndTOML(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "cloud-sync")
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": "# Hand-written\nversion: \"1.0\"\nrclone_path: /usr/local/bin/rclone # from Homebrew\n",
+	})
+
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "config.yaml"), configManager.GetConfigPath())
+	assert.Equal(t, filepath.Join(dir, "sync-config.yaml"), configManager.SyncConfigPath(), "new files follow config.yaml")
+	assert.Equal(t, configManager.SyncConfigPath(), syncconfig.DefaultPath(home))
+
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/local/bin/rclone", appConfig.RclonePath)
+	require.NoError(t, configManager.UpdateUIConfig(config.UIConfig{Theme: "dark"}))
+
+	data, err := os.ReadFile(configManager.GetConfigPath())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Hand-written\nversion: \"1.0\"\n")
+	assert.Contains(t, string(data), "rclone_path: /usr/local/bin/rclone # from Homebrew\n")
+	assert.Contains(t, string(data), "  theme: dark\n")
+	assert.FileExists(t, configManager.GetConfigPath()+".bak")
+
+	// A TOML sync config is read and written as TOML
+	pairsPath := filepath.Join(t.TempDir(), "sync-config.toml")
+	pairs := syncconfig.NewManager(pairsPath)
+	require.NoError(t, pairs.AddSyncPair(syncconfig.SyncPair{
+		Name: "Docs", LocalPath: "/data/docs", RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Tags: []string{"work"},
+	}))
+	data, err = os.ReadFile(pairsPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[[sync_pairs]]\ndirection = 'upload'\n")
+	assert.Contains(t, string(data), "\nname = 'Docs'\n")
+	loaded, err := pairs.GetSyncPair("Docs")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work"}, loaded.Tags)
+
+	require.NoError(t, pairs.ToggleEnabled("Docs"))
+	require.NoError(t, os.WriteFile(pairsPath, []byte("[[sync_pairs]]\nname = \n"), 0644))
+	_, err = pairs.Load()
+	assert.ErrorContains(t, err, "sync-config.toml is corrupt")
+	require.NoError(t, pairs.RestoreBackup())
+	_, err = pairs.GetSyncPair("Docs")
+	require.NoError(t, err)
+}
+
+func TestCLIConfigFormat(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "cloud-sync")
+	addTaggedPair(t, "Photos", true, "", "photos")
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, cli.Run([]string{"config", "format"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Format: json (config.json, sync-config.json)\n", stdout.String())
+	assert.Equal(t, 2, cli.Run([]string{"config", "format", "xml"}, &stdout, &stderr))
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"config", "format", "yaml"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Wrote "+filepath.Join(dir, "sync-config.yaml")+"\nWrote "+filepath.Join(dir, "config.yaml")+
+		"\nFormat: yaml (config.yaml, sync-config.yaml)\n", stdout.String())
+	assert.NoFileExists(t, filepath.Join(dir, "sync-config.json"))
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"config", "format", "toml"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Format: toml (config.toml, sync-config.toml)\n")
+	assert.NoFileExists(t, filepath.Join(dir, "sync-config.yaml"))
+	assert.NoFileExists(t, filepath.Join(dir, "config.yaml"))
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"config", "format"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Format: toml (config.toml, sync-config.toml)\n", stdout.String())
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"pairs", "--tag", "photos"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Photos")
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+func TestJSONFileLockWaitsForRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	unlock, err := jsonfile.Lock(path)
+	require.NoError(t, err)
+	assert.FileExists(t, jsonfile.LockPath(path))
+
+	acquired := make(chan struct{})
+	go func() {
+		unlockSecond, err := jsonfile.Lock(path)
+		if err == nil {
+			unlockSecond()
+		}
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second lock was taken while the first was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("second lock was not taken after the first was released")
+	}
+}
+
+func TestConcurrentConfigUpdatesAreKept(t *testing.T) {
+	dir := t.TempDir()
+	// Separate managers behave like separate processes sharing the files
+	const writers = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			mgr := config.NewManagerWithPath(filepath.Join(dir, "config.json"))
+			errs <- mgr.AddRemote(config.RemoteConfig{Name: fmt.Sprintf("remote-%d", i), Type: "b2"})
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			local := filepath.Join(dir, fmt.Sprintf("folder-%d", i))
+			if err := os.Mkdir(local, 0755); err != nil {
+				errs <- err
+				return
+			}
+			mgr := syncconfig.NewManager(filepath.Join(dir, "sync-config.json"))
+			errs <- mgr.AddSyncPair(syncconfig.SyncPair{
+				Name: fmt.Sprintf("pair-%d", i), LocalPath: local, RemoteName: "b2", RemotePath: "bucket", Direction: "upload",
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	appConfig, err := config.NewManagerWithPath(filepath.Join(dir, "config.json")).Load()
+	require.NoError(t, err)
+	assert.Len(t, appConfig.Remotes, writers)
+
+	pairs, err := syncconfig.NewManager(filepath.Join(dir, "sync-config.json")).Load()
+	require.NoError(t, err)
+	assert.Len(t, pairs.SyncPairs, writers)
+}
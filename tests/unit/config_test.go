@@ -0,0 +1,537 @@
+package unit
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSetupProgressSetStep(t *testing.T) {
+	var progress config.SetupProgress
+
+	progress.SetStep(3, true)
+	progress.SetStep(1, true)
+	progress.SetStep(3, true)
+	assert.Equal(t, []int{1, 3}, progress.CompletedSteps)
+	assert.True(t, progress.StepDone(1))
+	assert.False(t, progress.StepDone(2))
+
+	progress.SetStep(1, false)
+	assert.Equal(t, []int{3}, progress.CompletedSteps)
+	assert.False(t, progress.StepDone(1))
+}
+
+func TestConfigUpdateSetupProgress(t *testing.T) {
+	mgr := config.NewManagerWithPath(filepath.Join(t.TempDir(), "config.json"))
+
+	progress := config.SetupProgress{ToolsInstalled: true, CompletedSteps: []int{1, 2, 3}}
+	require.NoError(t, mgr.UpdateSetupProgress(progress))
+
+	loaded, err := mgr.Load()
+	require.NoError(t, err)
+	assert.Equal(t, progress, loaded.Setup)
+	assert.Equal(t, "com.cloud-sync.backup", loaded.LaunchAgent.Label)
+}
+
+func TestConfigExportImportRoundTrip(t *testing.T) {
+	oldHome := filepath.Join(t.TempDir(), "old")
+	newHome := t.TempDir()
+	t.Setenv("HOME", newHome)
+
+	src := config.NewManagerWithPath(filepath.Join(t.TempDir(), "src", "config.json"))
+	rcloneConf := filepath.Join(t.TempDir(), "rclone.conf")
+	require.NoError(t, os.WriteFile(rcloneConf, []byte("[b2]\ntype = b2\n"), 0600))
+	require.NoError(t, src.Save(&config.AppConfig{
+		HomeDir:      oldHome,
+		BinDir:       filepath.Join(oldHome, "bin"),
+		RcloneConfig: rcloneConf,
+		Remotes:      []config.RemoteConfig{{Name: "b2", Type: "b2", AccountID: "id", ApplicationKey: "secret"}},
+	}))
+	syncMgr := syncconfig.NewManager(src.SyncConfigPath())
+	require.NoError(t, syncMgr.Save(&syncconfig.Config{SyncPairs: []syncconfig.SyncPair{
+		{Name: "docs", LocalPath: filepath.Join(oldHome, "Documents"), RemoteName: "b2", RemotePath: "bucket", Direction: "upload"},
+	}}))
+
+	// Without credentials, keys are stripped and the bundle is plain
+	var plain bytes.Buffer
+	manifest, err := src.Export(&plain, config.ExportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config.json", "sync-config.json"}, manifest.Files)
+	assert.NotContains(t, plain.String(), "secret")
+
+	// Credentials require a passphrase
+	_, err = src.Export(&bytes.Buffer{}, config.ExportOptions{IncludeCredentials: true})
+	assert.Error(t, err)
+
+	var sealed bytes.Buffer
+	manifest, err = src.Export(&sealed, config.ExportOptions{IncludeCredentials: true, Passphrase: "hunter2"})
+	require.NoError(t, err)
+	assert.Contains(t, manifest.Files, "rclone.conf")
+
+	dst := config.NewManagerWithPath(filepath.Join(t.TempDir(), "dst", "config.json"))
+	_, err = dst.Import(bytes.NewReader(sealed.Bytes()), config.ImportOptions{Passphrase: "wrong"})
+	assert.Error(t, err)
+
+	_, err = dst.Import(bytes.NewReader(sealed.Bytes()), config.ImportOptions{Passphrase: "hunter2"})
+	require.NoError(t, err)
+
+	restored, err := dst.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", restored.Remotes[0].ApplicationKey)
+	assert.Equal(t, filepath.Join(newHome, "bin"), restored.BinDir)
+
+	pairs, err := syncconfig.NewManager(dst.SyncConfigPath()).ListSyncPairs()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(newHome, "Documents"), pairs[0].LocalPath)
+
+	// An existing configuration is only replaced when asked
+	_, err = dst.Import(bytes.NewReader(plain.Bytes()), config.ImportOptions{})
+	assert.Error(t, err)
+	_, err = dst.Import(bytes.NewReader(plain.Bytes()), config.ImportOptions{Overwrite: true})
+	assert.NoError(t, err)
+}
+
+func TestConfigImportRejectsOversizedEntries(t *testing.T) {
+	var bundle bytes.Buffer
+	gz := gzip.NewWriter(&bundle)
+	tw := tar.NewWriter(gz)
+	content := bytes.Repeat([]byte(" "), 16<<20+1)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: config.BundleConfigFile, Mode: 0600, Size: int64(len(content))}))
+	_, err := tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	dst := config.NewManagerWithPath(filepath.Join(t.TempDir(), "config.json"))
+	_, err = dst.Import(&bundle, config.ImportOptions{})
+	assert.ErrorContains(t, err, "is too large")
+}
+
+func TestConfigResolveSecret(t *testing.T) {
+	t.Setenv("CLOUD_SYNC_TEST_KEY", "from-env")
+
+	value, err := config.ResolveSecret("env:CLOUD_SYNC_TEST_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	value, err = config.ResolveSecret("cmd:echo from-command")
+	require.NoError(t, err)
+	assert.Equal(t, "from-command", value)
+
+	value, err = config.ResolveSecret("plain-key")
+	require.NoError(t, err)
+	assert.Equal(t, "plain-key", value)
+
+	_, err = config.ResolveSecret("env:CLOUD_SYNC_TEST_MISSING")
+	assert.Error(t, err)
+	_, err = config.ResolveSecret("cmd:exit 1")
+	assert.Error(t, err)
+}
+
+func TestConfigSecretRefsStayOffDisk(t *testing.T) {
+	t.Setenv("CLOUD_SYNC_TEST_KEY", "s3cret")
+	dir := t.TempDir()
+	mgr := config.NewManagerWithPath(filepath.Join(dir, "config.json"))
+	require.NoError(t, mgr.Save(&config.AppConfig{
+		RcloneConfig: filepath.Join(dir, "rclone.conf"),
+		Remotes: []config.RemoteConfig{
+			{Name: "my-b2", Type: "b2", AccountID: "abc", ApplicationKey: "env:CLOUD_SYNC_TEST_KEY"},
+		},
+	}))
+
+	require.NoError(t, mgr.GenerateRcloneConfig())
+	data, err := os.ReadFile(filepath.Join(dir, "rclone.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "account = abc")
+	assert.NotContains(t, string(data), "s3cret")
+	assert.NotContains(t, string(data), "key = env:")
+
+	env, err := mgr.SecretEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"RCLONE_CONFIG_MY_B2_KEY=s3cret"}, env)
+}
+
+func TestBackupSyncsRemotesWhoseSecretsResolve(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLOUD_SYNC_TEST_KEY", "s3cret")
+	cfg, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, cfg.Save(&config.AppConfig{
+		Remotes: []config.RemoteConfig{
+			{Name: "good", Type: "b2", AccountID: "abc", ApplicationKey: "env:CLOUD_SYNC_TEST_KEY"},
+			{Name: "bad", Type: "b2", AccountID: "abc", ApplicationKey: "env:CLOUD_SYNC_TEST_MISSING"},
+		},
+	}))
+
+	env, err := config.RcloneEnv()
+	var secretErr *config.SecretError
+	require.ErrorAs(t, err, &secretErr)
+	assert.Equal(t, []string{"RCLONE_CONFIG_GOOD_KEY=s3cret"}, env)
+	assert.NoError(t, secretErr.Remote("good"))
+	assert.ErrorContains(t, secretErr.Remote("bad"), "failed to resolve credentials: bad key:")
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	for _, pair := range []syncconfig.SyncPair{
+		{Name: "Docs", RemoteName: "good", RemotePath: "bucket/docs"},
+		{Name: "Photos", RemoteName: "bad", RemotePath: "bucket/photos"},
+		{Name: "Both", RemoteName: "good", RemotePath: "bucket/both", Destinations: []syncconfig.Destination{{RemoteName: "bad", RemotePath: "bucket/both"}}},
+	} {
+		pair.LocalPath = t.TempDir()
+		pair.Direction = "upload"
+		pair.Enabled = true
+		require.NoError(t, mgr.AddSyncPair(pair))
+	}
+
+	called := filepath.Join(home, "args")
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte("#!/bin/sh\necho \"$RCLONE_CONFIG_GOOD_KEY $@\" >> "+called+"\n"), 0755))
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: rcloneBin,
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err, "a remote that fails to resolve does not stop the others")
+
+	require.NoError(t, manager.SyncPair("Docs", false, false))
+	assert.ErrorContains(t, manager.SyncPair("Photos", false, false), "bad key:")
+
+	// Only the destination on the failing remote fails
+	results, err := manager.SyncPairDestinations("Both", false, false)
+	var replicationErr *backup.ReplicationError
+	require.ErrorAs(t, err, &replicationErr)
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorContains(t, results[1].Err, "bad key:")
+
+	data, err := os.ReadFile(called)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "s3cret sync")
+	assert.Contains(t, string(data), "good:bucket/docs")
+	assert.Contains(t, string(data), "good:bucket/both")
+	assert.NotContains(t, string(data), "bad:")
+}
+
+func TestConfigEnvExportsResolvedCredentials(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLOUD_SYNC_TEST_KEY", "it's s3cret")
+	cfg, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, cfg.Save(&config.AppConfig{
+		Remotes: []config.RemoteConfig{
+			{Name: "good", Type: "b2", AccountID: "abc", ApplicationKey: "env:CLOUD_SYNC_TEST_KEY"},
+			{Name: "bad", Type: "b2", AccountID: "abc", ApplicationKey: "env:CLOUD_SYNC_TEST_MISSING"},
+		},
+	}))
+
+	// The scripts evaluate the exports, so the references that resolve
+	// are printed even when another one fails
+	var stdout, stderr bytes.Buffer
+	assert.NotEqual(t, 0, cli.Run([]string{"config", "env"}, &stdout, &stderr))
+	assert.Equal(t, "export RCLONE_CONFIG_GOOD_KEY='it'\\''s s3cret'\n", stdout.String())
+	assert.Contains(t, stderr.String(), "bad key:")
+}
+
+func TestConfigObscureMatchesRclone(t *testing.T) {
+	// Output of 'rclone obscure potato'
+	plain, err := config.Reveal("YmJiYmJiYmJiYmJiYmJiYp3gcEWbAw")
+	require.NoError(t, err)
+	assert.Equal(t, "potato", plain)
+
+	obscured, err := config.Obscure("correct horse")
+	require.NoError(t, err)
+	assert.NotContains(t, obscured, "horse")
+	plain, err = config.Reveal(obscured)
+	require.NoError(t, err)
+	assert.Equal(t, "correct horse", plain)
+
+	_, err = config.Reveal("not base64!")
+	assert.Error(t, err)
+	_, err = config.Reveal("c2hvcnQ")
+	assert.Error(t, err)
+
+	// rclone reads access keys as written, so they must not be obscured
+	assert.True(t, config.IsRclonePasswordOption("password"))
+	assert.False(t, config.IsRclonePasswordOption("key"))
+	assert.False(t, config.IsRclonePasswordOption("secret_access_key"))
+}
+
+func TestConfigParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"96M":    96 << 20,
+		"96m":    96 << 20,
+		"200MiB": 200 << 20,
+		"1.5G":   3 << 29,
+		"64 K":   64 << 10,
+		"1T":     1 << 40,
+	}
+	for s, want := range tests {
+		got, err := config.ParseSize(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+	for _, s := range []string{"", "100", "M", "-5M", "5X", "5 megabytes"} {
+		_, err := config.ParseSize(s)
+		assert.Error(t, err, s)
+	}
+	assert.Equal(t, "5M", config.FormatSize(5<<20))
+	assert.Equal(t, "4657M", config.FormatSize(4657<<20))
+	assert.Equal(t, "5G", config.FormatSize(5<<30))
+}
+
+func TestConfigUploadTuningValidate(t *testing.T) {
+	assert.NoError(t, config.UploadTuning{}.Validate("drive"))
+	assert.NoError(t, config.UploadTuning{ChunkSize: "96M", UploadCutoff: "200M", UploadConcurrency: 8}.Validate("b2"))
+	assert.NoError(t, config.UploadTuning{UploadCutoff: "5G"}.Validate("s3"))
+
+	assert.ErrorContains(t, config.UploadTuning{ChunkSize: "96M"}.Validate("drive"), "only supported for b2 and s3")
+	assert.ErrorContains(t, config.UploadTuning{ChunkSize: "4M"}.Validate("b2"), "at least 5M")
+	assert.ErrorContains(t, config.UploadTuning{UploadCutoff: "5G"}.Validate("b2"), "more than 4657M")
+	assert.ErrorContains(t, config.UploadTuning{ChunkSize: "lots"}.Validate("s3"), "invalid chunk size")
+	assert.ErrorContains(t, config.UploadTuning{UploadConcurrency: -1}.Validate("s3"), "negative")
+
+	assert.Equal(t, "rclone defaults", config.UploadTuning{}.String())
+	assert.Equal(t, "chunk size 96M, 8 parts at once", config.UploadTuning{ChunkSize: "96M", UploadConcurrency: 8}.String())
+}
+
+func TestConfigUploadTuningInRcloneConf(t *testing.T) {
+	dir := t.TempDir()
+	mgr := config.NewManagerWithPath(filepath.Join(dir, "config.json"))
+	require.NoError(t, mgr.Save(&config.AppConfig{RcloneConfig: filepath.Join(dir, "rclone.conf")}))
+
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{
+		Name: "videos", Type: "b2", AccountID: "abc", ApplicationKey: "key",
+		Tuning: &config.UploadTuning{ChunkSize: "96MiB", UploadCutoff: "200M", UploadConcurrency: 8},
+	}))
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "plain", Type: "s3", Provider: "Scaleway", AccountID: "a", ApplicationKey: "b"}))
+	err := mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "s3", Tuning: &config.UploadTuning{UploadCutoff: "6G"}})
+	assert.ErrorContains(t, err, "remote 'bad': upload cutoff")
+
+	require.NoError(t, mgr.GenerateRcloneConfig())
+	data, err := os.ReadFile(filepath.Join(dir, "rclone.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[videos]\ntype = b2\naccount = abc\nkey = key\nchunk_size = 96M\nupload_cutoff = 200M\nupload_concurrency = 8\n")
+	assert.Contains(t, string(data), "[plain]\ntype = s3\nprovider = Scaleway\naccess_key_id = a\nsecret_access_key = b\n\n")
+}
+
+func TestConfigMinioRemote(t *testing.T) {
+	dir := t.TempDir()
+	mgr := config.NewManagerWithPath(filepath.Join(dir, "config.json"))
+	require.NoError(t, mgr.Save(&config.AppConfig{RcloneConfig: filepath.Join(dir, "rclone.conf")}))
+
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{
+		Name: "nas", Type: "s3", Provider: config.ProviderMinio, AccountID: "a", ApplicationKey: "b",
+		Endpoint: "https://nas.local:9000", SkipTLSVerify: true,
+	}))
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{
+		Name: "lab", Type: "s3", Provider: config.ProviderMinio, AccountID: "a", ApplicationKey: "b",
+		Endpoint: "https://s3.lab.example", Region: "eu-west-1", VirtualHostStyle: true,
+	}))
+	err := mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "s3", Provider: config.ProviderMinio, AccountID: "a", ApplicationKey: "b"})
+	assert.ErrorContains(t, err, "remote 'bad': a self-hosted S3 server needs an endpoint")
+
+	require.NoError(t, mgr.GenerateRcloneConfig())
+	data, err := os.ReadFile(filepath.Join(dir, "rclone.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[nas]\ntype = s3\nprovider = Minio\naccess_key_id = a\nsecret_access_key = b\n"+
+		"endpoint = https://nas.local:9000\nforce_path_style = true\noverride.no_check_certificate = true\n\n")
+	assert.Contains(t, string(data), "[lab]\ntype = s3\nprovider = Minio\naccess_key_id = a\nsecret_access_key = b\n"+
+		"region = eu-west-1\nendpoint = https://s3.lab.example\nforce_path_style = false\n\n")
+}
+
+func TestConfigSftpRemote(t *testing.T) {
+	dir := t.TempDir()
+	mgr := config.NewManagerWithPath(filepath.Join(dir, "config.json"))
+	require.NoError(t, mgr.Save(&config.AppConfig{RcloneConfig: filepath.Join(dir, "rclone.conf")}))
+
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{
+		Name: "nas", Type: "sftp", Host: "nas.local", User: "backup",
+		KeyFile: "~/.ssh/id_ed25519", KnownHostsFile: "~/.ssh/known_hosts",
+	}))
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{
+		Name: "vps", Type: "sftp", Host: "vps.example", Port: 2222, User: "me", Password: "hunter2",
+	}))
+	err := mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "sftp", Host: "nas.local", User: "backup"})
+	assert.ErrorContains(t, err, "remote 'bad': an SFTP server needs a password or a private key file")
+	err = mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "sftp", User: "backup", Password: "x"})
+	assert.ErrorContains(t, err, "needs a host and a user")
+
+	require.NoError(t, mgr.GenerateRcloneConfig())
+	data, err := os.ReadFile(filepath.Join(dir, "rclone.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[nas]\ntype = sftp\nhost = nas.local\nuser = backup\n"+
+		"key_file = ~/.ssh/id_ed25519\nknown_hosts_file = ~/.ssh/known_hosts\n\n")
+	assert.Contains(t, string(data), "[vps]\ntype = sftp\nhost = vps.example\nuser = me\nport = 2222\npass = ")
+	assert.NotContains(t, string(data), "hunter2", "rclone reads the password obscured")
+
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	assert.Empty(t, appConfig.WithoutCredentials().Remotes[1].Password)
+}
+
+func TestConfigWebdavRemote(t *testing.T) {
+	dir := t.TempDir()
+	mgr := config.NewManagerWithPath(filepath.Join(dir, "config.json"))
+	require.NoError(t, mgr.Save(&config.AppConfig{RcloneConfig: filepath.Join(dir, "rclone.conf")}))
+
+	cloud := config.RemoteConfig{Name: "cloud", Type: "webdav", Vendor: "nextcloud",
+		URL: "https://cloud.example.com/remote.php/dav/files/me/", User: "me", Password: "app-pass"}
+	require.NoError(t, mgr.AddRemote(cloud))
+	err := mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "webdav", Vendor: "nextcloud", URL: "https://cloud.example.com/apps/files/"})
+	assert.ErrorContains(t, err, "nextcloud serves WebDAV under /remote.php/, e.g. https://cloud.example.com/remote.php/dav/files/USERNAME/")
+	err = mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "webdav", URL: "cloud.example.com"})
+	assert.ErrorContains(t, err, "'cloud.example.com' is not an http:// or https:// address")
+	err = mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "webdav", Vendor: "box", URL: "https://dav.example.com"})
+	assert.ErrorContains(t, err, "unknown WebDAV vendor 'box'")
+
+	require.NoError(t, mgr.GenerateRcloneConfig())
+	data, err := os.ReadFile(filepath.Join(dir, "rclone.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[cloud]\ntype = webdav\nurl = https://cloud.example.com/remote.php/dav/files/me/\nvendor = nextcloud\nuser = me\npass = ")
+	assert.NotContains(t, string(data), "app-pass")
+
+	// The same settings can be handed to rclone without rclone.conf
+	env, err := cloud.Env("probe")
+	require.NoError(t, err)
+	assert.Contains(t, env, "RCLONE_CONFIG_PROBE_TYPE=webdav")
+	assert.Contains(t, env, "RCLONE_CONFIG_PROBE_VENDOR=nextcloud")
+	pass := env[len(env)-1]
+	require.True(t, strings.HasPrefix(pass, "RCLONE_CONFIG_PROBE_PASS="))
+	revealed, err := config.Reveal(strings.TrimPrefix(pass, "RCLONE_CONFIG_PROBE_PASS="))
+	require.NoError(t, err)
+	assert.Equal(t, "app-pass", revealed)
+}
+
+func TestConfigGcsRemote(t *testing.T) {
+	dir := t.TempDir()
+	mgr := config.NewManagerWithPath(filepath.Join(dir, "config.json"))
+	require.NoError(t, mgr.Save(&config.AppConfig{RcloneConfig: filepath.Join(dir, "rclone.conf")}))
+
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "gcs", Type: config.TypeGCS, Provider: "Google",
+		ServiceAccountFile: "~/keys/backup.json", ProjectNumber: "123456789012", BucketPolicyOnly: true}))
+	err := mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: config.TypeGCS})
+	assert.ErrorContains(t, err, "remote 'bad': a Google Cloud Storage remote needs a service account key file")
+
+	require.NoError(t, mgr.GenerateRcloneConfig())
+	data, err := os.ReadFile(filepath.Join(dir, "rclone.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[gcs]\ntype = google cloud storage\nservice_account_file = ~/keys/backup.json\n"+
+		"project_number = 123456789012\nbucket_policy_only = true\n\n")
+
+	key := filepath.Join(dir, "key.json")
+	require.NoError(t, os.WriteFile(key, []byte(`{"type": "service_account", "project_id": "acme-backups", "client_email": "sync@acme-backups.iam.gserviceaccount.com"}`), 0600))
+	account, err := config.ReadServiceAccount(key)
+	require.NoError(t, err)
+	assert.Equal(t, "acme-backups", account.ProjectID)
+	assert.Equal(t, "sync@acme-backups.iam.gserviceaccount.com", account.ClientEmail)
+
+	require.NoError(t, os.WriteFile(key, []byte(`{"type": "authorized_user"}`), 0600))
+	_, err = config.ReadServiceAccount(key)
+	assert.ErrorContains(t, err, "key.json is not a service account key file")
+}
+
+func TestConfigAzureRemote(t *testing.T) {
+	dir := t.TempDir()
+	mgr := config.NewManagerWithPath(filepath.Join(dir, "config.json"))
+	require.NoError(t, mgr.Save(&config.AppConfig{RcloneConfig: filepath.Join(dir, "rclone.conf")}))
+
+	sas := "https://acme.blob.core.windows.net/backups?sv=2022-11-02&sp=rwdl&sig=abc%3D"
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "az", Type: "azureblob", AccountID: "acme", ApplicationKey: "k"}))
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "azcn", Type: "azureblob", AccountID: "acme", ApplicationKey: "k",
+		EndpointSuffix: "core.chinacloudapi.cn"}))
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "azsas", Type: "azureblob", SASURL: sas}))
+
+	err := mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "azureblob", AccountID: "acme"})
+	assert.ErrorContains(t, err, "needs an account name and key, or a SAS URL")
+	err = mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "azureblob", AccountID: "acme", ApplicationKey: "k", SASURL: sas})
+	assert.ErrorContains(t, err, "use an account key or a SAS URL, not both")
+	err = mgr.AddRemote(config.RemoteConfig{Name: "bad", Type: "azureblob", SASURL: "https://acme.blob.core.windows.net/backups"})
+	assert.ErrorContains(t, err, "must be an https:// address with a signature")
+
+	require.NoError(t, mgr.GenerateRcloneConfig())
+	data, err := os.ReadFile(filepath.Join(dir, "rclone.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[az]\ntype = azureblob\naccount = acme\nkey = k\n\n")
+	assert.Contains(t, string(data), "[azcn]\ntype = azureblob\naccount = acme\nkey = k\nendpoint = https://acme.blob.core.chinacloudapi.cn\n\n")
+	assert.Contains(t, string(data), "[azsas]\ntype = azureblob\nsas_url = "+sas+"\n\n")
+
+	assert.Equal(t, "backups", config.RemoteConfig{SASURL: sas}.SASContainer())
+	assert.Empty(t, config.RemoteConfig{SASURL: "https://acme.blob.core.windows.net/?sv=1&sig=x"}.SASContainer())
+}
+
+func TestCLIConfigTuning(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	mgr, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "b2", Type: "b2", AccountID: "abc", ApplicationKey: "key"}))
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, cli.Run([]string{"config", "tuning", "b2"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "b2: rclone defaults\n", stdout.String())
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"config", "tuning", "--chunk-size", "96M", "--concurrency", "8", "b2"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "b2: chunk size 96M, 8 parts at once\n", stdout.String())
+
+	// Flags that are not given keep their value
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"config", "tuning", "--upload-cutoff", "200M", "b2"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "b2: chunk size 96M, upload cutoff 200M, 8 parts at once\n", stdout.String())
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	data, err := os.ReadFile(appConfig.RcloneConfig)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "chunk_size = 96M\nupload_cutoff = 200M\nupload_concurrency = 8\n")
+
+	stderr.Reset()
+	assert.Equal(t, 1, cli.Run([]string{"config", "tuning", "--chunk-size", "1M", "b2"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "chunk size must be at least 5M")
+	assert.Equal(t, 1, cli.Run([]string{"config", "tuning", "missing"}, &stdout, &stderr))
+	assert.Equal(t, 2, cli.Run([]string{"config", "tuning"}, &stdout, &stderr))
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"config", "tuning", "--reset", "b2"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "b2: rclone defaults\n", stdout.String())
+	remote, err := mgr.GetRemote("b2")
+	require.NoError(t, err)
+	assert.Nil(t, remote.Tuning)
+}
+
+func TestSyncPairsViewWarnsAboutUnresolvedCredentials(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cfg, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, cfg.Save(&config.AppConfig{
+		Remotes: []config.RemoteConfig{
+			{Name: "bad", Type: "b2", AccountID: "abc", ApplicationKey: "env:CLOUD_SYNC_TEST_MISSING"},
+		},
+	}))
+
+	var menu tea.Model = ui.NewModel()
+	menu, _ = menu.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	for _, key := range []string{"down", "enter"} {
+		menu, _ = menu.Update(keyPress(key))
+	}
+	view := menu.(ui.Model).ActiveView()
+	require.IsType(t, views.SyncPairsModel{}, view)
+	assert.Contains(t, view.View(), "failed to resolve credentials: bad key:")
+}
@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/cost"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/status"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestCostPlanFor(t *testing.T) {
+	tests := []struct {
+		remote map[string]string
+		want   string
+	}{
+		{map[string]string{"type": "b2"}, "Backblaze B2"},
+		{map[string]string{"type": "s3", "provider": "AWS"}, "Amazon S3 Standard"},
+		{map[string]string{"type": "s3", "provider": "AWS", "storage_class": "deep_archive"}, "Amazon S3 Glacier Deep Archive"},
+		{map[string]string{"type": "s3", "provider": "Wasabi"}, "Wasabi"},
+		{map[string]string{"type": "s3", "provider": "Scaleway", "storage_class": "GLACIER"}, "Scaleway Glacier"},
+		{map[string]string{"type": "s3", "provider": "Scaleway"}, "Scaleway Standard"},
+	}
+	for _, tt := range tests {
+		plan, ok := cost.PlanFor(tt.remote)
+		require.True(t, ok, "%v", tt.remote)
+		assert.Equal(t, tt.want, plan.Name)
+	}
+
+	for _, remote := range []map[string]string{
+		{"type": "drive"},
+		{"type": "s3", "provider": "Minio"},
+		{"type": "s3", "provider": "AWS", "storage_class": "REDUCED_REDUNDANCY"},
+		nil,
+	} {
+		_, ok := cost.PlanFor(remote)
+		assert.False(t, ok, "%v", remote)
+	}
+}
+
+func TestCostMonthly(t *testing.T) {
+	tests := []struct {
+		plan  string
+		usage cost.Usage
+		want  float64
+	}{
+		// The first 10 GB are free
+		{"b2", cost.Usage{StoredBytes: 110e9}, 0.6},
+		// Downloads up to three times the stored amount are free
+		{"b2", cost.Usage{StoredBytes: 100e9, EgressBytes: 300e9}, 0.54},
+		{"b2", cost.Usage{StoredBytes: 10e9, EgressBytes: 50e9}, 0.2},
+		// Wasabi bills at least 1 TB
+		{"wasabi", cost.Usage{StoredBytes: 100e9}, 6.99},
+		{"s3-standard", cost.Usage{StoredBytes: 100e9, EgressBytes: 150e9}, 2.3 + 4.5},
+		{"scaleway-standard", cost.Usage{StoredBytes: 0, EgressBytes: 75e9}, 0},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, cost.Plans[tt.plan].Monthly(tt.usage), 0.0001, "%s %+v", tt.plan, tt.usage)
+	}
+}
+
+func TestCostEstimateTotal(t *testing.T) {
+	var estimate cost.Estimate
+	assert.Equal(t, "", estimate.Total())
+
+	estimate.Add("b2", cost.Plans["b2"], cost.Usage{StoredBytes: 110e9})
+	assert.Equal(t, "$0.60/month", estimate.Total())
+
+	estimate.Add("aws", cost.Plans["s3-standard"], cost.Usage{StoredBytes: 100e9})
+	estimate.Add("scw", cost.Plans["scaleway-glacier"], cost.Usage{StoredBytes: 1000e9})
+	assert.Equal(t, "$2.90 + €2.54/month", estimate.Total())
+}
+
+// costHome sets up pairs on a B2, an S3 and a Google Drive remote. The
+// photos pair downloaded 2 MiB yesterday.
+func costHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	conf := filepath.Join(home, ".config", "rclone", "rclone.conf")
+	require.NoError(t, os.MkdirAll(filepath.Dir(conf), 0755))
+	require.NoError(t, os.WriteFile(conf, []byte(`[b2]
+type = b2
+
+[aws]
+type = s3
+provider = AWS
+storage_class = STANDARD_IA
+
+[gdrive]
+type = drive
+`), 0600))
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	for _, pair := range []syncconfig.SyncPair{
+		{Name: "docs", RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload"},
+		{Name: "photos", RemoteName: "aws", RemotePath: "bucket/photos", Direction: "download"},
+		{Name: "notes", RemoteName: "gdrive", RemotePath: "notes", Direction: "upload"},
+	} {
+		pair.LocalPath = filepath.Join(home, pair.Name)
+		pair.Enabled = true
+		require.NoError(t, os.MkdirAll(pair.LocalPath, 0755))
+		require.NoError(t, mgr.AddSyncPair(pair))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(home, "docs", "report.pdf"), make([]byte, 4096), 0644))
+
+	logDir := filepath.Join(home, "logs")
+	require.NoError(t, os.MkdirAll(logDir, 0755))
+	day := time.Now().AddDate(0, 0, -1).Format("2006/01/02")
+	createTestLogFile(t, logs.PairLogPath(logDir, "photos"), day+` 09:00:00 NOTICE: Manual Sync Requested
+Transferred:   	    2.000 MiB / 2.000 MiB, 100%, 1 MiB/s, ETA 0s
+`+day+` 09:01:00 NOTICE: Manual Sync Complete: Success
+`)
+	return home
+}
+
+func TestStatusEstimateCost(t *testing.T) {
+	costHome(t)
+
+	sizes, err := status.PairSizes()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"docs": 4096, "photos": 0, "notes": 0}, sizes)
+
+	estimate, err := status.EstimateCost(map[string]int64{"docs": 110e9, "photos": 50e9}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, estimate.Lines, 2)
+	assert.Equal(t, "b2", estimate.Lines[0].Remote)
+	assert.InDelta(t, 0.6, estimate.Lines[0].Cost, 0.0001)
+	assert.Equal(t, "aws", estimate.Lines[1].Remote)
+	assert.Equal(t, "Amazon S3 Standard-IA", estimate.Lines[1].Plan.Name)
+	assert.Equal(t, cost.Usage{StoredBytes: 50e9, EgressBytes: 2 << 20}, estimate.Lines[1].Usage)
+	assert.Equal(t, []string{"gdrive"}, estimate.Unpriced)
+}
+
+func TestCLIStatusSizeShowsCost(t *testing.T) {
+	costHome(t)
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, cli.Run([]string{"status", "--size"}, &stdout, &stderr), stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Protected size: 4.0 KB")
+	assert.Contains(t, out, "Estimated cost of b2 (Backblaze B2): $0.00/month for 4.0 KB stored, 0 B downloaded")
+	assert.Contains(t, out, "Estimated cost of aws (Amazon S3 Standard-IA): $0.00/month for 0 B stored, 2.0 MB downloaded")
+	assert.Contains(t, out, "Estimated monthly cost: $0.00/month")
+	assert.Contains(t, out, "No pricing for: gdrive")
+}
+
+func TestDashboardShowsCost(t *testing.T) {
+	summary := &status.Summary{Pairs: 1, EnabledPairs: 1}
+	estimate := &cost.Estimate{}
+	estimate.Add("b2", cost.Plans["b2"], cost.Usage{StoredBytes: 110e9})
+
+	out := views.RenderDashboard(summary, 110e9, estimate, 120)
+	assert.Contains(t, out, "Est. cost: ~$0.60/month")
+	assert.NotContains(t, views.RenderDashboard(summary, 0, &cost.Estimate{}, 120), "Est. cost")
+}
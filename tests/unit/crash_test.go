@@ -0,0 +1,187 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/crash"
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+)
+
+// panicky is a model that panics on the key "p", in View once told to with
+// "v", and in the command it returns for "c"
+type panicky struct {
+	viewPanics bool
+}
+
+func (m panicky) Init() tea.Cmd { return nil }
+
+func (m panicky) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "p":
+			panic("boom in update")
+		case "v":
+			m.viewPanics = true
+		case "c":
+			return m, tea.Batch(func() tea.Msg { return nil }, func() tea.Msg { panic("boom in command") })
+		}
+	}
+	return m, nil
+}
+
+func (m panicky) View() string {
+	if m.viewPanics {
+		panic("boom in view")
+	}
+	return "fine"
+}
+
+func TestCrashReporterRecent(t *testing.T) {
+	reporter := crash.NewReporter(t.TempDir(), "1.0.0", nil)
+	assert.Empty(t, reporter.Recent())
+
+	reporter.Record("tick")
+	reporter.Record("tick")
+	reporter.Record("key: enter")
+	assert.Equal(t, []string{"tick (x2)", "key: enter"}, reporter.Recent())
+
+	for i := 0; i < crash.MaxEvents+5; i++ {
+		reporter.Record(fmt.Sprintf("event %d", i))
+	}
+	recent := reporter.Recent()
+	require.Len(t, recent, crash.MaxEvents)
+	assert.Equal(t, "event 5", recent[0])
+	assert.Equal(t, fmt.Sprintf("event %d", crash.MaxEvents+4), recent[len(recent)-1])
+}
+
+func TestCrashReporterReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crash")
+	appConfig := &config.AppConfig{
+		HomeDir: "/Users/tester",
+		Remotes: []config.RemoteConfig{{Name: "b2", Type: "b2", AccountID: "account-id", ApplicationKey: "app-secret-key"}},
+	}
+	reporter := crash.NewReporter(dir, "1.2.3", func() (any, error) {
+		return appConfig.WithoutCredentials(), nil
+	})
+	reporter.Record("key: enter")
+	assert.False(t, reporter.Crashed())
+
+	path, err := reporter.Report("boom", []byte("goroutine 1 [running]:\nmain.main()\n"))
+	require.NoError(t, err)
+	assert.True(t, reporter.Crashed())
+	assert.Equal(t, dir, filepath.Dir(path))
+	assert.Contains(t, reporter.Message(), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	report := string(data)
+	assert.Contains(t, report, "Version: 1.2.3")
+	assert.Contains(t, report, "Panic: boom")
+	assert.Contains(t, report, "main.main()")
+	assert.Contains(t, report, "  key: enter")
+	assert.Contains(t, report, `"home_dir": "/Users/tester"`)
+	assert.NotContains(t, report, "app-secret-key")
+	assert.NotContains(t, report, "account-id")
+	assert.Equal(t, "app-secret-key", appConfig.Remotes[0].ApplicationKey, "the config is not modified")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Only the first panic is reported
+	again, err := reporter.Report("second", nil)
+	require.NoError(t, err)
+	assert.Equal(t, path, again)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// A snapshot that fails is noted instead
+	failing := crash.NewReporter(t.TempDir(), "1.2.3", func() (any, error) { return nil, errors.New("no config") })
+	path, err = failing.Report("boom", nil)
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "not available: no config")
+
+	// A report that cannot be written says why
+	blocked := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(blocked, nil, 0644))
+	unwritable := crash.NewReporter(filepath.Join(blocked, "crash"), "1.2.3", nil)
+	_, err = unwritable.Report("boom", nil)
+	assert.Error(t, err)
+	assert.True(t, unwritable.Crashed())
+	assert.Contains(t, unwritable.Message(), "could not be saved")
+}
+
+// reportText returns the only crash report in dir
+func reportText(t *testing.T, dir string) string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestGuardReportsPanics(t *testing.T) {
+	t.Run("update", func(t *testing.T) {
+		dir := t.TempDir()
+		reporter := crash.NewReporter(dir, "dev", nil)
+		model := ui.Guard(panicky{}, reporter)
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("secret")})
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+		require.NotNil(t, cmd)
+		assert.Equal(t, tea.QuitMsg{}, cmd())
+
+		report := reportText(t, dir)
+		assert.Contains(t, report, "Panic: boom in update")
+		assert.Contains(t, report, "  key: enter\n  key: (text) (x2)\n")
+		assert.NotContains(t, report, "secret")
+		assert.Equal(t, "fine", model.View())
+	})
+
+	t.Run("command", func(t *testing.T) {
+		dir := t.TempDir()
+		reporter := crash.NewReporter(dir, "dev", nil)
+		_, cmd := ui.Guard(panicky{}, reporter).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		require.NotNil(t, cmd)
+
+		// The batch's commands are guarded too
+		batch, ok := cmd().(tea.BatchMsg)
+		require.True(t, ok)
+		require.Len(t, batch, 2)
+		assert.Nil(t, batch[0]())
+		assert.Equal(t, tea.QuitMsg{}, batch[1]())
+		assert.Contains(t, reportText(t, dir), "Panic: boom in command")
+	})
+
+	t.Run("view", func(t *testing.T) {
+		dir := t.TempDir()
+		reporter := crash.NewReporter(dir, "dev", nil)
+		model, _ := ui.Guard(panicky{}, reporter).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+
+		view := model.View()
+		assert.True(t, strings.HasPrefix(view, "cloud-sync crashed. A report was saved to "+dir))
+		assert.Contains(t, view, "Press any key to quit.")
+		assert.Contains(t, reportText(t, dir), "Panic: boom in view")
+
+		// Any message then quits
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		require.NotNil(t, cmd)
+		assert.Equal(t, tea.QuitMsg{}, cmd())
+	})
+}
@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+)
+
+// simulatedCommands returns the recorded commands, one per line
+func simulatedCommands() string {
+	var lines []string
+	for _, action := range simulate.Actions() {
+		lines = append(lines, action.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDaemonPlist(t *testing.T) {
+	daemon := launchd.NewManager("tester").Daemon()
+	assert.True(t, daemon.IsDaemon())
+	assert.Equal(t, "LaunchDaemon", daemon.Kind())
+	assert.Equal(t, "/Library/LaunchDaemons/com.tester.cloudsync-daemon.plist", daemon.GetPlistPath())
+	assert.False(t, daemon.Mount().IsDaemon())
+	assert.Contains(t, daemon.Mount().GetPlistPath(), "LaunchAgents")
+
+	schedule := launchd.Schedule{Times: []launchd.TimeOfDay{{Hour: 2, Minute: 30}}, Weekdays: []int{1}}
+	data, err := launchd.RenderPlist(launchd.DaemonConfig(daemon.GetLabel(), "tester", "/Users/tester/bin/monthly_backup.sh", schedule))
+	require.NoError(t, err)
+	plist := string(data)
+	assert.Contains(t, plist, "<key>UserName</key>\n\t<string>tester</string>")
+	assert.Contains(t, plist, "<key>InitGroups</key>")
+	assert.Contains(t, plist, "<string>/Users/tester/bin/monthly_backup.sh</string>")
+	assert.Contains(t, plist, "<key>Weekday</key>\n\t\t\t<integer>1</integer>")
+	assert.NotContains(t, plist, "RunAtLoad")
+
+	// LaunchAgents run as the logged in user and name none
+	data, err = launchd.RenderPlist(&launchd.Config{Label: "com.tester.rclonebackup", ScriptPath: "/backup.sh"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "UserName")
+}
+
+func TestDaemonStatus(t *testing.T) {
+	// launchctl print nests the job's dictionaries, which repeat keys
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "launchctl"), []byte(`#!/bin/sh
+[ "$1 $2" = "print system/com.tester.cloudsync-daemon" ] || exit 1
+cat <<'EOF'
+system/com.tester.cloudsync-daemon = {
+	active count = 1
+	path = /Library/LaunchDaemons/com.tester.cloudsync-daemon.plist
+	state = running
+	pid = 4242
+	last exit code = 1
+	endpoints = {
+		pid = 1
+	}
+}
+EOF
+`), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	status, err := launchd.NewManager("tester").Daemon().GetStatus()
+	require.NoError(t, err)
+	assert.Equal(t, &launchd.Status{Loaded: true, Running: true, PID: 4242, LastExit: 1}, status)
+}
+
+func TestCLIDaemon(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, 1, cli.Run([]string{"daemon", "--user", "tester"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "monthly_backup.sh is missing")
+
+	writeFiles(t, filepath.Join(home, "bin"), map[string]string{"monthly_backup.sh": "#!/bin/zsh\n"})
+	require.Equal(t, 0, cli.Run([]string{"daemon", "--user", "tester"}, &stdout, &stderr), stderr.String())
+	plistPath := "/Library/LaunchDaemons/com.tester.cloudsync-daemon.plist"
+	assert.Contains(t, stdout.String(), "Installed "+plistPath+".")
+	assert.Contains(t, stdout.String(), "Backups run as tester (Daily at 10:05), whether or not anyone is logged in.")
+
+	// The plist is installed owned by root and loaded into the system domain
+	commands := simulatedCommands()
+	assert.Regexp(t, `install -m 0644 -o root -g wheel \S+\.plist `+plistPath, commands)
+	assert.Contains(t, commands, "launchctl bootstrap system "+plistPath)
+
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	assert.True(t, appConfig.LaunchAgent.System)
+	assert.True(t, appConfig.LaunchAgent.Enabled)
+	assert.True(t, config.BackupScheduler("tester").IsDaemon())
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"daemon", "--off", "--user", "tester"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Removed "+plistPath+".")
+	assert.False(t, config.BackupScheduler("tester").IsDaemon())
+
+	if os.Geteuid() == 0 {
+		assert.Equal(t, 2, cli.Run([]string{"daemon"}, &stdout, &stderr), "the user must be named under sudo")
+	}
+}
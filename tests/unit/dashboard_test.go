@@ -0,0 +1,60 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/status"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestDashboardLoadSummary(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	docs := filepath.Join(home, "Documents")
+	require.NoError(t, os.MkdirAll(docs, 0755))
+
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, syncConfigMgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "docs", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true,
+	}))
+
+	logDir := filepath.Join(home, "logs")
+	require.NoError(t, logs.NewManager(logDir).LogPairEvent("docs", "Manual Sync Requested"))
+	require.NoError(t, logs.NewManager(logDir).LogPairEvent("docs", "Manual Sync Complete: Success"))
+
+	summary := status.Load(launchd.NewManager("tester"), time.Now())
+	assert.Equal(t, 1, summary.Pairs)
+	assert.Equal(t, 1, summary.EnabledPairs)
+	require.NotNil(t, summary.LastRun)
+	assert.True(t, summary.LastRun.Success)
+	assert.True(t, summary.NextRun.IsZero())
+	assert.Empty(t, summary.Warnings)
+}
+
+func TestDashboardRender(t *testing.T) {
+	assert.Contains(t, views.RenderDashboard(nil, -1, nil, 80), "Loading status")
+
+	summary := &status.Summary{
+		Pairs:        2,
+		EnabledPairs: 1,
+		Warnings:     []string{"LaunchAgent is not loaded; scheduled backups will not run"},
+	}
+	out := views.RenderDashboard(summary, -1, nil, 100)
+	assert.Contains(t, out, "Pairs: 2 (1 enabled)")
+	assert.Contains(t, out, "calculating...")
+	assert.Contains(t, out, "not scheduled")
+	assert.Contains(t, out, "LaunchAgent is not loaded")
+
+	assert.Contains(t, views.RenderDashboard(summary, 2048, nil, 100), "2.0 KB")
+}
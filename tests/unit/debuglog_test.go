@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/debuglog"
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+func TestTakeDebugFlag(t *testing.T) {
+	args, level := ui.TakeDebugFlag([]string{"--read-only"})
+	assert.Equal(t, []string{"--read-only"}, args)
+	assert.Equal(t, debuglog.Off, level)
+
+	args, level = ui.TakeDebugFlag([]string{"-v", "--read-only"})
+	assert.Equal(t, []string{"--read-only"}, args)
+	assert.Equal(t, debuglog.Info, level)
+
+	_, level = ui.TakeDebugFlag([]string{"--debug", "-v"})
+	assert.Equal(t, debuglog.Debug, level)
+}
+
+func TestDebugLogLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", debuglog.FileName)
+	log := debuglog.New(path, debuglog.Off)
+	t.Cleanup(func() { log.Close() })
+
+	log.Printf(debuglog.Info, "not written")
+	assert.NoFileExists(t, path, "the file is only created once a line is logged")
+
+	log.SetLevel(debuglog.Info)
+	assert.True(t, log.Enabled(debuglog.Info))
+	assert.False(t, log.Enabled(debuglog.Debug))
+	log.Printf(debuglog.Info, "key %q", "enter")
+	log.Printf(debuglog.Debug, "tick")
+
+	log.SetLevel(debuglog.Debug)
+	log.Printf(debuglog.Debug, "tick\n")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `^\[\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}\.\d{3}\] key "enter"$`, lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], "] tick"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestDebugLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), debuglog.FileName)
+	log := debuglog.New(path, debuglog.Info)
+	t.Cleanup(func() { log.Close() })
+
+	line := strings.Repeat("x", 1000)
+	for i := 0; i < (debuglog.Keep+2)*debuglog.MaxSize/1000; i++ {
+		log.Printf(debuglog.Info, "%d %s", i, line)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2", path + ".3"} {
+		info, err := os.Stat(name)
+		require.NoError(t, err, name)
+		assert.LessOrEqual(t, info.Size(), int64(debuglog.MaxSize), name)
+	}
+	assert.NoFileExists(t, fmt.Sprintf("%s.%d", path, debuglog.Keep+1))
+
+	// The newest lines are in the current log
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), fmt.Sprintf("] %d x", (debuglog.Keep+2)*debuglog.MaxSize/1000-1))
+}
+
+func TestDebugModel(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, debuglog.FileName)
+	log := debuglog.New(path, debuglog.Off)
+	t.Cleanup(func() { log.Close() })
+
+	var model tea.Model = ui.NewDebugModel(ui.NewModel(), log)
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.NoFileExists(t, path, "logging starts off")
+
+	// The toggle key turns it on, at debug level when no flag was given
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	assert.Equal(t, debuglog.Debug, log.Level())
+	assert.Contains(t, testutil.PlainText(model.View()), "Debug log on (debug): "+path)
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 90, Height: 30})
+
+	// Typed text is left out in sub-views
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	assert.Equal(t, debuglog.Off, log.Level())
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyUp})
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	out := string(data)
+	assert.Contains(t, out, "=== Logging turned on at debug ===")
+	assert.Contains(t, out, `KeyMsg: "down"`)
+	assert.Contains(t, out, "List Index Before: 1")
+	assert.Contains(t, out, "List Index After: 2")
+	assert.Contains(t, out, "WindowSizeMsg: 90x30")
+	assert.Contains(t, out, `KeyMsg: "(text)"`)
+	assert.NotContains(t, out, `KeyMsg: "s"`)
+	assert.Contains(t, out, "=== Logging turned off ===")
+	assert.NotContains(t, out, `KeyMsg: "up"`)
+	assert.Equal(t, 1, strings.Count(out, `KeyMsg: "down"`))
+}
@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/dedup"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// writeFiles creates files under root with the given contents
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// dedupPairs returns a Documents pair, a Work pair inside it and a Photos
+// pair. report.txt is in Work and Photos, so it is backed up three times.
+func dedupPairs(t *testing.T, home string) []syncconfig.SyncPair {
+	t.Helper()
+	report := strings.Repeat("r", 1000)
+	writeFiles(t, filepath.Join(home, "Documents"), map[string]string{
+		"Work/report.txt": report,
+		"notes.txt":       strings.Repeat("n", 1000), // Same size, other content
+		"empty.txt":       "",
+	})
+	writeFiles(t, filepath.Join(home, "Pictures"), map[string]string{
+		"scan/report-copy.txt": report,
+		"cat.jpg":              "meow",
+		"empty.txt":            "",
+	})
+	return []syncconfig.SyncPair{
+		{Name: "Documents", LocalPath: filepath.Join(home, "Documents"), RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true},
+		{Name: "Work", LocalPath: filepath.Join(home, "Documents", "Work"), RemoteName: "b2", RemotePath: "bucket/work", Direction: "upload", Enabled: true},
+		{Name: "Photos", LocalPath: filepath.Join(home, "Pictures"), RemoteName: "b2", RemotePath: "bucket/photos", Direction: "upload", Enabled: true},
+	}
+}
+
+func TestDedupScanLocal(t *testing.T) {
+	home := t.TempDir()
+	report, err := dedup.ScanLocal(dedupPairs(t, home))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Pairs)
+	assert.Equal(t, 7, report.Files)
+	assert.Equal(t, []dedup.Overlap{{Outer: "Documents", Inner: "Work", Path: filepath.Join(home, "Documents", "Work")}}, report.Overlaps)
+
+	require.Len(t, report.Groups, 1)
+	group := report.Groups[0]
+	assert.Equal(t, int64(1000), group.Size)
+	assert.ElementsMatch(t, []dedup.Copy{
+		{Pair: "Documents", Path: filepath.Join("Work", "report.txt")},
+		{Pair: "Work", Path: "report.txt"},
+		{Pair: "Photos", Path: filepath.Join("scan", "report-copy.txt")},
+	}, group.Copies)
+	assert.Equal(t, int64(2000), report.Savings())
+	assert.Equal(t, 2, report.ExtraCopies())
+}
+
+func TestDedupScanRemote(t *testing.T) {
+	manager := fakeRclone(t, `case "$2" in
+b2:bucket/docs)
+	echo '[{"Path":"a.pdf","Size":500,"Hashes":{"sha1":"aaa","md5":"x"}},{"Path":"b.pdf","Size":500,"Hashes":{"sha1":"bbb"}}]' ;;
+b2:bucket/docs/2023)
+	echo '[{"Path":"a.pdf","Size":500,"Hashes":{"sha1":"aaa"}}]' ;;
+b2:bucket/photos)
+	echo '[{"Path":"a-copy.pdf","Size":500,"Hashes":{"sha1":"aaa"}},{"Path":"nohash.jpg","Size":500}]' ;;
+esac
+`)
+	pairs := []syncconfig.SyncPair{
+		{Name: "Docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload"},
+		{Name: "Archive", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/docs/2023", Direction: "download"},
+		{Name: "Photos", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/photos", Direction: "upload"},
+		{Name: "Mirror", LocalPath: t.TempDir(), Type: syncconfig.TypeLocal, TargetPath: t.TempDir(), Direction: "upload"},
+	}
+
+	report, err := dedup.ScanRemote(manager, rclone.NewIndexCache(t.TempDir()), pairs)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Pairs, "local pairs have no remote")
+	assert.Equal(t, 5, report.Files)
+	assert.Equal(t, []dedup.Overlap{{Outer: "Docs", Inner: "Archive", Path: "b2:bucket/docs/2023"}}, report.Overlaps)
+	require.Len(t, report.Groups, 1)
+	assert.Equal(t, "sha1:aaa", report.Groups[0].Hash)
+	assert.Equal(t, []dedup.Copy{{Pair: "Docs", Path: "a.pdf"}, {Pair: "Archive", Path: "a.pdf"}, {Pair: "Photos", Path: "a-copy.pdf"}}, report.Groups[0].Copies)
+}
+
+func TestDedupScanRemoteFailure(t *testing.T) {
+	manager := fakeRclone(t, "exit 3\n")
+	pairs := []syncconfig.SyncPair{{Name: "Docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/docs"}}
+
+	_, err := dedup.ScanRemote(manager, rclone.NewIndexCache(t.TempDir()), pairs)
+	assert.ErrorContains(t, err, "failed to list b2:bucket/docs")
+}
+
+func TestCLIDedup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, cli.Run([]string{"dedup"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "No sync pairs configured.")
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	for _, pair := range dedupPairs(t, home) {
+		require.NoError(t, mgr.AddSyncPair(pair))
+	}
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"dedup"}, &stdout, &stderr), stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Scanned 7 files in 3 pairs.")
+	assert.Contains(t, out, "Work is inside Documents")
+	assert.Contains(t, out, "Backed up more than once: 1 files, 2 extra copies, 2.0 KB could be saved.")
+	assert.Contains(t, out, "1000 B x3: ")
+	assert.Contains(t, out, "Photos:"+filepath.Join("scan", "report-copy.txt"))
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"dedup", "--limit", "0"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "...and 1 more")
+	assert.NotContains(t, stdout.String(), "x3")
+}
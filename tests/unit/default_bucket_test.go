@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// bucketWizard opens the edit form of the "sw" remote, with rclone
+// replaced by a script that runs body
+func bucketWizard(t *testing.T, body string) (*config.Manager, tea.Model) {
+	t.Helper()
+	mgr, _ := remoteFixture(t)
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = filepath.Join(filepath.Dir(fakeRclone(t, body).GetConfigPath()), "rclone")
+	require.NoError(t, mgr.Save(appConfig))
+
+	remote, err := mgr.GetRemote("sw")
+	require.NoError(t, err)
+	return mgr, views.NewEditRemoteModel(mgr, *remote)
+}
+
+func TestRemoteWizardPicksDefaultBucket(t *testing.T) {
+	mgr, form := bucketWizard(t, `[ "$1" = lsd ] && printf '          -1 2026-10-15 12:00:00        -1 archive\n          -1 2026-10-15 12:00:00        -1 photos\n'
+exit 0
+`)
+
+	// Saving lists the buckets, which checks the credentials
+	form, cmd := form.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	assert.Contains(t, form.View(), "Checking the credentials")
+	form, _ = form.Update(cmd())
+	view := form.View()
+	assert.Contains(t, view, "archive")
+	assert.Contains(t, view, "+ Create a new bucket")
+
+	form, _ = form.Update(keyPress("down"))
+	form, _ = form.Update(keyPress("enter"))
+	assert.Contains(t, form.View(), "Default bucket: photos")
+
+	remote, err := mgr.GetRemote("sw")
+	require.NoError(t, err)
+	assert.Equal(t, "photos", remote.Bucket)
+}
+
+func TestRemoteWizardCreatesDefaultBucket(t *testing.T) {
+	created := filepath.Join(t.TempDir(), "created")
+	mgr, form := bucketWizard(t, `[ "$1" = mkdir ] && echo "$2" > `+created+`
+exit 0
+`)
+
+	form = typeText(form, "")
+	require.Contains(t, form.View(), "+ Create a new bucket")
+	form, _ = form.Update(keyPress("enter"))
+	form = typeText(form, "Bad_Name")
+	assert.Contains(t, form.View(), "uppercase")
+
+	for range "Bad_Name" {
+		form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	form = typeText(form, "new-bucket")
+	assert.Contains(t, form.View(), "Default bucket: new-bucket")
+
+	target, err := os.ReadFile(created)
+	require.NoError(t, err)
+	assert.Equal(t, "sw:new-bucket\n", string(target))
+	remote, err := mgr.GetRemote("sw")
+	require.NoError(t, err)
+	assert.Equal(t, "new-bucket", remote.Bucket)
+}
+
+func TestDefaultBucketPrefillsSyncPair(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, configManager.AddRemote(config.RemoteConfig{Name: "b2", Type: "b2", Provider: "Backblaze", AccountID: "id", ApplicationKey: "key"}))
+	require.NoError(t, configManager.SetDefaultBucket("b2", "photos"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "photos", appConfig.DefaultBucket("b2"))
+	assert.Empty(t, appConfig.DefaultBucket("missing"))
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	var model tea.Model = views.NewSyncPairsModel(mgr, fakeRclone(t, "exit 0\n"))
+	model, _ = model.Update(keyPress("a"))
+	model = typeText(model, "docs")
+	model = typeText(model, t.TempDir())
+	model = typeText(model, "b2")
+	require.Contains(t, model.View(), "Enter the remote path")
+	assert.Contains(t, model.View(), "photos")
+}
@@ -0,0 +1,218 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deletingRclone is a fake rclone whose dry runs would delete 3 of the 4
+// destination files. Real syncs touch the synced file.
+func deletingRclone(synced string) string {
+	return `case "$1" in
+size) echo '{"count":4,"bytes":100}' ;;
+sync)
+	for arg in "$@"; do
+		if [ "$arg" = "--dry-run" ]; then
+			echo "NOTICE: keep.txt: Skipped copy as --dry-run is set (size 1)"
+			for f in a b c; do echo "NOTICE: $f.txt: Skipped delete as --dry-run is set (size 1)"; done
+			exit 0
+		fi
+	done
+	touch ` + synced + ` ;;
+esac
+`
+}
+
+func TestDeletePlanExceeds(t *testing.T) {
+	tests := []struct {
+		plan  rclone.DeletePlan
+		limit int
+		want  bool
+	}{
+		{rclone.DeletePlan{Deletes: 3, DestFiles: 4}, 50, true},
+		{rclone.DeletePlan{Deletes: 2, DestFiles: 4}, 50, false},
+		{rclone.DeletePlan{Deletes: 4, DestFiles: 4}, 100, false},
+		{rclone.DeletePlan{Deletes: 0, DestFiles: 0}, 0, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.plan.Exceeds(tt.limit), "%+v limit %d", tt.plan, tt.limit)
+	}
+	assert.Equal(t, 75.0, rclone.DeletePlan{Deletes: 3, DestFiles: 4}.Percent())
+}
+
+func TestSyncPairDeleteLimit(t *testing.T) {
+	pair := syncconfig.SyncPair{Name: "docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket", Direction: "upload"}
+	assert.Equal(t, syncconfig.DefaultMaxDeletePercent, pair.DeleteLimit())
+	require.NoError(t, syncconfig.ValidateSyncPair(&pair))
+
+	pair.MaxDeletePercent = 100
+	assert.Equal(t, 100, pair.DeleteLimit())
+	require.NoError(t, syncconfig.ValidateSyncPair(&pair))
+
+	pair.MaxDeletePercent = 101
+	assert.Error(t, syncconfig.ValidateSyncPair(&pair))
+}
+
+func TestRclonePreviewDeletes(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	manager := fakeRclone(t, `echo "$@" >> `+args+"\n"+deletingRclone(filepath.Join(dir, "synced")))
+
+	plan, err := manager.PreviewDeletes("/data", "b2:bucket", rclone.SyncOptions{
+		LogFile:    filepath.Join(dir, "pair.log"),
+		BackupDir:  "b2:bucket/.trash",
+		Excludes:   []string{"/.trash/**"},
+		FilterFrom: filepath.Join(dir, "docs.filter"),
+		ExtraFlags: []string{"-q", "--log-level", "ERROR", "--fast-list"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rclone.DeletePlan{Deletes: 3, DestFiles: 4}, plan)
+	assert.NoFileExists(t, filepath.Join(dir, "synced"))
+
+	calls, err := os.ReadFile(args)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "--dry-run")
+	assert.NotContains(t, lines[0], "--log-file")
+	assert.NotContains(t, lines[0], "--backup-dir")
+	// A quiet pair's dry run still prints the deletes it skips
+	assert.True(t, strings.HasSuffix(lines[0], "--fast-list --dry-run --log-level NOTICE"), lines[0])
+	assert.NotContains(t, lines[0], " -v ")
+	assert.NotContains(t, lines[0], " -q ")
+	assert.NotContains(t, lines[0], "ERROR")
+	assert.True(t, strings.HasPrefix(lines[1], "size b2:bucket --json"))
+	assert.Contains(t, lines[1], "--exclude /.trash/**")
+	assert.Contains(t, lines[1], "--filter-from "+filepath.Join(dir, "docs.filter"))
+}
+
+func TestRclonePreviewDeletesSkipsSizeWithoutDeletes(t *testing.T) {
+	args := filepath.Join(t.TempDir(), "args")
+	manager := fakeRclone(t, `echo "$@" >> `+args+"\n")
+
+	plan, err := manager.PreviewDeletes("/data", "b2:bucket", rclone.SyncOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, plan.Deletes)
+
+	calls, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(calls), "\n"))
+}
+
+func TestCLISyncDeleteLimitAsks(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	synced := filepath.Join(home, "synced")
+
+	bin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\n"+deletingRclone(synced)), 0755))
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = bin
+	appConfig.LogDir = filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(appConfig))
+	addPlainPair(t, "Documents", true)
+
+	// Declining leaves the destination alone
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.RunPlain(strings.NewReader("2\nDocuments\nn\nq\n"), &stdout, &stderr))
+	out := stdout.String()
+	assert.Contains(t, out, "sync would delete 3 of 4 files (75%) in b2:bucket/Documents, more than the 50% limit of 'Documents'")
+	assert.Contains(t, out, "Delete them anyway? [y/N]")
+	assert.Contains(t, out, "run with --allow-deletes")
+	assert.NoFileExists(t, synced)
+
+	// Confirming runs the sync
+	stdout.Reset()
+	require.Equal(t, 0, cli.RunPlain(strings.NewReader("2\nDocuments\ny\nq\n"), &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "Synced 1 of 1 pair(s).")
+	assert.FileExists(t, synced)
+
+	// --allow-deletes skips the check without asking
+	require.NoError(t, os.Remove(synced))
+	stdout.Reset()
+	assert.Equal(t, 0, cli.Run([]string{"sync", "--allow-deletes", "Documents"}, &stdout, &stderr))
+	assert.NotContains(t, stdout.String(), "Delete them anyway")
+	assert.FileExists(t, synced)
+}
+
+func TestSyncPairsWizardAsksDeleteLimit(t *testing.T) {
+	model := wizardAtRemotePath(t, fakeRclone(t, "exit 1\n"))
+	model = typeText(model, "bucket/docs")
+	model = typeText(model, "2")
+	model = typeText(model, "1")
+	require.Contains(t, model.View(), "Largest share of the destination a sync may delete")
+
+	model = typeText(model, "150")
+	assert.Contains(t, model.View(), "must be between 1 and 100")
+	for range "150" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	model = typeText(model, "20")
+	require.Contains(t, model.View(), "Skip hidden files?")
+	for range 4 {
+		model = typeText(model, "")
+	}
+	assert.Contains(t, model.View(), "Delete limit: 20% of the destination")
+}
+
+func TestSyncPairsListConfirmsDeleteLimit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	addPlainPair(t, "Documents", true)
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	var model tea.Model = views.NewSyncPairsModel(mgr, rclone.NewManager("rclone"))
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+
+	// The sync cannot ask without a terminal, so the list does
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	program, err := os.Executable()
+	require.NoError(t, err)
+	simulate.Stub(filepath.Base(program)+" sync", `Documents: sync would delete 3 of 4 files (75%) in b2:bucket/Documents, more than the 50% limit of 'Documents'
+Error: stopped to protect b2:bucket/Documents; run with --allow-deletes to sync anyway
+`, 1)
+	sync := func(model tea.Model, key string) tea.Model {
+		model, cmd := model.Update(keyPress(key))
+		require.NotNil(t, cmd)
+		for _, msg := range runBatch(cmd) {
+			model, _ = model.Update(msg)
+		}
+		return model
+	}
+
+	model = sync(model, "s")
+	view := model.View()
+	assert.Contains(t, view, "sync would delete 3 of 4 files (75%) in b2:bucket/Documents")
+	assert.Contains(t, view, "Delete them anyway and sync 'Documents' again? (y/n)")
+	assert.Contains(t, view, "y: Delete them and sync")
+
+	// Declining keeps the files and the error
+	model, _ = model.Update(keyPress("n"))
+	assert.NotContains(t, model.View(), "Delete them anyway")
+	assert.Contains(t, model.View(), "run with --allow-deletes")
+	require.Len(t, simulate.Actions(), 1)
+
+	model = sync(model, "s")
+	sync(model, "y")
+	actions := simulate.Actions()
+	require.Len(t, actions, 3)
+	assert.Equal(t, []string{"sync", "--progress", "--allow-deletes", "Documents"}, actions[2].Args)
+}
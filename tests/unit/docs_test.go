@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/queue"
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+func TestDocsTextReference(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, cli.ExitOK, cli.Run([]string{"docs"}, &stdout, &stderr), stderr.String())
+	doc := stdout.String()
+
+	// Every command of the usage is listed, with its flags
+	var help bytes.Buffer
+	cli.Run([]string{"help"}, &help, &help)
+	_, list, _ := strings.Cut(help.String(), "Commands:\n")
+	list, _, _ = strings.Cut(list, "\n\n")
+	for _, line := range strings.Split(list, "\n") {
+		name := strings.Fields(line)[0]
+		assert.Contains(t, doc, "\n  cloud-sync "+name+"\n")
+	}
+	assert.Regexp(t, `--dry-run\s+Show what would be transferred without changing anything`, doc)
+	assert.Regexp(t, `--format string\s+File format: yaml or json \(default yaml\)`, doc)
+	assert.Contains(t, doc, "  cloud-sync config format\n    Usage: cloud-sync config format [json|yaml|toml]\n")
+	assert.Contains(t, doc, "  cloud-sync docs\n")
+
+	// Keys come from the same definitions as the help screen
+	assert.Regexp(t, `v\s+Restore as of`, doc)
+	assert.Regexp(t, `9\s+Agent output`, doc)
+	assert.Contains(t, doc, "    3  another backup is running\n")
+
+	// Running with -h to find the flags changed nothing
+	assert.NoFileExists(t, filepath.Join(os.Getenv("HOME"), ".config", "cloud-sync", "config.json"))
+}
+
+func TestDocsManPage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "cloud-sync.1")
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, cli.ExitOK, cli.Run([]string{"docs", "--format", "man", "-o", file}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Wrote "+file+"\n", stdout.String())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	page := string(data)
+	assert.True(t, strings.HasPrefix(page, `.TH CLOUD\-SYNC 1`))
+	assert.Contains(t, page, ".B sync\n")
+	assert.Contains(t, page, `\fB\-\-allow\-deletes\fR`)
+	assert.Contains(t, page, `\fB\-\-path\fR \fIstring\fR`)
+	assert.Contains(t, page, ".SS Sync Pairs\n")
+	assert.Contains(t, page, ".SH EXIT STATUS\n.TP\n.B 0\nsuccess\n")
+
+	assert.Equal(t, cli.ExitConfig, cli.Run([]string{"docs", "--format", "pdf"}, &stdout, &stderr))
+}
+
+// TestKeyReferenceMatchesFooters checks views show the keys the reference
+// lists for them
+func TestKeyReferenceMatchesFooters(t *testing.T) {
+	sections := make(map[string][]views.ViewKey)
+	for _, section := range ui.KeyReference() {
+		sections[section.Title] = section.Keys
+	}
+
+	var model tea.Model = views.NewSyncQueueModel(queue.New(t.TempDir()), 200, 40)
+	model, _ = model.Update(model.Init()().(tea.BatchMsg)[0]())
+	view := testutil.PlainText(model.View())
+	require.NotEmpty(t, sections["Sync Queue"])
+	for _, k := range sections["Sync Queue"] {
+		assert.Contains(t, view, k.Key+": "+k.Help)
+	}
+
+	// Read-only mode leaves out the keys that change something
+	views.SetReadOnly(true)
+	t.Cleanup(func() { views.SetReadOnly(false) })
+	view = testutil.PlainText(model.View())
+	assert.Contains(t, view, "Read-only • ↑/↓: Navigate • r: Refresh • q/esc: Back")
+	assert.NotContains(t, view, "K/J")
+}
@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/doctor"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/scripts"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// doctorLevels maps each check's name to its outcome
+func doctorLevels(checks []doctor.Check) map[string]doctor.Level {
+	levels := make(map[string]doctor.Level)
+	for _, check := range checks {
+		levels[check.Name] = check.Level
+	}
+	return levels
+}
+
+func TestDoctorReportsProblems(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+
+	// rclone.conf lacks the remote config.json manages
+	appConfig.Remotes = []config.RemoteConfig{{Name: "b2", Type: "b2", AccountID: "id", ApplicationKey: "key"}}
+	require.NoError(t, os.MkdirAll(filepath.Dir(appConfig.RcloneConfig), 0755))
+	require.NoError(t, os.WriteFile(appConfig.RcloneConfig, []byte("[other]\ntype = s3\n"), 0600))
+	require.NoError(t, configManager.Save(appConfig))
+
+	// One script lost its executable bit
+	require.NoError(t, os.MkdirAll(appConfig.BinDir, 0755))
+	for _, name := range scripts.ScriptNames {
+		require.NoError(t, os.WriteFile(filepath.Join(appConfig.BinDir, name), []byte("#!/bin/sh\n"), 0755))
+	}
+	require.NoError(t, os.Chmod(filepath.Join(appConfig.BinDir, scripts.ScriptNames[0]), 0644))
+
+	// A lockfile left by a backup that died hours ago
+	require.NoError(t, os.MkdirAll(appConfig.LogDir, 0755))
+	lock := lockfile.NewManager(appConfig.LogDir)
+	require.NoError(t, lock.Create())
+	old := time.Now().Add(-7 * time.Hour)
+	require.NoError(t, os.Chtimes(lock.GetPath(), old, old))
+
+	bin := filepath.Join(t.TempDir(), "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\necho 'rclone v1.45.0'\n"), 0755))
+
+	checks := doctor.Run(doctor.Options{
+		Config:     configManager,
+		Pairs:      syncconfig.NewManager(configManager.SyncConfigPath()),
+		Launchd:    launchd.NewManager("tester"),
+		RclonePath: bin,
+	})
+	assert.Equal(t, map[string]doctor.Level{
+		"config.json":      doctor.Pass,
+		"sync-config.json": doctor.Pass,
+		"rclone":           doctor.Fail,
+		"rclone.conf":      doctor.Fail,
+		"LaunchAgent":      doctor.Pass,
+		"scripts":          doctor.Fail,
+		"lockfile":         doctor.Warn,
+		"logs":             doctor.Pass,
+	}, doctorLevels(checks))
+
+	for _, check := range checks {
+		if check.Level != doctor.Pass {
+			assert.NotEmpty(t, check.Fix, check.Name)
+		}
+	}
+}
+
+func TestDoctorSkipsChecksWithoutConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(configManager.GetConfigPath()), 0755))
+	require.NoError(t, os.WriteFile(configManager.GetConfigPath(), []byte("{"), 0600))
+
+	checks := doctor.Run(doctor.Options{
+		Config:  configManager,
+		Pairs:   syncconfig.NewManager(configManager.SyncConfigPath()),
+		Launchd: launchd.NewManager("tester"),
+	})
+	levels := doctorLevels(checks)
+	assert.Equal(t, doctor.Fail, levels["config.json"])
+	assert.Equal(t, doctor.Fail, levels["rclone"])
+	assert.Equal(t, doctor.Warn, levels["setup"])
+	assert.NotContains(t, levels, "LaunchAgent")
+}
+
+func TestCLIDoctor(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", t.TempDir())
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = filepath.Join(home, "missing", "rclone")
+	require.NoError(t, configManager.Save(appConfig))
+
+	var stdout, stderr bytes.Buffer
+	code := cli.Run([]string{"doctor"}, &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout.String(), "FAIL  rclone: not found in PATH")
+	assert.Contains(t, stdout.String(), "      Fix: Install it with 'brew install rclone'")
+	assert.Contains(t, stdout.String(), "PASS  LaunchAgent: scheduling is off")
+	assert.Contains(t, stdout.String(), "failed\n")
+}
@@ -0,0 +1,181 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/volume"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// fakeVolumes points volume.Root at an empty folder under home, so drives
+// created in it are folders on the same disk and never count as mounted
+func fakeVolumes(t *testing.T, home string) string {
+	t.Helper()
+	root := filepath.Join(home, "Volumes")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	previous := volume.Root
+	volume.Root = root
+	t.Cleanup(func() { volume.Root = previous })
+	return root
+}
+
+// addDrivePair adds an enabled local pair mirroring to a folder on the
+// Backup drive under root
+func addDrivePair(t *testing.T, root string, syncOnMount bool) {
+	t.Helper()
+	drive := filepath.Join(root, "Backup")
+	require.NoError(t, os.MkdirAll(drive, 0755))
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:        "Mirror",
+		LocalPath:   t.TempDir(),
+		Type:        syncconfig.TypeLocal,
+		TargetPath:  filepath.Join(drive, "Documents"),
+		Direction:   "upload",
+		Enabled:     true,
+		SyncOnMount: syncOnMount,
+	}))
+}
+
+func TestVolumeOf(t *testing.T) {
+	v, ok := volume.Of("/Volumes/Backup/Documents/2026")
+	assert.True(t, ok)
+	assert.Equal(t, "/Volumes/Backup", v)
+
+	v, ok = volume.Of("/Volumes/Backup")
+	assert.True(t, ok)
+	assert.Equal(t, "/Volumes/Backup", v)
+
+	for _, path := range []string{"/Volumes", "/Users/me/Documents", "/VolumesX/Backup"} {
+		_, ok := volume.Of(path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestVolumeMounted(t *testing.T) {
+	root := fakeVolumes(t, t.TempDir())
+	left := filepath.Join(root, "Backup")
+	require.NoError(t, os.Mkdir(left, 0755))
+
+	// A folder left behind is not a drive
+	assert.False(t, volume.Mounted(left))
+	assert.False(t, volume.Mounted(filepath.Join(root, "Missing")))
+
+	// /dev is a file system of its own on macOS and Linux
+	assert.True(t, volume.Mounted("/dev"))
+}
+
+func TestSyncPairVolumes(t *testing.T) {
+	pair := syncconfig.SyncPair{
+		LocalPath:  "/Volumes/Work/Projects",
+		Type:       syncconfig.TypeLocal,
+		TargetPath: "/Volumes/Backup/Projects",
+	}
+	assert.Equal(t, []string{"/Volumes/Work", "/Volumes/Backup"}, pair.Volumes())
+
+	pair = syncconfig.SyncPair{LocalPath: "/Volumes/Work/Projects", RemoteName: "b2", RemotePath: "bucket"}
+	assert.Equal(t, []string{"/Volumes/Work"}, pair.Volumes())
+
+	// Syncing on mount needs a drive
+	pair = syncconfig.SyncPair{Name: "Docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket", Direction: "upload", SyncOnMount: true}
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "needs a folder on a drive")
+}
+
+func TestBackupSkipsPairOnDisconnectedDrive(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	addDrivePair(t, fakeVolumes(t, home), false)
+
+	args, synced := filepath.Join(home, "args"), filepath.Join(home, "synced")
+	manager, err := backup.NewManager(&backup.Config{
+		Username:  "tester",
+		HomeDir:   home,
+		RsyncPath: fakeRsync(t, args, synced),
+		LogDir:    filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	err = manager.SyncPair("Mirror", false, false)
+	var volumeErr *backup.VolumeError
+	require.True(t, errors.As(err, &volumeErr), "got %v", err)
+	assert.Equal(t, filepath.Join(home, "Volumes", "Backup"), volumeErr.Volume)
+	assert.NoFileExists(t, args)
+
+	log, err := os.ReadFile(filepath.Join(home, "logs", "pairs", "Mirror.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "Sync Skipped: "+volumeErr.Volume+" is not mounted")
+
+	// Skipping is not a failure when syncing everything
+	results, err := manager.SyncAllEnabled(false, false)
+	assert.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Skipped())
+}
+
+func TestCLISyncSkipsDisconnectedDrive(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	addDrivePair(t, fakeVolumes(t, home), true)
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"sync", "Mirror"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Mirror: skipped: "+filepath.Join(home, "Volumes", "Backup")+" is not connected")
+	assert.Contains(t, stdout.String(), "Synced 0 of 1 pair(s), 1 skipped.")
+
+	// The mount agent's run leaves out pairs whose drive is missing
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"sync", "--mounted"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "No pairs to sync on mount have their drive connected.\n", stdout.String())
+
+	assert.Equal(t, 2, cli.Run([]string{"sync", "--all", "--mounted"}, &stdout, &stderr))
+}
+
+func TestMountAgentPlist(t *testing.T) {
+	data, err := launchd.RenderPlist(launchd.MountConfig("com.tester.cloudsync-mount", "/usr/local/bin/cloud-sync", "/Volumes"))
+	require.NoError(t, err)
+	plist := string(data)
+	assert.Contains(t, plist, "<string>/usr/local/bin/cloud-sync</string>\n\t\t<string>sync</string>\n\t\t<string>--scheduled</string>\n\t\t<string>--mounted</string>")
+	assert.Contains(t, plist, "<key>WatchPaths</key>\n\t<array>\n\t\t<string>/Volumes</string>")
+	assert.NotContains(t, plist, "/bin/zsh")
+	assert.NotContains(t, plist, "StartCalendarInterval")
+	assert.Equal(t, "com.tester.cloudsync-mount", launchd.NewManager("tester").Mount().GetLabel())
+}
+
+func TestCLIWatchDrives(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := fakeVolumes(t, home)
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+
+	// Nothing to watch yet
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, cli.Run([]string{"watch-drives"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "No enabled pair is set to sync on mount")
+
+	addDrivePair(t, root, true)
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"watch-drives"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Mirror ("+filepath.Join(root, "Backup")+")")
+	plists, err := filepath.Glob(filepath.Join(home, "Library", "LaunchAgents", "*.cloudsync-mount.plist"))
+	require.NoError(t, err)
+	require.Len(t, plists, 1)
+	data, err := os.ReadFile(plists[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<string>"+root+"</string>")
+
+	require.Equal(t, 0, cli.Run([]string{"watch-drives", "--off"}, &stdout, &stderr), stderr.String())
+	assert.NoFileExists(t, plists[0])
+}
@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+)
+
+func TestErrkindWrap(t *testing.T) {
+	assert.NoError(t, errkind.Wrap(errkind.ErrNetwork, nil))
+
+	base := errors.New("dial tcp: lookup api.backblazeb2.com: no such host")
+	err := fmt.Errorf("sync failed: %w", errkind.Wrap(errkind.ErrNetwork, base))
+	assert.Equal(t, "sync failed: dial tcp: lookup api.backblazeb2.com: no such host", err.Error())
+	assert.ErrorIs(t, err, errkind.ErrNetwork)
+	assert.ErrorIs(t, err, base)
+	assert.Equal(t, errkind.ErrNetwork, errkind.Of(err))
+	assert.Contains(t, errkind.Hint(err), "internet connection")
+
+	// The first kind given sticks
+	assert.Equal(t, errkind.ErrNetwork, errkind.Of(errkind.Wrap(errkind.ErrRemoteAuth, err)))
+
+	assert.Nil(t, errkind.Of(base))
+	assert.Empty(t, errkind.Hint(base))
+	assert.Empty(t, errkind.Hint(nil))
+}
+
+func TestRcloneClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		kind error
+	}{
+		{"auth", "echo 'ERROR : : error listing: 401 Unauthorized: bad_auth_token' >&2\nexit 1\n", errkind.ErrRemoteAuth},
+		{"quota", "echo 'ERROR : a.txt: Failed to copy: 403 cap_exceeded' >&2\nexit 1\n", errkind.ErrQuotaExceeded},
+		{"network", "echo 'ERROR : : error listing: dial tcp: lookup api.example.com: no such host' >&2\nexit 1\n", errkind.ErrNetwork},
+		{"ssh host key", "echo 'CRITICAL: Failed to create file system: ssh: handshake failed: knownhosts: key is unknown' >&2\nexit 1\n", errkind.ErrHostKey},
+		{"ssh login", "echo 'CRITICAL: Failed to create file system: ssh: handshake failed: ssh: unable to authenticate' >&2\nexit 1\n", errkind.ErrRemoteAuth},
+		{"retryable exit", "exit 5\n", errkind.ErrNetwork},
+		{"unknown", "echo 'ERROR : : directory not found' >&2\nexit 3\n", nil},
+		{"quota status code", "echo 'ERROR : a.txt: Failed to copy: HTTP error 507 returned body' >&2\nexit 1\n", errkind.ErrQuotaExceeded},
+		{"forbidden status code", "echo 'ERROR : : error listing: Error 403: forbidden' >&2\nexit 1\n", errkind.ErrRemoteAuth},
+		{"quota over status code", "echo \"ERROR : a.txt: Failed to copy: googleapi: Error 403: The user's Drive storage quota has been exceeded\" >&2\nexit 1\n", errkind.ErrQuotaExceeded},
+		// File names and sizes that look like failures are not ones
+		{"file name with keyword", "echo '2025/01/02 10:00:00 ERROR : reports/quota-2025.xlsx: Failed to copy: connection reset by peer' >&2\nexit 1\n", errkind.ErrNetwork},
+		{"file name with keyword and no kind", "echo 'ERROR : unauthorized/notes.txt: Failed to copy: file changed while copying' >&2\nexit 1\n", nil},
+		{"size like a status code", "echo 'ERROR : a.txt: Failed to copy: source is 507 B but destination is 0 B' >&2\nexit 1\n", nil},
+		{"error in a file name", "echo 'INFO  : error-quota.txt: Copied (new)' >&2\nexit 3\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := fakeRclone(t, tt.body)
+
+			_, err := manager.ListBuckets("b2")
+			require.Error(t, err)
+			assert.Equal(t, tt.kind, errkind.Of(err))
+
+			err = manager.TestRemote("b2")
+			require.Error(t, err)
+			assert.Equal(t, tt.kind, errkind.Of(err))
+		})
+	}
+
+	// A file name that happens to look like a failure is not one
+	manager := fakeRclone(t, "echo 'INFO  : quota-report.txt: Copied (new)' >&2\nexit 3\n")
+	assert.Nil(t, errkind.Of(manager.TestRemote("b2")))
+
+	missing := rclone.NewManagerWithConfig(filepath.Join(t.TempDir(), "rclone"), "")
+	_, err := missing.Version()
+	assert.ErrorIs(t, err, errkind.ErrRcloneMissing)
+	assert.Contains(t, errkind.Hint(err), "Install rclone")
+}
+
+func TestRcloneClassifyTransferLog(t *testing.T) {
+	// With --log-file rclone writes its errors there, not to stderr
+	manager := fakeRclone(t, `while [ $# -gt 0 ]; do
+	if [ "$1" = "--log-file" ]; then echo "ERROR : a.txt: Failed to copy: insufficient storage" >> "$2"; fi
+	shift
+done
+exit 1
+`)
+	logFile := filepath.Join(t.TempDir(), "pair.log")
+	require.NoError(t, os.WriteFile(logFile, []byte("ERROR : old.txt: 401 Unauthorized\n"), 0644))
+
+	err := manager.SyncWithOptions(t.TempDir(), "b2:bucket", rclone.SyncOptions{LogFile: logFile})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errkind.ErrQuotaExceeded, "only the lines of this run count")
+}
+
+func TestCLISyncPrintsHint(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	bin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte(`#!/bin/sh
+case "$1" in
+version) echo 'rclone v1.66.0' ;;
+*) echo 'ERROR : : error listing: 401 Unauthorized: bad_auth_token' >&2; exit 1 ;;
+esac
+`), 0755))
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = bin
+	appConfig.LogDir = filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(appConfig))
+	addPlainPair(t, "Documents", true)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, cli.Run([]string{"sync", "Documents"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "Documents: failed:")
+	assert.Contains(t, stdout.String(), "  Hint: Check the remote's keys")
+}
+
+func TestLockfileCreateLocked(t *testing.T) {
+	manager := lockfile.NewManagerWithPath(filepath.Join(t.TempDir(), "backup.lock"))
+	require.NoError(t, manager.Create())
+
+	err := manager.Create()
+	assert.ErrorIs(t, err, errkind.ErrLocked)
+	assert.Contains(t, err.Error(), "lockfile already exists")
+	assert.Contains(t, errkind.Hint(err), "running backup")
+}
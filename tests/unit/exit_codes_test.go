@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/errkind"
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+)
+
+// unreachableRclone syncs the pair named Photos and cannot reach the
+// remote of any other
+const unreachableRclone = `#!/bin/sh
+case "$1" in
+version) echo 'rclone v1.66.0' ;;
+size) echo '{"count":0,"bytes":0}' ;;
+sync)
+	case "$*" in
+	*bucket/Photos*) exit 0 ;;
+	esac
+	echo "ERROR : Attempt 1/3 failed: dial tcp: lookup api.backblazeb2.com: no such host" >&2
+	exit 1 ;;
+esac
+`
+
+func TestTakeQuietFlag(t *testing.T) {
+	args, found := cli.TakeQuietFlag([]string{"sync", "--quiet", "--all"})
+	assert.True(t, found)
+	assert.Equal(t, []string{"sync", "--all"}, args)
+
+	_, found = cli.TakeQuietFlag([]string{"sync", "--all"})
+	assert.False(t, found)
+
+	assert.True(t, cli.IsCommand([]string{"--quiet", "status"}))
+	assert.False(t, cli.IsCommand([]string{"--quiet"}))
+}
+
+func TestCLIExitCodes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	bin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte(unreachableRclone), 0755))
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = bin
+	appConfig.LogDir = filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(appConfig))
+	addPlainPair(t, "Photos", true)
+	addPlainPair(t, "Documents", true)
+
+	var stdout, stderr bytes.Buffer
+	run := func(args ...string) int {
+		stdout.Reset()
+		stderr.Reset()
+		return cli.Run(args, &stdout, &stderr)
+	}
+
+	assert.Equal(t, cli.ExitOK, run("sync", "Photos"))
+	assert.Equal(t, cli.ExitConfig, run("sync", "--bogus"))
+	assert.Equal(t, cli.ExitConfig, run("sync", "Nope"))
+	assert.Contains(t, stdout.String(), "sync pair 'Nope' not found")
+
+	// Failing the same way is reported as that failure, a partial failure
+	// as 1
+	assert.Equal(t, cli.ExitNetwork, run("sync", "Documents"))
+	assert.Contains(t, stdout.String(), "Hint: Check the internet connection")
+	assert.Equal(t, cli.ExitFailure, run("sync", "--all"))
+	assert.Contains(t, stdout.String(), "Synced 1 of 2 pair(s).")
+
+	// --quiet prints nothing, wherever it is
+	assert.Equal(t, cli.ExitNetwork, run("--quiet", "sync", "Documents"))
+	assert.Equal(t, cli.ExitConfig, run("sync", "Nope", "--quiet"))
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+
+	lock := lockfile.NewManager(appConfig.LogDir)
+	require.NoError(t, lock.Create())
+	assert.Equal(t, cli.ExitLocked, run("scrub", "Photos"))
+	assert.Contains(t, stderr.String(), "backup already running")
+	require.NoError(t, lock.Remove())
+
+	require.NoError(t, os.WriteFile(configManager.SyncConfigPath(), []byte("{not json"), 0600))
+	assert.Equal(t, cli.ExitConfig, run("pairs"))
+	assert.Contains(t, stderr.String(), "is corrupt")
+}
+
+func TestTransferOutputFollowsSetOutput(t *testing.T) {
+	manager := fakeRclone(t, `echo "Transferred: 1 / 1"
+echo "ERROR : Attempt 1/3 failed: dial tcp: lookup api.backblazeb2.com: no such host" >&2
+exit 1
+`)
+	var stdout, stderr bytes.Buffer
+	manager.SetOutput(&stdout, &stderr)
+
+	err := manager.SyncWithOptions("/src", "b2:bucket", rclone.SyncOptions{})
+	assert.Contains(t, stdout.String(), "Transferred: 1 / 1")
+	assert.Contains(t, stderr.String(), "no such host")
+	assert.ErrorIs(t, err, errkind.ErrNetwork, "the error is classified from stderr as before")
+
+	// Discarded output, as with --quiet, is still classified
+	manager.SetOutput(io.Discard, io.Discard)
+	assert.ErrorIs(t, manager.SyncWithOptions("/src", "b2:bucket", rclone.SyncOptions{}), errkind.ErrNetwork)
+}
@@ -0,0 +1,154 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// versionsListing is what 'lsjson --b2-versions' prints for a folder holding
+// report.pdf, two old versions of it and another file
+const versionsListing = `[
+{"Name":"report.pdf","Size":300,"ModTime":"2026-10-12T08:00:00Z"},
+{"Name":"report-v2026-10-01-143000-000.pdf","Size":100,"ModTime":"2026-09-30T10:00:00Z"},
+{"Name":"report-v2026-10-05-090000-500.pdf","Size":200,"ModTime":"2026-10-04T10:00:00Z"},
+{"Name":"notes.txt","Size":5,"ModTime":"2026-10-01T10:00:00Z"}
+]`
+
+func TestListFileVersions(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	mgr := versionedRclone(t, `echo "$@" >> `+calls+`
+echo '`+versionsListing+`'
+`)
+
+	versions, err := mgr.ListFileVersions("b2", "bucket/docs/report.pdf")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, rclone.FileVersion{Path: "bucket/docs/report.pdf", Size: 300, Time: time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC), Current: true}, versions[0])
+	assert.Equal(t, "bucket/docs/report-v2026-10-05-090000-500.pdf", versions[1].Path)
+	assert.Equal(t, time.Date(2026, 10, 5, 9, 0, 0, 500e6, time.UTC), versions[1].Time)
+	assert.Equal(t, "bucket/docs/report-v2026-10-01-143000-000.pdf", versions[2].Path)
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "lsjson b2:bucket/docs/ --files-only --no-mimetype --b2-versions --config "+mgr.GetConfigPath(), strings.TrimSpace(string(data)))
+
+	_, err = mgr.ListFileVersions("b2", "bucket/docs/missing.pdf")
+	assert.EqualError(t, err, "b2:bucket/docs/missing.pdf has no versions")
+	_, err = mgr.ListFileVersions("nas", "backups/report.pdf")
+	assert.EqualError(t, err, "file versions need a B2 or S3 remote, and 'nas' is sftp")
+}
+
+func TestPromoteVersion(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	// Downloads write the file, so the upload has something to send
+	mgr := versionedRclone(t, `echo "$@" >> `+calls+`
+case "$2" in b2:*) echo old > "$3" ;; esac
+exit 0
+`)
+	old := rclone.FileVersion{Path: "bucket/docs/report-v2026-10-01-143000-000.pdf", Size: 100}
+
+	require.NoError(t, mgr.PromoteVersion("b2", old, "bucket/docs/report.pdf"))
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	download, upload := strings.Fields(lines[0]), strings.Fields(lines[1])
+	assert.Equal(t, []string{"copyto", "b2:bucket/docs/report-v2026-10-01-143000-000.pdf"}, download[:2])
+	assert.Contains(t, download, "--b2-versions")
+	assert.Equal(t, download[2], upload[1], "the downloaded copy is uploaded")
+	assert.Equal(t, "b2:bucket/docs/report.pdf", upload[2])
+	assert.NotContains(t, upload, "--b2-versions")
+	assert.NoFileExists(t, download[2], "the temporary copy is removed")
+}
+
+func TestVersionsView(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	calls := filepath.Join(t.TempDir(), "calls")
+	mgr := versionedRclone(t, `echo "$@" >> `+calls+`
+case "$*" in
+*-R*) echo '[{"Path":"reports/report.pdf","Size":300},{"Path":"notes.txt","Size":5}]' ;;
+lsjson*) echo '`+versionsListing+`' ;;
+esac
+exit 0
+`)
+	local := t.TempDir()
+	pair := syncconfig.SyncPair{Name: "docs", LocalPath: local, RemoteName: "b2", RemotePath: "bucket/docs"}
+
+	var model tea.Model = views.NewVersionsModel(mgr, pair)
+	model, _ = model.Update(model.Init()())
+	view := model.View()
+	assert.Contains(t, view, "notes.txt")
+	assert.Contains(t, view, "reports/report.pdf")
+
+	// Files are sorted, so the report is second
+	model, _ = model.Update(keyPress("down"))
+	model, cmd := model.Update(keyPress("enter"))
+	model, _ = model.Update(cmd())
+	view = model.View()
+	assert.Contains(t, view, "Versions of reports/report.pdf")
+	assert.Contains(t, view, "current")
+	assert.Contains(t, view, time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC).Local().Format("2006-01-02 15:04:05"))
+
+	// The current version is neither downloaded nor promoted
+	model, _ = model.Update(keyPress("d"))
+	assert.Contains(t, model.View(), "pick an old version to download")
+	model, _ = model.Update(keyPress("p"))
+	assert.Contains(t, model.View(), "this is already the current version")
+
+	// Old versions download next to the file, under their own name
+	model, _ = model.Update(keyPress("down"))
+	model, cmd = model.Update(keyPress("d"))
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+	downloaded := filepath.Join(local, "reports", "report-v2026-10-05-090000-500.pdf")
+	assert.Contains(t, model.View(), "Downloaded to "+downloaded)
+
+	// Promoting asks first
+	model, _ = model.Update(keyPress("p"))
+	assert.Contains(t, model.View(), "The current one is kept as an old version. (y/n)")
+	model, _ = model.Update(keyPress("n"))
+	assert.Contains(t, model.View(), "Promote cancelled")
+	model, _ = model.Update(keyPress("p"))
+	model, cmd = model.Update(keyPress("y"))
+	require.NotNil(t, cmd)
+	model, cmd = model.Update(cmd())
+	require.NotNil(t, cmd, "the versions are listed again")
+	model, _ = model.Update(cmd())
+	assert.Contains(t, model.View(), "Promoted the version of")
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "copyto b2:bucket/docs/reports/report-v2026-10-05-090000-500.pdf "+downloaded+" --b2-versions")
+	assert.Regexp(t, `copyto \S+/report.pdf b2:bucket/docs/reports/report.pdf --config`, string(data))
+
+	// esc goes back to the files
+	model, _ = model.Update(keyPress("esc"))
+	assert.Contains(t, model.View(), "Files of 'docs' on b2:bucket/docs")
+
+	// The promote dropped the cached listing; once listed again, it is used
+	// until r asks for a fresh one
+	recursive := func() int {
+		data, err := os.ReadFile(calls)
+		require.NoError(t, err)
+		return strings.Count(string(data), " -R ")
+	}
+	model, _ = model.Update(views.NewVersionsModel(mgr, pair).Init()())
+	assert.Equal(t, 2, recursive())
+	model, _ = model.Update(views.NewVersionsModel(mgr, pair).Init()())
+	assert.Equal(t, 2, recursive())
+	_, cmd = model.Update(keyPress("r"))
+	cmd()
+	assert.Equal(t, 3, recursive())
+}
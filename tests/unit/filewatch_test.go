@@ -0,0 +1,47 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/filewatch"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestFileWatcherReportsChanges(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	pairs := filepath.Join(dir, "sync-config.json")
+	require.NoError(t, os.WriteFile(config, []byte("{}"), 0600))
+
+	w := filewatch.NewWatcher(config, pairs)
+	assert.Empty(t, w.Changed())
+
+	// A file created, then one rewritten by another program
+	require.NoError(t, os.WriteFile(pairs, []byte("{}"), 0600))
+	assert.Equal(t, []string{pairs}, w.Changed())
+	assert.Empty(t, w.Changed(), "a change is reported once")
+
+	require.NoError(t, os.WriteFile(config, []byte(`{"log_dir":"/tmp"}`), 0600))
+	assert.Equal(t, []string{config}, w.Changed())
+
+	require.NoError(t, os.Remove(pairs))
+	assert.Equal(t, []string{pairs}, w.Changed())
+}
+
+func TestConfigChangeReloadsOpenViews(t *testing.T) {
+	model := openSyncPairs(t)
+	require.Contains(t, model.View(), "No sync pairs configured")
+
+	// Another instance adds a pair
+	addPlainPair(t, "Documents", true)
+	model, cmd := model.Update(views.ConfigChangedMsg{})
+	for _, msg := range runBatch(cmd) {
+		model, _ = model.Update(msg)
+	}
+	assert.Contains(t, model.View(), "Documents")
+}
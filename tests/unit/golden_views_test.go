@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+// Snapshots are taken at the size of a default macOS Terminal window
+const (
+	goldenWidth  = 80
+	goldenHeight = 24
+)
+
+func TestGoldenMainMenu(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h := testutil.NewHarness(t, ui.NewModel(), goldenWidth, goldenHeight)
+	testutil.AssertGolden(t, "main_menu", h.View())
+}
+
+func TestGoldenHelp(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h := testutil.NewHarness(t, ui.NewModel(), goldenWidth, goldenHeight)
+	h.Press("down", "down", "down", "down", "down", "enter")
+	testutil.AssertGolden(t, "help", h.View())
+
+	// Leaving help repaints the whole menu, still on the help item
+	h.Press("q")
+	assert.NotContains(t, h.View(), "Keyboard Shortcuts")
+	assert.Contains(t, h.View(), "│ 6. Help")
+}
+
+func TestGoldenInstallation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h := testutil.NewHarness(t, views.NewConfigurationSetupModel(), goldenWidth, goldenHeight)
+	testutil.AssertGolden(t, "installation", h.View())
+}
+
+func TestGoldenLogViewerSessions(t *testing.T) {
+	dir := t.TempDir()
+	createTestLogFile(t, logs.PairLogPath(dir, "docs"), sessionLog)
+	manager := logs.NewManager(dir)
+	manager.AddPairs("docs")
+
+	h := testutil.NewHarness(t, views.NewLogViewerModel(manager, views.LogViewSessions, goldenWidth, goldenHeight), goldenWidth, goldenHeight)
+	h.Init()
+	testutil.AssertGolden(t, "log_viewer_sessions", h.View())
+}
+
+func TestGoldenLaunchdManager(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	manager := launchd.NewManager("tester")
+
+	// The status is sent directly; launchctl is not consulted
+	h := testutil.NewHarness(t, views.NewLaunchdManagerModel(manager, goldenWidth, goldenHeight), goldenWidth, goldenHeight)
+	h.Send(&launchd.Status{Loaded: true})
+	testutil.AssertGolden(t, "launchd_manager", strings.ReplaceAll(h.View(), home, "~"))
+}
@@ -0,0 +1,197 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/ignore"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/rsync"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// ruleLines formats rules as the lines of a filter file
+func ruleLines(rules []ignore.Rule) []string {
+	lines := make([]string, len(rules))
+	for i, rule := range rules {
+		lines[i] = rule.String()
+	}
+	return lines
+}
+
+func TestIgnoreParse(t *testing.T) {
+	tests := []struct {
+		dir     string
+		content string
+		want    []string
+	}{
+		{".", "*.log", []string{"- *.log", "- *.log/**"}},
+		{".", "# comment\n\n  \nnode_modules/\n", []string{"- node_modules/**"}},
+		{".", "/TODO.md\ndocs/draft.md\n", []string{"- /TODO.md", "- /TODO.md/**", "- /docs/draft.md", "- /docs/draft.md/**"}},
+		{".", "!important.log", []string{"+ important.log", "+ important.log/**"}},
+		{".", `\#notes` + "\n" + `\!bang`, []string{"- #notes", "- #notes/**", "- !bang", "- !bang/**"}},
+		{".", "**/cache/\n", []string{"- cache/**"}},
+		{".", "trailing.txt   \r\n", []string{"- trailing.txt", "- trailing.txt/**"}},
+		{"proj", "*.o", []string{"- /proj/*.o", "- /proj/**/*.o", "- /proj/*.o/**", "- /proj/**/*.o/**"}},
+		{"proj", "/build/\n", []string{"- /proj/build/**"}},
+		{"a/b", "out/bin", []string{"- /a/b/out/bin", "- /a/b/out/bin/**"}},
+		{"proj", "!**/keep.o", []string{"+ /proj/keep.o", "+ /proj/**/keep.o", "+ /proj/keep.o/**", "+ /proj/**/keep.o/**"}},
+		// A pattern without a slash also matches a folder, and so its files
+		{".", "node_modules", []string{"- node_modules", "- node_modules/**"}},
+		{".", "build/**", []string{"- /build/**"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ruleLines(ignore.Parse(tt.dir, tt.content)), "%s: %q", tt.dir, tt.content)
+	}
+}
+
+// filterExcludes reports whether rules exclude the file at path, relative
+// to the synced folder, matching their patterns the way rclone's filters do
+func filterExcludes(t *testing.T, rules []ignore.Rule, path string) bool {
+	t.Helper()
+	for _, rule := range rules {
+		pattern := regexp.QuoteMeta(rule.Pattern)
+		pattern = strings.ReplaceAll(pattern, `\*\*`, ".*")
+		pattern = strings.ReplaceAll(pattern, `\*`, "[^/]*")
+		if strings.HasPrefix(pattern, "/") {
+			pattern = "^" + pattern[1:] + "$"
+		} else {
+			pattern = "(^|/)" + pattern + "$"
+		}
+		if regexp.MustCompile(pattern).MatchString(path) {
+			return !rule.Include
+		}
+	}
+	return false
+}
+
+func TestIgnoreExcludesFolderContents(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		ignore.FileName:           "node_modules\n",
+		"proj/" + ignore.FileName: "dist\n!dist/keep.txt\n",
+	})
+	rules, err := ignore.Load(root)
+	require.NoError(t, err)
+
+	// A pattern without a trailing slash matches a folder, and so every
+	// file in it, as well as a file of that name
+	for _, excluded := range []string{
+		"node_modules",
+		"node_modules/left-pad/index.js",
+		"web/node_modules/react/cjs/react.js",
+		"proj/dist/app.js",
+		"proj/src/dist/app.js",
+	} {
+		assert.True(t, filterExcludes(t, rules, excluded), excluded)
+	}
+	for _, kept := range []string{"src/node_modules.md", "proj/dist/keep.txt", "dist/app.js"} {
+		assert.False(t, filterExcludes(t, rules, kept), kept)
+	}
+}
+
+func TestIgnoreLoad(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		ignore.FileName:                  "*.log\nbuild/\n!keep.log\n",
+		"proj/" + ignore.FileName:        "/secret.txt\n!debug.log\n",
+		"-early/" + ignore.FileName:      "*.tmp\n",
+		"proj/src/main.go":               "package main",
+		"unrelated/.cloudsyncignore.bak": "*",
+	})
+
+	rules, err := ignore.Load(root)
+	require.NoError(t, err)
+
+	// The deepest rules come first and the root's last line before its
+	// first, so later and deeper patterns win as in gitignore
+	lines := ruleLines(rules)
+	assert.Equal(t, []string{"+ keep.log/**", "+ keep.log", "- build/**", "- *.log/**", "- *.log"}, lines[len(lines)-5:])
+	assert.ElementsMatch(t, []string{
+		"+ /proj/**/debug.log/**", "+ /proj/debug.log/**", "+ /proj/**/debug.log", "+ /proj/debug.log",
+		"- /proj/secret.txt/**", "- /proj/secret.txt",
+		"- /-early/**/*.tmp/**", "- /-early/*.tmp/**", "- /-early/**/*.tmp", "- /-early/*.tmp",
+	}, lines[:10])
+	assert.Less(t, indexOf(lines, "+ /proj/debug.log"), indexOf(lines, "- /proj/secret.txt"))
+
+	empty, err := ignore.Load(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+// indexOf returns the position of line in lines, or -1
+func indexOf(lines []string, line string) int {
+	for i, l := range lines {
+		if l == line {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestFilterFromArgs(t *testing.T) {
+	args := rclone.NewManagerWithConfig("rclone", "/tmp/rclone.conf").BuildSyncArgs("/src", "b2:bucket",
+		rclone.SyncOptions{Excludes: []string{"/.trash/**"}, FilterFrom: "/tmp/docs.filter"})
+	joined := strings.Join(args, " ")
+	assert.Contains(t, joined, "--exclude /.trash/** --filter-from /tmp/docs.filter")
+
+	args = rsync.NewManager("rsync").BuildArgs("/src", "/dst", rsync.Options{FilterFrom: "/tmp/docs.filter"})
+	assert.Contains(t, strings.Join(args, " "), "--filter merge /tmp/docs.filter")
+}
+
+func TestBackupHonorsIgnoreFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	docs := filepath.Join(home, "Documents")
+	writeFiles(t, docs, map[string]string{
+		ignore.FileName:           "*.log\n",
+		"report.txt":              "report",
+		"debug.log":               "noise",
+		"proj/" + ignore.FileName: "node_modules/\n",
+	})
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Documents", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload",
+		Enabled: true, MaxDeletePercent: 100,
+	}))
+
+	called := filepath.Join(home, "args")
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte("#!/bin/sh\necho \"$@\" >> "+called+"\n"), 0755))
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: rcloneBin,
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, manager.SyncPair("Documents", false, false))
+	filter := filepath.Join(home, ".cache", "cloud-sync", "filters", "Documents.filter")
+	args, err := os.ReadFile(called)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "--filter-from "+filter)
+
+	rules, err := os.ReadFile(filter)
+	require.NoError(t, err)
+	assert.Equal(t, "- /proj/**/node_modules/**\n- /proj/node_modules/**\n- *.log/**\n- *.log\n", string(rules))
+
+	// Without ignore files the filter goes away
+	require.NoError(t, os.Remove(filepath.Join(docs, ignore.FileName)))
+	require.NoError(t, os.Remove(filepath.Join(docs, "proj", ignore.FileName)))
+	require.NoError(t, os.Remove(called))
+	require.NoError(t, manager.SyncPair("Documents", false, false))
+	assert.NoFileExists(t, filter)
+	args, err = os.ReadFile(called)
+	require.NoError(t, err)
+	assert.NotContains(t, string(args), "--filter-from")
+}
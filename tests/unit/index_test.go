@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// listingRclone prints a listing of count files of 10 bytes for lsjson
+func listingRclone(count int) string {
+	return `case "$1" in
+lsjson)
+	echo "["
+	i=1
+	while [ $i -le ` + strconv.Itoa(count) + ` ]; do
+		[ $i -gt 1 ] && echo ","
+		echo "{\"Path\":\"dir/file$i.txt\",\"Size\":10,\"ModTime\":\"2024-01-02T03:04:05Z\",\"Hashes\":{\"sha1\":\"abc\"}}"
+		i=$((i+1))
+	done
+	echo "]" ;;
+esac
+`
+}
+
+func TestBuildIndex(t *testing.T) {
+	manager := fakeRclone(t, listingRclone(2500))
+	cache := rclone.NewIndexCache(t.TempDir())
+
+	var reports []int
+	index, err := manager.BuildIndex("b2:bucket/photos", cache, func(files int, bytes int64) {
+		reports = append(reports, files)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2500, index.Files)
+	assert.Equal(t, int64(25000), index.Bytes)
+	assert.Equal(t, "b2:bucket/photos", index.Source)
+	assert.Equal(t, []int{1000, 2000, 2500}, reports)
+
+	cached, err := cache.Load("b2:bucket/photos")
+	require.NoError(t, err)
+	require.NotNil(t, cached)
+	assert.Equal(t, 2500, cached.Files)
+	assert.Equal(t, int64(25000), cached.Bytes)
+
+	listing, err := os.ReadFile(cache.ListingPath("b2:bucket/photos"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(listing)), "\n")
+	assert.Len(t, lines, 2500)
+	assert.Contains(t, lines[0], `"Path":"dir/file1.txt"`)
+	assert.Contains(t, lines[0], `"sha1":"abc"`)
+}
+
+func TestBuildIndexFailure(t *testing.T) {
+	manager := fakeRclone(t, `echo "directory not found" >&2; exit 3`)
+	cache := rclone.NewIndexCache(t.TempDir())
+
+	_, err := manager.BuildIndex("b2:bucket/missing", cache, nil)
+	require.Error(t, err)
+
+	cached, err := cache.Load("b2:bucket/missing")
+	require.NoError(t, err)
+	assert.Nil(t, cached, "a failed listing must not be cached")
+}
+
+func TestIndexCacheLoadMissing(t *testing.T) {
+	index, err := rclone.NewIndexCache(t.TempDir()).Load("b2:bucket")
+	require.NoError(t, err)
+	assert.Nil(t, index)
+}
+
+func TestIndexFirstRejectedForLocalPairs(t *testing.T) {
+	local := syncconfig.SyncPair{Name: "Mirror", LocalPath: t.TempDir(), Type: syncconfig.TypeLocal,
+		TargetPath: filepath.Join(t.TempDir(), "Documents"), Direction: "upload", IndexFirst: true}
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&local), "indexing first")
+}
+
+func TestBackupIndexesPairFirst(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:       "Photos",
+		LocalPath:  t.TempDir(),
+		RemoteName: "b2",
+		RemotePath: "bucket/photos",
+		Direction:  "download",
+		Enabled:    true,
+		IndexFirst: true,
+	}))
+
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte("#!/bin/sh\n"+listingRclone(1500)), 0755))
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: rcloneBin,
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	var reports []int
+	manager.SetIndexProgress(func(pair string, files int, bytes int64) {
+		assert.Equal(t, "Photos", pair)
+		reports = append(reports, files)
+	})
+	require.NoError(t, manager.SyncPair("Photos", false, false))
+	assert.Equal(t, []int{1000, 1500}, reports)
+
+	index, err := manager.IndexCache().Load("b2:bucket/photos")
+	require.NoError(t, err)
+	require.NotNil(t, index)
+	assert.Equal(t, 1500, index.Files)
+
+	log, err := os.ReadFile(filepath.Join(home, "logs", "pairs", "Photos.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "Indexed 1500 files, 15000 bytes")
+}
+
+func TestBackupReportsIndexedTotals(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:       "Photos",
+		LocalPath:  t.TempDir(),
+		RemoteName: "b2",
+		RemotePath: "bucket/photos",
+		Direction:  "download",
+		Enabled:    true,
+		IndexFirst: true,
+	}))
+
+	// The transfer says where it serves rclone's API, then waits for the
+	// test to have read its progress
+	addrFile := filepath.Join(home, "rc-addr")
+	released := filepath.Join(home, "released")
+	rcloneBin := filepath.Join(home, "rclone")
+	script := "#!/bin/sh\n" + listingRclone(1500) + `prev=""
+for arg in "$@"; do
+	if [ "$prev" = "--rc-addr" ]; then
+		echo "$arg" > "` + addrFile + `"
+		i=0
+		while [ ! -e "` + released + `" ] && [ $i -lt 100 ]; do sleep 0.1; i=$((i+1)); done
+	fi
+	prev="$arg"
+done
+`
+	require.NoError(t, os.WriteFile(rcloneBin, []byte(script), 0755))
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: rcloneBin,
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	// Serve core/stats for a run that checked 300 files and copied 20,
+	// having found 400 so far
+	go func() {
+		for i := 0; i < 100; i++ {
+			if data, err := os.ReadFile(addrFile); err == nil {
+				listener, err := net.Listen("tcp", strings.TrimSpace(string(data)))
+				if err != nil {
+					return
+				}
+				server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"bytes":200,"totalBytes":4000,"checks":300,"transfers":20,"totalTransfers":400,"elapsedTime":3}`))
+				})}
+				t.Cleanup(func() { server.Close() })
+				server.Serve(listener)
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+
+	var mu sync.Mutex
+	var reported []backup.TransferProgress
+	manager.SetTransferProgress(func(pair string, progress backup.TransferProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, progress)
+		os.WriteFile(released, nil, 0644)
+	})
+	require.NoError(t, manager.SyncPair("Photos", false, false))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, reported, "the transfer's progress was never read")
+	assert.Equal(t, backup.TransferProgress{Files: 320, TotalFiles: 1500, Bytes: 200, TotalBytes: 4000}, reported[0])
+}
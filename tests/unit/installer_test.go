@@ -215,4 +215,34 @@ func TestGetArchitecture(t *testing.T) {
 	arch := installer.GetArchitecture()
 	assert.NotEmpty(t, arch)
 	assert.Contains(t, []string{"arm64", "amd64", "386"}, arch)
-}
\ No newline at end of file
+}
+func TestInstallRcloneStreaming(t *testing.T) {
+	mockExec := new(MockExecutor)
+	mockExec.On("LookPath", "brew").Return("/opt/homebrew/bin/brew", nil)
+	mockExec.On("Command", "brew", []string{"install", "rclone"}).
+		Return(exec.Command("sh", "-c", `printf '==> Downloading\n#  10%%\r# 100%%\n'; printf 'Warning: done' >&2`))
+	mockExec.On("RunCommand", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		_ = args.Get(0).(*exec.Cmd).Run()
+	})
+
+	var lines []string
+	inst := installer.NewInstallerWithExecutor(mockExec)
+	err := inst.InstallRcloneStreaming(func(line string) { lines = append(lines, line) })
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"==> Downloading", "#  10%", "# 100%", "Warning: done"}, lines)
+	mockExec.AssertExpectations(t)
+}
+
+func TestInstallRcloneStreamingFailure(t *testing.T) {
+	mockExec := new(MockExecutor)
+	mockExec.On("LookPath", "brew").Return("/opt/homebrew/bin/brew", nil)
+	mockExec.On("Command", "brew", []string{"install", "rclone"}).Return(exec.Command("true"))
+	mockExec.On("RunCommand", mock.Anything).Return(errors.New("exit status 1"))
+
+	inst := installer.NewInstallerWithExecutor(mockExec)
+	err := inst.InstallRcloneStreaming(nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to install rclone")
+}
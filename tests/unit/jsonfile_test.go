@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/jsonfile"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+func TestJSONFileWriteKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	require.NoError(t, jsonfile.Write(path, map[string]int{"version": 1}, 0600))
+	assert.NoFileExists(t, jsonfile.BackupPath(path))
+
+	require.NoError(t, jsonfile.Write(path, map[string]int{"version": 2}, 0600))
+	backup, err := os.ReadFile(jsonfile.BackupPath(path))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":1}`, string(backup))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// A broken file does not replace a good backup
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 3`), 0600))
+	require.NoError(t, jsonfile.Write(path, map[string]int{"version": 4}, 0600))
+	backup, err = os.ReadFile(jsonfile.BackupPath(path))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":1}`, string(backup))
+}
+
+func TestConfigLoadReportsCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	mgr := syncconfig.NewManager(filepath.Join(dir, "sync-config.json"))
+	require.NoError(t, os.WriteFile(mgr.GetConfigPath(), []byte(`{"sync_pairs": [`), 0644))
+
+	_, err := mgr.Load()
+	var corrupt *jsonfile.CorruptError
+	require.True(t, errors.As(err, &corrupt))
+	assert.False(t, corrupt.HasBackup())
+	assert.NotContains(t, err.Error(), "config restore")
+
+	require.NoError(t, os.WriteFile(jsonfile.BackupPath(mgr.GetConfigPath()), []byte(`{"sync_pairs": [], "version": "1.0"}`), 0644))
+	_, err = mgr.Load()
+	require.True(t, errors.As(err, &corrupt))
+	assert.True(t, corrupt.HasBackup())
+	assert.Contains(t, err.Error(), "cloud-sync config restore")
+
+	require.NoError(t, mgr.RestoreBackup())
+	_, err = mgr.Load()
+	assert.NoError(t, err)
+	assert.FileExists(t, jsonfile.CorruptPath(mgr.GetConfigPath()))
+}
+
+func TestCLIConfigRestore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.LogDir = "/first"
+	require.NoError(t, configManager.Save(appConfig))
+	appConfig.LogDir = "/second"
+	require.NoError(t, configManager.Save(appConfig))
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"config", "restore"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "nothing to restore")
+
+	// A truncated config.json is found and restored without naming it
+	require.NoError(t, os.WriteFile(configManager.GetConfigPath(), []byte(`{"log_dir": "/se`), 0600))
+	require.Len(t, configManager.CorruptFiles(), 1)
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"config", "restore"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Restored config.json")
+
+	appConfig, err = configManager.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/first", appConfig.LogDir)
+	assert.Empty(t, configManager.CorruptFiles())
+
+	// sync-config.json has no backup yet
+	assert.Equal(t, 1, cli.Run([]string{"config", "restore", "sync-config.json"}, &stdout, &stderr))
+	assert.Equal(t, 2, cli.Run([]string{"config", "restore", "other.json"}, &stdout, &stderr))
+}
@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+)
+
+// fakeLaunchctl puts a launchctl that runs script and logs its arguments
+// first on PATH, and returns the log's path
+func fakeLaunchctl(t *testing.T, script string) string {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+	body := fmt.Sprintf("#!/bin/sh\necho \"$*\" >> %s\n%s\n", log, script)
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "launchctl"), []byte(body), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func TestLaunchctlModernCommands(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	target := fmt.Sprintf("gui/%d/com.tester.rclonebackup", os.Getuid())
+	log := fakeLaunchctl(t, fmt.Sprintf(`case "$1" in
+print) [ "$2" = "%s" ] || exit 1
+	printf '%s = {\n\tstate = not running\n\tlast exit code = 3\n}\n' ;;
+bootout) echo "Boot-out failed: 3: No such process"; exit 3 ;;
+esac`, target, target))
+
+	agent := launchd.NewManager("tester")
+	status, err := agent.GetStatus()
+	require.NoError(t, err)
+	assert.Equal(t, &launchd.Status{Loaded: true, PID: -1, LastExit: 3}, status)
+
+	// Booting out a job that is not loaded is not an error
+	require.NoError(t, agent.Unload())
+	require.NoError(t, agent.Start())
+	require.NoError(t, agent.Stop())
+
+	calls, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Equal(t, "print "+target+"\nbootout "+target+"\nkickstart "+target+"\nkill SIGTERM "+target+"\n", string(calls))
+}
+
+func TestLaunchctlLegacyFallback(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	// launchctl before OS X 10.10 only knows the legacy subcommands
+	log := fakeLaunchctl(t, `case "$1" in
+load|list) ;;
+*) echo "Unrecognized subcommand: $1"; exit 1 ;;
+esac
+[ "$1" = list ] && printf '{\n\t"PID" = 812;\n\t"LastExitStatus" = 0;\n};\n'
+exit 0`)
+
+	agent := launchd.NewManager("tester")
+	writeFiles(t, filepath.Dir(agent.GetPlistPath()), map[string]string{filepath.Base(agent.GetPlistPath()): "<plist/>"})
+	require.NoError(t, agent.Load())
+	status, err := agent.GetStatus()
+	require.NoError(t, err)
+	assert.True(t, status.Loaded)
+	assert.True(t, status.Running)
+	assert.Equal(t, 812, status.PID)
+
+	calls, err := os.ReadFile(log)
+	require.NoError(t, err)
+	target := fmt.Sprintf("gui/%d", os.Getuid())
+	assert.Equal(t, "bootstrap "+target+" "+agent.GetPlistPath()+"\nload "+agent.GetPlistPath()+
+		"\nprint "+target+"/com.tester.rclonebackup\nlist com.tester.rclonebackup\n", string(calls))
+}
@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+// writeAgents writes empty plists into the LaunchAgents directory of home
+func writeAgents(t *testing.T, home string, labels ...string) {
+	t.Helper()
+	files := make(map[string]string, len(labels))
+	for _, label := range labels {
+		files[label+".plist"] = ""
+	}
+	writeFiles(t, filepath.Join(home, "Library", "LaunchAgents"), files)
+}
+
+// bootstrapped returns the plists of the simulated 'launchctl bootstrap' runs
+func bootstrapped() []string {
+	var plists []string
+	for _, action := range simulate.Actions() {
+		if len(action.Args) == 3 && action.Args[0] == "bootstrap" {
+			plists = append(plists, filepath.Base(action.Args[2]))
+		}
+	}
+	return plists
+}
+
+func TestLaunchdJobs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	jobs, err := launchd.NewManager("tester").Jobs()
+	require.NoError(t, err)
+	assert.Empty(t, jobs, "no LaunchAgents directory yet")
+
+	writeAgents(t, home,
+		"com.tester.cloudsync-power",
+		"com.tester.rclonebackup",
+		"com.tester.cloudsync-mount",
+		"com.tester.cloudsync-digest",
+		"com.tester.other",
+		"com.someone.cloudsync-power",
+	)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "Library", "LaunchAgents", "com.tester.notes.txt"), 0755))
+
+	jobs, err = launchd.NewManager("tester").Jobs()
+	require.NoError(t, err)
+	var labels, descriptions []string
+	for _, job := range jobs {
+		labels = append(labels, job.GetLabel())
+		descriptions = append(descriptions, job.Description())
+	}
+	assert.Equal(t, []string{
+		"com.tester.rclonebackup",
+		"com.tester.cloudsync-digest",
+		"com.tester.cloudsync-mount",
+		"com.tester.cloudsync-power",
+	}, labels)
+	assert.Equal(t, []string{
+		"Scheduled backup",
+		"com.tester.cloudsync-digest",
+		"Sync on drive mount",
+		"Retry on power change",
+	}, descriptions)
+	assert.Equal(t, filepath.Join(home, "Library", "LaunchAgents", "com.tester.cloudsync-mount.plist"), jobs[2].GetPlistPath())
+}
+
+func TestLaunchdJobsView(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	writeAgents(t, home, "com.tester.rclonebackup", "com.tester.cloudsync-mount", "com.tester.cloudsync-power")
+
+	// Opened from the LaunchAgent manager
+	manager := launchd.NewManager("tester")
+	_, cmd := views.NewLaunchdManagerModel(manager, 120, 30).Update(testutil.KeyMsg("a"))
+	require.NotNil(t, cmd)
+	open, ok := cmd().(views.OpenViewMsg)
+	require.True(t, ok)
+	require.IsType(t, views.LaunchdJobsModel{}, open.View)
+
+	// Simulated launchctl reports every job as not loaded
+	h := testutil.NewHarness(t, open.View, 120, 30)
+	h.Init()
+	view := testutil.PlainText(h.View())
+	assert.Contains(t, view, "Scheduled backup")
+	assert.Contains(t, view, "Sync on drive mount")
+	assert.Contains(t, view, "Retry on power change")
+
+	h.Press("u")
+	assert.Contains(t, h.View(), "com.tester.rclonebackup is not loaded")
+	h.Press("d")
+	assert.Contains(t, h.View(), "Nothing to do: the jobs are already unloaded")
+
+	// Only the marked rows are enabled
+	h.Press("down", " ", "down", " ", "e")
+	assert.Contains(t, h.View(), "Enabled 2 of 2 job(s)")
+	assert.Equal(t, []string{"com.tester.cloudsync-mount.plist", "com.tester.cloudsync-power.plist"}, bootstrapped())
+
+	// With none marked, every job is
+	simulate.Reset()
+	simulate.Enable()
+	h.Press("e")
+	assert.Contains(t, h.View(), "Enabled 3 of 3 job(s)")
+	assert.Len(t, bootstrapped(), 3)
+
+	views.SetReadOnly(true)
+	t.Cleanup(func() { views.SetReadOnly(false) })
+	h.Press("l")
+	assert.Contains(t, h.View(), "Load LaunchAgent is disabled in read-only mode")
+}
@@ -2,11 +2,15 @@ package unit
 
 import (
 	"os"
+	"os/user"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,6 +24,12 @@ func TestLaunchdNewManager(t *testing.T) {
 	assert.Contains(t, manager.GetPlistPath(), "LaunchAgents")
 }
 
+func TestLaunchdCurrentUsername(t *testing.T) {
+	current, err := user.Current()
+	require.NoError(t, err)
+	assert.Equal(t, current.Username, launchd.CurrentUsername())
+}
+
 func TestGetLabel(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -253,3 +263,173 @@ func TestStartStopRequiresMocks(t *testing.T) {
 func TestGetStatusRequiresMocks(t *testing.T) {
 	t.Skip("GetStatus tests require launchctl mocking or actual macOS environment")
 }
+
+func TestLaunchdParseSchedule(t *testing.T) {
+	times, err := launchd.ParseTimes("18:30, 9:00")
+	require.NoError(t, err)
+	assert.Equal(t, []launchd.TimeOfDay{{Hour: 9, Minute: 0}, {Hour: 18, Minute: 30}}, times)
+
+	_, err = launchd.ParseTimes("25:00")
+	assert.Error(t, err)
+
+	weekdays, err := launchd.ParseWeekdays("mon-wed, Friday, 7")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 5, 7}, weekdays)
+
+	_, err = launchd.ParseWeekdays("fri-mon")
+	assert.Error(t, err)
+
+	days, err := launchd.ParseDaysOfMonth("15, 1-3")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 15}, days)
+
+	_, err = launchd.ParseDaysOfMonth("32")
+	assert.Error(t, err)
+}
+
+func TestLaunchdScheduleIntervals(t *testing.T) {
+	schedule := launchd.Schedule{
+		Times:    []launchd.TimeOfDay{{Hour: 9}, {Hour: 18, Minute: 30}},
+		Weekdays: []int{1, 5},
+	}
+	require.NoError(t, schedule.Validate())
+	assert.Len(t, schedule.Intervals(), 4)
+	assert.Equal(t, "Mon, Fri at 09:00, 18:30", schedule.String())
+
+	daily := launchd.Schedule{Times: []launchd.TimeOfDay{{Hour: 10, Minute: 5}}}
+	assert.Equal(t, []launchd.CalendarInterval{{Hour: 10, Minute: 5}}, daily.Intervals())
+
+	mixed := launchd.Schedule{Times: daily.Times, Weekdays: []int{1}, DaysOfMonth: []int{1}}
+	assert.Error(t, mixed.Validate())
+	assert.Error(t, launchd.Schedule{}.Validate())
+}
+
+func TestLaunchdRenderPlistIntervals(t *testing.T) {
+	config := &launchd.Config{
+		Label:      "com.test.backup",
+		ScriptPath: "/path/to/script.sh",
+		Intervals: []launchd.CalendarInterval{
+			{Hour: 9, Minute: 0, Weekday: 1},
+			{Hour: 18, Minute: 30, Day: 15},
+		},
+	}
+
+	data, err := launchd.RenderPlist(config)
+	require.NoError(t, err)
+	plist := string(data)
+	assert.Contains(t, plist, "<key>StartCalendarInterval</key>\n\t<array>")
+	assert.Equal(t, 2, strings.Count(plist, "<key>Hour</key>"))
+	assert.Contains(t, plist, "<key>Weekday</key>\n\t\t\t<integer>1</integer>")
+	assert.Contains(t, plist, "<key>Day</key>\n\t\t\t<integer>15</integer>")
+
+	config.Intervals = nil
+	config.Hour, config.Minute = 10, 5
+	data, err = launchd.RenderPlist(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<key>StartCalendarInterval</key>\n\t<dict>")
+	assert.NotContains(t, string(data), "<array>\n\t\t<dict>")
+}
+
+func TestLaunchdScheduleExpectedRuns(t *testing.T) {
+	// 2026-10-12 is a Monday
+	from := time.Date(2026, 10, 12, 12, 0, 0, 0, time.Local)
+	to := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+
+	daily := launchd.Schedule{Times: []launchd.TimeOfDay{{Hour: 10}, {Hour: 18}}}
+	runs := daily.ExpectedRuns(from, to)
+	require.Len(t, runs, 14)
+	assert.Equal(t, time.Date(2026, 10, 12, 18, 0, 0, 0, time.Local), runs[0])
+	assert.Equal(t, time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local), runs[len(runs)-1])
+
+	weekdays := launchd.Schedule{Times: []launchd.TimeOfDay{{Hour: 9}}, Weekdays: []int{2, 7}}
+	runs = weekdays.ExpectedRuns(from, to)
+	require.Len(t, runs, 2)
+	assert.Equal(t, time.Tuesday, runs[0].Weekday())
+	assert.Equal(t, time.Sunday, runs[1].Weekday())
+
+	monthly := launchd.Schedule{Times: []launchd.TimeOfDay{{Hour: 9}}, DaysOfMonth: []int{15}}
+	runs = monthly.ExpectedRuns(from, to)
+	require.Len(t, runs, 1)
+	assert.Equal(t, 15, runs[0].Day())
+}
+
+func TestLaunchdNextRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// 2026-10-12 is a Monday
+	now := time.Date(2026, 10, 12, 12, 0, 30, 0, time.Local)
+
+	weekdays := launchd.Schedule{Times: []launchd.TimeOfDay{{Hour: 9}}, Weekdays: []int{2, 7}}
+	assert.Equal(t, time.Date(2026, 10, 13, 9, 0, 0, 0, time.Local), launchd.NextCalendarRun(weekdays.Intervals(), now))
+	assert.True(t, launchd.NextCalendarRun(nil, now).IsZero())
+
+	// Nothing is installed yet
+	manager := launchd.NewManager("tester")
+	next, err := manager.NextRun(now)
+	require.NoError(t, err)
+	assert.True(t, next.IsZero())
+
+	monthly := launchd.Schedule{Times: []launchd.TimeOfDay{{Hour: 12}}, DaysOfMonth: []int{12, 31}}
+	require.NoError(t, manager.GeneratePlist(&launchd.Config{Label: manager.GetLabel(), ScriptPath: "/backup.sh", Intervals: monthly.Intervals()}))
+	next, err = manager.NextRun(now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 31, 12, 0, 0, 0, time.Local), next, "12:00 today has passed")
+
+	// A hand-edited plist: every hour at :30 on Sundays, which launchd also
+	// takes as weekday 0
+	writeFiles(t, filepath.Dir(manager.GetPlistPath()), map[string]string{filepath.Base(manager.GetPlistPath()): `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.tester.rclonebackup</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>StartCalendarInterval</key>
+	<dict>
+		<key>Weekday</key>
+		<integer>0</integer>
+		<key>Minute</key>
+		<integer>30</integer>
+	</dict>
+</dict>
+</plist>
+`})
+	next, err = manager.NextRun(now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 18, 0, 30, 0, 0, time.Local), next)
+
+	// Interval jobs have no fixed time
+	require.NoError(t, manager.GeneratePlist(&launchd.Config{Label: manager.GetLabel(), ScriptPath: "/backup.sh", StartInterval: 3600}))
+	next, err = manager.NextRun(now)
+	require.NoError(t, err)
+	assert.True(t, next.IsZero())
+
+	var model tea.Model = views.NewLaunchdManagerModel(manager, 100, 40)
+	model, _ = model.Update(&launchd.Status{Loaded: true})
+	model, _ = model.Update(views.NextRunMsg{Time: time.Date(2026, 10, 13, 9, 0, 0, 0, time.Local)})
+	assert.Contains(t, model.View(), "Next Run")
+	assert.Contains(t, model.View(), "Tue Oct 13 09:00")
+}
+
+func TestLaunchdIntervalSchedule(t *testing.T) {
+	schedule := launchd.Schedule{IntervalHours: 6}
+	require.NoError(t, schedule.Validate())
+	assert.True(t, schedule.UsesInterval())
+	assert.Equal(t, 6*3600, schedule.StartInterval())
+	assert.Empty(t, schedule.Intervals())
+	assert.Equal(t, "Every 6 hours", schedule.String())
+
+	from := time.Date(2026, 10, 15, 0, 0, 0, 0, time.Local)
+	assert.Len(t, schedule.ExpectedRuns(from, from.Add(24*time.Hour)), 4)
+
+	limited := launchd.Schedule{IntervalHours: 6, Weekdays: []int{1}}
+	assert.Error(t, limited.Validate())
+
+	data, err := launchd.RenderPlist(&launchd.Config{
+		Label:         "com.test.backup",
+		ScriptPath:    "/path/to/script.sh",
+		StartInterval: schedule.StartInterval(),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<key>StartInterval</key>\n\t<integer>21600</integer>")
+	assert.NotContains(t, string(data), "StartCalendarInterval")
+}
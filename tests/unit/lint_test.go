@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/doctor"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// lintFixture writes a configuration with one issue of each kind: a pair on
+// an unknown remote whose folder is gone, two pairs of one folder, and an
+// rclone and a script that moved. It returns the rclone found now.
+func lintFixture(t *testing.T, home string) (*config.Manager, string) {
+	t.Helper()
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = "/usr/local/bin/rclone-gone"
+	require.NoError(t, os.MkdirAll(filepath.Dir(appConfig.RcloneConfig), 0755))
+	require.NoError(t, os.WriteFile(appConfig.RcloneConfig, []byte("[b2]\ntype = b2\n"), 0600))
+	require.NoError(t, configManager.Save(appConfig))
+
+	require.NoError(t, os.MkdirAll(appConfig.BinDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appConfig.BinDir, "run_rclone_sync.sh"), []byte("#!/bin/zsh\nRCLONE_PATH=\"/usr/local/bin/rclone-gone\"\nLOG_FILE=\"log\"\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appConfig.BinDir, "monthly_backup.sh"), []byte("#!/bin/zsh\n"), 0755))
+	plist, err := launchd.RenderPlist(&launchd.Config{Label: "com.tester.rclonebackup", ScriptPath: "/Users/old/bin/monthly_backup.sh"})
+	require.NoError(t, err)
+	agents := filepath.Join(home, "Library", "LaunchAgents")
+	require.NoError(t, os.MkdirAll(agents, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(agents, "com.tester.rclonebackup.plist"), plist, 0644))
+
+	docs := filepath.Join(home, "Documents")
+	require.NoError(t, os.MkdirAll(docs, 0755))
+	usb := filepath.Join(fakeVolumes(t, home), "USB")
+	pairs := syncconfig.NewManager(configManager.SyncConfigPath())
+	require.NoError(t, pairs.Save(&syncconfig.Config{SyncPairs: []syncconfig.SyncPair{
+		{Name: "docs", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true},
+		{Name: "docs-copy", LocalPath: docs + "/", RemoteName: "b2", RemotePath: "bucket/copy", Direction: "upload", Enabled: true},
+		{Name: "photos", LocalPath: filepath.Join(home, "Pictures"), RemoteName: "gdrive", RemotePath: "photos", Direction: "upload", Enabled: true},
+		{Name: "camera", LocalPath: filepath.Join(usb, "DCIM"), RemoteName: "b2", RemotePath: "bucket/camera", Direction: "upload", Enabled: true},
+	}}))
+
+	rclone := filepath.Join(home, "bin", "rclone")
+	require.NoError(t, os.MkdirAll(filepath.Dir(rclone), 0755))
+	require.NoError(t, os.WriteFile(rclone, []byte("#!/bin/sh\necho 'rclone v1.66.0'\n"), 0755))
+	return configManager, rclone
+}
+
+// lintRules returns the rule of each issue
+func lintRules(issues []doctor.Issue) []string {
+	rules := make([]string, len(issues))
+	for i, issue := range issues {
+		rules[i] = issue.Rule
+	}
+	return rules
+}
+
+func TestLintFindsAndRepairsIssues(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configManager, rclonePath := lintFixture(t, home)
+	opts := doctor.Options{
+		Config:     configManager,
+		Pairs:      syncconfig.NewManager(configManager.SyncConfigPath()),
+		Launchd:    launchd.NewManager("tester"),
+		RclonePath: rclonePath,
+	}
+
+	issues, err := doctor.Lint(opts)
+	require.NoError(t, err)
+	// The camera's drive is not connected, so its folder is not gone
+	assert.Equal(t, []string{
+		doctor.RuleUnknownRemote,
+		doctor.RuleMissingFolder,
+		doctor.RuleDuplicateLocal,
+		doctor.RuleStaleRclone,
+		doctor.RuleStaleRclone,
+		doctor.RuleMovedProgram,
+	}, lintRules(issues))
+	assert.Equal(t, "pair 'photos' syncs to gdrive:photos, but remote 'gdrive' is not configured", issues[0].Detail)
+	assert.Equal(t, "Add remote 'gdrive' under Remotes, or point 'photos' at one of b2 in Sync Pairs", issues[0].Fix)
+	assert.Equal(t, "pairs 'docs', 'docs-copy' all sync "+filepath.Join(home, "Documents"), issues[2].Detail)
+
+	for _, issue := range issues {
+		assert.NotEmpty(t, issue.Fix, issue.Detail)
+		if issue.Repairable() {
+			require.NoError(t, issue.Apply(), issue.Detail)
+		}
+	}
+
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	assert.Equal(t, rclonePath, appConfig.RclonePath)
+	script, err := os.ReadFile(filepath.Join(appConfig.BinDir, "run_rclone_sync.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/zsh\nRCLONE_PATH=\""+rclonePath+"\"\nLOG_FILE=\"log\"\n", string(script))
+	args, err := launchd.NewManager("tester").ProgramArguments()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/bin/zsh", filepath.Join(appConfig.BinDir, "monthly_backup.sh")}, args)
+
+	// What needs a decision is left
+	issues, err = doctor.Lint(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{doctor.RuleUnknownRemote, doctor.RuleMissingFolder, doctor.RuleDuplicateLocal}, lintRules(issues))
+	assert.ErrorContains(t, issues[0].Apply(), "cannot be repaired automatically")
+}
+
+func TestCLILint(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	_, rclonePath := lintFixture(t, home)
+	t.Setenv("PATH", filepath.Dir(rclonePath))
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, cli.Run([]string{"lint"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "stale-rclone-path: run_rclone_sync.sh runs rclone from /usr/local/bin/rclone-gone, which no longer exists\n")
+	assert.Contains(t, stdout.String(), "      Or run 'cloud-sync lint --fix' to point run_rclone_sync.sh at "+rclonePath+"\n")
+	// The agents are those of the user running the tests, not tester's
+	assert.Contains(t, stdout.String(), "\n5 issue(s), 2 can be repaired with --fix\n")
+
+	stdout.Reset()
+	assert.Equal(t, 1, cli.Run([]string{"lint", "--fix"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "      Repaired: set rclone_path to "+rclonePath+"\n")
+	assert.Contains(t, stdout.String(), "\n5 issue(s), 2 repaired\n")
+
+	stdout.Reset()
+	assert.Equal(t, 1, cli.Run([]string{"lint"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "\n3 issue(s)\n")
+}
+
+func TestCLILintWithoutIssues(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, cli.Run([]string{"lint"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "No issues found.\n", stdout.String())
+}
@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// countedListings is a fake rclone that lists the buckets in $BUCKETS and
+// one folder, logging each listing to $RCLONE_LOG
+const countedListings = `echo "$1 $2" >> "$RCLONE_LOG"
+case "$1" in
+lsd) for b in $BUCKETS; do echo "          -1 2026-10-15 12:00:00        -1 $b"; done ;;
+lsjson) echo '[{"Name":"docs","Size":-1,"IsDir":true},{"Name":"a.txt","Size":10,"IsDir":false}]' ;;
+esac
+`
+
+// listingCalls returns how many listings the fake rclone made
+func listingCalls(t *testing.T) int {
+	data, err := os.ReadFile(os.Getenv("RCLONE_LOG"))
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(t, err)
+	return strings.Count(string(data), "\n")
+}
+
+func TestListingCache(t *testing.T) {
+	t.Setenv("RCLONE_LOG", filepath.Join(t.TempDir(), "rclone.log"))
+	t.Setenv("BUCKETS", "photos docs")
+	manager := fakeRclone(t, countedListings)
+	cache := rclone.NewListingCache(t.TempDir(), time.Hour)
+
+	buckets, listed, err := manager.CachedListBuckets(cache, "b2")
+	require.NoError(t, err)
+	assert.Equal(t, []rclone.Bucket{{Name: "photos"}, {Name: "docs"}}, buckets)
+	assert.Equal(t, 1, listingCalls(t))
+
+	// The second lookup is answered from the cache
+	t.Setenv("BUCKETS", "photos docs music")
+	cached, cachedAt, err := manager.CachedListBuckets(cache, "b2")
+	require.NoError(t, err)
+	assert.Equal(t, buckets, cached)
+	assert.True(t, listed.Equal(cachedAt))
+	assert.Equal(t, 1, listingCalls(t))
+
+	cache.Forget("b2:")
+	buckets, _, err = manager.CachedListBuckets(cache, "b2")
+	require.NoError(t, err)
+	assert.Len(t, buckets, 3)
+	assert.Equal(t, 2, listingCalls(t))
+
+	// Folders are cached apart from the buckets of the same path
+	entries, _, err := manager.CachedListDir(cache, "b2:")
+	require.NoError(t, err)
+	assert.Equal(t, []rclone.DirEntry{{Name: "a.txt", Size: 10}, {Name: "docs", Size: -1, IsDir: true}}, entries)
+	_, _, err = manager.CachedListDir(cache, "b2:")
+	require.NoError(t, err)
+	assert.Equal(t, 3, listingCalls(t))
+}
+
+func TestListingCacheExpires(t *testing.T) {
+	t.Setenv("RCLONE_LOG", filepath.Join(t.TempDir(), "rclone.log"))
+	manager := fakeRclone(t, countedListings)
+
+	expired := rclone.NewListingCache(t.TempDir(), 0)
+	for i := 0; i < 2; i++ {
+		_, _, err := manager.CachedListDir(expired, "b2:bucket")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, listingCalls(t))
+
+	// Without a cache every lookup lists the remote
+	_, _, err := manager.CachedListBuckets(nil, "b2")
+	require.NoError(t, err)
+	assert.Equal(t, 3, listingCalls(t))
+}
+
+func TestListingCacheRefreshed(t *testing.T) {
+	t.Setenv("RCLONE_LOG", filepath.Join(t.TempDir(), "rclone.log"))
+	manager := fakeRclone(t, countedListings)
+	cache := rclone.NewListingCache(t.TempDir(), time.Hour)
+
+	// Recursive listings are cached apart from the folder's own
+	_, _, err := manager.CachedListFiles(cache, "b2:bucket")
+	require.NoError(t, err)
+	_, _, err = manager.CachedListFiles(cache, "b2:bucket")
+	require.NoError(t, err)
+	_, _, err = manager.CachedListDir(cache, "b2:bucket")
+	require.NoError(t, err)
+	assert.Equal(t, 2, listingCalls(t))
+
+	// A refreshed cache lists again and keeps the new listing for the next
+	// lookup
+	_, _, err = manager.CachedListDir(cache.Refreshed(), "b2:bucket")
+	require.NoError(t, err)
+	assert.Equal(t, 3, listingCalls(t))
+	_, _, err = manager.CachedListDir(cache, "b2:bucket")
+	require.NoError(t, err)
+	assert.Equal(t, 3, listingCalls(t))
+
+	cache.Forget("b2:bucket")
+	_, _, err = manager.CachedListFiles(cache, "b2:bucket")
+	require.NoError(t, err)
+	assert.Equal(t, 4, listingCalls(t))
+}
+
+func TestSyncPairsWizardCachesBuckets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("RCLONE_LOG", filepath.Join(t.TempDir(), "rclone.log"))
+	t.Setenv("BUCKETS", "photos")
+	manager := fakeRclone(t, countedListings)
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+
+	atRemotePath := func() tea.Model {
+		var model tea.Model = views.NewSyncPairsModel(mgr, manager)
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+		model = typeText(model, "docs")
+		model = typeText(model, t.TempDir())
+		return typeText(model, "b2")
+	}
+
+	model := atRemotePath()
+	assert.Contains(t, model.View(), "Existing buckets: photos")
+	assert.Contains(t, model.View(), "ctrl+r: Refresh buckets")
+
+	// A bucket created elsewhere shows up once the list is refreshed
+	t.Setenv("BUCKETS", "photos music")
+	model = atRemotePath()
+	assert.NotContains(t, model.View(), "music")
+	assert.Equal(t, 1, listingCalls(t))
+
+	model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+	assert.Contains(t, model.View(), "Existing buckets: photos, music")
+	assert.Equal(t, 2, listingCalls(t))
+}
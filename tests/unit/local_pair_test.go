@@ -0,0 +1,225 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/rsync"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// fakeRsync writes an rsync stand-in that logs its arguments to args. Dry
+// runs listing changes report three of the target's files as deleted.
+func fakeRsync(t *testing.T, args, synced string) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "rsync")
+	script := `#!/bin/sh
+echo "$@" >> ` + args + `
+for arg in "$@"; do
+	if [ "$arg" = "--itemize-changes" ]; then
+		echo ">f+++++++++ keep.txt"
+		for f in a b c; do echo "*deleting   $f.txt"; done
+		echo "*deleting   old/"
+		exit 0
+	fi
+done
+touch ` + synced + "\n"
+	require.NoError(t, os.WriteFile(bin, []byte(script), 0755))
+	return bin
+}
+
+func TestValidateLocalPair(t *testing.T) {
+	source, drive := t.TempDir(), t.TempDir()
+	pair := syncconfig.SyncPair{
+		Name:       "Mirror",
+		LocalPath:  source,
+		Type:       syncconfig.TypeLocal,
+		TargetPath: filepath.Join(drive, "Documents"),
+		Direction:  "upload",
+		ExtraFlags: []string{"--exclude", ".DS_Store"},
+	}
+	require.NoError(t, syncconfig.ValidateSyncPair(&pair))
+	assert.True(t, pair.IsLocal())
+	assert.Equal(t, filepath.Join(drive, "Documents"), pair.Target())
+	assert.Empty(t, pair.AllDestinations())
+
+	invalid := []func(p *syncconfig.SyncPair){
+		func(p *syncconfig.SyncPair) { p.TargetPath = "" },
+		func(p *syncconfig.SyncPair) { p.TargetPath = filepath.Join(source, "copy") },
+		func(p *syncconfig.SyncPair) { p.LocalPath = filepath.Join(drive, "Documents", "inner") },
+		func(p *syncconfig.SyncPair) { p.RemoteName, p.RemotePath = "b2", "bucket" },
+		func(p *syncconfig.SyncPair) { p.Direction = "download" },
+		func(p *syncconfig.SyncPair) { p.Snapshot = true },
+		func(p *syncconfig.SyncPair) { p.ExtraFlags = []string{"--delete"} },
+		func(p *syncconfig.SyncPair) { p.Type = "ftp" },
+	}
+	for i, change := range invalid {
+		p := pair
+		change(&p)
+		assert.Error(t, syncconfig.ValidateSyncPair(&p), "case %d", i)
+	}
+
+	// Remote pairs cannot have a target
+	remote := syncconfig.SyncPair{Name: "Docs", LocalPath: source, RemoteName: "b2", RemotePath: "bucket", Direction: "upload", TargetPath: drive}
+	assert.Error(t, syncconfig.ValidateSyncPair(&remote))
+}
+
+func TestRsyncBuildArgs(t *testing.T) {
+	manager := rsync.NewManager("rsync")
+
+	args := manager.BuildArgs("/src", "/Volumes/Backup/src", rsync.Options{LogFile: "/logs/pair.log", ExtraFlags: []string{"--no-perms"}})
+	assert.Equal(t, []string{"-a", "--delete", "--log-file", "/logs/pair.log", "--log-file-format", "INFO  : %n: Copied",
+		"--no-perms", "/src/", "/Volumes/Backup/src"}, args)
+
+	args = manager.BuildArgs("/src/", "/dst", rsync.Options{Command: "copy", DryRun: true})
+	assert.Equal(t, []string{"-a", "--dry-run", "/src/", "/dst"}, args)
+
+	args = manager.BuildArgs("/src", "/dst", rsync.Options{Command: "move", Progress: true})
+	assert.Equal(t, []string{"-a", "--remove-source-files", "--progress", "/src/", "/dst"}, args)
+}
+
+func TestBackupMirrorsLocalPair(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	args, synced := filepath.Join(home, "args"), filepath.Join(home, "synced")
+	source, target := t.TempDir(), filepath.Join(t.TempDir(), "Documents")
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:       "Mirror",
+		LocalPath:  source,
+		Type:       syncconfig.TypeLocal,
+		TargetPath: target,
+		Direction:  "upload",
+		Enabled:    true,
+	}))
+
+	manager, err := backup.NewManager(&backup.Config{
+		Username:  "tester",
+		HomeDir:   home,
+		RsyncPath: fakeRsync(t, args, synced),
+		LogDir:    filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	// A new target has nothing to delete, so no dry run is needed
+	require.NoError(t, manager.SyncPair("Mirror", false, false))
+	assert.FileExists(t, synced)
+	calls, err := os.ReadFile(args)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	require.Len(t, lines, 1)
+	assert.True(t, strings.HasPrefix(lines[0], "-a --delete --log-file "+filepath.Join(home, "logs")))
+	assert.True(t, strings.HasSuffix(lines[0], source+"/ "+target))
+
+	// Deleting three of the target's four files is over the default limit
+	require.NoError(t, os.MkdirAll(target, 0755))
+	for _, name := range []string{"keep.txt", "a.txt", "b.txt", "c.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(target, name), nil, 0644))
+	}
+	require.NoError(t, os.Remove(synced))
+	err = manager.SyncPair("Mirror", false, false)
+	var limitErr *backup.DeleteLimitError
+	require.True(t, errors.As(err, &limitErr), "got %v", err)
+	assert.Equal(t, rclone.DeletePlan{Deletes: 3, DestFiles: 4}, limitErr.Plan)
+	assert.Equal(t, target, limitErr.Destination)
+	assert.NoFileExists(t, synced)
+
+	manager.AllowDeletes(true)
+	require.NoError(t, manager.SyncPair("Mirror", false, false))
+	assert.FileExists(t, synced)
+}
+
+func TestBackupLocalPairNeedsMountedTarget(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	synced := filepath.Join(home, "synced")
+	unmounted := filepath.Join(home, "Volumes", "Backup")
+	require.NoError(t, os.MkdirAll(unmounted, 0755))
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:       "Mirror",
+		LocalPath:  t.TempDir(),
+		Type:       syncconfig.TypeLocal,
+		TargetPath: filepath.Join(unmounted, "Documents"),
+		Direction:  "upload",
+		Enabled:    true,
+	}))
+	require.NoError(t, os.Remove(unmounted))
+
+	manager, err := backup.NewManager(&backup.Config{
+		Username:  "tester",
+		HomeDir:   home,
+		RsyncPath: fakeRsync(t, filepath.Join(home, "args"), synced),
+	})
+	require.NoError(t, err)
+
+	err = manager.SyncPair("Mirror", false, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "target is not available")
+	assert.NoFileExists(t, synced)
+}
+
+// wizardAtRemoteName starts adding a pair named docs and stops where the
+// remote or target folder is asked for
+func wizardAtRemoteName(t *testing.T, mgr *syncconfig.Manager) tea.Model {
+	t.Helper()
+	var model tea.Model = views.NewSyncPairsModel(mgr, fakeRclone(t, "exit 1\n"))
+	model, _ = model.Update(keyPress("a"))
+	model = typeText(model, "docs")
+	model = typeText(model, t.TempDir())
+	require.Contains(t, model.View(), "Enter the rclone remote name")
+	return model
+}
+
+func TestSyncPairsWizardLocalTarget(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	drive := t.TempDir()
+
+	// The drive has to be there
+	model := wizardAtRemoteName(t, mgr)
+	assert.Contains(t, model.View(), "to another disk with rsync")
+	model = typeText(model, filepath.Join(drive, "missing", "Documents"))
+	assert.Contains(t, model.View(), "path does not exist")
+
+	model = wizardAtRemoteName(t, mgr)
+	model = typeText(model, filepath.Join(drive, "Documents"))
+	require.Contains(t, model.View(), "3. move")
+	model = typeText(model, "1")
+	model = typeText(model, "")
+	require.Contains(t, model.View(), "Skip hidden files?")
+	model = typeText(model, "")
+	require.Contains(t, model.View(), "Otherwise links are copied as links.")
+	model = typeText(model, "")
+	model = typeText(model, "")
+	require.Contains(t, model.View(), "extra rsync flags")
+	model = typeText(model, "--exclude .DS_Store")
+	view := model.View()
+	assert.Contains(t, view, "Target:")
+	assert.NotContains(t, view, "Remote:")
+	assert.Contains(t, view, "Extra rsync flags: --exclude .DS_Store")
+
+	model = typeText(model, "")
+	require.Contains(t, model.View(), "Sync pair added successfully")
+	pair, err := mgr.GetSyncPair("docs")
+	require.NoError(t, err)
+	assert.Equal(t, syncconfig.TypeLocal, pair.Type)
+	assert.Equal(t, filepath.Join(drive, "Documents"), pair.TargetPath)
+	assert.Equal(t, "upload", pair.Direction)
+	assert.Empty(t, pair.RemoteName)
+}
@@ -0,0 +1,205 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+func TestRemoteWizardNetworkShare(t *testing.T) {
+	share := filepath.Join(fakeVolumes(t, t.TempDir()), "NAS")
+	require.NoError(t, os.MkdirAll(filepath.Join(share, "Backups"), 0755))
+	mgr, rcloneConf := remoteFixture(t)
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = filepath.Join(filepath.Dir(fakeRclone(t, "exit 0").GetConfigPath()), "rclone")
+	require.NoError(t, mgr.Save(appConfig))
+
+	form, _ := views.NewRemoteConfigModel(mgr).Update(keyPress("8"))
+	require.Contains(t, form.View(), "Local Folder / Network Share Configuration")
+
+	// A share that is not mounted is pointed out while typing
+	form = fillField(form, "nas")
+	for _, r := range filepath.Join(share, "Backups") {
+		form, _ = form.Update(keyPress(string(r)))
+	}
+	view := testutil.PlainText(form.View())
+	assert.Contains(t, view, share+" is not mounted")
+	assert.Contains(t, view, "Syncs to this remote are skipped while "+share+" is not mounted")
+
+	form = typeText(form, "")
+	assert.Contains(t, testutil.PlainText(form.View()), "Connected to "+filepath.Join(share, "Backups")+".")
+	remote, err := mgr.GetRemote("nas")
+	require.NoError(t, err)
+	assert.Equal(t, config.RemoteConfig{Name: "nas", Type: config.TypeAlias, Target: filepath.Join(share, "Backups")}, *remote)
+	data, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[nas]\ntype = alias\nremote = "+filepath.Join(share, "Backups")+"\n\n")
+
+	// Without a folder the remote reaches every folder, with nothing to test
+	form, _ = views.NewRemoteConfigModel(mgr).Update(keyPress("8"))
+	form = fillField(form, "disk")
+	form = typeText(form, "")
+	assert.Contains(t, form.View(), "Remote 'disk' configured successfully")
+	data, err = os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[disk]\ntype = local\n\n")
+
+	// Editing shows the folder again
+	form = views.NewEditRemoteModel(mgr, *remote)
+	assert.Regexp(t, `Folder: \S*/Volumes/NAS/Backups`, form.View())
+}
+
+func TestConfigValidatesAliases(t *testing.T) {
+	mgr := config.NewManagerWithPath(filepath.Join(t.TempDir(), "config.json"))
+
+	assert.ErrorContains(t, mgr.AddRemote(config.RemoteConfig{Name: "nas", Type: config.TypeAlias}), "needs a folder or remote path")
+	assert.ErrorContains(t, mgr.AddRemote(config.RemoteConfig{Name: "nas", Type: config.TypeAlias, Target: "Backups"}), "is not a full path")
+	assert.ErrorContains(t, mgr.AddRemote(config.RemoteConfig{Name: "nas", Type: config.TypeAlias, Target: "nas:Backups"}), "cannot stand for itself")
+	assert.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "nas", Type: config.TypeAlias, Target: "/Volumes/NAS/Backups"}))
+	assert.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "photos", Type: config.TypeAlias, Target: "nas:Photos"}))
+}
+
+func TestRenameRemoteRetargetsAliases(t *testing.T) {
+	mgr, rcloneConf := remoteFixture(t)
+	require.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "photos", Type: config.TypeAlias, Target: "b2:photos/2026"}))
+	require.NoError(t, mgr.GenerateRcloneConfig())
+
+	require.NoError(t, mgr.RenameRemote("b2", "backblaze"))
+	remote, err := mgr.GetRemote("photos")
+	require.NoError(t, err)
+	assert.Equal(t, "backblaze:photos/2026", remote.Target)
+	data, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[photos]\ntype = alias\nremote = backblaze:photos/2026\n")
+}
+
+func TestRcloneLocalFolder(t *testing.T) {
+	manager := fakeRclone(t, "exit 0")
+	require.NoError(t, os.WriteFile(manager.GetConfigPath(), []byte(`[disk]
+type = local
+
+[nas]
+type = alias
+remote = /Volumes/NAS/Backups
+
+[photos]
+type = alias
+remote = nas:Photos
+
+[cloud]
+type = alias
+remote = b2:bucket
+
+[loop]
+type = alias
+remote = loop:again
+
+[b2]
+type = b2
+`), 0600))
+
+	tests := []struct {
+		remote, path string
+		folder       string
+	}{
+		{"disk", "/Volumes/NAS/Docs", "/Volumes/NAS/Docs"},
+		{"disk", "Docs", ""},
+		{"nas", "Docs", "/Volumes/NAS/Backups/Docs"},
+		{"photos", "2026", "/Volumes/NAS/Backups/Photos/2026"},
+		{"cloud", "Docs", ""},
+		{"loop", "Docs", ""},
+		{"b2", "bucket", ""},
+		{"missing", "Docs", ""},
+	}
+	for _, tt := range tests {
+		folder, ok := manager.LocalFolder(tt.remote, tt.path)
+		assert.Equal(t, tt.folder != "", ok, tt.remote+":"+tt.path)
+		assert.Equal(t, tt.folder, folder, tt.remote+":"+tt.path)
+	}
+
+	// Aliases transfer like the remote they stand for
+	assert.Empty(t, manager.DefaultFlags(t.TempDir(), "photos:2026"))
+	assert.Equal(t, []string{"--fast-list"}, manager.DefaultFlags(t.TempDir(), "cloud:Docs"))
+}
+
+func TestBackupSkipsPairOnUnmountedShare(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	share := filepath.Join(fakeVolumes(t, home), "NAS")
+	require.NoError(t, os.MkdirAll(share, 0755))
+
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	require.NoError(t, configManager.AddRemote(config.RemoteConfig{Name: "nas", Type: config.TypeAlias, Target: filepath.Join(share, "Backups")}))
+	require.NoError(t, configManager.GenerateRcloneConfig())
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, syncConfigMgr.AddSyncPair(syncconfig.SyncPair{
+		Name:       "Docs",
+		LocalPath:  t.TempDir(),
+		RemoteName: "nas",
+		RemotePath: "Docs",
+		Direction:  "upload",
+		Enabled:    true,
+	}))
+
+	manager, err := backup.NewManager(&backup.Config{
+		Username: "tester",
+		HomeDir:  home,
+		LogDir:   filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	// rclone is never run, so nothing lands in the folder left behind
+	err = manager.SyncPair("Docs", false, false)
+	var volumeErr *backup.VolumeError
+	require.True(t, errors.As(err, &volumeErr), "got %v", err)
+	assert.Equal(t, share, volumeErr.Volume)
+	assert.NoDirExists(t, filepath.Join(share, "Backups"))
+}
+
+func TestBackupSoftDeleteOnLocalRemote(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	target := filepath.Join(t.TempDir(), "Docs")
+
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	require.NoError(t, configManager.AddRemote(config.RemoteConfig{Name: "disk", Type: config.TypeLocal}))
+	require.NoError(t, configManager.GenerateRcloneConfig())
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, syncConfigMgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Docs", LocalPath: t.TempDir(), RemoteName: "disk", RemotePath: target, Direction: "upload",
+		Enabled: true, MaxDeletePercent: 100, SoftDelete: true,
+	}))
+
+	called := filepath.Join(home, "args")
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte("#!/bin/sh\necho \"$@\" >> "+called+"\n"), 0755))
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: rcloneBin,
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, manager.SyncPair("Docs", false, false))
+
+	// The trash stays in the folder, not relative to rclone's working directory
+	args, err := os.ReadFile(called)
+	require.NoError(t, err)
+	trash := filepath.Join(target, ".cloud-sync-trash", "Docs", time.Now().Format("2006-01-02"))
+	assert.Contains(t, string(args), "--backup-dir disk:"+trash+" ")
+	assert.Contains(t, string(args), "--exclude /.cloud-sync-trash/**")
+}
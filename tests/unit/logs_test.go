@@ -12,6 +12,7 @@ import (
 )
 
 func createTestLogFile(t *testing.T, path string, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
 	err := os.WriteFile(path, []byte(content), 0644)
 	require.NoError(t, err)
 }
@@ -359,3 +360,247 @@ func TestGetTodaysTransfersNoLogFile(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, transfers)
 }
+
+func TestLogsMissedRuns(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2026, 10, d, h, 0, 0, 0, time.Local) }
+	logTime := func(d, h, min int) time.Time { return time.Date(2026, 10, d, h, min, 0, 0, time.Local) }
+
+	expected := []time.Time{day(12, 10), day(13, 10), day(14, 10), day(15, 10)}
+	sessions := []logs.SyncSession{
+		{Type: "Automated", StartTime: logTime(12, 10, 0), EndTime: logTime(12, 10, 5), Success: true},
+		// 13th: asleep, ran on wake later the same day
+		{Type: "Automated", StartTime: logTime(13, 19, 30), EndTime: logTime(13, 19, 40), Success: true},
+		// 14th: failed
+		{Type: "Automated", StartTime: logTime(14, 10, 0), EndTime: logTime(14, 10, 1), Success: false},
+		// Manual runs do not count as scheduled runs
+		{Type: "Manual", StartTime: logTime(15, 11, 0), EndTime: logTime(15, 11, 5), Success: true},
+	}
+
+	missed := logs.MissedRuns(expected, sessions, day(15, 20))
+	require.Len(t, missed, 2)
+	assert.Equal(t, day(14, 10), missed[0].Expected)
+	assert.Equal(t, "failed", missed[0].Reason)
+	assert.Equal(t, day(15, 10), missed[1].Expected)
+	assert.Equal(t, "did not run", missed[1].Reason)
+}
+
+func TestLogsPairLogPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("/tmp/logs", "pairs", "documents.log"), logs.PairLogPath("/tmp/logs", "documents"))
+	assert.Regexp(t, `^my_photos_2024-[0-9a-f]{8}\.log$`, logs.PairLogName("my photos/2024"))
+
+	// Names that sanitize alike still get logs of their own
+	assert.NotEqual(t, logs.PairLogName("a/b"), logs.PairLogName("a_b"))
+	assert.NotEqual(t, logs.PairLogName("a/b"), logs.PairLogName("a:b"))
+	// and a pair named after the main log does not share it
+	assert.NotEqual(t, filepath.Join("/tmp/logs", "rclone_backup.log"), logs.PairLogPath("/tmp/logs", "rclone_backup"))
+}
+
+func TestLogsMovesLegacyPairLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestLogFile(t, filepath.Join(tmpDir, "Documents.log"),
+		"2024/11/03 10:00:00 INFO  : doc.txt: Copied (new)\n")
+
+	mgr := logs.NewManager(tmpDir)
+	mgr.AddPairs("Documents")
+
+	assert.NoFileExists(t, filepath.Join(tmpDir, "Documents.log"))
+	assert.FileExists(t, logs.PairLogPath(tmpDir, "Documents"))
+	assert.Empty(t, logs.LegacyPairLogPath(tmpDir, "rclone_backup"))
+	assert.Empty(t, logs.LegacyPairLogPath(tmpDir, "a/b"))
+}
+
+func TestLogsAggregatesPairLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestLogFile(t, filepath.Join(tmpDir, "rclone_backup.log"),
+		"2024/11/03 10:00:00 INFO  : main.txt: Copied (new)\n")
+	createTestLogFile(t, logs.PairLogPath(tmpDir, "docs"),
+		"2024/11/03 09:00:00 NOTICE: Manual Sync Requested\n"+
+			"2024/11/03 09:00:05 INFO  : report.pdf: Copied (new)\n"+
+			"2024/11/03 09:00:10 NOTICE: Manual Sync Complete: Success\n")
+	createTestLogFile(t, logs.PairLogPath(tmpDir, "photos"),
+		"2024/11/03 11:00:00 INFO  : a.jpg: Copied (new)\n"+
+			"2024/11/03 11:00:01 INFO  : b.jpg: Copied (new)\n")
+
+	manager := logs.NewManager(tmpDir)
+	manager.AddPairs("photos", "docs", "docs")
+	assert.Equal(t, []string{"docs", "photos"}, manager.Pairs())
+
+	transfers, err := manager.GetAllTransfers()
+	require.NoError(t, err)
+	require.Len(t, transfers, 4)
+	assert.Equal(t, "report.pdf", transfers[0].Filename)
+	assert.Equal(t, "docs", transfers[0].Pair)
+	assert.Equal(t, "main.txt", transfers[1].Filename)
+	assert.Empty(t, transfers[1].Pair)
+	assert.Equal(t, "photos", transfers[3].Pair)
+
+	sessions, err := manager.GetSyncSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "docs", sessions[0].Pair)
+	assert.True(t, sessions[0].Success)
+	assert.Equal(t, 1, sessions[0].Transfers)
+
+	photos, err := manager.ForPair("photos").GetAllTransfers()
+	require.NoError(t, err)
+	assert.Len(t, photos, 2)
+}
+
+func TestLogsLogPairEvent(t *testing.T) {
+	tmpDir := filepath.Join(t.TempDir(), "logs")
+	manager := logs.NewManager(tmpDir)
+
+	require.NoError(t, manager.LogPairEvent("docs", "Manual Sync Requested"))
+	require.NoError(t, manager.LogPairEvent("docs", "Manual Sync Complete: Failed"))
+
+	sessions, err := manager.ForPair("docs").GetSyncSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "Manual", sessions[0].Type)
+	assert.False(t, sessions[0].Success)
+	assert.False(t, sessions[0].EndTime.IsZero())
+
+	data, err := os.ReadFile(logs.PairLogPath(tmpDir, "docs"))
+	require.NoError(t, err)
+	assert.Regexp(t, `^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} [+-]\d{4} NOTICE: Manual Sync Requested\n`, string(data))
+}
+
+// inZone runs the rest of a test with the local zone set to zone
+func inZone(t *testing.T, zone *time.Location) {
+	local := time.Local
+	time.Local = zone
+	t.Cleanup(func() { time.Local = local })
+}
+
+func TestLogsTimestampZones(t *testing.T) {
+	inZone(t, time.FixedZone("CEST", 2*60*60))
+	tmpDir := t.TempDir()
+
+	// Markers written with their offset on a machine in UTC, rclone's lines
+	// in local time, and JSON lines from Tokyo
+	createTestLogFile(t, logs.PairLogPath(tmpDir, "docs"), `2026/10/15 08:00:00 +0000 NOTICE: Manual Sync Requested
+2026/10/15 10:00:05 INFO  : a.txt: Copied (new)
+{"time":"2026-10-15T17:00:06.123456+09:00","level":"info","msg":"Copied (new)","object":"b.txt","size":10}
+2026/10/15 08:00:10 +0000 NOTICE: Manual Sync Complete: Success
+`)
+	// A run in the local zone that started earlier, though its clock reads later
+	createTestLogFile(t, logs.PairLogPath(tmpDir, "photos"), `2026/10/15 09:30:00 +0200 NOTICE: Manual Sync Requested
+2026/10/15 09:29:00 +0200 NOTICE: Manual Sync Complete: Success
+`)
+
+	manager := logs.NewManager(tmpDir)
+	manager.AddPairs("docs", "photos")
+	sessions, err := manager.GetSyncSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	// Photos started at 07:30 UTC, docs at 08:00
+	assert.Equal(t, "photos", sessions[0].Pair)
+	docs := sessions[1]
+	assert.Equal(t, "docs", docs.Pair)
+	assert.True(t, docs.StartTime.Equal(time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 10, docs.StartTime.Hour(), "shown in local time")
+	assert.Equal(t, 10*time.Second, docs.Duration())
+	assert.Equal(t, 2, docs.Transfers)
+
+	// The clock was set back a minute during the photos run
+	assert.Equal(t, time.Duration(0), sessions[0].Duration())
+
+	transfers, err := manager.ForPair("docs").GetAllTransfers()
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	assert.True(t, transfers[0].Timestamp.Equal(time.Date(2026, 10, 15, 8, 0, 5, 0, time.UTC)))
+	assert.True(t, transfers[1].Timestamp.Equal(time.Date(2026, 10, 15, 8, 0, 6, 123456000, time.UTC)))
+
+	// Automated runs written in another zone still satisfy the schedule
+	expected := []time.Time{time.Date(2026, 10, 15, 3, 0, 0, 0, time.Local)}
+	createTestLogFile(t, filepath.Join(tmpDir, "rclone_backup.log"), `2026/10/15 01:00:30 +0000 INFO  : Automated Check Started
+2026/10/15 01:05:00 +0000 INFO  : Backup successful, timestamp updated
+`)
+	sessions, err = logs.NewManager(tmpDir).GetSyncSessions()
+	require.NoError(t, err)
+	assert.Empty(t, logs.MissedRuns(expected, sessions, time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)))
+}
+
+func TestLogsParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"512 B", 512},
+		{"0 B", 0},
+		{"10 KiB", 10 * 1024},
+		{"1.5 MiB", 1572864},
+		{"2 GiB", 2 << 30},
+		{"10.5k", 10752},
+		{"1.000 MBytes", 1 << 20},
+		{"42", 42},
+	}
+	for _, tt := range tests {
+		got, err := logs.ParseSize(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, bad := range []string{"", "MiB", "1.5 XB", "-1 B"} {
+		_, err := logs.ParseSize(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestLogsSizesFromStatsLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "test.log")
+
+	createTestLogFile(t, logPath, `2024/11/01 09:00:00 INFO  : Manual Sync Requested
+2024/11/01 09:00:30 INFO  : file1.txt: Copied (new)
+Transferred:   	    1.000 MiB / 3.000 MiB, 33%, 1 MiB/s, ETA 2s
+2024/11/01 09:01:00 INFO  : file2.txt: Copied (new)
+Transferred:   	    3.000 MiB / 3.000 MiB, 100%, 1 MiB/s, ETA 0s
+Transferred:            2 / 2, 100%
+2024/11/01 09:05:00 INFO  : Manual Sync Complete: Success
+2024/11/02 09:00:00 INFO  : Manual Sync Requested
+Transferred:   	      512 B / 512 B, 100%, 512 B/s, ETA 0s
+2024/11/02 09:05:00 INFO  : Manual Sync Complete: Success
+`)
+
+	manager := logs.NewManagerWithPath(logPath)
+	sessions, err := manager.GetSyncSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, int64(3<<20), sessions[0].Bytes)
+	assert.Equal(t, int64(512), sessions[1].Bytes)
+
+	stats, err := manager.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3<<20+512), stats.TotalSize)
+}
+
+func TestLogsSizesFromJSONLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "test.log")
+
+	createTestLogFile(t, logPath, `2024/11/01 09:00:00 NOTICE: Manual Sync Requested
+{"time":"2024-11-01T09:00:10.123456+00:00","level":"info","msg":"Copied (new)","object":"a.txt","objectType":"*local.Object","size":1000,"source":"operations/copy.go:368"}
+{"time":"2024-11-01T09:00:11.123456+00:00","level":"info","msg":"Copied (replaced existing)","object":"dir/b.txt","size":2500}
+{"time":"2024-11-01T09:00:12.123456+00:00","level":"info","msg":"Transferred: 3.418 KiB / 3.418 KiB","stats":{"bytes":3500,"transfers":2}}
+2024/11/01 09:00:13 NOTICE: Manual Sync Complete: Success
+`)
+
+	manager := logs.NewManagerWithPath(logPath)
+	transfers, err := manager.GetAllTransfers()
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	assert.Equal(t, "a.txt", transfers[0].Filename)
+	assert.Equal(t, int64(1000), transfers[0].Size)
+	assert.Equal(t, "dir/b.txt", transfers[1].Filename)
+
+	sessions, err := manager.GetSyncSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, 2, sessions[0].Transfers)
+	assert.Equal(t, int64(3500), sessions[0].Bytes)
+
+	stats, err := manager.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3500), stats.TotalSize)
+}
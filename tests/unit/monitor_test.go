@@ -0,0 +1,120 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+func TestValidateMonitorPair(t *testing.T) {
+	pair := syncconfig.SyncPair{Name: "Arq", LocalPath: t.TempDir(), Type: syncconfig.TypeMonitor, RemoteName: "b2", RemotePath: "bucket/arq"}
+	require.NoError(t, syncconfig.ValidateSyncPair(&pair))
+	assert.True(t, pair.IsMonitor())
+	assert.Equal(t, "b2:bucket/arq", pair.Target())
+	assert.Empty(t, pair.AllDestinations())
+
+	// Or a folder, but not both
+	pair.TargetPath = t.TempDir()
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "either a remote or a target path")
+	pair.RemoteName, pair.RemotePath = "", ""
+	require.NoError(t, syncconfig.ValidateSyncPair(&pair))
+	assert.Equal(t, pair.TargetPath, pair.Target())
+
+	pair.Direction = "upload"
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "no direction or mode")
+	pair.Direction = ""
+	pair.SoftDelete = true
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "never transfer files")
+	pair.SoftDelete = false
+	pair.ExtraFlags = []string{"--checksum"}
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "transfer settings")
+
+	pair = syncconfig.SyncPair{Name: "Arq", LocalPath: t.TempDir(), Type: syncconfig.TypeMonitor}
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "needs a remote name and path, or a target path")
+}
+
+func TestMonitorAgentPlist(t *testing.T) {
+	data, err := launchd.RenderPlist(launchd.MonitorConfig("com.tester.cloudsync-monitor", "/usr/local/bin/cloud-sync"))
+	require.NoError(t, err)
+	plist := string(data)
+	assert.Contains(t, plist, "<string>monitor</string>\n\t\t<string>--scheduled</string>")
+	assert.Contains(t, plist, "<key>Hour</key>\n\t\t\t<integer>5</integer>")
+	assert.NotContains(t, plist, "<key>Day</key>")
+	assert.Equal(t, "com.tester.cloudsync-monitor", launchd.NewManager("tester").Monitor().GetLabel())
+}
+
+func TestCLIMonitor(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	args := setupScrub(t, home)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, cli.Run([]string{"monitor"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "No enabled monitor pairs")
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:       "Arq",
+		LocalPath:  t.TempDir(),
+		Type:       syncconfig.TypeMonitor,
+		RemoteName: "b2",
+		RemotePath: "bucket/arq",
+		Enabled:    true,
+	}))
+	addPlainPair(t, "Documents", true)
+
+	// Drift is reported and flagged like a scrub's findings
+	stdout.Reset()
+	stderr.Reset()
+	assert.Equal(t, 1, cli.Run([]string{"monitor"}, &stdout, &stderr), stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Checking 'Arq'...\n  b2:bucket/arq: 1 differ, 1 missing at the destination, 1 only at the destination (of 4 files)\n")
+	assert.NotContains(t, out, "Documents")
+	assert.Contains(t, out, "cloud-sync scrub --ack")
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"status"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "Integrity: 'Arq' at b2:bucket/arq: 1 differ")
+
+	// Monitor pairs never transfer: naming one fails, --all leaves it out
+	stdout.Reset()
+	stderr.Reset()
+	assert.Equal(t, 1, cli.Run([]string{"sync", "Arq"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "only monitors b2:bucket/arq and never transfers files")
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"sync", "--all"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Synced 1 of 1 pair(s).")
+
+	data, err := os.ReadFile(args)
+	require.NoError(t, err)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		assert.False(t, strings.HasPrefix(line, "sync ") && strings.Contains(line, "bucket/arq"), "transferred to the monitored remote: %s", line)
+	}
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"pairs"}, &stdout, &stderr))
+	assert.Regexp(t, `Arq\s+monitor\s+check\s+yes`, stdout.String())
+}
+
+func TestSyncPairsWizardMonitor(t *testing.T) {
+	model := wizardAtRemotePath(t, fakeRclone(t, "exit 1\n"))
+	model = typeText(model, "bucket/arq")
+	require.Contains(t, model.View(), "4. monitor")
+	model = typeText(model, "4")
+	require.Contains(t, model.View(), "Skip hidden files?")
+	for range 3 {
+		model = typeText(model, "")
+	}
+	view := model.View()
+	assert.Contains(t, view, "New Sync Pair Summary", "monitor pairs have no flags to add")
+	assert.Contains(t, view, "Direction: monitor")
+	assert.Contains(t, view, "Mode: check")
+}
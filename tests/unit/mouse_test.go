@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// lineOf returns the first line of a view that contains text
+func lineOf(t *testing.T, view, text string) int {
+	t.Helper()
+	for i, line := range strings.Split(view, "\n") {
+		if strings.Contains(line, text) {
+			return i
+		}
+	}
+	t.Fatalf("%q not found in view", text)
+	return -1
+}
+
+func leftClick(y int) tea.MouseMsg {
+	return tea.MouseMsg{X: 4, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+}
+
+func TestMouseItemAtLine(t *testing.T) {
+	view := "Title\n\n> 1. One\n   detail\n\n  2. Two\n   detail\n\nfooter"
+	labels := []string{"1. One", "2. Two"}
+
+	for y, want := range map[int]int{2: 0, 3: 0, 4: 0, 5: 1, 7: 1} {
+		i, ok := views.ItemAtLine(view, y, labels)
+		assert.True(t, ok, "line %d", y)
+		assert.Equal(t, want, i, "line %d", y)
+	}
+	for _, y := range []int{-1, 0, 1, 8} {
+		_, ok := views.ItemAtLine(view, y, labels)
+		assert.False(t, ok, "line %d", y)
+	}
+
+	_, ok := views.ItemAtLine(view, 2, []string{"missing"})
+	assert.False(t, ok)
+}
+
+func TestMouseClickTracker(t *testing.T) {
+	var clicks views.ClickTracker
+	now := time.Now()
+
+	assert.False(t, clicks.Click(1, now))
+	assert.True(t, clicks.Click(1, now.Add(200*time.Millisecond)))
+	assert.False(t, clicks.Click(1, now.Add(300*time.Millisecond)), "third click starts over")
+	assert.False(t, clicks.Click(2, now.Add(400*time.Millisecond)), "different item")
+	assert.False(t, clicks.Click(2, now.Add(2*time.Second)), "too slow")
+}
+
+func TestMouseMainMenuClick(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var model tea.Model = ui.NewModel()
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	y := lineOf(t, model.View(), "3. Scheduling & Maintenance")
+	model, _ = model.Update(leftClick(y))
+	m := model.(ui.Model)
+	assert.Equal(t, 2, m.List.Index())
+	assert.Equal(t, ui.StateMainMenu, m.State)
+
+	model, _ = model.Update(leftClick(y))
+	m = model.(ui.Model)
+	assert.Equal(t, ui.StateLaunchdManager, m.State)
+	require.NotNil(t, m.ActiveView())
+}
+
+func TestMouseMainMenuIgnoresClicksOutsideItems(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var model tea.Model = ui.NewModel()
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	model, _ = model.Update(leftClick(0))
+	model, _ = model.Update(leftClick(0))
+	m := model.(ui.Model)
+	assert.Equal(t, 0, m.List.Index())
+	assert.Equal(t, ui.StateMainMenu, m.State)
+}
+
+func TestMouseLaunchdManagerClick(t *testing.T) {
+	var model tea.Model = views.NewLaunchdManagerModel(launchd.NewManager("tester"), 100, 40)
+	model, _ = model.Update(&launchd.Status{Loaded: true})
+
+	model, _ = model.Update(leftClick(lineOf(t, model.View(), "Refresh Status")))
+	view := model.View()
+	assert.Contains(t, strings.Split(view, "\n")[lineOf(t, view, "Refresh Status")], "> ")
+
+	// Clicks above the actions select nothing
+	model, _ = model.Update(leftClick(0))
+	view = model.View()
+	assert.Contains(t, strings.Split(view, "\n")[lineOf(t, view, "Refresh Status")], "> ")
+}
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/internal/uninstall"
+)
+
+func keyPress(s string) tea.KeyMsg {
+	switch s {
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// openSyncPairs opens the Sync Pairs view from the main menu
+func openSyncPairs(t *testing.T) tea.Model {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	var model tea.Model = ui.NewModel()
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	model, _ = model.Update(keyPress("down"))
+	model, cmd := model.Update(keyPress("enter"))
+	require.IsType(t, views.SyncPairsModel{}, model.(ui.Model).ActiveView())
+
+	// Deliver the sync pairs the view loads when it opens
+	for _, msg := range runBatch(cmd) {
+		model, _ = model.Update(msg)
+	}
+	return model
+}
+
+// press sends a key to the app and delivers the navigation messages the
+// views send back, like the program loop would
+func press(model tea.Model, key string) tea.Model {
+	model, cmd := model.Update(keyPress(key))
+	if cmd == nil {
+		return model
+	}
+	msg := cmd()
+	switch msg.(type) {
+	case views.BackMsg, views.DoneMsg, views.OpenViewMsg:
+		model, _ = model.Update(msg)
+	}
+	return model
+}
+
+func TestNavigationBackPopsOneLevel(t *testing.T) {
+	model := openSyncPairs(t)
+
+	trash := views.NewTrashModel(rclone.NewManager("rclone"), syncconfig.SyncPair{Name: "docs"})
+	model, cmd := model.Update(views.OpenViewMsg{View: trash})
+	m := model.(ui.Model)
+	assert.Equal(t, 2, m.Depth())
+	assert.IsType(t, views.TrashModel{}, m.ActiveView())
+
+	// The trash ignores keys until it has loaded
+	for _, msg := range runBatch(cmd) {
+		model, _ = model.Update(msg)
+	}
+	model = press(model, "esc")
+	m = model.(ui.Model)
+	assert.Equal(t, 1, m.Depth())
+	assert.IsType(t, views.SyncPairsModel{}, m.ActiveView())
+
+	model = press(model, "q")
+	m = model.(ui.Model)
+	assert.Equal(t, 0, m.Depth())
+	assert.Nil(t, m.ActiveView())
+	assert.Equal(t, ui.StateMainMenu, m.State)
+}
+
+func TestNavigationWizardStepHandlesBackItself(t *testing.T) {
+	model := openSyncPairs(t)
+
+	// q is typed into the name field rather than closing anything
+	model = press(model, "a")
+	model = press(model, "q")
+	require.Equal(t, 1, model.(ui.Model).Depth())
+	assert.Contains(t, model.View(), "Enter a name")
+
+	// esc leaves the wizard step, then closes the view
+	model = press(model, "esc")
+	require.Equal(t, 1, model.(ui.Model).Depth())
+	assert.Contains(t, model.View(), "No sync pairs configured")
+
+	model = press(model, "esc")
+	assert.Equal(t, 0, model.(ui.Model).Depth())
+}
+
+func TestNavigationDoneShowsMessageOnMainMenu(t *testing.T) {
+	model := openSyncPairs(t)
+
+	model, _ = model.Update(views.DoneMsg{Message: "Remote saved"})
+	m := model.(ui.Model)
+	assert.Equal(t, 0, m.Depth())
+	assert.Contains(t, m.View(), "Remote saved")
+}
+
+func TestSubViewsDoNotQuitTheApp(t *testing.T) {
+	for name, view := range map[string]tea.Model{
+		"log viewer":   views.NewLogViewerModel(logs.NewManager(t.TempDir()), views.LogViewAll, 80, 30),
+		"launchd":      views.NewLaunchdManagerModel(launchd.NewManager("tester"), 80, 30),
+		"launchd jobs": views.NewLaunchdJobsModel(launchd.NewManager("tester"), 80, 30),
+		"uninstall":    views.NewUninstallModel(uninstall.Options{}),
+	} {
+		_, cmd := view.Update(keyPress("q"))
+		require.NotNil(t, cmd, name)
+		assert.Equal(t, views.BackMsg{}, cmd(), name)
+	}
+}
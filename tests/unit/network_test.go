@@ -0,0 +1,202 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/network"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// fakeNetwork returns a checker whose tools report a default route through
+// gateway on en0, the given hardware port and Wi-Fi network, and whose
+// probe answers with page
+func fakeNetwork(t *testing.T, gateway, port, ssid, page string) *network.Checker {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name, script string) string {
+		bin := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\n"+script), 0755))
+		return bin
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(server.Close)
+
+	route := "echo '   route to: default'\necho '    gateway: " + gateway + "'\necho '  interface: en0'\n"
+	if gateway == "" {
+		route = "echo 'route: writing to routing socket: not in table'\nexit 1\n"
+	}
+	return &network.Checker{
+		RoutePath: write("route", route),
+		NetworksetupPath: write("networksetup", `case "$1" in
+-listallhardwareports) printf 'Hardware Port: `+port+`\nDevice: en0\nEthernet Address: aa:bb\n\nHardware Port: Thunderbolt Bridge\nDevice: bridge0\n' ;;
+-getairportnetwork) echo 'You are not associated with an AirPort network.' ;;
+esac
+`),
+		IpconfigPath: write("ipconfig", `[ "$1" = getsummary ] && echo '  SSID : `+ssid+`'
+exit 0
+`),
+		ProbeURL: server.URL,
+	}
+}
+
+const successPage = "<HTML><HEAD><TITLE>Success</TITLE></HEAD><BODY>Success</BODY></HTML>"
+
+func TestNetworkStatus(t *testing.T) {
+	status := fakeNetwork(t, "192.168.1.1", "Wi-Fi", "Home", successPage).Status()
+	assert.Equal(t, network.Status{
+		Known: true, Online: true, Interface: "en0", Port: "Wi-Fi", Gateway: "192.168.1.1", SSID: "Home",
+	}, status)
+
+	// An iPhone's hotspot is recognised by its router address
+	status = fakeNetwork(t, "172.20.10.1", "Wi-Fi", "iPhone", successPage).Status()
+	assert.True(t, status.Hotspot)
+	status = fakeNetwork(t, "172.20.10.1", "iPhone USB", "", successPage).Status()
+	assert.True(t, status.Hotspot)
+	assert.Empty(t, status.SSID)
+
+	status = fakeNetwork(t, "10.0.0.1", "Wi-Fi", "Cafe", "<html>Please sign in</html>").Status()
+	assert.True(t, status.Online)
+	assert.True(t, status.CaptivePortal)
+
+	status = fakeNetwork(t, "", "Wi-Fi", "", successPage).Status()
+	assert.Equal(t, network.Status{Known: true}, status)
+
+	// Without macOS's tools nothing is known
+	checker := network.NewChecker()
+	checker.RoutePath = filepath.Join(t.TempDir(), "missing")
+	assert.False(t, checker.Status().Known)
+}
+
+func TestNetworkStatusAllows(t *testing.T) {
+	home := network.Status{Known: true, Online: true, SSID: "Home"}
+	hotspot := network.Status{Known: true, Online: true, SSID: "iPhone", Hotspot: true}
+
+	assert.NoError(t, home.Allows(network.Rules{}))
+	assert.NoError(t, home.Allows(network.Rules{WiFiNetworks: []string{"Office", "Home"}, SkipOnHotspot: true}))
+	assert.NoError(t, hotspot.Allows(network.Rules{}))
+	assert.ErrorIs(t, hotspot.Allows(network.Rules{SkipOnHotspot: true}), network.ErrHotspot)
+	assert.EqualError(t, hotspot.Allows(network.Rules{WiFiNetworks: []string{"Home", "Office"}}),
+		"the Mac is not on Wi-Fi network Home or Office")
+
+	assert.ErrorIs(t, network.Status{Known: true}.Allows(network.Rules{}), network.ErrOffline)
+	assert.ErrorIs(t, network.Status{Known: true, Online: true, CaptivePortal: true}.Allows(network.Rules{}), network.ErrCaptivePortal)
+	assert.NoError(t, network.Status{}.Allows(network.Rules{WiFiNetworks: []string{"Home"}}))
+}
+
+func TestValidateNetworkRules(t *testing.T) {
+	pair := syncconfig.SyncPair{Name: "Docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket", Direction: "upload",
+		WiFiNetworks: []string{"Home"}, SkipOnHotspot: true}
+	require.NoError(t, syncconfig.ValidateSyncPair(&pair))
+
+	pair.WiFiNetworks = []string{" "}
+	assert.Error(t, syncconfig.ValidateSyncPair(&pair))
+
+	local := syncconfig.SyncPair{Name: "Mirror", LocalPath: t.TempDir(), Type: syncconfig.TypeLocal,
+		TargetPath: filepath.Join(t.TempDir(), "Documents"), Direction: "upload", SkipOnHotspot: true}
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&local), "network rules")
+}
+
+func TestBackupSkipsPairOnHotspot(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	called := filepath.Join(home, "called")
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:          "Documents",
+		LocalPath:     t.TempDir(),
+		RemoteName:    "b2",
+		RemotePath:    "bucket",
+		Direction:     "upload",
+		Enabled:       true,
+		SkipOnHotspot: true,
+	}))
+
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte("#!/bin/sh\ntouch "+called+"\n"), 0755))
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: rcloneBin,
+		Network:    fakeNetwork(t, "172.20.10.1", "Wi-Fi", "iPhone", successPage),
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	err = manager.SyncPair("Documents", false, false)
+	var networkErr *backup.NetworkError
+	require.True(t, errors.As(err, &networkErr), "got %v", err)
+	assert.ErrorIs(t, err, network.ErrHotspot)
+	assert.True(t, backup.Skipped(err))
+	assert.NoFileExists(t, called)
+
+	log, err := os.ReadFile(filepath.Join(home, "logs", "pairs", "Documents.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "Sync Skipped: the Mac is on a personal hotspot")
+
+	results, err := manager.SyncAllEnabled(false, false)
+	assert.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Skipped())
+}
+
+func TestBackupChecksNetworkOncePerRun(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	for _, name := range []string{"Documents", "Photos"} {
+		require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+			Name:          name,
+			LocalPath:     t.TempDir(),
+			RemoteName:    "b2",
+			RemotePath:    "bucket",
+			Direction:     "upload",
+			Enabled:       true,
+			SkipOnHotspot: true,
+		}))
+	}
+
+	// Count the network inspections
+	checker := fakeNetwork(t, "172.20.10.1", "Wi-Fi", "iPhone", successPage)
+	checks := filepath.Join(home, "checks")
+	route := filepath.Join(home, "route")
+	require.NoError(t, os.WriteFile(route, []byte("#!/bin/sh\necho >> "+checks+"\nexec "+checker.RoutePath+" \"$@\"\n"), 0755))
+	checker.RoutePath = route
+
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte("#!/bin/sh\n"), 0755))
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: rcloneBin,
+		Network:    checker,
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	results, err := manager.SyncAllEnabled(false, false)
+	assert.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Skipped())
+	assert.True(t, results[1].Skipped())
+
+	data, err := os.ReadFile(checks)
+	require.NoError(t, err)
+	assert.Equal(t, "\n", string(data))
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/orphans"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+// orphanListings is a fake rclone that lists a bucket with a deleted pair's
+// folder and trash next to the docs and work pairs, and a Drive folder with
+// last year's photos next to this year's. Deletions are logged to
+// $RCLONE_LOG.
+const orphanListings = `case "$1 $2" in
+"lsjson b2:bucket")
+	echo '[{"Name":"old","Size":-1,"IsDir":true},{"Name":"docs","Size":-1,"IsDir":true},{"Name":"notes.zip","Size":100,"IsDir":false},{"Name":"projects","Size":-1,"IsDir":true},{"Name":".cloud-sync-trash","Size":-1,"IsDir":true}]' ;;
+"lsjson b2:bucket/projects")
+	echo '[{"Name":"work","Size":-1,"IsDir":true},{"Name":"old-app","Size":-1,"IsDir":true}]' ;;
+"lsjson b2:bucket/.cloud-sync-trash")
+	echo '[{"Name":"docs","Size":-1,"IsDir":true},{"Name":"gone","Size":-1,"IsDir":true}]' ;;
+"lsjson gdrive:Photos")
+	echo '[{"Name":"2025","Size":-1,"IsDir":true},{"Name":"2026","Size":-1,"IsDir":true}]' ;;
+"size gdrive:Photos/2025") echo '{"bytes":9000}' ;;
+"size b2:bucket/old") echo '{"bytes":5000}' ;;
+"size b2:bucket/projects/old-app") echo '{"bytes":2000}' ;;
+"size b2:bucket/.cloud-sync-trash/gone") echo '{"bytes":300}' ;;
+purge*|deletefile*) echo "$1 $2" >> "$RCLONE_LOG" ;;
+*) exit 3 ;;
+esac
+`
+
+// orphanPairs sync into the fake rclone's bucket and Drive folder
+func orphanPairs(t *testing.T) []syncconfig.SyncPair {
+	return []syncconfig.SyncPair{
+		{Name: "docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true},
+		{Name: "work", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/projects/work/", Direction: "upload", Enabled: true},
+		{Name: "photos", LocalPath: t.TempDir(), Type: syncconfig.TypeMonitor, RemoteName: "gdrive", RemotePath: "Photos/2026", Enabled: true},
+		{Name: "mirror", LocalPath: t.TempDir(), Type: syncconfig.TypeLocal, TargetPath: t.TempDir(), Direction: "upload", Enabled: true},
+	}
+}
+
+func TestOrphansScan(t *testing.T) {
+	t.Setenv("RCLONE_LOG", filepath.Join(t.TempDir(), "rclone.log"))
+	manager := fakeRclone(t, orphanListings)
+
+	report, err := orphans.Scan(manager, nil, orphanPairs(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"b2:bucket", "gdrive:Photos"}, report.Buckets)
+	assert.Equal(t, []orphans.Orphan{
+		{RemoteName: "gdrive", Path: "Photos/2025", IsDir: true, Size: 9000},
+		{RemoteName: "b2", Path: "bucket/old", IsDir: true, Size: 5000},
+		{RemoteName: "b2", Path: "bucket/projects/old-app", IsDir: true, Size: 2000},
+		{RemoteName: "b2", Path: "bucket/.cloud-sync-trash/gone", IsDir: true, Size: 300},
+		{RemoteName: "b2", Path: "bucket/notes.zip", Size: 100},
+	}, report.Orphans)
+	assert.Equal(t, int64(16400), report.Size())
+
+	require.NoError(t, orphans.Remove(manager, nil, report.Orphans[1]))
+	require.NoError(t, orphans.Remove(manager, nil, report.Orphans[4]))
+	log, err := os.ReadFile(os.Getenv("RCLONE_LOG"))
+	require.NoError(t, err)
+	assert.Equal(t, "purge b2:bucket/old\ndeletefile b2:bucket/notes.zip\n", string(log))
+}
+
+func TestOrphansScanWholeBucket(t *testing.T) {
+	manager := fakeRclone(t, orphanListings)
+	pairs := []syncconfig.SyncPair{
+		{Name: "docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket", Direction: "upload", Enabled: true},
+	}
+
+	// Only the trash of the deleted pair is left beside a pair of the whole bucket
+	report, err := orphans.Scan(manager, nil, pairs)
+	require.NoError(t, err)
+	assert.Equal(t, []orphans.Orphan{{RemoteName: "b2", Path: "bucket/.cloud-sync-trash/gone", IsDir: true, Size: 300}}, report.Orphans)
+}
+
+func TestCLIOrphans(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	bin := filepath.Join(home, "bin")
+	require.NoError(t, os.MkdirAll(bin, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "rclone"), []byte("#!/bin/sh\n"+orphanListings), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	logPath := filepath.Join(home, "rclone.log")
+	t.Setenv("RCLONE_LOG", logPath)
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"orphans"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "No sync pairs with a remote configured.\n", stdout.String())
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	for _, pair := range orphanPairs(t) {
+		require.NoError(t, mgr.AddSyncPair(pair))
+	}
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"orphans"}, &stdout, &stderr), stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Scanned 2 bucket(s).\nNo pair syncs to 5 path(s), 16.0 KB in total:\n")
+	assert.Contains(t, out, "  gdrive:Photos/2025  8.8 KB\n")
+	assert.Contains(t, out, "Run 'cloud-sync orphans --clean' to delete them one by one.")
+	assert.NoFileExists(t, logPath)
+
+	// Without a terminal to ask on, everything is kept
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"orphans", "--clean"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Kept b2:bucket/old\n")
+	assert.Contains(t, stdout.String(), "\nDeleted 0 of 5, 0 B freed.\n")
+	assert.NoFileExists(t, logPath)
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"orphans", "--clean", "--yes"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Deleted b2:bucket/.cloud-sync-trash/gone\n")
+	assert.Contains(t, stdout.String(), "\nDeleted 5 of 5, 16.0 KB freed.\n")
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "purge gdrive:Photos/2025\n")
+	assert.Contains(t, string(log), "deletefile b2:bucket/notes.zip\n")
+}
+
+func TestOrphansScanSkipsLocalFolders(t *testing.T) {
+	t.Setenv("RCLONE_LOG", filepath.Join(t.TempDir(), "rclone.log"))
+	manager := fakeRclone(t, `echo "$1 $2" >> "$RCLONE_LOG"
+`+orphanListings)
+	conf, err := os.OpenFile(manager.GetConfigPath(), os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = conf.WriteString("\n[disk]\ntype = local\n")
+	require.NoError(t, err)
+	require.NoError(t, conf.Close())
+	pairs := []syncconfig.SyncPair{
+		{Name: "docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket", Direction: "upload", Enabled: true},
+		{Name: "nas", LocalPath: t.TempDir(), RemoteName: "disk", RemotePath: "/Volumes/NAS/Docs", Direction: "upload", Enabled: true},
+	}
+
+	// /Volumes is no bucket; its other drives must never be offered for deletion
+	report, err := orphans.Scan(manager, nil, pairs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b2:bucket"}, report.Buckets)
+	assert.Equal(t, []string{"disk:/Volumes/NAS/Docs"}, report.Skipped)
+	assert.Len(t, report.Orphans, 1)
+	log, err := os.ReadFile(os.Getenv("RCLONE_LOG"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(log), "disk:")
+}
@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/fileformat"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+func TestFileformatYAML(t *testing.T) {
+	data, err := fileformat.YAMLFromJSON([]byte(`{"name": "Docs", "version": "1.0", "enabled": true, "tags": ["a", "b"], "empty": []}`))
+	require.NoError(t, err)
+	assert.Equal(t, "name: Docs\nversion: \"1.0\"\nenabled: true\ntags:\n  - a\n  - b\nempty: []\n", string(data))
+
+	back, err := fileformat.JSONFromYAML(data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "Docs", "version": "1.0", "enabled": true, "tags": ["a", "b"], "empty": []}`, string(back))
+
+	_, err = fileformat.JSONFromYAML([]byte("1: one\n"))
+	assert.ErrorContains(t, err, "mapping keys must be strings")
+}
+
+func TestExportSyncPairs(t *testing.T) {
+	pairs := []syncconfig.SyncPair{{
+		Name: "Obsidian", LocalPath: "/Users/tester/ObsidianVault", RemoteName: "b2", RemotePath: "bucket/vault",
+		Direction: "upload", Enabled: true, Tags: []string{"notes"},
+	}}
+
+	data, err := syncconfig.Export(pairs, "/Users/tester", "yaml")
+	require.NoError(t, err)
+	assert.Equal(t, `version: 1
+pairs:
+  - name: Obsidian
+    local_path: ~/ObsidianVault
+    remote_name: b2
+    remote_path: bucket/vault
+    direction: upload
+    enabled: true
+    tags:
+      - notes
+`, string(data))
+
+	file, err := syncconfig.ParsePairFile(data)
+	require.NoError(t, err)
+	assert.Equal(t, "~/ObsidianVault", file.Pairs[0].LocalPath)
+
+	_, err = syncconfig.Export(pairs, "/Users/tester", "xml")
+	assert.ErrorContains(t, err, "invalid format")
+	_, err = syncconfig.ParsePairFile([]byte("version: 1\npairs:\n  - name: Docs\n    local_pth: ~/Docs\n"))
+	assert.ErrorContains(t, err, `unknown field "local_pth"`)
+	_, err = syncconfig.ParsePairFile([]byte("version: 1\npairs: []\n"))
+	assert.ErrorContains(t, err, "no pairs")
+	_, err = syncconfig.ParsePairFile([]byte("version: 2\npairs: []\n"))
+	assert.ErrorContains(t, err, "newer")
+}
+
+func TestImportSyncPairs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	mgr := syncconfig.NewManager(filepath.Join(home, "sync-config.json"))
+	docs := syncconfig.SyncPair{Name: "Docs", LocalPath: "/data/docs", RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true}
+	require.NoError(t, mgr.AddSyncPair(docs))
+
+	changed := docs
+	changed.RemotePath = "bucket/documents"
+	photos := syncconfig.SyncPair{Name: "Photos", LocalPath: "~/Pictures", RemoteName: "b2", RemotePath: "bucket/photos", Direction: "upload"}
+	sameFolder := syncconfig.SyncPair{Name: "Papers", LocalPath: "/data/docs", RemoteName: "s3", RemotePath: "papers", Direction: "upload"}
+
+	// Invalid pairs and conflicts under the fail policy import nothing
+	_, err := mgr.Import([]syncconfig.SyncPair{photos, {Name: "Broken", RemoteName: "b2"}}, syncconfig.ConflictSkip, false)
+	assert.ErrorContains(t, err, "pair 'Broken': local path cannot be empty")
+	_, err = mgr.Import([]syncconfig.SyncPair{photos, changed}, syncconfig.ConflictFail, false)
+	assert.ErrorContains(t, err, "a different sync pair named 'Docs' already exists")
+	pairs, err := mgr.ListSyncPairs()
+	require.NoError(t, err)
+	assert.Len(t, pairs, 1)
+
+	// A dry run shows the result without saving it
+	results, err := mgr.Import([]syncconfig.SyncPair{docs, photos}, syncconfig.ConflictFail, true)
+	require.NoError(t, err)
+	assert.Equal(t, []syncconfig.ImportResult{
+		{Name: "Docs", Action: syncconfig.ImportUnchanged},
+		{Name: "Photos", Action: syncconfig.ImportAdded},
+	}, results)
+	pairs, err = mgr.ListSyncPairs()
+	require.NoError(t, err)
+	assert.Len(t, pairs, 1)
+
+	// Renaming a pair does not stop its folder from being synced twice
+	laptop := changed
+	laptop.LocalPath = "/data/laptop-docs"
+	results, err = mgr.Import([]syncconfig.SyncPair{changed, sameFolder, photos}, syncconfig.ConflictRename, false)
+	require.NoError(t, err)
+	assert.Equal(t, []syncconfig.ImportResult{
+		{Name: "Docs", Action: syncconfig.ImportSkipped, Detail: "local path '/data/docs' is already synced by 'Docs'"},
+		{Name: "Papers", Action: syncconfig.ImportSkipped, Detail: "local path '/data/docs' is already synced by 'Docs'"},
+		{Name: "Photos", Action: syncconfig.ImportAdded},
+	}, results)
+	results, err = mgr.Import([]syncconfig.SyncPair{laptop}, syncconfig.ConflictRename, false)
+	require.NoError(t, err)
+	assert.Equal(t, []syncconfig.ImportResult{{Name: "Docs", Action: syncconfig.ImportRenamed, Detail: "Docs-2"}}, results)
+	pairs, err = mgr.ListSyncPairs()
+	require.NoError(t, err)
+	require.Len(t, pairs, 3)
+	assert.Equal(t, filepath.Join(home, "Pictures"), pairs[1].LocalPath, "~ is the home of this machine")
+	assert.Equal(t, "/data/laptop-docs", pairs[2].LocalPath)
+
+	// Replacing the pair of the same folder removes it
+	results, err = mgr.Import([]syncconfig.SyncPair{sameFolder}, syncconfig.ConflictReplace, false)
+	require.NoError(t, err)
+	assert.Equal(t, []syncconfig.ImportResult{{Name: "Papers", Action: syncconfig.ImportReplaced, Detail: "replaces 'Docs'"}}, results)
+	pairs, err = mgr.ListSyncPairs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Photos", "Docs-2", "Papers"}, []string{pairs[0].Name, pairs[1].Name, pairs[2].Name})
+}
+
+func TestCLIPairsExportImport(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Obsidian", LocalPath: filepath.Join(home, "ObsidianVault"), RemoteName: "b2", RemotePath: "bucket/vault", Direction: "upload",
+	}))
+	addTaggedPair(t, "Photos", true, "", "photos")
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, 2, cli.Run([]string{"pairs", "export", "--format", "xml"}, &stdout, &stderr))
+	require.Equal(t, 0, cli.Run([]string{"pairs", "export", "Obsidian"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "local_path: ~/ObsidianVault\n")
+	assert.NotContains(t, stdout.String(), "Photos")
+
+	file := filepath.Join(t.TempDir(), "pairs.yaml")
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"pairs", "export", "--tag", "photos", "-o", file}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Exported 1 sync pair(s) to "+file+"\n", stdout.String())
+
+	// The other machine has its own home and pairs
+	t.Setenv("HOME", t.TempDir())
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"pairs", "import", "--dry-run", file}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Photos: added\nDry run: nothing was saved.\n", stdout.String())
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"pairs", "import", file}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Photos: added\n", stdout.String())
+
+	require.NoError(t, os.WriteFile(file, []byte("version: 1\npairs:\n  - name: Photos\n    local_path: /elsewhere\n    remote_name: b2\n    remote_path: p\n    direction: upload\n"), 0644))
+	stderr.Reset()
+	assert.Equal(t, 1, cli.Run([]string{"pairs", "import", file}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "a different sync pair named 'Photos' already exists")
+	assert.Contains(t, stderr.String(), "--on-conflict")
+	assert.Equal(t, 2, cli.Run([]string{"pairs", "import", "--on-conflict", "merge", file}, &stdout, &stderr))
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"pairs", "import", "--on-conflict", "skip", file}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Photos: skipped, a different sync pair named 'Photos' already exists\n", stdout.String())
+}
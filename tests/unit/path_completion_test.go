@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestCompletePath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Documents", "Desktop", "Downloads", ".hidden", "Music"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dossier.txt"), nil, 0644))
+
+	assert.Equal(t, []string{dir + "/Desktop/", dir + "/Documents/", dir + "/Downloads/"}, views.CompletePath(dir+"/D"))
+	assert.Equal(t, []string{dir + "/Documents/"}, views.CompletePath(dir+"/doc"), "case-insensitive")
+	assert.Equal(t, []string{dir + "/.hidden/"}, views.CompletePath(dir+"/."))
+	assert.Len(t, views.CompletePath(dir+"/"), 4, "hidden folders and files are left out")
+	assert.Empty(t, views.CompletePath(dir+"/missing/"))
+	assert.Empty(t, views.CompletePath(""))
+}
+
+func TestCompleteFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "keys"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key.JSON"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key.txt"), nil, 0600))
+
+	assert.Equal(t, []string{dir + "/key.JSON", dir + "/keys/"}, views.CompleteFile(dir+"/k", ".json"))
+	assert.Equal(t, []string{dir + "/keys/"}, views.CompletePath(dir+"/k"), "folders only")
+}
+
+func TestCompletePathKeepsTilde(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.Mkdir(filepath.Join(home, "Pictures"), 0755))
+
+	assert.Equal(t, []string{"~/Pictures/"}, views.CompletePath("~/Pic"))
+	assert.Equal(t, []string{"~/Pictures/"}, views.CompletePath("~"))
+
+	path, err := views.ExpandHome("~/Pictures")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "Pictures"), path)
+}
+
+func TestSyncPairsWizardCompletesLocalPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "Documents", "Projects"), 0755))
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+
+	var model tea.Model = views.NewSyncPairsModel(mgr, rclone.NewManager("rclone"))
+	model, _ = model.Update(keyPress("a"))
+	model = typeText(model, "docs")
+	assert.Contains(t, model.View(), "1. ~/Documents")
+
+	// A shortcut fills the field and lists its subdirectories
+	model, _ = model.Update(keyPress("1"))
+	assert.Contains(t, model.View(), "~/Documents/Projects/")
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	model = typeText(model, "")
+	assert.Contains(t, model.View(), "Enter the rclone remote name")
+
+	// The stored path has ~ expanded
+	model = typeText(model, "b2")
+	model = typeText(model, "bucket")
+	model = typeText(model, "2")
+	model = typeText(model, "1")
+	for range 5 {
+		model = typeText(model, "")
+	}
+	_ = typeText(model, "")
+	pair, err := mgr.GetSyncPair("docs")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "Documents", "Projects"), pair.LocalPath)
+}
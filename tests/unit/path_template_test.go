@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/orphans"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+func TestExpandRemotePath(t *testing.T) {
+	vars := syncconfig.PathVars{Hostname: "studio", User: "tester", Date: time.Date(2026, 10, 15, 23, 0, 0, 0, time.Local)}
+	tests := []struct {
+		path, want string
+	}{
+		{"bucket/docs", "bucket/docs"},
+		{"bucket/{hostname}/docs", "bucket/studio/docs"},
+		{"{user}-backups/{hostname}/{date}", "tester-backups/studio/2026-10-15"},
+		{"bucket/{hostname}-{user}", "bucket/studio-tester"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, syncconfig.ExpandRemotePath(tt.path, vars), tt.path)
+	}
+
+	pair := syncconfig.SyncPair{
+		Name: "Docs", RemoteName: "b2", RemotePath: "bucket/{hostname}/docs",
+		Destinations: []syncconfig.Destination{{RemoteName: "s3", RemotePath: "mirror/{user}"}},
+	}
+	assert.Equal(t, []syncconfig.Destination{
+		{RemoteName: "b2", RemotePath: "bucket/studio/docs"},
+		{RemoteName: "s3", RemotePath: "mirror/tester"},
+	}, pair.Expand(vars).AllDestinations())
+	assert.Equal(t, "mirror/{user}", pair.Destinations[0].RemotePath, "the pair is not changed")
+
+	assert.Equal(t, "bucket", syncconfig.StaticPrefix("bucket/{hostname}/docs"))
+	assert.Equal(t, "", syncconfig.StaticPrefix("{user}-backups/docs"))
+	assert.Equal(t, "bucket/docs", syncconfig.StaticPrefix("bucket/docs"))
+}
+
+func TestValidateSyncPairPathVariables(t *testing.T) {
+	pair := syncconfig.SyncPair{Name: "Docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/{host}/docs", Direction: "upload"}
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "unknown variable {host} in remote path 'bucket/{host}/docs'; use {hostname}, {user} or {date}")
+
+	pair.RemotePath = "bucket/{hostname}/docs"
+	pair.Destinations = []syncconfig.Destination{{RemoteName: "s3", RemotePath: "mirror/{USER}"}}
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "unknown variable {USER}")
+
+	pair.Destinations[0].RemotePath = "mirror/{user}/{date}"
+	assert.NoError(t, syncconfig.ValidateSyncPair(&pair))
+}
+
+func TestCLISyncExpandsPathVariables(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	docs := t.TempDir()
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Docs", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/{hostname}/{user}/docs", Direction: "upload", Enabled: true,
+	}))
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"sync", "Docs"}, &stdout, &stderr), stdout.String())
+	vars := syncconfig.CurrentPathVars()
+	assert.Contains(t, simulatedCommands(), "sync "+docs+" b2:bucket/"+vars.Hostname+"/"+vars.User+"/docs")
+
+	// The configuration keeps the variables for the next run and machine
+	pair, err := mgr.GetSyncPair("Docs")
+	require.NoError(t, err)
+	assert.Equal(t, "bucket/{hostname}/{user}/docs", pair.RemotePath)
+}
+
+func TestOrphansScanKeepsOtherMachinesPaths(t *testing.T) {
+	manager := fakeRclone(t, orphanListings)
+	pairs := []syncconfig.SyncPair{
+		{Name: "docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/{hostname}/docs", Direction: "upload", Enabled: true},
+	}
+
+	// Every folder in the bucket may be another Mac's
+	report, err := orphans.Scan(manager, nil, pairs)
+	require.NoError(t, err)
+	assert.Equal(t, []orphans.Orphan{{RemoteName: "b2", Path: "bucket/.cloud-sync-trash/gone", IsDir: true, Size: 300}}, report.Orphans)
+}
@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addPlainPair saves a sync pair under the current HOME
+func addPlainPair(t *testing.T, name string, enabled bool) {
+	t.Helper()
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:       name,
+		LocalPath:  t.TempDir(),
+		RemoteName: "b2",
+		RemotePath: "bucket/" + name,
+		Direction:  "upload",
+		Enabled:    enabled,
+	}))
+}
+
+func TestCLIPairsList(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, cli.Run([]string{"pairs"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "No sync pairs configured.")
+
+	addPlainPair(t, "Documents", true)
+	addPlainPair(t, "Photos", false)
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"pairs", "--plain"}, &stdout, &stderr), stderr.String())
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "NAME"))
+	assert.Contains(t, lines[1], "Documents")
+	assert.Contains(t, lines[1], "b2:bucket/Documents")
+	assert.Contains(t, lines[2], "no")
+}
+
+func TestCLISyncRequiresPairOrAll(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, 2, cli.Run([]string{"sync"}, &stdout, &stderr))
+	assert.Equal(t, 2, cli.Run([]string{"sync", "--all", "Documents"}, &stdout, &stderr))
+}
+
+func TestCLIStatusIsPlainText(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	addPlainPair(t, "Documents", true)
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, cli.Run([]string{"status"}, &stdout, &stderr), stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Sync pairs: 1 (1 enabled)")
+	assert.Contains(t, out, "Last run: never")
+	assert.NotContains(t, out, "\x1b[")
+	assert.NotContains(t, out, "✓")
+}
+
+func TestPlainMenu(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	addPlainPair(t, "Documents", true)
+	var stdout, stderr bytes.Buffer
+
+	code := cli.RunPlain(strings.NewReader("1\n3\nx\nq\n"), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	out := stdout.String()
+	assert.Contains(t, out, "1) List sync pairs")
+	assert.Contains(t, out, "Documents")
+	assert.Contains(t, out, "Sync pairs: 1 (1 enabled)")
+	assert.Contains(t, out, "Unknown option 'x'.")
+
+	// End of input quits too
+	stdout.Reset()
+	assert.Equal(t, 0, cli.RunPlain(strings.NewReader(""), &stdout, &stderr))
+}
+
+func TestWantsPlain(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	assert.True(t, cli.WantsPlain([]string{"--plain"}))
+	assert.False(t, cli.WantsPlain(nil))
+	assert.True(t, cli.IsCommand([]string{"--plain", "status"}))
+	assert.False(t, cli.IsCommand([]string{"--plain"}))
+	// like --quiet, --plain is accepted anywhere
+	assert.True(t, cli.WantsPlain([]string{"--simulate", "--plain"}))
+	assert.True(t, cli.IsCommand([]string{"status", "--plain"}))
+	rest, plain := cli.TakePlainFlag([]string{"sync", "--plain", "Documents"})
+	assert.True(t, plain)
+	assert.Equal(t, []string{"sync", "Documents"}, rest)
+
+	t.Setenv("TERM", "dumb")
+	assert.True(t, cli.WantsPlain(nil))
+}
+
+func TestCLIConfigTemplates(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"config", "templates"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "sync_now.sh")
+	assert.Contains(t, stdout.String(), "built-in")
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"config", "templates", "--export"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Wrote "+home+"/.config/cloud-sync/templates/sync_now.sh.tmpl")
+	assert.NotContains(t, stdout.String(), "built-in")
+}
@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// versionedRclone returns a fake rclone whose config also holds an S3 remote
+// and an SFTP server
+func versionedRclone(t *testing.T, body string) *rclone.Manager {
+	t.Helper()
+	mgr := fakeRclone(t, body)
+	conf := "[b2]\ntype = b2\naccount = id\nkey = secret\n\n[aws]\ntype = s3\nprovider = AWS\n\n[nas]\ntype = sftp\nhost = nas.local\n"
+	require.NoError(t, os.WriteFile(mgr.GetConfigPath(), []byte(conf), 0600))
+	return mgr
+}
+
+func TestCheckPointInTime(t *testing.T) {
+	mgr := versionedRclone(t, `case "$1 $2 $3" in
+"version"*) echo "rclone v1.68.0" ;;
+"backend versioning aws:versioned") echo Enabled ;;
+"backend versioning aws:plain") echo Unversioned ;;
+esac
+exit 0
+`)
+
+	assert.NoError(t, mgr.CheckPointInTime("b2", "photos/2026"))
+	assert.NoError(t, mgr.CheckPointInTime("aws", "versioned/docs"))
+	assert.EqualError(t, mgr.CheckPointInTime("aws", "plain/docs"), "versioning is not enabled on bucket 'plain', so it keeps no old versions to restore")
+	assert.EqualError(t, mgr.CheckPointInTime("nas", "backups"), "point-in-time restore needs a B2 or S3 remote, and 'nas' is sftp")
+
+	old := versionedRclone(t, `echo "rclone v1.60.0"`)
+	var versionErr *rclone.VersionError
+	require.ErrorAs(t, old.CheckPointInTime("b2", "photos"), &versionErr)
+	assert.Equal(t, rclone.FeatureVersionAt, versionErr.Feature)
+}
+
+func TestRestoreAt(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	mgr := versionedRclone(t, `echo "$@" >> `+calls+`
+[ "$1" = lsjson ] && echo '[{"Path":"a.txt","Size":10},{"Path":"b.txt","Size":20}]'
+exit 0
+`)
+	at := time.Date(2026, 10, 1, 14, 30, 0, 0, time.UTC)
+	local := filepath.Join(t.TempDir(), "docs")
+
+	sizes, err := mgr.FileSizesAt("aws", "bucket/docs", at)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 20}, sizes)
+	require.NoError(t, mgr.RestoreAt("b2", "bucket/docs", local, at, rclone.RestoreTuning{Transfers: 8}))
+	assert.DirExists(t, local)
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "--s3-version-at=2026-10-01T14:30:00Z")
+	assert.Equal(t, "copy b2:bucket/docs "+local+" --b2-version-at=2026-10-01T14:30:00Z --config "+mgr.GetConfigPath()+" --transfers 8", lines[1])
+
+	_, err = mgr.FileSizesAt("nas", "backups", at)
+	assert.EqualError(t, err, "point-in-time restore needs a B2 or S3 remote, and 'nas' is sftp")
+}
+
+func TestPointInTimeView(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	calls := filepath.Join(home, "calls")
+	mgr := versionedRclone(t, `case "$1" in
+version) echo "rclone v1.68.0" ;;
+lsjson) echo '[{"Path":"a.txt","Size":1048576},{"Path":"b.txt","Size":1048576}]' ;;
+copy) echo "$@" > `+calls+` ;;
+esac
+exit 0
+`)
+	local := filepath.Join(home, "docs")
+	pair := syncconfig.SyncPair{Name: "docs", LocalPath: local, RemoteName: "b2", RemotePath: "bucket/docs"}
+
+	model := openPointInTime(mgr, pair)
+	assert.Contains(t, model.View(), "Restore as of:")
+
+	// Times must be dates in the past
+	model = typeText(model, "yesterday")
+	assert.Contains(t, model.View(), "'yesterday' is not a date like 2026-10-01 or 2026-10-01 14:30")
+	for range "yesterday" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	model = typeText(model, "2099-01-01")
+	assert.Contains(t, model.View(), "2099-01-01 is not in the past")
+	for range "2099-01-01" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+
+	// The folder as it was is listed before restoring
+	model = typeText(model, "2026-10-01 14:30")
+	view := model.View()
+	assert.Contains(t, view, "At 2026-10-01 14:30, b2:bucket/docs held 2 files, 2.0 MB.")
+	assert.Contains(t, view, "Restore them into "+local+" with 2 files at once, 4 streams per large file?")
+
+	model, cmd := model.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+	assert.Contains(t, model.View(), "Restored b2:bucket/docs as of 2026-10-01 14:30 into "+local)
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	at := time.Date(2026, 10, 1, 14, 30, 0, 0, time.Local).Format(time.RFC3339)
+	assert.Contains(t, string(data), "copy b2:bucket/docs "+local+" --b2-version-at="+at)
+}
+
+func TestPointInTimeViewRefusesUnversionedRemotes(t *testing.T) {
+	mgr := versionedRclone(t, "exit 0\n")
+	model := openPointInTime(mgr, syncconfig.SyncPair{Name: "nas", LocalPath: t.TempDir(), RemoteName: "nas", RemotePath: "backups"})
+	assert.Contains(t, model.View(), "point-in-time restore needs a B2 or S3 remote, and 'nas' is sftp")
+	assert.NotContains(t, model.View(), "Restore as of:")
+}
+
+// openPointInTime opens a point-in-time restore and runs its remote check
+func openPointInTime(mgr *rclone.Manager, pair syncconfig.SyncPair) tea.Model {
+	var model tea.Model = views.NewPointInTimeModel(mgr, pair)
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+	return model
+}
@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/power"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+const (
+	onBattery = "Now drawing from 'Battery Power'\n -InternalBattery-0 (id=4325475)\t15%; discharging; 0:42 remaining present: true\n"
+	onAC      = "Now drawing from 'AC Power'\n -InternalBattery-0 (id=4325475)\t15%; charging; 1:10 remaining present: true\n"
+)
+
+func TestParseBatt(t *testing.T) {
+	assert.Equal(t, power.Status{Known: true, OnBattery: true, Percent: 15}, power.ParseBatt(onBattery))
+	assert.Equal(t, power.Status{Known: true, Percent: 15}, power.ParseBatt(onAC))
+
+	// Desktop Macs have no battery
+	assert.Equal(t, power.Status{Known: true, Percent: -1}, power.ParseBatt("Now drawing from 'AC Power'\n"))
+	assert.False(t, power.ParseBatt("").Known)
+}
+
+func TestPowerStatusDefers(t *testing.T) {
+	low := power.ParseBatt(onBattery)
+	assert.True(t, low.Defers(30))
+	assert.False(t, low.Defers(15))
+	assert.False(t, low.Defers(0))
+	assert.False(t, power.ParseBatt(onAC).Defers(30))
+	assert.False(t, power.Status{}.Defers(30))
+}
+
+func TestBackupDefersScheduledRunOnBattery(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	args, synced := filepath.Join(home, "args"), filepath.Join(home, "synced")
+	source := t.TempDir()
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:              "Mirror",
+		LocalPath:         source,
+		Type:              syncconfig.TypeLocal,
+		TargetPath:        filepath.Join(t.TempDir(), "Documents"),
+		Direction:         "upload",
+		Enabled:           true,
+		MinBatteryPercent: 30,
+	}))
+
+	batt := filepath.Join(home, "batt")
+	require.NoError(t, os.WriteFile(batt, []byte(onBattery), 0644))
+	pmset := filepath.Join(home, "pmset")
+	require.NoError(t, os.WriteFile(pmset, []byte("#!/bin/sh\ncat "+batt+"\n"), 0755))
+
+	manager, err := backup.NewManager(&backup.Config{
+		Username:  "tester",
+		HomeDir:   home,
+		RsyncPath: fakeRsync(t, args, synced),
+		Power:     &power.Checker{PmsetPath: pmset},
+		LogDir:    filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	// Manual runs ignore the battery
+	require.NoError(t, manager.SyncPair("Mirror", false, false))
+	require.NoError(t, os.Remove(synced))
+
+	manager.SetScheduled(true)
+	err = manager.SyncPair("Mirror", false, false)
+	var powerErr *backup.PowerError
+	require.True(t, errors.As(err, &powerErr), "got %v", err)
+	assert.Equal(t, backup.PowerError{Pair: "Mirror", Percent: 15, Min: 30}, *powerErr)
+	assert.True(t, backup.Skipped(err))
+	assert.NoFileExists(t, synced)
+	deferred, err := manager.DeferredPairs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Mirror"}, deferred)
+
+	log, err := os.ReadFile(filepath.Join(home, "logs", "pairs", "Mirror.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "Sync Deferred: on battery at 15%")
+
+	// Once on power the pair syncs and is no longer deferred
+	require.NoError(t, os.WriteFile(batt, []byte(onAC), 0644))
+	require.NoError(t, manager.SyncPair("Mirror", false, false))
+	assert.FileExists(t, synced)
+	deferred, err = manager.DeferredPairs()
+	require.NoError(t, err)
+	assert.Empty(t, deferred)
+}
+
+func TestCLISyncDeferredWithoutDeferredPairs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"sync", "--scheduled", "--deferred"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "No pairs are waiting for power.\n", stdout.String())
+	assert.Equal(t, 2, cli.Run([]string{"sync", "--deferred", "Mirror"}, &stdout, &stderr))
+}
+
+func TestPowerAgentPlist(t *testing.T) {
+	data, err := launchd.RenderPlist(launchd.PowerConfig("com.tester.cloudsync-power", "/usr/local/bin/cloud-sync"))
+	require.NoError(t, err)
+	plist := string(data)
+	assert.Contains(t, plist, "<string>sync</string>\n\t\t<string>--scheduled</string>\n\t\t<string>--deferred</string>")
+	assert.Contains(t, plist, "<key>LaunchEvents</key>")
+	assert.Contains(t, plist, "<key>com.apple.notifyd.matching</key>")
+	assert.Contains(t, plist, "<string>com.apple.system.powersources.source</string>")
+	assert.NotContains(t, plist, "StartCalendarInterval")
+	assert.Equal(t, "com.tester.cloudsync-power", launchd.NewManager("tester").Power().GetLabel())
+}
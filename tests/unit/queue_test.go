@@ -0,0 +1,223 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/queue"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+// livePID is a process that outlives the tests, standing in for another
+// cloud-sync process running the queue
+const livePID = 1
+
+// queuedPairs returns the targets of the jobs in order
+func queuedPairs(t *testing.T, q *queue.Queue) []string {
+	t.Helper()
+	jobs, err := q.Jobs()
+	require.NoError(t, err)
+	names := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		names = append(names, job.Target())
+	}
+	return names
+}
+
+func TestQueueAddOrdersByPriority(t *testing.T) {
+	q := queue.New(t.TempDir())
+	for _, job := range []queue.Job{
+		{Pair: "Nightly", Priority: queue.Low, Trigger: queue.TriggerScheduled},
+		{Pair: "Photos", Priority: queue.High, Trigger: queue.TriggerManual},
+		{Pair: "Drive", Priority: queue.Normal, Trigger: queue.TriggerMount},
+		{Pair: "Music", Priority: queue.Low, Trigger: queue.TriggerScheduled},
+		{Pair: "Photos", Subpath: "2024", Priority: queue.High, Trigger: queue.TriggerManual},
+	} {
+		_, added, err := q.Add(job)
+		require.NoError(t, err)
+		assert.True(t, added)
+	}
+	assert.Equal(t, []string{"Photos", "2024 of Photos", "Drive", "Nightly", "Music"}, queuedPairs(t, q))
+
+	// A pair already waiting is not queued twice, but moves up for a more
+	// urgent trigger
+	job, added, err := q.Add(queue.Job{Pair: "Music", Priority: queue.Normal, Trigger: queue.TriggerPower})
+	require.NoError(t, err)
+	assert.False(t, added)
+	assert.Equal(t, queue.TriggerScheduled, job.Trigger)
+	assert.Equal(t, queue.Normal, job.Priority)
+	assert.Equal(t, []string{"Photos", "2024 of Photos", "Drive", "Music", "Nightly"}, queuedPairs(t, q))
+
+	_, added, err = q.Add(queue.Job{Pair: "Photos", Priority: queue.Low})
+	require.NoError(t, err)
+	assert.False(t, added)
+	assert.Len(t, queuedPairs(t, q), 5)
+}
+
+func TestQueueClaim(t *testing.T) {
+	q := queue.New(t.TempDir())
+	for _, pair := range []string{"A", "B"} {
+		_, _, err := q.Add(queue.Job{Pair: pair, Priority: queue.High})
+		require.NoError(t, err)
+	}
+
+	job, ok, err := q.Claim(livePID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "A", job.Pair)
+	assert.False(t, job.Started.IsZero())
+
+	// Another process leaves the queue to the one running it
+	_, ok, err = q.Claim(os.Getpid())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// A running pair can be queued again, since it may have changed since
+	_, added, err := q.Add(queue.Job{Pair: "A", Priority: queue.Low})
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	require.NoError(t, q.Finish(job.ID))
+	job, ok, err = q.Claim(os.Getpid())
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "B", job.Pair)
+	require.NoError(t, q.Finish(job.ID))
+
+	// A job whose process died is dropped
+	dead := exec.Command("true")
+	require.NoError(t, dead.Run())
+	job, ok, err = q.Claim(dead.Process.Pid)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Empty(t, queuedPairs(t, q))
+	_, ok, err = q.Claim(os.Getpid())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestQueueMoveAndCancel(t *testing.T) {
+	q := queue.New(t.TempDir())
+	var ids []int
+	for _, pair := range []string{"Running", "A", "B", "C"} {
+		job, _, err := q.Add(queue.Job{Pair: pair, Priority: queue.Normal})
+		require.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+	_, _, err := q.Claim(livePID)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Move(ids[3], -1))
+	assert.Equal(t, []string{"Running", "A", "C", "B"}, queuedPairs(t, q))
+	require.NoError(t, q.Move(ids[1], -1), "a job cannot pass the running one")
+	assert.Equal(t, []string{"Running", "A", "C", "B"}, queuedPairs(t, q))
+	require.NoError(t, q.Move(ids[1], 1))
+	assert.Equal(t, []string{"Running", "C", "A", "B"}, queuedPairs(t, q))
+	assert.Error(t, q.Move(ids[0], 1))
+
+	require.NoError(t, q.Cancel(ids[1]))
+	assert.Equal(t, []string{"Running", "C", "B"}, queuedPairs(t, q))
+	assert.ErrorIs(t, q.Cancel(ids[1]), queue.ErrNotFound)
+
+	// The running job is asked to stop
+	cancelling, err := q.Cancelling(ids[0])
+	require.NoError(t, err)
+	assert.False(t, cancelling)
+	require.NoError(t, q.Cancel(ids[0]))
+	cancelling, err = q.Cancelling(ids[0])
+	require.NoError(t, err)
+	assert.True(t, cancelling)
+}
+
+// setupQueue saves a config with a fake rclone that succeeds and returns
+// the queue in its log directory
+func setupQueue(t *testing.T, home string) *queue.Queue {
+	t.Helper()
+	bin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\n[ \"$1\" = version ] && echo 'rclone v1.66.0'\nexit 0\n"), 0755))
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = bin
+	appConfig.LogDir = filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(appConfig))
+	return queue.New(appConfig.LogDir)
+}
+
+func TestCLISyncQueues(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	q := setupQueue(t, home)
+	addPlainPair(t, "Documents", true)
+	addPlainPair(t, "Photos", true)
+
+	// Another process is running the queue
+	running, _, err := q.Add(queue.Job{Pair: "Photos", Priority: queue.Low, Trigger: queue.TriggerScheduled})
+	require.NoError(t, err)
+	_, _, err = q.Claim(livePID)
+	require.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"sync", "Documents"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Documents: queued (position 1)\nQueued behind the running sync of Photos, which runs them next.\n", stdout.String())
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"sync", "--scheduled", "Documents"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Documents: already queued\n")
+
+	// Once it is done, the next sync runs what the others queued too
+	require.NoError(t, q.Finish(running.ID))
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"sync", "--scheduled", "Photos"}, &stdout, &stderr), stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Syncing Documents (queued by manual)...\n")
+	assert.Contains(t, out, "Syncing Photos...\n")
+	assert.Less(t, bytes.Index(stdout.Bytes(), []byte("Documents")), bytes.Index(stdout.Bytes(), []byte("Photos")), "the manual sync runs first")
+	assert.Contains(t, out, "Synced 2 of 2 pair(s).")
+	assert.Empty(t, queuedPairs(t, q))
+
+	// Dry runs do not wait
+	_, _, err = q.Add(queue.Job{Pair: "Photos"})
+	require.NoError(t, err)
+	_, _, err = q.Claim(livePID)
+	require.NoError(t, err)
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"sync", "--dry-run", "Documents"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Synced 1 of 1 pair(s).")
+}
+
+func TestSyncQueueView(t *testing.T) {
+	q := queue.New(t.TempDir())
+	for _, pair := range []string{"Running", "A", "B"} {
+		_, _, err := q.Add(queue.Job{Pair: pair, Priority: queue.High, Trigger: queue.TriggerManual})
+		require.NoError(t, err)
+	}
+	_, _, err := q.Claim(livePID)
+	require.NoError(t, err)
+
+	var model tea.Model = views.NewSyncQueueModel(q, 120, 40)
+	model, _ = model.Update(model.Init()().(tea.BatchMsg)[0]())
+	view := testutil.PlainText(model.View())
+	assert.Contains(t, view, "Sync Queue")
+	assert.Regexp(t, `▶\s+Running\s+manual\s+high\s+Running`, view)
+	assert.Regexp(t, `1\s+A\s+manual`, view)
+
+	// Move B up and cancel it
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	assert.Equal(t, []string{"Running", "B", "A"}, queuedPairs(t, q))
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	assert.Equal(t, []string{"Running", "A"}, queuedPairs(t, q))
+	assert.Contains(t, testutil.PlainText(model.View()), "Removed B from the queue")
+}
@@ -1,9 +1,13 @@
 package unit
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/andreisuslov/cloud-sync/internal/rclone"
 	"github.com/stretchr/testify/assert"
@@ -218,3 +222,169 @@ func TestValidateBucketName(t *testing.T) {
 		})
 	}
 }
+
+func TestRcloneBuildSyncArgs(t *testing.T) {
+	manager := rclone.NewManagerWithConfig("rclone", "/tmp/rclone.conf")
+
+	// The remote is not in a config, so no provider defaults are added
+	args := manager.BuildSyncArgs("/src", "remote:bucket", rclone.SyncOptions{})
+	assert.Equal(t, []string{"sync", "/src", "remote:bucket", "--config", "/tmp/rclone.conf", "-v"}, args)
+
+	args = manager.BuildSyncArgs("/src", "remote:bucket/docs", rclone.SyncOptions{
+		DryRun:    true,
+		BackupDir: "remote:bucket/.cloud-sync-trash/docs/2026-10-15",
+		Excludes:  []string{rclone.TrashExclude()},
+	})
+	assert.Contains(t, args, "--dry-run")
+	assert.Contains(t, args, "--backup-dir")
+	assert.Contains(t, args, "remote:bucket/.cloud-sync-trash/docs/2026-10-15")
+	assert.Contains(t, args, "/.cloud-sync-trash/**")
+
+	args = manager.BuildSyncArgs("/src", "remote:bucket", rclone.SyncOptions{LogFile: "/tmp/logs/docs.log"})
+	assert.Equal(t, []string{"--log-file", "/tmp/logs/docs.log"}, args[len(args)-2:])
+}
+
+func TestRcloneCapabilitiesFor(t *testing.T) {
+	b2 := rclone.CapabilitiesFor("b2")
+	assert.True(t, b2.ServerSideCopy)
+	assert.True(t, b2.Versioning)
+	assert.True(t, b2.FastList)
+	assert.Equal(t, "sha1", b2.Hash)
+
+	assert.False(t, rclone.CapabilitiesFor("onedrive").FastList)
+	assert.Equal(t, rclone.Capabilities{}, rclone.CapabilitiesFor("unknown"))
+}
+
+func TestRcloneDefaultFlags(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "rclone.conf")
+	require.NoError(t, os.WriteFile(conf, []byte("[b2]\ntype = b2\n\n[dav]\ntype = webdav\n\n[box]\ntype = box\n\n[one]\ntype = onedrive\n\n[qs]\ntype = qingstor\n"), 0600))
+	manager := rclone.NewManagerWithConfig("rclone", conf)
+
+	assert.Equal(t, []string{"--fast-list"}, manager.DefaultFlags("/src", "b2:bucket"))
+	assert.Empty(t, manager.DefaultFlags("/src", "dav:docs"), "no modtimes or checksums to compare")
+	assert.Empty(t, manager.DefaultFlags("/src", "one:docs"))
+	assert.Empty(t, manager.DefaultFlags("box:docs", "one:docs"))
+
+	// QingStor keeps no modification times, but its MD5s can be compared
+	assert.Equal(t, []string{"--fast-list", "--checksum"}, manager.DefaultFlags("/src", "qs:bucket"))
+	assert.Equal(t, []string{"--fast-list"}, manager.DefaultFlags("qs:bucket", "b2:bucket"), "MD5 and SHA-1 differ")
+
+	args := manager.BuildSyncArgs("/src", "b2:bucket", rclone.SyncOptions{})
+	assert.Equal(t, []string{"sync", "/src", "b2:bucket", "--config", conf, "--fast-list", "-v"}, args)
+
+	// Remotes defined only in the environment are recognized too
+	manager.SetEnv([]string{"RCLONE_CONFIG_MY_S3_TYPE=s3"})
+	assert.Equal(t, []string{"--fast-list"}, manager.DefaultFlags("my-s3:bucket", "/dst"))
+}
+
+func TestRcloneTrashPath(t *testing.T) {
+	date := time.Date(2026, 10, 15, 9, 30, 0, 0, time.Local)
+
+	assert.Equal(t, "bucket/.cloud-sync-trash/docs-pair", rclone.TrashRoot("bucket/docs", "docs-pair"))
+	assert.Equal(t, "bucket/.cloud-sync-trash/docs-pair", rclone.TrashRoot("/bucket/", "docs-pair"))
+	assert.Equal(t, "bucket/.cloud-sync-trash/docs-pair/2026-10-15", rclone.TrashPath("bucket/docs", "docs-pair", date))
+}
+
+func TestRcloneExpiredTrash(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	entries := []rclone.TrashEntry{
+		{Date: now.AddDate(0, 0, -1), Path: "bucket/.cloud-sync-trash/p/recent"},
+		{Date: now.AddDate(0, 0, -40), Path: "bucket/.cloud-sync-trash/p/old"},
+	}
+
+	expired := rclone.ExpiredTrash(entries, 30*24*time.Hour, now)
+	require.Len(t, expired, 1)
+	assert.Equal(t, "bucket/.cloud-sync-trash/p/old", expired[0].Path)
+}
+
+func TestRcloneBuildCopyArgsWithCompareDest(t *testing.T) {
+	manager := rclone.NewManagerWithConfig("rclone", "/tmp/rclone.conf")
+
+	args := manager.BuildCopyArgs("/src", "remote:bucket/2026-10-15", rclone.SyncOptions{
+		CompareDests: []string{"remote:bucket/2026-10-14", "remote:bucket/2026-10-13"},
+	})
+	assert.Equal(t, "copy", args[0])
+	assert.Equal(t, []string{"--compare-dest", "remote:bucket/2026-10-14", "--compare-dest", "remote:bucket/2026-10-13"}, args[len(args)-4:])
+}
+
+func TestRcloneSnapshotPath(t *testing.T) {
+	date := time.Date(2026, 10, 15, 23, 59, 0, 0, time.Local)
+	assert.Equal(t, "bucket/docs/2026-10-15", rclone.SnapshotPath("bucket/docs", date))
+}
+
+func TestRcloneSnapshotsToPrune(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	snapshots := make([]rclone.Snapshot, 0)
+	for i := 0; i < 5; i++ {
+		date := now.AddDate(0, 0, -i*10)
+		snapshots = append(snapshots, rclone.Snapshot{Date: date, Path: rclone.SnapshotPath("bucket", date)})
+	}
+
+	tests := []struct {
+		name   string
+		keep   int
+		maxAge time.Duration
+		want   int
+	}{
+		{name: "no limits", want: 0},
+		{name: "keep three", keep: 3, want: 2},
+		{name: "max age 25 days", maxAge: 25 * 24 * time.Hour, want: 2},
+		{name: "stricter limit wins", keep: 4, maxAge: 15 * 24 * time.Hour, want: 3},
+		{name: "newest always kept", maxAge: time.Hour, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prune := rclone.SnapshotsToPrune(snapshots, tt.keep, tt.maxAge, now)
+			assert.Len(t, prune, tt.want)
+			if tt.want > 0 {
+				assert.Equal(t, snapshots[len(snapshots)-1], prune[len(prune)-1])
+			}
+		})
+	}
+}
+
+func TestRcloneRCClientStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/core/stats", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		w.Write([]byte(`{"bytes":1048576,"totalBytes":4194304,"transfers":2,"totalTransfers":8,"speed":524288,"eta":6,"elapsedTime":2.5,"transferring":[{"name":"photos/a.jpg","size":100,"bytes":50,"percentage":50}]}`))
+	}))
+	defer server.Close()
+
+	client := rclone.NewRCClient(strings.TrimPrefix(server.URL, "http://"))
+	stats, err := client.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1048576), stats.Bytes)
+	assert.Equal(t, 8, stats.TotalTransfers)
+	require.NotNil(t, stats.ETA)
+	assert.Equal(t, 6.0, *stats.ETA)
+	require.Len(t, stats.Transferring, 1)
+	assert.Equal(t, "photos/a.jpg", stats.Transferring[0].Name)
+	assert.True(t, client.Running())
+
+	server.Close()
+	assert.False(t, client.Running())
+}
+
+func TestRcloneBuildTransferArgs(t *testing.T) {
+	manager := rclone.NewManagerWithConfig("rclone", "/tmp/rclone.conf")
+
+	args := manager.BuildTransferArgs("/src", "remote:bucket", rclone.SyncOptions{})
+	assert.Equal(t, "sync", args[0])
+
+	for _, command := range []string{"copy", "move"} {
+		args = manager.BuildTransferArgs("/src", "remote:bucket", rclone.SyncOptions{Command: command})
+		assert.Equal(t, []string{command, "/src", "remote:bucket"}, args[:3])
+	}
+}
+
+func TestRcloneBuildTransferArgsExtraFlags(t *testing.T) {
+	manager := rclone.NewManagerWithConfig("rclone", "/tmp/rclone.conf")
+
+	args := manager.BuildTransferArgs("/src", "remote:bucket", rclone.SyncOptions{
+		ExtraFlags: []string{"--track-renames", "--transfers", "8"},
+		DryRun:     true,
+	})
+	assert.Equal(t, []string{"--track-renames", "--transfers", "8", "--dry-run"}, args[len(args)-4:])
+}
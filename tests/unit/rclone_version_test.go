@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+)
+
+func TestParseRcloneVersion(t *testing.T) {
+	version, err := rclone.ParseVersion("rclone v1.68.2\n- os/version: darwin 14.5 (64 bit)\n")
+	require.NoError(t, err)
+	assert.Equal(t, rclone.Version{Major: 1, Minor: 68, Patch: 2}, version)
+	assert.Equal(t, "v1.68.2", version.String())
+
+	version, err = rclone.ParseVersion("rclone v1.53-DEV")
+	require.NoError(t, err)
+	assert.Equal(t, rclone.Version{Major: 1, Minor: 53}, version)
+
+	_, err = rclone.ParseVersion("command not found")
+	assert.Error(t, err)
+
+	assert.True(t, rclone.Version{Major: 1, Minor: 49, Patch: 9}.Less(rclone.MinVersion))
+	assert.False(t, rclone.MinVersion.Less(rclone.MinVersion))
+	assert.False(t, rclone.Version{Major: 2}.Less(rclone.MinVersion))
+}
+
+func TestRcloneRequire(t *testing.T) {
+	manager := fakeRclone(t, "echo 'rclone v1.45.0'\n")
+	err := manager.Require(rclone.FeatureBaseline)
+	var versionErr *rclone.VersionError
+	require.True(t, errors.As(err, &versionErr))
+	assert.Equal(t, rclone.MinVersion, versionErr.Required)
+	assert.Contains(t, err.Error(), "needs rclone v1.50.0 or newer, but v1.45.0 is installed")
+	assert.Contains(t, err.Error(), "brew upgrade rclone")
+
+	manager = fakeRclone(t, "echo 'rclone v1.55.0'\n")
+	assert.NoError(t, manager.Require(rclone.FeatureBaseline))
+	assert.Error(t, manager.Require(rclone.FeatureBisync))
+	assert.Error(t, manager.Require("teleport"))
+
+	// A version that cannot be read does not block anything
+	manager = fakeRclone(t, "exit 1\n")
+	assert.NoError(t, manager.Require(rclone.FeatureBisync))
+}
+
+func TestCLISyncOldRcloneOffersUpgrade(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	synced := filepath.Join(home, "synced")
+
+	bin := filepath.Join(home, "rclone")
+	script := "if [ \"$1\" = version ]; then echo 'rclone v1.45.0'; exit 0; fi\ntouch " + synced + "\n"
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\n"+script), 0755))
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = bin
+	appConfig.LogDir = filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(appConfig))
+	addPlainPair(t, "Documents", true)
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.RunPlain(strings.NewReader("2\nDocuments\nn\nq\n"), &stdout, &stderr))
+	out := stdout.String()
+	assert.Contains(t, out, "cloud-sync needs rclone v1.50.0 or newer, but v1.45.0 is installed")
+	assert.Contains(t, out, "Upgrade rclone with 'brew upgrade rclone' now? [y/N]")
+	assert.Contains(t, out, "Synced 0 of 1 pair(s).")
+	assert.NoFileExists(t, synced)
+}
@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"bytes"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestReadOnlyFlagAndConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { views.SetReadOnly(false) })
+
+	args, found := ui.TakeReadOnlyFlag([]string{"--simulate", "--read-only"})
+	assert.True(t, found)
+	assert.Equal(t, []string{"--simulate"}, args)
+
+	assert.False(t, ui.ApplyReadOnly(false))
+	assert.True(t, ui.ApplyReadOnly(true))
+	assert.True(t, views.ReadOnly())
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"config", "read-only", "on"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "Read-only: on\n", stdout.String())
+	assert.True(t, ui.ApplyReadOnly(false), "set in the configuration")
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"config", "read-only", "off"}, &stdout, &stderr), stderr.String())
+	assert.False(t, ui.ApplyReadOnly(false))
+	assert.Equal(t, 2, cli.Run([]string{"config", "read-only", "maybe"}, &stdout, &stderr))
+}
+
+func TestReadOnlyMainMenu(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	views.SetReadOnly(true)
+	t.Cleanup(func() { views.SetReadOnly(false) })
+
+	var model tea.Model = ui.NewModel()
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	assert.Contains(t, model.View(), "Read-only: installs, configuration changes and syncs are disabled")
+}
+
+func TestReadOnlySyncPairs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	views.SetReadOnly(true)
+	t.Cleanup(func() { views.SetReadOnly(false) })
+	addPlainPair(t, "Documents", true)
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+
+	var model tea.Model = views.NewSyncPairsModel(mgr, rclone.NewManager("rclone"))
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+	assert.Contains(t, model.View(), "Read-only • ")
+
+	for key, want := range map[string]string{
+		"t": "Enabling or disabling sync pairs is disabled in read-only mode",
+		"d": "Deleting sync pairs is disabled in read-only mode",
+		"s": "Syncing is disabled in read-only mode",
+		"a": "Adding sync pairs is disabled in read-only mode",
+	} {
+		var cmd tea.Cmd
+		model, cmd = model.Update(keyPress(key))
+		assert.Nil(t, cmd, key)
+		assert.Contains(t, model.View(), want, key)
+	}
+
+	// Nothing was changed or run, and the list can still be browsed
+	pairs, err := mgr.ListSyncPairs()
+	require.NoError(t, err)
+	require.Len(t, pairs, 1)
+	assert.True(t, pairs[0].Enabled)
+	assert.Empty(t, simulate.Actions())
+	assert.Contains(t, model.View(), "1. [✓] Documents")
+}
+
+func TestReadOnlyLaunchdManager(t *testing.T) {
+	views.SetReadOnly(true)
+	t.Cleanup(func() { views.SetReadOnly(false) })
+
+	var model tea.Model = views.NewLaunchdManagerModel(launchd.NewManager("tester"), 100, 40)
+	model, _ = model.Update(&launchd.Status{Loaded: true})
+	assert.Contains(t, model.View(), "Load Agent (disabled)")
+	assert.Contains(t, model.View(), "Remove Agent (disabled)")
+	assert.NotContains(t, model.View(), "Refresh Status (disabled)")
+
+	// The first action, Load, is refused without touching launchctl
+	_, cmd := model.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+	assert.Contains(t, model.View(), "Load Agent is disabled in read-only mode")
+
+	for key, want := range map[string]string{
+		"c": "Editing the schedule is disabled in read-only mode",
+		"u": "Uninstalling is disabled in read-only mode",
+	} {
+		var cmd tea.Cmd
+		model, cmd = model.Update(keyPress(key))
+		assert.Nil(t, cmd, key)
+		assert.Contains(t, model.View(), want, key)
+	}
+}
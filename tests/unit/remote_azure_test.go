@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// azureWizard opens the Azure form of the remote wizard, with rclone
+// replaced by a script that logs its arguments and runs body
+func azureWizard(t *testing.T, body string) (*config.Manager, string, tea.Model) {
+	t.Helper()
+	mgr, rcloneConf := remoteFixture(t)
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = filepath.Join(filepath.Dir(fakeRclone(t, body).GetConfigPath()), "rclone")
+	require.NoError(t, mgr.Save(appConfig))
+
+	form, _ := views.NewRemoteConfigModel(mgr).Update(keyPress("7"))
+	return mgr, rcloneConf, form
+}
+
+func TestRemoteWizardAzureAccountKey(t *testing.T) {
+	_, rcloneConf, form := azureWizard(t, `[ "$1" = lsd ] && printf '          -1 2026-10-15 12:00:00        -1 backups\n'
+exit 0
+`)
+	require.Contains(t, form.View(), "Access keys")
+
+	form = fillField(form, "az")
+	form = fillField(form, "")
+	form = fillField(form, "acme")
+	form = fillField(form, "account-key")
+	form = fillField(form, "")
+	for range config.AzurePublicSuffix {
+		form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	form = typeText(form, "core.usgovcloudapi.net")
+
+	// The containers are listed like buckets
+	view := form.View()
+	require.Contains(t, view, "backups")
+	form, _ = form.Update(keyPress("enter"))
+	assert.Contains(t, form.View(), "Default bucket: backups")
+
+	data, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[az]\ntype = azureblob\naccount = acme\nkey = account-key\nendpoint = https://acme.blob.core.usgovcloudapi.net\n\n")
+}
+
+func TestRemoteWizardAzureSAS(t *testing.T) {
+	args := filepath.Join(t.TempDir(), "args")
+	mgr, rcloneConf, form := azureWizard(t, `echo "$@" >> `+args+`
+exit 0
+`)
+	sas := "https://acme.blob.core.windows.net/backups?sv=2022-11-02&sp=rwdl&sig=abc"
+
+	form = fillField(form, "azsas")
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	form = fillField(form, "sas")
+	assert.Contains(t, form.View(), "A container's SAS URL makes it the default container")
+	form = fillField(form, "")
+	form = fillField(form, "typed before switching")
+	form = typeText(form, sas)
+
+	// The SAS URL's container is tested and becomes the default
+	assert.Contains(t, form.View(), "Default bucket: backups")
+	calls, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Contains(t, string(calls), "lsf azsas:backups")
+	assert.NotContains(t, string(calls), "lsd", "a container's SAS URL cannot list containers")
+
+	remote, err := mgr.GetRemote("azsas")
+	require.NoError(t, err)
+	assert.Empty(t, remote.ApplicationKey, "only the chosen mode's credential is kept")
+	data, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[azsas]\ntype = azureblob\nsas_url = "+sas+"\n\n")
+
+	// Editing reopens the form in SAS mode
+	form = views.NewEditRemoteModel(mgr, *remote)
+	assert.Contains(t, form.View(), "Auth (key/sas): sas")
+}
@@ -0,0 +1,134 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestRcloneBenchmark(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	// The upload must be a local file of the size asked for
+	mgr := fakeRclone(t, `echo "$1 $2 $3" >> `+calls+`
+if [ "$1" = copyto ] && [ "${2#b2:}" = "$2" ]; then
+	[ "$(wc -c < "$2")" -eq 1024 ] || exit 1
+fi
+exit 0
+`)
+
+	result, err := mgr.Benchmark("b2:photos", 1024)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1024), result.Size)
+	assert.Positive(t, result.Latency)
+	assert.Positive(t, result.UploadRate())
+	assert.Positive(t, result.DownloadRate())
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 4)
+	object := strings.Fields(lines[0])[2]
+	assert.True(t, strings.HasPrefix(object, "b2:photos/.cloud-sync-benchmark-"), object)
+	assert.Equal(t, "lsjson --stat "+object, lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "copyto "+object+" "))
+	assert.True(t, strings.HasPrefix(lines[3], "deletefile "+object+" "))
+}
+
+func TestRcloneBenchmarkCleansUpAfterFailure(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	mgr := fakeRclone(t, `echo "$1 $2" >> `+calls+`
+[ "$1" = lsjson ] && { echo 'ERROR : Failed to lsjson: couldn'"'"'t connect: dial tcp: i/o timeout' >&2; exit 1; }
+exit 0
+`)
+
+	_, err := mgr.Benchmark("nas:", 1024)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "benchmark stat failed")
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "copyto "))
+	assert.True(t, strings.HasPrefix(lines[2], "deletefile nas:.cloud-sync-benchmark-"), lines[2])
+}
+
+func TestBenchmarkHistory(t *testing.T) {
+	mgr := logs.NewManager(t.TempDir())
+	history, err := mgr.BenchmarkHistory()
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	older := logs.BenchmarkRun{Remote: "b2", Time: time.Now().Add(-time.Hour), Latency: 300 * time.Millisecond, UploadRate: 1 << 20, DownloadRate: 2 << 20}
+	newer := logs.BenchmarkRun{Remote: "b2", Time: time.Now(), Latency: 120 * time.Millisecond, UploadRate: 4.2 * (1 << 20), DownloadRate: 8.1 * (1 << 20)}
+	failed := logs.BenchmarkRun{Remote: "sw", Time: time.Now(), Error: "no default bucket"}
+	for _, run := range []logs.BenchmarkRun{older, newer, failed} {
+		require.NoError(t, mgr.RecordBenchmark(run))
+	}
+
+	history, err = mgr.BenchmarkHistory()
+	require.NoError(t, err)
+	assert.Len(t, history, 3)
+
+	latest, err := mgr.LatestBenchmarks()
+	require.NoError(t, err)
+	assert.Equal(t, "latency 120ms, up 4.2 MB/s, down 8.1 MB/s", latest["b2"].Summary())
+	assert.Equal(t, "benchmark failed: no default bucket", latest["sw"].Summary())
+}
+
+func TestRemotesViewBenchmarksRemotes(t *testing.T) {
+	mgr, _ := remoteFixture(t)
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.LogDir = t.TempDir()
+	appConfig.RclonePath = filepath.Join(filepath.Dir(fakeRclone(t, "exit 0\n").GetConfigPath()), "rclone")
+	require.NoError(t, mgr.Save(appConfig))
+
+	var model tea.Model = views.NewRemotesModel(mgr)
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+	assert.Contains(t, model.View(), "B: Benchmark all")
+
+	// Benchmark every remote, one after another
+	model, cmd := model.Update(keyPress("B"))
+	assert.Contains(t, model.View(), "Benchmarking with a 4 MB test object...")
+	for cmd != nil {
+		var next tea.Cmd
+		for _, msg := range runBatch(cmd) {
+			if _, tick := msg.(spinner.TickMsg); tick {
+				continue
+			}
+			model, next = model.Update(msg)
+		}
+		cmd = next
+	}
+
+	view := model.View()
+	assert.NotContains(t, view, "Benchmarking")
+	assert.Regexp(t, `latency \S+, up [\d.]+ MB/s, down [\d.]+ MB/s`, view)
+	assert.Contains(t, view, "benchmark failed: remote 'sw' has no default bucket to test in")
+
+	// The results are recorded, and shown again when the view reopens
+	latest, err := logs.NewManager(appConfig.LogDir).LatestBenchmarks()
+	require.NoError(t, err)
+	assert.Equal(t, "b2:photos", latest["b2"].Target)
+	assert.Equal(t, int64(rclone.BenchmarkSize), latest["b2"].Size)
+
+	model = views.NewRemotesModel(mgr)
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+	assert.Contains(t, model.View(), "benchmark failed: remote 'sw'")
+}
@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestRemoteWizardGcs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "Keys"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "Keys", "acme-1a2b.json"),
+		[]byte(`{"type": "service_account", "project_id": "acme", "client_email": "sync@acme.iam.gserviceaccount.com"}`), 0600))
+
+	mgr, rcloneConf := remoteFixture(t)
+	args := filepath.Join(home, "args")
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = filepath.Join(filepath.Dir(fakeRclone(t, `echo "$@" >> `+args+`
+[ "$2" = gcs:photos ] && exit 0
+echo 'ERROR : : error listing: googleapi: Error 403: sync@acme.iam.gserviceaccount.com does not have storage.objects.list access, forbidden' >&2
+exit 1
+`).GetConfigPath()), "rclone")
+	require.NoError(t, mgr.Save(appConfig))
+
+	form, _ := views.NewRemoteConfigModel(mgr).Update(keyPress("6"))
+	require.Contains(t, form.View(), "Google Cloud Storage Configuration")
+	form = fillField(form, "gcs")
+
+	// Tab completes the key's path, one level at a time
+	for _, r := range "~/K" {
+		form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyTab})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyTab})
+	view := form.View()
+	assert.Contains(t, view, "Service Account Key: ~/Keys/acme-1a2b.json")
+	assert.Contains(t, view, "sync@acme.iam.gserviceaccount.com (project acme)")
+
+	// The saved remote is tested on the bucket, which a service account
+	// may not be allowed to read
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyTab})
+	form = typeText(form, "archive")
+	view = form.View()
+	assert.Contains(t, view, "Could not connect to bucket 'archive'")
+	assert.Contains(t, view, "The remote was saved")
+
+	form, _ = form.Update(keyPress("esc"))
+	for range "archive" {
+		form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	form = typeText(form, "photos")
+	assert.Contains(t, form.View(), "Default bucket: photos")
+
+	remote, err := mgr.GetRemote("gcs")
+	require.NoError(t, err)
+	assert.Equal(t, config.RemoteConfig{Name: "gcs", Type: config.TypeGCS, Provider: "Google",
+		ServiceAccountFile: "~/Keys/acme-1a2b.json", BucketPolicyOnly: true, Bucket: "photos"}, *remote)
+	data, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[gcs]\ntype = google cloud storage\nservice_account_file = ~/Keys/acme-1a2b.json\n")
+	calls, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Contains(t, string(calls), "lsf gcs:photos --config")
+}
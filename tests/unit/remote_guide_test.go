@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestRemoteWizardSetupGuide(t *testing.T) {
+	mgr, _ := remoteFixture(t)
+	form, _ := views.NewRemoteConfigModel(mgr).Update(keyPress("1"))
+	view := form.View()
+	assert.Contains(t, view, "Press ctrl+o for a step-by-step guide")
+	assert.Contains(t, view, "ctrl+o: Setup guide")
+	assert.NotContains(t, view, "Least privilege")
+
+	// The guide opens in a panel under the form, and the form stays usable
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	view = form.View()
+	assert.Contains(t, view, "Creating a Backblaze B2 application key")
+	assert.Contains(t, view, "Allow access to Bucket(s)")
+	assert.Contains(t, view, "pgup/pgdn: Scroll guide")
+	assert.NotContains(t, view, "Least privilege", "only the top of the guide is visible")
+
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	view = form.View()
+	assert.Contains(t, view, "Never use the master application key")
+	assert.NotContains(t, view, "Creating a Backblaze B2 application key")
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	assert.Contains(t, form.View(), "Creating a Backblaze B2 application key")
+
+	form = fillField(form, "b2main")
+	assert.Contains(t, form.View(), "Remote Name: b2main")
+
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	assert.NotContains(t, form.View(), "Creating a Backblaze B2 application key")
+}
+
+func TestRemoteWizardSetupGuideFollowsProvider(t *testing.T) {
+	mgr, _ := remoteFixture(t)
+
+	tests := []struct {
+		provider string
+		title    string
+	}{
+		{"Amazon S3", "Creating an AWS access key"},
+		{"Scaleway Object Storage", "Creating a Scaleway API key"},
+		{"Cloudflare R2", "Creating S3-compatible access keys"},
+		{"Google Cloud Storage", "Creating a Google Cloud service account key"},
+		{"Microsoft Azure Blob Storage", "Getting Azure Blob Storage credentials"},
+		{"SFTP", "Setting up key-based SSH login"},
+		{"Local Folder", "Mounting a NAS share on this Mac"},
+		{"Union", "Combining remotes into a union"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			form, _ := views.NewRemoteConfigModelWithProvider(mgr, tt.provider).Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+			assert.Contains(t, form.View(), tt.title)
+		})
+	}
+
+	// The WebDAV guide follows the vendor typed in
+	form, _ := views.NewRemoteConfigModelWithProvider(mgr, "Nextcloud").Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	assert.Contains(t, form.View(), "Creating a Nextcloud app password")
+	form, _ = form.Update(keyPress("down"))
+	for range "nextcloud" {
+		form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("owncloud")})
+	assert.Contains(t, form.View(), "Creating an ownCloud app password")
+}
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/status"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+func TestRemoteDisplayName(t *testing.T) {
+	tests := []struct {
+		remote config.RemoteConfig
+		want   string
+	}{
+		{config.RemoteConfig{Name: "b2"}, "b2"},
+		{config.RemoteConfig{Name: "b2", Label: "Photos"}, "Photos"},
+		{config.RemoteConfig{Name: "b2", Label: "Photos", Icon: "📷"}, "📷 Photos"},
+		{config.RemoteConfig{Name: "b2", Icon: "📷"}, "📷 b2"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.remote.DisplayName())
+	}
+}
+
+func TestRemoteLabelForm(t *testing.T) {
+	mgr, _ := remoteFixture(t)
+	remote, err := mgr.GetRemote("b2")
+	require.NoError(t, err)
+
+	var form tea.Model = views.NewRemoteLabelModel(mgr, *remote)
+	form = fillField(form, "Photos archive")
+	form = fillField(form, "📷")
+	for _, r := range "mauve" {
+		form, _ = form.Update(keyPress(string(r)))
+	}
+	assert.Contains(t, testutil.PlainText(form.View()), "Preview: 📷 Photos archive (b2:path)")
+
+	// An unknown color is not saved
+	form, cmd := form.Update(keyPress("enter"))
+	assert.Nil(t, cmd)
+	assert.Contains(t, form.View(), "unknown color 'mauve'")
+	remote, err = mgr.GetRemote("b2")
+	require.NoError(t, err)
+	assert.Empty(t, remote.Label)
+
+	for range "mauve" {
+		form, _ = form.Update(keyPress("backspace"))
+	}
+	for _, r := range "Green" {
+		form, _ = form.Update(keyPress(string(r)))
+	}
+	_, cmd = form.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	assert.Equal(t, views.DoneMsg{Message: "Remote 'b2' is shown as 📷 Photos archive"}, cmd())
+	remote, err = mgr.GetRemote("b2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Photos archive", "📷", "green"}, []string{remote.Label, remote.Icon, remote.Color})
+
+	// The remotes list shows the label with the name
+	var list tea.Model = views.NewRemotesModel(mgr)
+	for _, msg := range runBatch(list.Init()) {
+		list, _ = list.Update(msg)
+	}
+	assert.Contains(t, testutil.PlainText(list.View()), "📷 Photos archive · b2 (b2, Backblaze)")
+
+	// Renaming the remote keeps its label
+	require.NoError(t, mgr.RenameRemote("b2", "backblaze"))
+	remote, err = mgr.GetRemote("backblaze")
+	require.NoError(t, err)
+	assert.Equal(t, "📷 Photos archive", remote.DisplayName())
+}
+
+func TestConfigValidatesRemoteLabels(t *testing.T) {
+	mgr := config.NewManagerWithPath(filepath.Join(t.TempDir(), "config.json"))
+	remote := config.RemoteConfig{Name: "b2", Type: "b2", AccountID: "id", ApplicationKey: "key"}
+	require.NoError(t, mgr.AddRemote(remote))
+
+	assert.ErrorContains(t, mgr.SetRemoteLabel("b2", "Photos", "", "mauve"), "unknown color 'mauve'")
+	assert.ErrorContains(t, mgr.SetRemoteLabel("b2", "Photos", "📷 🎵", ""), "is not a single emoji")
+	assert.ErrorContains(t, mgr.SetRemoteLabel("b2", " Photos", "", ""), "cannot start or end with spaces")
+	assert.ErrorContains(t, mgr.SetRemoteLabel("sw", "Photos", "", ""), "remote 'sw' not found")
+	assert.NoError(t, mgr.SetRemoteLabel("b2", "Photos", "🇳🇱", "#30A46C"))
+
+	remote.Name, remote.Color = "sw", "mauve"
+	assert.ErrorContains(t, mgr.AddRemote(remote), "remote 'sw': unknown color 'mauve'")
+}
+
+func TestRemoteLabelsInPairsAndDashboard(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, configManager.AddRemote(config.RemoteConfig{Name: "b2", Type: "b2", AccountID: "id", ApplicationKey: "key", Label: "Photos", Icon: "📷"}))
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, syncConfigMgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true,
+		Destinations: []syncconfig.Destination{{RemoteName: "sw", RemotePath: "copy"}},
+	}))
+	require.NoError(t, syncConfigMgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "music", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/music", Direction: "upload", Enabled: true,
+	}))
+
+	var model tea.Model = views.NewSyncPairsModel(syncConfigMgr, rclone.NewManager("rclone"))
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+	view := testutil.PlainText(model.View())
+	assert.Contains(t, view, "Remote: 📷 Photos (b2:bucket/docs)")
+	assert.Contains(t, view, "Also:   sw:copy")
+
+	// Each remote is listed once, remotes set up outside cloud-sync by name
+	summary := status.Load(launchd.NewManager("tester"), time.Now())
+	require.Len(t, summary.Remotes, 2)
+	assert.Contains(t, testutil.PlainText(views.RenderDashboard(&summary, 0, nil, 120)), "Remotes: 📷 Photos • sw")
+}
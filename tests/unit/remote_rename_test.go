@@ -0,0 +1,162 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// remoteFixture saves two remotes, a sync pair using one of them and an
+// rclone.conf with a remote cloud-sync does not manage
+func remoteFixture(t *testing.T) (*config.Manager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	rcloneConf := filepath.Join(dir, "rclone.conf")
+	require.NoError(t, os.WriteFile(rcloneConf, []byte("[b2]\ntype = b2\naccount = id\n# key is read from $RCLONE_CONFIG_B2_KEY\n\n[manual]\ntype = drive\n"), 0600))
+
+	mgr := config.NewManagerWithPath(filepath.Join(dir, "config.json"))
+	require.NoError(t, mgr.Save(&config.AppConfig{
+		RcloneConfig: rcloneConf,
+		Remotes: []config.RemoteConfig{
+			{Name: "b2", Type: "b2", Provider: "Backblaze", AccountID: "id", ApplicationKey: "env:B2_KEY", Bucket: "photos"},
+			{Name: "sw", Type: "s3", Provider: "Scaleway", AccountID: "ak", ApplicationKey: "sk", Region: "nl-ams", Endpoint: "s3.nl-ams.scw.cloud"},
+		},
+		SyncConfig: config.SyncConfig{SourceRemote: "b2", DestRemote: "sw"},
+	}))
+	require.NoError(t, syncconfig.NewManager(mgr.SyncConfigPath()).Save(&syncconfig.Config{SyncPairs: []syncconfig.SyncPair{
+		{Name: "docs", LocalPath: dir, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload",
+			Destinations: []syncconfig.Destination{{RemoteName: "sw", RemotePath: "copy"}}},
+		{Name: "music", LocalPath: dir, RemoteName: "sw", RemotePath: "bucket/music", Direction: "upload"},
+	}}))
+	return mgr, rcloneConf
+}
+
+func TestConfigRenameRemoteCascades(t *testing.T) {
+	mgr, rcloneConf := remoteFixture(t)
+
+	require.NoError(t, mgr.RenameRemote("b2", "backblaze"))
+
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "backblaze", appConfig.Remotes[0].Name)
+	assert.Equal(t, "backblaze", appConfig.SyncConfig.SourceRemote)
+	assert.Equal(t, "sw", appConfig.SyncConfig.DestRemote)
+
+	pair, err := syncconfig.NewManager(mgr.SyncConfigPath()).GetSyncPair("docs")
+	require.NoError(t, err)
+	assert.Equal(t, "backblaze", pair.RemoteName)
+	assert.Equal(t, "sw", pair.Destinations[0].RemoteName)
+
+	content, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Equal(t, "[backblaze]\ntype = b2\naccount = id\n# key is read from $RCLONE_CONFIG_BACKBLAZE_KEY\n\n[manual]\ntype = drive\n", string(content))
+
+	// Extra destinations are renamed too
+	require.NoError(t, mgr.RenameRemote("sw", "scaleway"))
+	pair, err = syncconfig.NewManager(mgr.SyncConfigPath()).GetSyncPair("docs")
+	require.NoError(t, err)
+	assert.Equal(t, "scaleway", pair.Destinations[0].RemoteName)
+}
+
+func TestConfigRenameRemoteRejects(t *testing.T) {
+	mgr, rcloneConf := remoteFixture(t)
+	before, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, mgr.RenameRemote("b2", "sw"), "already exists")
+	assert.ErrorContains(t, mgr.RenameRemote("missing", "other"), "not found")
+	assert.Error(t, mgr.RenameRemote("b2", "bad name!"))
+	assert.NoError(t, mgr.RenameRemote("b2", "b2"))
+
+	after, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestConfigRenameRemoteRollsBack(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+	mgr, rcloneConf := remoteFixture(t)
+	before, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+
+	// sync-config.json cannot be written, so nothing may change
+	require.NoError(t, os.Chmod(mgr.SyncConfigPath(), 0400))
+
+	require.Error(t, mgr.RenameRemote("b2", "backblaze"))
+	after, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+	_, err = mgr.GetRemote("b2")
+	assert.NoError(t, err)
+}
+
+func TestRemotesViewEditsAndRenames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr, _ := remoteFixture(t)
+
+	// The backup scripts were generated for the old name
+	home := t.TempDir()
+	binDir := filepath.Join(home, "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "run_rclone_sync.sh"), []byte(`SOURCE_REMOTE="b2"`), 0755))
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.HomeDir, appConfig.BinDir, appConfig.LogDir = home, binDir, filepath.Join(home, "logs")
+	appConfig.RclonePath = "/usr/local/bin/rclone"
+	appConfig.SyncConfig.DestBucket = "archive"
+	require.NoError(t, mgr.Save(appConfig))
+
+	var model tea.Model = views.NewRemotesModel(mgr)
+	assert.Contains(t, model.View(), "Loading remotes...")
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+	view := model.View()
+	assert.Contains(t, view, "b2 (b2, Backblaze)")
+	assert.Contains(t, view, "used by docs")
+	assert.Contains(t, view, "used by docs, music")
+
+	model, cmd := model.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	form := cmd().(views.OpenViewMsg).View
+	assert.Contains(t, form.View(), "Edit Rclone Remote")
+
+	// Replace the name and save
+	for range "b2" {
+		form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	form = typeText(form, "backblaze")
+	// Listing the buckets fails without the key; the default bucket is kept
+	assert.Contains(t, form.View(), "Could not list the buckets of 'backblaze'")
+	form, _ = form.Update(keyPress("s"))
+	assert.Contains(t, form.View(), "Remote 'backblaze' updated")
+
+	_, cmd = form.Update(keyPress("enter"))
+	model, cmd = model.Update(cmd())
+	for _, msg := range runBatch(cmd) {
+		model, _ = model.Update(msg)
+	}
+	assert.Contains(t, model.View(), "backblaze (b2, Backblaze)")
+
+	remote, err := mgr.GetRemote("backblaze")
+	require.NoError(t, err)
+	assert.Equal(t, "env:B2_KEY", remote.ApplicationKey)
+	assert.Equal(t, "photos", remote.Bucket, "settings outside the form are kept")
+	pair, err := syncconfig.NewManager(mgr.SyncConfigPath()).GetSyncPair("docs")
+	require.NoError(t, err)
+	assert.Equal(t, "backblaze", pair.RemoteName)
+
+	script, err := os.ReadFile(filepath.Join(binDir, "run_rclone_sync.sh"))
+	require.NoError(t, err)
+	assert.Contains(t, string(script), `SOURCE_REMOTE="backblaze"`)
+}
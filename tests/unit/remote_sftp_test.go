@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// sftpWizard opens the SFTP form of the remote wizard, with rclone replaced
+// by a script whose lsd fails until the trusted file exists
+func sftpWizard(t *testing.T, trusted string) (*config.Manager, tea.Model) {
+	t.Helper()
+	mgr, _ := remoteFixture(t)
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = filepath.Join(filepath.Dir(fakeRclone(t, `[ -f `+trusted+` ] && exit 0
+echo 'CRITICAL: Failed to create file system: ssh: handshake failed: knownhosts: key is unknown' >&2
+exit 1
+`).GetConfigPath()), "rclone")
+	require.NoError(t, mgr.Save(appConfig))
+
+	form, _ := views.NewRemoteConfigModel(mgr).Update(keyPress("4"))
+	return mgr, form
+}
+
+// fillField types a value into the focused field and moves to the next one
+func fillField(model tea.Model, value string) tea.Model {
+	for _, r := range value {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model, _ = model.Update(keyPress("down"))
+	return model
+}
+
+func TestRemoteWizardSftp(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".ssh"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), nil, 0600))
+	key := filepath.Join(home, ".ssh", "id_ed25519")
+	trusted := filepath.Join(home, "trusted")
+
+	mgr, form := sftpWizard(t, trusted)
+	view := form.View()
+	require.Contains(t, view, "SFTP Server Configuration")
+	assert.Contains(t, view, "Known Hosts File: ~/.ssh/known_hosts")
+
+	// A missing key file is flagged before anything is saved
+	form = fillField(form, "nas")
+	form = fillField(form, "nas.local")
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	form = fillField(form, "2222")
+	form = fillField(form, "backup")
+	form = fillField(form, "")
+	form = typeText(form, key)
+	assert.Contains(t, form.View(), "file not found")
+	_, err := mgr.GetRemote("nas")
+	assert.Error(t, err)
+
+	// Saving tests the connection; an unknown host key says how to trust it
+	require.NoError(t, os.WriteFile(key, []byte("key"), 0600))
+	form = typeText(form, "")
+	view = form.View()
+	assert.Contains(t, view, "Could not connect to backup@nas.local")
+	assert.Contains(t, view, "ssh-keyscan -p 2222 nas.local >> ~/.ssh/known_hosts")
+
+	remote, err := mgr.GetRemote("nas")
+	require.NoError(t, err)
+	assert.Equal(t, config.RemoteConfig{Name: "nas", Type: "sftp", Host: "nas.local", Port: 2222, User: "backup",
+		KeyFile: key, KnownHostsFile: "~/.ssh/known_hosts"}, *remote)
+
+	require.NoError(t, os.WriteFile(trusted, nil, 0600))
+	form, cmd := form.Update(keyPress("r"))
+	require.NotNil(t, cmd)
+	assert.Contains(t, form.View(), "Connecting to backup@nas.local...")
+	form, _ = form.Update(cmd())
+	assert.Contains(t, form.View(), "Connected to backup@nas.local.")
+
+	// Editing opens the SFTP form again, prefilled
+	form = views.NewEditRemoteModel(mgr, *remote)
+	view = form.View()
+	assert.Contains(t, view, "Host: nas.local")
+	assert.Contains(t, view, "Port: 2222")
+}
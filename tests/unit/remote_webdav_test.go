@@ -0,0 +1,68 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestRemoteWizardWebdav(t *testing.T) {
+	mgr, rcloneConf := remoteFixture(t)
+	accepted := filepath.Join(t.TempDir(), "accepted")
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = filepath.Join(filepath.Dir(fakeRclone(t, `[ "$RCLONE_CONFIG_CLOUDSYNCTEST_VENDOR" = nextcloud ] && [ -f `+accepted+` ] && exit 0
+echo 'CRITICAL: Failed to create file system: 401 Unauthorized' >&2
+exit 1
+`).GetConfigPath()), "rclone")
+	require.NoError(t, mgr.Save(appConfig))
+
+	form, _ := views.NewRemoteConfigModel(mgr).Update(keyPress("5"))
+	view := form.View()
+	require.Contains(t, view, "Vendor: nextcloud")
+	assert.Contains(t, view, "URL like: https://cloud.example.com/remote.php/dav/files/USERNAME/")
+	assert.Contains(t, view, "Devices & sessions")
+
+	// The address of the web interface is not the WebDAV one
+	form = fillField(form, "cloud")
+	form = fillField(form, "")
+	form = fillField(form, "https://cloud.example.com/apps/files/")
+	form = fillField(form, "me")
+	form = typeText(form, "app-pass")
+	assert.Contains(t, form.View(), "nextcloud serves WebDAV under /remote.php/")
+
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyUp})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyUp})
+	for range "apps/files/" {
+		form, _ = form.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	form = typeText(form, "remote.php/dav/files/me/")
+
+	// Nothing is saved while the server turns the login down
+	view = form.View()
+	assert.Contains(t, view, "Could not connect to https://cloud.example.com/remote.php/dav/files/me/")
+	assert.Contains(t, view, "Nothing was saved yet")
+	assert.Contains(t, view, "s: Save anyway")
+	_, err = mgr.GetRemote("cloud")
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(accepted, nil, 0600))
+	form, cmd := form.Update(keyPress("r"))
+	require.NotNil(t, cmd)
+	form, _ = form.Update(cmd())
+	assert.Contains(t, form.View(), "Connected to https://cloud.example.com/remote.php/dav/files/me/.")
+
+	remote, err := mgr.GetRemote("cloud")
+	require.NoError(t, err)
+	assert.Equal(t, "nextcloud", remote.Vendor)
+	assert.Equal(t, "me", remote.User)
+	data, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[cloud]\ntype = webdav\n")
+}
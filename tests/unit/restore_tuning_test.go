@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// repeatSizes returns count files of size bytes
+func repeatSizes(count int, size int64) []int64 {
+	sizes := make([]int64, count)
+	for i := range sizes {
+		sizes[i] = size
+	}
+	return sizes
+}
+
+func TestTuneRestore(t *testing.T) {
+	const mb = 1 << 20
+	tests := []struct {
+		name      string
+		sizes     []int64
+		bandwidth float64
+		want      rclone.RestoreTuning
+	}{
+		{"nothing to restore", nil, 0, rclone.RestoreTuning{}},
+		{"a few large files", repeatSizes(3, 2<<30), 0, rclone.RestoreTuning{Transfers: 2, MultiThreadStreams: 8}},
+		{"large files on a fast link", repeatSizes(3, 2<<30), 100 * mb, rclone.RestoreTuning{Transfers: 2, MultiThreadStreams: 16}},
+		{"large files on a slow link", repeatSizes(3, 2<<30), 1 * mb, rclone.RestoreTuning{Transfers: 2, MultiThreadStreams: 4}},
+		{"one large file among small ones", append(repeatSizes(10, mb), 1<<30), 0, rclone.RestoreTuning{Transfers: 4, MultiThreadStreams: 8}},
+		{"many small files", repeatSizes(500, 100<<10), 0, rclone.RestoreTuning{Transfers: 16, MultiThreadStreams: 4}},
+		{"many small files on a fast link", repeatSizes(500, 100<<10), 100 * mb, rclone.RestoreTuning{Transfers: 32, MultiThreadStreams: 8}},
+		{"many small files on a slow link", repeatSizes(500, 100<<10), 1 * mb, rclone.RestoreTuning{Transfers: 4, MultiThreadStreams: 4}},
+		{"fewer files than transfers", repeatSizes(2, mb), 0, rclone.RestoreTuning{Transfers: 2, MultiThreadStreams: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rclone.TuneRestore(tt.sizes, tt.bandwidth))
+		})
+	}
+}
+
+func TestRestoreTuningFlags(t *testing.T) {
+	tuning := rclone.RestoreTuning{Transfers: 2, MultiThreadStreams: 8}
+	assert.Equal(t, []string{"--transfers", "2", "--multi-thread-streams", "8"}, tuning.Flags())
+	assert.Equal(t, "2 files at once, 8 streams per large file", tuning.String())
+	assert.Empty(t, rclone.RestoreTuning{}.Flags())
+	assert.Equal(t, "rclone defaults", rclone.RestoreTuning{}.String())
+
+	assert.NoError(t, tuning.Validate())
+	assert.EqualError(t, rclone.RestoreTuning{Transfers: 65}.Validate(), "transfers must be between 0 and 64")
+	assert.EqualError(t, rclone.RestoreTuning{MultiThreadStreams: -1}.Validate(), "multi-thread streams must be between 0 and 64")
+}
+
+func TestConfigSetRestoreTuning(t *testing.T) {
+	mgr, _ := remoteFixture(t)
+
+	require.NoError(t, mgr.SetRestoreTuning("b2", rclone.RestoreTuning{Transfers: 2, MultiThreadStreams: 8}))
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	assert.Equal(t, &rclone.RestoreTuning{Transfers: 2, MultiThreadStreams: 8}, appConfig.RestoreTuning("b2"))
+	assert.Nil(t, appConfig.RestoreTuning("sw"))
+
+	assert.EqualError(t, mgr.SetRestoreTuning("manual", rclone.RestoreTuning{Transfers: 2}), "remote 'manual' not found")
+	assert.EqualError(t, mgr.SetRestoreTuning("b2", rclone.RestoreTuning{Transfers: 100}), "remote 'b2': transfers must be between 0 and 64")
+}
+
+func TestTrashRestoreIsTuned(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	logDir := filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(&config.AppConfig{
+		LogDir:  logDir,
+		Remotes: []config.RemoteConfig{{Name: "b2", Type: "b2", AccountID: "id", ApplicationKey: "key"}},
+	}))
+	require.NoError(t, logs.NewManager(logDir).RecordBenchmark(logs.BenchmarkRun{
+		Remote: "b2", Time: time.Date(2026, 10, 14, 9, 0, 0, 0, time.Local), DownloadRate: 100 << 20,
+	}))
+
+	calls := filepath.Join(home, "calls")
+	mgr := fakeRclone(t, `case "$*" in
+*--dirs-only*) echo '[{"Name":"2026-10-10","IsDir":true}]' ;;
+*--files-only*) echo '[{"Path":"a.mov","Size":2147483648},{"Path":"b.mov","Size":2147483648}]' ;;
+copy*) echo "$@" > `+calls+` ;;
+esac
+exit 0
+`)
+	local := filepath.Join(home, "docs")
+	var model tea.Model = views.NewTrashModel(mgr, syncconfig.SyncPair{Name: "docs", LocalPath: local, RemoteName: "b2", RemotePath: "bucket/docs"})
+	model, _ = model.Update(model.Init()())
+
+	// Restoring shows the plan, tuned to two large files on a fast link
+	model, cmd := model.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+	view := model.View()
+	assert.Contains(t, view, "Restore 2026-10-10 into "+local)
+	assert.Contains(t, view, "2 files, 4.0 GB, 2 over 256.0 MB")
+	assert.Contains(t, view, "Measured download: 100.0 MB/s (benchmark of 2026-10-14)")
+	assert.Contains(t, view, "Files at once (--transfers): 2")
+	assert.Contains(t, view, "Streams per large file (--multi-thread-streams): 16")
+
+	// The settings can be changed before restoring
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	model, _ = model.Update(keyPress("9"))
+	model, cmd = model.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+	assert.Contains(t, model.View(), "with 2 files at once, 9 streams per large file, saved for 'b2'")
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "copy b2:bucket/.cloud-sync-trash/docs/2026-10-10 "+local+" --config "+mgr.GetConfigPath()+" --transfers 2 --multi-thread-streams 9", strings.TrimSpace(string(data)))
+
+	// The next restore starts from the saved profile
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	assert.Equal(t, &rclone.RestoreTuning{Transfers: 2, MultiThreadStreams: 9}, appConfig.RestoreTuning("b2"))
+	model, cmd = model.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+	view = model.View()
+	assert.Contains(t, view, "Streams per large file (--multi-thread-streams): 9")
+	assert.Contains(t, view, "Starting from the profile saved for 'b2'. Auto-tuned: 2 files at once, 16 streams per large file.")
+
+	// a goes back to the auto-tuned settings, esc cancels
+	model, _ = model.Update(keyPress("a"))
+	assert.Contains(t, model.View(), "Streams per large file (--multi-thread-streams): 16")
+	model, _ = model.Update(keyPress("esc"))
+	assert.Contains(t, model.View(), "Restore cancelled")
+}
@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// waitForRun waits until the registry tracks a process
+func waitForRun(t *testing.T, runs *rclone.RunRegistry) *rclone.Run {
+	t.Helper()
+	require.Eventually(t, func() bool { return len(runs.Running()) > 0 }, 5*time.Second, 10*time.Millisecond)
+	return runs.Running()[0]
+}
+
+func TestRunRegistryInterruptsThenKills(t *testing.T) {
+	runs := rclone.NewRunRegistry()
+	dir := t.TempDir()
+	ready, interrupted := filepath.Join(dir, "ready"), filepath.Join(dir, "interrupted")
+
+	// A process that exits on SIGINT
+	run, err := runs.Start("sync", exec.Command("sh", "-c", "trap 'touch "+interrupted+"; exit 1' INT; touch "+ready+"; sleep 10 >/dev/null 2>&1 & wait"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { _, err := os.Stat(ready); return err == nil }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "sync", run.Command)
+	assert.NotZero(t, run.PID)
+	assert.Len(t, runs.Running(), 1)
+
+	assert.Equal(t, 1, runs.CancelAll(5*time.Second))
+	assert.ErrorIs(t, run.Wait(), rclone.ErrCancelled)
+	assert.FileExists(t, interrupted)
+	assert.Empty(t, runs.Running())
+
+	// One that ignores it is killed after the grace period
+	require.NoError(t, os.Remove(ready))
+	run, err = runs.Start("sync", exec.Command("sh", "-c", "trap '' INT; touch "+ready+"; sleep 10 >/dev/null 2>&1 & wait"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { _, err := os.Stat(ready); return err == nil }, 5*time.Second, 10*time.Millisecond)
+	start := time.Now()
+	run.Cancel(100 * time.Millisecond)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.ErrorIs(t, run.Wait(), rclone.ErrCancelled)
+}
+
+func TestRunRegistryUntracksFinishedRuns(t *testing.T) {
+	runs := rclone.NewRunRegistry()
+	run, err := runs.Start("copy", exec.Command("sh", "-c", "exit 3"))
+	require.NoError(t, err)
+
+	err = run.Wait()
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, rclone.ErrCancelled)
+	assert.Empty(t, runs.Running())
+	assert.Zero(t, runs.CancelAll(time.Second))
+}
+
+func TestRcloneTransferCancelled(t *testing.T) {
+	manager := fakeRclone(t, "sleep 10 >/dev/null 2>&1 & wait\n")
+
+	result := make(chan error, 1)
+	go func() {
+		result <- manager.SyncLocalToRemote(t.TempDir(), "b2", "bucket", false, false)
+	}()
+
+	run := waitForRun(t, rclone.DefaultRuns)
+	assert.Equal(t, "sync", run.Command)
+	rclone.DefaultRuns.CancelAll(5 * time.Second)
+
+	err := <-result
+	require.Error(t, err)
+	assert.ErrorIs(t, err, rclone.ErrCancelled)
+}
+
+func TestLogsParsesCancelledSession(t *testing.T) {
+	dir := t.TempDir()
+	createTestLogFile(t, logs.PairLogPath(dir, "docs"), `2024/11/03 09:00:00 NOTICE: Manual Sync Requested
+2024/11/03 09:01:00 INFO  : first.txt: Copied (new)
+2024/11/03 09:01:30 NOTICE: Manual Sync Complete: Cancelled
+`)
+	manager := logs.NewManager(dir).ForPair("docs")
+
+	sessions, err := manager.GetSyncSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Cancelled)
+	assert.False(t, sessions[0].Success)
+	assert.False(t, sessions[0].EndTime.IsZero())
+
+	groups, err := manager.GetErrorGroups()
+	require.NoError(t, err)
+	assert.Empty(t, groups, "the marker is not reported as a notice")
+}
+
+func TestRunRegistryShutdownRefusesNewRuns(t *testing.T) {
+	runs := rclone.NewRunRegistry()
+	ready := filepath.Join(t.TempDir(), "ready")
+	run, err := runs.Start("sync", exec.Command("sh", "-c", "touch "+ready+"; sleep 10 >/dev/null 2>&1 & wait"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { _, err := os.Stat(ready); return err == nil }, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 1, runs.Shutdown(time.Second))
+	assert.ErrorIs(t, run.Wait(), rclone.ErrCancelled)
+
+	started := filepath.Join(t.TempDir(), "started")
+	_, err = runs.Start("sync", exec.Command("touch", started))
+	assert.ErrorIs(t, err, rclone.ErrCancelled)
+	assert.NoFileExists(t, started)
+}
+
+func TestBackupCleanupLockfile(t *testing.T) {
+	dir := t.TempDir()
+	lock := lockfile.NewManager(dir)
+	require.NoError(t, lock.Create())
+
+	// Nothing serves rclone's API here, so the lockfile is left over
+	require.NoError(t, backup.CleanupLockfile(dir, rclone.DefaultRCAddr))
+	assert.False(t, lock.Exists())
+	assert.NoError(t, backup.CleanupLockfile(dir, rclone.DefaultRCAddr))
+}
@@ -22,6 +22,8 @@ func createTestConfig(tmpDir string) *scripts.Config {
 		DestBucket:   "dest-bucket",
 		LogDir:       filepath.Join(tmpDir, "logs"),
 		BinDir:       filepath.Join(tmpDir, "bin"),
+		RCAddr:       "localhost:5580",
+		CloudSync:    "/usr/local/bin/cloud-sync",
 	}
 }
 
@@ -84,6 +86,17 @@ func TestGenerateEngineScript(t *testing.T) {
 	assert.Contains(t, contentStr, config.DestRemote)
 	assert.Contains(t, contentStr, "#!/bin/zsh")
 
+	// rclone serves its API at the configured address, unless it is taken
+	assert.Contains(t, contentStr, `RC_ADDR="localhost:5580"`)
+	assert.Contains(t, contentStr, `lsof -nP -iTCP:"${RC_ADDR##*:}" -sTCP:LISTEN`)
+	assert.Contains(t, contentStr, `"${RC_FLAGS[@]}"`)
+	assert.NotContains(t, contentStr, "5572")
+
+	// Credential references are resolved by cloud-sync before rclone runs
+	assert.Contains(t, contentStr, `CLOUD_SYNC="/usr/local/bin/cloud-sync"`)
+	assert.Contains(t, contentStr, `CREDENTIALS=$("$CLOUD_SYNC" config env`)
+	assert.Contains(t, contentStr, `eval "$CREDENTIALS"`)
+
 	// Verify executable permissions
 	info, err := os.Stat(scriptPath)
 	require.NoError(t, err)
@@ -115,6 +128,14 @@ func TestGenerateMonthlyScript(t *testing.T) {
 	assert.Contains(t, contentStr, config.LogDir)
 	assert.Contains(t, contentStr, "lockfile")
 	assert.Contains(t, contentStr, "timestamp")
+	// Zone changes do not move the last run or the log lines
+	assert.Contains(t, contentStr, `date '+%s' > "$TIMESTAMP_FILE"`)
+	assert.Contains(t, contentStr, `date -r "$LAST_RUN" '+%Y-%m'`)
+	assert.Contains(t, contentStr, `$(date '+%Y/%m/%d %H:%M:%S %z') INFO  : Automated Check Started`)
+	// Pairs sync through the CLI, which defers them on low battery and
+	// applies their network rules
+	assert.Contains(t, contentStr, `CLOUD_SYNC="/usr/local/bin/cloud-sync"`)
+	assert.Contains(t, contentStr, `"$CLOUD_SYNC" sync --scheduled --all`)
 }
 
 func TestGenerateManualScript(t *testing.T) {
@@ -378,3 +399,60 @@ func TestScriptPaths(t *testing.T) {
 		})
 	}
 }
+
+func TestGeneratorUsesTemplateOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	config := createTestConfig(tmpDir)
+	overrideDir := filepath.Join(tmpDir, "templates")
+	require.NoError(t, os.MkdirAll(overrideDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(overrideDir, "sync_now.sh.tmpl"), []byte("#!/bin/zsh\necho custom {{.DestBucket}}\n"), 0644))
+
+	gen := scripts.NewGeneratorWithOverrides(overrideDir)
+	assert.Equal(t, []string{"sync_now.sh"}, gen.Overrides())
+	require.NoError(t, gen.Install(config))
+
+	content, err := os.ReadFile(filepath.Join(config.BinDir, "sync_now.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/zsh\necho custom dest-bucket\n", string(content))
+
+	// The others still come from the built-in templates
+	content, err = os.ReadFile(filepath.Join(config.BinDir, "run_rclone_sync.sh"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Core rclone execution script")
+
+	// A broken override names the file to fix
+	require.NoError(t, os.WriteFile(filepath.Join(overrideDir, "sync_now.sh.tmpl"), []byte("{{.Missing"), 0644))
+	err = gen.Install(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(overrideDir, "sync_now.sh.tmpl"))
+}
+
+func TestGeneratorExportTemplates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "templates")
+	gen := scripts.NewGeneratorWithOverrides(dir)
+
+	written, err := gen.ExportTemplates(dir)
+	require.NoError(t, err)
+	assert.Len(t, written, len(scripts.Templates))
+	assert.Len(t, gen.Overrides(), len(scripts.Templates))
+
+	// Edited templates are not overwritten
+	custom := filepath.Join(dir, "sync_now.sh.tmpl")
+	require.NoError(t, os.WriteFile(custom, []byte("custom"), 0644))
+	written, err = gen.ExportTemplates(dir)
+	require.NoError(t, err)
+	assert.Empty(t, written)
+	content, err := os.ReadFile(custom)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", string(content))
+}
+
+func TestInstallRejectsIncompleteConfig(t *testing.T) {
+	config := createTestConfig(t.TempDir())
+	config.DestBucket = ""
+
+	err := scripts.NewGeneratorWithOverrides("").Install(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DestBucket is required")
+	assert.NoDirExists(t, config.BinDir)
+}
@@ -0,0 +1,238 @@
+package unit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/status"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+// checkOutput is what 'rclone check --combined -' prints for a source with
+// one identical file, one changed file, one file not yet uploaded and one
+// file only at the destination
+const checkOutput = `= same.txt
+* changed.txt
++ new.txt
+- extra.txt
+`
+
+func TestRcloneCheck(t *testing.T) {
+	args := filepath.Join(t.TempDir(), "args")
+	manager := fakeRclone(t, fmt.Sprintf(`echo "$@" > %s
+printf '%%s' '%s'
+echo 'NOTICE: Encrypted drive: No common hash found - not using a hash for checks' >&2
+exit 1
+`, args, checkOutput))
+
+	result, err := manager.Check("/src", "b2:bucket/docs", rclone.SyncOptions{FilterFrom: "/filter", Command: "copy"})
+	require.NoError(t, err, "differences are not an error")
+	assert.Equal(t, 1, result.Matched)
+	assert.Equal(t, []string{"changed.txt"}, result.Differ)
+	assert.Equal(t, []string{"new.txt"}, result.MissingOnDest)
+	assert.Equal(t, []string{"extra.txt"}, result.MissingOnSource)
+	assert.Empty(t, result.Errors)
+	assert.True(t, result.SizeOnly)
+	assert.Equal(t, 3, result.Mismatches())
+
+	data, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "check /src b2:bucket/docs --combined - --config "))
+	assert.Contains(t, string(data), "--one-way --filter-from /filter")
+}
+
+func TestRcloneCheckFails(t *testing.T) {
+	// Exit 1 without differences listed is a failure
+	manager := fakeRclone(t, "echo 'ERROR : : error listing: 401 Unauthorized: bad_auth_token' >&2\nexit 1\n")
+	_, err := manager.Check("/src", "b2:bucket/docs", rclone.SyncOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "check failed")
+
+	manager = fakeRclone(t, "echo '= a.txt'\n")
+	result, err := manager.Check("/src", "b2:bucket/docs", rclone.SyncOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Matched)
+	assert.Zero(t, result.Mismatches())
+	assert.False(t, result.SizeOnly)
+}
+
+func TestScrubHistory(t *testing.T) {
+	manager := logs.NewManager(t.TempDir())
+	runs, err := manager.ScrubHistory()
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	files := make([]string, logs.MaxScrubFiles+5)
+	for i := range files {
+		files[i] = fmt.Sprintf("file%d", i)
+	}
+	require.NoError(t, manager.RecordScrub(logs.ScrubRun{Pair: "Docs", Destination: "b2:docs", Matched: 10}))
+	require.NoError(t, manager.RecordScrub(logs.ScrubRun{Pair: "Photos", Destination: "b2:photos", Matched: 5, Differ: 2, MissingOnDest: 1, Files: files}))
+	require.NoError(t, manager.RecordScrub(logs.ScrubRun{Pair: "Music", Destination: "b2:music", Error: "check failed: exit status 2"}))
+
+	runs, err = manager.ScrubHistory()
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	assert.Len(t, runs[1].Files, logs.MaxScrubFiles)
+	assert.Equal(t, "all 10 files match", runs[0].Summary())
+	assert.Equal(t, "2 differ, 1 missing at the destination (of 8 files)", runs[1].Summary())
+
+	open, err := manager.UnacknowledgedScrubs()
+	require.NoError(t, err)
+	require.Len(t, open, 2)
+	assert.Equal(t, "Photos", open[0].Pair)
+	assert.Equal(t, "Music", open[1].Pair)
+
+	count, err := manager.AcknowledgeScrubs()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	open, err = manager.UnacknowledgedScrubs()
+	require.NoError(t, err)
+	assert.Empty(t, open)
+
+	// Only the most recent runs are kept
+	for i := 0; i < logs.MaxScrubRuns; i++ {
+		require.NoError(t, manager.RecordScrub(logs.ScrubRun{Pair: "Docs", Matched: i}))
+	}
+	runs, err = manager.ScrubHistory()
+	require.NoError(t, err)
+	require.Len(t, runs, logs.MaxScrubRuns)
+	assert.Equal(t, 0, runs[0].Matched)
+}
+
+// setupScrub saves a config using a fake rclone whose check finds
+// checkOutput, and returns the file its arguments are appended to
+func setupScrub(t *testing.T, home string) string {
+	t.Helper()
+	args := filepath.Join(home, "args")
+	bin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte(fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %s
+case "$1" in
+version) echo 'rclone v1.66.0' ;;
+check) printf '%%s' '%s'; exit 1 ;;
+esac
+`, args, checkOutput)), 0755))
+
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = bin
+	appConfig.LogDir = filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(appConfig))
+	return args
+}
+
+func TestCLIScrub(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	args := setupScrub(t, home)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, cli.Run([]string{"scrub"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "No enabled pair has scrubbing on")
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:         "Photos",
+		LocalPath:    t.TempDir(),
+		RemoteName:   "b2",
+		RemotePath:   "bucket/photos",
+		Destinations: []syncconfig.Destination{{RemoteName: "s3", RemotePath: "backup/photos"}},
+		Direction:    "upload",
+		Enabled:      true,
+		Scrub:        true,
+	}))
+	addPlainPair(t, "Documents", true)
+
+	stdout.Reset()
+	stderr.Reset()
+	assert.Equal(t, 1, cli.Run([]string{"scrub"}, &stdout, &stderr), stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Scrubbing 'Photos'...")
+	assert.Contains(t, out, "  b2:bucket/photos: 1 differ, 1 missing at the destination, 1 only at the destination (of 4 files)\n    changed.txt\n")
+	assert.Contains(t, out, "  s3:backup/photos: ")
+	assert.Contains(t, out, "cloud-sync scrub --ack")
+	assert.NotContains(t, out, "Documents", "pairs without scrub are left out")
+
+	data, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "check "))
+
+	log, err := os.ReadFile(filepath.Join(home, "logs", "pairs", "Photos.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "NOTICE: Scrub b2:bucket/photos: 1 differ")
+
+	// The problems stay in the status until acknowledged
+	summary := status.Load(launchd.NewManager("tester"), time.Now())
+	require.Len(t, summary.ScrubIssues, 2)
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"status"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "Integrity: 'Photos' at b2:bucket/photos: 1 differ")
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"scrub", "--history"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "(not acknowledged)")
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"scrub", "--ack"}, &stdout, &stderr))
+	assert.Equal(t, "Acknowledged 2 scrub run(s) with problems.\n", stdout.String())
+	summary = status.Load(launchd.NewManager("tester"), time.Now())
+	assert.Empty(t, summary.ScrubIssues)
+
+	// Named pairs are checked whether or not scrub is set
+	stdout.Reset()
+	assert.Equal(t, 1, cli.Run([]string{"scrub", "Documents"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "  b2:bucket/Documents: ")
+}
+
+func TestScrubRejectsSnapshotPairs(t *testing.T) {
+	pair := syncconfig.SyncPair{
+		Name:       "Snapshots",
+		LocalPath:  t.TempDir(),
+		RemoteName: "b2",
+		RemotePath: "bucket/snap",
+		Direction:  "upload",
+		Snapshot:   true,
+		Scrub:      true,
+	}
+	err := syncconfig.NewManager(filepath.Join(t.TempDir(), "sync.json")).AddSyncPair(pair)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "integrity scrub is not supported")
+}
+
+func TestScrubAgentPlist(t *testing.T) {
+	data, err := launchd.RenderPlist(launchd.ScrubConfig("com.tester.cloudsync-scrub", "/usr/local/bin/cloud-sync"))
+	require.NoError(t, err)
+	plist := string(data)
+	assert.Contains(t, plist, "<string>scrub</string>\n\t\t<string>--scheduled</string>")
+	assert.Contains(t, plist, "<key>StartCalendarInterval</key>")
+	assert.Contains(t, plist, "<key>Day</key>\n\t\t\t<integer>1</integer>")
+	assert.Equal(t, "com.tester.cloudsync-scrub", launchd.NewManager("tester").Scrub().GetLabel())
+}
+
+func TestDashboardScrubBadge(t *testing.T) {
+	summary := &status.Summary{ScrubIssues: []logs.ScrubRun{{Pair: "Photos", Destination: "b2:photos", Matched: 3, Differ: 1}}}
+	view := testutil.PlainText(views.RenderDashboard(summary, 0, nil, 120))
+	assert.Contains(t, view, "✗ Integrity: 'Photos' at b2:photos: 1 differ (of 4 files) • i: acknowledge")
+
+	summary.ScrubIssues = append(summary.ScrubIssues, logs.ScrubRun{Pair: "Music", Error: "exit status 2"}, logs.ScrubRun{Pair: "Photos", Errors: 1})
+	view = testutil.PlainText(views.RenderDashboard(summary, 0, nil, 120))
+	assert.Contains(t, view, "Integrity: 3 scrub runs found problems (Photos, Music)")
+}
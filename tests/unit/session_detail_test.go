@@ -0,0 +1,213 @@
+package unit
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+const sessionLog = `2024/11/01 09:00:00 NOTICE: Manual Sync Requested
+2024/11/01 09:01:00 INFO  : first.txt: Copied (new)
+2024/11/01 09:02:00 ERROR : locked.txt: Failed to copy: permission denied
+Transferred:   	  10 KiB / 10 KiB, 100%, 1 KiB/s, ETA 0s
+Errors:                 1 (retrying may help)
+Elapsed time:        2m0.0s
+2024/11/01 09:02:01 NOTICE: Manual Sync Complete: Failed
+
+2024/11/02 09:00:00 NOTICE: Manual Sync Requested
+2024/11/02 09:01:00 INFO  : second.txt: Copied (new)
+2024/11/02 09:01:30 INFO  : third.txt: Copied (new)
+Transferred:   	  20 KiB / 20 KiB, 100%, 1 KiB/s, ETA 0s
+Elapsed time:        1m30.0s
+2024/11/02 09:02:00 NOTICE: Manual Sync Complete: Success
+`
+
+func TestLogsGetSessionDetail(t *testing.T) {
+	dir := t.TempDir()
+	createTestLogFile(t, logs.PairLogPath(dir, "docs"), sessionLog)
+	manager := logs.NewManager(dir).ForPair("docs")
+
+	sessions, err := manager.GetSyncSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	detail, err := manager.GetSessionDetail(sessions[0])
+	require.NoError(t, err)
+	require.Len(t, detail.Transfers, 1)
+	assert.Equal(t, "first.txt", detail.Transfers[0].Filename)
+	assert.Equal(t, "docs", detail.Transfers[0].Pair)
+	require.Len(t, detail.Errors, 1)
+	assert.Contains(t, detail.Errors[0], "permission denied")
+	assert.Equal(t, []string{
+		"Transferred:   	  10 KiB / 10 KiB, 100%, 1 KiB/s, ETA 0s",
+		"Errors:                 1 (retrying may help)",
+		"Elapsed time:        2m0.0s",
+	}, detail.Stats)
+	assert.Len(t, detail.Lines, 7)
+
+	detail, err = manager.GetSessionDetail(sessions[1])
+	require.NoError(t, err)
+	assert.Len(t, detail.Transfers, 2)
+	assert.Empty(t, detail.Errors)
+	assert.Contains(t, detail.Lines[0], "2024/11/02")
+
+	_, err = manager.GetSessionDetail(logs.SyncSession{})
+	assert.Error(t, err)
+}
+
+func TestLogViewerOpensSessionDetail(t *testing.T) {
+	dir := t.TempDir()
+	createTestLogFile(t, logs.PairLogPath(dir, "docs"), sessionLog)
+	manager := logs.NewManager(dir)
+	manager.AddPairs("docs")
+
+	var model tea.Model = views.NewLogViewerModel(manager, views.LogViewSessions, 120, 60)
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 120, Height: 60})
+	model, _ = model.Update(model.Init()())
+	require.Contains(t, model.View(), "Total sessions: 2")
+
+	// Newest first; move to the older, failed session
+	model, _ = model.Update(keyPress("down"))
+	model, cmd := model.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+
+	view := model.View()
+	assert.Contains(t, view, "Pair: docs")
+	assert.Contains(t, view, "first.txt")
+	assert.NotContains(t, view, "second.txt")
+	assert.Contains(t, view, "permission denied")
+	assert.Contains(t, view, "Elapsed time:        2m0.0s")
+
+	// esc closes the detail, then the viewer
+	model, cmd = model.Update(keyPress("esc"))
+	assert.Nil(t, cmd)
+	assert.Contains(t, model.View(), "Total sessions: 2")
+
+	_, cmd = model.Update(keyPress("esc"))
+	require.NotNil(t, cmd)
+	assert.Equal(t, views.BackMsg{}, cmd())
+}
+
+func TestLogViewerRerunsFailedSession(t *testing.T) {
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	dir := t.TempDir()
+	createTestLogFile(t, logs.PairLogPath(dir, "docs"), sessionLog)
+	manager := logs.NewManager(dir)
+	manager.AddPairs("docs")
+
+	var model tea.Model = views.NewLogViewerModel(manager, views.LogViewSessions, 120, 60)
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 120, Height: 60})
+	model, _ = model.Update(model.Init()())
+	assert.Contains(t, model.View(), "r: Refresh", "the newest session succeeded")
+
+	model, _ = model.Update(keyPress("down"))
+	assert.Contains(t, model.View(), "r: Re-run pair")
+
+	views.SetReadOnly(true)
+	refused, cmd := model.Update(keyPress("r"))
+	views.SetReadOnly(false)
+	assert.Nil(t, cmd)
+	assert.Contains(t, refused.View(), "Syncing is disabled in read-only mode")
+
+	_, cmd = model.Update(keyPress("r"))
+	require.NotNil(t, cmd)
+	open, ok := cmd().(views.OpenViewMsg)
+	require.True(t, ok)
+	run := open.View
+	assert.Contains(t, run.View(), "Syncing 'docs'...")
+
+	for _, msg := range runBatch(run.Init()) {
+		run, _ = run.Update(msg)
+	}
+	actions := simulate.Actions()
+	require.Len(t, actions, 1)
+	assert.Equal(t, []string{"sync", "docs"}, actions[0].Args)
+	assert.Contains(t, run.View(), "Backup completed successfully")
+
+	_, cmd = run.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	done, ok := cmd().(views.DoneMsg)
+	require.True(t, ok)
+	assert.Equal(t, "Synced 'docs'", done.Message)
+
+	// The viewer shows the result and lists the new session
+	model, cmd = model.Update(done)
+	require.NotNil(t, cmd)
+	assert.Contains(t, model.View(), "Synced 'docs'")
+}
+
+func TestLogsGetErrorGroups(t *testing.T) {
+	dir := t.TempDir()
+	createTestLogFile(t, logs.PairLogPath(dir, "docs"), sessionLog+
+		`2024/11/03 09:00:00 NOTICE: Manual Sync Requested
+2024/11/03 09:00:10 ERROR : other.txt: Failed to copy: permission denied
+2024/11/03 09:00:20 ERROR : report 2.pdf: Failed to copy: permission denied
+2024/11/03 09:00:30 NOTICE: Time may be set wrong - time from "s3.example.com" is -12m0s different from this computer
+2024/11/03 09:01:00 NOTICE: Manual Sync Complete: Failed
+`)
+	manager := logs.NewManager(dir)
+	manager.AddPairs("docs")
+
+	groups, err := manager.GetErrorGroups()
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+
+	// Most recently seen first
+	denied := groups[1]
+	assert.Equal(t, "ERROR", denied.Level)
+	assert.Equal(t, "…: Failed to copy: permission denied", denied.Signature)
+	assert.Equal(t, 3, denied.Count)
+	assert.Equal(t, "2024-11-03 09:00:20", denied.LastSeen.Format("2006-01-02 15:04:05"))
+	assert.Equal(t, "report 2.pdf: Failed to copy: permission denied", denied.Example)
+	assert.Equal(t, []string{"docs"}, denied.Pairs)
+	require.Len(t, denied.Sessions, 2)
+	latest, ok := denied.LatestSession()
+	require.True(t, ok)
+	assert.Equal(t, "2024-11-03", latest.StartTime.Format("2006-01-02"))
+
+	// Numbers are masked; session markers are not listed
+	assert.Equal(t, "NOTICE", groups[0].Level)
+	assert.Contains(t, groups[0].Signature, "is -NmNs different")
+	for _, group := range groups {
+		assert.NotContains(t, group.Signature, "Manual Sync")
+	}
+
+	detail, err := manager.GetSessionDetail(latest)
+	require.NoError(t, err)
+	assert.Len(t, detail.Errors, 2)
+}
+
+func TestLogViewerErrorsOpenLatestSession(t *testing.T) {
+	dir := t.TempDir()
+	createTestLogFile(t, logs.PairLogPath(dir, "docs"), sessionLog)
+	manager := logs.NewManager(dir)
+	manager.AddPairs("docs")
+
+	var model tea.Model = views.NewLogViewerModel(manager, views.LogViewAll, 120, 60)
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 120, Height: 60})
+	model, cmd := model.Update(keyPress("6"))
+	model, _ = model.Update(cmd())
+
+	view := model.View()
+	assert.Contains(t, view, "Errors and notices")
+	assert.Contains(t, view, "ERROR ×1")
+	assert.Contains(t, view, "Failed to copy: permission denied")
+
+	model, cmd = model.Update(keyPress("enter"))
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+	view = model.View()
+	assert.Contains(t, view, "first.txt")
+	assert.NotContains(t, view, "second.txt")
+
+	model, _ = model.Update(keyPress("esc"))
+	assert.Contains(t, model.View(), "Distinct messages: 1")
+}
@@ -0,0 +1,55 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/launchd"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+)
+
+func TestSimulateTakeFlag(t *testing.T) {
+	args, found := simulate.TakeFlag([]string{"sync", "--simulate", "--all"})
+	assert.True(t, found)
+	assert.Equal(t, []string{"sync", "--all"}, args)
+
+	args, found = simulate.TakeFlag([]string{"status"})
+	assert.False(t, found)
+	assert.Equal(t, []string{"status"}, args)
+}
+
+func TestSimulateRecordsCommandsInsteadOfRunning(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+
+	// The binary does not exist, so only the stand-in can succeed
+	manager := rclone.NewManagerWithConfig(filepath.Join(t.TempDir(), "rclone"), "/tmp/rclone.conf")
+	remotes, err := manager.ListRemotes()
+	require.NoError(t, err)
+	assert.Empty(t, remotes)
+
+	// launchctl answers that the agent is not loaded
+	agent := launchd.NewManager("tester")
+	status, err := agent.GetStatus()
+	require.NoError(t, err)
+	assert.False(t, status.Loaded)
+	require.NoError(t, agent.Unload())
+
+	actions := simulate.Actions()
+	require.Len(t, actions, 3)
+	assert.Equal(t, "rclone listremotes --config /tmp/rclone.conf", actions[0].String())
+	assert.Equal(t, fmt.Sprintf("launchctl print gui/%d/com.tester.rclonebackup", os.Getuid()), actions[1].String())
+	assert.Equal(t, "launchctl", actions[2].Name)
+	assert.Equal(t, "bootout", actions[2].Args[0])
+
+	simulate.Reset()
+	assert.False(t, simulate.Enabled())
+	assert.Empty(t, simulate.Actions())
+}
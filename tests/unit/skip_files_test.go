@@ -0,0 +1,41 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/rsync"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+)
+
+func TestSyncPairSkipSettings(t *testing.T) {
+	pair := syncconfig.SyncPair{Name: "docs", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket", Direction: "upload",
+		SkipHidden: true, SkipSymlinks: true, SkipLargerThan: " 1.5g "}
+	require.NoError(t, syncconfig.ValidateSyncPair(&pair))
+	assert.Equal(t, "1.5G", pair.SkipLargerThan)
+
+	pair.SkipLargerThan = "2"
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "'2' is not a size like 500M or 2G")
+
+	pair.SkipLargerThan = ""
+	pair.Archive = true
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "not supported in archive mode")
+}
+
+func TestSkipOptionsFlags(t *testing.T) {
+	args := strings.Join(rclone.NewManagerWithConfig("rclone", "/rclone.conf").BuildSyncArgs("/src", "b2:bucket",
+		rclone.SyncOptions{SkipHidden: true, SkipLinks: true, MaxSize: "2G"}), " ")
+	assert.Contains(t, args, "--exclude .* --exclude .*/** --skip-links --max-size 2G")
+
+	args = strings.Join(rsync.NewManager("rsync").BuildArgs("/src", "/dst",
+		rsync.Options{SkipHidden: true, SkipLinks: true, MaxSize: "2G"}), " ")
+	assert.Contains(t, args, "--exclude .* --no-links --max-size 2G")
+
+	args = strings.Join(rclone.NewManagerWithConfig("rclone", "/rclone.conf").BuildSyncArgs("/src", "b2:bucket", rclone.SyncOptions{}), " ")
+	assert.NotContains(t, args, "--skip-links")
+	assert.NotContains(t, args, "--max-size")
+}
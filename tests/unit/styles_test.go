@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/styles"
+)
+
+func TestStylesResolveTheme(t *testing.T) {
+	theme, err := styles.ResolveTheme("light", nil)
+	require.NoError(t, err)
+	assert.Equal(t, styles.LightTheme, theme)
+
+	theme, err = styles.ResolveTheme("high-contrast", map[string]string{"primary": "#123456"})
+	require.NoError(t, err)
+	assert.Equal(t, lipgloss.Color("#123456"), theme.Primary)
+	assert.Equal(t, styles.HighContrastTheme.Error, theme.Error)
+
+	_, err = styles.ResolveTheme("neon", nil)
+	assert.Error(t, err)
+
+	_, err = styles.ResolveTheme("dark", map[string]string{"primary": "blue"})
+	assert.Error(t, err)
+
+	_, err = styles.ResolveTheme("dark", map[string]string{"background": "#000000"})
+	assert.Error(t, err)
+}
+
+func TestStylesApplyTheme(t *testing.T) {
+	defer styles.ApplyTheme(styles.DarkTheme)
+
+	styles.ApplyTheme(styles.LightTheme)
+	assert.Equal(t, styles.LightTheme.Primary, styles.PrimaryColor)
+	assert.Equal(t, styles.LightTheme.Primary, styles.TitleStyle.GetForeground())
+	assert.Equal(t, styles.LightTheme.Highlight, styles.TableStyles().Selected.GetBackground())
+	assert.Equal(t, styles.ThemeLight, styles.CurrentTheme().Name)
+}
+
+func TestCLIConfigTheme(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, cli.Run([]string{"config", "theme", "--palette", "error=#AA0000", "light"}, &stdout, &stderr), stderr.String())
+
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "light", appConfig.UI.Theme)
+	assert.Equal(t, map[string]string{"error": "#AA0000"}, appConfig.UI.Palette)
+
+	stderr.Reset()
+	assert.Equal(t, 2, cli.Run([]string{"config", "theme", "sepia"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "unknown theme")
+}
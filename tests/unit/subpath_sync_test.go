@@ -0,0 +1,191 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/ignore"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+func TestIgnoreRebase(t *testing.T) {
+	rules := append(ignore.Parse("proj/app", "dist/\n"), ignore.Parse("proj", "*.o\n!/keep.o\n")...)
+	rules = append(rules, ignore.Parse(".", "*.log\n/TODO.md\nproj/app/secret.txt\n")...)
+
+	// Rules anchored in proj but outside app are dropped; those for any
+	// depth below proj apply at any depth in app
+	assert.Equal(t, []string{
+		"- /dist/**", "- dist/**",
+		"- *.o", "- *.o/**",
+		"- *.log", "- *.log/**", "- /secret.txt", "- /secret.txt/**",
+	}, ruleLines(ignore.Rebase(rules, "proj/app")))
+	assert.Equal(t, []string{"- *.log", "- *.log/**"}, ruleLines(ignore.Rebase(rules, "other")))
+}
+
+func TestSyncPairSubtree(t *testing.T) {
+	pair := syncconfig.SyncPair{
+		Name: "Docs", LocalPath: "/Users/tester/Documents", RemoteName: "b2", RemotePath: "bucket/docs",
+		Direction: "upload", Destinations: []syncconfig.Destination{{RemoteName: "s3", RemotePath: "mirror"}},
+	}
+
+	sub, err := pair.Subtree(" proj/app/ ")
+	require.NoError(t, err)
+	assert.Equal(t, "proj/app", sub.Subpath)
+	assert.Equal(t, "/Users/tester/Documents/proj/app", sub.LocalPath)
+	assert.Equal(t, "/Users/tester/Documents", sub.Root())
+	assert.Equal(t, []syncconfig.Destination{
+		{RemoteName: "b2", RemotePath: "bucket/docs/proj/app"},
+		{RemoteName: "s3", RemotePath: "mirror/proj/app"},
+	}, sub.AllDestinations())
+	assert.Equal(t, "bucket/docs", pair.RemotePath, "the pair is not changed")
+	assert.Equal(t, pair.LocalPath, pair.Root())
+
+	local := syncconfig.SyncPair{Name: "Mirror", LocalPath: "/src", Type: syncconfig.TypeLocal, TargetPath: "/Volumes/Backup/src"}
+	sub, err = local.Subtree("photos")
+	require.NoError(t, err)
+	assert.Equal(t, "/Volumes/Backup/src/photos", sub.TargetPath)
+
+	for _, subpath := range []string{"", ".", "/etc", "../other", "proj/../../x"} {
+		_, err := pair.Subtree(subpath)
+		assert.ErrorContains(t, err, "invalid subpath", subpath)
+	}
+	pair.Snapshot = true
+	_, err = pair.Subtree("proj")
+	assert.ErrorContains(t, err, "whole folder")
+}
+
+func TestBackupSyncSubpath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	docs := filepath.Join(home, "Documents")
+	writeFiles(t, docs, map[string]string{
+		ignore.FileName:           "*.log\n/proj/secret.txt\n",
+		"proj/main.go":            "package main",
+		"proj/" + ignore.FileName: "build/\n",
+		"other/notes.txt":         "notes",
+	})
+
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Documents", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload",
+		Enabled: true, MaxDeletePercent: 100, SoftDelete: true,
+	}))
+
+	called := filepath.Join(home, "args")
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte("#!/bin/sh\necho \"$@\" >> "+called+"\n"), 0755))
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: rcloneBin,
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, manager.SyncSubpath("Documents", "missing", false, false), "subpath validation failed")
+	require.NoError(t, manager.SyncSubpath("Documents", "proj", false, false))
+
+	args, err := os.ReadFile(called)
+	require.NoError(t, err)
+	trash := rclone.TrashPath("bucket/docs", "Documents", time.Now()) + "/proj"
+	assert.Contains(t, string(args), "sync "+filepath.Join(docs, "proj")+" b2:bucket/docs/proj")
+	assert.Contains(t, string(args), "--backup-dir b2:"+trash)
+
+	// The ignore files of the pair's folder apply below the subtree
+	rules, err := os.ReadFile(filepath.Join(home, ".cache", "cloud-sync", "filters", "Documents.filter"))
+	require.NoError(t, err)
+	assert.Equal(t, "- build/**\n- /build/**\n- /secret.txt/**\n- /secret.txt\n- *.log/**\n- *.log\n", string(rules))
+
+	// The session records the folder and is left out of the pair's history
+	sessions, err := logs.NewManager(filepath.Join(home, "logs")).ForPair("Documents").GetSyncSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "proj", sessions[0].Subpath)
+	assert.True(t, sessions[0].Success)
+	assert.Equal(t, 0, logs.HistoryOf(sessions).Runs)
+}
+
+func TestCLISyncSubpath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	docs := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(docs, "proj", "app"), 0755))
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Docs", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true,
+	}))
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, 2, cli.Run([]string{"sync", "--path", "proj", "--all"}, &stdout, &stderr))
+	assert.Equal(t, 2, cli.Run([]string{"sync", "--path", "proj", "Docs", "Other"}, &stdout, &stderr))
+
+	require.Equal(t, 0, cli.Run([]string{"sync", "--path", "proj/app", "Docs"}, &stdout, &stderr), stdout.String())
+	assert.Contains(t, stdout.String(), "Syncing proj/app of Docs...")
+	assert.Contains(t, simulatedCommands(), "sync "+filepath.Join(docs, "proj", "app")+" b2:bucket/docs/proj/app")
+
+	stdout.Reset()
+	assert.Equal(t, 1, cli.Run([]string{"sync", "--path", "../etc", "Docs"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "Docs: failed: invalid subpath '../etc'")
+}
+
+func TestSyncPairsSyncsFolder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	docs := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(docs, "projects", "app"), 0755))
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Docs", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true,
+	}))
+
+	var model tea.Model = views.NewSyncPairsModel(mgr, rclone.NewManager("rclone"))
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+	assert.Contains(t, model.View(), "p: Sync folder")
+
+	model, _ = model.Update(keyPress("p"))
+	assert.Contains(t, model.View(), "Sync one folder of 'Docs' now")
+	model = typeText(model, "missing")
+	assert.Contains(t, model.View(), "path does not exist")
+
+	for range "missing" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	for _, r := range "proj" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	assert.Contains(t, model.View(), "projects/")
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	for _, r := range "app" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	model, cmd := model.Update(keyPress("enter"))
+	assert.Contains(t, model.View(), "Syncing projects/app of 'Docs'...")
+	for _, msg := range runBatch(cmd) {
+		model, _ = model.Update(msg)
+	}
+	actions := simulate.Actions()
+	require.Len(t, actions, 1)
+	assert.Equal(t, []string{"sync", "--progress", "--path", "projects/app", "Docs"}, actions[0].Args)
+	assert.NotContains(t, model.View(), "Syncing projects/app")
+}
@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestSyncPairsWizardModeStep(t *testing.T) {
+	manager := fakeRclone(t, "exit 1\n")
+
+	// Upload in copy mode skips the soft delete question
+	model := wizardAtRemotePath(t, manager)
+	model = typeText(model, "bucket/docs")
+	model = typeText(model, "1")
+	view := model.View()
+	assert.Contains(t, view, "copy: only add and update files")
+	assert.Contains(t, view, "3. move")
+
+	model = typeText(model, "2")
+	require.Contains(t, model.View(), "Additional destinations")
+	model = typeText(model, "")
+	model = typeText(model, "n")
+	require.Contains(t, model.View(), "Skip hidden files?")
+	model = typeText(model, "")
+	model = typeText(model, "")
+	model = typeText(model, "")
+	require.Contains(t, model.View(), "extra rclone flags")
+	model = typeText(model, "--track-renames")
+	view = model.View()
+	assert.Contains(t, view, "New Sync Pair Summary")
+	assert.Contains(t, view, "Mode: copy")
+	assert.Contains(t, view, "Extra rclone flags: --track-renames")
+
+	// Bidirectional pairs cannot move
+	model = wizardAtRemotePath(t, manager)
+	model = typeText(model, "bucket/docs")
+	model = typeText(model, "3")
+	assert.NotContains(t, model.View(), "3. move")
+	model = typeText(model, "3")
+	assert.Contains(t, model.View(), "must be one of: 1, 2")
+}
+
+func TestSyncPairsWizardRejectsManagedFlags(t *testing.T) {
+	model := wizardAtRemotePath(t, fakeRclone(t, "exit 1\n"))
+	model = typeText(model, "bucket/docs")
+	model = typeText(model, "2")
+	model = typeText(model, "1")
+	model = typeText(model, "")
+	model = typeText(model, "")
+	model = typeText(model, "")
+	model = typeText(model, "")
+	require.Contains(t, model.View(), "extra rclone flags")
+
+	model = typeText(model, "--config /tmp/other.conf")
+	assert.Contains(t, model.View(), "set by cloud-sync")
+}
+
+func TestSyncPairsWizardSkipSteps(t *testing.T) {
+	model := wizardAtRemotePath(t, fakeRclone(t, "exit 1\n"))
+	model = typeText(model, "bucket/docs")
+	model = typeText(model, "2")
+	model = typeText(model, "1")
+	require.Contains(t, model.View(), "Largest share of the destination a sync may delete")
+	model = typeText(model, "")
+	require.Contains(t, model.View(), "Skip hidden files?")
+	model = typeText(model, "y")
+	require.Contains(t, model.View(), "Skip symbolic links?")
+	model = typeText(model, "n")
+	require.Contains(t, model.View(), "Skip files larger than")
+	model = typeText(model, "big")
+	assert.Contains(t, model.View(), "not a size like 500M or 2G")
+	for range "big" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	model = typeText(model, "1.5g")
+	model = typeText(model, "")
+	assert.Contains(t, model.View(), "Skip: hidden files, files over 1.5G")
+}
+
+func TestSyncPairsWizardSnapshotRetention(t *testing.T) {
+	model := wizardAtRemotePath(t, fakeRclone(t, "exit 1\n"))
+	model = typeText(model, "bucket/docs")
+	model = typeText(model, "1")
+	model = typeText(model, "1")
+	model = typeText(model, "")
+	model = typeText(model, "y")
+	require.Contains(t, model.View(), "How many snapshots should be kept?")
+	model = typeText(model, "7")
+	require.Contains(t, model.View(), "How many days should snapshots be kept?")
+	model = typeText(model, "0")
+	assert.Contains(t, model.View(), "must be between 1 and 36500")
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	model = typeText(model, "30")
+	require.Contains(t, model.View(), "Skip hidden files?")
+	for range 4 {
+		model = typeText(model, "")
+	}
+	assert.Contains(t, model.View(), "Snapshots: keep 7, for 30 days")
+}
+
+func TestSyncPairsListLoadsInBackground(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+
+	var model tea.Model = views.NewSyncPairsModel(mgr, rclone.NewManager("rclone"))
+	assert.Contains(t, model.View(), "Loading sync pairs...")
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+	assert.Contains(t, model.View(), "No sync pairs configured")
+
+	// Pairs added elsewhere show up on refresh
+	addPlainPair(t, "Documents", true)
+	assert.NotContains(t, model.View(), "Documents")
+	model, cmd := model.Update(keyPress("r"))
+	for _, msg := range runBatch(cmd) {
+		model, _ = model.Update(msg)
+	}
+	view := model.View()
+	assert.Contains(t, view, "Documents")
+	assert.NotContains(t, view, "Loading")
+}
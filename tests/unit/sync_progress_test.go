@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// failingRclone writes a fake rclone that appends its arguments to args and
+// fails every transfer to a path containing fail
+func failingRclone(t *testing.T, home, args, fail string) string {
+	t.Helper()
+	bin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(bin, []byte(fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %s
+case "$1" in
+version) echo 'rclone v1.66.0' ;;
+*) case "$3" in *%s*) echo 'ERROR : failed to upload' >&2; exit 1 ;; esac ;;
+esac
+`, args, fail)), 0755))
+	return bin
+}
+
+func TestBuildSyncArgsServesRC(t *testing.T) {
+	manager := rclone.NewManagerWithConfig("rclone", "/rclone.conf")
+	args := strings.Join(manager.BuildSyncArgs("/src", "b2:bucket", rclone.SyncOptions{RCAddr: "127.0.0.1:5999"}), " ")
+	assert.Contains(t, args, "--rc --rc-addr 127.0.0.1:5999")
+
+	args = strings.Join(manager.BuildSyncArgs("/src", "b2:bucket", rclone.SyncOptions{}), " ")
+	assert.NotContains(t, args, "--rc")
+}
+
+func TestSyncAllEnabledReportsEachPair(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	addPlainPair(t, "Documents", true)
+	addPlainPair(t, "Photos", true)
+	addPlainPair(t, "Music", false)
+
+	args := filepath.Join(home, "args")
+	manager, err := backup.NewManager(&backup.Config{
+		Username:   "tester",
+		HomeDir:    home,
+		RclonePath: failingRclone(t, home, args, "Photos"),
+		LogDir:     filepath.Join(home, "logs"),
+	})
+	require.NoError(t, err)
+
+	var started []string
+	manager.SetPairsProgress(func(pair string, done, total int) {
+		started = append(started, fmt.Sprintf("%s %d/%d", pair, done, total))
+	})
+	manager.SetTransferProgress(func(string, backup.TransferProgress) {})
+
+	results, err := manager.SyncAllEnabled(false, false)
+	var allErr *backup.SyncAllError
+	require.True(t, errors.As(err, &allErr), "got %v", err)
+	assert.Contains(t, err.Error(), "1 of 2 pairs failed: 'Photos': ")
+
+	require.Len(t, results, 2, "a failing pair does not stop the others")
+	assert.Equal(t, "Documents", results[0].Pair)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "Photos", results[1].Pair)
+	assert.Error(t, results[1].Err)
+	assert.Equal(t, []string{"Documents 0/2", "Photos 1/2", " 2/2"}, started)
+
+	// While progress is followed, transfers serve rclone's API
+	data, err := os.ReadFile(args)
+	require.NoError(t, err)
+	transfers := 0
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.HasPrefix(line, "sync ") && !strings.Contains(line, "--dry-run") {
+			assert.Contains(t, line, "--rc --rc-addr 127.0.0.1:")
+			transfers++
+		}
+	}
+	assert.Equal(t, 1, transfers, "Photos fails its delete preview")
+}
+
+func TestCLISyncPrintsOutcomes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	appConfig, err := configManager.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = failingRclone(t, home, filepath.Join(home, "args"), "Photos")
+	appConfig.LogDir = filepath.Join(home, "logs")
+	require.NoError(t, configManager.Save(appConfig))
+	addPlainPair(t, "Documents", true)
+	addPlainPair(t, "Photos", true)
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 1, cli.Run([]string{"sync", "--all", "--progress"}, &stdout, &stderr), stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Progress: 0 of 2 pairs done, Documents: 0 of 0 files, 0 of 0 bytes\n")
+	assert.Contains(t, out, "Progress: 1 of 2 pairs done, Photos: 0 of 0 files, 0 of 0 bytes\n")
+	assert.Contains(t, out, "PAIR       RESULT  TIME\nDocuments  done    0s\nPhotos     failed  0s\nSynced 1 of 2 pair(s).\n")
+
+	// One pair needs no table, and without --progress no progress lines
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"sync", "Documents"}, &stdout, &stderr), stderr.String())
+	assert.NotContains(t, stdout.String(), "PAIR")
+	assert.NotContains(t, stdout.String(), "Progress:")
+}
@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/logs"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/pkg/backup"
+)
+
+// reportSession is a failed run that copied, deleted and logged an error
+const reportSession = `2026/10/14 03:00:00 NOTICE: Manual Sync Requested
+2026/10/14 03:00:01 INFO  : a.txt: Copied (new)
+2026/10/14 03:00:01 INFO  : <b>.txt: Copied (new)
+2026/10/14 03:00:02 INFO  : old.txt: Deleted
+2026/10/14 03:00:03 ERROR : c.txt: Failed to copy: permission denied
+Transferred:   	    1.500 MiB / 1.500 MiB, 100%, 0 B/s, ETA -
+Errors:                 1 (retrying may help)
+2026/10/14 03:01:30 NOTICE: Manual Sync Complete: Failed
+`
+
+// lastSessionDetail returns the detail of the last session in a pair's log
+func lastSessionDetail(t *testing.T, logDir, pair string) *logs.SessionDetail {
+	t.Helper()
+	pairLogs := logs.NewManager(logDir).ForPair(pair)
+	sessions, err := pairLogs.GetSyncSessions()
+	require.NoError(t, err)
+	require.NotEmpty(t, sessions)
+	detail, err := pairLogs.GetSessionDetail(sessions[len(sessions)-1])
+	require.NoError(t, err)
+	return detail
+}
+
+func TestRenderReport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"docs.log": reportSession})
+	detail := lastSessionDetail(t, dir, "docs")
+	assert.Equal(t, []string{"old.txt"}, detail.Deleted)
+
+	var markdown strings.Builder
+	require.NoError(t, logs.RenderReport(&markdown, detail, logs.ReportMarkdown))
+	report := markdown.String()
+	assert.Contains(t, report, "# Sync report: docs")
+	assert.Contains(t, report, "| Result | Failed |")
+	assert.Contains(t, report, "| Duration | 1m30s |")
+	assert.Contains(t, report, "| Transferred | 1.5 MiB |")
+	assert.Contains(t, report, "## Transferred (2)\n\n- a.txt\n- <b>.txt\n")
+	assert.Contains(t, report, "## Deleted (1)\n\n- old.txt\n")
+	assert.Contains(t, report, "## Errors (1)\n\n    2026/10/14 03:00:03 ERROR : c.txt: Failed to copy: permission denied\n")
+	assert.Contains(t, report, "```\nTransferred:")
+
+	var html strings.Builder
+	require.NoError(t, logs.RenderReport(&html, detail, logs.ReportHTML))
+	page := html.String()
+	assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+	assert.Contains(t, page, `<td class="Failed">Failed</td>`)
+	assert.Contains(t, page, "<li>&lt;b&gt;.txt</li>", "file names are escaped")
+	assert.Contains(t, page, "<li>old.txt</li>")
+
+	// Runs without changes say so
+	writeFiles(t, dir, map[string]string{"quiet.log": "2026/10/14 03:00:00 NOTICE: Manual Sync Requested\n2026/10/14 03:00:05 NOTICE: Manual Sync Complete: Success\n"})
+	markdown.Reset()
+	require.NoError(t, logs.RenderReport(&markdown, lastSessionDetail(t, dir, "quiet"), logs.ReportMarkdown))
+	assert.Contains(t, markdown.String(), "Nothing was transferred.")
+	assert.Contains(t, markdown.String(), "No errors.")
+}
+
+func TestWriteReportKeepsTheLastReports(t *testing.T) {
+	dir := t.TempDir()
+	manager := logs.NewManager(dir)
+	writeRunHistory(t, dir, "docs", 3)
+	writeRunHistory(t, dir, "photos", 1)
+
+	photos := lastSessionDetail(t, dir, "photos")
+	_, err := manager.WriteReport(photos, logs.ReportHTML, 2)
+	require.NoError(t, err)
+
+	sessions, err := manager.ForPair("docs").GetSyncSessions()
+	require.NoError(t, err)
+	var paths []string
+	for _, session := range sessions {
+		detail, err := manager.ForPair("docs").GetSessionDetail(session)
+		require.NoError(t, err)
+		path, err := manager.WriteReport(detail, "", 2)
+		require.NoError(t, err)
+		paths = append(paths, path)
+	}
+	assert.Equal(t, filepath.Join(dir, "reports", "2026-10-03_030000_docs.md"), paths[2])
+
+	// The oldest report of docs is gone, the one of photos stays
+	reports, err := manager.Reports("docs")
+	require.NoError(t, err)
+	assert.Equal(t, paths[1:], reports)
+	all, err := manager.Reports("")
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	latest, err := manager.LatestReport()
+	require.NoError(t, err)
+	assert.Equal(t, paths[2], latest)
+	latest, err = manager.ForPair("photos").LatestReport()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "reports", "2026-10-01_030000_photos.html"), latest)
+
+	_, err = manager.WriteReport(photos, "pdf", 2)
+	assert.EqualError(t, err, "report format must be markdown or html, not 'pdf'")
+}
+
+func TestBackupWritesReportAfterRun(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	docs := filepath.Join(home, "Documents")
+	writeFiles(t, docs, map[string]string{"a.txt": "a"})
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name: "Documents", LocalPath: docs, RemoteName: "b2", RemotePath: "bucket/docs", Direction: "upload", Enabled: true,
+	}))
+	logDir := filepath.Join(home, "logs")
+
+	// The fake rclone logs one copied file to the pair's log
+	rcloneBin := filepath.Join(home, "rclone")
+	require.NoError(t, os.WriteFile(rcloneBin, []byte(`#!/bin/sh
+case "$1" in
+size) echo '{"count":0,"bytes":0}' ;;
+sync)
+	prev=""
+	for arg in "$@"; do
+		[ "$arg" = "--dry-run" ] && exit 0
+		[ "$prev" = "--log-file" ] && echo "2026/10/15 03:00:00 INFO  : a.txt: Copied (new)" >> "$arg"
+		prev="$arg"
+	done ;;
+esac
+`), 0755))
+	manager, err := backup.NewManager(&backup.Config{Username: "tester", HomeDir: home, RclonePath: rcloneBin, LogDir: logDir, ReportFormat: logs.ReportHTML})
+	require.NoError(t, err)
+
+	require.NoError(t, manager.SyncPair("Documents", false, false))
+	report, err := logs.NewManager(logDir).ForPair("Documents").LatestReport()
+	require.NoError(t, err)
+	require.NotEmpty(t, report)
+	assert.Equal(t, ".html", filepath.Ext(report))
+	data, err := os.ReadFile(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<td class="Success">Success</td>`)
+	assert.Contains(t, string(data), "<li>a.txt</li>")
+
+	// Dry runs are not reported
+	require.NoError(t, manager.SyncPair("Documents", false, true))
+	reports, err := logs.NewManager(logDir).Reports("Documents")
+	require.NoError(t, err)
+	assert.Len(t, reports, 1)
+}
+
+func TestReportsConfig(t *testing.T) {
+	assert.NoError(t, config.ReportsConfig{}.Validate())
+	assert.NoError(t, config.ReportsConfig{Format: "html", Keep: 5}.Validate())
+	assert.EqualError(t, config.ReportsConfig{Format: "pdf"}.Validate(), "report format must be markdown or html, not 'pdf'")
+	assert.EqualError(t, config.ReportsConfig{Keep: -1}.Validate(), "reports to keep must be at least 1")
+}
+
+func TestLogViewerStatsLinkLatestReport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"docs.log": reportSession})
+	manager := logs.NewManager(dir)
+	manager.AddPairs("docs")
+	path, err := manager.WriteReport(lastSessionDetail(t, dir, "docs"), "", 0)
+	require.NoError(t, err)
+
+	var model tea.Model = views.NewLogViewerModel(manager, views.LogViewAll, 200, 60)
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+	model, cmd := model.Update(keyPress("5"))
+	model, _ = model.Update(cmd())
+	assert.Contains(t, model.View(), "Latest report:          "+path)
+}
@@ -3,7 +3,9 @@ package unit
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
 )
@@ -441,6 +443,19 @@ func TestValidateSyncPair(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			name: "negative trash retention",
+			pair: syncconfig.SyncPair{
+				Name:               "test",
+				LocalPath:          "/tmp",
+				RemoteName:         "remote1",
+				RemotePath:         "bucket/folder",
+				Direction:          "upload",
+				SoftDelete:         true,
+				TrashRetentionDays: -1,
+			},
+			shouldErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -507,3 +522,217 @@ func TestValidateLocalPath(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncPairTrashRetention(t *testing.T) {
+	pair := syncconfig.SyncPair{Name: "test"}
+	if got := pair.TrashRetention(); got != syncconfig.DefaultTrashRetentionDays*24*time.Hour {
+		t.Errorf("expected default retention, got %v", got)
+	}
+
+	pair.TrashRetentionDays = 7
+	if got := pair.TrashRetention(); got != 7*24*time.Hour {
+		t.Errorf("expected 7 days, got %v", got)
+	}
+}
+
+func TestParseDestination(t *testing.T) {
+	dest, err := syncconfig.ParseDestination(" nas:backups/docs ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dest.RemoteName != "nas" || dest.RemotePath != "backups/docs" {
+		t.Errorf("unexpected destination: %+v", dest)
+	}
+	if dest.String() != "nas:backups/docs" {
+		t.Errorf("unexpected string form: %s", dest)
+	}
+
+	for _, input := range []string{"", "nas", ":path", "nas:"} {
+		if _, err := syncconfig.ParseDestination(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestSyncPairDestinations(t *testing.T) {
+	pair := syncconfig.SyncPair{
+		Name:       "docs",
+		LocalPath:  "/tmp",
+		RemoteName: "b2",
+		RemotePath: "bucket/docs",
+		Direction:  "upload",
+		Destinations: []syncconfig.Destination{
+			{RemoteName: "nas", RemotePath: "backups/docs"},
+		},
+	}
+
+	dests := pair.AllDestinations()
+	if len(dests) != 2 || dests[0].String() != "b2:bucket/docs" || dests[1].String() != "nas:backups/docs" {
+		t.Errorf("unexpected destinations: %v", dests)
+	}
+	if err := syncconfig.ValidateSyncPair(&pair); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	duplicate := pair
+	duplicate.Destinations = []syncconfig.Destination{{RemoteName: "b2", RemotePath: "bucket/docs"}}
+	if err := syncconfig.ValidateSyncPair(&duplicate); err == nil {
+		t.Error("expected error for duplicate destination")
+	}
+
+	download := pair
+	download.Direction = "download"
+	if err := syncconfig.ValidateSyncPair(&download); err == nil {
+		t.Error("expected error for download pair with extra destinations")
+	}
+}
+
+func TestValidateSnapshotPair(t *testing.T) {
+	pair := syncconfig.SyncPair{
+		Name:         "docs",
+		LocalPath:    "/tmp",
+		RemoteName:   "b2",
+		RemotePath:   "bucket/docs",
+		Direction:    "upload",
+		Snapshot:     true,
+		SnapshotKeep: 7,
+	}
+	if err := syncconfig.ValidateSyncPair(&pair); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	withTrash := pair
+	withTrash.SoftDelete = true
+	if err := syncconfig.ValidateSyncPair(&withTrash); err == nil {
+		t.Error("expected error combining snapshot mode and soft delete")
+	}
+
+	bidirectional := pair
+	bidirectional.Direction = "bidirectional"
+	if err := syncconfig.ValidateSyncPair(&bidirectional); err == nil {
+		t.Error("expected error for bidirectional snapshot pair")
+	}
+}
+
+func TestValidateArchivePair(t *testing.T) {
+	pair := syncconfig.SyncPair{
+		Name:        "docs",
+		LocalPath:   "/tmp/docs",
+		RemoteName:  "b2",
+		RemotePath:  "bucket/docs",
+		Direction:   "upload",
+		Archive:     true,
+		ArchiveKeep: 4,
+		StagingPath: "/tmp/staging",
+	}
+	if err := syncconfig.ValidateSyncPair(&pair); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *syncconfig.SyncPair)
+	}{
+		{"download", func(p *syncconfig.SyncPair) { p.Direction = "download" }},
+		{"snapshot", func(p *syncconfig.SyncPair) { p.Snapshot = true }},
+		{"soft delete", func(p *syncconfig.SyncPair) { p.SoftDelete = true }},
+		{"move", func(p *syncconfig.SyncPair) { p.Mode = syncconfig.ModeMove }},
+		{"negative keep", func(p *syncconfig.SyncPair) { p.ArchiveKeep = -1 }},
+		{"staging inside folder", func(p *syncconfig.SyncPair) { p.StagingPath = "/tmp/docs/.staging" }},
+		{"staging without archive", func(p *syncconfig.SyncPair) { p.Archive = false }},
+		{"local pair", func(p *syncconfig.SyncPair) {
+			p.Type = syncconfig.TypeLocal
+			p.TargetPath = "/tmp/mirror"
+			p.RemoteName, p.RemotePath = "", ""
+		}},
+	}
+	for _, tt := range tests {
+		invalid := pair
+		tt.modify(&invalid)
+		if err := syncconfig.ValidateSyncPair(&invalid); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestValidateSyncPairMode(t *testing.T) {
+	pair := syncconfig.SyncPair{
+		Name:       "docs",
+		LocalPath:  "/tmp",
+		RemoteName: "b2",
+		RemotePath: "bucket/docs",
+		Direction:  "upload",
+	}
+	if pair.TransferMode() != syncconfig.ModeSync {
+		t.Errorf("expected empty mode to default to sync, got %s", pair.TransferMode())
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(p *syncconfig.SyncPair)
+		wantErr bool
+	}{
+		{"copy", func(p *syncconfig.SyncPair) { p.Mode = syncconfig.ModeCopy }, false},
+		{"move upload", func(p *syncconfig.SyncPair) { p.Mode = syncconfig.ModeMove }, false},
+		{"unknown mode", func(p *syncconfig.SyncPair) { p.Mode = "mirror" }, true},
+		{"move bidirectional", func(p *syncconfig.SyncPair) {
+			p.Mode = syncconfig.ModeMove
+			p.Direction = "bidirectional"
+		}, true},
+		{"move with destinations", func(p *syncconfig.SyncPair) {
+			p.Mode = syncconfig.ModeMove
+			p.Destinations = []syncconfig.Destination{{RemoteName: "nas", RemotePath: "docs"}}
+		}, true},
+		{"copy with soft delete", func(p *syncconfig.SyncPair) {
+			p.Mode = syncconfig.ModeCopy
+			p.SoftDelete = true
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := pair
+			tt.modify(&p)
+			err := syncconfig.ValidateSyncPair(&p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSyncPair() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseExtraFlags(t *testing.T) {
+	flags, err := syncconfig.ParseExtraFlags(`--track-renames  --exclude "My Files/**" --transfers=8`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"--track-renames", "--exclude", "My Files/**", "--transfers=8"}
+	if strings.Join(flags, "|") != strings.Join(want, "|") {
+		t.Errorf("ParseExtraFlags() = %q, want %q", flags, want)
+	}
+
+	if _, err := syncconfig.ParseExtraFlags(`--exclude "open`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestValidateExtraFlags(t *testing.T) {
+	tests := []struct {
+		flags   []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"--b2-hard-delete"}, false},
+		{[]string{"--transfers", "8"}, false},
+		{[]string{"8", "--transfers"}, true},
+		{[]string{"--config", "/tmp/rclone.conf"}, true},
+		{[]string{"--log-file=/tmp/x.log"}, true},
+	}
+
+	for _, tt := range tests {
+		err := syncconfig.ValidateExtraFlags(tt.flags)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateExtraFlags(%q) error = %v, wantErr %v", tt.flags, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/simulate"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+// addTaggedPair adds an upload pair with tags to the default sync config
+func addTaggedPair(t *testing.T, name string, enabled bool, group string, tags ...string) {
+	t.Helper()
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSyncPair(syncconfig.SyncPair{
+		Name:       name,
+		LocalPath:  t.TempDir(),
+		RemoteName: "b2",
+		RemotePath: "bucket/" + name,
+		Direction:  "upload",
+		Enabled:    enabled,
+		Group:      group,
+		Tags:       tags,
+	}))
+}
+
+func TestSyncPairTags(t *testing.T) {
+	pair := syncconfig.SyncPair{Tags: []string{"photos", "family"}}
+	assert.True(t, pair.HasTag("Family"))
+	assert.False(t, pair.HasTag("work"))
+	assert.Equal(t, "photos", pair.ListGroup())
+
+	pair.Group = "Media"
+	assert.True(t, pair.HasTag("media"))
+	assert.Equal(t, "Media", pair.ListGroup())
+	assert.Equal(t, "", syncconfig.SyncPair{}.ListGroup())
+
+	valid := syncconfig.SyncPair{
+		Name: "Photos", LocalPath: t.TempDir(), RemoteName: "b2", RemotePath: "bucket/photos", Direction: "upload",
+		Group: " Media ", Tags: []string{" photos ", "family"},
+	}
+	require.NoError(t, syncconfig.ValidateSyncPair(&valid))
+	assert.Equal(t, "Media", valid.Group)
+	assert.Equal(t, []string{"photos", "family"}, valid.Tags)
+
+	for tags, want := range map[string]string{
+		"photos,Photos": "listed more than once",
+		"photos, ":      "cannot be empty",
+	} {
+		pair := valid
+		pair.Tags = strings.Split(tags, ",")
+		assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), want, tags)
+	}
+	pair = valid
+	pair.Tags = []string{"a,b"}
+	assert.ErrorContains(t, syncconfig.ValidateSyncPair(&pair), "cannot contain a comma")
+}
+
+func TestCLISyncByTag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	addTaggedPair(t, "Camera", true, "", "photos")
+	addTaggedPair(t, "Scans", false, "", "photos", "documents")
+	addTaggedPair(t, "Phone", true, "Photos")
+	addPlainPair(t, "Documents", true)
+	var stdout, stderr bytes.Buffer
+
+	require.Equal(t, 0, cli.Run([]string{"sync", "--progress", "--tag", "photos"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "Syncing Camera...")
+	assert.Contains(t, stdout.String(), "Syncing Phone...")
+	assert.NotContains(t, stdout.String(), "Scans")
+	assert.NotContains(t, stdout.String(), "Documents")
+	assert.Contains(t, stdout.String(), "Synced 2 of 2 pair(s).")
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"sync", "--tag", "documents"}, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "No enabled sync pairs are tagged 'documents'.\n", stdout.String())
+
+	assert.Equal(t, 1, cli.Run([]string{"sync", "--tag", "work"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "no sync pairs are tagged 'work'")
+	assert.Equal(t, 2, cli.Run([]string{"sync", "--tag", "photos", "--all"}, &stdout, &stderr))
+
+	stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"pairs", "--tag", "documents"}, &stdout, &stderr), stderr.String())
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[0], "TAGS"))
+	assert.True(t, strings.HasSuffix(lines[1], "photos,documents"))
+}
+
+func TestSyncPairsListGroups(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	addPlainPair(t, "Documents", true)
+	addTaggedPair(t, "Camera", true, "", "photos")
+	addTaggedPair(t, "Invoices", true, "Work")
+	addTaggedPair(t, "Phone", true, "", "photos")
+	// Neither is listed under "photos", so neither syncs with it
+	addTaggedPair(t, "Receipts", true, "Work", "photos")
+	addTaggedPair(t, "Album", true, "Photos")
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+
+	var model tea.Model = views.NewSyncPairsModel(mgr, rclone.NewManager("rclone"))
+	for _, msg := range runBatch(model.Init()) {
+		model, _ = model.Update(msg)
+	}
+	view := model.View()
+	documents, photos := strings.Index(view, "1. [✓] Documents"), strings.Index(view, "▾ photos (2 pairs)")
+	camera, phone := strings.Index(view, "2. [✓] Camera"), strings.Index(view, "4. [✓] Phone")
+	work := strings.Index(view, "▾ Work (2 pairs)")
+	require.True(t, documents >= 0 && photos >= 0 && camera >= 0 && phone >= 0 && work >= 0, view)
+	assert.True(t, documents < photos && photos < camera && camera < phone && phone < work, view)
+	assert.Contains(t, view, "Tags: photos")
+
+	// Folding the group under the cursor hides its pairs
+	model, _ = model.Update(keyPress("down"))
+	model, _ = model.Update(keyPress("down"))
+	model, _ = model.Update(keyPress("c"))
+	view = model.View()
+	assert.Contains(t, view, "▸ photos (2 pairs)")
+	assert.NotContains(t, view, "Camera")
+	assert.Contains(t, view, "Invoices")
+
+	// Syncing a heading syncs every pair of the group
+	simulate.Enable()
+	t.Cleanup(simulate.Reset)
+	model, cmd := model.Update(keyPress("s"))
+	assert.Contains(t, model.View(), "Syncing group 'photos'...")
+	// Follow the sync's progress, leaving the spinner out, until it ends
+	for cmd != nil {
+		var next tea.Cmd
+		for _, msg := range runBatch(cmd) {
+			if _, tick := msg.(spinner.TickMsg); !tick {
+				model, next = model.Update(msg)
+			}
+		}
+		cmd = next
+	}
+	var synced []string
+	for _, action := range simulate.Actions() {
+		if len(action.Args) > 0 && action.Args[0] == "sync" && !slices.Contains(action.Args, "--dry-run") {
+			synced = append(synced, action.Args[2])
+		}
+	}
+	assert.Equal(t, []string{"b2:bucket/Camera", "b2:bucket/Phone"}, synced)
+	view = model.View()
+	assert.NotContains(t, view, "Syncing group")
+	assert.Contains(t, view, "group 'photos': Synced 2 of 2 pair(s).")
+
+	// Unfolding it shows the pairs again
+	model, _ = model.Update(keyPress("c"))
+	assert.Contains(t, model.View(), "Camera")
+}
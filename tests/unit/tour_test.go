@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+// newTour starts the guided tour with an rclone that runs the given script
+// body, once it has checked rclone
+func newTour(t *testing.T, home, rcloneBody string) tea.Model {
+	t.Helper()
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	var model tea.Model = views.NewTourModel(configManager, syncConfigMgr, fakeRclone(t, rcloneBody))
+	model, _ = model.Update(model.Init()().(tea.BatchMsg)[1]())
+	return model
+}
+
+// tourNext presses enter and runs the action of the step, if any
+func tourNext(model tea.Model) tea.Model {
+	model, cmd := model.Update(keyPress("enter"))
+	if cmd == nil {
+		return model
+	}
+	if batch, ok := cmd().(tea.BatchMsg); ok {
+		model, _ = model.Update(batch[1]())
+	}
+	return model
+}
+
+func TestTourSetsUpAPracticePair(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, views.TourDirName)
+
+	model := newTour(t, home, `echo "rclone v1.68.0"`)
+	view := testutil.PlainText(model.View())
+	assert.Contains(t, view, "without a cloud account")
+	assert.Contains(t, view, "enter: Start")
+
+	model = tourNext(tourNext(model))
+	assert.FileExists(t, filepath.Join(dir, "Documents", "README.txt"))
+	assert.FileExists(t, filepath.Join(dir, "Documents", "notes", "todo.txt"))
+	assert.DirExists(t, filepath.Join(dir, "Cloud"))
+	assert.Contains(t, testutil.PlainText(model.View()), "Step 2 of 4: A remote")
+
+	model = tourNext(model)
+	conf, err := os.ReadFile(filepath.Join(home, ".config", "rclone", "rclone.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "[tour]\ntype = local\n\n", string(conf))
+
+	model = tourNext(model)
+	syncConfigMgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+	pair, err := syncConfigMgr.GetSyncPair(views.TourPairName)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "Documents"), pair.LocalPath)
+	assert.Equal(t, views.TourRemoteName, pair.RemoteName)
+	assert.Equal(t, filepath.Join(dir, "Cloud"), pair.RemotePath)
+	assert.Equal(t, "upload", pair.Direction)
+	view = testutil.PlainText(model.View())
+	assert.Contains(t, view, "Step 4 of 4: The first sync")
+	assert.Contains(t, view, "enter: Sync now")
+
+	// Taking the tour again keeps what it made, and the user's changes
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Documents", "README.txt"), []byte("mine"), 0644))
+	model = newTour(t, home, `echo "rclone v1.68.0"`)
+	model = tourNext(tourNext(tourNext(tourNext(model))))
+	assert.Contains(t, testutil.PlainText(model.View()), "Sync pair 'Tour' is already set up")
+	data, err := os.ReadFile(filepath.Join(dir, "Documents", "README.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "mine", string(data))
+
+	// d removes the folder, remote and pair
+	model, cmd := model.Update(keyPress("d"))
+	_, cmd = model.Update(cmd().(tea.BatchMsg)[1]())
+	require.NotNil(t, cmd)
+	assert.Equal(t, views.DoneMsg{Message: "Removed the tour's folder, remote and sync pair"}, cmd())
+	assert.NoDirExists(t, dir)
+	conf, err = os.ReadFile(filepath.Join(home, ".config", "rclone", "rclone.conf"))
+	require.NoError(t, err)
+	assert.Empty(t, string(conf))
+	_, err = syncConfigMgr.GetSyncPair(views.TourPairName)
+	assert.Error(t, err)
+}
+
+func TestTourRefusesToReplaceARemote(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configManager := config.NewManagerWithPath(filepath.Join(home, ".config", "cloud-sync", "config.json"))
+	require.NoError(t, configManager.AddRemote(config.RemoteConfig{Name: "tour", Type: "b2", AccountID: "id", ApplicationKey: "key"}))
+
+	model := tourNext(tourNext(tourNext(newTour(t, home, `echo "rclone v1.68.0"`))))
+	view := testutil.PlainText(model.View())
+	assert.Contains(t, view, "a b2 remote named 'tour' already exists")
+	assert.Contains(t, view, "Step 2 of 4")
+
+	// Removing what the tour made leaves the user's remote alone
+	model, cmd := model.Update(keyPress("d"))
+	model.Update(cmd().(tea.BatchMsg)[1]())
+	remote, err := configManager.GetRemote("tour")
+	require.NoError(t, err)
+	assert.Equal(t, "b2", remote.Type)
+}
+
+func TestTourNeedsRcloneAndWrites(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	model := newTour(t, home, "exit 127")
+	assert.Contains(t, testutil.PlainText(model.View()), "rclone cannot run")
+
+	views.SetReadOnly(true)
+	t.Cleanup(func() { views.SetReadOnly(false) })
+	model = tourNext(newTour(t, home, `echo "rclone v1.68.0"`))
+	assert.Contains(t, testutil.PlainText(model.View()), "The guided tour is disabled in read-only mode")
+	assert.NoDirExists(t, filepath.Join(home, views.TourDirName))
+}
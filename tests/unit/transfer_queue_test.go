@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/lockfile"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestTransferQueueUpdate(t *testing.T) {
+	var queue views.TransferQueue
+	queue = queue.Update([]rclone.RCTransfer{
+		{Name: "a.txt", Size: 100, Bytes: 50, Percentage: 50, Speed: 10},
+		{Name: "b.txt", Size: 200, Bytes: 20, Percentage: 10, Speed: 5},
+	}, 0)
+	require.Len(t, queue.Active, 2)
+	assert.Empty(t, queue.Completed)
+
+	queue = queue.Update([]rclone.RCTransfer{
+		{Name: "b.txt", Size: 200, Bytes: 120, Percentage: 60, Speed: 5},
+		{Name: "c.txt", Size: 300, Bytes: 0, Percentage: 0},
+	}, 0)
+	require.Len(t, queue.Active, 2)
+	assert.Equal(t, 60, queue.Active[0].Percent)
+	require.Len(t, queue.Completed, 1)
+	assert.Equal(t, views.QueuedTransfer{Name: "a.txt", Size: 100, Bytes: 100, Speed: 10, Percent: 100, Done: true}, queue.Completed[0])
+
+	queue = queue.Update(nil, 0)
+	assert.Empty(t, queue.Active)
+	require.Len(t, queue.Completed, 3)
+	assert.Equal(t, "a.txt", queue.Completed[2].Name, "newest completed first")
+
+	rows := queue.Rows()
+	require.Len(t, rows, 3)
+	assert.Equal(t, "✓ Done", rows[0][0])
+	assert.Equal(t, "100%", rows[0][4])
+}
+
+func TestTransferQueueMarksFailedTransfers(t *testing.T) {
+	var queue views.TransferQueue
+	queue = queue.Update([]rclone.RCTransfer{
+		{Name: "a.txt", Size: 100, Bytes: 50, Percentage: 50},
+		{Name: "b.txt", Size: 100, Bytes: 10, Percentage: 10},
+	}, 0)
+
+	// a.txt leaves the list as the error count rises
+	queue = queue.Update([]rclone.RCTransfer{{Name: "b.txt", Size: 100, Bytes: 60, Percentage: 60}}, 1)
+	assert.Equal(t, 1, queue.Errors)
+	require.Len(t, queue.Completed, 1)
+	assert.True(t, queue.Completed[0].Failed)
+	assert.Equal(t, 50, queue.Completed[0].Percent, "a failed file keeps how far it got")
+
+	// b.txt finishes without another error
+	queue = queue.Update(nil, 1)
+	require.Len(t, queue.Completed, 2)
+	assert.False(t, queue.Completed[0].Failed)
+
+	rows := queue.Rows()
+	assert.Equal(t, "✓ Done", rows[0][0])
+	assert.Equal(t, "✗ Failed", rows[1][0])
+	assert.Equal(t, "50%", rows[1][4])
+}
+
+func TestTransferQueueKeepsRecentCompleted(t *testing.T) {
+	var queue views.TransferQueue
+	for i := 0; i < 60; i++ {
+		queue = queue.Update([]rclone.RCTransfer{{Name: fmt.Sprintf("file%d.txt", i)}}, 0)
+	}
+	queue = queue.Update(nil, 0)
+	assert.Len(t, queue.Completed, 50)
+	assert.Equal(t, "file59.txt", queue.Completed[0].Name)
+}
+
+func TestTransferQueueRowsShortenLongPaths(t *testing.T) {
+	long := strings.Repeat("deep/", 20) + "photo.jpg"
+	queue := views.TransferQueue{}.Update([]rclone.RCTransfer{{Name: long, Size: 2048, Speed: 1024, Percentage: 25}}, 0)
+
+	row := queue.Rows()[0]
+	assert.Equal(t, "↻ Moving", row[0])
+	assert.True(t, strings.HasPrefix(row[1], "…"))
+	assert.True(t, strings.HasSuffix(row[1], "/photo.jpg"))
+	assert.Equal(t, "2.0 KB", row[2])
+	assert.Equal(t, "1.0 KB/s", row[3])
+	assert.Equal(t, "25%", row[4])
+}
+
+func TestBackupOpsShowsTransferQueue(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch polls.Add(1) {
+		case 1:
+			w.Write([]byte(`{"transfers":0,"totalTransfers":2,"transferring":[{"name":"docs/report.pdf","size":4096,"bytes":1024,"percentage":25,"speed":2048},{"name":"docs/notes.txt","size":10,"bytes":5,"percentage":50}]}`))
+		case 2:
+			w.Write([]byte(`{"transfers":1,"totalTransfers":2,"transferring":[{"name":"docs/notes.txt","size":10,"bytes":8,"percentage":80}]}`))
+		default:
+			w.Write([]byte(`{"transfers":1,"totalTransfers":2,"errors":1,"transferring":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	lock := lockfile.NewManagerWithPath(filepath.Join(t.TempDir(), "rclone_backup.lock"))
+	require.NoError(t, lock.Create())
+
+	model := views.NewBackupOpsModel(views.BackupAutomated, 100, 40).
+		AttachRunningBackup(lock, rclone.NewRCClient(strings.TrimPrefix(server.URL, "http://")))
+
+	var current tea.Model = model
+	var next tea.Cmd
+	for _, msg := range runBatch(model.Init()) {
+		var cmd tea.Cmd
+		current, cmd = current.Update(msg)
+		if cmd != nil {
+			next = cmd
+		}
+	}
+	view := current.View()
+	assert.Contains(t, view, "Transfers: 2 in flight, 0 recently completed")
+	assert.Contains(t, view, "docs/report.pdf")
+	assert.Contains(t, view, "2.0 KB/s")
+	assert.Contains(t, view, "25%")
+
+	// The next poll finds report.pdf finished
+	for _, msg := range runBatch(next) {
+		var cmd tea.Cmd
+		current, cmd = current.Update(msg)
+		if cmd != nil {
+			next = cmd
+		}
+	}
+	view = current.View()
+	assert.Contains(t, view, "Transfers: 1 in flight, 1 recently completed")
+	assert.NotContains(t, view, "failed")
+	assert.Contains(t, view, "✓ Done")
+	assert.Contains(t, view, "80%")
+	assert.Contains(t, view, "scroll transfers")
+
+	// notes.txt then fails
+	for _, msg := range runBatch(next) {
+		current, _ = current.Update(msg)
+	}
+	view = current.View()
+	assert.Contains(t, view, "Transfers: 0 in flight, 2 recently completed, 1 failed")
+	assert.Contains(t, view, "✗ Failed")
+}
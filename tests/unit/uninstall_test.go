@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreisuslov/cloud-sync/internal/cli"
+	"github.com/andreisuslov/cloud-sync/internal/uninstall"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeUninstallFixture creates scripts, logs and config under a temp home
+func writeUninstallFixture(t *testing.T, home string) {
+	t.Helper()
+	files := []string{
+		filepath.Join(home, "bin", "monthly_backup.sh"),
+		filepath.Join(home, "bin", "sync_now.sh"),
+		filepath.Join(home, "bin", "unrelated.sh"),
+		filepath.Join(home, "logs", "rclone_backup.log"),
+		filepath.Join(home, "logs", "other.log"),
+		filepath.Join(home, ".config", "cloud-sync", "sync-config.json"),
+	}
+	for _, f := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), 0755))
+		require.NoError(t, os.WriteFile(f, []byte("x"), 0644))
+	}
+}
+
+func TestUninstallPlanAndRun(t *testing.T) {
+	home := t.TempDir()
+	writeUninstallFixture(t, home)
+
+	opts := uninstall.Options{
+		BinDir:    filepath.Join(home, "bin"),
+		LogDir:    filepath.Join(home, "logs"),
+		ConfigDir: filepath.Join(home, ".config", "cloud-sync"),
+	}
+
+	// Logs and config are kept unless asked for
+	assert.Len(t, uninstall.Plan(opts), 2)
+
+	opts.RemoveLogs = true
+	opts.RemoveConfig = true
+	opts.DryRun = true
+	result, err := uninstall.Run(opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 4)
+	assert.Contains(t, result.Summary(), "Would remove")
+	assert.FileExists(t, filepath.Join(home, "bin", "sync_now.sh"))
+
+	opts.DryRun = false
+	result, err = uninstall.Run(opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 4)
+	assert.NoFileExists(t, filepath.Join(home, "bin", "sync_now.sh"))
+	assert.NoFileExists(t, filepath.Join(home, "logs", "rclone_backup.log"))
+	assert.NoDirExists(t, filepath.Join(home, ".config", "cloud-sync"))
+
+	// Files cloud-sync did not create are left alone
+	assert.FileExists(t, filepath.Join(home, "bin", "unrelated.sh"))
+	assert.FileExists(t, filepath.Join(home, "logs", "other.log"))
+
+	assert.Empty(t, uninstall.Plan(opts))
+}
+
+func TestCLIUninstallRequiresYes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeUninstallFixture(t, home)
+
+	var stdout, stderr bytes.Buffer
+	code := cli.Run([]string{"uninstall"}, &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "Would remove script")
+	assert.Contains(t, stdout.String(), "--yes")
+	assert.FileExists(t, filepath.Join(home, "bin", "monthly_backup.sh"))
+
+	stdout.Reset()
+	code = cli.Run([]string{"uninstall", "--yes"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Removed script")
+	assert.NoFileExists(t, filepath.Join(home, "bin", "monthly_backup.sh"))
+	assert.FileExists(t, filepath.Join(home, "logs", "rclone_backup.log"))
+}
+
+func TestCLIUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, cli.Run([]string{"bogus"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "unknown command")
+	assert.False(t, cli.IsCommand([]string{"bogus"}))
+	assert.True(t, cli.IsCommand([]string{"uninstall"}))
+}
@@ -0,0 +1,67 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+	"github.com/andreisuslov/cloud-sync/tests/testutil"
+)
+
+func TestRemoteWizardUnion(t *testing.T) {
+	mgr, rcloneConf := remoteFixture(t)
+	appConfig, err := mgr.Load()
+	require.NoError(t, err)
+	appConfig.RclonePath = filepath.Join(filepath.Dir(fakeRclone(t, "exit 0").GetConfigPath()), "rclone")
+	require.NoError(t, mgr.Save(appConfig))
+
+	form, _ := views.NewRemoteConfigModel(mgr).Update(keyPress("9"))
+	require.Contains(t, form.View(), "Union of Remotes Configuration")
+
+	// One upstream is not a union
+	form = fillField(form, "free")
+	form = fillField(form, "b2:backups")
+	form = typeText(form, "")
+	assert.Contains(t, form.View(), "list at least two upstreams")
+	_, err = mgr.GetRemote("free")
+	assert.Error(t, err)
+
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyUp})
+	form = typeText(form, ", sw:more files")
+	assert.Contains(t, testutil.PlainText(form.View()), "Connected to b2:backups + sw:more files.")
+	remote, err := mgr.GetRemote("free")
+	require.NoError(t, err)
+	assert.Equal(t, config.RemoteConfig{Name: "free", Type: config.TypeUnion, Upstreams: []string{"b2:backups", "sw:more files"}}, *remote)
+	data, err := os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[free]\ntype = union\nupstreams = b2:backups \"sw:more files\"\n\n")
+
+	// Renaming an upstream's remote updates the union
+	require.NoError(t, mgr.RenameRemote("sw", "scaleway"))
+	remote, err = mgr.GetRemote("free")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b2:backups", "scaleway:more files"}, remote.Upstreams)
+	data, err = os.ReadFile(rcloneConf)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "upstreams = b2:backups \"scaleway:more files\"\n")
+
+	// Editing shows the upstreams as they were entered
+	form = views.NewEditRemoteModel(mgr, *remote)
+	assert.Contains(t, form.View(), "Upstreams: b2:backups, scaleway:more files")
+}
+
+func TestConfigValidatesUnions(t *testing.T) {
+	mgr := config.NewManagerWithPath(filepath.Join(t.TempDir(), "config.json"))
+
+	assert.ErrorContains(t, mgr.AddRemote(config.RemoteConfig{Name: "free", Type: config.TypeUnion, Upstreams: []string{"b2:a"}}), "at least two upstreams")
+	assert.ErrorContains(t, mgr.AddRemote(config.RemoteConfig{Name: "free", Type: config.TypeUnion, Upstreams: []string{"b2:a", "backups"}}), "upstream 'backups' is not a full path")
+	assert.ErrorContains(t, mgr.AddRemote(config.RemoteConfig{Name: "free", Type: config.TypeUnion, Upstreams: []string{"b2:a", "free:b"}}), "cannot contain itself")
+	assert.ErrorContains(t, mgr.AddRemote(config.RemoteConfig{Name: "free", Type: config.TypeUnion, Upstreams: []string{"b2:a", "sw:b"}, Policy: "most"}), "unknown union policy 'most'")
+	assert.NoError(t, mgr.AddRemote(config.RemoteConfig{Name: "free", Type: config.TypeUnion, Upstreams: []string{"b2:a:ro", "/Volumes/NAS:nc", "sw:b"}, Policy: "ff"}))
+}
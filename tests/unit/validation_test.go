@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreisuslov/cloud-sync/internal/config"
+	"github.com/andreisuslov/cloud-sync/internal/rclone"
+	"github.com/andreisuslov/cloud-sync/internal/syncconfig"
+	"github.com/andreisuslov/cloud-sync/internal/ui/views"
+)
+
+func TestFormValidators(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(file, nil, 0600))
+
+	tests := []struct {
+		name      string
+		validator views.Validator
+		value     string
+		wantValid bool
+	}{
+		{"required empty", views.Required, "", false},
+		{"required set", views.Required, "x", true},
+		{"int in range", views.IntRange(1, 10), "5", true},
+		{"int too big", views.IntRange(1, 10), "11", false},
+		{"int not a number", views.IntRange(1, 10), "five", false},
+		{"dir exists", views.DirExists, dir, true},
+		{"dir missing", views.DirExists, dir + "/missing", false},
+		{"file exists", views.FileExists, file, true},
+		{"file missing", views.FileExists, dir + "/missing", false},
+		{"file is a dir", views.FileExists, dir, false},
+		{"bucket valid", views.BucketPath("b2"), "my-bucket.2024", true},
+		{"bucket too short", views.BucketPath("b2"), "ab", false},
+		{"bucket underscore", views.BucketPath("s3"), "my_bucket", false},
+		{"bucket ends with hyphen", views.BucketPath("b2"), "bucket-", false},
+		{"bucket with folders", views.BucketPath("b2"), "my-bucket/Photos 2024", true},
+		{"folder on a drive", views.BucketPath("drive"), "My Backups/Photos", true},
+		{"bucket of unknown remote", views.BucketPath(""), "My Backups", true},
+		{"bucket empty", views.BucketPath("drive"), "", false},
+		{"endpoint host", views.EndpointURL, "s3.nl-ams.scw.cloud", true},
+		{"endpoint url", views.EndpointURL, "http://minio.local:9000", true},
+		{"endpoint scheme", views.EndpointURL, "ftp://example.com", false},
+		{"endpoint spaces", views.EndpointURL, "s3 example com", false},
+		{"one of", views.OneOf("y", "n"), "Y", true},
+		{"not one of", views.OneOf("y", "n"), "maybe", false},
+		{"optional empty", views.Optional(views.IntRange(1, 2)), "", true},
+		{"all stops at first", views.All(views.Required, views.DirExists), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validator(tt.value)
+			if tt.wantValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestFormValidatorInlineErrors(t *testing.T) {
+	inputs := []textinput.Model{textinput.New(), textinput.New()}
+	inputs[1].SetValue("Bad Bucket")
+
+	form := views.NewFormValidator(views.RemoteName, views.BucketPath("b2"))
+
+	// Leaving an untouched empty field does not flag it
+	form.Recheck(0, "")
+	assert.Empty(t, form.Err(0))
+
+	assert.False(t, form.Validate(inputs))
+	assert.Contains(t, form.Err(0), "cannot be empty")
+	assert.Contains(t, form.Err(1), "uppercase")
+	assert.Contains(t, form.RenderField(1, inputs[1]), "uppercase")
+
+	form.Recheck(0, "b2")
+	assert.Empty(t, form.Err(0))
+	assert.NotEmpty(t, form.Err(1))
+
+	inputs[0].SetValue("b2")
+	inputs[1].SetValue("my-bucket")
+	assert.True(t, form.Validate(inputs))
+	assert.Empty(t, form.Err(1))
+}
+
+func TestSyncConfigFormShowsFieldErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+
+	var model tea.Model = views.NewSyncConfigModel(configManager)
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	view := model.View()
+	assert.Contains(t, view, "remote name cannot be empty")
+	assert.Contains(t, view, "bucket name cannot be empty")
+	assert.NotContains(t, view, "Sync configuration saved")
+}
+
+func TestSyncPairsWizardRejectsMissingFolder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr, err := syncconfig.NewDefaultManager()
+	require.NoError(t, err)
+
+	var model tea.Model = views.NewSyncPairsModel(mgr, rclone.NewManager("rclone"))
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	for _, r := range "docs" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, model.View(), "Enter the local folder path")
+
+	for _, r := range "/does/not/exist" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	view := model.View()
+	assert.Contains(t, view, "Enter the local folder path")
+	assert.Contains(t, view, "path does not exist")
+}
+
+func TestSyncConfigFormChecksBucketsByRemoteType(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configManager, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, configManager.AddRemote(config.RemoteConfig{Name: "b2", Type: "b2"}))
+	require.NoError(t, configManager.AddRemote(config.RemoteConfig{Name: "gdrive", Type: "drive"}))
+
+	var model tea.Model = views.NewSyncConfigModel(configManager)
+	for _, value := range []string{"gdrive", "My Backups/Photos", "b2", "Backups/Mac"} {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(value)})
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// Folders on a drive take any name; only the B2 bucket is checked
+	view := model.View()
+	assert.Equal(t, 1, strings.Count(view, "bucket name cannot contain uppercase letters"), view)
+	assert.NotContains(t, view, "Sync configuration saved")
+}